@@ -143,7 +143,7 @@ type KBConfig struct {
 }
 
 // extractConfigValues extracts values from ConfigDefaults (map[string]ParameterValue) to map[string]interface{}
-func extractConfigValues(config defaultsTypes.ConfigDefaults) map[string]interface{} {
+func extractConfigValues(config defaultsTypes.ParameterMap) map[string]interface{} {
 	result := make(map[string]interface{})
 	for k, paramValue := range config {
 		result[k] = paramValue.Value
@@ -152,7 +152,7 @@ func extractConfigValues(config defaultsTypes.ConfigDefaults) map[string]interfa
 }
 
 // extractVariableValues extracts values from SystemVariables (map[string]ParameterValue) to map[string]interface{}
-func extractVariableValues(variables defaultsTypes.SystemVariables) map[string]interface{} {
+func extractVariableValues(variables defaultsTypes.ParameterMap) map[string]interface{} {
 	result := make(map[string]interface{})
 	for k, paramValue := range variables {
 		result[k] = paramValue.Value
@@ -175,9 +175,9 @@ func loadBaseline(filename string) (*BaselineResult, error) {
 }
 
 func loadKnowledgeBase(kbDir, version string) (map[string]KBConfig, error) {
-	// Use pkg/collector/kbgenerator.LoadKnowledgeBase to load the knowledge base
+	// Use pkg/collector.LoadKnowledgeBase to load the knowledge base
 	// kbDir is the base knowledge directory (e.g., "knowledge")
-	kb, err := kbgenerator.LoadKnowledgeBase(kbDir, version)
+	kb, err := collector.LoadKnowledgeBase(kbDir, version)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load knowledge base: %w", err)
 	}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules/high_risk_params"
+	"github.com/spf13/cobra"
+)
+
+var rootCmd = &cobra.Command{
+	Use:   "high-risk-params",
+	Short: "Validate, merge and generate high-risk parameter configs",
+	Long: `Tools for the high-risk parameters config read by the HIGH_RISK_PARAMS rule.
+
+The config format is the one of knowledge/high_risk_params/high_risk_params.json
+(see pkg/analyzer/rules/high_risk_params/default.json for the shipped default).`,
+	SilenceUsage: true,
+}
+
+func main() {
+	rootCmd.AddCommand(newValidateCommand(), newMergeCommand(), newFromReportCommand())
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// newValidateCommand creates the "validate" subcommand that checks a config and prints its problems
+func newValidateCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate <config.json>",
+		Short: "Check a high-risk parameters config",
+		Long: `Check the schema of a high-risk parameters config and print the problems of each entry.
+
+Unknown fields are rejected. Each entry must have a known severity and a description,
+well-formed from_version/to_version in order, non-negative tolerance below critical_distance
+and no value both in allowed_values and unsafe_values. Exits with a non-zero status if a problem is found.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			config, err := high_risk_params.LoadConfigFile(args[0])
+			if err != nil {
+				return err
+			}
+			problems := high_risk_params.Validate(config)
+			for _, problem := range problems {
+				fmt.Fprintln(cmd.OutOrStdout(), problem.String())
+			}
+			if len(problems) > 0 {
+				return fmt.Errorf("%s: %d problem(s) found", args[0], len(problems))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s: %d entries, no problems found\n", args[0], high_risk_params.CountEntries(config))
+			return nil
+		},
+	}
+}
+
+// newMergeCommand creates the "merge" subcommand that merges org-specific overrides onto a base config
+func newMergeCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "merge <base.json> <override.json>",
+		Short: "Merge org-specific overrides onto a base config",
+		Long: `Merge the entries of override.json onto base.json (typically the shipped default).
+
+Entries defined in both files are taken from override.json as a whole; the ones that differ
+are reported as conflicts on stderr. The merged config is validated before it is written.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			base, err := high_risk_params.LoadConfigFile(args[0])
+			if err != nil {
+				return err
+			}
+			override, err := high_risk_params.LoadConfigFile(args[1])
+			if err != nil {
+				return err
+			}
+			merged, conflicts := high_risk_params.Merge(base, override)
+			for _, conflict := range conflicts {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Conflict: %s\n", conflict.String())
+			}
+			for _, problem := range high_risk_params.Validate(merged) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Warning: %s\n", problem.String())
+			}
+			return writeConfig(cmd, merged, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file for the merged config (default: stdout)")
+	return cmd
+}
+
+// newFromReportCommand creates the "from-report" subcommand that proposes entries from a precheck report
+func newFromReportCommand() *cobra.Command {
+	var output string
+
+	cmd := &cobra.Command{
+		Use:   "from-report <report.json>",
+		Short: "Propose high-risk entries from the findings of a precheck JSON report",
+		Long: `Propose a high-risk entry for every critical or error finding of a precheck JSON report
+(generated with --format=json), pre-filled with the parameter name, component, severity and
+the finding message as description.
+
+The candidates are a starting point: review the descriptions, add allowed_values/unsafe_values
+and remove the entries that are not risks for your organization, then merge them onto the
+shipped default with the merge subcommand.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			data, err := os.ReadFile(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", args[0], err)
+			}
+			var report analyzer.AnalysisResult
+			if err := json.Unmarshal(data, &report); err != nil {
+				return fmt.Errorf("%s is not a precheck JSON report: %w", args[0], err)
+			}
+			candidates := high_risk_params.CandidatesFromFindings(report.CheckResults, report.TargetVersion)
+			fmt.Fprintf(cmd.ErrOrStderr(), "Proposed %d entries from %d findings\n", high_risk_params.CountEntries(candidates), len(report.CheckResults))
+			return writeConfig(cmd, candidates, output)
+		},
+	}
+
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output file for the candidate config (default: stdout)")
+	return cmd
+}
+
+// writeConfig writes a config to the output file, or to stdout if output is empty
+func writeConfig(cmd *cobra.Command, config *rules.HighRiskParamsConfig, output string) error {
+	if output != "" {
+		if err := high_risk_params.SaveConfigFile(config, output); err != nil {
+			return err
+		}
+		fmt.Fprintf(cmd.ErrOrStderr(), "Config written to %s\n", output)
+		return nil
+	}
+	data, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal high-risk params config: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(data))
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	"github.com/spf13/cobra"
+)
+
+// newCheckPrivilegesCommand creates the "check-privileges" subcommand that probes the privileges
+// of the MySQL user and prints the GRANT statements of a dedicated precheck user
+func newCheckPrivilegesCommand() *cobra.Command {
+	var (
+		topologyFile string
+		tidbAddr     string
+		tidbUser     string
+		tidbPassword string
+		grantUser    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "check-privileges",
+		Short: "Check the privileges of the MySQL user and print the GRANT statements precheck needs",
+		Long: `Run only the probe queries of the privileges the collection needs, without collecting
+anything, and report which ones the MySQL user lacks. The GRANT statements of every privilege
+are printed for a dedicated precheck user (--grant-user), the ones the user lacks first.
+
+Exits with an error if any privilege is missing. precheck still runs without them, reporting
+the data it could not collect in the "Collection Limitations" section.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			endpoints := &collector.ClusterEndpoints{TiDBAddr: tidbAddr, TiDBUser: tidbUser, TiDBPassword: tidbPassword}
+			if topologyFile != "" {
+				var err error
+				endpoints, err = collector.LoadTopologyFromFile(topologyFile)
+				if err != nil {
+					return fmt.Errorf("failed to load topology file: %w", err)
+				}
+				if tidbUser != "" {
+					endpoints.TiDBUser = tidbUser
+				}
+				if tidbPassword != "" {
+					endpoints.TiDBPassword = tidbPassword
+				}
+			}
+			if endpoints.TiDBAddr == "" {
+				return errors.New("no TiDB endpoint, please provide --tidb-addr or --topology-file")
+			}
+
+			checks, err := tidb.NewTiDBCollector().CheckPrivileges(context.Background(), endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Privileges of %s on %s:\n", endpoints.TiDBUser, endpoints.TiDBAddr)
+			var missing, granted []tidb.PrivilegeRequirement
+			for _, check := range checks {
+				status := "OK"
+				switch {
+				case !check.Granted:
+					status = "MISSING"
+					missing = append(missing, check.Requirement)
+				case check.Err != nil:
+					// Not a privilege error (e.g., a table of a newer TiDB version), the collection skips it
+					status = fmt.Sprintf("OK (not supported: %v)", check.Err)
+					granted = append(granted, check.Requirement)
+				default:
+					granted = append(granted, check.Requirement)
+				}
+				fmt.Fprintf(out, "  %-8s %s (%s)\n", status, check.Requirement.Statement, check.Requirement.Privilege)
+				if !check.Granted {
+					fmt.Fprintf(out, "           Possibly incomplete: %s\n", check.Requirement.Impact)
+				}
+			}
+
+			fmt.Fprintf(out, "\nGRANT statements for a dedicated precheck user:\n")
+			if len(missing) > 0 {
+				fmt.Fprintf(out, "  -- missing\n")
+				printGrantStatements(cmd, missing, grantUser)
+			}
+			if len(granted) > 0 {
+				fmt.Fprintf(out, "  -- already granted to %s\n", endpoints.TiDBUser)
+				printGrantStatements(cmd, granted, grantUser)
+			}
+
+			if len(missing) > 0 {
+				return fmt.Errorf("%d of %d privileges are missing", len(missing), len(checks))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&topologyFile, "topology-file", "", "Path to cluster topology YAML file (TiUP/TiDB Operator format) or TiDB Ansible inventory")
+	cmd.Flags().StringVar(&tidbAddr, "tidb-addr", "", "TiDB MySQL protocol endpoint (host:port)")
+	cmd.Flags().StringVar(&tidbUser, "tidb-user", "root", "TiDB MySQL username to check")
+	cmd.Flags().StringVar(&tidbPassword, "tidb-password", "", "TiDB MySQL password")
+	cmd.Flags().StringVar(&grantUser, "grant-user", "'precheck'@'%'", "User of the printed GRANT statements")
+	return cmd
+}
+
+// printGrantStatements prints the GRANT statements of the requirements, once per distinct statement
+// (several queries need the same privilege)
+func printGrantStatements(cmd *cobra.Command, requirements []tidb.PrivilegeRequirement, user string) {
+	printed := make(map[string]bool)
+	for _, requirement := range requirements {
+		statement := requirement.GrantStatement(user)
+		if printed[statement] {
+			continue
+		}
+		printed[statement] = true
+		fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", statement)
+	}
+}
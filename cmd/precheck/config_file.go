@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"gopkg.in/yaml.v3"
+)
+
+// precheckConfig is the precheck configuration file (--config-file), in YAML
+// Example:
+//
+//	ignore_patterns:
+//	  - "rocksdb.*"
+//	  - tidb_ddl_reorg_batch_size
+type precheckConfig struct {
+	// IgnorePatterns suppress the findings about the matching parameters (see analyzer.AnalysisOptions.IgnorePatterns)
+	IgnorePatterns []string `yaml:"ignore_patterns"`
+}
+
+// loadPrecheckConfig loads and validates the configuration file, rejecting unknown fields
+// so that misspelled keys are not silently ignored
+// Returns an empty configuration if path is empty
+func loadPrecheckConfig(path string) (*precheckConfig, error) {
+	config := &precheckConfig{}
+	if path == "" {
+		return config, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	if err := analyzer.ValidateIgnorePatterns(config.IgnorePatterns); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return config, nil
+}
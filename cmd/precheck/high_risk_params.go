@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules/high_risk_params"
+)
+
+// newHighRiskParamsRule creates the high-risk parameters rule from the merge of, lowest priority first:
+//   - the global knowledge base file (knowledge/high_risk_params/high_risk_params.json, default location search)
+//   - the file of the target version family (knowledge/<version_family>/high_risk_params.json)
+//   - the configFiles, in order
+//
+// The knowledge base files are best effort, a config file given by the user that can't be loaded is an error
+func newHighRiskParamsRule(knowledgeBasePath, targetVersion string, configFiles []string) (rules.Rule, error) {
+	var layers []high_risk_params.Layer
+
+	globalPath := high_risk_params.GetKnowledgeBaseConfigPath()
+	if _, err := os.Stat(globalPath); err == nil {
+		globalConfig, err := high_risk_params.NewManager("").LoadConfig()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to load high-risk params config %s: %v\n", globalPath, err)
+		} else {
+			layers = append(layers, high_risk_params.Layer{Source: globalPath, Config: globalConfig})
+		}
+	}
+
+	familyConfig, err := rules.LoadHighRiskParamsFromKB(knowledgeBasePath, targetVersion)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v, skipping the high-risk params of the target version family\n", err)
+	} else if familyConfig != nil {
+		layers = append(layers, high_risk_params.Layer{
+			Source: fmt.Sprintf("%s of the %s version family", rules.HighRiskParamsFile, targetVersion),
+			Config: familyConfig,
+		})
+	}
+
+	for _, path := range configFiles {
+		config, err := high_risk_params.LoadConfigFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load high-risk params config: %w", err)
+		}
+		layers = append(layers, high_risk_params.Layer{Source: path, Config: config})
+	}
+
+	config, stats := high_risk_params.MergeLayers(layers)
+	for _, layer := range stats {
+		fmt.Printf("High-risk params layer %s\n", layer)
+	}
+	return rules.NewHighRiskParamsRuleWithLayers(config, stats)
+}
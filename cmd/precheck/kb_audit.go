@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+// newKBAuditCommand creates the "kb-audit" subcommand that compares knowledge base defaults with the documented defaults
+func newKBAuditCommand() *cobra.Command {
+	var (
+		knowledgePath  string
+		component      string
+		version        string
+		docsDefaults   string
+		outputFile     string
+		jsonOutput     bool
+		failOnFindings bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "kb-audit",
+		Short: "Compare knowledge base defaults with the documented defaults",
+		Long: `Compare the defaults extracted into the knowledge base (defaults.json) for a component and
+version with the defaults documented by the docs team, and report:
+  - parameters whose extracted and documented defaults disagree
+  - parameters documented but missing from the knowledge base
+  - knowledge base parameters with no documentation
+
+The documented defaults are a name -> value map, as a JSON object or a CSV file with one
+"name,value" row per parameter. Names are matched ignoring case, dashes vs underscores, a
+"<component>." prefix and, if unambiguous, the section prefix of config names. Use the
+"sysvar:" prefix to name a system variable explicitly.
+
+The report is markdown, suitable for filing issues against the docs or the extractor.`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if knowledgePath == "" {
+				knowledgePath = resolveKnowledgeBasePath()
+			}
+			documented, err := collector.LoadDocumentedDefaults(docsDefaults)
+			if err != nil {
+				return err
+			}
+			audit, err := collector.AuditKnowledgeBaseDefaults(knowledgePath, component, version, documented)
+			if err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			if outputFile != "" {
+				file, err := os.Create(outputFile)
+				if err != nil {
+					return fmt.Errorf("failed to create %s: %w", outputFile, err)
+				}
+				defer file.Close()
+				out = file
+			}
+			if err := writeKBAudit(out, audit, jsonOutput, filepath.Base(docsDefaults)); err != nil {
+				return err
+			}
+			if outputFile != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "%d disagreeing, %d documented only, %d undocumented parameters, report written to %s\n",
+					len(audit.Mismatched), len(audit.DocumentedOnly), len(audit.Undocumented), outputFile)
+			}
+
+			if failOnFindings && len(audit.Mismatched) > 0 {
+				return fmt.Errorf("%d parameters have documented defaults disagreeing with the knowledge base", len(audit.Mismatched))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&knowledgePath, "knowledge-path", "", "Knowledge base directory. If not specified, the default knowledge base location is used")
+	cmd.Flags().StringVar(&component, "component", "", "Component to audit (tidb, pd, tikv, tiflash)")
+	cmd.Flags().StringVar(&version, "version", "", "Knowledge base version to audit, e.g. v8.5.0")
+	cmd.Flags().StringVar(&docsDefaults, "docs-defaults", "", "Documented defaults, a JSON object or a CSV file mapping parameter names to values")
+	cmd.Flags().StringVar(&outputFile, "output", "", "Write the report to this file instead of stdout")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Write the audit as JSON instead of markdown")
+	cmd.Flags().BoolVar(&failOnFindings, "fail-on-mismatch", false, "Exit with a non-zero status if documented and extracted defaults disagree (e.g., for a nightly job)")
+	cmd.MarkFlagRequired("component")
+	cmd.MarkFlagRequired("version")
+	cmd.MarkFlagRequired("docs-defaults")
+	return cmd
+}
+
+// writeKBAudit writes the audit as markdown or JSON
+func writeKBAudit(out io.Writer, audit *collector.KBAudit, jsonOutput bool, docsSource string) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(audit)
+	}
+	return audit.WriteMarkdown(out, docsSource)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+// newKBDiffCommand creates the "kb diff" subcommand that lists the parameter defaults changed between two versions
+func newKBDiffCommand() *cobra.Command {
+	var (
+		knowledgePath string
+		jsonOutput    bool
+		noCache       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <source-version> <target-version>",
+		Short: "List parameter defaults changed between two knowledge base versions",
+		Long: `List the parameters whose default differs between two knowledge base versions,
+including parameters added or removed in the target version.
+
+Results are cached in the user cache directory (~/.cache/tidb-upgrade-precheck on Linux),
+keyed by the content of the compared defaults.json files, so repeated runs on an unchanged
+knowledge base (e.g., in CI) do not parse the files again. Entries older than 30 days are evicted.
+Use --no-cache to bypass the cache, and 'precheck cache clean' to clear it.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if knowledgePath == "" {
+				knowledgePath = resolveKnowledgeBasePath()
+			}
+
+			var cache *collector.KBComparisonCache
+			if !noCache {
+				cacheDir, err := collector.DefaultKBCacheDir()
+				if err != nil {
+					return err
+				}
+				cache = collector.NewKBComparisonCache(cacheDir)
+			}
+
+			diff, err := collector.DiffKnowledgeBaseVersionsCached(knowledgePath, args, cache)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(diff)
+			}
+			return printKBDiff(cmd.OutOrStdout(), diff)
+		},
+	}
+
+	cmd.Flags().StringVar(&knowledgePath, "knowledge-path", "", "Knowledge base directory. If not specified, the default knowledge base location is used")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the diff as JSON")
+	cmd.Flags().BoolVar(&noCache, "no-cache", false, "Do not read or write the kb-diff cache")
+	return cmd
+}
+
+// printKBDiff prints the changed parameters as a table, one row per parameter
+func printKBDiff(out io.Writer, diff *collector.KBComparison) error {
+	if len(diff.Components) == 0 {
+		fmt.Fprintf(out, "No parameter default changed between %s and %s\n", diff.Versions[0], diff.Versions[1])
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "COMPONENT\tPARAMETER\t%s\t%s\n", diff.Versions[0], diff.Versions[1])
+	for _, component := range diff.Components {
+		for _, param := range component.Params {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", component.Component, param.Name,
+				formatKBDiffValue(param.Defaults[0], param.Present[0]),
+				formatKBDiffValue(param.Defaults[1], param.Present[1]))
+		}
+	}
+	return w.Flush()
+}
+
+// kbDiffMaxValueWidth is the width from which values are truncated in the diff table (use --json for full values)
+const kbDiffMaxValueWidth = 60
+
+// formatKBDiffValue formats a default value of the diff table
+func formatKBDiffValue(value interface{}, present bool) string {
+	if !present {
+		return "(absent)"
+	}
+	formatted := rules.FormatValue(value)
+	if len(formatted) > kbDiffMaxValueWidth {
+		formatted = formatted[:kbDiffMaxValueWidth-3] + "..."
+	}
+	return formatted
+}
+
+// newCacheCommand creates the "cache" command that manages the local result cache
+func newCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Short: "Manage the local result cache",
+		Args:  cobra.NoArgs,
+	}
+
+	var expiredOnly bool
+	clean := &cobra.Command{
+		Use:   "clean",
+		Short: "Remove cached kb-diff results",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cacheDir, err := collector.DefaultKBCacheDir()
+			if err != nil {
+				return err
+			}
+			removed, err := collector.NewKBComparisonCache(cacheDir).Clean(expiredOnly)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d cache entries from %s\n", removed, cacheDir)
+			return nil
+		},
+	}
+	clean.Flags().BoolVar(&expiredOnly, "expired", false, "Only remove entries older than 30 days")
+	cmd.AddCommand(clean)
+	return cmd
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules/high_risk_params"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/spf13/cobra"
+	"github.com/xuri/excelize/v2"
+)
+
+// kbExportSummarySheet is the name of the summary worksheet of the Excel export
+const kbExportSummarySheet = "Summary"
+
+// newKBCommand creates the "kb" parent command for knowledge base maintenance subcommands
+func newKBCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kb",
+		Short: "Knowledge base maintenance commands",
+		Args:  cobra.NoArgs,
+	}
+	cmd.AddCommand(newKBExportCommand())
+	cmd.AddCommand(newKBDiffCommand())
+	cmd.AddCommand(newKBGenerateCommand())
+	return cmd
+}
+
+// newKBExportCommand creates the "kb export" subcommand that exports parameter defaults of several versions side by side
+func newKBExportCommand() *cobra.Command {
+	var (
+		knowledgePath string
+		format        string
+		output        string
+		versions      string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export knowledge base defaults of several versions to a spreadsheet",
+		Long: `Export the parameter defaults of several knowledge base versions side by side.
+
+The xlsx export has a Summary sheet with parameter counts per component, and one sheet per
+component with the default of each version, whether the default changed between the versions,
+the parameter type and its sensitivity (high_risk, deployment_specific, machine_derived).
+Changed parameters are highlighted in yellow.
+
+Example:
+  precheck kb export --format xlsx --output kb.xlsx --versions v7.5.0,v8.1.0`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "xlsx" {
+				return fmt.Errorf("unsupported export format: %s (supported: xlsx)", format)
+			}
+			versionList := splitAddrs(versions)
+			if len(versionList) == 0 {
+				return fmt.Errorf("--versions is required")
+			}
+			if knowledgePath == "" {
+				knowledgePath = resolveKnowledgeBasePath()
+			}
+
+			comparison, err := collector.CompareKnowledgeBaseVersions(knowledgePath, versionList)
+			if err != nil {
+				return err
+			}
+			sensitivity, err := loadKBSensitivity(knowledgePath, versionList[len(versionList)-1])
+			if err != nil {
+				return err
+			}
+			if err := writeKBExportXLSX(output, comparison, sensitivity); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Knowledge base exported to %s\n", output)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&knowledgePath, "knowledge-path", "", "Knowledge base directory. If not specified, the default knowledge base location is used")
+	cmd.Flags().StringVar(&format, "format", "xlsx", "Export format (xlsx)")
+	cmd.Flags().StringVar(&output, "output", "kb.xlsx", "Output file")
+	cmd.Flags().StringVar(&versions, "versions", "", "Comma-separated list of versions to export (e.g., v7.5.0,v8.1.0)")
+	return cmd
+}
+
+// kbSensitivity classifies parameters using the global (version-agnostic) files of the knowledge base
+type kbSensitivity struct {
+	highRisk           *rules.HighRiskParamsConfig
+	deploymentSpecific rules.DeploymentSpecificParams
+	machineDerived     rules.MachineDerivedParams
+}
+
+// loadKBSensitivity loads high_risk_params, deployment_specific and machine_derived_params from the knowledge base
+func loadKBSensitivity(knowledgePath, version string) (*kbSensitivity, error) {
+	kb, err := collector.LoadKnowledgeBase(knowledgePath, version)
+	if err != nil {
+		return nil, err
+	}
+
+	sensitivity := &kbSensitivity{
+		highRisk:       &rules.HighRiskParamsConfig{},
+		machineDerived: rules.DefaultMachineDerivedParams(),
+	}
+	if raw, ok := kb["high_risk_params"]; ok {
+		data, err := json.Marshal(raw)
+		if err == nil {
+			err = json.Unmarshal(data, sensitivity.highRisk)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse high_risk_params: %w", err)
+		}
+	}
+	if raw, ok := kb["deployment_specific"]; ok {
+		if sensitivity.deploymentSpecific, err = rules.ParseDeploymentSpecificParams(raw); err != nil {
+			return nil, err
+		}
+	}
+	if raw, ok := kb["machine_derived_params"]; ok {
+		fromKB, err := rules.ParseMachineDerivedParams(raw)
+		if err != nil {
+			return nil, err
+		}
+		sensitivity.machineDerived.Merge(fromKB)
+	}
+	return sensitivity, nil
+}
+
+// classify returns the sensitivity classes of a parameter, comma-separated (empty if none)
+func (s *kbSensitivity) classify(component string, param collector.KBParamComparison) string {
+	plainName := strings.TrimPrefix(param.Name, "sysvar:")
+
+	var classes []string
+	if _, ok := high_risk_params.FindParameterInConfig(s.highRisk, component, param.ParamType, plainName); ok {
+		classes = append(classes, "high_risk")
+	}
+	if s.deploymentSpecific.Contains(component, param.Name) {
+		classes = append(classes, "deployment_specific")
+	}
+	if _, ok := s.machineDerived[component][plainName]; ok {
+		classes = append(classes, "machine_derived")
+	}
+	return strings.Join(classes, ",")
+}
+
+// writeKBExportXLSX writes the comparison to an Excel workbook
+func writeKBExportXLSX(path string, comparison *collector.KBComparison, sensitivity *kbSensitivity) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	headerStyle, err := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	if err != nil {
+		return err
+	}
+	changedStyle, err := f.NewStyle(&excelize.Style{
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"FFFF00"}},
+	})
+	if err != nil {
+		return err
+	}
+
+	// The default sheet of a new workbook becomes the summary
+	if err := f.SetSheetName("Sheet1", kbExportSummarySheet); err != nil {
+		return err
+	}
+	summaryRows := [][]interface{}{{"component", "parameters", "changed", "sensitive"}}
+
+	header := []interface{}{"parameter_name"}
+	for _, version := range comparison.Versions {
+		header = append(header, version+"_default")
+	}
+	header = append(header, "changed", "type", "sensitivity")
+
+	for _, component := range comparison.Components {
+		if _, err := f.NewSheet(component.Component); err != nil {
+			return err
+		}
+		rows := [][]interface{}{header}
+		sensitive := 0
+		for _, param := range component.Params {
+			row := []interface{}{param.Name}
+			for i, value := range param.Defaults {
+				row = append(row, formatKBExportValue(value, param.Present[i]))
+			}
+			classes := sensitivity.classify(component.Component, param)
+			if classes != "" {
+				sensitive++
+			}
+			row = append(row, param.Changed, param.Type, classes)
+			rows = append(rows, row)
+		}
+		if err := writeSheetRows(f, component.Component, rows, headerStyle); err != nil {
+			return err
+		}
+
+		lastColumn, err := excelize.ColumnNumberToName(len(header))
+		if err != nil {
+			return err
+		}
+		for i, param := range component.Params {
+			if !param.Changed {
+				continue
+			}
+			row := i + 2
+			if err := f.SetCellStyle(component.Component, fmt.Sprintf("A%d", row), fmt.Sprintf("%s%d", lastColumn, row), changedStyle); err != nil {
+				return err
+			}
+		}
+		summaryRows = append(summaryRows, []interface{}{component.Component, len(component.Params), component.ChangedCount(), sensitive})
+	}
+
+	summaryRows = append(summaryRows, []interface{}{}, []interface{}{"versions", strings.Join(comparison.Versions, ", ")})
+	if err := writeSheetRows(f, kbExportSummarySheet, summaryRows, headerStyle); err != nil {
+		return err
+	}
+
+	if err := f.SaveAs(path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// writeSheetRows writes rows starting at A1, with the first row in the header style
+func writeSheetRows(f *excelize.File, sheet string, rows [][]interface{}, headerStyle int) error {
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		if err != nil {
+			return err
+		}
+		if err := f.SetSheetRow(sheet, cell, &row); err != nil {
+			return err
+		}
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	lastColumn, err := excelize.ColumnNumberToName(len(rows[0]))
+	if err != nil {
+		return err
+	}
+	return f.SetCellStyle(sheet, "A1", lastColumn+"1", headerStyle)
+}
+
+// formatKBExportValue formats a default value for a spreadsheet cell
+// Absent parameters are left empty, composite values are written as JSON
+func formatKBExportValue(value interface{}, present bool) interface{} {
+	if !present {
+		return ""
+	}
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string, bool, float64:
+		return v
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprintf("%v", v)
+		}
+		return string(data)
+	}
+}
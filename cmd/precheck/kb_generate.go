@@ -0,0 +1,147 @@
+package main
+
+import (
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/kbgenerator"
+	"github.com/spf13/cobra"
+)
+
+// newKBGenerateCommand creates the "kb generate" command that generates the knowledge base
+// It replaces the kb-generator binary, which is kept as a shim for "kb generate all"
+func newKBGenerateCommand() *cobra.Command {
+	opts := kbgenerator.DefaultOptions()
+
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate the knowledge base from source code, a playground or a running cluster",
+		Long: `Generate the knowledge base files.
+
+  defaults       defaults.json of each component for one version (or --from-tag/--to-tag),
+                 and parameter_history.json with --parameter-history
+  upgrade-logic  upgrade_logic.json of TiDB and PD, extracted once from the master branch
+  upgrade-history  upgrade_history.json of TiDB, the release of each bootstrap version
+  all            upgrade-logic, then defaults (what kb-generator did)
+
+The files are written to --knowledge-dir (./knowledge by default).`,
+		Args: cobra.NoArgs,
+	}
+
+	flags := cmd.PersistentFlags()
+	flags.StringVar(&opts.KnowledgeDir, "knowledge-dir", opts.KnowledgeDir, "Knowledge base directory the files are generated in")
+	flags.StringVar(&opts.TiDBRepo, "tidb-repo", "", "Path to TiDB repository root")
+	flags.StringVar(&opts.PDRepo, "pd-repo", "", "Path to PD repository root")
+	flags.StringVar(&opts.TiKVRepo, "tikv-repo", "", "Path to TiKV repository root")
+	flags.StringVar(&opts.TiFlashRepo, "tiflash-repo", "", "Path to TiFlash repository root")
+	flags.StringSliceVar(&opts.Components, "components", opts.Components, "Components to generate")
+	flags.BoolVar(&opts.Strict, "strict", false, "Fail if a variable name of the TiDB upgrade logic cannot be resolved, or if TiKV/TiFlash defaults have duplicate keys with conflicting values")
+
+	cmd.AddCommand(newKBGenerateDefaultsCommand(&opts))
+	cmd.AddCommand(newKBGenerateUpgradeLogicCommand(&opts))
+	cmd.AddCommand(newKBGenerateUpgradeHistoryCommand(&opts))
+	cmd.AddCommand(newKBGenerateAllCommand(&opts))
+	return cmd
+}
+
+// newKBGenerateDefaultsCommand creates the "kb generate defaults" subcommand
+func newKBGenerateDefaultsCommand(opts *kbgenerator.Options) *cobra.Command {
+	var paramHistory bool
+	cmd := &cobra.Command{
+		Use:   "defaults",
+		Short: "Generate the defaults of the components for a version",
+		Long: `Generate knowledge/<version_group>/<version>/<component>/defaults.json.
+
+Example:
+  precheck kb generate defaults --version v8.5.0 --tidb-repo ../tidb --pd-repo ../pd --tikv-repo ../tikv
+  precheck kb generate defaults --version v8.5.0 --source runtime-only --tidb-addr 10.0.0.1:4000 --pd-addr 10.0.0.2:2379
+  precheck kb generate defaults --parameter-history --components tidb,pd`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if paramHistory {
+				return kbgenerator.GenerateParameterHistory(*opts)
+			}
+			return kbgenerator.GenerateDefaults(*opts)
+		},
+	}
+	addKBGenerateVersionFlags(cmd, opts)
+	cmd.Flags().BoolVar(&paramHistory, "parameter-history", false, "Generate knowledge/<component>/parameter_history.json from the versions already in the knowledge base instead (no version or playground needed)")
+	return cmd
+}
+
+// newKBGenerateUpgradeLogicCommand creates the "kb generate upgrade-logic" subcommand
+func newKBGenerateUpgradeLogicCommand(opts *kbgenerator.Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "upgrade-logic",
+		Short: "Generate the upgrade logic of TiDB and PD from their repositories",
+		Long: `Generate knowledge/tidb/upgrade_logic.json (with its bootstrap version index) and knowledge/pd/upgrade_logic.json.
+
+The upgrade logic is version-agnostic: the repositories should be on their master branch.
+
+Example:
+  precheck kb generate upgrade-logic --tidb-repo ../tidb --pd-repo ../pd --strict`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return kbgenerator.GenerateUpgradeLogic(*opts)
+		},
+	}
+}
+
+// newKBGenerateUpgradeHistoryCommand creates the "kb generate upgrade-history" subcommand
+func newKBGenerateUpgradeHistoryCommand(opts *kbgenerator.Options) *cobra.Command {
+	return &cobra.Command{
+		Use:   "upgrade-history",
+		Short: "Generate the release of each TiDB bootstrap version",
+		Long: `Generate knowledge/tidb/upgrade_history.json, mapping the bootstrap versions of TiDB to the first
+release at each of them (e.g., {"198": "v8.1.0", "181": "v7.5.3"}). Forced changes are reported with
+the release that introduced them.
+
+With --tidb-repo, currentBootstrapVersion is read at each release tag (the working tree is not checked out).
+Without it, the bootstrap versions recorded in the TiDB defaults of the knowledge base are used.
+
+Example:
+  precheck kb generate upgrade-history --tidb-repo ../tidb`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return kbgenerator.GenerateUpgradeHistory(*opts)
+		},
+	}
+}
+
+// newKBGenerateAllCommand creates the "kb generate all" subcommand
+func newKBGenerateAllCommand(opts *kbgenerator.Options) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "all",
+		Short: "Generate the upgrade logic, then the defaults of the components for a version",
+		Long: `Generate the upgrade logic (if --tidb-repo or --pd-repo is given), then the defaults of the version.
+
+A failure of the upgrade logic is a warning, except unresolved variable names with --strict.
+
+Example:
+  precheck kb generate all --version v8.5.0 --tidb-repo ../tidb --pd-repo ../pd --tikv-repo ../tikv --tiflash-repo ../tiflash`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return kbgenerator.GenerateAll(*opts)
+		},
+	}
+	addKBGenerateVersionFlags(cmd, opts)
+	return cmd
+}
+
+// addKBGenerateVersionFlags adds the flags selecting the versions and where their defaults are extracted from
+func addKBGenerateVersionFlags(cmd *cobra.Command, opts *kbgenerator.Options) {
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Version, "version", "", "Version to generate (single version mode)")
+	flags.StringVar(&opts.FromTag, "from-tag", "", "Source version (version range mode)")
+	flags.StringVar(&opts.ToTag, "to-tag", "", "Target version (version range mode)")
+	flags.StringVar(&opts.Source, "source", opts.Source, "Where defaults are extracted from: playground (a tiup playground per version, and source code), source-only (source code only, no TiUP needed) or runtime-only (the running cluster given by --tidb-addr etc.)")
+	flags.StringVar(&opts.Cluster.TiDBAddr, "tidb-addr", opts.Cluster.TiDBAddr, "TiDB MySQL protocol endpoint of the cluster (--source runtime-only)")
+	flags.StringVar(&opts.Cluster.TiDBUser, "tidb-user", opts.Cluster.TiDBUser, "TiDB MySQL username (--source runtime-only)")
+	flags.StringVar(&opts.Cluster.TiDBPassword, "tidb-password", "", "TiDB MySQL password (--source runtime-only)")
+	flags.StringVar(&opts.Cluster.PDAddr, "pd-addr", opts.Cluster.PDAddr, "PD HTTP API endpoint of the cluster (--source runtime-only)")
+	flags.StringVar(&opts.Cluster.TiKVAddr, "tikv-addr", "", "TiKV instance (host:port, as in SHOW CONFIG) to read the configuration of (--source runtime-only, TiKV is skipped if empty)")
+	flags.StringVar(&opts.Cluster.TiFlashAddr, "tiflash-addr", "", "TiFlash instance (host:port, as in SHOW CONFIG) to read the configuration of (--source runtime-only, TiFlash is skipped if empty)")
+	flags.StringVar(&opts.FromBinaries, "from-binaries", "", "Directory with tidb-server, pd-server, tikv-server (and optionally tiflash) binaries of a custom build: a playground is started from them and defaults are collected from runtime APIs only (requires --version)")
+	flags.BoolVar(&opts.FailFast, "fail-fast", false, "Stop the generation of a version as soon as one component fails (--source playground)")
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/spf13/cobra"
+)
+
+// nearestKBVersionCount is the number of available versions suggested when a version is missing
+const nearestKBVersionCount = 5
+
+// newKBListCommand creates the "kb-list" subcommand that lists the versions covered by the knowledge base
+func newKBListCommand() *cobra.Command {
+	var (
+		knowledgePath string
+		jsonOutput    bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "kb-list",
+		Short: "List knowledge base versions and their contents",
+		Long: `List the versions covered by the knowledge base directory.
+
+For each version, shows the components that have defaults.json, their parameter counts,
+whether upgrade_logic.json exists for the component and when the defaults were generated.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if knowledgePath == "" {
+				knowledgePath = resolveKnowledgeBasePath()
+			}
+			listing, err := collector.ListKnowledgeBase(knowledgePath)
+			if err != nil {
+				return err
+			}
+			if jsonOutput {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(listing)
+			}
+			return printKBListing(cmd.OutOrStdout(), listing)
+		},
+	}
+
+	cmd.Flags().StringVar(&knowledgePath, "knowledge-path", "", "Knowledge base directory. If not specified, the default knowledge base location is used")
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the listing as JSON")
+	return cmd
+}
+
+// printKBListing prints the knowledge base listing as a table, one row per version and component
+func printKBListing(out io.Writer, listing *collector.KBListing) error {
+	fmt.Fprintf(out, "Knowledge base: %s\n\n", listing.Path)
+	if len(listing.Versions) == 0 {
+		fmt.Fprintln(out, "No versions found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "GROUP\tVERSION\tCOMPONENT\tCONFIG\tSYSVARS\tUPGRADE LOGIC\tGENERATED")
+	for _, version := range listing.Versions {
+		if len(version.Components) == 0 {
+			fmt.Fprintf(w, "%s\t%s\t-\t-\t-\t-\t-\n", version.VersionGroup, version.Version)
+			continue
+		}
+		for _, component := range version.Components {
+			if component.Error != "" {
+				fmt.Fprintf(w, "%s\t%s\t%s\tinvalid: %s\t\t\t\n", version.VersionGroup, version.Version, component.Component, component.Error)
+				continue
+			}
+			upgradeLogic := "no"
+			if listing.UpgradeLogic[component.Component] {
+				upgradeLogic = "yes"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%d\t%s\t%s\n",
+				version.VersionGroup, version.Version, component.Component,
+				component.ConfigParams, component.SystemVariables, upgradeLogic,
+				component.GeneratedAt.Format("2006-01-02 15:04:05"))
+		}
+	}
+	return w.Flush()
+}
+
+// printNearestKBVersions suggests the available knowledge base versions closest to a missing version
+func printNearestKBVersions(listing *collector.KBListing, version string) {
+	nearest := listing.NearestVersions(version, nearestKBVersionCount)
+	if len(nearest) == 0 {
+		fmt.Fprintf(os.Stderr, "No knowledge base versions are available in %s, please generate the knowledge base first\n", listing.Path)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Nearest available versions: %s\n", strings.Join(nearest, ", "))
+	fmt.Fprintf(os.Stderr, "Run 'precheck kb-list' to see all available versions\n")
+}
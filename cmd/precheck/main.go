@@ -2,17 +2,26 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
-	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules/high_risk_params"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules/catalog"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/buildinfo"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/tracing"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 func main() {
@@ -21,6 +30,14 @@ func main() {
 		targetVersion string
 		outputFormat  string
 		outputDir     string
+		outputURI     string
+		outputAppend  bool
+		// Report file name template and its options
+		outputName    string
+		overwrite     bool
+		latestSymlink bool
+		// Directory of report templates overriding the built-in formats
+		templateDir string
 		// Topology file (alternative to individual connection parameters)
 		topologyFile string
 		// Cluster connection parameters (provided by TiUP/Operator)
@@ -31,7 +48,50 @@ func main() {
 		tikvAddrs    string // Comma-separated list
 		pdAddrs      string // Comma-separated list
 		// High-risk parameters configuration
-		highRiskParamsConfig string
+		highRiskParamsConfig []string
+		// Golden configuration profile (optional baseline to report drift from)
+		goldenConfig string
+		// Rules configuration (thresholds of the rules)
+		rulesConfig string
+		// Declarative rule files (JSON rule definitions run next to the built-in rules)
+		ruleFiles []string
+		// Severity profile: built-in profile name or profile file
+		severityProfile string
+		// OpenTelemetry OTLP/gRPC endpoint (tracing is disabled if empty)
+		otelEndpoint string
+		// pprof output files (developer/support diagnostics, disabled if empty)
+		cpuProfile string
+		memProfile string
+		// Notification of the results (disabled if no webhook is specified)
+		notifyWebhook string
+		notifyFormat  string
+		notifyOn      string
+		// Limits on the load collection puts on the cluster's PD and TiKV APIs
+		collectionRateLimit   float64
+		collectionConcurrency int
+		// Time limit of each SQL statement issued to TiDB
+		sqlTimeout time.Duration
+		// Checkpoint file making an interrupted collection resumable, and its maximum age
+		checkpointFile string
+		checkpointTTL  time.Duration
+		// Configuration-only collection (no SHOW GLOBAL VARIABLES)
+		skipSysVars bool
+		// Collection through the TiDB SQL endpoint only (auto-detected if only --tidb-addr is given)
+		sqlOnly bool
+		// Precheck configuration file (ignore patterns), and whether ignored findings are reported
+		configFile  string
+		showIgnored bool
+		// Selection of the catalog rules to run (all registered rules by default)
+		includeRules []string
+		excludeRules []string
+		// Snapshot files: the collected snapshot is saved to saveSnapshot, and findings are
+		// restricted to the parameters changed since the changedSince snapshot
+		saveSnapshot string
+		changedSince string
+		// Only check that the cluster endpoints are reachable, without collecting configuration
+		validateConnection bool
+		// Check the target version against the published releases listed in the knowledge base
+		checkReleaseExists bool
 	)
 
 	rootCmd := &cobra.Command{
@@ -40,7 +100,8 @@ func main() {
 		Long: `A tool to check compatibility issues before upgrading TiDB cluster.
 
 Connection information can be provided in two ways:
-1. Topology file (recommended): Use --topology-file to specify a TiUP/TiDB Operator topology YAML file
+1. Topology file (recommended): Use --topology-file to specify a TiUP/TiDB Operator topology YAML file,
+   or a TiDB Ansible inventory (INI format)
 2. Individual parameters: Use --tidb-addr, --tikv-addrs, --pd-addrs, etc.
 
 Connection parameters are typically provided by TiUP or TiDB Operator.
@@ -51,18 +112,132 @@ The knowledge base is automatically located:
 
 Source and target version numbers are used as keys to locate version-specific defaults.json files.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			runPrecheck(sourceVersion, targetVersion, outputFormat, outputDir,
-				topologyFile, tidbAddr, tidbUser, tidbPassword, tikvAddrs, pdAddrs, highRiskParamsConfig)
+			notify, err := newNotifyConfig(notifyWebhook, notifyFormat, notifyOn)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if collectionRateLimit < 0 || collectionConcurrency < 0 {
+				fmt.Fprintln(os.Stderr, "Error: --collection-rate-limit and --collection-concurrency must not be negative")
+				os.Exit(1)
+			}
+			if sqlTimeout < 0 {
+				fmt.Fprintln(os.Stderr, "Error: --sql-timeout must not be negative")
+				os.Exit(1)
+			}
+			if checkpointTTL <= 0 {
+				fmt.Fprintln(os.Stderr, "Error: --checkpoint-ttl must be positive")
+				os.Exit(1)
+			}
+			ruleIDs, err := catalog.Select(includeRules, excludeRules)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			profile, err := analyzer.LoadSeverityProfile(severityProfile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			config, err := loadPrecheckConfig(configFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if templateDir != "" {
+				if err := reporter.ValidateTemplateDir(templateDir); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if outputAppend {
+				if err := validateOutputAppend(outputFormat, outputURI); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+			if err := validateOutputName(outputName, outputURI, latestSymlink); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if validateConnection {
+				os.Exit(runValidateConnection(os.Stdout, explicitSourceVersion(sourceVersion), topologyFile, tidbAddr, tidbUser, tidbPassword, tikvAddrs, pdAddrs, ruleIDs))
+			}
+			opts := &precheckOptions{
+				sourceVersion:        sourceVersion,
+				targetVersion:        targetVersion,
+				checkReleaseExists:   checkReleaseExists,
+				topologyFile:         topologyFile,
+				tidbAddr:             tidbAddr,
+				tidbUser:             tidbUser,
+				tidbPassword:         tidbPassword,
+				tikvAddrs:            tikvAddrs,
+				pdAddrs:              pdAddrs,
+				sqlOnly:              sqlOnly,
+				ruleIDs:              ruleIDs,
+				rulesConfig:          rulesConfig,
+				highRiskParamsConfig: highRiskParamsConfig,
+				goldenConfig:         goldenConfig,
+				ruleFiles:            ruleFiles,
+				throttle:             common.NewThrottle(collectionRateLimit, collectionConcurrency),
+				sqlTimeout:           sqlTimeout,
+				checkpoint:           collector.CheckpointOptions{Path: checkpointFile, TTL: checkpointTTL},
+				skipSysVars:          skipSysVars,
+				saveSnapshot:         saveSnapshot,
+				changedSince:         changedSince,
+				severityProfile:      profile,
+				ignorePatterns:       config.IgnorePatterns,
+				showIgnored:          showIgnored,
+				outputFormat:         outputFormat,
+				outputDir:            outputDir,
+				outputURI:            outputURI,
+				outputName:           outputName,
+				templateDir:          templateDir,
+				outputAppend:         outputAppend,
+				overwrite:            overwrite,
+				latestSymlink:        latestSymlink,
+				notify:               notify,
+				otelEndpoint:         otelEndpoint,
+				cpuProfile:           cpuProfile,
+				memProfile:           memProfile,
+			}
+			// Exit only once runPrecheck has returned, so that the traces and profiles of failed runs are written
+			if err := runPrecheck(opts); err != nil {
+				printPrecheckError(err, opts.targetVersion)
+				os.Exit(1)
+			}
 		},
 	}
 
+	// Tool version: --version prints the version, "precheck version" prints full build metadata
+	rootCmd.Version = buildinfo.Version
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "version",
+		Short: "Print version and build information",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Println(buildinfo.Get())
+		},
+	})
+
+	rootCmd.AddCommand(newKBListCommand())
+	rootCmd.AddCommand(newKBAuditCommand())
+	rootCmd.AddCommand(newKBCommand())
+	rootCmd.AddCommand(newCacheCommand())
+	rootCmd.AddCommand(newServeCommand())
+	rootCmd.AddCommand(newSchemaCommand())
+	rootCmd.AddCommand(newSelfTestCommand())
+	rootCmd.AddCommand(newResultDiffCommand())
+	rootCmd.AddCommand(newCheckPrivilegesCommand())
+
 	// Version flags
-	rootCmd.Flags().StringVar(&sourceVersion, "source-version", "", "Source TiDB version (current cluster version). If not provided, will be detected from cluster")
-	rootCmd.Flags().StringVar(&targetVersion, "target-version", "", "Target TiDB version for upgrade (required)")
+	rootCmd.Flags().StringVar(&sourceVersion, "source-version", autoSourceVersion, "Source TiDB version (current cluster version). \"auto\" takes it from the topology file or detects it from the cluster, querying TiDB with SQL if collection did not report it")
+	rootCmd.Flags().StringVar(&targetVersion, "target-version", "", "Target TiDB version for upgrade (required), e.g. v8.5.1 or 8.5.1. A version group (v8.5 or 8.5) selects its latest patch version in the knowledge base")
+	rootCmd.Flags().BoolVar(&checkReleaseExists, "check-release-exists", false, "Reject a target version that is not a published TiDB release (listed in knowledge/releases.json)")
 	rootCmd.MarkFlagRequired("target-version")
 
 	// Topology file (alternative to individual parameters)
-	rootCmd.Flags().StringVar(&topologyFile, "topology-file", "", "Path to cluster topology YAML file (TiUP/TiDB Operator format)")
+	rootCmd.Flags().StringVar(&topologyFile, "topology-file", "", "Path to cluster topology YAML file (TiUP/TiDB Operator format) or TiDB Ansible inventory")
 
 	// Cluster connection parameters (provided by TiUP/Operator)
 	// These are used if topology file is not provided
@@ -73,11 +248,63 @@ Source and target version numbers are used as keys to locate version-specific de
 	rootCmd.Flags().StringVar(&pdAddrs, "pd-addrs", "", "PD HTTP API endpoints (comma-separated, provided by TiUP/Operator)")
 
 	// Output options
-	rootCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format (text, markdown, html, json)")
+	rootCmd.Flags().StringVar(&outputFormat, "format", "text", "Output format (text, markdown, html, json, csv, junit). Multiple formats can be comma-separated")
 	rootCmd.Flags().StringVar(&outputDir, "output-dir", ".", "Output directory for reports")
+	rootCmd.Flags().StringVar(&outputURI, "output", "", "Report destination URI: file:///path, s3://bucket/prefix, or - for stdout. Overrides --output-dir")
+	rootCmd.Flags().BoolVar(&outputAppend, "output-append", false, "Append the report to the existing report file instead of writing a new timestamped one (text, markdown and json formats, local destinations only). JSON reports are collected in an array, text and markdown reports are separated by a rule and a timestamp. Useful to accumulate the reports of several clusters in CI")
+	rootCmd.Flags().StringVar(&outputName, "output-name", "", "Report file name template, without extension (default "+reporter.DefaultOutputName+"). Variables: {timestamp}, {source}, {target}, {format} and {cluster} (PD cluster ID). An existing report of the same name gets a numeric suffix (e.g. report_1.json) unless --overwrite is set")
+	rootCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Overwrite an existing report of the same name instead of adding a numeric suffix to the new one")
+	rootCmd.Flags().BoolVar(&latestSymlink, "latest-symlink", false, "Point "+reporter.LatestName+".<ext> (e.g. latest.json) in the output directory at the new report of each format, so that automation finds the newest report at a stable path (a copy on platforms without symlinks, local destinations only)")
+	rootCmd.Flags().StringVar(&templateDir, "template-dir", "", "Directory of report templates (Go templates named <format>.tmpl, e.g. html.tmpl) overriding the built-in formats. Formats without a template use the built-in one")
 
 	// High-risk parameters configuration
-	rootCmd.Flags().StringVar(&highRiskParamsConfig, "high-risk-params-config", "", "Path to high-risk parameters configuration file (JSON format). If not specified, will try to load from default locations")
+	rootCmd.Flags().StringSliceVar(&highRiskParamsConfig, "high-risk-params-config", nil, "High-risk parameters configuration files (JSON format), repeated or comma-separated. They are layered over the knowledge base files, later files overriding earlier ones; an entry with \"disabled\": true suppresses an earlier one")
+
+	// Golden configuration profile
+	rootCmd.Flags().StringVar(&goldenConfig, "golden-config", "", "Path to a golden configuration profile (JSON, same per-component layout as the knowledge base). Drift from it is reported in its own section")
+
+	// Rules configuration
+	rootCmd.Flags().StringVar(&rulesConfig, "rules-config", "", `Path to a rules configuration file (JSON) setting the thresholds of the rules, e.g. {"operational_conflicts": {"gc_safe_point_max_age": "12h"}}`)
+	rootCmd.Flags().StringSliceVar(&ruleFiles, "rule-file", nil, `Declarative rule files (repeatable or comma-separated), each a JSON rule definition or an array of them, e.g. {"rule_id": "PD_LOW_REPLICAS", "component": "pd", "param_name": "replication.max-replicas", "condition": "less_than", "value": 3, "message_template": "{param} is {current}"}`)
+
+	// Configuration file
+	rootCmd.Flags().StringVar(&configFile, "config-file", "", `Precheck configuration file (YAML). ignore_patterns suppresses the findings about the matching parameters, exact names or glob patterns, e.g. ignore_patterns: ["rocksdb.*", "tidb_ddl_reorg_batch_size"]`)
+	rootCmd.Flags().BoolVar(&showIgnored, "show-ignored", false, "Report the findings suppressed by the ignore patterns of --config-file, in their own section marked as ignored")
+
+	// Severity profile
+	rootCmd.Flags().StringVar(&severityProfile, "profile", analyzer.SeverityProfileDefault, fmt.Sprintf(`Severity profile applied to the findings: %s, or a profile file (JSON), e.g. {"name": "production", "severities": {"consistency": {"warning": "error"}}}. strict promotes forced changes and TiKV inconsistencies from warning to error, lenient demotes user-modified parameters and golden config drift from warning to info`, strings.Join(analyzer.SeverityProfileNames(), ", ")))
+
+	// Rule selection
+	rootCmd.Flags().StringSliceVar(&includeRules, "include-rule", nil, fmt.Sprintf("Rules to run (repeatable or comma-separated). Default: all rules (%s)", strings.Join(catalog.IDs(), ", ")))
+	rootCmd.Flags().StringSliceVar(&excludeRules, "exclude-rule", nil, "Rules not to run (repeatable or comma-separated). The high-risk parameters and golden config checks are controlled by their own flags")
+
+	// Snapshot files
+	rootCmd.Flags().StringVar(&saveSnapshot, "save-snapshot", "", "Save the collected cluster snapshot to this file (JSON), to be given to a later run with --changed-since")
+	rootCmd.Flags().StringVar(&changedSince, "changed-since", "", "Only report findings about parameters changed since this snapshot (saved with --save-snapshot, or a baseline-validator capture). Forced changes are always reported")
+
+	// Connection validation
+	rootCmd.Flags().BoolVar(&validateConnection, "validate-connection", false, "Only check that every endpoint required by the enabled rules is reachable and the source version has knowledge, print a JSON verdict and exit (0 if the precheck can run). No configuration is collected and no report is written")
+
+	// Observability
+	rootCmd.Flags().StringVar(&otelEndpoint, "otel-endpoint", "", "OpenTelemetry OTLP/gRPC endpoint (host:port) to export traces to. Tracing is disabled if not specified")
+
+	// Performance diagnostics (developer/support tools)
+	rootCmd.Flags().StringVar(&cpuProfile, "profile-cpu", "", "Write a pprof CPU profile of collection and analysis to this file (developer/support tool)")
+	rootCmd.Flags().StringVar(&memProfile, "profile-mem", "", "Write a pprof heap profile taken after analysis to this file (developer/support tool)")
+
+	// Collection load limits (large or busy clusters)
+	rootCmd.Flags().Float64Var(&collectionRateLimit, "collection-rate-limit", common.DefaultCollectionRateLimit, "Maximum number of HTTP requests per second sent to PD and TiKV during collection (0 for no limit)")
+	rootCmd.Flags().IntVar(&collectionConcurrency, "collection-concurrency", common.DefaultCollectionConcurrency, "Maximum number of TiKV nodes collected from concurrently (0 for no limit)")
+	rootCmd.Flags().DurationVar(&sqlTimeout, "sql-timeout", tidb.DefaultSQLTimeout, "Time limit of each SQL statement issued to TiDB, so that a locked system table doesn't hang the run (0 for no limit)")
+	rootCmd.Flags().StringVar(&checkpointFile, "checkpoint-file", "", "Record each collected component and TiKV/TiFlash node in this file (JSON lines), so that an interrupted collection resumes where it stopped when run again with the same file. The file is removed once the collection completes")
+	rootCmd.Flags().DurationVar(&checkpointTTL, "checkpoint-ttl", collector.DefaultCheckpointTTL, "Maximum age of the --checkpoint-file to resume from. Older checkpoints, and checkpoints of another cluster (PD cluster ID), are refused")
+	rootCmd.Flags().BoolVar(&skipSysVars, "skip-sysvars", false, "Do not collect the TiDB system variables (SHOW GLOBAL VARIABLES, mysql.global_variables), for users without the privileges to read them. TiDB configuration is read from the status port if it is reachable, without any SQL statement. Only configuration parameters are checked")
+	rootCmd.Flags().BoolVar(&sqlOnly, "sql-only", false, "Collect through the TiDB SQL endpoint only (TiDB Cloud and other clusters where the PD, TiKV and status ports are not reachable): PD, TiKV and TiFlash configuration is read from information_schema.cluster_config and stores from information_schema.tikv_store_status. Enabled automatically when only --tidb-addr is given")
+
+	// Notification of the results (e.g., to Slack when run from automation)
+	rootCmd.Flags().StringVar(&notifyWebhook, "notify-webhook", "", "Webhook URL to POST a summary of the results to after the report is generated. Notification failures do not change the exit code")
+	rootCmd.Flags().StringVar(&notifyFormat, "notify-format", "json", "Notification body format: json (summary payload) or slack (Slack-compatible blocks)")
+	rootCmd.Flags().StringVar(&notifyOn, "notify-on", "always", "When to notify: always, on-warning or on-critical")
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Println(err)
@@ -85,77 +312,232 @@ Source and target version numbers are used as keys to locate version-specific de
 	}
 }
 
-func runPrecheck(sourceVersion, targetVersion, outputFormat, outputDir,
-	topologyFile, tidbAddr, tidbUser, tidbPassword, tikvAddrs, pdAddrs, highRiskParamsConfig string) {
-
-	// Knowledge base is fixed at ./knowledge in the tidb-upgrade-precheck directory
-	// Source and target version numbers are used as keys to locate version-specific defaults.json files
-	// Try multiple locations:
-	// 1. Environment variable TIDB_UPGRADE_PRECHECK_KNOWLEDGE_BASE
-	// 2. Relative to executable (for TiUP component installation)
-	// 3. Current working directory
-	// 4. Relative paths from executable (go up to find tidb-upgrade-precheck directory)
-	var knowledgeBasePath string
-	if envPath := os.Getenv("TIDB_UPGRADE_PRECHECK_KNOWLEDGE_BASE"); envPath != "" {
-		knowledgeBasePath = envPath
-	} else {
-		// Try multiple locations
-		candidates := []string{
-			"knowledge", // Current working directory
+// precheckOptions are the options of a precheck run, set from the command line flags
+// The serve mode only sets the options of the analysis (see analyzeCluster)
+type precheckOptions struct {
+	// Versions of the upgrade, an empty or "auto" source version is taken from the topology file or detected
+	// from the cluster. The target version is checked against the published releases if checkReleaseExists is set
+	sourceVersion      string
+	targetVersion      string
+	checkReleaseExists bool
+	// Cluster connection: a topology file, or the endpoints (comma-separated addresses for TiKV and PD)
+	topologyFile string
+	tidbAddr     string
+	tidbUser     string
+	tidbPassword string
+	tikvAddrs    string
+	pdAddrs      string
+	// Collection through the TiDB SQL endpoint only
+	sqlOnly bool
+	// Rules: the catalog rules to run (all registered rules if nil), configured from the rulesConfig file if set,
+	// the high-risk parameters files layered over the knowledge base, the golden configuration profile drift is
+	// checked against if set, and the declarative rules of ruleFiles, run after the catalog rules
+	ruleIDs              []string
+	rulesConfig          string
+	highRiskParamsConfig []string
+	goldenConfig         string
+	ruleFiles            []string
+	// PD and TiKV requests made during collection are limited by throttle, and each SQL statement by sqlTimeout
+	throttle   *common.Throttle
+	sqlTimeout time.Duration
+	// The collection resumes from the checkpoint file of an interrupted run if checkpoint has a path
+	checkpoint collector.CheckpointOptions
+	// If skipSysVars is set, the system variables are not collected and only configuration is checked
+	skipSysVars bool
+	// The collected snapshot is saved to saveSnapshot if set, and findings are restricted to the parameters
+	// changed since the changedSince snapshot if set
+	saveSnapshot string
+	changedSince string
+	// The severities of the findings are transformed by severityProfile if it is not nil, and the findings about
+	// the parameters matching ignorePatterns are suppressed (reported as ignored if showIgnored is set)
+	severityProfile *analyzer.SeverityProfile
+	ignorePatterns  []string
+	showIgnored     bool
+	// Report output (see reporter.Options)
+	outputFormat  string
+	outputDir     string
+	outputURI     string
+	outputName    string
+	templateDir   string
+	outputAppend  bool
+	overwrite     bool
+	latestSymlink bool
+	// Notification of the results
+	notify *notifyConfig
+	// Diagnostics: OpenTelemetry endpoint and pprof output files
+	otelEndpoint string
+	cpuProfile   string
+	memProfile   string
+}
+
+// runPrecheck collects the cluster, analyzes it and generates the reports
+// The run is traced as one precheck.run span. Errors are returned rather than exiting, so that the pending spans
+// are flushed on failure too
+func runPrecheck(opts *precheckOptions) (err error) {
+	// Set up tracing first so that the whole run is traced
+	// Without --otel-endpoint a no-op tracer is used
+	shutdownTracing, setupErr := tracing.Setup(context.Background(), opts.otelEndpoint)
+	if setupErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to set up tracing, continuing without it: %v\n", setupErr)
+		shutdownTracing, _ = tracing.Setup(context.Background(), "")
+	}
+	// Flush pending spans, after the root span has ended
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to flush traces: %v\n", err)
 		}
+	}()
+	ctx, span := tracing.StartSpan(context.Background(), "precheck.run", attribute.String("target_version", opts.targetVersion))
+	defer func() { tracing.EndSpan(span, err) }()
 
-		// Try relative to executable
-		if execPath, execErr := os.Executable(); execErr == nil {
-			execDir := filepath.Dir(execPath)
-			candidates = append(candidates,
-				filepath.Join(execDir, "knowledge"),                                // Same dir as executable
-				filepath.Join(execDir, "..", "knowledge"),                          // Parent dir
-				filepath.Join(execDir, "..", "tidb-upgrade-precheck", "knowledge"), // Go up to find tidb-upgrade-precheck
-			)
+	knowledgeBasePath := resolveKnowledgeBasePath()
+	fmt.Printf("[DEBUG] Using knowledge base path: %s\n", knowledgeBasePath)
+
+	// Validate the target version before connecting to the cluster
+	resolvedTargetVersion, err := resolveTargetVersion(os.Stdout, knowledgeBasePath, opts.targetVersion, opts.checkReleaseExists)
+	if err != nil {
+		return err
+	}
+	opts.targetVersion = resolvedTargetVersion
+
+	// Step 0: Load cluster connection information
+	endpoints, err := buildEndpoints(os.Stdout, opts.topologyFile, opts.tidbAddr, opts.tidbUser, opts.tidbPassword, splitAddrs(opts.tikvAddrs), splitAddrs(opts.pdAddrs))
+	if err != nil {
+		return err
+	}
+	if opts.sqlOnly && !endpoints.SQLOnly {
+		if endpoints.TiDBAddr == "" {
+			return errors.New("--sql-only requires the TiDB address (--tidb-addr or a topology file with a TiDB server)")
 		}
+		fmt.Println("SQL-only collection: PD, TiKV and TiFlash are read through TiDB's information_schema")
+		endpoints.SQLOnly = true
+	}
 
-		// Find first existing path
-		for _, candidate := range candidates {
-			if absPath, absErr := filepath.Abs(candidate); absErr == nil {
-				if _, statErr := os.Stat(absPath); statErr == nil {
-					knowledgeBasePath = absPath
-					break
-				}
-			}
+	// Load the previous snapshot to restrict the findings to
+	var previousSnapshot *types.ClusterSnapshot
+	if opts.changedSince != "" {
+		previousSnapshot, err = types.LoadClusterSnapshot(opts.changedSince)
+		if err != nil {
+			return err
 		}
+		fmt.Printf("Restricting findings to parameters changed since %s (%s)\n", opts.changedSince, previousSnapshot.Timestamp.Format(time.RFC3339))
+	}
 
-		// Final fallback
-		if knowledgeBasePath == "" {
-			if absPath, absErr := filepath.Abs("knowledge"); absErr == nil {
-				knowledgeBasePath = absPath
-			} else {
-				knowledgeBasePath = "knowledge"
-			}
+	// Profile the collection and analysis pipeline if requested
+	stopProfiling, err := startProfiling(opts.cpuProfile, opts.memProfile)
+	if err != nil {
+		return err
+	}
+	// Write the profiles of failed runs too, the analysis stops profiling itself when it succeeds
+	defer stopProfiling()
+
+	if opts.severityProfile.Name != analyzer.SeverityProfileDefault {
+		fmt.Printf("Applying severity profile %s\n", opts.severityProfile.Name)
+	}
+	if len(opts.ignorePatterns) > 0 {
+		fmt.Printf("Ignoring findings about parameters matching %s\n", strings.Join(opts.ignorePatterns, ", "))
+	}
+	analysisResult, err := analyzeCluster(ctx, knowledgeBasePath, endpoints, opts, previousSnapshot)
+	if err != nil {
+		return err
+	}
+	stopProfiling()
+
+	// Step 5: Generate report
+	fmt.Println("Generating report...")
+	generator := reporter.NewGenerator()
+	options := &reporter.Options{
+		OutputDir:     opts.outputDir,
+		OutputURI:     opts.outputURI,
+		TemplateDir:   opts.templateDir,
+		Append:        opts.outputAppend,
+		OutputName:    opts.outputName,
+		Overwrite:     opts.overwrite,
+		LatestSymlink: opts.latestSymlink,
+	}
+	var reportFormats []reporter.Format
+	for _, format := range strings.Split(opts.outputFormat, ",") {
+		if format = strings.TrimSpace(format); format != "" {
+			reportFormats = append(reportFormats, reporter.Format(format))
 		}
 	}
-	fmt.Printf("[DEBUG] Using knowledge base path: %s\n", knowledgeBasePath)
 
-	var endpoints *collector.ClusterEndpoints
-	var err error
+	reportCtx, reportSpan := tracing.StartSpan(ctx, "report.generate", attribute.String("formats", opts.outputFormat))
+	reportPaths, err := generator.GenerateFormats(reportCtx, analysisResult, reportFormats, options)
+	tracing.EndSpan(reportSpan, err)
+	if err != nil {
+		// Reports that could not be uploaded are saved locally (see reporter.FallbackError)
+		var fallbackErr *reporter.FallbackError
+		if !errors.As(err, &fallbackErr) {
+			return fmt.Errorf("failed to generate report: %w", err)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
 
-	// Step 0: Load cluster connection information
-	// Priority: topology file > individual parameters
+	// Step 6: Print summary
+	fmt.Printf("\n=== Precheck Summary ===\n")
+	fmt.Printf("Modified Parameters: %d\n", countModifiedParams(analysisResult.ModifiedParams))
+	fmt.Printf("TiKV Inconsistencies: %d\n", len(analysisResult.TikvInconsistencies))
+	fmt.Printf("Upgrade Differences: %d\n", countUpgradeDifferences(analysisResult.UpgradeDifferences))
+	fmt.Printf("Forced Changes: %d\n", countForcedChanges(analysisResult.ForcedChanges))
+	fmt.Printf("Focus Parameters: %d\n", countFocusParams(analysisResult.FocusParams))
+	fmt.Printf("Check Results: %d\n", len(analysisResult.CheckResults))
+
+	if analysisResult.MixedVersion != nil {
+		fmt.Printf("\n⚠️  WARNING: mixed-version cluster detected (%d instances checked). See report for per-node versions.\n", len(analysisResult.MixedVersion.Nodes))
+	}
+
+	// Severity breakdown per component, then the critical issues
+	fmt.Println()
+	if err := analysisResult.WriteSeveritySummary(os.Stdout, analyzer.DefaultSummaryCriticalLimit); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to print severity summary: %v\n", err)
+	}
+
+	if criticalCount := len(analysisResult.CriticalFindings()); criticalCount > 0 {
+		fmt.Printf("\n⚠️  WARNING: %d critical issue(s) found. Please review before upgrading.\n", criticalCount)
+	}
+
+	for _, reportPath := range reportPaths {
+		if reportPath == reporter.StdoutURI {
+			continue
+		}
+		fmt.Printf("\nReport generated successfully: %s\n", reportPath)
+	}
+
+	// Step 7: Notify the results
+	opts.notify.send(ctx, analysisResult, reportPaths)
+	return nil
+}
+
+// splitAddrs splits a comma-separated address list, trimming spaces and dropping empty entries
+func splitAddrs(addrs string) []string {
+	var result []string
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			result = append(result, addr)
+		}
+	}
+	return result
+}
+
+// buildEndpoints builds the cluster connection information
+// Priority: topology file > individual parameters
+// Credentials given explicitly override the topology file (passwords are not stored in topology)
+// If only the TiDB address is given, the cluster is collected through SQL only (e.g., TiDB Cloud)
+// Progress messages are written to out
+func buildEndpoints(out io.Writer, topologyFile, tidbAddr, tidbUser, tidbPassword string, tikvAddrs, pdAddrs []string) (*collector.ClusterEndpoints, error) {
+	var endpoints *collector.ClusterEndpoints
 	if topologyFile != "" {
 		// Load from topology file (TiUP/TiDB Operator format)
-		fmt.Printf("Loading topology from file: %s\n", topologyFile)
+		fmt.Fprintf(out, "Loading topology from file: %s\n", topologyFile)
+		var err error
 		endpoints, err = collector.LoadTopologyFromFile(topologyFile)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error loading topology file: %v\n", err)
-			os.Exit(1)
+			return nil, fmt.Errorf("failed to load topology file: %w", err)
 		}
-
-		// Extract source version from topology if available
-		if sourceVersion == "" && endpoints.SourceVersion != "" {
-			sourceVersion = endpoints.SourceVersion
-			fmt.Printf("Extracted source version from topology: %s\n", sourceVersion)
+		if endpoints.SourceVersion != "" {
+			fmt.Fprintf(out, "Extracted source version from topology: %s\n", endpoints.SourceVersion)
 		}
-
-		// Override credentials if provided via command line (for security, passwords are not in topology)
 		if tidbUser != "" {
 			endpoints.TiDBUser = tidbUser
 		}
@@ -163,70 +545,139 @@ func runPrecheck(sourceVersion, targetVersion, outputFormat, outputDir,
 			endpoints.TiDBPassword = tidbPassword
 		}
 	} else {
-		// Build ClusterEndpoints from individual command line arguments
 		endpoints = &collector.ClusterEndpoints{
 			TiDBAddr:     tidbAddr,
 			TiDBUser:     tidbUser,
 			TiDBPassword: tidbPassword,
+			TiKVAddrs:    tikvAddrs,
+			PDAddrs:      pdAddrs,
 		}
+	}
 
-		// Parse comma-separated addresses
-		if tikvAddrs != "" {
-			endpoints.TiKVAddrs = strings.Split(tikvAddrs, ",")
-			for i := range endpoints.TiKVAddrs {
-				endpoints.TiKVAddrs[i] = strings.TrimSpace(endpoints.TiKVAddrs[i])
-			}
-		}
+	// Validate that we have at least some connection information
+	if endpoints.TiDBAddr == "" && len(endpoints.TiKVAddrs) == 0 && len(endpoints.PDAddrs) == 0 {
+		return nil, errors.New("no cluster connection information provided, please provide either --topology-file or connection parameters (--tidb-addr, --tikv-addrs, --pd-addrs)")
+	}
+	if topologyFile == "" && endpoints.TiDBAddr != "" && len(endpoints.TiKVAddrs) == 0 && len(endpoints.PDAddrs) == 0 {
+		fmt.Fprintf(out, "Only the TiDB address is provided, using SQL-only collection (PD, TiKV and TiFlash are read through TiDB's information_schema)\n")
+		endpoints.SQLOnly = true
+	}
+	return endpoints, nil
+}
 
-		if pdAddrs != "" {
-			endpoints.PDAddrs = strings.Split(pdAddrs, ",")
-			for i := range endpoints.PDAddrs {
-				endpoints.PDAddrs[i] = strings.TrimSpace(endpoints.PDAddrs[i])
-			}
+// printPrecheckError prints an error of the precheck
+// A missing target knowledge base is followed by the nearest available versions
+func printPrecheckError(err error, targetVersion string) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	var kbErr *targetKBNotFoundError
+	if errors.As(err, &kbErr) {
+		if kbErr.listing != nil {
+			printNearestKBVersions(kbErr.listing, kbErr.version)
+		} else {
+			fmt.Fprintf(os.Stderr, "Please ensure knowledge base is generated for version %s\n", targetVersion)
 		}
 	}
+}
 
-	// Validate that we have at least some connection information
-	if endpoints.TiDBAddr == "" && len(endpoints.TiKVAddrs) == 0 && len(endpoints.PDAddrs) == 0 {
-		fmt.Fprintf(os.Stderr, "Error: No cluster connection information provided.\n")
-		fmt.Fprintf(os.Stderr, "Please provide either --topology-file or connection parameters (--tidb-addr, --tikv-addrs, --pd-addrs)\n")
-		os.Exit(1)
+// targetKBNotFoundError is returned by analyzeCluster when the knowledge base of the target version is missing
+type targetKBNotFoundError struct {
+	version string
+	path    string
+	// listing is the knowledge base listing used to suggest the nearest versions, nil if it could not be read
+	listing *collector.KBListing
+	err     error
+}
+
+func (e *targetKBNotFoundError) Error() string {
+	if e.err != nil {
+		return fmt.Sprintf("failed to load target knowledge base %s: %v", e.version, e.err)
 	}
+	return fmt.Sprintf("knowledge base for target version %s not found in %s", e.version, e.path)
+}
 
+func (e *targetKBNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// analyzeCluster collects the cluster configuration and runs all rules against the source and target knowledge bases
+// with the analysis options of opts (see precheckOptions), restricting the findings to the parameters changed since
+// previousSnapshot if it is not nil
+// It is shared by the precheck command and the serve mode
+func analyzeCluster(ctx context.Context, knowledgeBasePath string, endpoints *collector.ClusterEndpoints, opts *precheckOptions,
+	previousSnapshot *types.ClusterSnapshot) (*analyzer.AnalysisResult, error) {
 	// Step 1: Create analyzer with default rules to determine data requirements
 	fmt.Println("Initializing analyzer...")
 
-	// Build rules list
-	var rulesList []rules.Rule
+	// An invalid upgrade matrix fails the run before the cluster is collected
+	if _, err := rules.LoadUpgradeMatrix(filepath.Join(knowledgeBasePath, rules.UpgradeMatrixFile)); err != nil {
+		return nil, err
+	}
 
-	// Add default rules
-	rulesList = append(rulesList,
-		rules.NewUserModifiedParamsRule(),
-		rules.NewUpgradeDifferencesRule(),
-	)
+	// Build rules list from the catalog
+	ruleIDs := opts.ruleIDs
+	if ruleIDs == nil {
+		ruleIDs = catalog.IDs()
+	}
+	rulesList, err := catalog.Build(ruleIDs)
+	if err != nil {
+		return nil, err
+	}
+	if opts.rulesConfig != "" {
+		config, err := rules.LoadRulesConfig(opts.rulesConfig)
+		if err != nil {
+			return nil, err
+		}
+		if err := rules.ApplyRulesConfig(rulesList, config); err != nil {
+			return nil, err
+		}
+		fmt.Printf("Rules config loaded from %s\n", opts.rulesConfig)
+	}
 
-	// Add high-risk parameters rule (loads from knowledge base)
-	// Knowledge base only maintains a single file: knowledge/high_risk_params/high_risk_params.json
-	// The highRiskParamsConfig parameter is kept for backward compatibility but not used
-	manager := high_risk_params.NewManager(highRiskParamsConfig)
-	highRiskConfig, err := manager.LoadConfig()
+	// Add high-risk parameters rule, merged from the knowledge base files and the --high-risk-params-config files
+	highRiskRule, err := newHighRiskParamsRule(knowledgeBasePath, opts.targetVersion, opts.highRiskParamsConfig)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Warning: failed to load high-risk params config: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Continuing without high-risk parameters check...\n")
-	} else {
-		// Create rule with loaded config
-		highRiskRule, err := rules.NewHighRiskParamsRule(highRiskConfig)
+		return nil, err
+	}
+	rulesList = append(rulesList, highRiskRule)
+	fmt.Printf("High-risk parameters rule loaded successfully\n")
+
+	// Add golden config rule if a profile is given
+	if opts.goldenConfig != "" {
+		profile, err := rules.LoadGoldenProfile(opts.goldenConfig)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "Warning: failed to create high-risk params rule: %v\n", err)
-			fmt.Fprintf(os.Stderr, "Continuing without high-risk parameters check...\n")
-		} else {
-			rulesList = append(rulesList, highRiskRule)
-			fmt.Printf("High-risk parameters rule loaded successfully\n")
+			return nil, err
 		}
+		rulesList = append(rulesList, rules.NewGoldenConfigRule(profile))
+		fmt.Printf("Golden config profile loaded from %s\n", opts.goldenConfig)
+	}
+
+	// Add the declarative rules of the rule files, whose IDs must not clash with the other rules
+	for _, ruleFile := range opts.ruleFiles {
+		fileRules, err := rules.LoadRuleFile(ruleFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range fileRules {
+			for _, existing := range rulesList {
+				if strings.EqualFold(existing.Name(), rule.Name()) {
+					return nil, fmt.Errorf("%s: rule %s is already defined", ruleFile, rule.Name())
+				}
+			}
+			rulesList = append(rulesList, rule)
+		}
+		fmt.Printf("%d declarative rules loaded from %s\n", len(fileRules), ruleFile)
 	}
 
 	analyzerOptions := &analyzer.AnalysisOptions{
-		Rules: rulesList,
+		Rules:               rulesList,
+		KnowledgeBasePath:   knowledgeBasePath, // Used to load per-instance KBs for mixed-version clusters
+		ChangedSince:        previousSnapshot,
+		SeverityProfile:     opts.severityProfile,
+		SkipSystemVariables: opts.skipSysVars,
+		// Upgrade functions already run on the cluster are left out of the forced changes
+		BootstrapVersionQuery: bootstrapVersionQuery(ctx, endpoints, opts.sqlTimeout),
+		IgnorePatterns:        opts.ignorePatterns,
+		ShowIgnored:           opts.showIgnored,
 	}
 	analyzerInstance := analyzer.NewAnalyzer(analyzerOptions)
 
@@ -237,106 +688,159 @@ func runPrecheck(sourceVersion, targetVersion, outputFormat, outputDir,
 
 	// Step 3: Collect runtime configuration from cluster based on requirements
 	fmt.Println("Collecting cluster configuration...")
-	collectorInstance := collector.NewCollector()
+	collectorInstance := collector.NewCollectorWithThrottle(opts.throttle)
+	collectorInstance.SetSQLTimeout(opts.sqlTimeout)
+	collectorInstance.SetCheckpoint(opts.checkpoint)
 	// Convert analyzer's CollectionRequirements to collector's CollectDataRequirements
 	// (They have the same structure, so we can convert directly)
 	collectReq := collector.CollectDataRequirements{
-		Components:          analyzerCollectReq.Components,
-		NeedConfig:          analyzerCollectReq.NeedConfig,
-		NeedSystemVariables: analyzerCollectReq.NeedSystemVariables,
-		NeedAllTikvNodes:    analyzerCollectReq.NeedAllTikvNodes,
+		Components:               analyzerCollectReq.Components,
+		NeedConfig:               analyzerCollectReq.NeedConfig,
+		NeedSystemVariables:      analyzerCollectReq.NeedSystemVariables,
+		NeedAllTikvNodes:         analyzerCollectReq.NeedAllTikvNodes,
+		NeedGlobalVariablesTable: analyzerCollectReq.NeedGlobalVariablesTable,
+		NeedGCSafePoints:         analyzerCollectReq.NeedGCSafePoints,
+		NeedPlacement:            analyzerCollectReq.NeedPlacement,
+		NeedStores:               analyzerCollectReq.NeedStores,
 	}
-	snapshot, err := collectorInstance.Collect(*endpoints, &collectReq)
+	snapshot, err := collectorInstance.Collect(ctx, *endpoints, &collectReq)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error collecting cluster configuration: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to collect cluster configuration: %w", err)
 	}
 
 	if snapshot == nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to collect cluster snapshot\n")
-		os.Exit(1)
+		return nil, errors.New("failed to collect cluster snapshot")
 	}
 
 	// Set target version
-	snapshot.TargetVersion = targetVersion
+	snapshot.TargetVersion = opts.targetVersion
 
 	// Determine source version: priority: user input > topology file > cluster detection
+	sourceVersion := explicitSourceVersion(opts.sourceVersion)
 	if sourceVersion != "" {
 		// Use user-provided source version (highest priority)
 		snapshot.SourceVersion = sourceVersion
 		fmt.Printf("Using provided source version: %s\n", sourceVersion)
+	} else if endpoints.SourceVersion != "" {
+		// Use version from topology file
+		snapshot.SourceVersion = endpoints.SourceVersion
+		fmt.Printf("Using source version from topology: %s\n", snapshot.SourceVersion)
 	} else if snapshot.SourceVersion != "" {
-		// Use version detected from cluster (from topology file or runtime detection)
+		// Use version detected from cluster
 		fmt.Printf("Detected source version from cluster: %s\n", snapshot.SourceVersion)
+	} else if version, err := detectSourceVersion(ctx, endpoints, opts.sqlTimeout); version != "" {
+		// The collection did not report a version (e.g., TiDB was not needed by the enabled rules), ask TiDB
+		snapshot.SourceVersion = version
+		fmt.Printf("Detected source version from TiDB: %s\n", version)
+	} else if err != nil {
+		return nil, fmt.Errorf("could not determine source version, please provide --source-version or ensure topology file contains version: %w", err)
 	} else {
 		// Neither user input, topology file, nor cluster detection provided a version
-		fmt.Fprintf(os.Stderr, "Error: could not determine source version.\n")
-		fmt.Fprintf(os.Stderr, "Please provide --source-version, ensure topology file contains version, or ensure cluster connection is working.\n")
-		os.Exit(1)
+		return nil, errors.New("could not determine source version, please provide --source-version, ensure topology file contains version, or ensure cluster connection is working")
 	}
 
-	fmt.Printf("Cluster version: %s -> Target version: %s\n", snapshot.SourceVersion, targetVersion)
+	fmt.Printf("Cluster version: %s -> Target version: %s\n", snapshot.SourceVersion, opts.targetVersion)
+
+	if opts.saveSnapshot != "" {
+		if err := types.SaveClusterSnapshot(snapshot, opts.saveSnapshot); err != nil {
+			return nil, fmt.Errorf("failed to save cluster snapshot: %w", err)
+		}
+		fmt.Printf("Cluster snapshot saved to %s\n", opts.saveSnapshot)
+	}
 
 	// Step 4: Load knowledge base for source and target versions based on requirements
 	fmt.Println("Loading knowledge base...")
-	sourceKB, err := collector.LoadKnowledgeBase(knowledgeBasePath, snapshot.SourceVersion)
+	sourceKB, err := loadKnowledgeBase(ctx, knowledgeBasePath, snapshot.SourceVersion)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to load source knowledge base: %v\n", err)
 		sourceKB = make(map[string]interface{})
 	}
 
-	targetKB, err := collector.LoadKnowledgeBase(knowledgeBasePath, targetVersion)
+	// A missing version directory does not fail loading, it just yields an empty KB,
+	// so check the listing first to point the user to the versions that are available
+	listing, listErr := collector.ListKnowledgeBase(knowledgeBasePath)
+	if listErr != nil {
+		listing = nil
+	}
+	if listing != nil && !listing.HasVersion(opts.targetVersion) {
+		return nil, &targetKBNotFoundError{version: opts.targetVersion, path: knowledgeBasePath, listing: listing}
+	}
+	targetKB, err := loadKnowledgeBase(ctx, knowledgeBasePath, opts.targetVersion)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: failed to load target knowledge base: %v\n", err)
-		fmt.Fprintf(os.Stderr, "Please ensure knowledge base is generated for version %s\n", targetVersion)
-		os.Exit(1)
+		return nil, &targetKBNotFoundError{version: opts.targetVersion, path: knowledgeBasePath, listing: listing, err: err}
 	}
 
 	// Step 5: Run analysis using rules
 	fmt.Println("Running compatibility checks...")
-	ctx := context.Background()
-	analysisResult, err := analyzerInstance.Analyze(ctx, snapshot, snapshot.SourceVersion, targetVersion, sourceKB, targetKB)
+	analysisResult, err := analyzerInstance.Analyze(ctx, snapshot, snapshot.SourceVersion, opts.targetVersion, sourceKB, targetKB)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error running analysis: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("failed to run analysis: %w", err)
 	}
+	return analysisResult, nil
+}
 
-	// Step 5: Generate report
-	fmt.Println("Generating report...")
-	generator := reporter.NewGenerator()
-	options := &reporter.Options{
-		Format:    reporter.Format(outputFormat),
-		OutputDir: outputDir,
+// resolveKnowledgeBasePath locates the knowledge base directory
+// Knowledge base is fixed at ./knowledge in the tidb-upgrade-precheck directory
+// Source and target version numbers are used as keys to locate version-specific defaults.json files
+// Try multiple locations:
+// 1. Environment variable TIDB_UPGRADE_PRECHECK_KNOWLEDGE_BASE
+// 2. Relative to executable (for TiUP component installation)
+// 3. Current working directory
+// 4. Relative paths from executable (go up to find tidb-upgrade-precheck directory)
+func resolveKnowledgeBasePath() string {
+	if envPath := os.Getenv("TIDB_UPGRADE_PRECHECK_KNOWLEDGE_BASE"); envPath != "" {
+		return envPath
 	}
 
-	reportPath, err := generator.GenerateFromAnalysisResult(analysisResult, options)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error generating report: %v\n", err)
-		os.Exit(1)
+	// Try multiple locations
+	candidates := []string{
+		"knowledge", // Current working directory
 	}
 
-	// Step 6: Print summary
-	fmt.Printf("\n=== Precheck Summary ===\n")
-	fmt.Printf("Modified Parameters: %d\n", countModifiedParams(analysisResult.ModifiedParams))
-	fmt.Printf("TiKV Inconsistencies: %d\n", len(analysisResult.TikvInconsistencies))
-	fmt.Printf("Upgrade Differences: %d\n", countUpgradeDifferences(analysisResult.UpgradeDifferences))
-	fmt.Printf("Forced Changes: %d\n", countForcedChanges(analysisResult.ForcedChanges))
-	fmt.Printf("Focus Parameters: %d\n", countFocusParams(analysisResult.FocusParams))
-	fmt.Printf("Check Results: %d\n", len(analysisResult.CheckResults))
+	// Try relative to executable
+	if execDir, execErr := executableDir(); execErr == nil {
+		candidates = append(candidates,
+			filepath.Join(execDir, "knowledge"),                                // Same dir as executable
+			filepath.Join(execDir, "..", "knowledge"),                          // Parent dir
+			filepath.Join(execDir, "..", "tidb-upgrade-precheck", "knowledge"), // Go up to find tidb-upgrade-precheck
+		)
+	}
 
-	// Count critical issues
-	criticalCount := 0
-	for _, check := range analysisResult.CheckResults {
-		if check.Severity == "critical" || check.Severity == "error" {
-			criticalCount++
+	// Find first existing path
+	for _, candidate := range candidates {
+		if absPath, absErr := filepath.Abs(candidate); absErr == nil {
+			if _, statErr := os.Stat(absPath); statErr == nil {
+				return absPath
+			}
 		}
 	}
 
-	if criticalCount > 0 {
-		fmt.Printf("\n⚠️  WARNING: %d critical issue(s) found. Please review before upgrading.\n", criticalCount)
+	// Final fallback
+	if absPath, absErr := filepath.Abs("knowledge"); absErr == nil {
+		return absPath
+	}
+	return "knowledge"
+}
+
+// executableDir returns the directory of the running executable, symlinks resolved
+// TiUP, Homebrew and /usr/local/bin installations start the binary through a symlink;
+// the knowledge base is installed next to the real binary
+func executableDir() (string, error) {
+	execPath, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(execPath); err == nil {
+		execPath = resolved
 	}
+	return filepath.Dir(execPath), nil
+}
 
-	fmt.Printf("\nReport generated successfully: %s\n", reportPath)
+// loadKnowledgeBase loads the knowledge base of a version, traced as a span
+func loadKnowledgeBase(ctx context.Context, knowledgeBasePath, version string) (kb map[string]interface{}, err error) {
+	_, span := tracing.StartSpan(ctx, "knowledge_base.load", attribute.String("version", version))
+	defer func() { tracing.EndSpan(span, err) }()
+	return collector.LoadKnowledgeBase(knowledgeBasePath, version)
 }
 
 // Helper functions for summary
@@ -371,3 +875,29 @@ func countFocusParams(focusParams map[string]map[string]analyzer.FocusParamInfo)
 	}
 	return count
 }
+
+// validateOutputName checks --output-name and --latest-symlink before the cluster is collected
+func validateOutputName(outputName, outputURI string, latestSymlink bool) error {
+	if outputName != "" {
+		if err := reporter.ValidateOutputName(outputName); err != nil {
+			return fmt.Errorf("--output-name: %w", err)
+		}
+	}
+	if latestSymlink && (outputURI == reporter.StdoutURI || strings.HasPrefix(outputURI, "s3://")) {
+		return fmt.Errorf("--latest-symlink requires a local output destination")
+	}
+	return nil
+}
+
+// validateOutputAppend checks that the reports can be appended to (see --output-append)
+func validateOutputAppend(outputFormat, outputURI string) error {
+	if outputURI == reporter.StdoutURI || strings.HasPrefix(outputURI, "s3://") {
+		return fmt.Errorf("--output-append requires a local output destination")
+	}
+	for _, format := range strings.Split(outputFormat, ",") {
+		if format = strings.TrimSpace(format); format != "" && !reporter.CanAppend(reporter.Format(format)) {
+			return fmt.Errorf("--output-append does not support the %s format", format)
+		}
+	}
+	return nil
+}
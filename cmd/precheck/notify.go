@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/notifier"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter"
+)
+
+// notifyConfig holds the notification options of a precheck run
+type notifyConfig struct {
+	notifier  notifier.Notifier
+	threshold notifier.Threshold
+}
+
+// newNotifyConfig validates the --notify-* flags
+// Returns nil if no webhook is configured
+func newNotifyConfig(webhook, format, on string) (*notifyConfig, error) {
+	if webhook == "" {
+		return nil, nil
+	}
+	notifyFormat, err := notifier.ParseFormat(format)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := notifier.ParseThreshold(on)
+	if err != nil {
+		return nil, err
+	}
+	return &notifyConfig{
+		notifier:  notifier.NewWebhookNotifier(webhook, notifyFormat),
+		threshold: threshold,
+	}, nil
+}
+
+// send notifies the result of the run if it reaches the threshold
+// Failures are only logged: notifications must not change the outcome of the precheck
+func (c *notifyConfig) send(ctx context.Context, result *analyzer.AnalysisResult, reportPaths []string) {
+	if c == nil {
+		return
+	}
+	var reports []string
+	for _, reportPath := range reportPaths {
+		if reportPath != reporter.StdoutURI {
+			reports = append(reports, reportPath)
+		}
+	}
+
+	payload := notifier.NewPayload(result, reports, 0)
+	if !c.threshold.ShouldNotify(payload) {
+		fmt.Printf("Notification skipped (status %s, threshold %s)\n", payload.Status, c.threshold)
+		return
+	}
+	if err := c.notifier.Notify(ctx, payload); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to send notification: %v\n", err)
+		return
+	}
+	fmt.Println("Notification sent")
+}
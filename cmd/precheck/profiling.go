@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"sync"
+)
+
+// startProfiling starts CPU profiling to cpuProfile if it is set
+// The returned stop function stops CPU profiling and writes a heap profile to memProfile if it is set
+// Only its first call has an effect, so that it can also be deferred for the runs that fail
+// Both flags are developer/support tools for diagnosing slow prechecks on very large clusters
+func startProfiling(cpuProfile, memProfile string) (func(), error) {
+	var cpuFile *os.File
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create CPU profile file: %w", err)
+		}
+		if err := pprof.StartCPUProfile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to start CPU profile: %w", err)
+		}
+		cpuFile = f
+	}
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() {
+			if cpuFile != nil {
+				pprof.StopCPUProfile()
+				cpuFile.Close()
+				fmt.Printf("CPU profile written to %s\n", cpuProfile)
+			}
+
+			if memProfile != "" {
+				if err := writeHeapProfile(memProfile); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+				} else {
+					fmt.Printf("Memory profile written to %s\n", memProfile)
+				}
+			}
+		})
+	}
+	return stop, nil
+}
+
+// writeHeapProfile writes the current heap profile to path
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create memory profile file: %w", err)
+	}
+	defer f.Close()
+
+	// Get up-to-date statistics of live objects
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		return fmt.Errorf("failed to write memory profile: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/spf13/cobra"
+)
+
+// newResultDiffCommand creates the "diff" subcommand that compares the findings of two JSON reports
+func newResultDiffCommand() *cobra.Command {
+	var (
+		jsonOutput       bool
+		failOnRegression bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "diff <before.json> <after.json>",
+		Short: "Compare the findings of two JSON reports of the same cluster",
+		Long: `Compare the findings of two JSON reports (--format json) of the same cluster, e.g. before and
+after a config change, and list the new, resolved and unchanged findings.
+
+Findings are matched by component, rule and parameter. A report appended to with --output-append
+(a JSON array of reports) is compared by its last report.
+
+With --fail-on-regression the command exits with a non-zero status if the after report has new
+critical or error findings, so that CI can check that a change did not make the upgrade riskier.`,
+		Args:         cobra.ExactArgs(2),
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			before, err := loadJSONReport(args[0])
+			if err != nil {
+				return err
+			}
+			after, err := loadJSONReport(args[1])
+			if err != nil {
+				return err
+			}
+
+			comparison := analyzer.CompareAnalysisResults(before, after)
+			if jsonOutput {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(comparison); err != nil {
+					return err
+				}
+			} else if err := printResultComparison(cmd.OutOrStdout(), comparison); err != nil {
+				return err
+			}
+
+			if failOnRegression && comparison.HasRegression() {
+				return fmt.Errorf("%s has new critical or error findings", args[1])
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Print the comparison as JSON")
+	cmd.Flags().BoolVar(&failOnRegression, "fail-on-regression", false, "Exit with a non-zero status if the after report has new critical or error findings")
+	return cmd
+}
+
+// loadJSONReport reads a JSON report, the last one of an appended report
+func loadJSONReport(path string) (*analyzer.AnalysisResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read report %s: %w", path, err)
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var reports []json.RawMessage
+		if err := json.Unmarshal(data, &reports); err != nil {
+			return nil, fmt.Errorf("report %s is not a valid JSON report: %w", path, err)
+		}
+		if len(reports) == 0 {
+			return nil, fmt.Errorf("report %s is an empty JSON array", path)
+		}
+		data = reports[len(reports)-1]
+	}
+	var result analyzer.AnalysisResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("report %s is not a valid JSON report: %w", path, err)
+	}
+	return &result, nil
+}
+
+// printResultComparison prints the new and resolved findings as tables, and the count of unchanged findings
+func printResultComparison(out io.Writer, comparison *analyzer.ResultComparison) error {
+	fmt.Fprintf(out, "Before: %s, after: %s\n", comparison.BeforeVersions, comparison.AfterVersions)
+	fmt.Fprintf(out, "%d new, %d resolved, %d unchanged findings\n", len(comparison.New), len(comparison.Resolved), len(comparison.Unchanged))
+
+	for _, section := range []struct {
+		title  string
+		checks []rules.CheckResult
+	}{
+		{"New findings", comparison.New},
+		{"Resolved findings", comparison.Resolved},
+	} {
+		if len(section.checks) == 0 {
+			continue
+		}
+		fmt.Fprintf(out, "\n%s:\n", section.title)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SEVERITY\tCOMPONENT\tRULE\tPARAMETER")
+		for _, check := range section.checks {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", check.Severity, check.Component, check.RuleID, check.ParameterName)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/spf13/cobra"
+)
+
+// newSchemaCommand creates the "schema" command that prints the JSON Schema of the JSON report
+func newSchemaCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print the JSON Schema of the JSON report",
+		Long: `Print the JSON Schema (draft-07) describing the report produced by --format json,
+so that downstream tools can validate it. The schema is embedded in the binary.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			_, err := cmd.OutOrStdout().Write(analyzer.AnalysisResultSchema())
+			return err
+		},
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/selftest"
+	"github.com/spf13/cobra"
+)
+
+// newSelfTestCommand creates the "self-test" subcommand that checks the installation offline
+func newSelfTestCommand() *cobra.Command {
+	var (
+		knowledgePath string
+		outputDir     string
+		verbose       bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "self-test",
+		Short: "Check that the precheck installation works, without a cluster",
+		Long: fmt.Sprintf(`Run the whole precheck pipeline on fixtures embedded in the binary (a cluster snapshot and
+a small %s -> %s knowledge base), without connecting to any cluster.
+
+Checks that the analysis of the fixtures gives the expected findings, that every report format
+can be generated, and that the installed knowledge directory can be loaded.
+Prints one PASS/FAIL line per check and exits with a non-zero status if any check failed.`,
+			selftest.FixtureSourceVersion, selftest.FixtureTargetVersion),
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if knowledgePath == "" {
+				knowledgePath = resolveKnowledgeBasePath()
+			}
+			opts := selftest.Options{KnowledgeBasePath: knowledgePath, OutputDir: outputDir}
+
+			var checks []selftest.Check
+			if verbose {
+				checks = selftest.Run(context.Background(), opts)
+			} else {
+				// The analyzer logs its progress to stdout, which would bury the results
+				err := withSilencedStdout(func() {
+					checks = selftest.Run(context.Background(), opts)
+				})
+				if err != nil {
+					return err
+				}
+			}
+
+			printSelfTestChecks(cmd.OutOrStdout(), checks)
+			if !selftest.Passed(checks) {
+				return fmt.Errorf("self-test failed")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&knowledgePath, "knowledge-path", "", "Knowledge base directory to verify. If not specified, the default knowledge base location is used")
+	cmd.Flags().StringVar(&outputDir, "output-dir", "", "Directory to keep the generated reports in. If not specified, they are generated in a temporary directory and removed")
+	cmd.Flags().BoolVar(&verbose, "verbose", false, "Show the analyzer output")
+	return cmd
+}
+
+// withSilencedStdout runs fn with os.Stdout redirected to the null device
+func withSilencedStdout(fn func()) error {
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", os.DevNull, err)
+	}
+	defer devNull.Close()
+
+	stdout := os.Stdout
+	os.Stdout = devNull
+	defer func() { os.Stdout = stdout }()
+	fn()
+	return nil
+}
+
+// printSelfTestChecks prints one line per self-test check
+func printSelfTestChecks(out io.Writer, checks []selftest.Check) {
+	for _, check := range checks {
+		status := "PASS"
+		if !check.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(out, "%s  %s (%s)", status, check.Name, check.Duration.Round(time.Millisecond))
+		if check.Message != "" {
+			fmt.Fprintf(out, "  %s", check.Message)
+		}
+		fmt.Fprintln(out)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/api"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	"github.com/spf13/cobra"
+)
+
+// defaultServeAddr is the default listen address of the serve mode (loopback only)
+const defaultServeAddr = "127.0.0.1:8089"
+
+// newServeCommand creates the "serve" subcommand, which exposes precheck over the REST API in pkg/api
+// The routes are documented in pkg/api/openapi.json (also served under /api/v1/openapi.json)
+func newServeCommand() *cobra.Command {
+	var listenAddr string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve precheck over a REST API (used by TiUP cluster check)",
+		Long: `Serve precheck over a REST API.
+
+POST /api/v1/checks runs a precheck and returns a findings summary (per-severity and per-component
+counts, and the top critical findings with remediation). The full analysis result of a run is
+available under /api/v1/checks/{id}/result. The API is documented in /api/v1/openapi.json.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(listenAddr)
+		},
+	}
+	cmd.Flags().StringVar(&listenAddr, "listen", defaultServeAddr, "Address to listen on (host:port)")
+	return cmd
+}
+
+func runServe(listenAddr string) error {
+	knowledgeBasePath := resolveKnowledgeBasePath()
+	fmt.Printf("[DEBUG] Using knowledge base path: %s\n", knowledgeBasePath)
+
+	server := api.NewServer(func(ctx context.Context, req api.CheckRequest) (*analyzer.AnalysisResult, error) {
+		endpoints, err := buildEndpoints(os.Stdout, req.TopologyFile, req.TiDBAddr, req.TiDBUser, req.TiDBPassword, req.TiKVAddrs, req.PDAddrs)
+		if err != nil {
+			return nil, err
+		}
+		targetVersion, err := resolveTargetVersion(os.Stdout, knowledgeBasePath, req.TargetVersion, false)
+		if err != nil {
+			return nil, err
+		}
+		return analyzeCluster(ctx, knowledgeBasePath, endpoints, &precheckOptions{
+			sourceVersion:        req.SourceVersion,
+			targetVersion:        targetVersion,
+			highRiskParamsConfig: splitAddrs(req.HighRiskParamsConfig),
+			goldenConfig:         req.GoldenConfig,
+			rulesConfig:          req.RulesConfig,
+			// Every check gets its own throttle with the default limits
+			throttle:   common.NewDefaultThrottle(),
+			sqlTimeout: tidb.DefaultSQLTimeout,
+		}, nil)
+	})
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+
+	httpServer := &http.Server{
+		Addr:              listenAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	// Shut down gracefully on SIGINT/SIGTERM, letting running checks finish
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		httpServer.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("Serving precheck API on http://%s (OpenAPI document: /api/v1/openapi.json)\n", listenAddr)
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
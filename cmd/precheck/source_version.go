@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+)
+
+// autoSourceVersion is the --source-version value (and default) asking for the source version to be
+// taken from the topology file or detected from the cluster
+const autoSourceVersion = "auto"
+
+// explicitSourceVersion returns the source version given by the user, or an empty string for "auto"
+func explicitSourceVersion(sourceVersion string) string {
+	if sourceVersion == autoSourceVersion {
+		return ""
+	}
+	return sourceVersion
+}
+
+// detectSourceVersion queries TiDB for its release version (e.g., "v7.5.0"), each statement limited to sqlTimeout
+// Returns an empty version without error if no TiDB endpoint is configured
+func detectSourceVersion(ctx context.Context, endpoints *collector.ClusterEndpoints, sqlTimeout time.Duration) (string, error) {
+	if endpoints.TiDBAddr == "" {
+		return "", nil
+	}
+	return tidb.NewTiDBCollectorWithSQLTimeout(sqlTimeout).DetectVersion(ctx, endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+}
+
+// bootstrapVersionQuery returns the query of the bootstrap version the cluster is at (tidb_server_version),
+// each statement limited to sqlTimeout. Returns nil if no TiDB endpoint is configured
+func bootstrapVersionQuery(ctx context.Context, endpoints *collector.ClusterEndpoints, sqlTimeout time.Duration) func() (int64, error) {
+	if endpoints.TiDBAddr == "" {
+		return nil
+	}
+	return func() (int64, error) {
+		return tidb.NewTiDBCollectorWithSQLTimeout(sqlTimeout).QueryBootstrapVersion(ctx, endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// resolveTargetVersion validates the target version given by the user and resolves it against the knowledge base
+// Short forms are accepted: "8.5.1" is normalized to "v8.5.1", and a version group ("8.5" or "v8.5") is expanded
+// to the latest patch version of the group in the knowledge base, with a note written to w
+// A version missing from the knowledge base is reported as a *targetKBNotFoundError, before the cluster is collected
+// If checkReleaseExists is set, the version must also be listed in the published releases of the knowledge base
+func resolveTargetVersion(w io.Writer, knowledgeBasePath, raw string, checkReleaseExists bool) (string, error) {
+	version, isGroup, err := types.ParseUserVersion(raw)
+	if err != nil {
+		return "", fmt.Errorf("--target-version: %w", err)
+	}
+
+	if checkReleaseExists && !isGroup {
+		releases, err := collector.LoadPublishedReleases(knowledgeBasePath)
+		if err != nil {
+			return "", fmt.Errorf("--check-release-exists: %w", err)
+		}
+		if !containsVersion(releases, version) {
+			return "", fmt.Errorf("target version %s is not a published TiDB release (closest releases: %s)",
+				version, strings.Join(collector.ClosestVersions(releases, version, nearestKBVersionCount), ", "))
+		}
+	}
+
+	// Without a readable knowledge base, the version is checked when the knowledge base is loaded
+	listing, err := collector.ListKnowledgeBase(knowledgeBasePath)
+	if err != nil {
+		if isGroup {
+			return "", fmt.Errorf("cannot expand target version group %s: %w", version, err)
+		}
+		return version, nil
+	}
+
+	if isGroup {
+		latest := listing.LatestInGroup(version)
+		if latest == "" {
+			return "", &targetKBNotFoundError{version: version, path: knowledgeBasePath, listing: listing}
+		}
+		fmt.Fprintf(w, "Note: target version %s expanded to %s, the latest patch version of %s in the knowledge base\n", raw, latest, version)
+		return latest, nil
+	}
+	if !listing.HasVersion(version) {
+		return "", &targetKBNotFoundError{version: version, path: knowledgeBasePath, listing: listing}
+	}
+	return version, nil
+}
+
+// containsVersion checks if a list of versions contains a version
+func containsVersion(versions []string, version string) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
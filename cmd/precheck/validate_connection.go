@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules/catalog"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+)
+
+// validateConnectionDeadline bounds the whole --validate-connection run, so that TiUP can call it
+// before scheduling the precheck without waiting on unreachable hosts
+const validateConnectionDeadline = 10 * time.Second
+
+// runValidateConnection checks that the precheck can run against the cluster, without collecting any configuration
+// The JSON verdict (see collector.PreflightResult) is written to out, progress messages to stderr
+// ruleIDs are the enabled catalog rules, whose requirements decide which endpoints are required
+// Returns the exit code: 0 if every required endpoint is reachable and the source version has knowledge
+func runValidateConnection(out io.Writer, sourceVersion, topologyFile, tidbAddr, tidbUser, tidbPassword, tikvAddrs, pdAddrs string, ruleIDs []string) int {
+	endpoints, err := buildEndpoints(os.Stderr, topologyFile, tidbAddr, tidbUser, tidbPassword, splitAddrs(tikvAddrs), splitAddrs(pdAddrs))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	if ruleIDs == nil {
+		ruleIDs = catalog.IDs()
+	}
+	rulesList, err := catalog.Build(ruleIDs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	requirements := analyzer.NewAnalyzer(&analyzer.AnalysisOptions{Rules: rulesList}).GetCollectionRequirements()
+
+	ctx, cancel := context.WithTimeout(context.Background(), validateConnectionDeadline)
+	defer cancel()
+	result := collector.ValidateConnection(ctx, *endpoints, collector.PreflightOptions{
+		Components:        requirements.Components,
+		SourceVersion:     sourceVersion,
+		KnowledgeBasePath: resolveKnowledgeBasePath(),
+	})
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: failed to marshal verdict: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(out, string(data))
+	if !result.OK {
+		return 1
+	}
+	return 0
+}
@@ -5,23 +5,48 @@ package analyzer
 import (
 	"context"
 	"fmt"
-	"strconv"
 	"strings"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules/catalog"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/tracing"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // AnalysisOptions contains options for analysis
 type AnalysisOptions struct {
 	// Rules is the list of rules to apply. If empty, default rules will be used
 	Rules []rules.Rule `json:"rules,omitempty"`
+	// KnowledgeBasePath is the knowledge base directory
+	// It is used to load additional versions' knowledge base for mixed-version clusters
+	KnowledgeBasePath string `json:"knowledge_base_path,omitempty"`
+	// ChangedSince is a previous snapshot of the cluster (see --changed-since)
+	// If set, findings are restricted to the parameters that changed since then (see rules.ChangedParameters)
+	ChangedSince *collector.ClusterSnapshot `json:"-"`
+	// SeverityProfile transforms the severity of the results after deduplication (see --profile)
+	// If nil, the severities set by the rules are kept
+	SeverityProfile *SeverityProfile `json:"-"`
+	// SkipSystemVariables disables the collection of system variables (see --skip-sysvars)
+	// Rules are then evaluated on configuration only, see rules.RuleContext.SystemVariablesUnavailable
+	SkipSystemVariables bool `json:"skip_system_variables,omitempty"`
+	// BootstrapVersionQuery queries the bootstrap version the running cluster is at
+	// If nil, upgrade logic is filtered with the bootstrap version of the source KB (see rules.RuleContext.BootstrapVersionQuery)
+	BootstrapVersionQuery func() (int64, error) `json:"-"`
+	// IgnorePatterns suppress the findings about the matching parameters, intentionally customized by the operator
+	// A pattern is an exact parameter name (e.g., tidb_ddl_reorg_batch_size) or a glob pattern (e.g., rocksdb.*)
+	IgnorePatterns []string `json:"ignore_patterns,omitempty"`
+	// ShowIgnored keeps the suppressed findings in AnalysisResult.IgnoredResults, marked as ignored (see --show-ignored)
+	ShowIgnored bool `json:"show_ignored,omitempty"`
 }
 
 // Analyzer performs comprehensive risk analysis on cluster snapshots based on rules
 type Analyzer struct {
 	options *AnalysisOptions
 	rules   []rules.Rule
+	// kbCache caches knowledge bases loaded per version
+	kbCache map[string]map[string]interface{}
 }
 
 // NewAnalyzer creates a new analyzer with the provided rules
@@ -40,6 +65,7 @@ func NewAnalyzer(options *AnalysisOptions) *Analyzer {
 	return &Analyzer{
 		options: options,
 		rules:   ruleList,
+		kbCache: make(map[string]map[string]interface{}),
 	}
 }
 
@@ -55,11 +81,20 @@ func (a *Analyzer) GetDataRequirements() rules.DataSourceRequirement {
 // Returns a struct compatible with collector/runtime.CollectDataRequirements
 func (a *Analyzer) GetCollectionRequirements() CollectionRequirements {
 	dataReqs := a.collectDataRequirements()
+	if a.options.SkipSystemVariables {
+		// mysql.global_variables holds the system variables too
+		dataReqs.SourceClusterRequirements.NeedSystemVariables = false
+		dataReqs.SourceClusterRequirements.NeedGlobalVariablesTable = false
+	}
 	return CollectionRequirements{
-		Components:          dataReqs.SourceClusterRequirements.Components,
-		NeedConfig:          dataReqs.SourceClusterRequirements.NeedConfig,
-		NeedSystemVariables: dataReqs.SourceClusterRequirements.NeedSystemVariables,
-		NeedAllTikvNodes:    dataReqs.SourceClusterRequirements.NeedAllTikvNodes,
+		Components:               dataReqs.SourceClusterRequirements.Components,
+		NeedConfig:               dataReqs.SourceClusterRequirements.NeedConfig,
+		NeedSystemVariables:      dataReqs.SourceClusterRequirements.NeedSystemVariables,
+		NeedAllTikvNodes:         dataReqs.SourceClusterRequirements.NeedAllTikvNodes,
+		NeedGlobalVariablesTable: dataReqs.SourceClusterRequirements.NeedGlobalVariablesTable,
+		NeedGCSafePoints:         dataReqs.SourceClusterRequirements.NeedGCSafePoints,
+		NeedPlacement:            dataReqs.SourceClusterRequirements.NeedPlacement,
+		NeedStores:               dataReqs.SourceClusterRequirements.NeedStores,
 	}
 }
 
@@ -75,15 +110,19 @@ type CollectionRequirements struct {
 	NeedSystemVariables bool `json:"need_system_variables"`
 	// NeedAllTikvNodes indicates if all TiKV nodes' data is needed (for consistency checks)
 	NeedAllTikvNodes bool `json:"need_all_tikv_nodes"`
+	// NeedGlobalVariablesTable indicates if the rows of the mysql.global_variables table are needed
+	NeedGlobalVariablesTable bool `json:"need_global_variables_table"`
+	// NeedGCSafePoints indicates if the GC safepoint and the PD service safepoints are needed
+	NeedGCSafePoints bool `json:"need_gc_safe_points"`
+	// NeedPlacement indicates if the resource groups, placement policies and PD store labels are needed
+	NeedPlacement bool `json:"need_placement"`
+	// NeedStores indicates if the TiKV stores registered in PD, with their versions and states, are needed
+	NeedStores bool `json:"need_stores"`
 }
 
-// getDefaultRules returns the default set of rules
+// getDefaultRules returns the default set of rules: all the rules registered in the catalog
 func getDefaultRules() []rules.Rule {
-	return []rules.Rule{
-		rules.NewUserModifiedParamsRule(),
-		rules.NewUpgradeDifferencesRule(),
-		rules.NewTikvConsistencyRule(),
-	}
+	return catalog.BuildAll()
 }
 
 // Analyze performs comprehensive analysis on a cluster snapshot based on rules
@@ -102,6 +141,12 @@ func (a *Analyzer) Analyze(
 		return nil, fmt.Errorf("snapshot cannot be nil")
 	}
 
+	ctx, span := tracing.StartSpan(ctx, "analyzer.Analyze",
+		attribute.String("source_version", sourceVersion),
+		attribute.String("target_version", targetVersion),
+	)
+	defer span.End()
+
 	// Step 1: Collect data requirements from all rules
 	// Merge requirements from all rules to determine what data needs to be loaded
 	dataReqs := a.collectDataRequirements()
@@ -116,18 +161,57 @@ func (a *Analyzer) Analyze(
 	// This ensures source KB defaults and runtime parameters are properly matched
 	componentMapping := a.buildComponentMapping(snapshot, sourceDefaults)
 
+	// Step 2.2: Detect mixed-version clusters (e.g., a partially completed previous upgrade)
+	// For components whose instance runs a different version, use that version's defaults as source defaults
+	// The provided source and target KBs are cached so they are not loaded again
+	if v := types.NormalizeVersion(sourceVersion); v != "" {
+		a.kbCache[v] = sourceKB
+	}
+	if v := types.NormalizeVersion(targetVersion); v != "" {
+		a.kbCache[v] = targetKB
+	}
+	mixedVersion := detectMixedVersions(snapshot, sourceVersion)
+	var componentSourceVersions map[string]string
+	var instanceSourceDefaults map[string]map[string]map[string]interface{}
+	if mixedVersion != nil {
+		fmt.Printf("[WARNING Analyzer] Mixed-version cluster detected (cluster source version: %s)\n", sourceVersion)
+		instanceSourceDefaults, componentSourceVersions = a.applyInstanceVersionDefaults(snapshot, componentMapping, sourceVersion, sourceDefaults, dataReqs)
+		if len(componentSourceVersions) > 0 {
+			mixedVersion.ComponentSourceVersions = componentSourceVersions
+		}
+		mixedVersion.ComponentInstanceVersions = componentInstanceVersions(instanceSourceDefaults, componentSourceVersions, sourceVersion)
+	}
+
+	// System variables may be required by rules but missing from the snapshot (--skip-sysvars, or no SQL access)
+	sysVarsUnavailable := dataReqs.SourceClusterRequirements.NeedSystemVariables &&
+		(a.options.SkipSystemVariables || !snapshot.HasCollected(types.DataClassSystemVariables))
+
+	if sysVarsUnavailable {
+		// Rules then only compare configuration parameters, instead of reporting every variable as missing
+		sourceDefaults = withoutSystemVariables(sourceDefaults)
+		targetDefaults = withoutSystemVariables(targetDefaults)
+		for version, defaults := range instanceSourceDefaults {
+			instanceSourceDefaults[version] = withoutSystemVariables(defaults)
+		}
+	}
+
 	// Validate and report any mismatches (KB has defaults but runtime doesn't, or vice versa)
 	mismatchResults := a.validateComponentMapping(snapshot, sourceDefaults, componentMapping, sourceVersion)
+	if sysVarsUnavailable {
+		mismatchResults = append(mismatchResults, buildSystemVariablesUnavailableCheckResult(a.options.SkipSystemVariables))
+	}
+
+	// Components running in the cluster may have no knowledge in the source KB (e.g., TiFlash knowledge never
+	// generated for an old source version). They are reported once and only compared with the target defaults
+	missingSourceKBComponents := findComponentsMissingInSourceKB(snapshot, sourceDefaults, targetDefaults)
+	if len(missingSourceKBComponents) > 0 {
+		mismatchResults = append(mismatchResults, buildMissingSourceKBCheckResult(missingSourceKBComponents, sourceVersion))
+	}
 
 	// Load upgrade logic (only need to load once, contains all historical changes)
-	// Upgrade logic is version-agnostic and contains all changes with version tags
+	// Upgrade logic is version-agnostic and contains all changes with version tags,
+	// it is filtered to the changes that run during the upgrade once the rule context is created
 	upgradeLogic := a.loadUpgradeLogic(sourceKB, targetKB, dataReqs)
-	// Debug: log loaded components
-	upgradeLogicKeys := make([]string, 0, len(upgradeLogic))
-	for k := range upgradeLogic {
-		upgradeLogicKeys = append(upgradeLogicKeys, k)
-	}
-	fmt.Printf("[DEBUG Analyzer] Loaded upgrade_logic for components: %v\n", upgradeLogicKeys)
 
 	// Load parameter notes (global, version-agnostic)
 	parameterNotes := a.loadParameterNotes(sourceKB, targetKB)
@@ -140,7 +224,6 @@ func (a *Analyzer) Analyze(
 		targetVersion,
 		sourceDefaults,
 		targetDefaults,
-		upgradeLogic,
 		parameterNotes,
 		sourceBootstrapVersions["tidb"],
 		targetBootstrapVersions["tidb"],
@@ -159,11 +242,46 @@ func (a *Analyzer) Analyze(
 		targetVersion,
 		cleanedSourceDefaults, // Use cleaned defaults (filtered parameters removed)
 		cleanedTargetDefaults, // Use cleaned defaults (filtered parameters removed)
-		upgradeLogic,
 		sourceBootstrapVersion,
 		targetBootstrapVersion,
 		parameterNotes,
 	)
+	ruleCtx.ComponentSourceVersions = componentSourceVersions
+	ruleCtx.InstanceSourceDefaults = withoutPreprocessedInstanceDefaults(instanceSourceDefaults, sourceDefaults, cleanedSourceDefaults)
+	ruleCtx.ComponentBootstrapVersions = componentBootstrapVersions(sourceBootstrapVersions, targetBootstrapVersions)
+	ruleCtx.BootstrapVersionQuery = a.options.BootstrapVersionQuery
+	ruleCtx.SetUpgradeLogic(upgradeLogic)
+	for comp := range upgradeLogic {
+		fmt.Printf("[DEBUG Analyzer] Loaded upgrade_logic for %s: %d of %d changes run during the upgrade\n",
+			comp, len(ruleCtx.UpgradeLogic[comp]), len(upgradeLogic[comp]))
+	}
+	ruleCtx.MachineDerivedParams = a.loadMachineDerivedParams(sourceKB, targetKB)
+	ruleCtx.FormatChanges = a.loadFormatChanges(sourceKB, targetKB)
+	ruleCtx.SectionMigrations = a.loadSectionMigrations(sourceKB, targetKB)
+	ruleCtx.RenameMap = a.loadRenameMap(sourceKB, targetKB)
+	upgradeMatrix, err := a.loadUpgradeMatrix(sourceKB, targetKB)
+	if err != nil {
+		return nil, err
+	}
+	ruleCtx.UpgradeMatrix = upgradeMatrix
+	ruleCtx.DeploymentSpecificParams = a.loadDeploymentSpecificParams(sourceKB, targetKB)
+	ruleCtx.ConsistencyIgnore = a.loadConsistencyIgnore(sourceKB, targetKB)
+	ruleCtx.ResourceControlChanges = a.loadResourceControlChanges(sourceKB, targetKB)
+	ruleCtx.DangerousCombinations = a.loadDangerousCombinations(sourceKB, targetKB)
+	ruleCtx.DeprecatedStartupFlags = a.loadDeprecatedStartupFlags(sourceKB, targetKB)
+	ruleCtx.ParameterHistory = a.loadParameterHistory(sourceKB, targetKB)
+	ruleCtx.UpgradeHistory = a.loadUpgradeHistory(sourceKB, targetKB)
+	ruleCtx.SystemVariablesUnavailable = sysVarsUnavailable
+	if len(missingSourceKBComponents) > 0 {
+		ruleCtx.MissingSourceKBComponents = make(map[string]bool, len(missingSourceKBComponents))
+		for _, comp := range missingSourceKBComponents {
+			ruleCtx.MissingSourceKBComponents[comp] = true
+		}
+	}
+
+	if a.options.ChangedSince != nil {
+		ruleCtx.ChangedParameters = rules.NewChangedParameters(a.options.ChangedSince, snapshot)
+	}
 
 	// Step 4: Execute all rules with the shared context
 	ruleRunner := rules.NewRuleRunner(a.rules)
@@ -173,11 +291,29 @@ func (a *Analyzer) Analyze(
 	}
 
 	// Step 5: Merge all results (preprocessed + mismatch + rule results)
-	allCheckResults := append(preprocessedResults, mismatchResults...)
+	allCheckResults := make([]rules.CheckResult, 0, len(preprocessedResults)+len(mismatchResults)+len(checkResults)+1)
+	allCheckResults = append(allCheckResults, preprocessedResults...)
+	allCheckResults = append(allCheckResults, mismatchResults...)
 	allCheckResults = append(allCheckResults, checkResults...)
+	if mixedVersion != nil {
+		allCheckResults = append(allCheckResults, buildMixedVersionCheckResult(mixedVersion))
+	}
 
 	// Step 6: Organize results by category
-	result := a.organizeResults(allCheckResults, sourceVersion, targetVersion)
+	result := a.organizeResults(allCheckResults, ruleRunner.Executions(), sourceVersion, targetVersion)
+	result.ClusterID = snapshot.ClusterInfo.ClusterID
+	result.MixedVersion = mixedVersion
+	result.Statistics.ParametersCollected = countCollectedParameters(snapshot, componentMapping)
+	if ruleCtx.ChangedParameters != nil {
+		result.ChangedSince = newChangedSinceInfo(ruleCtx.ChangedParameters, result.RuleExecutions)
+	}
+	if snapshot.CollectionMode != "" || len(snapshot.MissingPrivileges) > 0 {
+		result.Collection = &CollectionInfo{
+			Mode:              snapshot.CollectionMode,
+			Limitations:       snapshot.CollectionLimitations,
+			MissingPrivileges: snapshot.MissingPrivileges,
+		}
+	}
 
 	return result, nil
 }
@@ -200,6 +336,10 @@ func (a *Analyzer) collectDataRequirements() rules.DataSourceRequirement {
 		merged.SourceClusterRequirements.NeedConfig = merged.SourceClusterRequirements.NeedConfig || req.SourceClusterRequirements.NeedConfig
 		merged.SourceClusterRequirements.NeedSystemVariables = merged.SourceClusterRequirements.NeedSystemVariables || req.SourceClusterRequirements.NeedSystemVariables
 		merged.SourceClusterRequirements.NeedAllTikvNodes = merged.SourceClusterRequirements.NeedAllTikvNodes || req.SourceClusterRequirements.NeedAllTikvNodes
+		merged.SourceClusterRequirements.NeedGlobalVariablesTable = merged.SourceClusterRequirements.NeedGlobalVariablesTable || req.SourceClusterRequirements.NeedGlobalVariablesTable
+		merged.SourceClusterRequirements.NeedGCSafePoints = merged.SourceClusterRequirements.NeedGCSafePoints || req.SourceClusterRequirements.NeedGCSafePoints
+		merged.SourceClusterRequirements.NeedPlacement = merged.SourceClusterRequirements.NeedPlacement || req.SourceClusterRequirements.NeedPlacement
+		merged.SourceClusterRequirements.NeedStores = merged.SourceClusterRequirements.NeedStores || req.SourceClusterRequirements.NeedStores
 
 		// Merge source KB requirements
 		merged.SourceKBRequirements.Components = mergeStringSlices(
@@ -236,40 +376,23 @@ func (a *Analyzer) loadKBFromRequirements(
 ) (map[string]map[string]interface{}, map[string]int64) {
 	defaults := make(map[string]map[string]interface{})
 	bootstrapVersions := make(map[string]int64)
+	knowledgeBase := collector.NewKnowledgeBase(kb)
 
 	// Always load bootstrap_version if available (needed for upgrade logic filtering)
 	// Even if we don't need config defaults or system variables
 	for _, comp := range components {
-		if compKB, ok := kb[comp].(map[string]interface{}); ok {
-			// Load bootstrap_version (always load if available)
-			if bootstrapVersion, ok := compKB["bootstrap_version"]; ok {
-				fmt.Printf("[DEBUG loadKBFromRequirements] Found bootstrap_version for %s: %v\n", comp, bootstrapVersion)
-				var version int64
-				switch v := bootstrapVersion.(type) {
-				case int64:
-					version = v
-				case float64:
-					version = int64(v)
-				case int:
-					version = int64(v)
-				default:
-					if str, ok := v.(string); ok {
-						if parsed, err := strconv.ParseInt(str, 10, 64); err == nil {
-							version = parsed
-						}
-					}
-				}
-				if version > 0 {
-					bootstrapVersions[comp] = version
-					fmt.Printf("[DEBUG loadKBFromRequirements] Loaded bootstrap_version for %s: %d\n", comp, version)
-				} else {
-					fmt.Printf("[DEBUG loadKBFromRequirements] bootstrap_version for %s is 0 or invalid: %v\n", comp, bootstrapVersion)
-				}
+		switch {
+		case !knowledgeBase.HasComponent(comp):
+			fmt.Printf("[DEBUG loadKBFromRequirements] Component %s not found in KB\n", comp)
+		case !knowledgeBase.HasBootstrapVersion(comp):
+			fmt.Printf("[DEBUG loadKBFromRequirements] No bootstrap_version found for %s in KB\n", comp)
+		default:
+			if version := knowledgeBase.BootstrapVersion(comp); version > 0 {
+				bootstrapVersions[comp] = version
+				fmt.Printf("[DEBUG loadKBFromRequirements] Loaded bootstrap_version for %s: %d\n", comp, version)
 			} else {
-				fmt.Printf("[DEBUG loadKBFromRequirements] No bootstrap_version found for %s in KB\n", comp)
+				fmt.Printf("[DEBUG loadKBFromRequirements] bootstrap_version for %s is 0 or invalid\n", comp)
 			}
-		} else {
-			fmt.Printf("[DEBUG loadKBFromRequirements] Component %s not found in KB\n", comp)
 		}
 	}
 
@@ -285,32 +408,19 @@ func (a *Analyzer) loadKBFromRequirements(
 		defaults[comp] = make(map[string]interface{})
 
 		// Check if component exists in KB
-		compKB, compExists := kb[comp]
-		if !compExists {
+		if !knowledgeBase.HasComponent(comp) {
 			fmt.Printf("[DEBUG loadKBFromRequirements] Component '%s' not found in KB (available components: %v)\n", comp, getComponentKeys(kb))
 			continue
 		}
 
-		compKBMap, ok := compKB.(map[string]interface{})
-		if !ok {
-			fmt.Printf("[DEBUG loadKBFromRequirements] Component '%s' data is not a map, type: %T\n", comp, compKB)
-			continue
-		}
-
 		// Load config defaults
 		if needConfigDefaults {
-			configDefaults, configExists := compKBMap["config_defaults"]
-			if !configExists {
+			configDefaultsMap := knowledgeBase.ConfigDefaults(comp)
+			if configDefaultsMap == nil {
 				fmt.Printf("[DEBUG loadKBFromRequirements] config_defaults not found for component %s\n", comp)
 				continue
 			}
 
-			configDefaultsMap, ok := configDefaults.(map[string]interface{})
-			if !ok {
-				fmt.Printf("[DEBUG loadKBFromRequirements] config_defaults for component %s is not a map, type: %T\n", comp, configDefaults)
-				continue
-			}
-
 			paramCount := 0
 			// Debug: Check if critical parameters exist in configDefaultsMap before loading
 			criticalParams := []string{
@@ -356,11 +466,9 @@ func (a *Analyzer) loadKBFromRequirements(
 
 		// Load system variables
 		if needSystemVariables {
-			if systemVars, ok := compKBMap["system_variables"].(map[string]interface{}); ok {
-				for k, v := range systemVars {
-					// Prefix with "sysvar:" to distinguish from config params
-					defaults[comp]["sysvar:"+k] = v
-				}
+			for k, v := range knowledgeBase.SystemVariables(comp) {
+				// Prefix with "sysvar:" to distinguish from config params
+				defaults[comp]["sysvar:"+k] = v
 			}
 		}
 
@@ -411,6 +519,13 @@ func (a *Analyzer) buildComponentMapping(snapshot *collector.ClusterSnapshot, so
 
 	// Build mapping for each component type in source defaults
 	for compType := range sourceDefaults {
+		// Prefer the component keyed by its type (e.g., "tikv" is always the first TiKV node)
+		// This keeps the mapping deterministic when multiple instances of the same type are collected
+		if _, ok := snapshot.Components[compType]; ok {
+			mapping[compType] = compType
+			continue
+		}
+
 		// Try to find component by exact type match
 		for name, comp := range snapshot.Components {
 			if string(comp.Type) == compType {
@@ -436,6 +551,18 @@ func (a *Analyzer) buildComponentMapping(snapshot *collector.ClusterSnapshot, so
 	return mapping
 }
 
+// countCollectedParameters returns the number of parameters collected for each mapped component type
+// (config parameters and system variables of the instance compared against the source KB)
+func countCollectedParameters(snapshot *collector.ClusterSnapshot, componentMapping map[string]string) map[string]int {
+	counts := make(map[string]int)
+	for compType, compName := range componentMapping {
+		if comp, ok := snapshot.Components[compName]; ok && comp.ParameterCount() > 0 {
+			counts[compType] = comp.ParameterCount()
+		}
+	}
+	return counts
+}
+
 // validateComponentMapping validates one-to-one correspondence between source KB and runtime
 // Reports mismatches where KB has defaults but runtime doesn't have the component/parameter
 // or vice versa
@@ -449,6 +576,10 @@ func (a *Analyzer) validateComponentMapping(
 
 	// Check 1: KB has defaults for a component, but runtime doesn't have it
 	for compType, defaults := range sourceDefaults {
+		// Components without source knowledge have an empty defaults map: nothing to validate
+		if len(defaults) == 0 {
+			continue
+		}
 		if compName, ok := componentMapping[compType]; !ok || compName == "" {
 			// KB has defaults but runtime doesn't have this component
 			results = append(results, rules.CheckResult{
@@ -469,61 +600,49 @@ func (a *Analyzer) validateComponentMapping(
 			continue
 		}
 
-		// Build runtime parameter map for O(1) lookup (avoid nested loops)
-		runtimeConfigMap := make(map[string]bool)
-		runtimeVarsMap := make(map[string]bool)
-
-		for paramName := range comp.Config {
-			runtimeConfigMap[paramName] = true
-		}
-		for varName := range comp.Variables {
-			runtimeVarsMap[varName] = true
-		}
-
 		// Check KB defaults against runtime (single loop, O(1) lookup)
-		for paramName := range defaults {
-			isSystemVar := strings.HasPrefix(paramName, "sysvar:")
-			varName := paramName
-			if isSystemVar {
-				varName = strings.TrimPrefix(paramName, "sysvar:")
+		// Components omit string parameters left to their empty default, or return them as null:
+		// an empty default matches an absent runtime value (see rules.CompareValueStates)
+		for paramName, defaultValue := range defaults {
+			if _, ok := comp.GetParam(paramName); ok {
+				continue
+			}
+			if rules.GetValueState(extractValueFromDefault(defaultValue), true).IsUnsetOrEmpty() {
+				continue
 			}
 
-			if isSystemVar {
-				if !runtimeVarsMap[varName] {
-					// KB has system variable default, but runtime doesn't have it
-					results = append(results, rules.CheckResult{
-						RuleID:        "PARAMETER_MISMATCH",
-						Category:      "validation",
-						Component:     compType,
-						ParameterName: varName,
-						ParamType:     "system_variable",
-						Severity:      "warning",
-						Message:       fmt.Sprintf("Source KB (v%s) has default for system variable %s in %s, but not found in runtime", sourceVersion, varName, compType),
-						Details:       fmt.Sprintf("System variable %s exists in source KB defaults but not in runtime cluster", varName),
-						Suggestions: []string{
-							"Verify system variable name spelling",
-							"Check if variable was removed in this version",
-						},
-					})
-				}
+			if varName, isSystemVar := strings.CutPrefix(paramName, types.SystemVariablePrefix); isSystemVar {
+				// KB has system variable default, but runtime doesn't have it
+				results = append(results, rules.CheckResult{
+					RuleID:        "PARAMETER_MISMATCH",
+					Category:      "validation",
+					Component:     compType,
+					ParameterName: varName,
+					ParamType:     "system_variable",
+					Severity:      "warning",
+					Message:       fmt.Sprintf("Source KB (v%s) has default for system variable %s in %s, but not found in runtime", sourceVersion, varName, compType),
+					Details:       fmt.Sprintf("System variable %s exists in source KB defaults but not in runtime cluster", varName),
+					Suggestions: []string{
+						"Verify system variable name spelling",
+						"Check if variable was removed in this version",
+					},
+				})
 			} else {
-				if !runtimeConfigMap[paramName] {
-					// KB has config parameter default, but runtime doesn't have it
-					results = append(results, rules.CheckResult{
-						RuleID:        "PARAMETER_MISMATCH",
-						Category:      "validation",
-						Component:     compType,
-						ParameterName: paramName,
-						ParamType:     "config",
-						Severity:      "warning",
-						Message:       fmt.Sprintf("Source KB (v%s) has default for parameter %s in %s, but not found in runtime", sourceVersion, paramName, compType),
-						Details:       fmt.Sprintf("Parameter %s exists in source KB defaults but not in runtime cluster", paramName),
-						Suggestions: []string{
-							"Verify parameter name spelling",
-							"Check if parameter was removed in this version",
-						},
-					})
-				}
+				// KB has config parameter default, but runtime doesn't have it
+				results = append(results, rules.CheckResult{
+					RuleID:        "PARAMETER_MISMATCH",
+					Category:      "validation",
+					Component:     compType,
+					ParameterName: paramName,
+					ParamType:     "config",
+					Severity:      "warning",
+					Message:       fmt.Sprintf("Source KB (v%s) has default for parameter %s in %s, but not found in runtime", sourceVersion, paramName, compType),
+					Details:       fmt.Sprintf("Parameter %s exists in source KB defaults but not in runtime cluster", paramName),
+					Suggestions: []string{
+						"Verify parameter name spelling",
+						"Check if parameter was removed in this version",
+					},
+				})
 			}
 		}
 	}
@@ -531,12 +650,101 @@ func (a *Analyzer) validateComponentMapping(
 	return results
 }
 
-// loadUpgradeLogic loads upgrade logic from knowledge base
+// findComponentsMissingInSourceKB returns the components that run in the cluster and have target defaults,
+// but have no source defaults (the source KB was generated without them), sorted
+func findComponentsMissingInSourceKB(
+	snapshot *collector.ClusterSnapshot,
+	sourceDefaults, targetDefaults map[string]map[string]interface{},
+) []string {
+	var missing []string
+	for _, compType := range []collector.ComponentType{collector.TiDBComponent, collector.PDComponent, collector.TiKVComponent, collector.TiFlashComponent} {
+		comp := string(compType)
+		if len(sourceDefaults[comp]) > 0 || len(targetDefaults[comp]) == 0 {
+			continue
+		}
+		if snapshot.HasComponent(compType) {
+			missing = append(missing, comp)
+		}
+	}
+	return missing
+}
+
+// buildMissingSourceKBCheckResult builds the single result explaining the limitation of the check
+// for components without source knowledge
+func buildMissingSourceKBCheckResult(components []string, sourceVersion string) rules.CheckResult {
+	return rules.CheckResult{
+		RuleID:    "SOURCE_KB_COMPONENT_MISSING",
+		Category:  "validation",
+		Severity:  "info",
+		RiskLevel: rules.RiskLevelLow,
+		Message:   fmt.Sprintf("Source KB (%s) has no knowledge for %s", sourceVersion, strings.Join(components, ", ")),
+		Details: fmt.Sprintf("The cluster runs %s, but the knowledge base of the source version has no defaults for it.\n"+
+			"Runtime values were only compared with the target version defaults: "+
+			"user-modified parameters could not be detected and are reported as target default differences.", strings.Join(components, ", ")),
+		Suggestions: []string{
+			"Generate the knowledge base of the source version for these components to detect user-modified parameters",
+		},
+		Metadata: map[string]interface{}{
+			"components": components,
+		},
+	}
+}
+
+// buildSystemVariablesUnavailableCheckResult builds the single result explaining that the rules
+// requiring system variables only checked configuration
+func buildSystemVariablesUnavailableCheckResult(skipped bool) rules.CheckResult {
+	reason := "System variables could not be collected (the MySQL protocol endpoint of TiDB was not reachable)"
+	if skipped {
+		reason = "System variables were not collected (--skip-sysvars)"
+	}
+	return rules.CheckResult{
+		RuleID:    "SYSTEM_VARIABLES_UNAVAILABLE",
+		Category:  "validation",
+		Component: "tidb",
+		Severity:  "info",
+		RiskLevel: rules.RiskLevelLow,
+		Message:   "System variables were not checked",
+		Details: reason + ".\n" +
+			"Rules were only evaluated on configuration parameters, findings about system variables may be missing.",
+		Suggestions: []string{
+			"Run the precheck with a user that can run SHOW GLOBAL VARIABLES to check system variables",
+		},
+	}
+}
+
+// withoutSystemVariables returns a copy of the defaults without the system variables ("sysvar:" parameters)
+func withoutSystemVariables(defaults map[string]map[string]interface{}) map[string]map[string]interface{} {
+	filtered := make(map[string]map[string]interface{}, len(defaults))
+	for comp, params := range defaults {
+		filtered[comp] = make(map[string]interface{}, len(params))
+		for name, value := range params {
+			if !strings.HasPrefix(name, types.SystemVariablePrefix) {
+				filtered[comp][name] = value
+			}
+		}
+	}
+	return filtered
+}
+
+// componentBootstrapVersions returns the bootstrap versions of the components other than TiDB that have one
+// in both knowledge bases (e.g., PD), whose upgrade logic is numbered independently of TiDB's
+func componentBootstrapVersions(sourceBootstrapVersions, targetBootstrapVersions map[string]int64) map[string]rules.BootstrapVersionRange {
+	result := make(map[string]rules.BootstrapVersionRange)
+	for comp, source := range sourceBootstrapVersions {
+		if comp == "tidb" || source <= 0 || targetBootstrapVersions[comp] <= 0 {
+			continue
+		}
+		result[comp] = rules.BootstrapVersionRange{Source: source, Target: targetBootstrapVersions[comp]}
+	}
+	return result
+}
+
+// loadUpgradeLogic loads the changes of the upgrade logic from knowledge base
 // Upgrade logic is version-agnostic and contains all historical changes with version tags
 // We prefer to load from target KB, but fallback to source KB if target doesn't have it
 // Since upgrade logic contains all historical changes, we only need to load it once
-func (a *Analyzer) loadUpgradeLogic(sourceKB, targetKB map[string]interface{}, req rules.DataSourceRequirement) map[string]interface{} {
-	upgradeLogic := make(map[string]interface{})
+func (a *Analyzer) loadUpgradeLogic(sourceKB, targetKB map[string]interface{}, req rules.DataSourceRequirement) map[string][]types.UpgradeParamChange {
+	upgradeLogic := make(map[string][]types.UpgradeParamChange)
 
 	// Check if any rule needs upgrade logic
 	needUpgradeLogic := req.SourceKBRequirements.NeedUpgradeLogic || req.TargetKBRequirements.NeedUpgradeLogic
@@ -555,31 +763,20 @@ func (a *Analyzer) loadUpgradeLogic(sourceKB, targetKB map[string]interface{}, r
 	// Since upgrade logic is the same across versions (contains all historical changes),
 	// it doesn't matter which KB we load from, but we prefer target KB
 	for _, comp := range components {
-		// Try target KB first
-		if compKB, ok := targetKB[comp].(map[string]interface{}); ok {
-			fmt.Printf("[DEBUG loadUpgradeLogic] Found component %s in target KB\n", comp)
-			if upgrade, ok := compKB["upgrade_logic"].(map[string]interface{}); ok {
-				upgradeLogic[comp] = upgrade
-				fmt.Printf("[DEBUG loadUpgradeLogic] ✅ Loaded upgrade_logic for %s from target KB\n", comp)
+		for _, kb := range []struct {
+			name string
+			kb   *collector.KnowledgeBase
+		}{{"target", collector.NewKnowledgeBase(targetKB)}, {"source", collector.NewKnowledgeBase(sourceKB)}} {
+			if !kb.kb.HasComponent(comp) {
+				fmt.Printf("[DEBUG loadUpgradeLogic] Component %s not found in %s KB\n", comp, kb.name)
 				continue
-			} else {
-				fmt.Printf("[DEBUG loadUpgradeLogic] Component %s in target KB but upgrade_logic type is %T (not map[string]interface{})\n", comp, compKB["upgrade_logic"])
 			}
-		} else {
-			fmt.Printf("[DEBUG loadUpgradeLogic] Component %s not found in target KB\n", comp)
-		}
-
-		// Fallback to source KB
-		if compKB, ok := sourceKB[comp].(map[string]interface{}); ok {
-			fmt.Printf("[DEBUG loadUpgradeLogic] Found component %s in source KB\n", comp)
-			if upgrade, ok := compKB["upgrade_logic"].(map[string]interface{}); ok {
-				upgradeLogic[comp] = upgrade
-				fmt.Printf("[DEBUG loadUpgradeLogic] ✅ Loaded upgrade_logic for %s from source KB\n", comp)
-			} else {
-				fmt.Printf("[DEBUG loadUpgradeLogic] Component %s in source KB but upgrade_logic type is %T (not map[string]interface{})\n", comp, compKB["upgrade_logic"])
+			if _, ok := kb.kb.UpgradeLogic(comp); ok {
+				upgradeLogic[comp] = kb.kb.UpgradeChanges(comp)
+				fmt.Printf("[DEBUG loadUpgradeLogic] ✅ Loaded upgrade_logic for %s from %s KB\n", comp, kb.name)
+				break
 			}
-		} else {
-			fmt.Printf("[DEBUG loadUpgradeLogic] Component %s not found in source KB\n", comp)
+			fmt.Printf("[DEBUG loadUpgradeLogic] Component %s in %s KB but has no upgrade_logic\n", comp, kb.name)
 		}
 	}
 
@@ -605,8 +802,263 @@ func (a *Analyzer) loadParameterNotes(sourceKB, targetKB map[string]interface{})
 	return parameterNotes
 }
 
+// loadMachineDerivedParams loads the machine-derived parameter list
+// Starts from the built-in list and extends it with machine_derived_params from knowledge base (global, version-agnostic)
+func (a *Analyzer) loadMachineDerivedParams(sourceKB, targetKB map[string]interface{}) rules.MachineDerivedParams {
+	machineDerivedParams := rules.DefaultMachineDerivedParams()
+
+	// Try to load from target KB first, fallback to source KB
+	raw, ok := targetKB["machine_derived_params"]
+	if !ok {
+		raw, ok = sourceKB["machine_derived_params"]
+	}
+	if !ok {
+		fmt.Printf("[DEBUG loadMachineDerivedParams] No machine_derived_params found in KB, using built-in list\n")
+		return machineDerivedParams
+	}
+
+	fromKB, err := rules.ParseMachineDerivedParams(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadMachineDerivedParams] Failed to parse machine_derived_params, using built-in list: %v\n", err)
+		return machineDerivedParams
+	}
+	machineDerivedParams.Merge(fromKB)
+	fmt.Printf("[DEBUG loadMachineDerivedParams] ✅ Loaded machine_derived_params from KB\n")
+
+	return machineDerivedParams
+}
+
+// loadFormatChanges loads the on-disk format changes checked by StorageFormatRule
+// format_changes is global (version-agnostic), so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadFormatChanges(sourceKB, targetKB map[string]interface{}) []rules.FormatChange {
+	raw, ok := targetKB["format_changes"]
+	if !ok {
+		raw, ok = sourceKB["format_changes"]
+	}
+	if !ok {
+		fmt.Printf("[DEBUG loadFormatChanges] No format_changes found in KB\n")
+		return nil
+	}
+
+	formatChanges, err := rules.ParseFormatChanges(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadFormatChanges] Failed to parse format_changes, storage format check disabled: %v\n", err)
+		return nil
+	}
+	fmt.Printf("[DEBUG loadFormatChanges] ✅ Loaded %d format changes from KB\n", len(formatChanges))
+
+	return formatChanges
+}
+
+// loadUpgradeMatrix loads the supported direct upgrade paths, used by UpgradePathRule
+// Target KB is checked first, then source KB. An invalid matrix is an error, the path check is never silently skipped
+func (a *Analyzer) loadUpgradeMatrix(sourceKB, targetKB map[string]interface{}) (*rules.UpgradeMatrix, error) {
+	raw, ok := targetKB["upgrade_matrix"]
+	if !ok {
+		raw, ok = sourceKB["upgrade_matrix"]
+	}
+	if !ok {
+		fmt.Printf("[DEBUG loadUpgradeMatrix] No upgrade_matrix found in KB\n")
+		return nil, nil
+	}
+
+	upgradeMatrix, err := rules.ParseUpgradeMatrix(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upgrade matrix in knowledge base: %w", err)
+	}
+	fmt.Printf("[DEBUG loadUpgradeMatrix] ✅ Loaded upgrade matrix with %d LTS versions from KB\n", len(upgradeMatrix.LTS))
+
+	return upgradeMatrix, nil
+}
+
+// loadSectionMigrations loads the config parameters that moved to another section, used by UpgradeDifferencesRule
+// section_migrations is global (version-agnostic), so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadSectionMigrations(sourceKB, targetKB map[string]interface{}) []rules.SectionMigration {
+	raw, ok := targetKB["section_migrations"]
+	if !ok {
+		raw, ok = sourceKB["section_migrations"]
+	}
+	if !ok {
+		fmt.Printf("[DEBUG loadSectionMigrations] No section_migrations found in KB\n")
+		return nil
+	}
+
+	sectionMigrations, err := rules.ParseSectionMigrations(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadSectionMigrations] Failed to parse section_migrations, moved parameters are reported as new: %v\n", err)
+		return nil
+	}
+	fmt.Printf("[DEBUG loadSectionMigrations] ✅ Loaded %d section migrations from KB\n", len(sectionMigrations))
+
+	return sectionMigrations
+}
+
+// loadRenameMap loads the parameters renamed between versions, used by UpgradeDifferencesRule
+// rename_map is global (version-agnostic), so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadRenameMap(sourceKB, targetKB map[string]interface{}) rules.RenameMap {
+	raw, ok := targetKB["rename_map"]
+	if !ok {
+		raw, ok = sourceKB["rename_map"]
+	}
+	if !ok {
+		fmt.Printf("[DEBUG loadRenameMap] No rename_map found in KB\n")
+		return nil
+	}
+
+	renameMap, err := rules.ParseRenameMap(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadRenameMap] Failed to parse rename_map, renamed parameters are reported as new: %v\n", err)
+		return nil
+	}
+	fmt.Printf("[DEBUG loadRenameMap] ✅ Loaded renamed parameters of %d components from KB\n", len(renameMap))
+
+	return renameMap
+}
+
+// loadParameterHistory loads the parameter history of each component
+// parameter_history is version-agnostic, so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadParameterHistory(sourceKB, targetKB map[string]interface{}) map[string]*collector.ParameterHistory {
+	histories := make(map[string]*collector.ParameterHistory)
+	for _, comp := range []string{"tidb", "pd", "tikv", "tiflash"} {
+		raw, ok := collector.NewKnowledgeBase(targetKB).ParameterHistory(comp)
+		if !ok {
+			raw, ok = collector.NewKnowledgeBase(sourceKB).ParameterHistory(comp)
+		}
+		if !ok {
+			continue
+		}
+		history, err := collector.ParseParameterHistory(raw)
+		if err != nil {
+			fmt.Printf("[WARNING loadParameterHistory] Failed to parse parameter_history for %s: %v\n", comp, err)
+			continue
+		}
+		histories[comp] = history
+	}
+	if len(histories) == 0 {
+		return nil
+	}
+	return histories
+}
+
+// loadUpgradeHistory loads the releases of TiDB's bootstrap versions
+// upgrade_history is version-agnostic, so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadUpgradeHistory(sourceKB, targetKB map[string]interface{}) collector.UpgradeHistory {
+	raw, ok := collector.NewKnowledgeBase(targetKB).UpgradeHistory("tidb")
+	if !ok {
+		raw, ok = collector.NewKnowledgeBase(sourceKB).UpgradeHistory("tidb")
+	}
+	if !ok {
+		return nil
+	}
+	history, err := collector.ParseUpgradeHistory(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadUpgradeHistory] Failed to parse upgrade_history, forced changes are not annotated with their release: %v\n", err)
+		return nil
+	}
+	return history
+}
+
+// loadDeploymentSpecificParams loads the deployment-specific parameters skipped by UpgradeDifferencesRule
+// deployment_specific is global (version-agnostic), so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadDeploymentSpecificParams(sourceKB, targetKB map[string]interface{}) rules.DeploymentSpecificParams {
+	raw, ok := targetKB["deployment_specific"]
+	if !ok {
+		raw, ok = sourceKB["deployment_specific"]
+	}
+	if !ok {
+		fmt.Printf("[DEBUG loadDeploymentSpecificParams] No deployment_specific found in KB\n")
+		return nil
+	}
+
+	params, err := rules.ParseDeploymentSpecificParams(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadDeploymentSpecificParams] Failed to parse deployment_specific, no parameter is skipped: %v\n", err)
+		return nil
+	}
+	fmt.Printf("[DEBUG loadDeploymentSpecificParams] ✅ Loaded deployment_specific from KB\n")
+
+	return params
+}
+
+// loadConsistencyIgnore loads the parameters ignored by the consistency checks from the knowledge base
+// consistency_ignore is global (version-agnostic), so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadConsistencyIgnore(sourceKB, targetKB map[string]interface{}) rules.ConsistencyIgnore {
+	raw, ok := targetKB["consistency_ignore"]
+	if !ok {
+		raw, ok = sourceKB["consistency_ignore"]
+	}
+	if !ok {
+		return nil
+	}
+
+	ignore, err := rules.ParseConsistencyIgnore(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadConsistencyIgnore] Failed to parse consistency_ignore, every parameter is checked: %v\n", err)
+		return nil
+	}
+	return ignore
+}
+
+// loadResourceControlChanges loads the versions where the accounting of request units changed from the knowledge base
+// resource_control_changes is global (version-agnostic), so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadResourceControlChanges(sourceKB, targetKB map[string]interface{}) []rules.ResourceControlChange {
+	raw, ok := targetKB["resource_control_changes"]
+	if !ok {
+		raw, ok = sourceKB["resource_control_changes"]
+	}
+	if !ok {
+		return nil
+	}
+
+	changes, err := rules.ParseResourceControlChanges(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadResourceControlChanges] Failed to parse resource_control_changes, resource groups are not checked: %v\n", err)
+		return nil
+	}
+	return changes
+}
+
+// loadDangerousCombinations loads the configurations that become dangerous under a target version from the knowledge base
+// dangerous_combinations is global (version-agnostic), so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadDangerousCombinations(sourceKB, targetKB map[string]interface{}) []rules.DangerousCombination {
+	raw, ok := targetKB["dangerous_combinations"]
+	if !ok {
+		raw, ok = sourceKB["dangerous_combinations"]
+	}
+	if !ok {
+		return nil
+	}
+
+	combinations, err := rules.ParseDangerousCombinations(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadDangerousCombinations] Failed to parse dangerous_combinations, dangerous combinations are not checked: %v\n", err)
+		return nil
+	}
+	return combinations
+}
+
+// loadDeprecatedStartupFlags loads the command-line flags deprecated or removed in some version from the knowledge base
+// deprecated_startup_flags is global (version-agnostic), so it is taken from the target KB, falling back to the source KB
+func (a *Analyzer) loadDeprecatedStartupFlags(sourceKB, targetKB map[string]interface{}) []rules.DeprecatedStartupFlag {
+	raw, ok := targetKB["deprecated_startup_flags"]
+	if !ok {
+		raw, ok = sourceKB["deprecated_startup_flags"]
+	}
+	if !ok {
+		return nil
+	}
+
+	flags, err := rules.ParseDeprecatedStartupFlags(raw)
+	if err != nil {
+		fmt.Printf("[WARNING loadDeprecatedStartupFlags] Failed to parse deprecated_startup_flags, startup flags are not checked for deprecation: %v\n", err)
+		return nil
+	}
+	return flags
+}
+
 // organizeResults organizes check results by category for reporter
-func (a *Analyzer) organizeResults(checkResults []rules.CheckResult, sourceVersion, targetVersion string) *AnalysisResult {
+// Statistics are aggregated from the statistics reported by the rules in executions
+func (a *Analyzer) organizeResults(checkResults []rules.CheckResult, executions []rules.RuleExecution, sourceVersion, targetVersion string) *AnalysisResult {
 	result := &AnalysisResult{
 		SourceVersion:       sourceVersion,
 		TargetVersion:       targetVersion,
@@ -615,37 +1067,23 @@ func (a *Analyzer) organizeResults(checkResults []rules.CheckResult, sourceVersi
 		UpgradeDifferences:  make(map[string]map[string]UpgradeDifference),
 		ForcedChanges:       make(map[string]map[string]ForcedChange),
 		CheckResults:        []rules.CheckResult{},
-		Statistics:          Statistics{},
-	}
-
-	// Filter out statistics CheckResults and extract statistics
-	var filteredResults []rules.CheckResult
-	for _, check := range checkResults {
-		// Check if this is a statistics CheckResult
-		if check.ParameterName == "__statistics__" && strings.HasSuffix(check.RuleID, "_STATS") {
-			// Extract statistics from Description
-			// Format: "Compared X parameters, skipped Y (source == target), filtered Z (deployment-specific)"
-			var totalCompared, totalSkipped, totalFiltered int
-			// Try to parse with filtered count first
-			n, _ := fmt.Sscanf(check.Description, "Compared %d parameters, skipped %d (source == target), filtered %d (deployment-specific)", &totalCompared, &totalSkipped, &totalFiltered)
-			if n < 3 {
-				// Fallback to old format without filtered count
-				fmt.Sscanf(check.Description, "Compared %d parameters, skipped %d", &totalCompared, &totalSkipped)
-				totalFiltered = 0
-			}
-			result.Statistics.TotalParametersCompared += totalCompared
-			result.Statistics.ParametersSkipped += totalSkipped
-			result.Statistics.ParametersFiltered += totalFiltered
-			result.Statistics.ParametersWithDifferences = totalCompared - totalSkipped - totalFiltered
-			continue // Skip this CheckResult
-		}
-		filteredResults = append(filteredResults, check)
+		Statistics:          newStatistics(executions),
+		RuleExecutions:      executions,
 	}
 
 	// Deduplicate results: same parameter (Component + ParameterName + ParamType) should only appear once
 	// Priority: Forced > User Modified > Upgrade Difference > Consistency
-	deduplicatedResults := deduplicateCheckResults(filteredResults)
+	deduplicatedResults := deduplicateCheckResults(checkResults)
+	a.options.SeverityProfile.Apply(deduplicatedResults)
+	// Results come out of map iterations: sort them once all rules have run, so that runs are reproducible
+	SortCheckResults(deduplicatedResults)
+	deduplicatedResults, ignored := applyIgnorePatterns(a.options.IgnorePatterns, deduplicatedResults)
+	result.Statistics.FindingsIgnored = len(ignored)
+	if a.options.ShowIgnored {
+		result.IgnoredResults = ignored
+	}
 	result.CheckResults = deduplicatedResults
+	result.Statistics.SeverityByComponent = newSeverityBreakdown(deduplicatedResults)
 
 	// Organize results by category
 	for _, check := range deduplicatedResults {
@@ -658,6 +1096,9 @@ func (a *Analyzer) organizeResults(checkResults []rules.CheckResult, sourceVersi
 			} else {
 				a.addUpgradeDifference(result, check)
 			}
+		case "new_in_target":
+			// Parameters introduced by the target version are upgrade differences with no source default
+			a.addUpgradeDifference(result, check)
 		case "consistency":
 			a.addTikvInconsistency(result, check)
 		}
@@ -704,6 +1145,7 @@ func (a *Analyzer) addForcedChange(result *AnalysisResult, check rules.CheckResu
 		ParamName:     check.ParameterName,
 		CurrentValue:  check.CurrentValue,
 		ForcedValue:   check.ForcedValue,
+		Removed:       rules.IsForcedRemoval(check),
 		SourceDefault: check.SourceDefault,
 		ParamType:     check.ParamType,
 		Summary:       check.Details,
@@ -711,19 +1153,19 @@ func (a *Analyzer) addForcedChange(result *AnalysisResult, check rules.CheckResu
 }
 
 func (a *Analyzer) addTikvInconsistency(result *AnalysisResult, check rules.CheckResult) {
-	// For TiKV consistency, we need to extract node information from the details
-	// The actual node information is stored in the check result's metadata or details
-	// For now, we'll store the parameter name and let reporter handle the details
-	// In a full implementation, we might need to parse the details string or use metadata
-
-	// Check if we already have this parameter
-	if _, exists := result.TikvInconsistencies[check.ParameterName]; !exists {
-		// Initialize with empty slice - the actual node details are in check.Details
-		result.TikvInconsistencies[check.ParameterName] = []InconsistentNode{}
+	// TikvConsistencyRule reports each inconsistent parameter once, with the value of every node
+	nodeValues, _ := check.Metadata["nodes"].([]rules.TikvNodeValue)
+	nodes := make([]InconsistentNode, 0, len(nodeValues))
+	for _, node := range nodeValues {
+		nodes = append(nodes, InconsistentNode{
+			NodeName:    node.Name,
+			NodeAddress: node.Instance,
+			Value:       node.Value,
+			Missing:     node.Missing,
+			IsMajority:  node.Majority,
+		})
 	}
-
-	// Note: Full node details are available in check.Details and check.Metadata
-	// Reporter will display them from the CheckResult
+	result.TikvInconsistencies[check.ParameterName] = nodes
 }
 
 // Helper function to merge string slices without duplicates
@@ -748,13 +1190,30 @@ func mergeStringSlices(slice1, slice2 []string) []string {
 	return result
 }
 
+// checkResultKey identifies the parameter a check result relates to
+type checkResultKey struct {
+	component     string
+	parameterName string
+	paramType     string
+	// category is only set for categories that are never merged with upgrade findings (golden_drift)
+	category string
+}
+
+// resultChain is the first and last index of the results sharing a checkResultKey
+// Chains are kept in order of first appearance
+type resultChain struct {
+	first int
+	last  int
+}
+
 // deduplicateCheckResults removes duplicate check results for the same parameter
 // Priority: Forced > User Modified > Upgrade Difference > Consistency
 // Key: Component + ParameterName + ParamType
+// Results keep the order in which each parameter first appears
 func deduplicateCheckResults(results []rules.CheckResult) []rules.CheckResult {
-	// Map to store the best result for each parameter
+	// Best result for each parameter, in order of first appearance
 	// Key: Component + ParameterName + ParamType
-	bestResults := make(map[string]rules.CheckResult)
+	deduplicated := make([]rules.CheckResult, 0, len(results))
 
 	// Priority order: higher number = higher priority
 	getPriority := func(check rules.CheckResult) int {
@@ -778,12 +1237,28 @@ func deduplicateCheckResults(results []rules.CheckResult) []rules.CheckResult {
 	}
 
 	// Process all results
-	// First pass: collect all results for each parameter
-	resultsByKey := make(map[string][]rules.CheckResult)
-	for _, check := range results {
+	// First pass: chain the indexes of all results for each parameter
+	// next[i] is the index of the next result with the same key as results[i], or -1
+	chains := make([]resultChain, 0, len(results))
+	chainIndex := make(map[checkResultKey]int, len(results))
+	next := make([]int, len(results))
+	for i, check := range results {
+		next[i] = -1
 		// Create unique key: Component + ParameterName + ParamType
-		key := fmt.Sprintf("%s:%s:%s", check.Component, check.ParameterName, check.ParamType)
-		resultsByKey[key] = append(resultsByKey[key], check)
+		key := checkResultKey{component: check.Component, parameterName: check.ParameterName, paramType: check.ParamType}
+		if check.Category == "golden_drift" || check.Category == "global_variables_table" {
+			// Drift from the golden config and mysql.global_variables inconsistencies are separate comparisons,
+			// keep them next to upgrade findings of the same parameter
+			key.category = check.Category
+		}
+		c, seen := chainIndex[key]
+		if !seen {
+			chainIndex[key] = len(chains)
+			chains = append(chains, resultChain{first: i, last: i})
+			continue
+		}
+		next[chains[c].last] = i
+		chains[c].last = i
 	}
 
 	// Second pass: merge results for each parameter
@@ -791,11 +1266,17 @@ func deduplicateCheckResults(results []rules.CheckResult) []rules.CheckResult {
 	// - UserModifiedParamsRule: currentValue vs sourceDefault (no targetDefault)
 	// - UpgradeDifferencesRule: currentValue vs sourceDefault vs targetDefault (all three)
 	// - TikvConsistencyRule: currentValue from multiple nodes (may have sourceDefault/targetDefault as reference)
-	for key, checks := range resultsByKey {
-		if len(checks) == 1 {
-			bestResults[key] = checks[0]
+	var checks []rules.CheckResult
+	for _, chain := range chains {
+		if chain.first == chain.last {
+			deduplicated = append(deduplicated, results[chain.first])
 			continue
 		}
+		// The buffer is reused across parameters, merged results never keep a reference to it
+		checks = checks[:0]
+		for i := chain.first; i != -1; i = next[i] {
+			checks = append(checks, results[i])
+		}
 
 		// Find the result with highest priority as base
 		// Special handling: if multiple results have same priority, prefer Deprecated over Default Changed
@@ -890,9 +1371,18 @@ func deduplicateCheckResults(results []rules.CheckResult) []rules.CheckResult {
 		}
 
 		// Merge ForcedValue: prefer non-nil value
+		// The forced removal marker belongs to the forced value, so it is merged along with it
 		for _, check := range checks {
 			if merged.ForcedValue == nil && check.ForcedValue != nil {
 				merged.ForcedValue = check.ForcedValue
+				if rules.IsForcedRemoval(check) {
+					metadata := make(map[string]interface{}, len(merged.Metadata)+1)
+					for k, v := range merged.Metadata {
+						metadata[k] = v
+					}
+					metadata["forced_removal"] = true
+					merged.Metadata = metadata
+				}
 				break
 			}
 		}
@@ -918,13 +1408,7 @@ func deduplicateCheckResults(results []rules.CheckResult) []rules.CheckResult {
 			}
 		}
 
-		bestResults[key] = merged
-	}
-
-	// Convert map back to slice
-	deduplicated := make([]rules.CheckResult, 0, len(bestResults))
-	for _, check := range bestResults {
-		deduplicated = append(deduplicated, check)
+		deduplicated = append(deduplicated, merged)
 	}
 
 	return deduplicated
@@ -0,0 +1,169 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Synthetic input sizes for BenchmarkAnalyzer_Analyze, close to a large production cluster:
+// ~8k runtime parameters across all instances and ~6k knowledge base entries
+const (
+	benchTiDBConfigParams = 1200
+	benchTiDBSysVars      = 800
+	benchPDConfigParams   = 500
+	benchTiKVConfigParams = 1500
+	benchTiKVNodes        = 3
+)
+
+// benchParamName returns a parameter name that is not filtered by the preprocessor
+// (no path/host keywords), so that it goes through all rules
+func benchParamName(section, i int) string {
+	return fmt.Sprintf("section-%d.param-%d", section, i)
+}
+
+// benchValue returns runtime and default values that differ for a share of the parameters,
+// so that rules produce a realistic number of results
+func benchValues(i int) (current, source, target interface{}) {
+	switch i % 10 {
+	case 0: // user-modified
+		return fmt.Sprintf("%d", i*2), fmt.Sprintf("%d", i), fmt.Sprintf("%d", i)
+	case 1: // default changed in target
+		return fmt.Sprintf("%d", i), fmt.Sprintf("%d", i), fmt.Sprintf("%d", i+1)
+	case 2: // boolean-like value
+		return "ON", "ON", "OFF"
+	default:
+		return fmt.Sprintf("%d", i), fmt.Sprintf("%d", i), fmt.Sprintf("%d", i)
+	}
+}
+
+func newBenchAnalyzeInput() (*collector.ClusterSnapshot, map[string]interface{}, map[string]interface{}) {
+	snapshot := &collector.ClusterSnapshot{
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		Components:    make(map[string]collector.ComponentState),
+	}
+	sourceKB := make(map[string]interface{})
+	targetKB := make(map[string]interface{})
+
+	addComponent := func(compType types.ComponentType, configCount, sysVarCount int) (types.ParameterMap, types.ParameterMap) {
+		config := make(types.ParameterMap, configCount)
+		sourceConfig := make(map[string]interface{}, configCount)
+		targetConfig := make(map[string]interface{}, configCount)
+		for i := 0; i < configCount; i++ {
+			name := benchParamName(i%50, i)
+			current, source, target := benchValues(i)
+			config[name] = types.ParameterValue{Value: current, Type: "string"}
+			sourceConfig[name] = map[string]interface{}{"value": source, "type": "string"}
+			targetConfig[name] = map[string]interface{}{"value": target, "type": "string"}
+		}
+
+		variables := make(types.ParameterMap, sysVarCount)
+		sourceVars := make(map[string]interface{}, sysVarCount)
+		targetVars := make(map[string]interface{}, sysVarCount)
+		for i := 0; i < sysVarCount; i++ {
+			name := fmt.Sprintf("tidb_bench_var_%d", i)
+			current, source, target := benchValues(i)
+			variables[name] = types.ParameterValue{Value: current, Type: "string"}
+			sourceVars[name] = map[string]interface{}{"value": source, "type": "string"}
+			targetVars[name] = map[string]interface{}{"value": target, "type": "string"}
+		}
+
+		sourceKB[string(compType)] = map[string]interface{}{
+			"config_defaults":   sourceConfig,
+			"system_variables":  sourceVars,
+			"bootstrap_version": float64(180),
+		}
+		targetKB[string(compType)] = map[string]interface{}{
+			"config_defaults":   targetConfig,
+			"system_variables":  targetVars,
+			"bootstrap_version": float64(218),
+		}
+		return config, variables
+	}
+
+	tidbConfig, tidbVars := addComponent(types.ComponentTiDB, benchTiDBConfigParams, benchTiDBSysVars)
+	snapshot.Components["tidb"] = collector.ComponentState{
+		Type: types.ComponentTiDB, Version: "v7.5.0", Config: tidbConfig, Variables: tidbVars,
+		Status: map[string]interface{}{"address": "127.0.0.1:4000"},
+	}
+
+	pdConfig, _ := addComponent(types.ComponentPD, benchPDConfigParams, 0)
+	snapshot.Components["pd"] = collector.ComponentState{
+		Type: types.ComponentPD, Version: "v7.5.0", Config: pdConfig,
+		Status: map[string]interface{}{"address": "127.0.0.1:2379"},
+	}
+
+	tikvConfig, _ := addComponent(types.ComponentTiKV, benchTiKVConfigParams, 0)
+	for n := 0; n < benchTiKVNodes; n++ {
+		addr := fmt.Sprintf("127.0.0.%d:20160", n+1)
+		nodeConfig := make(types.ParameterMap, len(tikvConfig))
+		for name, value := range tikvConfig {
+			nodeConfig[name] = value
+		}
+		state := collector.ComponentState{
+			Type: types.ComponentTiKV, Version: "v7.5.0", Config: nodeConfig,
+			Status: map[string]interface{}{"address": addr},
+		}
+		snapshot.Components[fmt.Sprintf("tikv-127-0-0-%d-20160", n+1)] = state
+		if n == 0 {
+			snapshot.Components["tikv"] = state
+		}
+	}
+
+	return snapshot, sourceKB, targetKB
+}
+
+// BenchmarkAnalyzer_Analyze measures a full analysis of a large cluster snapshot
+//
+// Reference numbers (go test -bench=Analyze -benchmem ./pkg/analyzer/):
+//
+//	before optimization: ~95 ms/op, 42.2 MB/op, ~204k allocs/op
+//	after optimization:  ~60 ms/op, 26.5 MB/op,  ~58k allocs/op
+//
+// Allocations are expected to stay at least 3x below the pre-optimization baseline.
+// Wall time includes the TiKV consistency rule trying to reach the fake cluster address.
+func BenchmarkAnalyzer_Analyze(b *testing.B) {
+	snapshot, sourceKB, targetKB := newBenchAnalyzeInput()
+
+	// The analyzer logs debug output to stdout, discard it during the benchmark
+	stdout := os.Stdout
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	os.Stdout = devNull
+	defer func() {
+		os.Stdout = stdout
+		devNull.Close()
+	}()
+
+	ctx := context.Background()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		analyzer := NewAnalyzer(nil)
+		if _, err := analyzer.Analyze(ctx, snapshot, "v7.5.0", "v8.5.0", sourceKB, targetKB); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkShouldFilterParameter measures the preprocessor filter on a typical parameter name
+func BenchmarkShouldFilterParameter(b *testing.B) {
+	names := []string{
+		"raftstore.region-compact-check-step",
+		"performance.max-procs",
+		"readpool.unified.max-thread-count",
+		"log.file.max-size",
+		"server.grpc-concurrency",
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ShouldFilterParameter(names[i%len(names)])
+	}
+}
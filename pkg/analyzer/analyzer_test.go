@@ -4,6 +4,7 @@ import (
 	"context"
 	"testing"
 
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules/catalog"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
@@ -64,6 +65,30 @@ func TestAnalyzer_GetCollectionRequirements(t *testing.T) {
 	assert.NotNil(t, req)
 	assert.NotEmpty(t, req.Components)
 	assert.True(t, req.NeedConfig || req.NeedSystemVariables)
+	assert.True(t, req.NeedGlobalVariablesTable)
+
+	// The extra query for mysql.global_variables only runs if a rule needs it
+	analyzer = NewAnalyzer(&AnalysisOptions{Rules: []rules.Rule{rules.NewUpgradeDifferencesRule()}})
+	assert.False(t, analyzer.GetCollectionRequirements().NeedGlobalVariablesTable)
+}
+
+// TestAnalyzer_GetCollectionRequirements_Consistent checks that every catalog rule, run alone,
+// declares the components its data needs are collected from
+func TestAnalyzer_GetCollectionRequirements_Consistent(t *testing.T) {
+	for _, rule := range catalog.BuildAll() {
+		req := NewAnalyzer(&AnalysisOptions{Rules: []rules.Rule{rule}}).GetCollectionRequirements()
+		err := collector.ValidateRequirements(collector.CollectDataRequirements{
+			Components:               req.Components,
+			NeedConfig:               req.NeedConfig,
+			NeedSystemVariables:      req.NeedSystemVariables,
+			NeedAllTikvNodes:         req.NeedAllTikvNodes,
+			NeedGlobalVariablesTable: req.NeedGlobalVariablesTable,
+			NeedGCSafePoints:         req.NeedGCSafePoints,
+			NeedPlacement:            req.NeedPlacement,
+			NeedStores:               req.NeedStores,
+		})
+		assert.NoError(t, err, rule.Name())
+	}
 }
 
 func TestAnalyzer_Analyze(t *testing.T) {
@@ -103,10 +128,10 @@ func TestAnalyzer_Analyze(t *testing.T) {
 					"tidb": {
 						Type:    types.ComponentTiDB,
 						Version: "v7.5.0",
-						Config: types.ConfigDefaults{
+						Config: types.ParameterMap{
 							"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 						},
-						Variables: types.SystemVariables{
+						Variables: types.ParameterMap{
 							"tidb_mem_quota_query": types.ParameterValue{Value: 1073741824, Type: "int"},
 						},
 					},
@@ -158,6 +183,131 @@ func TestAnalyzer_Analyze(t *testing.T) {
 	}
 }
 
+func TestAnalyzer_Analyze_MissingSourceKBComponent(t *testing.T) {
+	snapshot := &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{
+			"tidb": {
+				Type:    types.ComponentTiDB,
+				Version: "v6.1.0",
+				Config: types.ParameterMap{
+					"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
+				},
+			},
+			"tiflash": {
+				Type:    types.ComponentTiFlash,
+				Version: "v6.1.0",
+				Config: types.ParameterMap{
+					"flash.compact_rows_threshold": types.ParameterValue{Value: 40960, Type: "int"},
+					"flash.overlap_threshold":      types.ParameterValue{Value: 0.6, Type: "float"},
+				},
+			},
+		},
+	}
+	// TiFlash knowledge was never generated for the source version
+	sourceKB := map[string]interface{}{
+		"tidb": map[string]interface{}{
+			"config_defaults": map[string]interface{}{"max-connections": 1000},
+		},
+	}
+	targetKB := map[string]interface{}{
+		"tidb": map[string]interface{}{
+			"config_defaults": map[string]interface{}{"max-connections": 1000},
+		},
+		"tiflash": map[string]interface{}{
+			"config_defaults": map[string]interface{}{
+				"flash.compact_rows_threshold": 102400,
+				"flash.overlap_threshold":      0.6,
+			},
+		},
+	}
+
+	result, err := NewAnalyzer(nil).Analyze(context.Background(), snapshot, "v6.1.0", "v8.5.0", sourceKB, targetKB)
+	require.NoError(t, err)
+
+	var missing []rules.CheckResult
+	var tiflashDiffs []string
+	for _, check := range result.CheckResults {
+		if check.RuleID == "SOURCE_KB_COMPONENT_MISSING" {
+			missing = append(missing, check)
+		}
+		if check.Component != "tiflash" {
+			continue
+		}
+		// No per-parameter "not found in source KB" result and no user-modified classification
+		assert.NotEqual(t, "USER_MODIFIED_PARAMS", check.RuleID, check.Message)
+		assert.NotEqual(t, "PARAMETER_MISMATCH", check.RuleID, check.Message)
+		if check.RuleID == "UPGRADE_DIFFERENCES" {
+			tiflashDiffs = append(tiflashDiffs, check.ParameterName)
+		}
+	}
+
+	for _, check := range result.CheckResults {
+		// PD and TiKV are neither in the cluster nor in the source KB
+		assert.NotEqual(t, "COMPONENT_MISMATCH", check.RuleID, check.Message)
+	}
+	require.Len(t, missing, 1)
+	assert.Equal(t, "info", missing[0].Severity)
+	assert.Contains(t, missing[0].Message, "tiflash")
+	// Target-vs-runtime comparisons still run for the component
+	assert.Equal(t, []string{"flash.compact_rows_threshold"}, tiflashDiffs)
+	assert.Equal(t, 1, result.Statistics.ParametersCollected["tidb"])
+}
+
+func TestAnalyzer_Analyze_SystemVariablesUnavailable(t *testing.T) {
+	snapshot := &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{
+			"tidb": {
+				Type:    types.ComponentTiDB,
+				Version: "v7.5.0",
+				Config: types.ParameterMap{
+					"max-connections": types.ParameterValue{Value: 500, Type: "int"},
+				},
+			},
+		},
+		// The MySQL protocol endpoint was not reachable, only the configuration was collected
+		CollectedData: []types.DataClass{types.DataClassConfig},
+	}
+	kb := map[string]interface{}{
+		"tidb": map[string]interface{}{
+			"config_defaults":  map[string]interface{}{"max-connections": 0},
+			"system_variables": map[string]interface{}{"tidb_txn_mode": "pessimistic"},
+		},
+	}
+
+	result, err := NewAnalyzer(nil).Analyze(context.Background(), snapshot, "v7.5.0", "v8.5.0", kb, kb)
+	require.NoError(t, err)
+
+	var unavailable []rules.CheckResult
+	var modified []string
+	for _, check := range result.CheckResults {
+		// System variables missing from the snapshot are not reported as mismatches
+		assert.NotEqual(t, "PARAMETER_MISMATCH", check.RuleID, check.Message)
+		switch check.RuleID {
+		case "SYSTEM_VARIABLES_UNAVAILABLE":
+			unavailable = append(unavailable, check)
+		case "USER_MODIFIED_PARAMS":
+			modified = append(modified, check.ParameterName)
+		}
+	}
+	require.Len(t, unavailable, 1)
+	assert.Equal(t, "info", unavailable[0].Severity)
+	assert.Contains(t, unavailable[0].Details, "not reachable")
+	// Configuration is still checked
+	assert.Equal(t, []string{"max-connections"}, modified)
+	for _, execution := range result.RuleExecutions {
+		if execution.RuleID == "USER_MODIFIED_PARAMS" {
+			assert.True(t, execution.Partial)
+		}
+	}
+
+	// With --skip-sysvars, neither the system variables nor mysql.global_variables are collected
+	analyzer := NewAnalyzer(&AnalysisOptions{SkipSystemVariables: true})
+	req := analyzer.GetCollectionRequirements()
+	assert.False(t, req.NeedSystemVariables)
+	assert.False(t, req.NeedGlobalVariablesTable)
+	assert.True(t, req.NeedConfig)
+}
+
 func TestAnalyzer_collectDataRequirements(t *testing.T) {
 	analyzer := NewAnalyzer(nil)
 	req := analyzer.collectDataRequirements()
@@ -321,3 +471,82 @@ func TestMergeStringSlices(t *testing.T) {
 	}
 }
 
+
+func TestAnalyzer_organizeResults_Statistics(t *testing.T) {
+	analyzer := NewAnalyzer(nil)
+	executions := []rules.RuleExecution{
+		{
+			RuleID:     "UPGRADE_DIFFERENCES",
+			Statistics: &rules.RuleStatistics{ParametersExamined: 10, ParametersCompared: 10, ParametersSkipped: 6, ParametersFiltered: 1},
+		},
+		{
+			RuleID:     "USER_MODIFIED_PARAMS",
+			Statistics: &rules.RuleStatistics{ParametersExamined: 12, ParametersMachineDerived: 3},
+		},
+		{RuleID: "TIKV_CONSISTENCY", Skipped: true, SkipReason: "no tikv data collected"},
+	}
+
+	result := analyzer.organizeResults(nil, executions, "v7.5.0", "v8.5.0")
+	assert.Equal(t, 10, result.Statistics.TotalParametersCompared)
+	assert.Equal(t, 3, result.Statistics.ParametersWithDifferences)
+	assert.Equal(t, 1, result.Statistics.ParametersFiltered)
+	assert.Equal(t, 3, result.Statistics.ParametersMachineDerived)
+	assert.Equal(t, executions, result.RuleExecutions)
+	assert.Empty(t, result.CheckResults)
+}
+
+func TestAnalyzer_organizeResults_TikvInconsistencies(t *testing.T) {
+	node := func(address, reserveSpace string) collector.ComponentState {
+		return collector.ComponentState{
+			Type:   types.ComponentTiKV,
+			Config: types.ParameterMap{"storage.reserve-space": types.ParameterValue{Value: reserveSpace, Type: "string"}},
+			Status: map[string]interface{}{"address": address},
+		}
+	}
+	ruleCtx := &rules.RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv-0": node("10.0.0.1:20180", "5GB"),
+				"tikv-1": node("10.0.0.2:20180", "2GB"),
+				"tikv-2": node("10.0.0.3:20180", "5GB"),
+			},
+		},
+	}
+	checkResults, err := rules.NewTikvConsistencyRule().Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+
+	result := NewAnalyzer(nil).organizeResults(checkResults, nil, "v7.5.0", "v8.5.0")
+	assert.Equal(t, map[string][]InconsistentNode{
+		"storage.reserve-space": {
+			{NodeName: "tikv-0", NodeAddress: "10.0.0.1:20180", Value: "5GB", IsMajority: true},
+			{NodeName: "tikv-1", NodeAddress: "10.0.0.2:20180", Value: "2GB"},
+			{NodeName: "tikv-2", NodeAddress: "10.0.0.3:20180", Value: "5GB", IsMajority: true},
+		},
+	}, result.TikvInconsistencies)
+}
+
+func TestAnalyzer_ValidateComponentMapping_EmptyDefaults(t *testing.T) {
+	snapshot := &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{
+			"tikv": {
+				Type: types.ComponentTiKV,
+				Config: types.ParameterMap{
+					"metric.address": types.ParameterValue{Value: nil},
+				},
+			},
+		},
+	}
+	sourceDefaults := map[string]map[string]interface{}{
+		"tikv": {
+			"log.file.filename":     map[string]interface{}{"value": "", "type": "string"},
+			"metric.address":        map[string]interface{}{"value": "", "type": "string"},
+			"security.cert-path":    map[string]interface{}{"value": "", "type": "string"},
+			"storage.reserve-space": map[string]interface{}{"value": "5GiB", "type": "string"},
+		},
+	}
+
+	results := NewAnalyzer(nil).validateComponentMapping(snapshot, sourceDefaults, map[string]string{"tikv": "tikv"}, "7.5.0")
+	require.Len(t, results, 1)
+	assert.Equal(t, "PARAMETER_MISMATCH", results[0].RuleID)
+	assert.Equal(t, "storage.reserve-space", results[0].ParameterName)
+}
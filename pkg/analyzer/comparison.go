@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// FindingKey identifies a finding across two analyses of the same cluster
+type FindingKey struct {
+	Component     string `json:"component"`
+	RuleID        string `json:"rule_id"`
+	ParameterName string `json:"parameter_name"`
+}
+
+// findingKeyOf returns the key of a check result
+func findingKeyOf(check rules.CheckResult) FindingKey {
+	return FindingKey{Component: check.Component, RuleID: check.RuleID, ParameterName: check.ParameterName}
+}
+
+// ResultComparison is the difference between two analyses of the same cluster (e.g., before and after a config change)
+type ResultComparison struct {
+	// BeforeVersions and AfterVersions are the source -> target versions of the compared analyses
+	BeforeVersions string `json:"before_versions"`
+	AfterVersions  string `json:"after_versions"`
+	// New are the findings of the after analysis that the before analysis didn't have
+	New []rules.CheckResult `json:"new"`
+	// Resolved are the findings of the before analysis that the after analysis doesn't have
+	Resolved []rules.CheckResult `json:"resolved"`
+	// Unchanged are the findings of both analyses, as reported by the after analysis
+	Unchanged []rules.CheckResult `json:"unchanged"`
+}
+
+// CompareAnalysisResults compares the findings of two analyses by (Component, RuleID, ParameterName)
+// A key reported several times (e.g., once per node) is matched occurrence by occurrence, the
+// occurrences only one analysis has are new or resolved. Findings keep the order of their analysis
+func CompareAnalysisResults(before, after *AnalysisResult) *ResultComparison {
+	comparison := &ResultComparison{}
+	var beforeChecks, afterChecks []rules.CheckResult
+	if before != nil {
+		comparison.BeforeVersions = before.SourceVersion + " -> " + before.TargetVersion
+		beforeChecks = before.CheckResults
+	}
+	if after != nil {
+		comparison.AfterVersions = after.SourceVersion + " -> " + after.TargetVersion
+		afterChecks = after.CheckResults
+	}
+
+	remaining := make(map[FindingKey]int)
+	for _, check := range beforeChecks {
+		remaining[findingKeyOf(check)]++
+	}
+	matched := make(map[FindingKey]int)
+	for _, check := range afterChecks {
+		key := findingKeyOf(check)
+		if remaining[key] > 0 {
+			remaining[key]--
+			matched[key]++
+			comparison.Unchanged = append(comparison.Unchanged, check)
+		} else {
+			comparison.New = append(comparison.New, check)
+		}
+	}
+	for _, check := range beforeChecks {
+		key := findingKeyOf(check)
+		if matched[key] > 0 {
+			matched[key]--
+		} else {
+			comparison.Resolved = append(comparison.Resolved, check)
+		}
+	}
+	return comparison
+}
+
+// HasRegression checks if the after analysis has new critical or error findings
+func (c *ResultComparison) HasRegression() bool {
+	for _, check := range c.New {
+		if isCriticalSeverity(check.Severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasImprovement checks if findings of the before analysis were resolved
+func (c *ResultComparison) HasImprovement() bool {
+	return len(c.Resolved) > 0
+}
@@ -0,0 +1,62 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareAnalysisResults(t *testing.T) {
+	before := &AnalysisResult{
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		CheckResults: []rules.CheckResult{
+			{RuleID: "USER_MODIFIED_PARAMS", Component: "tidb", ParameterName: "max-connections", Severity: "info"},
+			{RuleID: "UPGRADE_DIFFERENCES", Component: "tikv", ParameterName: "storage.engine", Severity: "critical"},
+			{RuleID: "TIKV_CONSISTENCY", Component: "tikv", ParameterName: "raftstore.store-pool-size", Severity: "warning"},
+		},
+	}
+	after := &AnalysisResult{
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		CheckResults: []rules.CheckResult{
+			{RuleID: "USER_MODIFIED_PARAMS", Component: "tidb", ParameterName: "max-connections", Severity: "info", Message: "after"},
+			{RuleID: "TIKV_CONSISTENCY", Component: "tikv", ParameterName: "raftstore.store-pool-size", Severity: "warning"},
+			// Reported twice (e.g., once per node): the second occurrence is new
+			{RuleID: "TIKV_CONSISTENCY", Component: "tikv", ParameterName: "raftstore.store-pool-size", Severity: "warning"},
+			{RuleID: "FORCED_CHANGES", Component: "tidb", ParameterName: "tidb_enable_async_commit", Severity: "warning"},
+		},
+	}
+
+	comparison := CompareAnalysisResults(before, after)
+	assert.Equal(t, "v7.5.0 -> v8.5.0", comparison.BeforeVersions)
+	if assert.Len(t, comparison.Unchanged, 2) {
+		assert.Equal(t, "after", comparison.Unchanged[0].Message, "unchanged findings are reported as in the after analysis")
+	}
+	if assert.Len(t, comparison.New, 2) {
+		assert.Equal(t, "raftstore.store-pool-size", comparison.New[0].ParameterName)
+		assert.Equal(t, "tidb_enable_async_commit", comparison.New[1].ParameterName)
+	}
+	if assert.Len(t, comparison.Resolved, 1) {
+		assert.Equal(t, "storage.engine", comparison.Resolved[0].ParameterName)
+	}
+	assert.False(t, comparison.HasRegression(), "no new critical or error finding")
+	assert.True(t, comparison.HasImprovement())
+
+	// The same analysis compared in reverse
+	reverse := CompareAnalysisResults(after, before)
+	assert.True(t, reverse.HasRegression(), "storage.engine is a new critical finding")
+	assert.False(t, CompareAnalysisResults(before, before).HasRegression())
+	assert.False(t, CompareAnalysisResults(before, before).HasImprovement())
+}
+
+func TestCompareAnalysisResults_Nil(t *testing.T) {
+	after := &AnalysisResult{CheckResults: []rules.CheckResult{
+		{RuleID: "FORCED_CHANGES", Component: "tidb", ParameterName: "tidb_enable_async_commit", Severity: "error"},
+	}}
+	comparison := CompareAnalysisResults(nil, after)
+	assert.Len(t, comparison.New, 1)
+	assert.True(t, comparison.HasRegression())
+	assert.Empty(t, CompareAnalysisResults(after, nil).New)
+}
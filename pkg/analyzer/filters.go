@@ -160,6 +160,46 @@ var globalFilterConfig = &FilterConfig{
 	},
 }
 
+// filterMatchers holds matchers precomputed from globalFilterConfig, so that
+// ShouldFilterParameter does not rebuild keyword variants for every parameter
+var filterMatchers = newKeywordMatchers(globalFilterConfig)
+
+// keywordMatchers are the precomputed forms of the FilterConfig keywords
+type keywordMatchers struct {
+	// hostSuffixes are the host keywords prefixed with "."
+	hostSuffixes []string
+	// hostPrefixes are the host keywords suffixed with "."
+	hostPrefixes []string
+	// pathKeywords are the path keywords without those containing another path keyword,
+	// which can never change the result of a Contains check
+	pathKeywords []string
+}
+
+func newKeywordMatchers(config *FilterConfig) *keywordMatchers {
+	m := &keywordMatchers{
+		hostSuffixes: make([]string, 0, len(config.HostKeywords)),
+		hostPrefixes: make([]string, 0, len(config.HostKeywords)),
+	}
+	for _, keyword := range config.HostKeywords {
+		m.hostSuffixes = append(m.hostSuffixes, "."+keyword)
+		m.hostPrefixes = append(m.hostPrefixes, keyword+".")
+	}
+
+	for i, keyword := range config.PathKeywords {
+		redundant := false
+		for j, other := range config.PathKeywords {
+			if i != j && other != keyword && strings.Contains(keyword, other) {
+				redundant = true
+				break
+			}
+		}
+		if !redundant {
+			m.pathKeywords = append(m.pathKeywords, keyword)
+		}
+	}
+	return m
+}
+
 // ShouldFilterParameter checks if a parameter should be filtered during preprocessing
 // Returns (shouldFilter, filterReason)
 func ShouldFilterParameter(paramName string) (bool, string) {
@@ -176,14 +216,14 @@ func ShouldFilterParameter(paramName string) (bool, string) {
 	}
 
 	// Check host/network keywords
-	for _, keyword := range globalFilterConfig.HostKeywords {
-		if paramNameLower == keyword || strings.HasSuffix(paramNameLower, "."+keyword) || strings.HasPrefix(paramNameLower, keyword+".") {
+	for i, keyword := range globalFilterConfig.HostKeywords {
+		if paramNameLower == keyword || strings.HasSuffix(paramNameLower, filterMatchers.hostSuffixes[i]) || strings.HasPrefix(paramNameLower, filterMatchers.hostPrefixes[i]) {
 			return true, "host/network parameter (deployment-specific)"
 		}
 	}
 
 	// Check path keywords
-	for _, keyword := range globalFilterConfig.PathKeywords {
+	for _, keyword := range filterMatchers.pathKeywords {
 		if strings.Contains(paramNameLower, keyword) {
 			return true, "path parameter (deployment-specific)"
 		}
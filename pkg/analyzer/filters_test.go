@@ -26,8 +26,8 @@ func TestShouldFilterParameter_ExactMatch(t *testing.T) {
 			filterReason: "deployment-specific parameter (exact match)",
 		},
 		{
-			name:         "exact match - log.file.filename",
-			paramName:    "log.file.filename",
+			name:         "exact match - log.file.max-size",
+			paramName:    "log.file.max-size",
 			shouldFilter: true,
 			filterReason: "deployment-specific parameter (exact match)",
 		},
@@ -220,7 +220,7 @@ func TestGetIgnoredParamsMapForUpgradeDifferences(t *testing.T) {
 	// Check some known parameters
 	assert.True(t, ignoredParams["host"])
 	assert.True(t, ignoredParams["data-dir"])
-	assert.True(t, ignoredParams["log.file.filename"])
+	assert.True(t, ignoredParams["log.file.max-size"])
 	assert.True(t, ignoredParams["version_compile_machine"])
 
 	// Check non-ignored parameter
@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// ValidateIgnorePatterns checks that the ignore patterns are valid glob patterns (see AnalysisOptions.IgnorePatterns)
+func ValidateIgnorePatterns(patterns []string) error {
+	for _, pattern := range patterns {
+		if pattern == "" {
+			return fmt.Errorf("empty ignore pattern")
+		}
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// matchIgnorePattern returns the first pattern matching the parameter of a check result
+// Results without a parameter (e.g., cluster-level findings) are never ignored
+func matchIgnorePattern(patterns []string, check rules.CheckResult) (string, bool) {
+	if check.ParameterName == "" {
+		return "", false
+	}
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, check.ParameterName); matched {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// applyIgnorePatterns removes the results whose parameter matches an ignore pattern
+// The removed results are returned marked as ignored, with the pattern that matched
+func applyIgnorePatterns(patterns []string, results []rules.CheckResult) (kept, ignored []rules.CheckResult) {
+	if len(patterns) == 0 {
+		return results, nil
+	}
+	kept = make([]rules.CheckResult, 0, len(results))
+	for _, check := range results {
+		pattern, ok := matchIgnorePattern(patterns, check)
+		if !ok {
+			kept = append(kept, check)
+			continue
+		}
+		check.Ignored = true
+		check.IgnoreReason = fmt.Sprintf("matches ignore pattern %q", pattern)
+		ignored = append(ignored, check)
+	}
+	return kept, ignored
+}
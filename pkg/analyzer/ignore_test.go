@@ -0,0 +1,45 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateIgnorePatterns(t *testing.T) {
+	assert.NoError(t, ValidateIgnorePatterns([]string{"rocksdb.*", "tidb_ddl_reorg_batch_size", "raftstore.[a-m]*"}))
+	assert.ErrorContains(t, ValidateIgnorePatterns([]string{""}), "empty ignore pattern")
+	assert.ErrorContains(t, ValidateIgnorePatterns([]string{"rocksdb.["}), `invalid ignore pattern "rocksdb.["`)
+}
+
+func TestAnalyzer_organizeResults_IgnorePatterns(t *testing.T) {
+	checkResults := []rules.CheckResult{
+		{RuleID: "USER_MODIFIED_PARAMS", Category: "user_modified", Component: "tikv", ParameterName: "rocksdb.max-open-files", ParamType: "config", Severity: "warning"},
+		{RuleID: "USER_MODIFIED_PARAMS", Category: "user_modified", Component: "tidb", ParameterName: "tidb_ddl_reorg_batch_size", ParamType: "system_variable", Severity: "warning"},
+		{RuleID: "USER_MODIFIED_PARAMS", Category: "user_modified", Component: "tidb", ParameterName: "tidb_ddl_reorg_worker_cnt", ParamType: "system_variable", Severity: "warning"},
+		// Results without a parameter are never ignored
+		{RuleID: "MIXED_VERSION_CLUSTER", Severity: "error"},
+	}
+	patterns := []string{"rocksdb.*", "tidb_ddl_reorg_batch_size"}
+
+	result := NewAnalyzer(&AnalysisOptions{IgnorePatterns: patterns}).organizeResults(checkResults, nil, "v7.5.0", "v8.5.0")
+	require.Len(t, result.CheckResults, 2)
+	assert.Equal(t, "tidb_ddl_reorg_worker_cnt", result.CheckResults[0].ParameterName)
+	assert.Equal(t, "MIXED_VERSION_CLUSTER", result.CheckResults[1].RuleID)
+	assert.Equal(t, map[string]map[string]ModifiedParamInfo{
+		"tidb": {"tidb_ddl_reorg_worker_cnt": {Component: "tidb", ParamName: "tidb_ddl_reorg_worker_cnt", ParamType: "system_variable"}},
+	}, result.ModifiedParams)
+	assert.Equal(t, 2, result.Statistics.FindingsIgnored)
+	assert.Empty(t, result.IgnoredResults, "ignored results are only kept with ShowIgnored")
+
+	result = NewAnalyzer(&AnalysisOptions{IgnorePatterns: patterns, ShowIgnored: true}).organizeResults(checkResults, nil, "v7.5.0", "v8.5.0")
+	assert.Len(t, result.CheckResults, 2)
+	require.Len(t, result.IgnoredResults, 2)
+	for _, check := range result.IgnoredResults {
+		assert.True(t, check.Ignored)
+	}
+	assert.Equal(t, `matches ignore pattern "tidb_ddl_reorg_batch_size"`, result.IgnoredResults[0].IgnoreReason)
+	assert.Equal(t, `matches ignore pattern "rocksdb.*"`, result.IgnoredResults[1].IgnoreReason)
+}
@@ -0,0 +1,279 @@
+// Package analyzer provides risk analysis logic for upgrade precheck
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// detectMixedVersions checks whether component instances report different versions
+// A mixed-version cluster typically results from a partially completed previous upgrade,
+// e.g., TiKV nodes already run the target version while TiDB is still on the source version
+// Returns nil if all instances run the cluster source version (or versions are unknown)
+func detectMixedVersions(snapshot *collector.ClusterSnapshot, sourceVersion string) *MixedVersionInfo {
+	nodes := collectNodeVersions(snapshot)
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	clusterVersion := types.NormalizeVersion(sourceVersion)
+	distinctVersions := make(map[string]bool)
+	mixed := false
+	for _, node := range nodes {
+		if node.Version == "" {
+			continue
+		}
+		distinctVersions[node.Version] = true
+		if clusterVersion != "" && node.Version != clusterVersion {
+			mixed = true
+		}
+	}
+	// Without a known cluster version, fall back to comparing instances with each other
+	if clusterVersion == "" && len(distinctVersions) > 1 {
+		mixed = true
+	}
+
+	if !mixed {
+		return nil
+	}
+
+	return &MixedVersionInfo{
+		ClusterVersion: sourceVersion,
+		Nodes:          nodes,
+	}
+}
+
+// collectNodeVersions returns the version of every component instance in the snapshot
+// Uses the versions recorded by the collector if available, otherwise derives them from component states
+// Results are sorted by component and address for stable output
+func collectNodeVersions(snapshot *collector.ClusterSnapshot) []types.NodeVersion {
+	var nodes []types.NodeVersion
+
+	if len(snapshot.NodeVersions) > 0 {
+		nodes = append(nodes, snapshot.NodeVersions...)
+	} else {
+		// Derive from component states (e.g., snapshots loaded from file)
+		// "tikv" and "tiflash" keys alias the first node, so skip duplicates by component and address
+		seen := make(map[string]bool)
+		names := make([]string, 0, len(snapshot.Components))
+		for name := range snapshot.Components {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			comp := snapshot.Components[name]
+			if comp.Version == "" {
+				continue
+			}
+			addr := name
+			if addrFromStatus, ok := comp.Status["address"].(string); ok && addrFromStatus != "" {
+				addr = addrFromStatus
+			}
+			key := fmt.Sprintf("%s:%s", comp.Type, addr)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			nodes = append(nodes, types.NodeVersion{
+				Component:  comp.Type,
+				Address:    addr,
+				Version:    types.NormalizeVersion(comp.Version),
+				RawVersion: comp.Version,
+			})
+		}
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Component != nodes[j].Component {
+			return nodes[i].Component < nodes[j].Component
+		}
+		return nodes[i].Address < nodes[j].Address
+	})
+
+	return nodes
+}
+
+// applyInstanceVersionDefaults loads the source defaults of the versions the component instances run, for
+// instances whose version differs from the cluster source version
+// The user-modified comparison of such an instance must use the defaults of the version it actually runs,
+// otherwise every default changed in between would be reported as modified. The instances of a component may
+// run different versions (e.g., a rolling upgrade of TiKV stopped halfway), so the defaults are kept per version.
+// When all the instances of a component run the same version, the source defaults of the component are replaced
+// as well, so that the other rules use them too.
+// Target defaults are not touched: upgrade differences are still computed against the global target KB.
+// Returns the loaded defaults (version -> component type -> defaults), and a map of component type to the version
+// whose KB replaced the source defaults
+func (a *Analyzer) applyInstanceVersionDefaults(
+	snapshot *collector.ClusterSnapshot,
+	componentMapping map[string]string,
+	sourceVersion string,
+	sourceDefaults map[string]map[string]interface{},
+	req rules.DataSourceRequirement,
+) (map[string]map[string]map[string]interface{}, map[string]string) {
+	versionDefaults := make(map[string]map[string]map[string]interface{})
+	componentVersions := make(map[string]string)
+	clusterVersion := types.NormalizeVersion(sourceVersion)
+	if clusterVersion == "" {
+		return versionDefaults, componentVersions
+	}
+
+	for compType := range componentMapping {
+		// Instances of unknown version are assumed to run the cluster source version
+		versions := make(map[string]bool)
+		for _, instance := range snapshot.ComponentsByType(types.ComponentType(compType)) {
+			version := types.NormalizeVersion(instance.Version)
+			if version == "" {
+				version = clusterVersion
+			}
+			versions[version] = true
+		}
+
+		for _, version := range sortedVersions(versions) {
+			if version == clusterVersion {
+				continue
+			}
+			kb, err := a.loadKBForVersion(version)
+			if err != nil {
+				fmt.Printf("[WARNING applyInstanceVersionDefaults] Failed to load knowledge base %s for %s, using cluster source version defaults: %v\n", version, compType, err)
+				continue
+			}
+
+			instanceDefaults, _ := a.loadKBFromRequirements(
+				kb,
+				[]string{compType},
+				req.SourceKBRequirements.NeedConfigDefaults,
+				req.SourceKBRequirements.NeedSystemVariables,
+			)
+			if len(instanceDefaults[compType]) == 0 {
+				fmt.Printf("[WARNING applyInstanceVersionDefaults] Knowledge base %s has no defaults for %s, using cluster source version defaults\n", version, compType)
+				continue
+			}
+
+			fmt.Printf("[DEBUG applyInstanceVersionDefaults] Using %s defaults for the %s instances running %s (cluster source version is %s)\n",
+				version, compType, version, clusterVersion)
+			if versionDefaults[version] == nil {
+				versionDefaults[version] = make(map[string]map[string]interface{})
+			}
+			versionDefaults[version][compType] = instanceDefaults[compType]
+			if len(versions) == 1 {
+				sourceDefaults[compType] = instanceDefaults[compType]
+				componentVersions[compType] = version
+			}
+		}
+	}
+
+	return versionDefaults, componentVersions
+}
+
+// componentInstanceVersions returns, for the components whose instances run different versions, the versions
+// whose defaults the instances are compared against (see applyInstanceVersionDefaults), sorted
+func componentInstanceVersions(versionDefaults map[string]map[string]map[string]interface{}, componentVersions map[string]string, sourceVersion string) map[string][]string {
+	versionSets := make(map[string]map[string]bool)
+	for version, components := range versionDefaults {
+		for compType := range components {
+			if _, replaced := componentVersions[compType]; replaced {
+				continue
+			}
+			if versionSets[compType] == nil {
+				versionSets[compType] = map[string]bool{types.NormalizeVersion(sourceVersion): true}
+			}
+			versionSets[compType][version] = true
+		}
+	}
+	if len(versionSets) == 0 {
+		return nil
+	}
+	instanceVersions := make(map[string][]string, len(versionSets))
+	for compType, versions := range versionSets {
+		instanceVersions[compType] = sortedVersions(versions)
+	}
+	return instanceVersions
+}
+
+// sortedVersions returns the versions of a set, sorted for stable output
+func sortedVersions(versions map[string]bool) []string {
+	sorted := make([]string, 0, len(versions))
+	for version := range versions {
+		sorted = append(sorted, version)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+// withoutPreprocessedInstanceDefaults removes from the defaults of each version the parameters the preprocessor
+// removed from the source defaults (deployment-specific parameters, ...), so that the instances of every version
+// are compared alike. Parameters only known to the version are kept
+func withoutPreprocessedInstanceDefaults(versionDefaults map[string]map[string]map[string]interface{}, sourceDefaults, cleanedSourceDefaults map[string]map[string]interface{}) map[string]map[string]map[string]interface{} {
+	filtered := make(map[string]map[string]map[string]interface{}, len(versionDefaults))
+	for version, components := range versionDefaults {
+		filtered[version] = make(map[string]map[string]interface{}, len(components))
+		for compType, params := range components {
+			filtered[version][compType] = make(map[string]interface{}, len(params))
+			for name, value := range params {
+				_, known := sourceDefaults[compType][name]
+				_, kept := cleanedSourceDefaults[compType][name]
+				if kept || !known {
+					filtered[version][compType][name] = value
+				}
+			}
+		}
+	}
+	return filtered
+}
+
+// loadKBForVersion loads the knowledge base for a specific version
+// Loaded knowledge bases are cached per version, so each version is read at most once per analyzer
+func (a *Analyzer) loadKBForVersion(version string) (map[string]interface{}, error) {
+	if kb, ok := a.kbCache[version]; ok {
+		return kb, nil
+	}
+
+	if a.options.KnowledgeBasePath == "" {
+		return nil, fmt.Errorf("knowledge base path is not configured")
+	}
+
+	kb, err := collector.LoadKnowledgeBase(a.options.KnowledgeBasePath, version)
+	if err != nil {
+		return nil, err
+	}
+	a.kbCache[version] = kb
+	return kb, nil
+}
+
+// buildMixedVersionCheckResult builds a validation CheckResult describing the mixed-version state
+func buildMixedVersionCheckResult(info *MixedVersionInfo) rules.CheckResult {
+	var nodeLines []string
+	for _, node := range info.Nodes {
+		version := node.Version
+		if version == "" {
+			version = "unknown"
+		}
+		nodeLines = append(nodeLines, fmt.Sprintf("%s %s: %s", node.Component, node.Address, version))
+	}
+
+	suggestions := []string{
+		"Complete or roll back the previous upgrade so that all instances run the same version",
+		"Verify the upgrade path from each instance's actual version to the target version",
+	}
+	if len(info.ComponentSourceVersions) > 0 || len(info.ComponentInstanceVersions) > 0 {
+		suggestions = append(suggestions, "User-modified parameters of components running a different version were compared against that version's defaults")
+	}
+
+	return rules.CheckResult{
+		RuleID:      "MIXED_VERSION_CLUSTER",
+		Category:    "validation",
+		Severity:    "warning",
+		RiskLevel:   rules.RiskLevelHigh,
+		Message:     fmt.Sprintf("Cluster is running mixed versions (expected %s on all instances)", info.ClusterVersion),
+		Details:     "Instance versions:\n" + strings.Join(nodeLines, "\n"),
+		Suggestions: suggestions,
+		Metadata: map[string]interface{}{
+			"mixed_version": true,
+		},
+	}
+}
@@ -0,0 +1,220 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectMixedVersions(t *testing.T) {
+	tests := []struct {
+		name          string
+		snapshot      *collector.ClusterSnapshot
+		sourceVersion string
+		wantMixed     bool
+	}{
+		{
+			name: "all instances on source version",
+			snapshot: &collector.ClusterSnapshot{
+				NodeVersions: []types.NodeVersion{
+					{Component: types.ComponentTiDB, Address: "127.0.0.1:4000", Version: "v7.5.0"},
+					{Component: types.ComponentTiKV, Address: "127.0.0.1:20180", Version: "v7.5.0"},
+				},
+			},
+			sourceVersion: "v7.5.0",
+			wantMixed:     false,
+		},
+		{
+			name: "tikv already upgraded",
+			snapshot: &collector.ClusterSnapshot{
+				NodeVersions: []types.NodeVersion{
+					{Component: types.ComponentTiDB, Address: "127.0.0.1:4000", Version: "v7.5.0"},
+					{Component: types.ComponentTiKV, Address: "127.0.0.1:20180", Version: "v8.5.0"},
+				},
+			},
+			sourceVersion: "v7.5.0",
+			wantMixed:     true,
+		},
+		{
+			name: "derived from component states",
+			snapshot: &collector.ClusterSnapshot{
+				Components: map[string]collector.ComponentState{
+					"tidb":                 {Type: types.ComponentTiDB, Version: "5.7.25-TiDB-v7.5.0"},
+					"tikv":                 {Type: types.ComponentTiKV, Version: "7.5.0", Status: map[string]interface{}{"address": "127.0.0.1:20180"}},
+					"tikv-127-0-0-1-20180": {Type: types.ComponentTiKV, Version: "7.5.0", Status: map[string]interface{}{"address": "127.0.0.1:20180"}},
+					"tikv-127-0-0-1-20181": {Type: types.ComponentTiKV, Version: "8.5.0", Status: map[string]interface{}{"address": "127.0.0.1:20181"}},
+				},
+			},
+			sourceVersion: "v7.5.0",
+			wantMixed:     true,
+		},
+		{
+			name: "unknown source version with differing instances",
+			snapshot: &collector.ClusterSnapshot{
+				NodeVersions: []types.NodeVersion{
+					{Component: types.ComponentTiDB, Address: "127.0.0.1:4000", Version: "v7.5.0"},
+					{Component: types.ComponentPD, Address: "127.0.0.1:2379", Version: "v8.1.0"},
+				},
+			},
+			sourceVersion: "",
+			wantMixed:     true,
+		},
+		{
+			name: "no versions",
+			snapshot: &collector.ClusterSnapshot{
+				Components: make(map[string]collector.ComponentState),
+			},
+			sourceVersion: "v7.5.0",
+			wantMixed:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := detectMixedVersions(tt.snapshot, tt.sourceVersion)
+			if !tt.wantMixed {
+				assert.Nil(t, info)
+				return
+			}
+			require.NotNil(t, info)
+			assert.Equal(t, tt.sourceVersion, info.ClusterVersion)
+			assert.NotEmpty(t, info.Nodes)
+		})
+	}
+}
+
+func TestCollectNodeVersions_DeduplicatesAliases(t *testing.T) {
+	snapshot := &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{
+			"tikv":                 {Type: types.ComponentTiKV, Version: "7.5.0", Status: map[string]interface{}{"address": "127.0.0.1:20180"}},
+			"tikv-127-0-0-1-20180": {Type: types.ComponentTiKV, Version: "7.5.0", Status: map[string]interface{}{"address": "127.0.0.1:20180"}},
+		},
+	}
+
+	nodes := collectNodeVersions(snapshot)
+	require.Len(t, nodes, 1)
+	assert.Equal(t, "v7.5.0", nodes[0].Version)
+	assert.Equal(t, "127.0.0.1:20180", nodes[0].Address)
+}
+
+func TestAnalyzer_Analyze_MixedVersion(t *testing.T) {
+	// TiKV already runs v8.1.0 while TiDB is still on the source version v7.5.0
+	snapshot := &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{
+			"tidb": {
+				Type:    types.ComponentTiDB,
+				Version: "5.7.25-TiDB-v7.5.0",
+				Config:  types.ParameterMap{},
+			},
+			"tikv": {
+				Type:    types.ComponentTiKV,
+				Version: "8.1.0",
+				Config: types.ParameterMap{
+					"raftstore.messages-per-tick": types.ParameterValue{Value: "4096", Type: "string"},
+				},
+				Status: map[string]interface{}{"address": "127.0.0.1:20180"},
+			},
+		},
+		NodeVersions: []types.NodeVersion{
+			{Component: types.ComponentTiDB, Address: "127.0.0.1:4000", Version: "v7.5.0"},
+			{Component: types.ComponentTiKV, Address: "127.0.0.1:20180", Version: "v8.1.0"},
+		},
+	}
+	newTiKVKB := func(messagesPerTick string) map[string]interface{} {
+		return map[string]interface{}{
+			"tikv": map[string]interface{}{
+				"config_defaults": map[string]interface{}{
+					"raftstore.messages-per-tick": map[string]interface{}{"value": messagesPerTick, "type": "string"},
+				},
+			},
+		}
+	}
+
+	analyzer := NewAnalyzer(&AnalysisOptions{
+		Rules: []rules.Rule{rules.NewUserModifiedParamsRule()},
+	})
+	// Pre-populate the per-version cache instead of loading from disk
+	analyzer.kbCache["v8.1.0"] = newTiKVKB("4096")
+
+	result, err := analyzer.Analyze(context.Background(), snapshot, "v7.5.0", "v8.5.0", newTiKVKB("1024"), newTiKVKB("8192"))
+	require.NoError(t, err)
+
+	require.NotNil(t, result.MixedVersion)
+	assert.Equal(t, "v8.1.0", result.MixedVersion.ComponentSourceVersions["tikv"])
+	assert.Len(t, result.MixedVersion.Nodes, 2)
+
+	// TiKV value equals the defaults of the version it actually runs, so it is not user-modified
+	assert.Empty(t, result.ModifiedParams["tikv"])
+
+	hasMixedVersionResult := false
+	for _, check := range result.CheckResults {
+		if check.RuleID == "MIXED_VERSION_CLUSTER" {
+			hasMixedVersionResult = true
+		}
+	}
+	assert.True(t, hasMixedVersionResult)
+}
+
+func TestAnalyzer_Analyze_MixedVersionTiKVNodes(t *testing.T) {
+	// A rolling upgrade of TiKV stopped halfway: one node runs v8.1.0, the other one is still on v7.5.0
+	oldNode := collector.ComponentState{
+		Type:    types.ComponentTiKV,
+		Version: "7.5.0",
+		Config: types.ParameterMap{
+			"raftstore.messages-per-tick": types.ParameterValue{Value: "1024", Type: "string"},
+			"raftstore.store-pool-size":   types.ParameterValue{Value: "2", Type: "string"},
+		},
+		Status: map[string]interface{}{"address": "127.0.0.1:20180"},
+	}
+	newNode := collector.ComponentState{
+		Type:    types.ComponentTiKV,
+		Version: "8.1.0",
+		Config: types.ParameterMap{
+			"raftstore.messages-per-tick": types.ParameterValue{Value: "4096", Type: "string"},
+			"raftstore.store-pool-size":   types.ParameterValue{Value: "4", Type: "string"},
+		},
+		Status: map[string]interface{}{"address": "127.0.0.1:20181"},
+	}
+	snapshot := &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{
+			"tidb":                 {Type: types.ComponentTiDB, Version: "5.7.25-TiDB-v7.5.0", Config: types.ParameterMap{}},
+			"tikv":                 oldNode,
+			"tikv-127-0-0-1-20180": oldNode,
+			"tikv-127-0-0-1-20181": newNode,
+		},
+	}
+	newTiKVKB := func(messagesPerTick string) map[string]interface{} {
+		return map[string]interface{}{
+			"tikv": map[string]interface{}{
+				"config_defaults": map[string]interface{}{
+					"raftstore.messages-per-tick": map[string]interface{}{"value": messagesPerTick, "type": "string"},
+					"raftstore.store-pool-size":   map[string]interface{}{"value": "2", "type": "string"},
+				},
+			},
+		}
+	}
+
+	analyzer := NewAnalyzer(&AnalysisOptions{
+		Rules: []rules.Rule{rules.NewUserModifiedParamsRule()},
+	})
+	analyzer.kbCache["v8.1.0"] = newTiKVKB("4096")
+
+	result, err := analyzer.Analyze(context.Background(), snapshot, "v7.5.0", "v8.5.0", newTiKVKB("1024"), newTiKVKB("8192"))
+	require.NoError(t, err)
+
+	// The nodes run different versions, so the source defaults of TiKV are not replaced as a whole
+	require.NotNil(t, result.MixedVersion)
+	assert.Empty(t, result.MixedVersion.ComponentSourceVersions)
+	assert.Equal(t, map[string][]string{"tikv": {"v7.5.0", "v8.1.0"}}, result.MixedVersion.ComponentInstanceVersions)
+
+	// Each node is compared against the defaults of its version: messages-per-tick is the default of both,
+	// store-pool-size is modified on the v8.1.0 node only
+	require.Contains(t, result.ModifiedParams["tikv"], "raftstore.store-pool-size")
+	assert.Equal(t, "4", result.ModifiedParams["tikv"]["raftstore.store-pool-size"].CurrentValue)
+	assert.NotContains(t, result.ModifiedParams["tikv"], "raftstore.messages-per-tick")
+}
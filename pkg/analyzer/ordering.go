@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// SortCheckResults sorts check results in place by component (tidb, pd, tikv, tiflash, other components
+// sorted, then cluster-level results), parameter name, rule ID and parameter type
+// Rules produce results in map iteration order, so sorting them makes the reports of two runs on
+// the same cluster identical. The sort is stable: results with the same keys keep their order
+func SortCheckResults(results []rules.CheckResult) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Component != b.Component {
+			rankA, rankB := componentRank(a.Component), componentRank(b.Component)
+			if rankA != rankB {
+				return rankA < rankB
+			}
+			return a.Component < b.Component
+		}
+		if a.ParameterName != b.ParameterName {
+			return a.ParameterName < b.ParameterName
+		}
+		if a.RuleID != b.RuleID {
+			return a.RuleID < b.RuleID
+		}
+		return a.ParamType < b.ParamType
+	})
+}
+
+// componentRank returns the rank of a component in the summary order
+// Components outside of summaryComponentOrder share a rank (they are then sorted by name),
+// and results without a component come last
+func componentRank(component string) int {
+	if component == "" {
+		return len(summaryComponentOrder) + 1
+	}
+	for i, c := range summaryComponentOrder {
+		if c == component {
+			return i
+		}
+	}
+	return len(summaryComponentOrder)
+}
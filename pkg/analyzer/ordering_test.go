@@ -0,0 +1,37 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortCheckResults(t *testing.T) {
+	results := []rules.CheckResult{
+		{Component: "", RuleID: "UPGRADE_PATH"},
+		{Component: "tikv", ParameterName: "b", RuleID: "USER_MODIFIED_PARAMS"},
+		{Component: "ticdc", ParameterName: "a", RuleID: "USER_MODIFIED_PARAMS"},
+		{Component: "tidb", ParameterName: "x", RuleID: "UPGRADE_DIFFERENCES", ParamType: "system_variable"},
+		{Component: "tidb", ParameterName: "x", RuleID: "UPGRADE_DIFFERENCES", ParamType: "config"},
+		{Component: "tidb", ParameterName: "x", RuleID: "GOLDEN_CONFIG"},
+		{Component: "pd", ParameterName: "a", RuleID: "USER_MODIFIED_PARAMS"},
+		{Component: "tikv", ParameterName: "a", RuleID: "TIKV_CONSISTENCY"},
+	}
+	SortCheckResults(results)
+
+	var order []string
+	for _, r := range results {
+		order = append(order, r.Component+"/"+r.ParameterName+"/"+r.RuleID+"/"+r.ParamType)
+	}
+	assert.Equal(t, []string{
+		"tidb/x/GOLDEN_CONFIG/",
+		"tidb/x/UPGRADE_DIFFERENCES/config",
+		"tidb/x/UPGRADE_DIFFERENCES/system_variable",
+		"pd/a/USER_MODIFIED_PARAMS/",
+		"tikv/a/TIKV_CONSISTENCY/",
+		"tikv/b/USER_MODIFIED_PARAMS/",
+		"ticdc/a/USER_MODIFIED_PARAMS/",
+		"//UPGRADE_PATH/",
+	}, order)
+}
@@ -37,11 +37,11 @@ func (a *Analyzer) preprocessParameters(
 	snapshot *collector.ClusterSnapshot,
 	sourceVersion, targetVersion string,
 	sourceDefaults, targetDefaults map[string]map[string]interface{},
-	upgradeLogic map[string]interface{},
 	parameterNotes map[string]interface{},
 	sourceBootstrapVersion, targetBootstrapVersion int64,
 ) ([]rules.CheckResult, map[string]map[string]interface{}, map[string]map[string]interface{}) {
 	var preprocessedResults []rules.CheckResult
+	var details strings.Builder
 
 	// Create cleaned defaults maps (will remove processed parameters)
 	cleanedSourceDefaults := make(map[string]map[string]interface{})
@@ -79,7 +79,7 @@ func (a *Analyzer) preprocessParameters(
 
 			// Check if this parameter should be filtered (deployment-specific, path parameters, etc.)
 			shouldFilter, filterReason := ShouldFilterParameter(displayName)
-			if !shouldFilter {
+			if !shouldFilter && isSystemVar {
 				// Also check with full paramName (for system variables with "sysvar:" prefix)
 				shouldFilter, filterReason = ShouldFilterParameter(paramName)
 			}
@@ -195,24 +195,31 @@ func (a *Analyzer) preprocessParameters(
 				}
 
 				// Build details message
-				details := fmt.Sprintf("This parameter has been filtered from detailed analysis.\nReason: %s", filterReason)
+				// A single builder avoids re-allocating the message for every appended line
+				details.Reset()
+				details.Grow(256)
+				details.WriteString("This parameter has been filtered from detailed analysis.\nReason: ")
+				details.WriteString(filterReason)
 				if currentValue != nil {
-					details += fmt.Sprintf("\n\nCurrent Value: %v", rules.FormatValue(currentValue))
+					details.WriteString("\n\nCurrent Value: ")
+					details.WriteString(rules.FormatValue(currentValue))
 				}
 				if sourceDefault != nil {
-					details += fmt.Sprintf("\nSource Default: %v", rules.FormatValue(sourceDefault))
+					details.WriteString("\nSource Default: ")
+					details.WriteString(rules.FormatValue(sourceDefault))
 				}
 				if targetDefault != nil {
-					details += fmt.Sprintf("\nTarget Default: %v", rules.FormatValue(targetDefault))
+					details.WriteString("\nTarget Default: ")
+					details.WriteString(rules.FormatValue(targetDefault))
 				}
 
 				// Add note about why it's filtered
 				if strings.Contains(filterReason, "deployment-specific") || strings.Contains(filterReason, "path parameter") {
-					details += "\n\nNote: This parameter varies by deployment environment and does not require user action during upgrade."
+					details.WriteString("\n\nNote: This parameter varies by deployment environment and does not require user action during upgrade.")
 				} else if strings.Contains(filterReason, "resource-dependent") {
-					details += "\n\nNote: This parameter is automatically adjusted by the system based on available resources (CPU cores, memory, etc.)."
+					details.WriteString("\n\nNote: This parameter is automatically adjusted by the system based on available resources (CPU cores, memory, etc.).")
 				} else if strings.Contains(filterReason, "all values identical") {
-					details += "\n\nNote: All values (current, source default, target default) are identical. No action needed."
+					details.WriteString("\n\nNote: All values (current, source default, target default) are identical. No action needed.")
 				}
 
 				preprocessedResults = append(preprocessedResults, rules.CheckResult{
@@ -223,8 +230,8 @@ func (a *Analyzer) preprocessParameters(
 					ParamType:     paramType,
 					Severity:      severity,
 					RiskLevel:     rules.RiskLevelLow,
-					Message:       fmt.Sprintf("Parameter %s in %s: %s", displayName, compType, filterReason),
-					Details:       details,
+					Message:       "Parameter " + displayName + " in " + compType + ": " + filterReason,
+					Details:       details.String(),
 					CurrentValue:  currentValue,
 					SourceDefault: sourceDefault,
 					TargetDefault: targetDefault,
@@ -275,7 +282,7 @@ func (a *Analyzer) preprocessParameters(
 
 				// Check if should be filtered
 				shouldFilter, filterReason := ShouldFilterParameter(displayName)
-				if !shouldFilter {
+				if !shouldFilter && isSystemVar {
 					// Also check with full paramName (for system variables with "sysvar:" prefix)
 					shouldFilter, filterReason = ShouldFilterParameter(paramName)
 				}
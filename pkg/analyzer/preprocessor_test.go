@@ -14,7 +14,7 @@ func TestPreprocessParameters_FilterPathParameters(t *testing.T) {
 	snapshot := &collector.ClusterSnapshot{
 		Components: map[string]collector.ComponentState{
 			"tidb": {
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"data-dir":        types.ParameterValue{Value: "/data/tidb", Type: "string"},
 					"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 				},
@@ -40,7 +40,7 @@ func TestPreprocessParameters_FilterPathParameters(t *testing.T) {
 		snapshot,
 		"v7.5.0", "v8.0.0",
 		sourceDefaults, targetDefaults,
-		nil, nil,
+		nil,
 		0, 0,
 	)
 
@@ -70,7 +70,7 @@ func TestPreprocessParameters_FilterIdenticalValues(t *testing.T) {
 	snapshot := &collector.ClusterSnapshot{
 		Components: map[string]collector.ComponentState{
 			"tidb": {
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 				},
 			},
@@ -93,7 +93,7 @@ func TestPreprocessParameters_FilterIdenticalValues(t *testing.T) {
 		snapshot,
 		"v7.5.0", "v8.0.0",
 		sourceDefaults, targetDefaults,
-		nil, nil,
+		nil,
 		0, 0,
 	)
 
@@ -119,7 +119,7 @@ func TestPreprocessParameters_KeepDifferentValues(t *testing.T) {
 	snapshot := &collector.ClusterSnapshot{
 		Components: map[string]collector.ComponentState{
 			"tidb": {
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 				},
 			},
@@ -142,7 +142,7 @@ func TestPreprocessParameters_KeepDifferentValues(t *testing.T) {
 		snapshot,
 		"v7.5.0", "v8.0.0",
 		sourceDefaults, targetDefaults,
-		nil, nil,
+		nil,
 		0, 0,
 	)
 
@@ -164,7 +164,7 @@ func TestPreprocessParameters_FilterResourceDependent(t *testing.T) {
 	snapshot := &collector.ClusterSnapshot{
 		Components: map[string]collector.ComponentState{
 			"tikv": {
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"backup.num-threads": types.ParameterValue{Value: 8, Type: "int"}, // Different from default (auto-tuned)
 				},
 			},
@@ -187,7 +187,7 @@ func TestPreprocessParameters_FilterResourceDependent(t *testing.T) {
 		snapshot,
 		"v7.5.0", "v8.0.0",
 		sourceDefaults, targetDefaults,
-		nil, nil,
+		nil,
 		0, 0,
 	)
 
@@ -213,7 +213,7 @@ func TestPreprocessParameters_SystemVariables(t *testing.T) {
 	snapshot := &collector.ClusterSnapshot{
 		Components: map[string]collector.ComponentState{
 			"tidb": {
-				Variables: types.SystemVariables{
+				Variables: types.ParameterMap{
 					"tidb_max_connections": types.ParameterValue{Value: 1000, Type: "int"},
 					"system_time_zone":     types.ParameterValue{Value: "UTC", Type: "string"},
 				},
@@ -239,7 +239,7 @@ func TestPreprocessParameters_SystemVariables(t *testing.T) {
 		snapshot,
 		"v7.5.0", "v8.0.0",
 		sourceDefaults, targetDefaults,
-		nil, nil,
+		nil,
 		0, 0,
 	)
 
@@ -258,7 +258,7 @@ func TestPreprocessParameters_NewParameters(t *testing.T) {
 	snapshot := &collector.ClusterSnapshot{
 		Components: map[string]collector.ComponentState{
 			"tidb": {
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"new-param": types.ParameterValue{Value: 100, Type: "int"},
 				},
 			},
@@ -279,7 +279,7 @@ func TestPreprocessParameters_NewParameters(t *testing.T) {
 		snapshot,
 		"v7.5.0", "v8.0.0",
 		sourceDefaults, targetDefaults,
-		nil, nil,
+		nil,
 		0, 0,
 	)
 
@@ -304,7 +304,7 @@ func TestPreprocessParameters_ReturnsCheckResults(t *testing.T) {
 	snapshot := &collector.ClusterSnapshot{
 		Components: map[string]collector.ComponentState{
 			"tidb": {
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"data-dir": types.ParameterValue{Value: "/data/tidb", Type: "string"},
 				},
 			},
@@ -327,7 +327,7 @@ func TestPreprocessParameters_ReturnsCheckResults(t *testing.T) {
 		snapshot,
 		"v7.5.0", "v8.0.0",
 		sourceDefaults, targetDefaults,
-		nil, nil,
+		nil,
 		0, 0,
 	)
 
@@ -2,16 +2,24 @@
 package analyzer
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 )
 
 // AnalysisResult contains the complete analysis results
 // This structure is designed for reporter to display
 type AnalysisResult struct {
 	// SourceVersion is the current cluster version
-	SourceVersion string `json:"source_version"`
+	SourceVersion string `json:"source_version" jsonschema:"required"`
 	// TargetVersion is the target version for upgrade
-	TargetVersion string `json:"target_version"`
+	TargetVersion string `json:"target_version" jsonschema:"required"`
+	// ClusterID is the cluster ID assigned by PD, if it was collected
+	ClusterID string `json:"cluster_id,omitempty"`
 
 	// ModifiedParams contains parameters that have been modified from source defaults
 	// Structure: map[component]map[param_name]ModifiedParamInfo
@@ -39,6 +47,119 @@ type AnalysisResult struct {
 
 	// Statistics contains comparison statistics
 	Statistics Statistics `json:"statistics,omitempty"`
+
+	// RuleExecutions describes the execution of each rule, in rule order
+	RuleExecutions []rules.RuleExecution `json:"rule_executions,omitempty"`
+
+	// MixedVersion is set when component instances report different versions
+	// (e.g., a previous upgrade was only partially completed)
+	MixedVersion *MixedVersionInfo `json:"mixed_version,omitempty"`
+
+	// ChangedSince is set when the analysis was restricted to the parameters changed since a previous snapshot
+	ChangedSince *ChangedSinceInfo `json:"changed_since,omitempty"`
+
+	// IgnoredResults are the findings suppressed by the ignore patterns, only kept with AnalysisOptions.ShowIgnored
+	IgnoredResults []rules.CheckResult `json:"ignored_results,omitempty"`
+
+	// Collection is set when the snapshot was not collected from every component directly (e.g., --sql-only)
+	// or when the MySQL user lacked privileges for some queries
+	Collection *CollectionInfo `json:"collection,omitempty"`
+
+	// Metadata describes the tool that generated the report
+	// It is filled in by the reporter if not set
+	Metadata *ReportMetadata `json:"metadata,omitempty"`
+}
+
+// ReportMetadata contains build information of the precheck tool that generated a report
+type ReportMetadata struct {
+	// ToolVersion is the release version of the precheck binary
+	ToolVersion string `json:"tool_version"`
+	// GitCommit is the git commit the binary was built from
+	GitCommit string `json:"git_commit"`
+	// BuildTime is the build timestamp of the binary
+	BuildTime string `json:"build_time"`
+}
+
+// MixedVersionInfo describes a cluster whose component instances run different versions
+type MixedVersionInfo struct {
+	// ClusterVersion is the source version assumed for the cluster
+	ClusterVersion string `json:"cluster_version"`
+	// Nodes contains the version reported by each component instance
+	Nodes []types.NodeVersion `json:"nodes"`
+	// ComponentSourceVersions maps component type to the version whose knowledge base
+	// was used as source defaults, for components that differ from ClusterVersion
+	ComponentSourceVersions map[string]string `json:"component_source_versions,omitempty"`
+	// ComponentInstanceVersions maps component type to the versions whose knowledge bases were used as source
+	// defaults, for components whose instances run different versions: each instance is compared against
+	// the defaults of the version it runs
+	ComponentInstanceVersions map[string][]string `json:"component_instance_versions,omitempty"`
+}
+
+// SortedComponentSourceVersions returns the component types in ComponentSourceVersions in sorted order
+func (m *MixedVersionInfo) SortedComponentSourceVersions() []string {
+	components := make([]string, 0, len(m.ComponentSourceVersions))
+	for comp := range m.ComponentSourceVersions {
+		components = append(components, comp)
+	}
+	sort.Strings(components)
+	return components
+}
+
+// SortedComponentInstanceVersions returns the component types in ComponentInstanceVersions in sorted order
+func (m *MixedVersionInfo) SortedComponentInstanceVersions() []string {
+	components := make([]string, 0, len(m.ComponentInstanceVersions))
+	for comp := range m.ComponentInstanceVersions {
+		components = append(components, comp)
+	}
+	sort.Strings(components)
+	return components
+}
+
+// CollectionInfo describes how the snapshot was collected, when some data could not be collected
+type CollectionInfo struct {
+	// Mode is the collection mode (e.g., sql-only), empty for a full collection
+	Mode string `json:"mode,omitempty"`
+	// Limitations describe the data the collection mode could not collect
+	Limitations []string `json:"limitations,omitempty"`
+	// MissingPrivileges are the queries denied to the MySQL user, whose data was not collected
+	MissingPrivileges []types.MissingPrivilege `json:"missing_privileges,omitempty"`
+}
+
+// ChangedSinceInfo describes the previous snapshot an analysis was restricted to (see --changed-since)
+// Forced changes are reported whether or not their parameter changed
+type ChangedSinceInfo struct {
+	// PreviousTimestamp is when the previous snapshot was collected
+	PreviousTimestamp time.Time `json:"previous_timestamp"`
+	// PreviousVersion is the source version of the previous snapshot
+	PreviousVersion string `json:"previous_version,omitempty"`
+	// ParametersChanged is the number of parameters whose value changed
+	ParametersChanged int `json:"parameters_changed"`
+	// ParametersNew is the number of parameters not present in the previous snapshot
+	ParametersNew int `json:"parameters_new"`
+	// FindingsUnchanged is the number of findings not reported because their parameter did not change
+	FindingsUnchanged int `json:"findings_unchanged"`
+}
+
+// Summary formats the info as "12 changed, 3 new parameters since 2026-01-02T15:04:05Z (v7.5.0)"
+func (c *ChangedSinceInfo) Summary() string {
+	summary := fmt.Sprintf("%d changed, %d new parameters since %s", c.ParametersChanged, c.ParametersNew, c.PreviousTimestamp.UTC().Format(time.RFC3339))
+	if c.PreviousVersion != "" {
+		summary += fmt.Sprintf(" (%s)", c.PreviousVersion)
+	}
+	return summary
+}
+
+// newChangedSinceInfo summarizes the changed parameters and the findings the rules did not report
+func newChangedSinceInfo(changed *rules.ChangedParameters, executions []rules.RuleExecution) *ChangedSinceInfo {
+	info := &ChangedSinceInfo{
+		PreviousTimestamp: changed.PreviousTimestamp,
+		PreviousVersion:   changed.PreviousVersion,
+	}
+	info.ParametersChanged, info.ParametersNew = changed.Count()
+	for _, execution := range executions {
+		info.FindingsUnchanged += execution.FindingsUnchanged
+	}
+	return info
 }
 
 // Statistics contains comparison statistics
@@ -51,6 +172,49 @@ type Statistics struct {
 	ParametersSkipped int `json:"parameters_skipped,omitempty"`
 	// ParametersFiltered is the number of parameters filtered out (deployment-specific, resource-dependent, etc.)
 	ParametersFiltered int `json:"parameters_filtered,omitempty"`
+	// ParametersMachineDerived is the number of parameters whose modified-versus-default check was skipped
+	// because their defaults are derived from host resources (CPU cores, memory)
+	ParametersMachineDerived int `json:"parameters_machine_derived,omitempty"`
+	// ParametersCollected is the number of collected parameters (config and system variables) per component type
+	ParametersCollected map[string]int `json:"parameters_collected,omitempty"`
+	// SeverityByComponent counts the deduplicated check results per component and severity
+	// Findings that do not belong to a component are counted under "cluster"
+	SeverityByComponent SeverityBreakdown `json:"severity_by_component,omitempty"`
+	// FindingsIgnored is the number of findings suppressed by the ignore patterns
+	FindingsIgnored int `json:"findings_ignored,omitempty"`
+}
+
+// ParametersCollectedSummary formats ParametersCollected as "pd 120, tidb 612", sorted by component type
+// Returns "" if no parameter was collected
+func (s Statistics) ParametersCollectedSummary() string {
+	components := make([]string, 0, len(s.ParametersCollected))
+	for comp := range s.ParametersCollected {
+		components = append(components, comp)
+	}
+	sort.Strings(components)
+	parts := make([]string, 0, len(components))
+	for _, comp := range components {
+		parts = append(parts, fmt.Sprintf("%s %d", comp, s.ParametersCollected[comp]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// newStatistics aggregates the statistics reported by the rules
+// SeverityByComponent is not set, it is computed from the deduplicated check results
+func newStatistics(executions []rules.RuleExecution) Statistics {
+	var total rules.RuleStatistics
+	for _, execution := range executions {
+		if execution.Statistics != nil {
+			total.Add(*execution.Statistics)
+		}
+	}
+	return Statistics{
+		TotalParametersCompared:   total.ParametersCompared,
+		ParametersWithDifferences: total.ParametersCompared - total.ParametersSkipped - total.ParametersFiltered,
+		ParametersSkipped:         total.ParametersSkipped,
+		ParametersFiltered:        total.ParametersFiltered,
+		ParametersMachineDerived:  total.ParametersMachineDerived,
+	}
 }
 
 // ModifiedParamInfo contains information about a modified parameter
@@ -67,12 +231,21 @@ type ModifiedParamInfo struct {
 	ParamType string `json:"param_type"`
 }
 
-// InconsistentNode represents a TiKV node with inconsistent parameter value
+// InconsistentNode represents the value of an inconsistent parameter on a TiKV node
+// Every node is listed, IsMajority tells the nodes that deviate from the others
 type InconsistentNode struct {
+	// NodeName is the name of the TiKV node in the snapshot (e.g., "tikv-0")
+	NodeName string `json:"node_name,omitempty"`
 	// NodeAddress is the address of the TiKV node
 	NodeAddress string `json:"node_address"`
 	// Value is the parameter value on this node
 	Value interface{} `json:"value"`
+	// Display is the value as shown in the reports, only set in JSON reports
+	Display string `json:"display,omitempty"`
+	// Missing is set when the parameter is not set on this node
+	Missing bool `json:"missing,omitempty"`
+	// IsMajority is set when this node has the majority value of the parameter
+	IsMajority bool `json:"is_majority"`
 }
 
 // UpgradeDifference contains information about parameter differences after upgrade
@@ -101,6 +274,8 @@ type ForcedChange struct {
 	CurrentValue interface{} `json:"current_value"`
 	// ForcedValue is the value that will be forced during upgrade
 	ForcedValue interface{} `json:"forced_value"`
+	// Removed indicates the variable is deleted during upgrade instead of being set to ForcedValue
+	Removed bool `json:"removed,omitempty"`
 	// SourceDefault is the default value in source version
 	SourceDefault interface{} `json:"source_default"`
 	// ParamType is "config" or "system_variable"
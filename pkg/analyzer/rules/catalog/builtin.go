@@ -0,0 +1,38 @@
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// The built-in rules are registered here rather than from the rules package,
+// which can't import catalog without an import cycle
+// Rules whose constructor needs a configuration (HIGH_RISK_PARAMS, GOLDEN_CONFIG) are not registered:
+// they are added by the caller when their configuration is loaded
+// Thresholds of the registered rules are set afterwards with rules.ApplyRulesConfig
+func init() {
+	// upgrade_path runs first: an unsupported upgrade path matters more than any parameter finding
+	Register("upgrade_path", rules.NewUpgradePathRule)
+	Register("user_modified_params", rules.NewUserModifiedParamsRule)
+	Register("upgrade_differences", rules.NewUpgradeDifferencesRule)
+	Register("forced_changes", rules.NewForcedChangesRule)
+	Register("tikv_consistency", rules.NewTikvConsistencyRule)
+	Register("storage_format", rules.NewStorageFormatRule)
+	Register("global_variables_table", rules.NewGlobalVariablesTableRule)
+	Register("operational_conflicts", rules.NewOperationalConflictsRule)
+	Register("placement_resource_control", rules.NewPlacementResourceControlRule)
+	Register("store_version", rules.NewStoreVersionRule)
+	Register("dangerous_combinations", rules.NewDangerousCombinationRule)
+	Register("startup_args", rules.NewStartupArgsRule)
+
+	// Declarative rules (rules/builtin/*.json) are registered under their lower-case rule ID
+	// They hold no state, so every Build returns the same instance
+	declarative, err := rules.BuiltinDeclarativeRules()
+	if err != nil {
+		panic(fmt.Sprintf("catalog: invalid built-in declarative rule: %v", err))
+	}
+	for _, rule := range declarative {
+		Register(rule.Name(), func() rules.Rule { return rule })
+	}
+}
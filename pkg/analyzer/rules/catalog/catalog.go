@@ -0,0 +1,121 @@
+// Package catalog maps rule IDs to rule constructors, so that the rules run by precheck
+// can be selected by name instead of being instantiated one by one
+package catalog
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// entry is a registered rule
+type entry struct {
+	id          string
+	constructor func() rules.Rule
+}
+
+var (
+	// registry holds the registered rules, in registration order
+	registry []entry
+	// byID indexes registry by rule ID
+	byID = make(map[string]func() rules.Rule)
+)
+
+// Register makes a rule available under id (e.g., "user_modified_params")
+// IDs are case-insensitive. Register panics if id is empty or already registered,
+// it is meant to be called from init functions
+func Register(id string, constructor func() rules.Rule) {
+	id = normalizeID(id)
+	if id == "" {
+		panic("catalog: Register called with an empty rule ID")
+	}
+	if constructor == nil {
+		panic(fmt.Sprintf("catalog: Register called with a nil constructor for rule %s", id))
+	}
+	if _, ok := byID[id]; ok {
+		panic(fmt.Sprintf("catalog: rule %s registered twice", id))
+	}
+	registry = append(registry, entry{id: id, constructor: constructor})
+	byID[id] = constructor
+}
+
+// IDs returns the IDs of the registered rules, in registration order
+func IDs() []string {
+	ids := make([]string, 0, len(registry))
+	for _, e := range registry {
+		ids = append(ids, e.id)
+	}
+	return ids
+}
+
+// Build constructs the rules with the given IDs, in the given order
+// Duplicate IDs are constructed once. An unknown ID is an error listing the registered rules
+func Build(ids []string) ([]rules.Rule, error) {
+	built := make(map[string]bool, len(ids))
+	result := make([]rules.Rule, 0, len(ids))
+	for _, id := range ids {
+		id = normalizeID(id)
+		constructor, ok := byID[id]
+		if !ok {
+			return nil, unknownRuleError(id)
+		}
+		if built[id] {
+			continue
+		}
+		built[id] = true
+		result = append(result, constructor())
+	}
+	return result, nil
+}
+
+// BuildAll constructs all the registered rules, in registration order
+func BuildAll() []rules.Rule {
+	result := make([]rules.Rule, 0, len(registry))
+	for _, e := range registry {
+		result = append(result, e.constructor())
+	}
+	return result
+}
+
+// Select returns the IDs of the rules to run, in registration order:
+// the included rules (all registered rules if include is empty) minus the excluded ones
+// Unknown IDs in either list are an error listing the registered rules
+func Select(include, exclude []string) ([]string, error) {
+	included := make(map[string]bool, len(include))
+	for _, id := range include {
+		id = normalizeID(id)
+		if _, ok := byID[id]; !ok {
+			return nil, unknownRuleError(id)
+		}
+		included[id] = true
+	}
+	excluded := make(map[string]bool, len(exclude))
+	for _, id := range exclude {
+		id = normalizeID(id)
+		if _, ok := byID[id]; !ok {
+			return nil, unknownRuleError(id)
+		}
+		excluded[id] = true
+	}
+
+	// Never nil, so that excluding every rule is not mistaken for no selection
+	ids := make([]string, 0, len(registry))
+	for _, e := range registry {
+		if (len(included) == 0 || included[e.id]) && !excluded[e.id] {
+			ids = append(ids, e.id)
+		}
+	}
+	return ids, nil
+}
+
+// normalizeID returns the canonical form of a rule ID: trimmed and lower case,
+// so that rule names such as USER_MODIFIED_PARAMS are accepted as well
+func normalizeID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+// unknownRuleError is the error of an ID that is not registered
+func unknownRuleError(id string) error {
+	return fmt.Errorf("unknown rule %q (available rules: %s)", id, strings.Join(IDs(), ", "))
+}
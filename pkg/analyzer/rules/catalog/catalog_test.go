@@ -0,0 +1,77 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIDs_BuiltinRules(t *testing.T) {
+	assert.Equal(t, []string{
+		"upgrade_path",
+		"user_modified_params",
+		"upgrade_differences",
+		"forced_changes",
+		"tikv_consistency",
+		"storage_format",
+		"global_variables_table",
+		"operational_conflicts",
+		"placement_resource_control",
+		"store_version",
+		"dangerous_combinations",
+		"startup_args",
+		"pd_max_replicas_below_three",
+		"tidb_analyze_version_1",
+	}, IDs())
+
+	// Every catalog ID is the lower-case name of the rule it builds
+	for _, rule := range BuildAll() {
+		assert.Contains(t, IDs(), normalizeID(rule.Name()))
+	}
+}
+
+func TestBuild(t *testing.T) {
+	built, err := Build([]string{"forced_changes", "USER_MODIFIED_PARAMS", " forced_changes "})
+	require.NoError(t, err)
+	require.Len(t, built, 2)
+	assert.Equal(t, "FORCED_CHANGES", built[0].Name())
+	assert.Equal(t, "USER_MODIFIED_PARAMS", built[1].Name())
+
+	_, err = Build([]string{"no_such_rule"})
+	assert.ErrorContains(t, err, `unknown rule "no_such_rule"`)
+	assert.ErrorContains(t, err, "user_modified_params")
+}
+
+func TestSelect(t *testing.T) {
+	ids, err := Select(nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, IDs(), ids)
+
+	// Registration order is kept whatever the order of the flags
+	ids, err = Select([]string{"storage_format", "user_modified_params"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"user_modified_params", "storage_format"}, ids)
+
+	ids, err = Select(nil, []string{"tikv_consistency"})
+	require.NoError(t, err)
+	assert.NotContains(t, ids, "tikv_consistency")
+	assert.Len(t, ids, len(IDs())-1)
+
+	ids, err = Select([]string{"forced_changes"}, []string{"forced_changes"})
+	require.NoError(t, err)
+	assert.NotNil(t, ids)
+	assert.Empty(t, ids)
+
+	_, err = Select(nil, []string{"tikv_consistancy"})
+	assert.ErrorContains(t, err, `unknown rule "tikv_consistancy"`)
+}
+
+func TestRegister_Panics(t *testing.T) {
+	constructor := func() rules.Rule { return rules.NewForcedChangesRule() }
+	assert.Panics(t, func() { Register("forced_changes", constructor) })
+	assert.Panics(t, func() { Register(" ", constructor) })
+	assert.Panics(t, func() { Register("new_rule", nil) })
+	assert.NotContains(t, IDs(), "new_rule")
+}
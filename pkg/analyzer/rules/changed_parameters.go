@@ -0,0 +1,200 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// ParameterChange is a parameter whose value changed since a previous snapshot
+type ParameterChange struct {
+	// PreviousValue is the value in the previous snapshot (nil for a new parameter)
+	PreviousValue interface{}
+	// New is set when the parameter was not present in the previous snapshot
+	New bool
+}
+
+// ChangedParameters is the set of parameters that changed since a previous snapshot (see --changed-since)
+// When set in the RuleContext, the findings of the parameter rules are restricted to these parameters
+type ChangedParameters struct {
+	// PreviousTimestamp is when the previous snapshot was collected
+	PreviousTimestamp time.Time
+	// PreviousVersion is the source version of the previous snapshot
+	PreviousVersion string
+	// Changes maps component type to the changed parameters
+	// System variables are prefixed with "sysvar:", like in the knowledge base
+	Changes map[string]map[string]ParameterChange
+}
+
+// NewChangedParameters compares the parameters of the current snapshot with the previous one
+// Instances are matched by their key in the snapshot; every parameter of an instance missing from the
+// previous snapshot is new. Parameters that only exist in the previous snapshot are not reported
+func NewChangedParameters(previous, current *collector.ClusterSnapshot) *ChangedParameters {
+	changed := &ChangedParameters{
+		PreviousTimestamp: previous.Timestamp,
+		PreviousVersion:   previous.SourceVersion,
+		Changes:           make(map[string]map[string]ParameterChange),
+	}
+	for key, comp := range current.Components {
+		compType := componentTypeOf(key, comp)
+		if compType == "" {
+			continue
+		}
+		previousComp, found := previous.Components[key]
+		for name, value := range comp.Config {
+			var previousValue defaultsTypes.ParameterValue
+			var ok bool
+			if found {
+				previousValue, ok = previousComp.Config[name]
+			}
+			changed.add(compType, name, value, previousValue, ok)
+		}
+		for name, value := range comp.Variables {
+			var previousValue defaultsTypes.ParameterValue
+			var ok bool
+			if found {
+				previousValue, ok = previousComp.Variables[name]
+			}
+			changed.add(compType, defaultsTypes.SystemVariablePrefix+name, value, previousValue, ok)
+		}
+	}
+	return changed
+}
+
+// add records a parameter of compType if it is new or its value differs from the previous one
+// For components with several instances, the first change found is kept
+func (c *ChangedParameters) add(compType, name string, value, previousValue defaultsTypes.ParameterValue, existed bool) {
+	if existed && value.Equal(previousValue) {
+		return
+	}
+	if _, ok := c.Changes[compType][name]; ok {
+		return
+	}
+	if c.Changes[compType] == nil {
+		c.Changes[compType] = make(map[string]ParameterChange)
+	}
+	change := ParameterChange{New: !existed}
+	if existed {
+		change.PreviousValue = previousValue.Value
+	}
+	c.Changes[compType][name] = change
+}
+
+// Count returns the number of changed and of new parameters
+func (c *ChangedParameters) Count() (changed, added int) {
+	for _, params := range c.Changes {
+		for _, change := range params {
+			if change.New {
+				added++
+			} else {
+				changed++
+			}
+		}
+	}
+	return changed, added
+}
+
+// Get returns the change of a parameter reported by a finding
+// paramType is the ParamType of the finding ("config" or "system_variable"); a nested field name
+// (e.g., "storage.block-cache.capacity" of a map-valued "storage") matches its enclosing parameter
+func (c *ChangedParameters) Get(component, paramName, paramType string) (ParameterChange, bool) {
+	params := c.Changes[component]
+	if params == nil {
+		return ParameterChange{}, false
+	}
+	if paramType == "system_variable" {
+		change, ok := params[defaultsTypes.SystemVariablePrefix+paramName]
+		return change, ok
+	}
+	for name := paramName; name != ""; {
+		if change, ok := params[name]; ok {
+			return change, true
+		}
+		i := strings.LastIndex(name, ".")
+		if i < 0 {
+			break
+		}
+		name = name[:i]
+	}
+	return ParameterChange{}, false
+}
+
+// upgradeScopedRule is implemented by rules whose findings are about the upgrade itself, not about
+// configuration drift. Their findings are reported whether or not the parameter changed since the
+// previous snapshot (e.g., a forced change applies to an unchanged parameter as well)
+type upgradeScopedRule interface {
+	upgradeScoped()
+}
+
+// restrictToChangedParameters keeps the findings about parameters that changed since the previous snapshot
+// and notes their previous value. Findings that are not about a parameter are kept as they are
+// Findings of upgrade-scoped rules are always kept, unchanged parameters are marked so in their metadata
+// Returns the kept findings and the number of findings removed
+func restrictToChangedParameters(rule Rule, results []CheckResult, changed *ChangedParameters) ([]CheckResult, int) {
+	_, upgradeScoped := rule.(upgradeScopedRule)
+	kept := results[:0]
+	removed := 0
+	for _, result := range results {
+		if result.ParamType != "config" && result.ParamType != "system_variable" {
+			kept = append(kept, result)
+			continue
+		}
+		change, ok := changed.Get(result.Component, result.ParameterName, result.ParamType)
+		switch {
+		case ok:
+			kept = append(kept, changed.annotate(result, change))
+		case upgradeScoped:
+			result.Metadata = withMetadata(result.Metadata, "unchanged_since_previous", true)
+			kept = append(kept, result)
+		default:
+			removed++
+		}
+	}
+	return kept, removed
+}
+
+// annotate adds the previous value of a changed parameter to a finding
+func (c *ChangedParameters) annotate(result CheckResult, change ParameterChange) CheckResult {
+	since := c.PreviousTimestamp.UTC().Format(time.RFC3339)
+	var note string
+	if change.New {
+		result.Metadata = withMetadata(result.Metadata, "new_since_previous", true)
+		note = fmt.Sprintf("Not present in the previous snapshot (%s)", since)
+	} else {
+		result.Metadata = withMetadata(result.Metadata, "previous_value", change.PreviousValue)
+		note = fmt.Sprintf("Previous value: %s (snapshot of %s)", FormatValue(change.PreviousValue), since)
+	}
+	if result.Details == "" {
+		result.Details = note
+	} else {
+		result.Details += " | " + note
+	}
+	return result
+}
+
+// withMetadata sets a metadata key, allocating the map if needed
+// The map is copied so that metadata shared between findings is not modified
+func withMetadata(metadata map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(metadata)+1)
+	for k, v := range metadata {
+		copied[k] = v
+	}
+	copied[key] = value
+	return copied
+}
+
+// componentTypeOf returns the component type of a snapshot component ("" if it is unknown)
+func componentTypeOf(key string, comp collector.ComponentState) string {
+	if comp.Type != "" {
+		return string(comp.Type)
+	}
+	for _, t := range []string{"tidb", "pd", "tikv", "tiflash"} {
+		if strings.HasPrefix(key, t) {
+			return t
+		}
+	}
+	return ""
+}
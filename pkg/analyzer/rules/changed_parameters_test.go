@@ -0,0 +1,183 @@
+package rules
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var previousSnapshotTime = time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
+// newChangedSinceSnapshots returns a previous and a current snapshot where:
+// tidb max-connections is unchanged, tidb log.level and sysvar tidb_mem_quota_query changed,
+// tidb new-param is new and the tikv instance was added
+func newChangedSinceSnapshots() (previous, current *collector.ClusterSnapshot) {
+	previous = &collector.ClusterSnapshot{
+		Timestamp:     previousSnapshotTime,
+		SourceVersion: "v7.5.0",
+		Components: map[string]collector.ComponentState{
+			"tidb": {
+				Type: types.ComponentTiDB,
+				Config: types.ParameterMap{
+					"max-connections": {Value: 1000},
+					"log":             {Value: map[string]interface{}{"level": "info"}},
+				},
+				Variables: types.ParameterMap{
+					"tidb_mem_quota_query": {Value: "1073741824"},
+				},
+			},
+		},
+	}
+	current = &collector.ClusterSnapshot{
+		SourceVersion: "v7.5.0",
+		Components: map[string]collector.ComponentState{
+			"tidb": {
+				Type: types.ComponentTiDB,
+				Config: types.ParameterMap{
+					"max-connections": {Value: 1000},
+					"log":             {Value: map[string]interface{}{"level": "warn"}},
+					"new-param":       {Value: true},
+				},
+				Variables: types.ParameterMap{
+					"tidb_mem_quota_query": {Value: "2147483648"},
+				},
+			},
+			"tikv-127.0.0.1:20160": {
+				Type: types.ComponentTiKV,
+				Config: types.ParameterMap{
+					"storage.reserve-space": {Value: "5GiB"},
+				},
+			},
+		},
+	}
+	return previous, current
+}
+
+func TestNewChangedParameters(t *testing.T) {
+	previous, current := newChangedSinceSnapshots()
+	changed := NewChangedParameters(previous, current)
+
+	assert.Equal(t, previousSnapshotTime, changed.PreviousTimestamp)
+	assert.Equal(t, "v7.5.0", changed.PreviousVersion)
+	assert.Equal(t, map[string]map[string]ParameterChange{
+		"tidb": {
+			"log":                         {PreviousValue: map[string]interface{}{"level": "info"}},
+			"new-param":                   {New: true},
+			"sysvar:tidb_mem_quota_query": {PreviousValue: "1073741824"},
+		},
+		"tikv": {
+			"storage.reserve-space": {New: true},
+		},
+	}, changed.Changes)
+
+	changedCount, newCount := changed.Count()
+	assert.Equal(t, 2, changedCount)
+	assert.Equal(t, 2, newCount)
+
+	// Findings name system variables without prefix and nested fields of map-valued parameters
+	_, ok := changed.Get("tidb", "tidb_mem_quota_query", "system_variable")
+	assert.True(t, ok)
+	_, ok = changed.Get("tidb", "tidb_mem_quota_query", "config")
+	assert.False(t, ok)
+	change, ok := changed.Get("tidb", "log.level", "config")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]interface{}{"level": "info"}, change.PreviousValue)
+	_, ok = changed.Get("tidb", "max-connections", "config")
+	assert.False(t, ok)
+	_, ok = changed.Get("pd", "log", "config")
+	assert.False(t, ok)
+}
+
+func TestNewChangedParameters_TypedValues(t *testing.T) {
+	// The same values in another representation (e.g., from a JSON snapshot file) are not changes
+	snapshot := func(params types.ParameterMap) *collector.ClusterSnapshot {
+		return &collector.ClusterSnapshot{Components: map[string]collector.ComponentState{
+			"tikv": {Type: types.ComponentTiKV, Config: params},
+		}}
+	}
+	previous := snapshot(types.ParameterMap{
+		"server.grpc-concurrency":           {Value: float64(5), Type: "int"},
+		"storage.block-cache.capacity":      {Value: "1GiB", Type: "size"},
+		"raftstore.raft-base-tick-interval": {Value: "1s", Type: "duration"},
+		"rocksdb.defaultcf.compression":     {Value: "lz4", Type: "string"},
+	})
+	current := snapshot(types.ParameterMap{
+		"server.grpc-concurrency":           {Value: int64(5), Type: "int"},
+		"storage.block-cache.capacity":      {Value: "1024MiB", Type: "size"},
+		"raftstore.raft-base-tick-interval": {Value: "1000ms", Type: "duration"},
+		"rocksdb.defaultcf.compression":     {Value: "zstd", Type: "string"},
+	})
+
+	changed := NewChangedParameters(previous, current)
+	assert.Equal(t, map[string]map[string]ParameterChange{
+		"tikv": {"rocksdb.defaultcf.compression": {PreviousValue: "lz4"}},
+	}, changed.Changes)
+}
+
+// TestRuleRunner_ChangedParameters checks that findings are restricted to the changed parameters,
+// except for the forced changes which are about the upgrade and not about drift
+func TestRuleRunner_ChangedParameters(t *testing.T) {
+	previous, current := newChangedSinceSnapshots()
+
+	drift := newStubRule("DRIFT", []string{"tidb"}, []CheckResult{
+		{Component: "tidb", ParameterName: "max-connections", ParamType: "config", Severity: "warning"},
+		{Component: "tidb", ParameterName: "log.level", ParamType: "config", Severity: "warning", Details: "Current: warn"},
+		{Component: "tidb", ParameterName: "new-param", ParamType: "config", Severity: "info"},
+		{Component: "tidb", ParameterName: "tidb_mem_quota_query", ParamType: "system_variable", Severity: "warning"},
+		{Component: "tidb", ParameterName: "gc_worker", ParamType: "gc_safe_point", Severity: "critical"},
+	}, nil)
+
+	// max-connections did not change since the previous snapshot, but the upgrade forces it to a new value
+	ruleCtx := withUpgradeLogic(&RuleContext{
+		SourceClusterSnapshot: current,
+		SourceVersion:         "v7.5.0",
+		TargetVersion:         "v8.5.0",
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {"max-connections": 2000},
+		},
+		SourceBootstrapVersion: 140,
+		TargetBootstrapVersion: 160,
+		ChangedParameters:      NewChangedParameters(previous, current),
+	}, map[string][]types.UpgradeParamChange{"tidb": {{Version: "150", Name: "max-connections", Value: 3000}}})
+
+	runner := NewRuleRunner([]Rule{drift, NewForcedChangesRule()})
+	results, err := runner.Run(context.Background(), ruleCtx)
+	require.NoError(t, err)
+
+	byRule := make(map[string][]CheckResult)
+	for _, result := range results {
+		byRule[result.RuleID] = append(byRule[result.RuleID], result)
+	}
+
+	// The unchanged max-connections finding is removed, the others are kept with their previous value
+	driftFindings := byRule["DRIFT"]
+	require.Len(t, driftFindings, 4)
+	assert.Equal(t, "log.level", driftFindings[0].ParameterName)
+	assert.Equal(t, map[string]interface{}{"level": "info"}, driftFindings[0].Metadata["previous_value"])
+	assert.Contains(t, driftFindings[0].Details, "Current: warn | Previous value: ")
+	assert.Contains(t, driftFindings[0].Details, "(snapshot of 2026-01-02T15:04:05Z)")
+	assert.Equal(t, true, driftFindings[1].Metadata["new_since_previous"])
+	assert.Equal(t, "1073741824", driftFindings[2].Metadata["previous_value"])
+	assert.Equal(t, "Previous value: 1073741824 (snapshot of 2026-01-02T15:04:05Z)", driftFindings[2].Details)
+	// Findings that are not about a parameter are not restricted
+	assert.Equal(t, "gc_safe_point", driftFindings[3].ParamType)
+	assert.Nil(t, driftFindings[3].Metadata)
+
+	// The forced change of the unchanged max-connections is still reported
+	forced := byRule["FORCED_CHANGES"]
+	require.Len(t, forced, 1)
+	assert.Equal(t, "max-connections", forced[0].ParameterName)
+	assert.Equal(t, 3000, forced[0].ForcedValue)
+	assert.Equal(t, true, forced[0].Metadata["unchanged_since_previous"])
+
+	executions := runner.Executions()
+	require.Len(t, executions, 2)
+	assert.Equal(t, 1, executions[0].FindingsUnchanged)
+	assert.Equal(t, map[string]int{"warning": 2, "info": 1, "critical": 1}, executions[0].FindingsBySeverity)
+	assert.Equal(t, 0, executions[1].FindingsUnchanged)
+}
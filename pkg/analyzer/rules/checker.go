@@ -2,6 +2,11 @@ package rules
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
 )
 
 // RiskLevel represents the risk level of a check result
@@ -29,27 +34,140 @@ func GetRiskLevel(severity string) RiskLevel {
 
 // CheckResult represents the result of a single check
 type CheckResult struct {
-	RuleID        string                 `json:"rule_id"`
-	Category      string                 `json:"category,omitempty"`       // Category/group of this rule
-	Component     string                 `json:"component,omitempty"`      // Component this result relates to
-	ParameterName string                 `json:"parameter_name,omitempty"` // Parameter or system variable name
-	ParamType     string                 `json:"param_type,omitempty"`     // "config" or "system_variable"
-	Description   string                 `json:"description"`
-	Severity      string                 `json:"severity"`             // "info", "warning", "error", "critical"
-	RiskLevel     RiskLevel              `json:"risk_level,omitempty"` // Risk level: "high", "medium", "low" (auto-set from severity if not provided)
-	Message       string                 `json:"message"`
-	Details       string                 `json:"details,omitempty"`
-	Suggestions   []string               `json:"suggestions,omitempty"` // Optional suggestions for fixing the issue
-	CurrentValue  interface{}            `json:"current_value,omitempty"`
-	SourceDefault interface{}            `json:"source_default,omitempty"`
-	TargetDefault interface{}            `json:"target_default,omitempty"`
-	ForcedValue   interface{}            `json:"forced_value,omitempty"`
-	Metadata      map[string]interface{} `json:"metadata,omitempty"` // Additional metadata
+	RuleID           string                 `json:"rule_id" jsonschema:"required"`
+	Category         string                 `json:"category,omitempty"`       // Category/group of this rule
+	Component        string                 `json:"component,omitempty"`      // Component this result relates to
+	ParameterName    string                 `json:"parameter_name,omitempty"` // Parameter or system variable name
+	ParamType        string                 `json:"param_type,omitempty"`     // "config" or "system_variable"
+	Description      string                 `json:"description"`
+	Severity         string                 `json:"severity" jsonschema:"required"`                                   // "info", "warning", "error", "critical"
+	OriginalSeverity string                 `json:"original_severity,omitempty"`                                      // Severity set by the rule, if a severity profile changed it
+	RiskLevel        RiskLevel              `json:"risk_level,omitempty" jsonschema:"enum=high,enum=medium,enum=low"` // Risk level: "high", "medium", "low" (auto-set from severity if not provided)
+	Message          string                 `json:"message" jsonschema:"required"`
+	Details          string                 `json:"details,omitempty"`
+	Suggestions      []string               `json:"suggestions,omitempty"` // Optional suggestions for fixing the issue
+	CurrentValue     interface{}            `json:"current_value,omitempty"`
+	SourceDefault    interface{}            `json:"source_default,omitempty"`
+	TargetDefault    interface{}            `json:"target_default,omitempty"`
+	ForcedValue      interface{}            `json:"forced_value,omitempty"`
+	Metadata         map[string]interface{} `json:"metadata,omitempty"` // Additional metadata
+
+	// Display holds the values above as shown in the reports (e.g., "30m" for 1800000000000), by JSON field name
+	// Only set in JSON reports, see formats.FormatValue
+	Display map[string]string `json:"display,omitempty"`
+
+	// Ignored is set on results suppressed by an ignore pattern (see --config-file), only reported with --show-ignored
+	Ignored bool `json:"ignored,omitempty"`
+	// IgnoreReason tells why the result is ignored (e.g., the pattern that matched)
+	IgnoreReason string `json:"ignore_reason,omitempty"`
+
+	// Statistics is only set on the statistics result of a rule (see NewStatisticsResult)
+	// RuleRunner removes such results from the findings and records them in the rule's RuleExecution
+	Statistics *RuleStatistics `json:"-"`
+}
+
+// RuleStatistics counts the parameters a rule looked at
+type RuleStatistics struct {
+	// ParametersExamined is the number of parameters the rule iterated over
+	ParametersExamined int `json:"parameters_examined"`
+	// ParametersCompared is the number of parameters whose value was compared with the target default
+	ParametersCompared int `json:"parameters_compared,omitempty"`
+	// ParametersSkipped is the number of parameters skipped because source == target (no difference)
+	ParametersSkipped int `json:"parameters_skipped,omitempty"`
+	// ParametersFiltered is the number of parameters filtered out as deployment-specific
+	ParametersFiltered int `json:"parameters_filtered,omitempty"`
+	// ParametersMachineDerived is the number of parameters not compared with their default
+	// because the default is derived from host resources (CPU cores, memory)
+	ParametersMachineDerived int `json:"parameters_machine_derived,omitempty"`
+	// ConfigLayers are the config files merged into the configuration of the rule, lowest priority first
+	ConfigLayers []ConfigLayer `json:"config_layers,omitempty"`
+}
+
+// ConfigLayer is a config file merged into the configuration of a rule (e.g., high-risk parameters)
+type ConfigLayer struct {
+	// Source is the path of the file, or a description of where the configuration came from
+	Source string `json:"source"`
+	// Entries is the number of entries of the file
+	Entries int `json:"entries"`
+	// Overrides is the number of entries replacing an entry of a lower-priority file
+	Overrides int `json:"overrides,omitempty"`
+	// Disabled is the number of entries suppressing an entry of a lower-priority file
+	Disabled int `json:"disabled,omitempty"`
+}
+
+// String formats the layer as "source: N entries, N overrides, N disabled"
+func (l ConfigLayer) String() string {
+	return fmt.Sprintf("%s: %d entries, %d overrides, %d disabled", l.Source, l.Entries, l.Overrides, l.Disabled)
+}
+
+// Add adds the counts of other to s
+func (s *RuleStatistics) Add(other RuleStatistics) {
+	s.ParametersExamined += other.ParametersExamined
+	s.ParametersCompared += other.ParametersCompared
+	s.ParametersSkipped += other.ParametersSkipped
+	s.ParametersFiltered += other.ParametersFiltered
+	s.ParametersMachineDerived += other.ParametersMachineDerived
+	s.ConfigLayers = append(s.ConfigLayers, other.ConfigLayers...)
+}
+
+// NewStatisticsResult returns the result a rule appends to its findings to report its statistics
+// It is not a finding: RuleRunner strips it from the results
+func NewStatisticsResult(rule Rule, stats RuleStatistics) CheckResult {
+	return CheckResult{
+		RuleID:     rule.Name(),
+		Category:   rule.Category(),
+		Severity:   "info",
+		RiskLevel:  RiskLevelLow,
+		Statistics: &stats,
+	}
+}
+
+// RuleExecution describes how a rule was executed by RuleRunner
+type RuleExecution struct {
+	// RuleID is the name of the rule
+	RuleID string `json:"rule_id"`
+	// DurationMs is the time spent evaluating the rule, in milliseconds
+	DurationMs float64 `json:"duration_ms"`
+	// Statistics are the counts reported by the rule (nil if the rule does not report statistics)
+	Statistics *RuleStatistics `json:"statistics,omitempty"`
+	// FindingsBySeverity counts the findings of the rule by severity, before deduplication
+	FindingsBySeverity map[string]int `json:"findings_by_severity,omitempty"`
+	// Skipped is set when the rule was not evaluated because the data it requires was not collected
+	Skipped bool `json:"skipped,omitempty"`
+	// SkipReason explains why the rule was skipped
+	SkipReason string `json:"skip_reason,omitempty"`
+	// Partial is set when the rule was evaluated without part of the data it requires
+	Partial bool `json:"partial,omitempty"`
+	// PartialReason explains what the rule did not check
+	PartialReason string `json:"partial_reason,omitempty"`
+	// Error is the error returned by the rule, if any
+	Error string `json:"error,omitempty"`
+	// FindingsUnchanged is the number of findings not reported because their parameter
+	// did not change since the previous snapshot (see RuleContext.ChangedParameters)
+	FindingsUnchanged int `json:"findings_unchanged,omitempty"`
+}
+
+// ParametersExamined returns the number of parameters the rule examined (0 if it does not report statistics)
+func (e RuleExecution) ParametersExamined() int {
+	if e.Statistics == nil {
+		return 0
+	}
+	return e.Statistics.ParametersExamined
+}
+
+// FindingsCount returns the total number of findings of the rule
+func (e RuleExecution) FindingsCount() int {
+	count := 0
+	for _, n := range e.FindingsBySeverity {
+		count += n
+	}
+	return count
 }
 
 // RuleRunner orchestrates the execution of all rules with full context
 type RuleRunner struct {
-	rules []Rule
+	rules      []Rule
+	executions []RuleExecution
 }
 
 // NewRuleRunner creates a new rule runner
@@ -60,15 +178,34 @@ func NewRuleRunner(rules []Rule) *RuleRunner {
 }
 
 // Run executes all rules with the provided context and returns combined results
+// Rules whose required data is missing from the snapshot are skipped
+// Each rule is timed and its statistics result is removed from the findings, see Executions
+// If ruleCtx.ChangedParameters is set, the findings are restricted to the changed parameters
 func (r *RuleRunner) Run(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
 	var allResults []CheckResult
+	r.executions = make([]RuleExecution, 0, len(r.rules))
 
 	for _, rule := range r.rules {
 		if ctx.Err() != nil {
 			break
 		}
 
+		execution := RuleExecution{RuleID: rule.Name()}
+		if reason := missingData(rule.DataRequirements(), ruleCtx); reason != "" {
+			execution.Skipped = true
+			execution.SkipReason = reason
+			r.executions = append(r.executions, execution)
+			continue
+		}
+
+		if reason := partialData(rule.DataRequirements(), ruleCtx); reason != "" {
+			execution.Partial = true
+			execution.PartialReason = reason
+		}
+
+		start := time.Now()
 		results, err := rule.Evaluate(ctx, ruleCtx)
+		execution.DurationMs = float64(time.Since(start).Microseconds()) / 1000
 		if err != nil {
 			// Create an error result for this rule
 			allResults = append(allResults, CheckResult{
@@ -78,11 +215,22 @@ func (r *RuleRunner) Run(ctx context.Context, ruleCtx *RuleContext) ([]CheckResu
 				Message:     "Rule execution failed",
 				Details:     err.Error(),
 			})
+			execution.Error = err.Error()
+			execution.FindingsBySeverity = map[string]int{"error": 1}
+			r.executions = append(r.executions, execution)
 			continue
 		}
 
 		// Ensure all results have the rule ID, category, and risk level set
+		findings := make([]CheckResult, 0, len(results))
 		for i := range results {
+			if results[i].Statistics != nil {
+				if execution.Statistics == nil {
+					execution.Statistics = &RuleStatistics{}
+				}
+				execution.Statistics.Add(*results[i].Statistics)
+				continue
+			}
 			if results[i].RuleID == "" {
 				results[i].RuleID = rule.Name()
 			}
@@ -96,10 +244,72 @@ func (r *RuleRunner) Run(ctx context.Context, ruleCtx *RuleContext) ([]CheckResu
 			if results[i].RiskLevel == "" {
 				results[i].RiskLevel = GetRiskLevel(results[i].Severity)
 			}
+			findings = append(findings, results[i])
+		}
+		if ruleCtx.ChangedParameters != nil {
+			findings, execution.FindingsUnchanged = restrictToChangedParameters(rule, findings, ruleCtx.ChangedParameters)
+		}
+		for _, finding := range findings {
+			if execution.FindingsBySeverity == nil {
+				execution.FindingsBySeverity = make(map[string]int)
+			}
+			execution.FindingsBySeverity[finding.Severity]++
 		}
 
-		allResults = append(allResults, results...)
+		allResults = append(allResults, findings...)
+		r.executions = append(r.executions, execution)
 	}
 
 	return allResults, nil
 }
+
+// Executions returns the execution of each rule of the last Run, in rule order
+func (r *RuleRunner) Executions() []RuleExecution {
+	return r.executions
+}
+
+// missingData checks if the source cluster data a rule requires is missing from the context
+// Returns the reason to skip the rule, or "" if the rule can be evaluated
+func missingData(req DataSourceRequirement, ruleCtx *RuleContext) string {
+	snapshot := ruleCtx.SourceClusterSnapshot
+	if snapshot == nil {
+		return "no source cluster snapshot"
+	}
+	if components := req.SourceClusterRequirements.Components; len(components) > 0 {
+		found := false
+		for _, comp := range components {
+			if snapshot.HasComponent(collector.ComponentType(comp)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Sprintf("no %s data collected", strings.Join(components, "/"))
+		}
+	}
+	if req.SourceClusterRequirements.NeedSystemVariables && !req.SourceClusterRequirements.NeedConfig && ruleCtx.SystemVariablesUnavailable {
+		return "system variables not collected"
+	}
+	if req.SourceClusterRequirements.NeedGlobalVariablesTable && snapshot.GlobalVariablesTable == nil {
+		return "mysql.global_variables table not collected"
+	}
+	if req.SourceClusterRequirements.NeedGCSafePoints && snapshot.GCSafePoints == nil {
+		return "GC safepoints not collected"
+	}
+	if req.SourceClusterRequirements.NeedPlacement && snapshot.Placement == nil {
+		return "placement data not collected"
+	}
+	if req.SourceClusterRequirements.NeedStores && snapshot.Stores == nil {
+		return "PD stores not collected"
+	}
+	return ""
+}
+
+// partialData checks if a rule can only be evaluated on part of the source cluster data it requires
+// Returns what the rule does not check, or "" if all the data is available
+func partialData(req DataSourceRequirement, ruleCtx *RuleContext) string {
+	if req.SourceClusterRequirements.NeedSystemVariables && ruleCtx.SystemVariablesUnavailable {
+		return "system variables not collected, only configuration parameters were checked"
+	}
+	return ""
+}
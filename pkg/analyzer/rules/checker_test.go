@@ -0,0 +1,150 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// withoutStatistics removes the statistics result from the results of a rule, as RuleRunner does
+func withoutStatistics(results []CheckResult) []CheckResult {
+	findings := make([]CheckResult, 0, len(results))
+	for _, result := range results {
+		if result.Statistics == nil {
+			findings = append(findings, result)
+		}
+	}
+	return findings
+}
+
+// stubRule is a rule returning fixed results
+type stubRule struct {
+	*BaseRule
+	components []string
+	results    []CheckResult
+	err        error
+	evaluated  bool
+}
+
+func newStubRule(name string, components []string, results []CheckResult, err error) *stubRule {
+	return &stubRule{
+		BaseRule:   NewBaseRule(name, "stub rule", "stub"),
+		components: components,
+		results:    results,
+		err:        err,
+	}
+}
+
+func (r *stubRule) DataRequirements() DataSourceRequirement {
+	var req DataSourceRequirement
+	req.SourceClusterRequirements.Components = r.components
+	return req
+}
+
+func (r *stubRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	r.evaluated = true
+	return r.results, r.err
+}
+
+func TestRuleRunner_Executions(t *testing.T) {
+	reporting := newStubRule("REPORTING", []string{"tidb"}, nil, nil)
+	reporting.results = []CheckResult{
+		{ParameterName: "a", Severity: "warning"},
+		{ParameterName: "b", Severity: "warning"},
+		{ParameterName: "c", Severity: "critical"},
+		NewStatisticsResult(reporting, RuleStatistics{ParametersExamined: 12, ParametersFiltered: 2}),
+	}
+	missingComponent := newStubRule("MISSING_COMPONENT", []string{"tiflash"}, []CheckResult{{Severity: "info"}}, nil)
+	failing := newStubRule("FAILING", nil, nil, errors.New("boom"))
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {Type: types.ComponentTiDB},
+			},
+		},
+	}
+	runner := NewRuleRunner([]Rule{reporting, missingComponent, failing})
+	results, err := runner.Run(context.Background(), ruleCtx)
+	require.NoError(t, err)
+
+	// The statistics result is not a finding
+	require.Len(t, results, 4)
+	for _, result := range results {
+		assert.Nil(t, result.Statistics)
+	}
+	assert.False(t, missingComponent.evaluated)
+
+	executions := runner.Executions()
+	require.Len(t, executions, 3)
+
+	assert.Equal(t, "REPORTING", executions[0].RuleID)
+	assert.Equal(t, map[string]int{"warning": 2, "critical": 1}, executions[0].FindingsBySeverity)
+	assert.Equal(t, 3, executions[0].FindingsCount())
+	assert.Equal(t, 12, executions[0].ParametersExamined())
+	assert.Equal(t, 2, executions[0].Statistics.ParametersFiltered)
+	assert.False(t, executions[0].Skipped)
+	assert.GreaterOrEqual(t, executions[0].DurationMs, 0.0)
+
+	assert.Equal(t, "MISSING_COMPONENT", executions[1].RuleID)
+	assert.True(t, executions[1].Skipped)
+	assert.Equal(t, "no tiflash data collected", executions[1].SkipReason)
+	assert.Nil(t, executions[1].Statistics)
+
+	assert.Equal(t, "FAILING", executions[2].RuleID)
+	assert.Equal(t, "boom", executions[2].Error)
+	assert.Equal(t, map[string]int{"error": 1}, executions[2].FindingsBySeverity)
+}
+
+func TestRuleRunner_SkipsRulesWithoutData(t *testing.T) {
+	rule := newStubRule("ANY", nil, []CheckResult{{Severity: "info"}}, nil)
+
+	runner := NewRuleRunner([]Rule{rule, NewGlobalVariablesTableRule()})
+	results, err := runner.Run(context.Background(), &RuleContext{})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+	for _, execution := range runner.Executions() {
+		assert.True(t, execution.Skipped)
+		assert.Equal(t, "no source cluster snapshot", execution.SkipReason)
+	}
+
+	runner.Run(context.Background(), &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{"tidb": {Type: types.ComponentTiDB}},
+		},
+	})
+	executions := runner.Executions()
+	require.Len(t, executions, 2)
+	assert.False(t, executions[0].Skipped)
+	assert.True(t, executions[1].Skipped)
+	assert.Equal(t, "mysql.global_variables table not collected", executions[1].SkipReason)
+}
+
+func TestRuleRunner_SystemVariablesUnavailable(t *testing.T) {
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{"tidb": {Type: types.ComponentTiDB}},
+			// Only the table was collected, so the rule is skipped because of the system variables
+			GlobalVariablesTable: []types.GlobalVariableRow{{Name: "tidb_txn_mode", Value: "pessimistic"}},
+		},
+		SystemVariablesUnavailable: true,
+	}
+	runner := NewRuleRunner([]Rule{NewUserModifiedParamsRule(), NewGlobalVariablesTableRule()})
+	_, err := runner.Run(context.Background(), ruleCtx)
+	require.NoError(t, err)
+
+	executions := runner.Executions()
+	require.Len(t, executions, 2)
+	// Rules checking configuration too are evaluated on the configuration only
+	assert.False(t, executions[0].Skipped)
+	assert.True(t, executions[0].Partial)
+	assert.Contains(t, executions[0].PartialReason, "only configuration parameters were checked")
+	assert.True(t, executions[1].Skipped)
+	assert.Equal(t, "system variables not collected", executions[1].SkipReason)
+	assert.False(t, executions[1].Partial)
+}
@@ -86,7 +86,7 @@ func CompareMapsDeep(current, source interface{}, opts CompareOptions) map[strin
 
 	if currentMap == nil || sourceMap == nil {
 		// If not both maps, fall back to simple comparison
-		if fmt.Sprintf("%v", current) != fmt.Sprintf("%v", source) {
+		if !CompareValues(current, source) {
 			result[""] = MapDiff{Current: current, Source: source}
 		}
 		return result
@@ -120,7 +120,7 @@ func CompareMapsDeep(current, source interface{}, opts CompareOptions) map[strin
 					result[fmt.Sprintf("%s.%s", key, nestedKey)] = nestedDiff
 				}
 			}
-		} else if fmt.Sprintf("%v", currentVal) != fmt.Sprintf("%v", sourceVal) {
+		} else if !CompareValues(currentVal, sourceVal) {
 			// Simple value comparison
 			result[key] = MapDiff{Current: currentVal, Source: sourceVal}
 		}
@@ -209,11 +209,11 @@ func FormatValue(v interface{}) string {
 		// Try to parse string as number to handle scientific notation
 		str := val.String()
 		// Try parsing as float64 first (handles scientific notation)
-		if f, err := strconv.ParseFloat(str, 64); err == nil {
+		if f, ok := parseFloatString(str); ok {
 			// Successfully parsed as float, format it properly
 			if f == float64(int64(f)) {
 				// Whole number, format as integer to avoid scientific notation
-				return fmt.Sprintf("%.0f", f)
+				return strconv.FormatFloat(f, 'f', 0, 64)
 			}
 			// Decimal number, use %f to avoid scientific notation
 			if f >= 1 && f < 1000000 {
@@ -221,23 +221,23 @@ func FormatValue(v interface{}) string {
 			} else if f >= 0.001 && f < 1 {
 				return fmt.Sprintf("%.9f", f)
 			} else {
-				return fmt.Sprintf("%.0f", f)
+				return strconv.FormatFloat(f, 'f', 0, 64)
 			}
 		}
 		// Not a number, return as quoted string
 		return fmt.Sprintf("%q", v)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		// Format integers without scientific notation
-		return fmt.Sprintf("%d", val.Int())
+		return strconv.FormatInt(val.Int(), 10)
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		// Format unsigned integers without scientific notation
-		return fmt.Sprintf("%d", val.Uint())
+		return strconv.FormatUint(val.Uint(), 10)
 	case reflect.Float32, reflect.Float64:
 		// For floats, check if it's a whole number
 		f := val.Float()
 		if f == float64(int64(f)) {
 			// Whole number, format as integer to avoid scientific notation
-			return fmt.Sprintf("%.0f", f)
+			return strconv.FormatFloat(f, 'f', 0, 64)
 		}
 		// Decimal number, use %f to avoid scientific notation
 		// Determine appropriate precision based on magnitude
@@ -249,17 +249,17 @@ func FormatValue(v interface{}) string {
 			return fmt.Sprintf("%.9f", f)
 		} else {
 			// For very large numbers, use %f with no decimal places (they should be integers anyway)
-			return fmt.Sprintf("%.0f", f)
+			return strconv.FormatFloat(f, 'f', 0, 64)
 		}
 	default:
 		// Try to convert to string and parse as number if possible
 		str := fmt.Sprintf("%v", v)
 		// Try parsing as float64 to handle scientific notation
-		if f, err := strconv.ParseFloat(str, 64); err == nil {
+		if f, ok := parseFloatString(str); ok {
 			// Successfully parsed as float, format it properly
 			if f == float64(int64(f)) {
 				// Whole number, format as integer to avoid scientific notation
-				return fmt.Sprintf("%.0f", f)
+				return strconv.FormatFloat(f, 'f', 0, 64)
 			}
 			// Decimal number, use %f to avoid scientific notation
 			if f >= 1 && f < 1000000 {
@@ -267,7 +267,7 @@ func FormatValue(v interface{}) string {
 			} else if f >= 0.001 && f < 1 {
 				return fmt.Sprintf("%.9f", f)
 			} else {
-				return fmt.Sprintf("%.0f", f)
+				return strconv.FormatFloat(f, 'f', 0, 64)
 			}
 		}
 		// Not a number, return as-is
@@ -414,7 +414,7 @@ func FormatDefaultChangeDiff(current, source, target interface{}, ignoredParams
 	targetStr := FormatValue(target)
 
 	// Check if source and target are different
-	if fmt.Sprintf("%v", source) != fmt.Sprintf("%v", target) {
+	if !CompareValues(source, target) {
 		return fmt.Sprintf("Source Default: %s → Target Default: %s\nCurrent: %s", sourceStr, targetStr, currentStr)
 	}
 
@@ -470,14 +470,64 @@ func CompareFileNames(path1, path2 interface{}) bool {
 	return filename1 == filename2 && filename1 != ""
 }
 
+// ValueState is the state of a parameter value in a comparison
+type ValueState int
+
+const (
+	// ValueUnset is a parameter that is absent (e.g., omitted by the status API) or null
+	ValueUnset ValueState = iota
+	// ValueSetEmpty is a parameter set to the empty string
+	ValueSetEmpty
+	// ValueSet is a parameter set to any other value
+	ValueSet
+)
+
+// String returns the name of the state
+func (s ValueState) String() string {
+	switch s {
+	case ValueUnset:
+		return "unset"
+	case ValueSetEmpty:
+		return "set-empty"
+	default:
+		return "set"
+	}
+}
+
+// GetValueState returns the state of a value, present telling whether the parameter was found at all
+func GetValueState(value interface{}, present bool) ValueState {
+	if !present || value == nil {
+		return ValueUnset
+	}
+	if str, ok := value.(string); ok && str == "" {
+		return ValueSetEmpty
+	}
+	return ValueSet
+}
+
+// IsUnsetOrEmpty checks if a value is unset (absent or null) or the empty string
+// Components omit string parameters left to their empty default, or return them as null,
+// so an unset value is equivalent to the empty string
+func (s ValueState) IsUnsetOrEmpty() bool {
+	return s == ValueUnset || s == ValueSetEmpty
+}
+
+// CompareValueStates compares two possibly absent values (present tells whether each was found)
+// Unset (absent or null) and set-empty values are equal, set values are compared with CompareValues
+func CompareValueStates(v1 interface{}, present1 bool, v2 interface{}, present2 bool) bool {
+	state1, state2 := GetValueState(v1, present1), GetValueState(v2, present2)
+	if state1 != ValueSet || state2 != ValueSet {
+		return state1.IsUnsetOrEmpty() && state2.IsUnsetOrEmpty()
+	}
+	return CompareValues(v1, v2)
+}
+
 // CompareValues compares two values properly, handling numeric types to avoid scientific notation issues
+// nil (unset or null) and the empty string are equal (see CompareValueStates)
 // Returns true if values are equal, false otherwise
 func CompareValues(v1, v2 interface{}) bool {
-	if v1 == nil && v2 == nil {
-		return true
-	}
 	if v1 == nil || v2 == nil {
-		return false
+		return GetValueState(v1, true).IsUnsetOrEmpty() && GetValueState(v2, true).IsUnsetOrEmpty()
 	}
 
 	// Try to convert both values to float64 for numeric comparison
@@ -494,7 +544,7 @@ func CompareValues(v1, v2 interface{}) bool {
 		ok1 = true
 	} else if val1.Kind() == reflect.String {
 		// Try parsing string as float (handles scientific notation)
-		if parsed, err := strconv.ParseFloat(val1.String(), 64); err == nil {
+		if parsed, ok := parseFloatString(val1.String()); ok {
 			f1 = parsed
 			ok1 = true
 		}
@@ -506,7 +556,7 @@ func CompareValues(v1, v2 interface{}) bool {
 		ok2 = true
 	} else if val2.Kind() == reflect.String {
 		// Try parsing string as float (handles scientific notation)
-		if parsed, err := strconv.ParseFloat(val2.String(), 64); err == nil {
+		if parsed, ok := parseFloatString(val2.String()); ok {
 			f2 = parsed
 			ok2 = true
 		}
@@ -521,6 +571,106 @@ func CompareValues(v1, v2 interface{}) bool {
 	return FormatValue(v1) == FormatValue(v2)
 }
 
+// ForcedRemovalDisplay is how a forced change that deletes the variable is rendered in reports
+const ForcedRemovalDisplay = "variable removed"
+
+// normalizeBoolKeyword returns the canonical "ON"/"OFF" form of a boolean keyword (ON/OFF/TRUE/FALSE or bool)
+// Numbers such as "1"/"0" are not keywords: they are only boolean if the variable is known to be boolean
+func normalizeBoolKeyword(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case bool:
+		if val {
+			return "ON", true
+		}
+		return "OFF", true
+	case string:
+		switch strings.ToUpper(strings.TrimSpace(val)) {
+		case "ON", "TRUE":
+			return "ON", true
+		case "OFF", "FALSE":
+			return "OFF", true
+		}
+	}
+	return "", false
+}
+
+// NormalizeBoolLikeValues canonicalizes the values of a boolean-like parameter to "ON"/"OFF"
+// Upgrade logic stores boolean system variables as "1"/"0" while the runtime reports "ON"/"OFF",
+// so the values must be canonicalized before they are compared or displayed.
+// A parameter is considered boolean-like if any of the values is a boolean keyword (ON/OFF/TRUE/FALSE);
+// then "1"/"0" (or 1/0) are converted as well. Otherwise the values are returned unchanged,
+// so numeric parameters such as tidb_analyze_version are not affected.
+func NormalizeBoolLikeValues(values ...interface{}) []interface{} {
+	result := make([]interface{}, len(values))
+	copy(result, values)
+
+	boolLike := false
+	for _, v := range values {
+		if _, ok := normalizeBoolKeyword(v); ok {
+			boolLike = true
+			break
+		}
+	}
+	if !boolLike {
+		return result
+	}
+
+	for i, v := range values {
+		if keyword, ok := normalizeBoolKeyword(v); ok {
+			result[i] = keyword
+			continue
+		}
+		if f, ok := ToNumeric(v); ok && (f == 0 || f == 1) {
+			if f == 1 {
+				result[i] = "ON"
+			} else {
+				result[i] = "OFF"
+			}
+		}
+	}
+	return result
+}
+
+// IsForcedRemoval checks whether a forced change check result deletes the variable instead of setting a value
+func IsForcedRemoval(check CheckResult) bool {
+	return check.Metadata != nil && check.Metadata["forced_removal"] == true
+}
+
+// FormatForcedValue formats the forced value of a check result for display
+// Forced changes that delete the variable are rendered as "variable removed" instead of an empty string
+func FormatForcedValue(check CheckResult) string {
+	if IsForcedRemoval(check) {
+		return ForcedRemovalDisplay
+	}
+	return FormatValue(check.ForcedValue)
+}
+
+// parseFloatString parses a string as float64 (handles scientific notation)
+// Strings that cannot be numbers are rejected before calling strconv.ParseFloat,
+// because a failed ParseFloat allocates an error and most parameter values are not numeric
+func parseFloatString(str string) (float64, bool) {
+	if str == "" {
+		return 0, false
+	}
+	// Skip the sign, ParseFloat only accepts a digit, '.', "inf" or "nan" after it
+	first := str[0]
+	if (first == '+' || first == '-') && len(str) > 1 {
+		first = str[1]
+	}
+	switch {
+	case first >= '0' && first <= '9', first == '.':
+	case first == 'i', first == 'I', first == 'n', first == 'N':
+	default:
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
 // isNumeric checks if a reflect.Value is a numeric type
 func isNumeric(v reflect.Value) bool {
 	switch v.Kind() {
@@ -583,10 +733,7 @@ func ToNumeric(v interface{}) (float64, bool) {
 		return val, true
 	case string:
 		// Try parsing string as float
-		if f, err := strconv.ParseFloat(val, 64); err == nil {
-			return f, true
-		}
-		return 0, false
+		return parseFloatString(val)
 	default:
 		return 0, false
 	}
@@ -0,0 +1,70 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeBoolLikeValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []interface{}
+		want   []interface{}
+	}{
+		{
+			name:   "numeric forced value with boolean runtime value",
+			values: []interface{}{"1", "OFF"},
+			want:   []interface{}{"ON", "OFF"},
+		},
+		{
+			name:   "keywords are upper-cased",
+			values: []interface{}{"on", "False", true},
+			want:   []interface{}{"ON", "OFF", "ON"},
+		},
+		{
+			name:   "numbers without boolean reference are unchanged",
+			values: []interface{}{"1", "2"},
+			want:   []interface{}{"1", "2"},
+		},
+		{
+			name:   "non 0/1 numbers and empty values are unchanged",
+			values: []interface{}{"ON", 2, ""},
+			want:   []interface{}{"ON", 2, ""},
+		},
+		{
+			name:   "numeric types",
+			values: []interface{}{float64(0), "ON"},
+			want:   []interface{}{"OFF", "ON"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeBoolLikeValues(tt.values...))
+		})
+	}
+}
+
+func TestCompareValueStates(t *testing.T) {
+	assert.Equal(t, ValueUnset, GetValueState(nil, false))
+	assert.Equal(t, ValueUnset, GetValueState(nil, true))
+	assert.Equal(t, ValueSetEmpty, GetValueState("", true))
+	assert.Equal(t, ValueSet, GetValueState("/var/log/tikv.log", true))
+	assert.Equal(t, ValueSet, GetValueState(0, true))
+
+	// KB default "" vs absent or null runtime value (log.file.filename, metric.address)
+	assert.True(t, CompareValueStates(nil, false, "", true))
+	assert.True(t, CompareValueStates(nil, true, "", true))
+	assert.True(t, CompareValueStates(nil, false, nil, true))
+	// A set value differs from an unset or empty one (security.ca-path configured)
+	assert.False(t, CompareValueStates("/etc/tls/ca.pem", true, "", true))
+	assert.False(t, CompareValueStates(nil, false, "/etc/tls/ca.pem", true))
+	assert.False(t, CompareValueStates(0, true, nil, false))
+	assert.True(t, CompareValueStates("1024", true, 1024, true))
+
+	// CompareValues treats nil as unset as well
+	assert.True(t, CompareValues(nil, ""))
+	assert.False(t, CompareValues(nil, 0))
+	assert.False(t, CompareValues(nil, false))
+}
@@ -0,0 +1,32 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ConsistencyIgnore maps component to the parameters that legitimately differ between the nodes of a component
+// (addresses, data directories, labels, ...), so they are not reported by the consistency checks
+// Patterns follow DeploymentSpecificParams: "*" matches a single segment of a dotted name, and nested
+// fields of a listed parameter are ignored as well
+// Loaded from knowledge/consistency_ignore.json (global, version-agnostic)
+type ConsistencyIgnore map[string][]string
+
+// ParseConsistencyIgnore converts consistency_ignore loaded from the knowledge base (generic JSON map)
+// into ConsistencyIgnore
+func ParseConsistencyIgnore(raw interface{}) (ConsistencyIgnore, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal consistency_ignore: %w", err)
+	}
+	ignore := make(ConsistencyIgnore)
+	if err := json.Unmarshal(data, &ignore); err != nil {
+		return nil, fmt.Errorf("failed to parse consistency_ignore: %w", err)
+	}
+	return ignore, nil
+}
+
+// Contains checks if a parameter of a component is ignored by the consistency checks
+func (c ConsistencyIgnore) Contains(component, paramName string) bool {
+	return DeploymentSpecificParams(c).Contains(component, paramName)
+}
@@ -3,6 +3,7 @@ package rules
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -31,6 +32,18 @@ type RuleContext struct {
 	// This is used to filter upgrade logic changes by bootstrap version range (X, Y]
 	TargetBootstrapVersion int64
 
+	// ComponentBootstrapVersions contains the source and target bootstrap versions of components other than TiDB
+	// that number their upgrade logic with their own bootstrap version (e.g., PD)
+	// SourceBootstrapVersion and TargetBootstrapVersion are TiDB's
+	ComponentBootstrapVersions map[string]BootstrapVersionRange
+
+	// BootstrapVersionQuery queries the bootstrap version the running cluster is at (tidb_server_version in mysql.tidb)
+	// Nil if the cluster can't be queried (e.g., analysis of a saved snapshot), see GetBootstrapVersionForSourceCluster
+	BootstrapVersionQuery func() (int64, error)
+
+	// clusterBootstrapVersion caches the result of BootstrapVersionQuery, nil until it is queried
+	clusterBootstrapVersion *int64
+
 	// SourceDefaults contains the source version default values from knowledge base
 	// Structure: map[component]map[param_name]default_value
 	// Only contains data for components and types specified in rules' requirements
@@ -41,25 +54,116 @@ type RuleContext struct {
 	// Only contains data for components and types specified in rules' requirements
 	TargetDefaults map[string]map[string]interface{}
 
-	// UpgradeLogic contains all forced changes and upgrade logic for all components
-	// Structure: map[component]upgrade_logic_data
-	// Each component's upgrade_logic contains all historical changes with version tags
+	// UpgradeLogic contains the forced changes of each component that run during the upgrade
+	// Structure: map[component][]change, in the order of upgrade_logic.json
+	// The upgrade logic holds every historical change, only the ones in the bootstrap version range
+	// (sourceBootstrapVersion, targetBootstrapVersion] are kept when it is loaded (see SetUpgradeLogic)
 	// Only loaded if rules require it
-	// Changes are filtered by version range (sourceVersion, targetVersion] during evaluation
-	UpgradeLogic map[string]interface{}
+	UpgradeLogic map[string][]UpgradeLogicChange
 
 	// ParameterNotes contains special notes/descriptions for parameters
 	// Structure: map[component]map[param_type]map[param_name]note_info
 	// Only loaded if needed
 	ParameterNotes map[string]interface{}
+
+	// ComponentSourceVersions maps component type to the version its source defaults were loaded from
+	// Only set for mixed-version clusters, where a component's instance runs a version
+	// different from SourceVersion (e.g., TiKV already upgraded while TiDB is not)
+	ComponentSourceVersions map[string]string
+
+	// InstanceSourceDefaults contains the source defaults of the versions the component instances run, for the
+	// instances of a mixed-version cluster whose version differs from SourceVersion
+	// Structure: map[version]map[component]map[param_name]default_value (see GetInstanceSourceDefaults)
+	InstanceSourceDefaults map[string]map[string]map[string]interface{}
+
+	// MachineDerivedParams contains parameters whose defaults are computed from host resources
+	// Structure: map[component]map[param_name]MachineDerivedParam
+	// If nil, no parameter is treated as machine-derived
+	MachineDerivedParams MachineDerivedParams
+
+	// FormatChanges contains on-disk format changes that make a downgrade impossible
+	// Loaded from knowledge/format_changes.json (global, version-agnostic)
+	// If nil, no format change is checked
+	FormatChanges []FormatChange
+
+	// SectionMigrations contains config parameters that moved to another section or key
+	// Loaded from knowledge/section_migrations.json (global, version-agnostic)
+	// If nil, a moved parameter is reported as a new parameter
+	SectionMigrations []SectionMigration
+
+	// RenameMap contains the parameters renamed between versions, per component
+	// Loaded from knowledge/rename_map.json (global, version-agnostic)
+	// If nil, a renamed parameter is reported as a new parameter
+	RenameMap RenameMap
+
+	// UpgradeMatrix contains the supported direct upgrade paths between version groups
+	// Loaded from knowledge/upgrade_matrix.json (global, version-agnostic)
+	// If nil, the upgrade path is not checked
+	UpgradeMatrix *UpgradeMatrix
+
+	// DeploymentSpecificParams contains parameters whose values vary by deployment (addresses, directories, ...)
+	// Loaded from knowledge/deployment_specific.json (global, version-agnostic)
+	// If nil, no parameter is skipped as deployment-specific
+	DeploymentSpecificParams DeploymentSpecificParams
+
+	// ConsistencyIgnore contains parameters that legitimately differ between the nodes of a component
+	// Loaded from knowledge/consistency_ignore.json (global, version-agnostic)
+	// If nil, every parameter is checked for consistency
+	ConsistencyIgnore ConsistencyIgnore
+
+	// ResourceControlChanges contains the versions where the accounting of request units changed
+	// Loaded from knowledge/resource_control_changes.json (global, version-agnostic)
+	// If nil, resource groups are not checked
+	ResourceControlChanges []ResourceControlChange
+
+	// DangerousCombinations contains the configurations that become dangerous under a target version
+	// Loaded from knowledge/dangerous_combinations.json (global, version-agnostic)
+	// If nil, no combination is checked
+	DangerousCombinations []DangerousCombination
+
+	// DeprecatedStartupFlags contains the command-line flags deprecated or removed in some version
+	// Loaded from knowledge/deprecated_startup_flags.json (global, version-agnostic)
+	// If nil, startup flags are not checked for deprecation
+	DeprecatedStartupFlags []DeprecatedStartupFlag
+
+	// ParameterHistory contains the versions where parameter defaults changed, per component
+	// Loaded from knowledge/<component>/parameter_history.json
+	// If nil, findings do not mention when a default changed
+	ParameterHistory map[string]*collector.ParameterHistory
+
+	// UpgradeHistory maps TiDB's bootstrap versions to the first release at each of them
+	// Loaded from knowledge/tidb/upgrade_history.json
+	// If nil, forced changes do not mention the release that introduced them
+	UpgradeHistory collector.UpgradeHistory
+
+	// MissingSourceKBComponents contains the components running in the cluster and present in the target
+	// knowledge base, but without defaults in the source knowledge base (e.g., TiFlash knowledge was never
+	// generated for the source version)
+	// Their runtime values can only be compared with the target defaults: user modifications cannot be detected
+	MissingSourceKBComponents map[string]bool
+
+	// ChangedParameters contains the parameters that changed since a previous snapshot (see --changed-since)
+	// If set, RuleRunner only reports the findings about these parameters, except for upgrade-scoped rules
+	// such as FORCED_CHANGES. If nil, every finding is reported
+	ChangedParameters *ChangedParameters
+
+	// SystemVariablesUnavailable is set when rules require the system variables but they were not collected
+	// (--skip-sysvars, or the MySQL protocol endpoint was not reachable)
+	// RuleRunner skips the rules that only check system variables, the others only check configuration
+	SystemVariablesUnavailable bool
+}
+
+// IsMissingInSourceKB checks if the source knowledge base has no defaults for a component running in the cluster
+func (ctx *RuleContext) IsMissingInSourceKB(component string) bool {
+	return ctx.MissingSourceKBComponents[component]
 }
 
 // NewRuleContext creates a new rule context
+// The upgrade logic is set afterwards with SetUpgradeLogic, once the bootstrap versions are known
 func NewRuleContext(
 	sourceSnapshot *collector.ClusterSnapshot,
 	sourceVersion, targetVersion string,
 	sourceDefaults, targetDefaults map[string]map[string]interface{},
-	upgradeLogic map[string]interface{},
 	sourceBootstrapVersion, targetBootstrapVersion int64,
 	parameterNotes map[string]interface{},
 ) *RuleContext {
@@ -69,13 +173,140 @@ func NewRuleContext(
 		TargetVersion:          targetVersion,
 		SourceDefaults:         sourceDefaults,
 		TargetDefaults:         targetDefaults,
-		UpgradeLogic:           upgradeLogic,
 		SourceBootstrapVersion: sourceBootstrapVersion,
 		TargetBootstrapVersion: targetBootstrapVersion,
 		ParameterNotes:         parameterNotes,
 	}
 }
 
+// GetBootstrapVersionForSourceCluster returns the bootstrap version the running cluster is at, i.e. the last
+// upgrade function run on it, instead of the bootstrap version of its release version in the source KB
+// It is queried with BootstrapVersionQuery on the first call and cached. Returns 0 if it is unknown
+func (ctx *RuleContext) GetBootstrapVersionForSourceCluster() int64 {
+	if ctx.clusterBootstrapVersion != nil {
+		return *ctx.clusterBootstrapVersion
+	}
+	var version int64
+	if ctx.BootstrapVersionQuery != nil {
+		queried, err := ctx.BootstrapVersionQuery()
+		if err != nil {
+			fmt.Printf("Warning: failed to query the bootstrap version of the cluster, using the one of the source KB: %v\n", err)
+		} else {
+			version = queried
+		}
+	}
+	ctx.clusterBootstrapVersion = &version
+	return version
+}
+
+// GetComponentSourceVersion returns the version whose defaults are used as source defaults for a component
+// This is the instance version for components of a mixed-version cluster, otherwise SourceVersion
+func (ctx *RuleContext) GetComponentSourceVersion(component string) string {
+	if version, ok := ctx.ComponentSourceVersions[component]; ok && version != "" {
+		return version
+	}
+	return ctx.SourceVersion
+}
+
+// GetInstanceSourceDefaults returns the source defaults an instance of a component is compared against, and their
+// version: the defaults of the version the instance runs for mixed-version clusters (see InstanceSourceDefaults),
+// the source defaults of the component otherwise
+func (ctx *RuleContext) GetInstanceSourceDefaults(component string, instance *collector.ComponentState) (map[string]interface{}, string) {
+	if version := defaultsTypes.NormalizeVersion(instance.Version); version != "" {
+		if defaults, ok := ctx.InstanceSourceDefaults[version][component]; ok {
+			return defaults, version
+		}
+	}
+	return ctx.SourceDefaults[component], ctx.GetComponentSourceVersion(component)
+}
+
+// SourceComparison is a component instance and the source defaults it is compared against
+type SourceComparison struct {
+	// Component is the component type
+	Component string
+	// Instance is the compared instance
+	Instance *collector.ComponentState
+	// Defaults are the source defaults of the version the instance runs
+	Defaults map[string]interface{}
+	// SourceVersion is the version of Defaults
+	SourceVersion string
+}
+
+// GetSourceComparisons returns the instances to compare against the source defaults, for each component of
+// SourceDefaults in the snapshot: the first instance of the component, and for mixed-version clusters the first
+// instance of every other version the component runs (see GetInstanceSourceDefaults)
+func (ctx *RuleContext) GetSourceComparisons() []SourceComparison {
+	components := make([]string, 0, len(ctx.SourceDefaults))
+	for component := range ctx.SourceDefaults {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+
+	var comparisons []SourceComparison
+	for _, component := range components {
+		first, ok := ctx.SourceClusterSnapshot.ComponentByType(collector.ComponentType(component))
+		if !ok {
+			continue
+		}
+		defaults, version := ctx.GetInstanceSourceDefaults(component, first)
+		comparisons = append(comparisons, SourceComparison{Component: component, Instance: first, Defaults: defaults, SourceVersion: version})
+		if len(ctx.InstanceSourceDefaults) == 0 {
+			continue
+		}
+		compared := map[string]bool{version: true}
+		for _, instance := range ctx.SourceClusterSnapshot.ComponentsByType(collector.ComponentType(component)) {
+			defaults, version := ctx.GetInstanceSourceDefaults(component, instance)
+			if compared[version] {
+				continue
+			}
+			compared[version] = true
+			comparisons = append(comparisons, SourceComparison{Component: component, Instance: instance, Defaults: defaults, SourceVersion: version})
+		}
+	}
+	return comparisons
+}
+
+// GetClusterInfo returns cluster-level topology metadata of the source cluster
+// For snapshots without collected cluster info (e.g., older snapshot files), the TiKV node count
+// and storage engines are derived from the collected components
+func (ctx *RuleContext) GetClusterInfo() collector.ClusterInfo {
+	if ctx.SourceClusterSnapshot == nil {
+		return collector.ClusterInfo{}
+	}
+	info := ctx.SourceClusterSnapshot.ClusterInfo
+
+	if info.TiKVNodeCount == 0 {
+		// "tikv" aliases the first node, so count distinct addresses
+		tikvAddrs := make(map[string]bool)
+		for _, node := range ctx.SourceClusterSnapshot.NodeVersions {
+			if node.Component == defaultsTypes.ComponentTiKV {
+				tikvAddrs[node.Address] = true
+			}
+		}
+		info.TiKVNodeCount = len(tikvAddrs)
+		if info.TiKVNodeCount == 0 {
+			info.TiKVNodeCount = len(ctx.SourceClusterSnapshot.ComponentsByType(defaultsTypes.ComponentTiKV))
+		}
+	}
+
+	if len(info.StorageEngines) == 0 {
+		for _, engine := range []defaultsTypes.ComponentType{defaultsTypes.ComponentTiKV, defaultsTypes.ComponentTiFlash} {
+			if ctx.SourceClusterSnapshot.HasComponent(engine) {
+				info.StorageEngines = append(info.StorageEngines, string(engine))
+			}
+		}
+	}
+
+	return info
+}
+
+// GetMachineDerivedParam returns the machine-derived classification of a parameter
+// paramName is the display name (for system variables, without "sysvar:" prefix)
+func (ctx *RuleContext) GetMachineDerivedParam(component, paramName string) (MachineDerivedParam, bool) {
+	param, ok := ctx.MachineDerivedParams[component][paramName]
+	return param, ok
+}
+
 // GetSourceDefault gets the default value for a parameter in source version
 // component: "tidb", "pd", "tikv", "tiflash"
 // paramName: parameter name (for system variables, use "sysvar:variable_name")
@@ -110,94 +341,9 @@ func (ctx *RuleContext) GetTargetDefault(component, paramName string) interface{
 func (ctx *RuleContext) GetForcedChanges(component string) map[string]interface{} {
 	result := make(map[string]interface{})
 
-	if len(ctx.UpgradeLogic) == 0 {
-		return result
-	}
-
-	if logic, ok := ctx.UpgradeLogic[component]; ok {
-		// Parse upgrade logic structure
-		// Expected structure: UpgradeLogicSnapshot with Changes array
-		// Each change has a Version field that contains bootstrap version number (e.g., "68", "71")
-		if logicMap, ok := logic.(map[string]interface{}); ok {
-			// Try UpgradeLogicSnapshot format: {"component": "...", "changes": [...]}
-			if changes, ok := logicMap["changes"].([]interface{}); ok {
-				for _, change := range changes {
-					if changeMap, ok := change.(map[string]interface{}); ok {
-						// Get bootstrap version from change
-						// Version field in upgrade_logic.json is bootstrap version (e.g., "68", "71")
-						var changeBootstrapVersion int64
-						if versionStr, ok := changeMap["version"].(string); ok {
-							// Version is a string like "68" or "71"
-							if versionNum, err := strconv.ParseInt(versionStr, 10, 64); err == nil {
-								changeBootstrapVersion = versionNum
-							} else {
-								continue
-							}
-						} else if versionNum, ok := changeMap["version"].(float64); ok {
-							// Version is a number (JSON unmarshaled as float64)
-							changeBootstrapVersion = int64(versionNum)
-						} else {
-							continue
-						}
-
-						// Check if bootstrap version is in range (sourceBootstrapVersion, targetBootstrapVersion]
-						// This means: sourceBootstrapVersion < changeBootstrapVersion <= targetBootstrapVersion
-						if ctx.SourceBootstrapVersion > 0 && ctx.TargetBootstrapVersion > 0 {
-							if changeBootstrapVersion > ctx.SourceBootstrapVersion && changeBootstrapVersion <= ctx.TargetBootstrapVersion {
-								// Extract parameter name and value
-								var paramName string
-								var forcedValue interface{}
-
-								// Try different field names for parameter name
-								if name, ok := changeMap["name"].(string); ok {
-									paramName = name
-								} else if varName, ok := changeMap["var_name"].(string); ok {
-									paramName = varName
-								} else if target, ok := changeMap["target"].(string); ok {
-									paramName = target
-								} else {
-									continue
-								}
-
-								// Extract forced value
-								if value, ok := changeMap["value"]; ok {
-									forcedValue = value
-								} else if defaultVal, ok := changeMap["default_value"]; ok {
-									forcedValue = defaultVal
-								} else {
-									continue
-								}
-
-								result[paramName] = forcedValue
-							}
-						} else {
-							// Fallback to release version comparison if bootstrap versions are not available
-							// This maintains backward compatibility
-							changeVersion := fmt.Sprintf("%d", changeBootstrapVersion)
-							if isVersionInRange(changeVersion, ctx.SourceVersion, ctx.TargetVersion) {
-								var paramName string
-								var forcedValue interface{}
-
-								if name, ok := changeMap["name"].(string); ok {
-									paramName = name
-								} else if varName, ok := changeMap["var_name"].(string); ok {
-									paramName = varName
-								} else {
-									continue
-								}
-
-								if value, ok := changeMap["value"]; ok {
-									forcedValue = value
-								} else {
-									continue
-								}
-
-								result[paramName] = forcedValue
-							}
-						}
-					}
-				}
-			}
+	for _, change := range ctx.GetUpgradeLogicChanges(component) {
+		if change.HasValue() {
+			result[change.Name] = change.Value
 		}
 	}
 
@@ -208,197 +354,120 @@ func (ctx *RuleContext) GetForcedChanges(component string) map[string]interface{
 // This method matches the from_value field in upgrade_logic.json to determine the correct forced value
 // Returns the forced value if a match is found, nil otherwise
 func (ctx *RuleContext) GetForcedChangeForValue(component, paramName string, currentValue interface{}) interface{} {
-	if len(ctx.UpgradeLogic) == 0 {
-		return nil
-	}
+	for _, change := range ctx.GetUpgradeLogicChanges(component) {
+		if change.Name != paramName {
+			continue
+		}
 
-	if logic, ok := ctx.UpgradeLogic[component]; ok {
-		if logicMap, ok := logic.(map[string]interface{}); ok {
-			if changes, ok := logicMap["changes"].([]interface{}); ok {
-				currentValueStr := fmt.Sprintf("%v", currentValue)
-
-				for _, change := range changes {
-					if changeMap, ok := change.(map[string]interface{}); ok {
-						// Get bootstrap version from change
-						var changeBootstrapVersion int64
-						if versionStr, ok := changeMap["version"].(string); ok {
-							if versionNum, err := strconv.ParseInt(versionStr, 10, 64); err == nil {
-								changeBootstrapVersion = versionNum
-							} else {
-								continue
-							}
-						} else if versionNum, ok := changeMap["version"].(float64); ok {
-							changeBootstrapVersion = int64(versionNum)
-						} else {
-							continue
-						}
-
-						// Check if bootstrap version is in range
-						var versionInRange bool
-						if ctx.SourceBootstrapVersion > 0 && ctx.TargetBootstrapVersion > 0 {
-							versionInRange = changeBootstrapVersion > ctx.SourceBootstrapVersion && changeBootstrapVersion <= ctx.TargetBootstrapVersion
-						} else {
-							// Fallback to release version comparison
-							changeVersion := fmt.Sprintf("%d", changeBootstrapVersion)
-							versionInRange = isVersionInRange(changeVersion, ctx.SourceVersion, ctx.TargetVersion)
-						}
-
-						if !versionInRange {
-							continue
-						}
-
-						// Check if parameter name matches
-						var changeParamName string
-						if name, ok := changeMap["name"].(string); ok {
-							changeParamName = name
-						} else if varName, ok := changeMap["var_name"].(string); ok {
-							changeParamName = varName
-						} else {
-							continue
-						}
-
-						if changeParamName != paramName {
-							continue
-						}
-
-						// Check if from_value matches current value
-						if fromValue, ok := changeMap["from_value"]; ok {
-							fromValueStr := fmt.Sprintf("%v", fromValue)
-							if fromValueStr != currentValueStr {
-								// from_value doesn't match current value, skip this entry
-								continue
-							}
-						}
-
-						// Extract forced value
-						if value, ok := changeMap["value"]; ok {
-							return value
-						} else if defaultVal, ok := changeMap["default_value"]; ok {
-							return defaultVal
-						}
-					}
-				}
-			}
+		// Check if from_value matches current value
+		if change.HasFromValue() && !forcedFromValueMatches(change.FromValue, currentValue) {
+			// from_value doesn't match current value, skip this entry
+			continue
+		}
+
+		if change.HasValue() {
+			return change.Value
 		}
 	}
 
 	return nil
 }
 
+// GetForcedChange gets the upgrade logic change forcing a parameter, for the current value
+// The change is the one of GetForcedChangeForValue, or the last change with a value if none matches
+// the current value (the fallback of GetForcedChanges)
+func (ctx *RuleContext) GetForcedChange(component, paramName string, currentValue interface{}) (UpgradeLogicChange, bool) {
+	var fallback UpgradeLogicChange
+	hasFallback := false
+	for _, change := range ctx.GetUpgradeLogicChanges(component) {
+		if change.Name != paramName || !change.HasValue() {
+			continue
+		}
+		if !change.HasFromValue() || forcedFromValueMatches(change.FromValue, currentValue) {
+			return change, true
+		}
+		fallback, hasFallback = change, true
+	}
+	return fallback, hasFallback
+}
+
 // ForcedChangeMetadata contains special handling metadata for a forced change
 type ForcedChangeMetadata struct {
 	DetailsNote    string   // Additional note to append to details message
 	Suggestions    []string // Custom suggestions (if nil, use default)
 	ReportSeverity string   // Override report severity: "error", "warning", "info" (if empty, use default)
+	Removed        bool     // The change deletes the variable (DELETE statement) instead of setting a value
+	// FunctionDocstring is the doc comment of the upgrade function making the change, explaining why it is made
+	FunctionDocstring string
+	// Condition is the condition under which the upgrade function makes the change (e.g., "ver < version92")
+	Condition string
+}
+
+// forcedFromValueMatches checks whether the from_value of an upgrade logic entry matches the current value
+// Boolean-like values are canonicalized first, so that from_value "OFF" matches a runtime value of "0"
+func forcedFromValueMatches(fromValue, currentValue interface{}) bool {
+	normalized := NormalizeBoolLikeValues(fromValue, currentValue)
+	return defaultsTypes.ParameterValue{Value: normalized[0]}.Equal(defaultsTypes.ParameterValue{Value: normalized[1]})
 }
 
 // GetForcedChangeMetadata gets special handling metadata for a forced change
 // Returns metadata if found, nil otherwise
 func (ctx *RuleContext) GetForcedChangeMetadata(component, paramName string, currentValue interface{}) *ForcedChangeMetadata {
-	if len(ctx.UpgradeLogic) == 0 {
-		return nil
-	}
+	for _, change := range ctx.GetUpgradeLogicChanges(component) {
+		if change.Name != paramName {
+			continue
+		}
 
-	if logic, ok := ctx.UpgradeLogic[component]; ok {
-		if logicMap, ok := logic.(map[string]interface{}); ok {
-			if changes, ok := logicMap["changes"].([]interface{}); ok {
-				currentValueStr := fmt.Sprintf("%v", currentValue)
-
-				for _, change := range changes {
-					if changeMap, ok := change.(map[string]interface{}); ok {
-						// Get bootstrap version from change
-						var changeBootstrapVersion int64
-						if versionStr, ok := changeMap["version"].(string); ok {
-							if versionNum, err := strconv.ParseInt(versionStr, 10, 64); err == nil {
-								changeBootstrapVersion = versionNum
-							} else {
-								continue
-							}
-						} else if versionNum, ok := changeMap["version"].(float64); ok {
-							changeBootstrapVersion = int64(versionNum)
-						} else {
-							continue
-						}
-
-						// Check if bootstrap version is in range
-						var versionInRange bool
-						if ctx.SourceBootstrapVersion > 0 && ctx.TargetBootstrapVersion > 0 {
-							versionInRange = changeBootstrapVersion > ctx.SourceBootstrapVersion && changeBootstrapVersion <= ctx.TargetBootstrapVersion
-						} else {
-							// Fallback to release version comparison
-							changeVersion := fmt.Sprintf("%d", changeBootstrapVersion)
-							versionInRange = isVersionInRange(changeVersion, ctx.SourceVersion, ctx.TargetVersion)
-						}
-
-						if !versionInRange {
-							continue
-						}
-
-						// Check if parameter name matches
-						var changeParamName string
-						if name, ok := changeMap["name"].(string); ok {
-							changeParamName = name
-						} else if varName, ok := changeMap["var_name"].(string); ok {
-							changeParamName = varName
-						} else {
-							continue
-						}
-
-						if changeParamName != paramName {
-							continue
-						}
-
-						// Check if from_value matches current value (if specified)
-						if fromValue, ok := changeMap["from_value"]; ok {
-							fromValueStr := fmt.Sprintf("%v", fromValue)
-							if fromValueStr != currentValueStr {
-								// from_value doesn't match current value, skip this entry
-								continue
-							}
-						}
-
-						// Extract metadata
-						metadata := &ForcedChangeMetadata{}
-						hasMetadata := false
-
-						// Extract details_note
-						if detailsNote, ok := changeMap["details_note"].(string); ok && detailsNote != "" {
-							metadata.DetailsNote = detailsNote
-							hasMetadata = true
-						}
-
-						// Extract suggestions
-						if suggestions, ok := changeMap["suggestions"].([]interface{}); ok && len(suggestions) > 0 {
-							metadata.Suggestions = make([]string, 0, len(suggestions))
-							for _, s := range suggestions {
-								if str, ok := s.(string); ok {
-									metadata.Suggestions = append(metadata.Suggestions, str)
-								}
-							}
-							if len(metadata.Suggestions) > 0 {
-								hasMetadata = true
-							}
-						}
-
-						// Extract report_severity
-						if reportSeverity, ok := changeMap["report_severity"].(string); ok && reportSeverity != "" {
-							metadata.ReportSeverity = reportSeverity
-							hasMetadata = true
-						}
-
-						// Return metadata if any field is set
-						if hasMetadata {
-							return metadata
-						}
-					}
-				}
-			}
+		// Check if from_value matches current value (if specified)
+		if change.HasFromValue() && !forcedFromValueMatches(change.FromValue, currentValue) {
+			// from_value doesn't match current value, skip this entry
+			continue
+		}
+
+		metadata := &ForcedChangeMetadata{
+			DetailsNote:       change.DetailsNote,
+			ReportSeverity:    change.ReportSeverity,
+			FunctionDocstring: change.FunctionDocstring,
+			// Context of the change extracted from the upgrade function
+			Condition: change.Condition,
+			// DELETE statements remove the variable instead of setting a value
+			Removed: strings.Contains(strings.ToUpper(change.Method), "DELETE"),
+		}
+		if len(change.Suggestions) > 0 {
+			metadata.Suggestions = change.Suggestions
+		}
+		hasMetadata := metadata.DetailsNote != "" || metadata.Suggestions != nil || metadata.ReportSeverity != "" ||
+			metadata.FunctionDocstring != "" || metadata.Condition != "" || metadata.Removed
+
+		// Return metadata if any field is set
+		if hasMetadata {
+			return metadata
 		}
 	}
 
 	return nil
 }
 
+// DescribeDefaultHistory describes when the default of a parameter changed between the source and target versions,
+// one line per change (e.g., "Default changed in v7.1.0 from 1000 to 2000")
+// paramName uses the knowledge base naming (system variables have the "sysvar:" prefix)
+// Returns an empty string if the parameter history is not available or has no change in the range
+func (ctx *RuleContext) DescribeDefaultHistory(component, paramName string) string {
+	changes := ctx.ParameterHistory[component].ChangesBetween(paramName, ctx.GetComponentSourceVersion(component), ctx.TargetVersion)
+	lines := make([]string, 0, len(changes))
+	for _, change := range changes {
+		switch {
+		case change.Added:
+			lines = append(lines, fmt.Sprintf("Added in %s with default %s", change.Version, FormatValue(change.NewValue)))
+		case change.Removed:
+			lines = append(lines, fmt.Sprintf("Removed in %s (default was %s)", change.Version, FormatValue(change.OldValue)))
+		default:
+			lines = append(lines, fmt.Sprintf("Default changed in %s from %s to %s", change.Version, FormatValue(change.OldValue), FormatValue(change.NewValue)))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
 // GetParameterNote gets special note/description for a parameter from knowledge base
 // Returns the note if found and conditions match, empty string otherwise
 func (ctx *RuleContext) GetParameterNote(component, paramName, paramType string, targetDefault interface{}) string {
@@ -538,3 +607,16 @@ func extractValueFromDefault(defaultValue interface{}) interface{} {
 	// Otherwise, return as-is
 	return defaultValue
 }
+
+// extractTypeFromDefault returns the type of a default (handles ParameterValue structures), "" if it has none
+func extractTypeFromDefault(defaultValue interface{}) string {
+	if paramValue, ok := defaultValue.(defaultsTypes.ParameterValue); ok {
+		return paramValue.Type
+	}
+	if paramMap, ok := defaultValue.(map[string]interface{}); ok {
+		if paramType, ok := paramMap["type"].(string); ok {
+			return paramType
+		}
+	}
+	return ""
+}
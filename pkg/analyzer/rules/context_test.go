@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -18,7 +19,6 @@ func TestNewRuleContext(t *testing.T) {
 		"v8.5.0",
 		make(map[string]map[string]interface{}),
 		make(map[string]map[string]interface{}),
-		make(map[string]interface{}),
 		140,                          // sourceBootstrapVersion
 		160,                          // targetBootstrapVersion
 		make(map[string]interface{}), // parameterNotes
@@ -30,13 +30,13 @@ func TestNewRuleContext(t *testing.T) {
 	assert.Equal(t, "v8.5.0", ruleCtx.TargetVersion)
 	assert.NotNil(t, ruleCtx.SourceDefaults)
 	assert.NotNil(t, ruleCtx.TargetDefaults)
-	assert.NotNil(t, ruleCtx.UpgradeLogic)
 }
 
 func TestRuleContext_GetForcedChanges(t *testing.T) {
 	tests := []struct {
 		name         string
 		ruleCtx      *RuleContext
+		upgradeLogic map[string][]types.UpgradeParamChange
 		wantLen      int
 		checkVersion string
 	}{
@@ -45,7 +45,6 @@ func TestRuleContext_GetForcedChanges(t *testing.T) {
 			ruleCtx: &RuleContext{
 				SourceVersion: "v7.5.0",
 				TargetVersion: "v8.5.0",
-				UpgradeLogic:  make(map[string]interface{}),
 			},
 			wantLen: 0,
 		},
@@ -56,19 +55,11 @@ func TestRuleContext_GetForcedChanges(t *testing.T) {
 				TargetVersion:          "v8.5.0",
 				SourceBootstrapVersion: 140,
 				TargetBootstrapVersion: 160,
-				UpgradeLogic: map[string]interface{}{
-					"tidb": map[string]interface{}{
-						"changes": []interface{}{
-							map[string]interface{}{
-								"version":           "150", // Bootstrap version in range (140 < 150 <= 160)
-								"bootstrap_version": 150,
-								"name":              "tidb_mem_quota_query",
-								"value":             2147483648,
-							},
-						},
-					},
-				},
 			},
+			upgradeLogic: map[string][]types.UpgradeParamChange{"tidb": {
+				// Bootstrap version in range (140 < 150 <= 160)
+				{Version: "150", Name: "tidb_mem_quota_query", Value: 2147483648},
+			}},
 			wantLen: 1,
 		},
 		{
@@ -78,27 +69,60 @@ func TestRuleContext_GetForcedChanges(t *testing.T) {
 				TargetVersion:          "v8.5.0",
 				SourceBootstrapVersion: 140,
 				TargetBootstrapVersion: 160,
-				UpgradeLogic: map[string]interface{}{
-					"tidb": map[string]interface{}{
-						"changes": []interface{}{
-							map[string]interface{}{
-								"version":           "130", // Bootstrap version outside range (130 <= 140)
-								"bootstrap_version": 130,
-								"name":              "tidb_mem_quota_query",
-								"value":             1073741824,
-							},
-						},
-					},
-				},
 			},
+			upgradeLogic: map[string][]types.UpgradeParamChange{"tidb": {
+				// Bootstrap version outside range (130 <= 140)
+				{Version: "130", Name: "tidb_mem_quota_query", Value: 1073741824},
+			}},
 			wantLen: 0,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			tt.ruleCtx.SetUpgradeLogic(tt.upgradeLogic)
 			changes := tt.ruleCtx.GetForcedChanges("tidb")
 			assert.Equal(t, tt.wantLen, len(changes))
 		})
 	}
 }
+
+func TestRuleContext_GetClusterInfo(t *testing.T) {
+	t.Run("collected cluster info", func(t *testing.T) {
+		ruleCtx := &RuleContext{
+			SourceClusterSnapshot: &collector.ClusterSnapshot{
+				ClusterInfo: collector.ClusterInfo{
+					TiKVNodeCount:  3,
+					PDLeaderAddr:   "http://127.0.0.1:2379",
+					ClusterID:      "7301234567890123456",
+					StorageEngines: []string{"tikv"},
+				},
+			},
+		}
+		info := ruleCtx.GetClusterInfo()
+		assert.Equal(t, 3, info.TiKVNodeCount)
+		assert.Equal(t, "http://127.0.0.1:2379", info.PDLeaderAddr)
+		assert.Equal(t, []string{"tikv"}, info.StorageEngines)
+	})
+
+	t.Run("derived from components", func(t *testing.T) {
+		// "tikv" aliases the first node and must not be counted twice
+		ruleCtx := &RuleContext{
+			SourceClusterSnapshot: &collector.ClusterSnapshot{
+				Components: map[string]collector.ComponentState{
+					"tikv":                 {Type: types.ComponentTiKV, Status: map[string]interface{}{"address": "127.0.0.1:20180"}},
+					"tikv-127-0-0-1-20180": {Type: types.ComponentTiKV, Status: map[string]interface{}{"address": "127.0.0.1:20180"}},
+					"tiflash":              {Type: types.ComponentTiFlash},
+				},
+			},
+		}
+		info := ruleCtx.GetClusterInfo()
+		assert.Equal(t, 1, info.TiKVNodeCount)
+		assert.Equal(t, []string{"tikv", "tiflash"}, info.StorageEngines)
+	})
+
+	t.Run("nil snapshot", func(t *testing.T) {
+		ruleCtx := &RuleContext{}
+		assert.Equal(t, 0, ruleCtx.GetClusterInfo().TiKVNodeCount)
+	})
+}
@@ -0,0 +1,40 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// DeploymentSpecificParams maps component to the parameters whose values vary by deployment
+// (listen addresses, ports, directories, log file names, ...)
+// Their cluster values legitimately differ from the knowledge base defaults, so they are skipped in default comparisons
+// System variables are listed with the "sysvar:" prefix, and "*" matches a single segment of a dotted name
+// Loaded from knowledge/deployment_specific.json (global, version-agnostic)
+type DeploymentSpecificParams map[string][]string
+
+// ParseDeploymentSpecificParams converts deployment_specific loaded from the knowledge base
+// (generic JSON map) into DeploymentSpecificParams
+func ParseDeploymentSpecificParams(raw interface{}) (DeploymentSpecificParams, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deployment_specific: %w", err)
+	}
+	params := make(DeploymentSpecificParams)
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, fmt.Errorf("failed to parse deployment_specific: %w", err)
+	}
+	return params, nil
+}
+
+// Contains checks if a parameter of a component is deployment-specific
+// paramName is the knowledge base key (system variables carry the "sysvar:" prefix)
+// Nested fields of a listed parameter (e.g., "log.file.filename" for "log.file") are deployment-specific as well
+func (p DeploymentSpecificParams) Contains(component, paramName string) bool {
+	for _, pattern := range p[component] {
+		if matchConfigKey(pattern, paramName) || strings.HasPrefix(paramName, pattern+".") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"fmt"
+	"strconv"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// UpgradeLogicChange is a change of upgrade_logic.json
+// Each change is made by the upgradeToVerN function of TiDB's bootstrap, where N is the change's bootstrap version
+type UpgradeLogicChange struct {
+	defaultsTypes.UpgradeParamChange
+	// BootstrapVersion is the bootstrap version of the upgrade function making the change (e.g., 68, 71), parsed from Version
+	BootstrapVersion int64
+}
+
+// HasValue checks if the change forces a value
+func (c UpgradeLogicChange) HasValue() bool {
+	return c.Value != nil
+}
+
+// HasFromValue checks if the change is restricted to clusters whose current value matches FromValue
+func (c UpgradeLogicChange) HasFromValue() bool {
+	return c.FromValue != nil
+}
+
+// FunctionName returns the name of the upgrade function making the change (e.g., "upgradeToVer68")
+// Falls back to upgradeToVer<bootstrap version> if the knowledge base entry has no func_name
+func (c UpgradeLogicChange) FunctionName() string {
+	if c.FuncName != "" {
+		return c.FuncName
+	}
+	return fmt.Sprintf("upgradeToVer%d", c.BootstrapVersion)
+}
+
+// NewUpgradeLogicChanges types the changes of a component's upgrade logic
+// The Version of a change is its bootstrap version ("68"); Name falls back to VarName
+// Changes without a valid version or a parameter name are skipped; order is preserved
+func NewUpgradeLogicChanges(changes []defaultsTypes.UpgradeParamChange) []UpgradeLogicChange {
+	result := make([]UpgradeLogicChange, 0, len(changes))
+	for _, change := range changes {
+		bootstrapVersion, err := strconv.ParseInt(change.Version, 10, 64)
+		if err != nil {
+			continue
+		}
+		if change.Name == "" {
+			change.Name = change.VarName
+		}
+		if change.Name == "" {
+			continue
+		}
+		result = append(result, UpgradeLogicChange{UpgradeParamChange: change, BootstrapVersion: bootstrapVersion})
+	}
+	return result
+}
+
+// BootstrapVersionInRange checks if a change of the given bootstrap version runs when upgrading
+// from sourceBootstrapVersion to targetBootstrapVersion, i.e. the range (source, target]:
+//   - the lower bound is exclusive: upgradeToVer<source> already ran when the source cluster was bootstrapped or upgraded
+//   - the upper bound is inclusive: upgradeToVer<target> is the last upgrade function run by the target version
+func BootstrapVersionInRange(changeBootstrapVersion, sourceBootstrapVersion, targetBootstrapVersion int64) bool {
+	return changeBootstrapVersion > sourceBootstrapVersion && changeBootstrapVersion <= targetBootstrapVersion
+}
+
+// FilterChangesByBootstrapRange returns the changes that run when upgrading from sourceBootstrapVersion
+// to targetBootstrapVersion (see BootstrapVersionInRange), in their original order
+func FilterChangesByBootstrapRange(changes []UpgradeLogicChange, sourceBootstrapVersion, targetBootstrapVersion int64) []UpgradeLogicChange {
+	var result []UpgradeLogicChange
+	for _, change := range changes {
+		if BootstrapVersionInRange(change.BootstrapVersion, sourceBootstrapVersion, targetBootstrapVersion) {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// BootstrapVersionRange is the bootstrap versions of the source and target versions of a component
+type BootstrapVersionRange struct {
+	Source int64
+	Target int64
+}
+
+// bootstrapVersions returns the bootstrap versions that number the upgrade logic of a component
+// TiDB uses SourceBootstrapVersion and TargetBootstrapVersion, other components their own bootstrap versions
+// (zero if unknown) since their upgrade functions are numbered independently of TiDB's
+// TiDB's source bootstrap version is the one the cluster is at if it could be queried, so that
+// the upgrade functions already run on the cluster are left out
+func (ctx *RuleContext) bootstrapVersions(component string) BootstrapVersionRange {
+	if component == "tidb" {
+		source := ctx.SourceBootstrapVersion
+		if clusterVersion := ctx.GetBootstrapVersionForSourceCluster(); clusterVersion > 0 {
+			source = clusterVersion
+		}
+		return BootstrapVersionRange{Source: source, Target: ctx.TargetBootstrapVersion}
+	}
+	return ctx.ComponentBootstrapVersions[component]
+}
+
+// SetUpgradeLogic sets UpgradeLogic to the changes of each component's upgrade logic that run during the upgrade
+// The upgrade logic holds every historical change: it is filtered once here, by the bootstrap version range of
+// the component (see bootstrapVersions), so it must be called after the bootstrap versions of the context are set
+// If TiDB's bootstrap versions are not available, the release versions are compared instead (backward compatibility),
+// the changes of other components are dropped
+func (ctx *RuleContext) SetUpgradeLogic(logic map[string][]defaultsTypes.UpgradeParamChange) {
+	ctx.UpgradeLogic = make(map[string][]UpgradeLogicChange, len(logic))
+	for component, changes := range logic {
+		typed := NewUpgradeLogicChanges(changes)
+		if bootstrapVersions := ctx.bootstrapVersions(component); bootstrapVersions.Source > 0 && bootstrapVersions.Target > 0 {
+			ctx.UpgradeLogic[component] = FilterChangesByBootstrapRange(typed, bootstrapVersions.Source, bootstrapVersions.Target)
+			continue
+		}
+		if component != "tidb" {
+			// The changes cannot be placed without the component's own bootstrap versions
+			continue
+		}
+		var inRange []UpgradeLogicChange
+		for _, change := range typed {
+			if isVersionInRange(fmt.Sprintf("%d", change.BootstrapVersion), ctx.SourceVersion, ctx.TargetVersion) {
+				inRange = append(inRange, change)
+			}
+		}
+		ctx.UpgradeLogic[component] = inRange
+	}
+}
+
+// GetUpgradeLogicChanges returns the upgrade logic changes of a component that run during the upgrade (see SetUpgradeLogic)
+func (ctx *RuleContext) GetUpgradeLogicChanges(component string) []UpgradeLogicChange {
+	return ctx.UpgradeLogic[component]
+}
@@ -0,0 +1,66 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// GoldenValue is a parameter value of a golden configuration profile
+type GoldenValue struct {
+	// Value is the expected runtime value
+	Value interface{} `json:"value"`
+	// Type is the parameter type (informational, same as in defaults.json)
+	Type string `json:"type,omitempty"`
+	// Severity is the severity reported when the runtime value deviates (default: warning)
+	Severity string `json:"severity,omitempty"`
+	// Description explains why the golden value was chosen
+	Description string `json:"description,omitempty"`
+}
+
+// GoldenComponentProfile is the golden configuration of a component
+// It uses the same layout as the component defaults.json of the knowledge base
+type GoldenComponentProfile struct {
+	ConfigDefaults  map[string]GoldenValue `json:"config_defaults,omitempty"`
+	SystemVariables map[string]GoldenValue `json:"system_variables,omitempty"`
+}
+
+// GoldenProfile is an operator-maintained baseline configuration (the "golden config")
+// Key: component type (tidb, pd, tikv, tiflash)
+type GoldenProfile map[string]GoldenComponentProfile
+
+// LoadGoldenProfile loads a golden configuration profile from a JSON file
+func LoadGoldenProfile(path string) (GoldenProfile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read golden config profile %s: %w", path, err)
+	}
+	var profile GoldenProfile
+	if err := json.Unmarshal(data, &profile); err != nil {
+		return nil, fmt.Errorf("failed to parse golden config profile %s: %w", path, err)
+	}
+	for component, compProfile := range profile {
+		for name, value := range compProfile.ConfigDefaults {
+			if err := validateGoldenSeverity(value.Severity); err != nil {
+				return nil, fmt.Errorf("golden config profile %s: %s config %s: %w", path, component, name, err)
+			}
+		}
+		for name, value := range compProfile.SystemVariables {
+			if err := validateGoldenSeverity(value.Severity); err != nil {
+				return nil, fmt.Errorf("golden config profile %s: %s system variable %s: %w", path, component, name, err)
+			}
+		}
+	}
+	return profile, nil
+}
+
+// validateGoldenSeverity checks the severity of a golden profile entry
+func validateGoldenSeverity(severity string) error {
+	switch severity {
+	case "", "critical", "error", "warning", "info":
+		return nil
+	default:
+		return fmt.Errorf("invalid severity %q (expected critical, error, warning or info)", severity)
+	}
+}
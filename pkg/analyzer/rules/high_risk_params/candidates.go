@@ -0,0 +1,61 @@
+package high_risk_params
+
+import (
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// candidateSeverityRank ranks the severities of findings that become candidates (higher is more severe)
+var candidateSeverityRank = map[string]int{"error": 1, "critical": 2}
+
+// CandidatesFromFindings proposes a high-risk entry for every critical or error finding of a precheck report,
+// so that teams can curate their own config from real incidents
+// Entries are pre-filled from the finding: the severity, the message as description, check_modified for
+// user-modified parameters, and targetVersion as from_version. A parameter reported by several findings
+// gets the entry of its most severe finding. Findings without a parameter or of an unknown component are skipped
+func CandidatesFromFindings(findings []rules.CheckResult, targetVersion string) *rules.HighRiskParamsConfig {
+	candidates := &rules.HighRiskParamsConfig{}
+	bySection := make(map[string]section)
+	for _, sec := range sections(candidates) {
+		bySection[sec.Component+"/"+sec.ParamType] = sec
+	}
+
+	for _, finding := range findings {
+		severity := strings.ToLower(finding.Severity)
+		rank, ok := candidateSeverityRank[severity]
+		if !ok || finding.ParameterName == "" || strings.HasPrefix(finding.ParameterName, "__") {
+			continue
+		}
+		paramType := "config"
+		if finding.ParamType == "system_variable" {
+			paramType = "system_variable"
+		}
+		sec, ok := bySection[strings.ToLower(finding.Component)+"/"+paramType]
+		if !ok {
+			continue
+		}
+		if *sec.Params == nil {
+			*sec.Params = make(map[string]rules.HighRiskParamConfig)
+		}
+		if existing, ok := (*sec.Params)[finding.ParameterName]; ok && candidateSeverityRank[existing.Severity] >= rank {
+			continue
+		}
+		(*sec.Params)[finding.ParameterName] = rules.HighRiskParamConfig{
+			Severity:      severity,
+			Description:   finding.Message,
+			CheckModified: finding.Category == "user_modified",
+			FromVersion:   targetVersion,
+		}
+	}
+	return candidates
+}
+
+// CountEntries returns the number of entries of a config
+func CountEntries(config *rules.HighRiskParamsConfig) int {
+	count := 0
+	for _, sec := range sections(config) {
+		count += len(*sec.Params)
+	}
+	return count
+}
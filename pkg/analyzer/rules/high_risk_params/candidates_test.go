@@ -0,0 +1,43 @@
+package high_risk_params
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCandidatesFromFindings(t *testing.T) {
+	findings := []rules.CheckResult{
+		{Component: "tidb", ParameterName: "tidb_txn_mode", ParamType: "system_variable", Severity: "error", Category: "user_modified", Message: "Transaction mode was modified"},
+		{Component: "tikv", ParameterName: "server.grpc-concurrency", ParamType: "config", Severity: "error", Message: "Less severe finding"},
+		{Component: "tikv", ParameterName: "server.grpc-concurrency", ParamType: "config", Severity: "critical", Message: "Unsafe value"},
+		{Component: "tikv", ParameterName: "server.grpc-concurrency", ParamType: "config", Severity: "error", Message: "Reported after the critical finding"},
+		{Component: "pd", ParameterName: "schedule.leader-schedule-limit", ParamType: "config", Severity: "warning", Message: "Only a warning"},
+		{Component: "tidb", ParameterName: "", Severity: "critical", Message: "Finding without a parameter"},
+		{Component: "tikv", ParameterName: "__statistics__", Severity: "critical", Message: "Statistics"},
+		{Component: "tiproxy", ParameterName: "proxy.max-connections", Severity: "critical", Message: "Unsupported component"},
+	}
+
+	candidates := CandidatesFromFindings(findings, "v8.5.0")
+	assert.Equal(t, 2, CountEntries(candidates))
+
+	txnMode := candidates.TiDB.SystemVariables["tidb_txn_mode"]
+	assert.Equal(t, rules.HighRiskParamConfig{
+		Severity:      "error",
+		Description:   "Transaction mode was modified",
+		CheckModified: true,
+		FromVersion:   "v8.5.0",
+	}, txnMode)
+
+	grpc := candidates.TiKV.Config["server.grpc-concurrency"]
+	assert.Equal(t, "critical", grpc.Severity)
+	assert.Equal(t, "Unsafe value", grpc.Description)
+	assert.False(t, grpc.CheckModified)
+
+	// The candidates are a valid config for the rule
+	assert.Empty(t, Validate(candidates))
+	_, err := rules.NewHighRiskParamsRule(candidates)
+	require.NoError(t, err)
+}
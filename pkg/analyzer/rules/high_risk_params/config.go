@@ -31,10 +31,11 @@ func GetKnowledgeBaseConfigPath() string {
 
 	// Default: use knowledge directory relative to executable or current directory
 	// Try to find knowledge directory in common locations
+	defaultPath := filepath.Join("knowledge", "high_risk_params", "high_risk_params.json")
 	possiblePaths := []string{
-		"./knowledge/high_risk_params/high_risk_params.json",
-		"../knowledge/high_risk_params/high_risk_params.json",
-		"../../knowledge/high_risk_params/high_risk_params.json",
+		defaultPath,
+		filepath.Join("..", defaultPath),
+		filepath.Join("..", "..", defaultPath),
 	}
 
 	for _, path := range possiblePaths {
@@ -44,7 +45,7 @@ func GetKnowledgeBaseConfigPath() string {
 	}
 
 	// If not found, return the default relative path
-	return "./knowledge/high_risk_params/high_risk_params.json"
+	return defaultPath
 }
 
 // LoadConfig loads the high-risk parameters configuration from knowledge base
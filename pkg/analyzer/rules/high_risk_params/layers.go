@@ -0,0 +1,57 @@
+package high_risk_params
+
+import (
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// Layer is a high-risk parameters config merged by MergeLayers
+type Layer struct {
+	// Source is the path of the config file, or a description of where the config came from
+	Source string
+	Config *rules.HighRiskParamsConfig
+}
+
+// LoadLayers loads high-risk parameters config files (see LoadConfigFile), lowest priority first, and merges them
+// with MergeLayers. A file that cannot be read or parsed is an error
+func LoadLayers(paths []string) (*rules.HighRiskParamsConfig, []rules.ConfigLayer, error) {
+	layers := make([]Layer, 0, len(paths))
+	for _, path := range paths {
+		config, err := LoadConfigFile(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		layers = append(layers, Layer{Source: path, Config: config})
+	}
+	merged, stats := MergeLayers(layers)
+	return merged, stats, nil
+}
+
+// MergeLayers merges configs, lowest priority first: an entry of a layer replaces the entry of the same
+// (component, parameter) of the layers before it, and a disabled entry removes it (see Merge)
+// Returns the merged config and, for each layer, its entry count and the overrides it applied
+func MergeLayers(layers []Layer) (*rules.HighRiskParamsConfig, []rules.ConfigLayer) {
+	merged := &rules.HighRiskParamsConfig{}
+	stats := make([]rules.ConfigLayer, 0, len(layers))
+	for _, layer := range layers {
+		stat := rules.ConfigLayer{Source: layer.Source}
+		if layer.Config != nil {
+			mergedSections := sections(merged)
+			for i, sec := range sections(layer.Config) {
+				for name, entry := range *sec.Params {
+					stat.Entries++
+					if _, ok := (*mergedSections[i].Params)[name]; !ok {
+						continue
+					}
+					if entry.Disabled {
+						stat.Disabled++
+					} else {
+						stat.Overrides++
+					}
+				}
+			}
+			merged, _ = Merge(merged, layer.Config)
+		}
+		stats = append(stats, stat)
+	}
+	return merged, stats
+}
@@ -0,0 +1,102 @@
+package high_risk_params
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeLayers_Order(t *testing.T) {
+	shipped := &rules.HighRiskParamsConfig{}
+	shipped.TiKV.Config = map[string]rules.HighRiskParamConfig{
+		"server.grpc-concurrency":   {Severity: "warning", Description: "Shipped"},
+		"raftstore.apply-pool-size": {Severity: "warning", Description: "Shipped"},
+		"storage.reserve-space":     {Severity: "info", Description: "Shipped"},
+	}
+	company := &rules.HighRiskParamsConfig{}
+	company.TiKV.Config = map[string]rules.HighRiskParamConfig{
+		"server.grpc-concurrency": {Severity: "error", Description: "Company"},
+		"storage.reserve-space":   {Disabled: true},
+	}
+	company.TiDB.SystemVariables = map[string]rules.HighRiskParamConfig{
+		"tidb_txn_mode": {Severity: "warning", Description: "Company"},
+	}
+	cluster := &rules.HighRiskParamsConfig{}
+	cluster.TiKV.Config = map[string]rules.HighRiskParamConfig{
+		"server.grpc-concurrency": {Severity: "critical", Description: "Cluster"},
+		// Re-enabled by a higher-priority layer after being disabled
+		"storage.reserve-space": {Severity: "warning", Description: "Cluster"},
+		// Disabling an entry no lower layer has is not an override
+		"rocksdb.max-open-files": {Disabled: true},
+	}
+
+	merged, stats := MergeLayers([]Layer{
+		{Source: "shipped", Config: shipped},
+		{Source: "company.json", Config: company},
+		{Source: "cluster.json", Config: cluster},
+	})
+
+	assert.Equal(t, "Cluster", merged.TiKV.Config["server.grpc-concurrency"].Description, "the last layer wins")
+	assert.Equal(t, "Shipped", merged.TiKV.Config["raftstore.apply-pool-size"].Description)
+	assert.Equal(t, "Cluster", merged.TiKV.Config["storage.reserve-space"].Description)
+	assert.NotContains(t, merged.TiKV.Config, "rocksdb.max-open-files")
+	assert.Equal(t, "Company", merged.TiDB.SystemVariables["tidb_txn_mode"].Description)
+	assert.Equal(t, 4, CountEntries(merged))
+
+	assert.Equal(t, []rules.ConfigLayer{
+		{Source: "shipped", Entries: 3},
+		{Source: "company.json", Entries: 3, Overrides: 1, Disabled: 1},
+		{Source: "cluster.json", Entries: 3, Overrides: 1},
+	}, stats)
+	assert.Equal(t, "company.json: 3 entries, 1 overrides, 1 disabled", stats[1].String())
+
+	// In the reverse order, the shipped entries override the others
+	merged, _ = MergeLayers([]Layer{
+		{Source: "cluster.json", Config: cluster},
+		{Source: "company.json", Config: company},
+		{Source: "shipped", Config: shipped},
+	})
+	assert.Equal(t, "Shipped", merged.TiKV.Config["server.grpc-concurrency"].Description)
+	assert.Equal(t, "Shipped", merged.TiKV.Config["storage.reserve-space"].Description)
+}
+
+func TestMergeLayers_Disabled(t *testing.T) {
+	shipped := &rules.HighRiskParamsConfig{}
+	shipped.PD.Config = map[string]rules.HighRiskParamConfig{
+		"schedule.max-merge-region-size": {Severity: "warning", Description: "Shipped"},
+	}
+	override := &rules.HighRiskParamsConfig{}
+	override.PD.Config = map[string]rules.HighRiskParamConfig{
+		"schedule.max-merge-region-size": {Disabled: true},
+	}
+
+	merged, stats := MergeLayers([]Layer{{Source: "shipped", Config: shipped}, {Source: "override", Config: override}, {Source: "empty"}})
+	assert.Empty(t, merged.PD.Config)
+	assert.Equal(t, 0, CountEntries(merged))
+	assert.Equal(t, rules.ConfigLayer{Source: "empty"}, stats[2])
+
+	// A disabled entry only needs its name
+	assert.Empty(t, Validate(override))
+}
+
+func TestLoadLayers(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	require.NoError(t, os.WriteFile(base, []byte(`{"tidb": {"config": {"mem-quota-query": {"severity": "warning", "description": "Base"}}}}`), 0644))
+	cluster := filepath.Join(dir, "cluster.json")
+	require.NoError(t, os.WriteFile(cluster, []byte(`{"tidb": {"config": {"mem-quota-query": {"disabled": true}}}}`), 0644))
+
+	merged, stats, err := LoadLayers([]string{base, cluster})
+	require.NoError(t, err)
+	assert.Empty(t, merged.TiDB.Config)
+	assert.Equal(t, []rules.ConfigLayer{{Source: base, Entries: 1}, {Source: cluster, Entries: 1, Disabled: 1}}, stats)
+
+	invalid := filepath.Join(dir, "invalid.json")
+	require.NoError(t, os.WriteFile(invalid, []byte(`{"tidb": {"config": {"mem-quota-query": {"severty": "warning"}}}}`), 0644))
+	_, _, err = LoadLayers([]string{base, invalid})
+	assert.ErrorContains(t, err, "invalid.json")
+}
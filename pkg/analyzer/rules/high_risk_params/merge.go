@@ -0,0 +1,93 @@
+package high_risk_params
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// MergeConflict is an entry defined differently in the base and the override config
+// The override entry replaces the base entry
+type MergeConflict struct {
+	Component string
+	ParamType string
+	ParamName string
+	// Fields are the JSON fields whose value differs, sorted
+	Fields []string
+}
+
+// String formats the conflict as "component/param_type/param_name: overridden fields ..."
+func (c MergeConflict) String() string {
+	return fmt.Sprintf("%s/%s/%s: override replaces base entry (differs in %s)", c.Component, c.ParamType, c.ParamName, strings.Join(c.Fields, ", "))
+}
+
+// Merge merges org-specific overrides onto a base config (typically the shipped default.json)
+// Entries only in one of the configs are kept, entries in both are taken from override as a whole
+// A disabled override entry removes the base entry (and is not kept itself)
+// The entries of both configs that differ are returned as conflicts, in the order of Validate
+// base and override are not modified
+func Merge(base, override *rules.HighRiskParamsConfig) (*rules.HighRiskParamsConfig, []MergeConflict) {
+	merged := &rules.HighRiskParamsConfig{}
+	mergedSections := sections(merged)
+	baseSections := sections(base)
+	var conflicts []MergeConflict
+	for i, overrideSection := range sections(override) {
+		params := make(map[string]rules.HighRiskParamConfig)
+		for name, entry := range *baseSections[i].Params {
+			params[name] = entry
+		}
+		for _, name := range sortedNames(*overrideSection.Params) {
+			entry := (*overrideSection.Params)[name]
+			if entry.Disabled {
+				delete(params, name)
+				continue
+			}
+			if baseEntry, ok := params[name]; ok && !reflect.DeepEqual(baseEntry, entry) {
+				conflicts = append(conflicts, MergeConflict{
+					Component: overrideSection.Component,
+					ParamType: overrideSection.ParamType,
+					ParamName: name,
+					Fields:    differingFields(baseEntry, entry),
+				})
+			}
+			params[name] = entry
+		}
+		if len(params) > 0 {
+			*mergedSections[i].Params = params
+		}
+	}
+	return merged, conflicts
+}
+
+// differingFields returns the JSON fields whose value differs between two entries, sorted
+func differingFields(a, b rules.HighRiskParamConfig) []string {
+	fieldsA, fieldsB := entryFields(a), entryFields(b)
+	var fields []string
+	for name, value := range fieldsA {
+		if !reflect.DeepEqual(value, fieldsB[name]) {
+			fields = append(fields, name)
+		}
+	}
+	for name := range fieldsB {
+		if _, ok := fieldsA[name]; !ok {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// entryFields returns the JSON fields of an entry (omitted fields are absent)
+func entryFields(entry rules.HighRiskParamConfig) map[string]interface{} {
+	fields := make(map[string]interface{})
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fields
+	}
+	_ = json.Unmarshal(data, &fields)
+	return fields
+}
@@ -0,0 +1,51 @@
+package high_risk_params
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMerge(t *testing.T) {
+	base := &rules.HighRiskParamsConfig{}
+	base.TiKV.Config = map[string]rules.HighRiskParamConfig{
+		"server.grpc-concurrency":   {Severity: "warning", Description: "Default changed", CheckModified: true, FromVersion: "v8.5.0"},
+		"raftstore.apply-pool-size": {Severity: "warning", Description: "Shipped entry"},
+	}
+	base.TiDB.SystemVariables = map[string]rules.HighRiskParamConfig{
+		"tidb_txn_mode": {Severity: "info", Description: "Same in both"},
+	}
+
+	override := &rules.HighRiskParamsConfig{}
+	override.TiKV.Config = map[string]rules.HighRiskParamConfig{
+		"server.grpc-concurrency": {Severity: "error", Description: "Default changed", FromVersion: "v8.5.0", UnsafeValues: []interface{}{float64(1)}},
+	}
+	override.TiDB.SystemVariables = map[string]rules.HighRiskParamConfig{
+		"tidb_txn_mode": {Severity: "info", Description: "Same in both"},
+	}
+	override.TiFlash.Config = map[string]rules.HighRiskParamConfig{
+		"profiles.default.max_threads": {Severity: "warning", Description: "Org-specific entry"},
+	}
+
+	merged, conflicts := Merge(base, override)
+
+	require.Len(t, conflicts, 1)
+	assert.Equal(t, "tikv", conflicts[0].Component)
+	assert.Equal(t, "config", conflicts[0].ParamType)
+	assert.Equal(t, "server.grpc-concurrency", conflicts[0].ParamName)
+	assert.Equal(t, []string{"check_modified", "severity", "unsafe_values"}, conflicts[0].Fields)
+	assert.Equal(t, "tikv/config/server.grpc-concurrency: override replaces base entry (differs in check_modified, severity, unsafe_values)", conflicts[0].String())
+
+	assert.Equal(t, override.TiKV.Config["server.grpc-concurrency"], merged.TiKV.Config["server.grpc-concurrency"])
+	assert.Equal(t, base.TiKV.Config["raftstore.apply-pool-size"], merged.TiKV.Config["raftstore.apply-pool-size"])
+	assert.Contains(t, merged.TiDB.SystemVariables, "tidb_txn_mode")
+	assert.Contains(t, merged.TiFlash.Config, "profiles.default.max_threads")
+	assert.Nil(t, merged.PD.Config)
+	assert.Equal(t, 4, CountEntries(merged))
+
+	// The inputs are not modified
+	assert.Equal(t, "warning", base.TiKV.Config["server.grpc-concurrency"].Severity)
+	assert.Len(t, override.TiKV.Config, 1)
+}
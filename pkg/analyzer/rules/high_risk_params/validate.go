@@ -0,0 +1,191 @@
+package high_risk_params
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// validSeverities are the severities accepted in high-risk entries
+var validSeverities = map[string]bool{"critical": true, "error": true, "warning": true, "info": true}
+
+// versionPattern matches the from_version/to_version format (e.g., v7.5.0)
+var versionPattern = regexp.MustCompile(`^v?(\d+)\.(\d+)\.(\d+)$`)
+
+// section is the entries of a component for one parameter type
+// Params points into the config so that sections can be modified in place
+type section struct {
+	Component string
+	ParamType string // "config" or "system_variable"
+	Params    *map[string]rules.HighRiskParamConfig
+}
+
+// sections returns the sections of a config, in a fixed order
+func sections(config *rules.HighRiskParamsConfig) []section {
+	return []section{
+		{Component: "tidb", ParamType: "config", Params: &config.TiDB.Config},
+		{Component: "tidb", ParamType: "system_variable", Params: &config.TiDB.SystemVariables},
+		{Component: "pd", ParamType: "config", Params: &config.PD.Config},
+		{Component: "tikv", ParamType: "config", Params: &config.TiKV.Config},
+		{Component: "tiflash", ParamType: "config", Params: &config.TiFlash.Config},
+	}
+}
+
+// sortedNames returns the parameter names of a section, sorted
+func sortedNames(params map[string]rules.HighRiskParamConfig) []string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Problem is a problem found in a high-risk parameters config
+type Problem struct {
+	Component string
+	ParamType string
+	ParamName string
+	Message   string
+}
+
+// String formats the problem as "component/param_type/param_name: message"
+func (p Problem) String() string {
+	return fmt.Sprintf("%s/%s/%s: %s", p.Component, p.ParamType, p.ParamName, p.Message)
+}
+
+// ParseConfig parses a high-risk parameters config, rejecting unknown fields
+// so that misspelled keys (e.g., "check_modifed") are not silently ignored
+func ParseConfig(data []byte) (*rules.HighRiskParamsConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	config := &rules.HighRiskParamsConfig{}
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("invalid high-risk params config: %w", err)
+	}
+	return config, nil
+}
+
+// LoadConfigFile reads and parses a high-risk parameters config file (see ParseConfig)
+func LoadConfigFile(path string) (*rules.HighRiskParamsConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	config, err := ParseConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// SaveConfigFile writes a high-risk parameters config in the format of the knowledge base file
+func SaveConfigFile(config *rules.HighRiskParamsConfig, path string) error {
+	data, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal high-risk params config: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks every entry of a config and returns its problems, sorted by component, type and name
+// An entry is valid if it has a known severity and a description, well-formed versions in order,
+// non-negative thresholds with tolerance below critical_distance, and no value both allowed and unsafe
+func Validate(config *rules.HighRiskParamsConfig) []Problem {
+	var problems []Problem
+	for _, sec := range sections(config) {
+		for _, name := range sortedNames(*sec.Params) {
+			for _, message := range validateEntry(name, (*sec.Params)[name]) {
+				problems = append(problems, Problem{Component: sec.Component, ParamType: sec.ParamType, ParamName: name, Message: message})
+			}
+		}
+	}
+	return problems
+}
+
+// validateEntry returns the problems of a single entry
+// A disabled entry only needs a name, its other fields are ignored
+func validateEntry(name string, entry rules.HighRiskParamConfig) []string {
+	var problems []string
+	if strings.TrimSpace(name) == "" {
+		problems = append(problems, "parameter name is empty")
+	}
+	if entry.Disabled {
+		return problems
+	}
+	if entry.Severity == "" {
+		problems = append(problems, "severity is required")
+	} else if !validSeverities[entry.Severity] {
+		problems = append(problems, fmt.Sprintf("invalid severity %q (expected critical, error, warning or info)", entry.Severity))
+	}
+	if strings.TrimSpace(entry.Description) == "" {
+		problems = append(problems, "description is empty, findings would not explain the risk")
+	}
+
+	from, fromOK := parseVersion(entry.FromVersion)
+	if entry.FromVersion != "" && !fromOK {
+		problems = append(problems, fmt.Sprintf("invalid from_version %q (expected vX.Y.Z)", entry.FromVersion))
+	}
+	to, toOK := parseVersion(entry.ToVersion)
+	if entry.ToVersion != "" && !toOK {
+		problems = append(problems, fmt.Sprintf("invalid to_version %q (expected vX.Y.Z)", entry.ToVersion))
+	}
+	if fromOK && toOK && compareVersionParts(from, to) > 0 {
+		problems = append(problems, fmt.Sprintf("from_version %s is after to_version %s", entry.FromVersion, entry.ToVersion))
+	}
+
+	if entry.Tolerance < 0 {
+		problems = append(problems, "tolerance must not be negative")
+	}
+	if entry.CriticalDistance < 0 {
+		problems = append(problems, "critical_distance must not be negative")
+	}
+	if entry.CriticalDistance > 0 && entry.Tolerance >= entry.CriticalDistance {
+		problems = append(problems, fmt.Sprintf("tolerance %v must be below critical_distance %v", entry.Tolerance, entry.CriticalDistance))
+	}
+
+	for _, unsafe := range entry.UnsafeValues {
+		for _, allowed := range entry.AllowedValues {
+			if rules.CompareValues(unsafe, allowed) {
+				problems = append(problems, fmt.Sprintf("value %s is both allowed and unsafe", rules.FormatValue(unsafe)))
+			}
+		}
+	}
+	return problems
+}
+
+// parseVersion parses a vX.Y.Z version into its numeric parts
+func parseVersion(version string) ([3]int, bool) {
+	var parts [3]int
+	match := versionPattern.FindStringSubmatch(version)
+	if match == nil {
+		return parts, false
+	}
+	for i := range parts {
+		parts[i], _ = strconv.Atoi(match[i+1])
+	}
+	return parts, true
+}
+
+// compareVersionParts compares two parsed versions
+func compareVersionParts(a, b [3]int) int {
+	for i := range a {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
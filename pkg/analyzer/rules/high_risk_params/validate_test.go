@@ -0,0 +1,84 @@
+package high_risk_params
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate_DefaultConfig(t *testing.T) {
+	config, err := LoadConfigFile("default.json")
+	require.NoError(t, err)
+	assert.Empty(t, Validate(config))
+
+	// The shipped default must be accepted by the rule reading it
+	_, err = rules.NewHighRiskParamsRule(config)
+	require.NoError(t, err)
+}
+
+func TestValidate_Problems(t *testing.T) {
+	config := &rules.HighRiskParamsConfig{}
+	config.TiKV.Config = map[string]rules.HighRiskParamConfig{
+		"raftstore.apply-pool-size": {Severity: "warning", Description: "Valid entry", FromVersion: "v7.5.0", ToVersion: "v8.5.0"},
+		"server.grpc-concurrency": {
+			Severity:         "fatal",
+			Description:      "Invalid entry",
+			FromVersion:      "v8.5.0",
+			ToVersion:        "v7.5.0",
+			Tolerance:        4,
+			CriticalDistance: 2,
+		},
+	}
+	config.TiDB.SystemVariables = map[string]rules.HighRiskParamConfig{
+		"tidb_txn_mode": {
+			AllowedValues: []interface{}{"pessimistic", "optimistic"},
+			UnsafeValues:  []interface{}{"optimistic"},
+			FromVersion:   "8.5",
+		},
+	}
+
+	problems := Validate(config)
+	var messages []string
+	for _, problem := range problems {
+		messages = append(messages, problem.String())
+	}
+	assert.Equal(t, []string{
+		"tidb/system_variable/tidb_txn_mode: severity is required",
+		"tidb/system_variable/tidb_txn_mode: description is empty, findings would not explain the risk",
+		`tidb/system_variable/tidb_txn_mode: invalid from_version "8.5" (expected vX.Y.Z)`,
+		`tidb/system_variable/tidb_txn_mode: value "optimistic" is both allowed and unsafe`,
+		`tikv/config/server.grpc-concurrency: invalid severity "fatal" (expected critical, error, warning or info)`,
+		"tikv/config/server.grpc-concurrency: from_version v8.5.0 is after to_version v7.5.0",
+		"tikv/config/server.grpc-concurrency: tolerance 4 must be below critical_distance 2",
+	}, messages)
+}
+
+func TestParseConfig_UnknownField(t *testing.T) {
+	_, err := ParseConfig([]byte(`{"tidb": {"system_variables": {"tidb_txn_mode": {"severity": "warning", "check_modifed": true}}}}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "check_modifed")
+
+	_, err = ParseConfig([]byte(`{"tidb": {"system_variables": {"tidb_txn_mode": {"severity": "warning", "check_modified": true}}}}`))
+	require.NoError(t, err)
+}
+
+func TestSaveConfigFile_RoundTrip(t *testing.T) {
+	config := &rules.HighRiskParamsConfig{}
+	config.PD.Config = map[string]rules.HighRiskParamConfig{
+		"schedule.max-merge-region-size": {Severity: "error", Description: "Merging large regions", UnsafeValues: []interface{}{float64(0)}},
+	}
+	path := filepath.Join(t.TempDir(), "high_risk_params.json")
+	require.NoError(t, SaveConfigFile(config, path))
+
+	loaded, err := LoadConfigFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, config, loaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("{"), 0644))
+	_, err = LoadConfigFile(path)
+	assert.Error(t, err)
+}
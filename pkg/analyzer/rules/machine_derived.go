@@ -0,0 +1,206 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Resource types that machine-derived parameters depend on
+const (
+	// ResourceCPU indicates the default is derived from the number of CPU cores
+	ResourceCPU = "cpu"
+	// ResourceMemory indicates the default is derived from the amount of memory (in bytes)
+	ResourceMemory = "memory"
+)
+
+// Status keys used by collectors to expose node resources in ComponentState.Status
+const (
+	// StatusKeyCPUCores is the number of CPU cores available to the instance
+	StatusKeyCPUCores = "cpu_cores"
+	// StatusKeyMemoryBytes is the amount of memory available to the instance in bytes
+	StatusKeyMemoryBytes = "memory_bytes"
+)
+
+// MachineDerivedParam describes a parameter whose default value is computed from host resources
+// The KB defaults of such parameters come from the playground host, so they never match a real cluster
+// and must not be compared against the current value
+type MachineDerivedParam struct {
+	// Resource is the resource the default is derived from ("cpu" or "memory")
+	Resource string `json:"resource"`
+	// MinRatio is the lowest sane value relative to the resource amount (0 means no lower bound)
+	MinRatio float64 `json:"min_ratio,omitempty"`
+	// MaxRatio is the highest sane value relative to the resource amount (0 means no upper bound)
+	MaxRatio float64 `json:"max_ratio,omitempty"`
+	// Description explains how the default is derived
+	Description string `json:"description,omitempty"`
+}
+
+// MachineDerivedParams maps component to parameter name to its machine-derived classification
+// System variables use the plain variable name (without "sysvar:" prefix)
+type MachineDerivedParams map[string]map[string]MachineDerivedParam
+
+// DefaultMachineDerivedParams returns the built-in list of machine-derived parameters
+// The list can be extended via knowledge/machine_derived_params.json
+func DefaultMachineDerivedParams() MachineDerivedParams {
+	return MachineDerivedParams{
+		"tidb": {
+			"performance.max-procs": {
+				Resource:    ResourceCPU,
+				MaxRatio:    1,
+				Description: "Defaults to 0 (use all CPU cores of the host)",
+			},
+		},
+		"tikv": {
+			"readpool.unified.max-thread-count": {
+				Resource:    ResourceCPU,
+				MaxRatio:    1,
+				Description: "Defaults to 80% of CPU cores (minimum 4)",
+			},
+			"readpool.storage.max-thread-count": {
+				Resource:    ResourceCPU,
+				MaxRatio:    1,
+				Description: "Defaults to 80% of CPU cores (minimum 4)",
+			},
+			"readpool.coprocessor.max-thread-count": {
+				Resource:    ResourceCPU,
+				MaxRatio:    1,
+				Description: "Defaults to 80% of CPU cores (minimum 4)",
+			},
+			"server.grpc-concurrency": {
+				Resource:    ResourceCPU,
+				MaxRatio:    1,
+				Description: "Defaults to a value derived from the number of CPU cores",
+			},
+			"storage.block-cache.capacity": {
+				Resource:    ResourceMemory,
+				MaxRatio:    0.8,
+				Description: "Defaults to 45% of total system memory",
+			},
+		},
+	}
+}
+
+// Merge adds all entries of other to m, overriding existing entries with the same name
+func (m MachineDerivedParams) Merge(other MachineDerivedParams) {
+	for component, params := range other {
+		if m[component] == nil {
+			m[component] = make(map[string]MachineDerivedParam)
+		}
+		for name, param := range params {
+			m[component][name] = param
+		}
+	}
+}
+
+// ParseMachineDerivedParams converts machine_derived_params loaded from the knowledge base
+// (generic JSON map) into MachineDerivedParams
+func ParseMachineDerivedParams(raw interface{}) (MachineDerivedParams, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	params := make(MachineDerivedParams)
+	if err := json.Unmarshal(data, &params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// NodeResources returns the resource amount of a component instance, as exposed by the collector
+// Returns false if the resource is not available
+func NodeResources(status map[string]interface{}, resource string) (float64, bool) {
+	var key string
+	switch resource {
+	case ResourceCPU:
+		key = StatusKeyCPUCores
+	case ResourceMemory:
+		key = StatusKeyMemoryBytes
+	default:
+		return 0, false
+	}
+	amount, ok := ToNumeric(status[key])
+	if !ok || amount <= 0 {
+		return 0, false
+	}
+	return amount, true
+}
+
+// sizeValuePattern matches size values such as "4GiB", "512MB", "1.5gb" or "1024"
+var sizeValuePattern = regexp.MustCompile(`^([0-9]+(?:\.[0-9]+)?)\s*([a-zA-Z]*)$`)
+
+// sizeUnits maps size unit suffixes (lower case) to their multiplier in bytes
+// TiKV treats KB/MB/GB as binary units, same as KiB/MiB/GiB
+var sizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1 << 10,
+	"kb":  1 << 10,
+	"kib": 1 << 10,
+	"m":   1 << 20,
+	"mb":  1 << 20,
+	"mib": 1 << 20,
+	"g":   1 << 30,
+	"gb":  1 << 30,
+	"gib": 1 << 30,
+	"t":   1 << 40,
+	"tb":  1 << 40,
+	"tib": 1 << 40,
+}
+
+// ParseResourceAmount converts a parameter value to an amount comparable to the node resource
+// CPU values are plain numbers; memory values may be size strings (e.g., "4GiB") and are converted to bytes
+func ParseResourceAmount(value interface{}, resource string) (float64, bool) {
+	if amount, ok := ToNumeric(value); ok {
+		return amount, true
+	}
+	if resource != ResourceMemory {
+		return 0, false
+	}
+
+	str, ok := value.(string)
+	if !ok {
+		return 0, false
+	}
+	match := sizeValuePattern.FindStringSubmatch(strings.TrimSpace(str))
+	if match == nil {
+		return 0, false
+	}
+	multiplier, ok := sizeUnits[strings.ToLower(match[2])]
+	if !ok {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	return number * multiplier, true
+}
+
+// IsOutOfResourceRange checks whether a parameter value is outside the sane range for the node resources
+// Returns (outOfRange, ratio, ok); ok is false if the value or the resource amount is unknown
+// A value of 0 means "auto" for these parameters and is always considered in range
+func (p MachineDerivedParam) IsOutOfResourceRange(value interface{}, status map[string]interface{}) (bool, float64, bool) {
+	resourceAmount, ok := NodeResources(status, p.Resource)
+	if !ok {
+		return false, 0, false
+	}
+	amount, ok := ParseResourceAmount(value, p.Resource)
+	if !ok {
+		return false, 0, false
+	}
+	if amount == 0 {
+		return false, 0, true
+	}
+
+	ratio := amount / resourceAmount
+	if p.MinRatio > 0 && ratio < p.MinRatio {
+		return true, ratio, true
+	}
+	if p.MaxRatio > 0 && ratio > p.MaxRatio {
+		return true, ratio, true
+	}
+	return false, ratio, true
+}
@@ -0,0 +1,91 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseResourceAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		resource string
+		want     float64
+		wantOK   bool
+	}{
+		{name: "cpu int", value: 8, resource: ResourceCPU, want: 8, wantOK: true},
+		{name: "cpu string", value: "12", resource: ResourceCPU, want: 12, wantOK: true},
+		{name: "cpu size string", value: "4GiB", resource: ResourceCPU, wantOK: false},
+		{name: "memory bytes", value: float64(1 << 30), resource: ResourceMemory, want: 1 << 30, wantOK: true},
+		{name: "memory GiB", value: "4GiB", resource: ResourceMemory, want: 4 << 30, wantOK: true},
+		{name: "memory MB", value: "512MB", resource: ResourceMemory, want: 512 << 20, wantOK: true},
+		{name: "memory fractional", value: "1.5GB", resource: ResourceMemory, want: 1.5 * (1 << 30), wantOK: true},
+		{name: "memory unknown unit", value: "4XB", resource: ResourceMemory, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseResourceAmount(tt.value, tt.resource)
+			assert.Equal(t, tt.wantOK, ok)
+			if tt.wantOK {
+				assert.InDelta(t, tt.want, got, 1)
+			}
+		})
+	}
+}
+
+func TestMachineDerivedParam_IsOutOfResourceRange(t *testing.T) {
+	cpuParam := MachineDerivedParam{Resource: ResourceCPU, MaxRatio: 1}
+	memoryParam := MachineDerivedParam{Resource: ResourceMemory, MaxRatio: 0.8}
+	status := map[string]interface{}{
+		StatusKeyCPUCores:    float64(8),
+		StatusKeyMemoryBytes: float64(16 << 30),
+	}
+
+	tests := []struct {
+		name           string
+		param          MachineDerivedParam
+		value          interface{}
+		status         map[string]interface{}
+		wantOutOfRange bool
+		wantOK         bool
+	}{
+		{name: "cpu within range", param: cpuParam, value: 6, status: status, wantOutOfRange: false, wantOK: true},
+		{name: "cpu above range", param: cpuParam, value: 32, status: status, wantOutOfRange: true, wantOK: true},
+		{name: "zero means auto", param: cpuParam, value: 0, status: status, wantOutOfRange: false, wantOK: true},
+		{name: "memory within range", param: memoryParam, value: "7GiB", status: status, wantOutOfRange: false, wantOK: true},
+		{name: "memory above range", param: memoryParam, value: "15GiB", status: status, wantOutOfRange: true, wantOK: true},
+		{name: "below minimum", param: MachineDerivedParam{Resource: ResourceCPU, MinRatio: 0.5}, value: 2, status: status, wantOutOfRange: true, wantOK: true},
+		{name: "no resource info", param: cpuParam, value: 32, status: map[string]interface{}{}, wantOutOfRange: false, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outOfRange, _, ok := tt.param.IsOutOfResourceRange(tt.value, tt.status)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantOutOfRange, outOfRange)
+		})
+	}
+}
+
+func TestParseMachineDerivedParams(t *testing.T) {
+	raw := map[string]interface{}{
+		"tikv": map[string]interface{}{
+			"rocksdb.max-background-jobs": map[string]interface{}{
+				"resource":  "cpu",
+				"max_ratio": 1.0,
+			},
+		},
+	}
+
+	params, err := ParseMachineDerivedParams(raw)
+	require.NoError(t, err)
+	assert.Equal(t, ResourceCPU, params["tikv"]["rocksdb.max-background-jobs"].Resource)
+
+	merged := DefaultMachineDerivedParams()
+	merged.Merge(params)
+	assert.Contains(t, merged["tikv"], "rocksdb.max-background-jobs")
+	assert.Contains(t, merged["tikv"], "readpool.unified.max-thread-count")
+}
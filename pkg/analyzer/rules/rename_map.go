@@ -0,0 +1,66 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// RenameMap lists the parameters renamed between versions, per component: component -> old name -> new name
+// Names are the knowledge base keys (system variables are prefixed with "sysvar:")
+// It is loaded from knowledge/rename_map.json, e.g.:
+//
+//	{"tikv": {"server.snap-max-write-bytes-per-sec": "server.snap-io-max-bytes-per-sec"}}
+type RenameMap map[string]map[string]string
+
+// ParseRenameMap converts rename_map loaded from the knowledge base (generic JSON map) into a RenameMap
+func ParseRenameMap(raw interface{}) (RenameMap, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var renameMap RenameMap
+	if err := json.Unmarshal(data, &renameMap); err != nil {
+		return nil, err
+	}
+	return renameMap, nil
+}
+
+// RenamedParameter is a parameter of the source version knowledge base that is renamed in the target version
+type RenamedParameter struct {
+	// OldName is the knowledge base key of the parameter in the source version
+	OldName string
+	// NewName is the knowledge base key of the parameter in the target version
+	NewName string
+}
+
+// GetRenamedParameters returns the parameters of a component renamed by the upgrade, keyed by their new name
+// A rename applies if the old name is only in the source knowledge base and the new name only in the target one,
+// i.e. if the upgrade would otherwise show the old name disappearing and the new name appearing
+func (ctx *RuleContext) GetRenamedParameters(component string) map[string]RenamedParameter {
+	renamed := make(map[string]RenamedParameter)
+	for oldName, newName := range ctx.RenameMap[component] {
+		if _, ok := ctx.SourceDefaults[component][oldName]; !ok {
+			continue
+		}
+		if _, ok := ctx.TargetDefaults[component][oldName]; ok {
+			continue
+		}
+		if _, ok := ctx.TargetDefaults[component][newName]; !ok {
+			continue
+		}
+		if _, ok := ctx.SourceDefaults[component][newName]; ok {
+			continue
+		}
+		renamed[newName] = RenamedParameter{OldName: oldName, NewName: newName}
+	}
+	return renamed
+}
+
+// displayParameterName returns the name of a knowledge base key as shown in reports, and its parameter type
+func displayParameterName(key string) (string, string) {
+	if name := strings.TrimPrefix(key, "sysvar:"); name != key {
+		return name, "system_variable"
+	}
+	return key, "config"
+}
@@ -0,0 +1,40 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ResourceControlChange is a version where the accounting of request units (RU) changed
+// Resource groups with an RU quota were sized for the old accounting, the same workload may
+// consume a different number of RUs after an upgrade crossing the change and be throttled
+// Loaded from knowledge/resource_control_changes.json (global, version-agnostic)
+type ResourceControlChange struct {
+	// Version is the first version with the new accounting (e.g., "v7.4.0")
+	Version string `json:"version"`
+	// Description explains what changed
+	Description string `json:"description"`
+	// Suggestions are the actions recommended before upgrading
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// ParseResourceControlChanges converts resource_control_changes loaded from the knowledge base (generic JSON map)
+// into the list of RU accounting changes
+func ParseResourceControlChanges(raw interface{}) ([]ResourceControlChange, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal resource_control_changes: %w", err)
+	}
+	var file struct {
+		RUAccountingChanges []ResourceControlChange `json:"ru_accounting_changes"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse resource_control_changes: %w", err)
+	}
+	for i, change := range file.RUAccountingChanges {
+		if change.Version == "" {
+			return nil, fmt.Errorf("resource_control_changes entry %d has no version", i)
+		}
+	}
+	return file.RUAccountingChanges, nil
+}
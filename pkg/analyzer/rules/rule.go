@@ -19,6 +19,15 @@ type DataSourceRequirement struct {
 		NeedSystemVariables bool `json:"need_system_variables"`
 		// NeedAllTikvNodes indicates if all TiKV nodes' data is needed (for consistency checks)
 		NeedAllTikvNodes bool `json:"need_all_tikv_nodes"`
+		// NeedGlobalVariablesTable indicates if the rows of the mysql.global_variables table are needed
+		// Reading the table requires an extra query and the SELECT privilege on it
+		NeedGlobalVariablesTable bool `json:"need_global_variables_table"`
+		// NeedGCSafePoints indicates if the GC safepoint (mysql.tidb) and the PD service safepoints are needed
+		NeedGCSafePoints bool `json:"need_gc_safe_points"`
+		// NeedPlacement indicates if the resource groups, placement policies (information_schema) and PD store labels are needed
+		NeedPlacement bool `json:"need_placement"`
+		// NeedStores indicates if the TiKV stores registered in PD (/pd/api/v1/stores), with their versions and states, are needed
+		NeedStores bool `json:"need_stores"`
 	} `json:"source_cluster_requirements"`
 
 	// SourceKBRequirements defines what data is needed from source version knowledge base
@@ -0,0 +1,394 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Comparisons of a dangerous combination condition (see CombinationCondition)
+const (
+	// ComparisonEqual matches a runtime value equal to the value of the condition
+	ComparisonEqual = "eq"
+	// ComparisonNotEqual matches a runtime value different from the value of the condition
+	ComparisonNotEqual = "ne"
+	// ComparisonGreater matches a runtime value greater than the value of the condition
+	ComparisonGreater = "gt"
+	// ComparisonGreaterEqual matches a runtime value greater than or equal to the value of the condition
+	ComparisonGreaterEqual = "ge"
+	// ComparisonLess matches a runtime value less than the value of the condition
+	ComparisonLess = "lt"
+	// ComparisonLessEqual matches a runtime value less than or equal to the value of the condition
+	ComparisonLessEqual = "le"
+)
+
+// MaxCombinationConditions is the maximum number of conditions of a dangerous combination
+const MaxCombinationConditions = 4
+
+// CombinationCondition is a predicate over a runtime parameter of a dangerous combination
+// Example: {"component": "tidb", "param": "sysvar:tidb_ddl_disk_quota", "comparison": "ge", "value": "100GiB", "type": "size"}
+type CombinationCondition struct {
+	// Component is the component of the parameter (tidb, pd, tikv or tiflash)
+	Component string `json:"component"`
+	// Param is the parameter name, system variables with the "sysvar:" prefix
+	// Parameters of nested sections are named with dots (e.g., "storage.block-cache.shared")
+	Param string `json:"param"`
+	// Comparison is eq, ne (any type), gt, ge, lt or le (numbers, sizes and durations)
+	Comparison string `json:"comparison"`
+	// Value is compared with the runtime value
+	Value interface{} `json:"value"`
+	// Type guides the comparison (see types.ParameterValue.Equal): bool, int, float, size, duration or string
+	// If empty, the type of the runtime value, of the knowledge base default, or of Value is used
+	Type string `json:"type,omitempty"`
+}
+
+// VersionRange is a range of versions [Min, Max), either bound may be empty
+type VersionRange struct {
+	Min string `json:"min,omitempty"`
+	Max string `json:"max,omitempty"`
+}
+
+// Contains checks if version is in the range
+func (r VersionRange) Contains(version string) bool {
+	version = strings.TrimPrefix(version, "v")
+	if r.Min != "" && compareVersions(version, strings.TrimPrefix(r.Min, "v")) < 0 {
+		return false
+	}
+	if r.Max != "" && compareVersions(version, strings.TrimPrefix(r.Max, "v")) >= 0 {
+		return false
+	}
+	return true
+}
+
+// String describes the range (e.g., ">= v8.4.0")
+func (r VersionRange) String() string {
+	switch {
+	case r.Min != "" && r.Max != "":
+		return fmt.Sprintf(">= %s and < %s", r.Min, r.Max)
+	case r.Min != "":
+		return ">= " + r.Min
+	case r.Max != "":
+		return "< " + r.Max
+	}
+	return "any version"
+}
+
+// DangerousCombination is a configuration that becomes dangerous under the behavior of the target version,
+// e.g., a value kept by the user while the meaning of the parameter or of an interacting default changed
+// It holds when the target version is in TargetVersions and all its conditions hold
+// Loaded from knowledge/dangerous_combinations.json (global, version-agnostic)
+type DangerousCombination struct {
+	// ID uniquely identifies the combination (e.g., "tidb-binlog-removed")
+	ID string `json:"id"`
+	// TargetVersions are the target versions the combination is dangerous on
+	TargetVersions VersionRange `json:"target_versions"`
+	// Conditions must all hold (AND), on at least one instance of their component
+	Conditions []CombinationCondition `json:"conditions"`
+	// Explanation tells what goes wrong on the target version
+	Explanation string `json:"explanation"`
+	// Suggestions are reported with the finding
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// validate checks the combination
+func (c DangerousCombination) validate() error {
+	if c.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	if c.Explanation == "" {
+		return fmt.Errorf("combination %s: explanation is required", c.ID)
+	}
+	if len(c.Conditions) == 0 || len(c.Conditions) > MaxCombinationConditions {
+		return fmt.Errorf("combination %s: between 1 and %d conditions are required, got %d", c.ID, MaxCombinationConditions, len(c.Conditions))
+	}
+	for i, condition := range c.Conditions {
+		switch condition.Component {
+		case "tidb", "pd", "tikv", "tiflash":
+		default:
+			return fmt.Errorf("combination %s: condition %d: invalid component %q (tidb, pd, tikv or tiflash)", c.ID, i, condition.Component)
+		}
+		if condition.Param == "" {
+			return fmt.Errorf("combination %s: condition %d: param is required", c.ID, i)
+		}
+		if condition.Value == nil {
+			return fmt.Errorf("combination %s: condition %d: value is required", c.ID, i)
+		}
+		switch condition.Comparison {
+		case ComparisonEqual, ComparisonNotEqual:
+		case ComparisonGreater, ComparisonGreaterEqual, ComparisonLess, ComparisonLessEqual:
+			value := defaultsTypes.ParameterValue{Value: condition.Value, Type: condition.Type}
+			if _, ok := value.Compare(value); !ok {
+				return fmt.Errorf("combination %s: condition %d: comparison %s requires a number, size or duration value", c.ID, i, condition.Comparison)
+			}
+		default:
+			return fmt.Errorf("combination %s: condition %d: invalid comparison %q (eq, ne, gt, ge, lt or le)", c.ID, i, condition.Comparison)
+		}
+	}
+	return nil
+}
+
+// ParseDangerousCombinations converts dangerous_combinations loaded from the knowledge base (generic JSON map)
+// into the list of dangerous combinations
+func ParseDangerousCombinations(raw interface{}) ([]DangerousCombination, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal dangerous_combinations: %w", err)
+	}
+	var file struct {
+		DangerousCombinations []DangerousCombination `json:"dangerous_combinations"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse dangerous_combinations: %w", err)
+	}
+	for _, combination := range file.DangerousCombinations {
+		if err := combination.validate(); err != nil {
+			return nil, fmt.Errorf("invalid dangerous_combinations: %w", err)
+		}
+	}
+	return file.DangerousCombinations, nil
+}
+
+// conditionMatch is a condition holding on some instances of its component
+type conditionMatch struct {
+	condition CombinationCondition
+	// values are the runtime values satisfying the condition, by instance address
+	values map[string]interface{}
+}
+
+// evaluateCondition checks a condition on every instance of its component
+// Returns the instances where it holds; instances without the parameter don't satisfy it
+func evaluateCondition(ruleCtx *RuleContext, condition CombinationCondition) conditionMatch {
+	match := conditionMatch{condition: condition, values: make(map[string]interface{})}
+	name, isSysvar := strings.CutPrefix(condition.Param, "sysvar:")
+	kbType := extractTypeFromDefault(ruleCtx.SourceDefaults[condition.Component][condition.Param])
+	for _, node := range ruleCtx.SourceClusterSnapshot.ComponentsByType(defaultsTypes.ComponentType(condition.Component)) {
+		params := node.Config
+		if isSysvar {
+			params = node.Variables
+		}
+		current, ok := lookupParameter(params, name)
+		if !ok {
+			continue
+		}
+		if conditionHolds(condition, current, kbType) {
+			address := condition.Component
+			if addr, ok := node.Status["address"].(string); ok && addr != "" {
+				address = addr
+			}
+			match.values[address] = current.Value
+		}
+	}
+	return match
+}
+
+// lookupParameter returns a parameter by name, looking into nested sections if it is not a top-level key
+func lookupParameter(params defaultsTypes.ParameterMap, name string) (defaultsTypes.ParameterValue, bool) {
+	if value, ok := params[name]; ok && value.Value != nil {
+		return value, true
+	}
+	if value, ok := flattenParameterMap(params)[name]; ok && value != nil {
+		return defaultsTypes.ParameterValue{Value: value}, true
+	}
+	return defaultsTypes.ParameterValue{}, false
+}
+
+// conditionHolds evaluates the comparison of a condition on a runtime value
+// The type of the comparison is the type of the condition, else of the runtime value, of the
+// knowledge base default (kbType) or of the condition value, in that order
+func conditionHolds(condition CombinationCondition, current defaultsTypes.ParameterValue, kbType string) bool {
+	paramType := condition.Type
+	for _, candidate := range []string{current.Type, kbType, valueType(condition.Value)} {
+		if paramType == "" && isComparisonType(candidate) {
+			paramType = candidate
+		}
+	}
+	current.Type = paramType
+	expected := defaultsTypes.ParameterValue{Value: condition.Value, Type: paramType}
+
+	switch condition.Comparison {
+	case ComparisonEqual:
+		return current.Equal(expected)
+	case ComparisonNotEqual:
+		return !current.Equal(expected)
+	}
+	order, ok := current.Compare(expected)
+	if !ok {
+		return false
+	}
+	switch condition.Comparison {
+	case ComparisonGreater:
+		return order > 0
+	case ComparisonGreaterEqual:
+		return order >= 0
+	case ComparisonLess:
+		return order < 0
+	case ComparisonLessEqual:
+		return order <= 0
+	}
+	return false
+}
+
+// isComparisonType checks if a parameter type guides the comparison of values (see types.ParameterValue.Equal)
+func isComparisonType(paramType string) bool {
+	switch paramType {
+	case "bool", "int", "float", "number", "size", "duration", "string":
+		return true
+	}
+	return false
+}
+
+// valueType returns the comparison type of a JSON value of a condition
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	}
+	return ""
+}
+
+// DangerousCombinationRule reports configurations that become dangerous under the target version
+// Rule: For each combination of knowledge/dangerous_combinations.json whose target version range
+// contains the target version, report a critical finding if all its conditions hold on the runtime
+// configuration (each on at least one instance of its component), with the explanation of the combination
+type DangerousCombinationRule struct {
+	*BaseRule
+}
+
+// NewDangerousCombinationRule creates a new dangerous combination rule
+func NewDangerousCombinationRule() Rule {
+	return &DangerousCombinationRule{
+		BaseRule: NewBaseRule(
+			"DANGEROUS_COMBINATIONS",
+			"Detect configurations that conflict dangerously with the behavior or defaults of the target version",
+			"dangerous_combination",
+		),
+	}
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *DangerousCombinationRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:          []string{"tidb", "pd", "tikv", "tiflash"},
+			NeedConfig:          true,
+			NeedSystemVariables: true,
+			NeedAllTikvNodes:    true, // Conditions on TiKV hold if any node matches
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          []string{"tidb", "pd", "tikv", "tiflash"},
+			NeedConfigDefaults:  true, // The types of the defaults guide the comparisons
+			NeedSystemVariables: true,
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{}, // dangerous_combinations is a global knowledge file
+		},
+	}
+}
+
+// Evaluate performs the rule check
+func (r *DangerousCombinationRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil || len(ruleCtx.DangerousCombinations) == 0 {
+		return results, nil
+	}
+
+	examined := 0
+	for _, combination := range ruleCtx.DangerousCombinations {
+		if !combination.TargetVersions.Contains(ruleCtx.TargetVersion) {
+			continue
+		}
+		examined += len(combination.Conditions)
+		matches := make([]conditionMatch, 0, len(combination.Conditions))
+		for _, condition := range combination.Conditions {
+			match := evaluateCondition(ruleCtx, condition)
+			if len(match.values) == 0 {
+				break
+			}
+			matches = append(matches, match)
+		}
+		if len(matches) == len(combination.Conditions) {
+			results = append(results, r.newResult(ruleCtx, combination, matches))
+		}
+	}
+	results = append(results, NewStatisticsResult(r, RuleStatistics{ParametersExamined: examined}))
+	return results, nil
+}
+
+// newResult creates the critical finding of a dangerous combination
+func (r *DangerousCombinationRule) newResult(ruleCtx *RuleContext, combination DangerousCombination, matches []conditionMatch) CheckResult {
+	var conditions, lines []string
+	var current interface{}
+	for i, match := range matches {
+		condition := match.condition
+		conditions = append(conditions, fmt.Sprintf("%s %s %s %s", condition.Component, condition.Param,
+			condition.Comparison, FormatValue(condition.Value)))
+		addresses := make([]string, 0, len(match.values))
+		for address := range match.values {
+			addresses = append(addresses, address)
+		}
+		sort.Strings(addresses)
+		if i == 0 {
+			current = match.values[addresses[0]]
+		}
+		for _, address := range addresses {
+			lines = append(lines, fmt.Sprintf("- %s %s = %s (%s)", condition.Component, condition.Param,
+				FormatValue(match.values[address]), address))
+		}
+	}
+
+	first := matches[0]
+	paramType := "config"
+	if strings.HasPrefix(first.condition.Param, "sysvar:") {
+		paramType = "system_variable"
+	}
+	suggestions := combination.Suggestions
+	if len(suggestions) == 0 {
+		suggestions = []string{"Change the configuration before upgrading, or review the behavior of the target version with the explanation above"}
+	}
+
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     first.condition.Component,
+		ParameterName: strings.TrimPrefix(first.condition.Param, "sysvar:"),
+		ParamType:     paramType,
+		Description:   r.Description(),
+		Severity:      "critical",
+		RiskLevel:     RiskLevelHigh,
+		Message: fmt.Sprintf("Dangerous configuration for %s (%s): %s", ruleCtx.TargetVersion, combination.ID,
+			strings.Join(conditions, " and ")),
+		Details: fmt.Sprintf("%s\n\nMatching configuration (target versions %s):\n%s",
+			combination.Explanation, combination.TargetVersions, strings.Join(lines, "\n")),
+		CurrentValue: current,
+		Suggestions:  suggestions,
+		Metadata:     map[string]interface{}{"combination": combination.ID},
+	}
+}
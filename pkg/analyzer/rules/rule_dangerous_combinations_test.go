@@ -0,0 +1,234 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConditionHolds(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition CombinationCondition
+		current   defaultsTypes.ParameterValue
+		kbType    string
+		want      bool
+	}{
+		// Numeric comparisons with unit normalization
+		{"size ge across units", CombinationCondition{Comparison: "ge", Value: "100GiB", Type: "size"}, defaultsTypes.ParameterValue{Value: float64(107374182400)}, "", true},
+		{"size lt across units", CombinationCondition{Comparison: "lt", Value: "1GB", Type: "size"}, defaultsTypes.ParameterValue{Value: "512MiB"}, "", true},
+		{"size gt false", CombinationCondition{Comparison: "gt", Value: "1GiB", Type: "size"}, defaultsTypes.ParameterValue{Value: "1024MB"}, "", false},
+		{"size type of the runtime value", CombinationCondition{Comparison: "le", Value: "64MB"}, defaultsTypes.ParameterValue{Value: "32MiB", Type: "size"}, "", true},
+		{"size type of the knowledge base", CombinationCondition{Comparison: "ge", Value: "8KB"}, defaultsTypes.ParameterValue{Value: "8KiB"}, "size", true},
+		{"duration gt across units", CombinationCondition{Comparison: "gt", Value: "10m", Type: "duration"}, defaultsTypes.ParameterValue{Value: "1h"}, "", true},
+		{"duration le", CombinationCondition{Comparison: "le", Value: "1s", Type: "duration"}, defaultsTypes.ParameterValue{Value: "1500ms"}, "", false},
+		{"number lt", CombinationCondition{Comparison: "lt", Value: float64(3)}, defaultsTypes.ParameterValue{Value: int64(2)}, "", true},
+		{"number from string", CombinationCondition{Comparison: "ge", Value: float64(1000)}, defaultsTypes.ParameterValue{Value: "1.44e+06"}, "", true},
+		{"number not comparable", CombinationCondition{Comparison: "gt", Value: float64(0)}, defaultsTypes.ParameterValue{Value: "auto"}, "", false},
+		// Equality on normalized booleans
+		{"bool ON", CombinationCondition{Comparison: "eq", Value: true}, defaultsTypes.ParameterValue{Value: "ON"}, "", true},
+		{"bool 1", CombinationCondition{Comparison: "eq", Value: true}, defaultsTypes.ParameterValue{Value: "1"}, "", true},
+		{"bool false vs OFF", CombinationCondition{Comparison: "eq", Value: false, Type: "bool"}, defaultsTypes.ParameterValue{Value: "off"}, "", true},
+		{"bool ne", CombinationCondition{Comparison: "ne", Value: true}, defaultsTypes.ParameterValue{Value: false}, "", true},
+		{"bool differs", CombinationCondition{Comparison: "eq", Value: true}, defaultsTypes.ParameterValue{Value: "OFF"}, "", false},
+		// Equality on enums
+		{"enum ignoring case", CombinationCondition{Comparison: "eq", Value: "CANCEL"}, defaultsTypes.ParameterValue{Value: "cancel"}, "", true},
+		{"enum ne", CombinationCondition{Comparison: "ne", Value: "LOG"}, defaultsTypes.ParameterValue{Value: "CANCEL"}, "", true},
+		{"enum differs", CombinationCondition{Comparison: "eq", Value: "zstd"}, defaultsTypes.ParameterValue{Value: "lz4"}, "", false},
+		{"path", CombinationCondition{Comparison: "eq", Value: "/tmp/tidb", Type: "string"}, defaultsTypes.ParameterValue{Value: "/tmp/tidb"}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, conditionHolds(tt.condition, tt.current, tt.kbType))
+		})
+	}
+}
+
+func TestVersionRange_Contains(t *testing.T) {
+	r := VersionRange{Min: "v6.5.0", Max: "v8.0.0"}
+	assert.False(t, r.Contains("v6.1.7"))
+	assert.True(t, r.Contains("v6.5.0"))
+	assert.True(t, r.Contains("7.5.1"))
+	assert.False(t, r.Contains("v8.0.0"))
+	assert.True(t, VersionRange{}.Contains("v8.5.0"))
+	assert.True(t, VersionRange{Min: "v8.4.0"}.Contains("v8.5.0"))
+	assert.Equal(t, ">= v6.5.0 and < v8.0.0", r.String())
+}
+
+func TestParseDangerousCombinations(t *testing.T) {
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"id":          "test",
+			"explanation": "dangerous",
+			"conditions": []interface{}{
+				map[string]interface{}{"component": "tidb", "param": "sysvar:tidb_mem_quota_query", "comparison": "ge", "value": "8GiB", "type": "size"},
+			},
+		}
+	}
+	parse := func(combination map[string]interface{}) error {
+		_, err := ParseDangerousCombinations(map[string]interface{}{"dangerous_combinations": []interface{}{combination}})
+		return err
+	}
+	assert.NoError(t, parse(valid()))
+
+	tests := []struct {
+		name   string
+		modify func(map[string]interface{})
+		errMsg string
+	}{
+		{"no id", func(c map[string]interface{}) { delete(c, "id") }, "id is required"},
+		{"no explanation", func(c map[string]interface{}) { delete(c, "explanation") }, "explanation is required"},
+		{"no conditions", func(c map[string]interface{}) { c["conditions"] = []interface{}{} }, "between 1 and 4 conditions"},
+		{"too many conditions", func(c map[string]interface{}) {
+			condition := c["conditions"].([]interface{})[0]
+			c["conditions"] = []interface{}{condition, condition, condition, condition, condition}
+		}, "between 1 and 4 conditions"},
+		{"invalid component", func(c map[string]interface{}) {
+			c["conditions"].([]interface{})[0].(map[string]interface{})["component"] = "ticdc"
+		}, `invalid component "ticdc"`},
+		{"invalid comparison", func(c map[string]interface{}) {
+			c["conditions"].([]interface{})[0].(map[string]interface{})["comparison"] = "contains"
+		}, `invalid comparison "contains"`},
+		{"ordering a string", func(c map[string]interface{}) {
+			condition := c["conditions"].([]interface{})[0].(map[string]interface{})
+			condition["value"] = "CANCEL"
+			delete(condition, "type")
+		}, "requires a number, size or duration value"},
+		{"no value", func(c map[string]interface{}) {
+			delete(c["conditions"].([]interface{})[0].(map[string]interface{}), "value")
+		}, "value is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			combination := valid()
+			tt.modify(combination)
+			assert.ErrorContains(t, parse(combination), tt.errMsg)
+		})
+	}
+}
+
+func TestParseDangerousCombinations_KnowledgeFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "knowledge", "dangerous_combinations.json"))
+	require.NoError(t, err)
+	var raw interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+
+	combinations, err := ParseDangerousCombinations(raw)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, len(combinations), 3)
+	ids := make(map[string]bool)
+	for _, combination := range combinations {
+		assert.False(t, ids[combination.ID], "duplicate id %s", combination.ID)
+		ids[combination.ID] = true
+	}
+}
+
+// newDangerousCombinationContext returns a rule context of an upgrade to targetVersion with a TiDB instance
+// and two TiKV nodes
+func newDangerousCombinationContext(targetVersion string, tidbConfig, tidbVariables map[string]interface{}, tikvConfigs ...map[string]interface{}) *RuleContext {
+	snapshot := &collector.ClusterSnapshot{Components: map[string]collector.ComponentState{
+		"tidb": {
+			Type:      defaultsTypes.ComponentTiDB,
+			Config:    defaultsTypes.ConvertConfigToDefaults(tidbConfig),
+			Variables: defaultsTypes.ConvertConfigToDefaults(tidbVariables),
+			Status:    map[string]interface{}{"address": "10.0.0.1:4000"},
+		},
+	}}
+	for i, config := range tikvConfigs {
+		addr := []string{"10.0.0.2:20160", "10.0.0.3:20160"}[i]
+		snapshot.Components["tikv-"+addr] = collector.ComponentState{
+			Type:   defaultsTypes.ComponentTiKV,
+			Config: defaultsTypes.ConvertConfigToDefaults(config),
+			Status: map[string]interface{}{"address": addr},
+		}
+	}
+	return &RuleContext{
+		SourceClusterSnapshot: snapshot,
+		SourceVersion:         "v6.1.7",
+		TargetVersion:         targetVersion,
+		DangerousCombinations: []DangerousCombination{
+			{
+				ID:             "fast-reorg-default-temp-dir",
+				TargetVersions: VersionRange{Min: "v6.5.0"},
+				Conditions: []CombinationCondition{
+					{Component: "tidb", Param: "sysvar:tidb_ddl_enable_fast_reorg", Comparison: "eq", Value: true, Type: "bool"},
+					{Component: "tidb", Param: "temp-dir", Comparison: "eq", Value: "/tmp/tidb", Type: "string"},
+					{Component: "tidb", Param: "sysvar:tidb_ddl_disk_quota", Comparison: "ge", Value: "100GiB", Type: "size"},
+				},
+				Explanation: "Fast reorg sorts index data under temp-dir",
+				Suggestions: []string{"Set temp-dir to a dedicated disk"},
+			},
+			{
+				ID:             "tikv-block-cache-always-shared",
+				TargetVersions: VersionRange{Min: "v6.6.0"},
+				Conditions: []CombinationCondition{
+					{Component: "tikv", Param: "storage.block-cache.shared", Comparison: "eq", Value: false, Type: "bool"},
+				},
+				Explanation: "The block cache is always shared",
+			},
+		},
+	}
+}
+
+func TestDangerousCombinationRule_Evaluate(t *testing.T) {
+	rule := NewDangerousCombinationRule()
+	tidbConfig := map[string]interface{}{"temp-dir": "/tmp/tidb"}
+	tidbVariables := map[string]interface{}{"tidb_ddl_enable_fast_reorg": "ON", "tidb_ddl_disk_quota": "107374182400"}
+	tikvConfigs := []map[string]interface{}{
+		{"storage": map[string]interface{}{"block-cache": map[string]interface{}{"shared": true}}},
+		{"storage": map[string]interface{}{"block-cache": map[string]interface{}{"shared": false}}},
+	}
+
+	results, err := rule.Evaluate(context.Background(), newDangerousCombinationContext("v6.6.0", tidbConfig, tidbVariables, tikvConfigs...))
+	require.NoError(t, err)
+	findings := withoutStatistics(results)
+	require.Len(t, findings, 2)
+
+	assert.Equal(t, "critical", findings[0].Severity)
+	assert.Equal(t, RiskLevelHigh, findings[0].RiskLevel)
+	assert.Equal(t, "tidb_ddl_enable_fast_reorg", findings[0].ParameterName)
+	assert.Equal(t, "system_variable", findings[0].ParamType)
+	assert.Equal(t, "fast-reorg-default-temp-dir", findings[0].Metadata["combination"])
+	assert.Contains(t, findings[0].Details, "Fast reorg sorts index data under temp-dir")
+	assert.Contains(t, findings[0].Details, `tidb temp-dir = "/tmp/tidb" (10.0.0.1:4000)`)
+	assert.Equal(t, []string{"Set temp-dir to a dedicated disk"}, findings[0].Suggestions)
+
+	// Conditions on TiKV hold if any node matches, and the finding lists the matching nodes only
+	assert.Equal(t, "tikv", findings[1].Component)
+	assert.Contains(t, findings[1].Details, "10.0.0.3:20160")
+	assert.NotContains(t, findings[1].Details, "10.0.0.2:20160")
+	assert.Equal(t, false, findings[1].CurrentValue)
+}
+
+func TestDangerousCombinationRule_Evaluate_NoMatch(t *testing.T) {
+	rule := NewDangerousCombinationRule()
+	tidbVariables := map[string]interface{}{"tidb_ddl_enable_fast_reorg": "ON", "tidb_ddl_disk_quota": "107374182400"}
+
+	tests := []struct {
+		name          string
+		targetVersion string
+		tidbConfig    map[string]interface{}
+		tidbVariables map[string]interface{}
+	}{
+		{"one condition does not hold", "v7.5.0", map[string]interface{}{"temp-dir": "/data/tidb-tmp"}, tidbVariables},
+		{"quota below the threshold", "v7.5.0", map[string]interface{}{"temp-dir": "/tmp/tidb"},
+			map[string]interface{}{"tidb_ddl_enable_fast_reorg": "ON", "tidb_ddl_disk_quota": "10GiB"}},
+		{"missing parameter", "v7.5.0", map[string]interface{}{"temp-dir": "/tmp/tidb"},
+			map[string]interface{}{"tidb_ddl_enable_fast_reorg": "ON"}},
+		{"target version out of range", "v6.1.7", map[string]interface{}{"temp-dir": "/tmp/tidb"}, tidbVariables},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			results, err := rule.Evaluate(context.Background(), newDangerousCombinationContext(tt.targetVersion, tt.tidbConfig, tt.tidbVariables))
+			require.NoError(t, err)
+			assert.Empty(t, withoutStatistics(results))
+		})
+	}
+}
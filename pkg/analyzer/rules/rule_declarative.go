@@ -0,0 +1,509 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Conditions of a declarative rule (see RuleDefinition)
+const (
+	// ConditionChanged matches a parameter whose default differs between the source and target versions
+	// If the definition has a value, only when the target default equals it
+	ConditionChanged = "changed"
+	// ConditionEquals matches a runtime value equal to the value of the definition
+	ConditionEquals = "equals"
+	// ConditionNotEquals matches a runtime value different from the value of the definition
+	ConditionNotEquals = "not_equals"
+	// ConditionGreaterThan matches a numeric runtime value greater than the value of the definition
+	ConditionGreaterThan = "greater_than"
+	// ConditionLessThan matches a numeric runtime value less than the value of the definition
+	ConditionLessThan = "less_than"
+)
+
+// DefaultDeclarativeCategory is the category of a declarative rule whose definition has none
+const DefaultDeclarativeCategory = "high_risk"
+
+// builtinRules holds the declarative rules shipped with precheck, see BuiltinDeclarativeRules
+//
+//go:embed builtin/*.json
+var builtinRules embed.FS
+
+// RuleDefinition is a declarative rule: a parameter comparison described in JSON instead of Go code
+// Example:
+//
+//	{"rule_id": "PD_MAX_REPLICAS", "severity": "warning", "component": "pd",
+//	 "param_name": "replication.max-replicas", "condition": "less_than", "value": 3,
+//	 "message_template": "{param} is {current} on {instances} instance(s)"}
+//
+// param_name may contain wildcards ("*", "?", "[...]", see path.Match), e.g. "rocksdb.*.format-version"
+// System variables are named with the "sysvar:" prefix, e.g. "sysvar:tidb_analyze_version"
+// message_template placeholders: {param}, {component}, {value}, {current}, {source_default},
+// {target_default}, {instances} (number of matching instances) and {total} (number of instances)
+type RuleDefinition struct {
+	RuleID          string      `json:"rule_id"`
+	Description     string      `json:"description,omitempty"`
+	Category        string      `json:"category,omitempty"`
+	Severity        string      `json:"severity,omitempty"`
+	Component       string      `json:"component"`
+	ParamName       string      `json:"param_name"`
+	Condition       string      `json:"condition"`
+	Value           interface{} `json:"value,omitempty"`
+	MessageTemplate string      `json:"message_template"`
+	Suggestions     []string    `json:"suggestions,omitempty"`
+}
+
+// validate checks the definition and fills the optional fields with their defaults
+func (d *RuleDefinition) validate() error {
+	if d.RuleID == "" {
+		return fmt.Errorf("rule_id is required")
+	}
+	switch d.Component {
+	case "tidb", "pd", "tikv", "tiflash":
+	default:
+		return fmt.Errorf("rule %s: invalid component %q (tidb, pd, tikv or tiflash)", d.RuleID, d.Component)
+	}
+	if d.ParamName == "" {
+		return fmt.Errorf("rule %s: param_name is required", d.RuleID)
+	}
+	if _, err := path.Match(d.paramPattern(), ""); err != nil {
+		return fmt.Errorf("rule %s: invalid param_name %q: %w", d.RuleID, d.ParamName, err)
+	}
+	switch d.Condition {
+	case ConditionChanged:
+	case ConditionEquals, ConditionNotEquals:
+		if d.Value == nil {
+			return fmt.Errorf("rule %s: condition %s requires a value", d.RuleID, d.Condition)
+		}
+	case ConditionGreaterThan, ConditionLessThan:
+		if _, ok := ToNumeric(d.Value); !ok {
+			return fmt.Errorf("rule %s: condition %s requires a numeric value", d.RuleID, d.Condition)
+		}
+	default:
+		return fmt.Errorf("rule %s: invalid condition %q (%s, %s, %s, %s or %s)", d.RuleID, d.Condition,
+			ConditionChanged, ConditionEquals, ConditionNotEquals, ConditionGreaterThan, ConditionLessThan)
+	}
+	if d.MessageTemplate == "" {
+		return fmt.Errorf("rule %s: message_template is required", d.RuleID)
+	}
+	if d.Severity == "" {
+		d.Severity = "warning"
+	}
+	switch d.Severity {
+	case "info", "warning", "error", "critical":
+	default:
+		return fmt.Errorf("rule %s: invalid severity %q (info, warning, error or critical)", d.RuleID, d.Severity)
+	}
+	if d.Category == "" {
+		d.Category = DefaultDeclarativeCategory
+	}
+	if d.Description == "" {
+		d.Description = fmt.Sprintf("Check that %s %s %s", d.Component, d.ParamName, d.describeCondition())
+	}
+	return nil
+}
+
+// isSystemVariable reports whether the definition targets system variables ("sysvar:" prefix)
+func (d *RuleDefinition) isSystemVariable() bool {
+	return strings.HasPrefix(d.ParamName, "sysvar:")
+}
+
+// paramPattern returns param_name without the "sysvar:" prefix
+func (d *RuleDefinition) paramPattern() string {
+	return strings.TrimPrefix(d.ParamName, "sysvar:")
+}
+
+// describeCondition describes the condition for the default description of the rule
+func (d *RuleDefinition) describeCondition() string {
+	if d.Condition == ConditionChanged {
+		return "default changes"
+	}
+	return fmt.Sprintf("is %s %s", strings.ReplaceAll(d.Condition, "_", " "), FormatValue(d.Value))
+}
+
+// DeclarativeRule is a rule built from a RuleDefinition by NewRuleFromJSON
+// Rule: For each parameter matching param_name, check the condition on every instance of the component
+// (or, for "changed", on the source and target defaults) and report one finding per matching parameter
+type DeclarativeRule struct {
+	*BaseRule
+	def RuleDefinition
+}
+
+// NewRuleFromJSON creates a rule from a JSON RuleDefinition, rejecting unknown fields
+// so that misspelled keys are not silently ignored
+func NewRuleFromJSON(def []byte) (Rule, error) {
+	decoder := json.NewDecoder(bytes.NewReader(def))
+	decoder.DisallowUnknownFields()
+	var definition RuleDefinition
+	if err := decoder.Decode(&definition); err != nil {
+		return nil, fmt.Errorf("invalid rule definition: %w", err)
+	}
+	if err := definition.validate(); err != nil {
+		return nil, fmt.Errorf("invalid rule definition: %w", err)
+	}
+	return &DeclarativeRule{
+		BaseRule: NewBaseRule(definition.RuleID, definition.Description, definition.Category),
+		def:      definition,
+	}, nil
+}
+
+// LoadRuleFile reads the declarative rules of a file given with --rule-file:
+// a JSON rule definition, or a JSON array of rule definitions
+func LoadRuleFile(filePath string) ([]Rule, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+	rules, err := parseRuleDefinitions(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filePath, err)
+	}
+	return rules, nil
+}
+
+// parseRuleDefinitions parses a rule definition or an array of rule definitions
+func parseRuleDefinitions(data []byte) ([]Rule, error) {
+	trimmed := bytes.TrimSpace(data)
+	if !bytes.HasPrefix(trimmed, []byte("[")) {
+		rule, err := NewRuleFromJSON(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return []Rule{rule}, nil
+	}
+	var definitions []json.RawMessage
+	if err := json.Unmarshal(trimmed, &definitions); err != nil {
+		return nil, fmt.Errorf("invalid rule definitions: %w", err)
+	}
+	rules := make([]Rule, 0, len(definitions))
+	for _, definition := range definitions {
+		rule, err := NewRuleFromJSON(definition)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// BuiltinDeclarativeRules returns the declarative rules embedded from builtin/*.json, sorted by file name
+func BuiltinDeclarativeRules() ([]Rule, error) {
+	entries, err := builtinRules.ReadDir("builtin")
+	if err != nil {
+		return nil, err
+	}
+	var rules []Rule
+	for _, entry := range entries {
+		data, err := builtinRules.ReadFile("builtin/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		fileRules, err := parseRuleDefinitions(data)
+		if err != nil {
+			return nil, fmt.Errorf("builtin/%s: %w", entry.Name(), err)
+		}
+		rules = append(rules, fileRules...)
+	}
+	return rules, nil
+}
+
+// Definition returns the definition the rule was built from
+func (r *DeclarativeRule) Definition() RuleDefinition {
+	return r.def
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *DeclarativeRule) DataRequirements() DataSourceRequirement {
+	components := []string{r.def.Component}
+	sysvar := r.def.isSystemVariable()
+	changed := r.def.Condition == ConditionChanged
+
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:          components,
+			NeedConfig:          !sysvar,
+			NeedSystemVariables: sysvar,
+			NeedAllTikvNodes:    r.def.Component == "tikv", // The condition is checked on every node
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          components,
+			NeedConfigDefaults:  !sysvar,
+			NeedSystemVariables: sysvar,
+			NeedUpgradeLogic:    false,
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          components,
+			NeedConfigDefaults:  changed && !sysvar,
+			NeedSystemVariables: changed && sysvar,
+			NeedUpgradeLogic:    false,
+		},
+	}
+}
+
+// declarativeMatch is a parameter matching the condition of a declarative rule
+type declarativeMatch struct {
+	name      string
+	current   interface{}
+	instances []string
+}
+
+// Evaluate performs the rule check
+func (r *DeclarativeRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil {
+		return results, nil
+	}
+
+	nodes := ruleCtx.SourceClusterSnapshot.ComponentsByType(defaultsTypes.ComponentType(r.def.Component))
+	nodeParams := make([]map[string]interface{}, 0, len(nodes))
+	addresses := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		params := node.Config
+		if r.def.isSystemVariable() {
+			params = node.Variables
+		}
+		nodeParams = append(nodeParams, flattenParameterMap(params))
+		address := r.def.Component
+		if addr, ok := node.Status["address"].(string); ok && addr != "" {
+			address = addr
+		}
+		addresses = append(addresses, address)
+	}
+
+	var matches []declarativeMatch
+	if r.def.Condition == ConditionChanged {
+		matches = r.changedParams(ruleCtx, nodeParams)
+	} else {
+		matches = r.matchingParams(nodeParams, addresses)
+	}
+
+	for _, match := range matches {
+		results = append(results, r.newResult(ruleCtx, match, len(nodes)))
+	}
+	results = append(results, NewStatisticsResult(r, RuleStatistics{ParametersExamined: r.countExamined(ruleCtx, nodeParams)}))
+	return results, nil
+}
+
+// matchingParams returns the runtime parameters matching param_name whose value satisfies the condition
+// on at least one instance, sorted by name
+func (r *DeclarativeRule) matchingParams(nodeParams []map[string]interface{}, addresses []string) []declarativeMatch {
+	byName := make(map[string]*declarativeMatch)
+	for i, params := range nodeParams {
+		for name, value := range params {
+			if !r.matchesName(name) || !r.matchesValue(value) {
+				continue
+			}
+			match, ok := byName[name]
+			if !ok {
+				match = &declarativeMatch{name: name, current: value}
+				byName[name] = match
+			}
+			match.instances = append(match.instances, addresses[i])
+		}
+	}
+
+	matches := make([]declarativeMatch, 0, len(byName))
+	for _, match := range byName {
+		sort.Strings(match.instances)
+		matches = append(matches, *match)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].name < matches[j].name })
+	return matches
+}
+
+// changedParams returns the knowledge base parameters matching param_name whose default changes
+// between the source and target versions, sorted by name
+func (r *DeclarativeRule) changedParams(ruleCtx *RuleContext, nodeParams []map[string]interface{}) []declarativeMatch {
+	names := make(map[string]bool)
+	for _, defaults := range []map[string]interface{}{ruleCtx.SourceDefaults[r.def.Component], ruleCtx.TargetDefaults[r.def.Component]} {
+		for key := range defaults {
+			name, ok := r.kbParamName(key)
+			if ok && r.matchesName(name) {
+				names[name] = true
+			}
+		}
+	}
+
+	var matches []declarativeMatch
+	for name := range names {
+		sourceDefault, targetDefault := r.defaults(ruleCtx, name)
+		if CompareValues(sourceDefault, targetDefault) {
+			continue
+		}
+		if r.def.Value != nil && !CompareValues(targetDefault, r.def.Value) {
+			continue
+		}
+		match := declarativeMatch{name: name}
+		for _, params := range nodeParams {
+			if value, ok := params[name]; ok {
+				match.current = value
+				break
+			}
+		}
+		matches = append(matches, match)
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].name < matches[j].name })
+	return matches
+}
+
+// countExamined returns the number of distinct parameters matching param_name
+func (r *DeclarativeRule) countExamined(ruleCtx *RuleContext, nodeParams []map[string]interface{}) int {
+	names := make(map[string]bool)
+	for _, params := range nodeParams {
+		for name := range params {
+			if r.matchesName(name) {
+				names[name] = true
+			}
+		}
+	}
+	if r.def.Condition == ConditionChanged {
+		for key := range ruleCtx.TargetDefaults[r.def.Component] {
+			if name, ok := r.kbParamName(key); ok && r.matchesName(name) {
+				names[name] = true
+			}
+		}
+	}
+	return len(names)
+}
+
+// kbParamName returns the parameter name of a knowledge base key if it is of the type of the rule
+// (system variables are stored with the "sysvar:" prefix)
+func (r *DeclarativeRule) kbParamName(key string) (string, bool) {
+	name, isSysvar := strings.CutPrefix(key, "sysvar:")
+	return name, isSysvar == r.def.isSystemVariable()
+}
+
+// defaults returns the source and target defaults of a parameter
+func (r *DeclarativeRule) defaults(ruleCtx *RuleContext, name string) (interface{}, interface{}) {
+	lookupName := name
+	if r.def.isSystemVariable() {
+		lookupName = "sysvar:" + name
+	}
+	return ruleCtx.GetSourceDefault(r.def.Component, lookupName), ruleCtx.GetTargetDefault(r.def.Component, lookupName)
+}
+
+// matchesName checks if a parameter name matches param_name
+func (r *DeclarativeRule) matchesName(name string) bool {
+	pattern := r.def.paramPattern()
+	if pattern == name {
+		return true
+	}
+	matched, _ := path.Match(pattern, name)
+	return matched
+}
+
+// matchesValue checks if a runtime value satisfies the condition
+func (r *DeclarativeRule) matchesValue(value interface{}) bool {
+	switch r.def.Condition {
+	case ConditionEquals:
+		return CompareValues(value, r.def.Value)
+	case ConditionNotEquals:
+		return !CompareValues(value, r.def.Value)
+	case ConditionGreaterThan, ConditionLessThan:
+		current, ok := ToNumeric(value)
+		if !ok {
+			return false
+		}
+		threshold, _ := ToNumeric(r.def.Value)
+		if r.def.Condition == ConditionGreaterThan {
+			return current > threshold
+		}
+		return current < threshold
+	}
+	return false
+}
+
+// newResult creates the finding of a matching parameter
+func (r *DeclarativeRule) newResult(ruleCtx *RuleContext, match declarativeMatch, total int) CheckResult {
+	sourceDefault, targetDefault := r.defaults(ruleCtx, match.name)
+	paramType := "config"
+	if r.def.isSystemVariable() {
+		paramType = "system_variable"
+	}
+
+	replacer := strings.NewReplacer(
+		"{param}", match.name,
+		"{component}", r.def.Component,
+		"{value}", FormatValue(r.def.Value),
+		"{current}", FormatValue(match.current),
+		"{source_default}", FormatValue(sourceDefault),
+		"{target_default}", FormatValue(targetDefault),
+		"{instances}", fmt.Sprintf("%d", len(match.instances)),
+		"{total}", fmt.Sprintf("%d", total),
+	)
+	details := fmt.Sprintf("Condition: %s %s", match.name, r.def.describeCondition())
+	if len(match.instances) > 0 {
+		details = fmt.Sprintf("%s\nMatching instances: %s", details, strings.Join(match.instances, ", "))
+	}
+
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     r.def.Component,
+		ParameterName: match.name,
+		ParamType:     paramType,
+		Description:   r.Description(),
+		Severity:      r.def.Severity,
+		RiskLevel:     GetRiskLevel(r.def.Severity),
+		Message:       replacer.Replace(r.def.MessageTemplate),
+		Details:       details,
+		Suggestions:   r.def.Suggestions,
+		CurrentValue:  match.current,
+		SourceDefault: sourceDefault,
+		TargetDefault: targetDefault,
+		Metadata: map[string]interface{}{
+			"condition":      r.def.Condition,
+			"instance_count": len(match.instances),
+		},
+	}
+}
+
+// flattenParameterMap returns the values of params by dotted name, nested maps (e.g., the
+// "replication" section of the PD configuration) being flattened into "replication.max-replicas"
+func flattenParameterMap(params defaultsTypes.ParameterMap) map[string]interface{} {
+	flattened := make(map[string]interface{}, len(params))
+	for name, param := range params {
+		flattenValue(flattened, name, param.Value)
+	}
+	return flattened
+}
+
+// flattenValue adds value to flattened under name, or its entries under "name.key" if it is a map
+func flattenValue(flattened map[string]interface{}, name string, value interface{}) {
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		flattened[name] = value
+		return
+	}
+	for key, v := range nested {
+		flattenValue(flattened, name+"."+key, v)
+	}
+}
@@ -0,0 +1,168 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRuleFromJSON_Validation(t *testing.T) {
+	rule, err := NewRuleFromJSON([]byte(`{"rule_id": "GRPC", "component": "tikv", "param_name": "server.grpc-concurrency",
+		"condition": "greater_than", "value": 8, "message_template": "{param} is {current}"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "GRPC", rule.Name())
+	assert.Equal(t, DefaultDeclarativeCategory, rule.Category())
+	assert.Equal(t, "Check that tikv server.grpc-concurrency is greater than 8", rule.Description())
+	assert.Equal(t, "warning", rule.(*DeclarativeRule).Definition().Severity)
+
+	tests := []struct {
+		name, def, err string
+	}{
+		{"unknown field", `{"rule_id": "X", "component": "tidb", "param_name": "a", "condition": "changed", "message_template": "m", "severty": "info"}`, "unknown field"},
+		{"no rule id", `{"component": "tidb", "param_name": "a", "condition": "changed", "message_template": "m"}`, "rule_id is required"},
+		{"component", `{"rule_id": "X", "component": "tidb-server", "param_name": "a", "condition": "changed", "message_template": "m"}`, "invalid component"},
+		{"pattern", `{"rule_id": "X", "component": "tidb", "param_name": "a[", "condition": "changed", "message_template": "m"}`, "invalid param_name"},
+		{"condition", `{"rule_id": "X", "component": "tidb", "param_name": "a", "condition": "matches", "message_template": "m"}`, "invalid condition"},
+		{"no value", `{"rule_id": "X", "component": "tidb", "param_name": "a", "condition": "equals", "message_template": "m"}`, "requires a value"},
+		{"non-numeric value", `{"rule_id": "X", "component": "tidb", "param_name": "a", "condition": "less_than", "value": "low", "message_template": "m"}`, "requires a numeric value"},
+		{"severity", `{"rule_id": "X", "component": "tidb", "param_name": "a", "condition": "changed", "message_template": "m", "severity": "fatal"}`, "invalid severity"},
+		{"no message", `{"rule_id": "X", "component": "tidb", "param_name": "a", "condition": "changed"}`, "message_template is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewRuleFromJSON([]byte(tt.def))
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func TestDeclarativeRule_Evaluate_Conditions(t *testing.T) {
+	snapshot := &collector.ClusterSnapshot{Components: map[string]collector.ComponentState{
+		"tikv-1": {
+			Type:   defaultsTypes.ComponentTiKV,
+			Config: defaultsTypes.ConvertConfigToDefaults(map[string]interface{}{"rocksdb.defaultcf.format-version": 2, "rocksdb.writecf.format-version": 5}),
+			Status: map[string]interface{}{"address": "tikv-1:20160"},
+		},
+		"tikv-2": {
+			Type:   defaultsTypes.ComponentTiKV,
+			Config: defaultsTypes.ConvertConfigToDefaults(map[string]interface{}{"rocksdb.defaultcf.format-version": 5, "rocksdb.writecf.format-version": 5}),
+			Status: map[string]interface{}{"address": "tikv-2:20160"},
+		},
+		"pd": {
+			Type: defaultsTypes.ComponentPD,
+			// The PD configuration is collected nested
+			Config: defaultsTypes.ConvertConfigToDefaults(map[string]interface{}{"replication": map[string]interface{}{"max-replicas": 1}}),
+		},
+	}}
+	ruleCtx := &RuleContext{SourceClusterSnapshot: snapshot}
+
+	evaluate := func(def string) []CheckResult {
+		rule, err := NewRuleFromJSON([]byte(def))
+		require.NoError(t, err)
+		results, err := NewRuleRunner([]Rule{rule}).Run(context.Background(), ruleCtx)
+		require.NoError(t, err)
+		return results
+	}
+
+	results := evaluate(`{"rule_id": "FORMAT", "component": "tikv", "param_name": "rocksdb.*.format-version", "condition": "less_than",
+		"value": 5, "severity": "error", "message_template": "{param} is {current} on {instances} of {total} TiKV instances"}`)
+	require.Len(t, results, 1)
+	assert.Equal(t, "rocksdb.defaultcf.format-version", results[0].ParameterName)
+	assert.Equal(t, "rocksdb.defaultcf.format-version is 2 on 1 of 2 TiKV instances", results[0].Message)
+	assert.Equal(t, "error", results[0].Severity)
+	assert.Equal(t, RiskLevelHigh, results[0].RiskLevel)
+	assert.Contains(t, results[0].Details, "Matching instances: tikv-1:20160")
+
+	results = evaluate(`{"rule_id": "FORMAT", "component": "tikv", "param_name": "rocksdb.*.format-version", "condition": "not_equals",
+		"value": 2, "message_template": "{param}"}`)
+	require.Len(t, results, 2)
+	assert.Equal(t, "rocksdb.defaultcf.format-version", results[0].ParameterName)
+	assert.Equal(t, "rocksdb.writecf.format-version", results[1].ParameterName)
+
+	results = evaluate(`{"rule_id": "REPLICAS", "component": "pd", "param_name": "replication.max-replicas", "condition": "equals",
+		"value": "1", "message_template": "{component} {param} is {current}"}`)
+	require.Len(t, results, 1)
+	assert.Equal(t, "pd replication.max-replicas is 1", results[0].Message)
+
+	assert.Empty(t, evaluate(`{"rule_id": "REPLICAS", "component": "pd", "param_name": "replication.max-replicas", "condition": "greater_than",
+		"value": 3, "message_template": "m"}`))
+}
+
+func TestDeclarativeRule_Evaluate_Changed(t *testing.T) {
+	snapshot := &collector.ClusterSnapshot{Components: map[string]collector.ComponentState{
+		"tidb": {
+			Type:      defaultsTypes.ComponentTiDB,
+			Variables: defaultsTypes.ConvertVariablesToSystemVariables(map[string]string{"tidb_enable_fast_analyze": "OFF"}),
+		},
+	}}
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: snapshot,
+		SourceDefaults: map[string]map[string]interface{}{"tidb": {
+			"sysvar:tidb_enable_fast_analyze": map[string]interface{}{"value": "OFF"},
+			"sysvar:tidb_enable_paging":       map[string]interface{}{"value": "OFF"},
+			"sysvar:tidb_mem_quota_query":     map[string]interface{}{"value": 1073741824},
+			"tidb_enable_config":              map[string]interface{}{"value": false},
+		}},
+		TargetDefaults: map[string]map[string]interface{}{"tidb": {
+			"sysvar:tidb_enable_fast_analyze": map[string]interface{}{"value": "ON"},
+			"sysvar:tidb_enable_paging":       map[string]interface{}{"value": "ON"},
+			"sysvar:tidb_mem_quota_query":     map[string]interface{}{"value": 1073741824},
+			"tidb_enable_config":              map[string]interface{}{"value": true},
+		}},
+	}
+
+	rule, err := NewRuleFromJSON([]byte(`{"rule_id": "ENABLE", "component": "tidb", "param_name": "sysvar:tidb_enable_*",
+		"condition": "changed", "message_template": "{param}: {source_default} -> {target_default} (current {current})"}`))
+	require.NoError(t, err)
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+	assert.Equal(t, `tidb_enable_fast_analyze: "OFF" -> "ON" (current "OFF")`, results[0].Message)
+	assert.Equal(t, "system_variable", results[0].ParamType)
+	assert.Equal(t, "tidb_enable_paging", results[1].ParameterName)
+	assert.Equal(t, 2, results[2].Statistics.ParametersExamined)
+
+	// With a value, only the parameters whose target default equals it
+	rule, err = NewRuleFromJSON([]byte(`{"rule_id": "ENABLE", "component": "tidb", "param_name": "tidb_enable_*",
+		"condition": "changed", "value": true, "message_template": "{param}"}`))
+	require.NoError(t, err)
+	results, err = rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, "tidb_enable_config", results[0].ParameterName)
+	assert.Equal(t, "config", results[0].ParamType)
+}
+
+func TestLoadRuleFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "rules.json")
+	require.NoError(t, os.WriteFile(file, []byte(`[
+		{"rule_id": "A", "component": "tidb", "param_name": "a", "condition": "changed", "message_template": "m"},
+		{"rule_id": "B", "component": "pd", "param_name": "b", "condition": "equals", "value": 1, "message_template": "m"}
+	]`), 0644))
+	loaded, err := LoadRuleFile(file)
+	require.NoError(t, err)
+	require.Len(t, loaded, 2)
+	assert.Equal(t, "B", loaded[1].Name())
+
+	require.NoError(t, os.WriteFile(file, []byte(`[{"rule_id": "A", "component": "tidb"}]`), 0644))
+	_, err = LoadRuleFile(file)
+	assert.ErrorContains(t, err, "rules.json")
+}
+
+func TestBuiltinDeclarativeRules(t *testing.T) {
+	builtin, err := BuiltinDeclarativeRules()
+	require.NoError(t, err)
+	require.NotEmpty(t, builtin)
+	names := make(map[string]bool)
+	for _, rule := range builtin {
+		assert.False(t, names[rule.Name()], "duplicate built-in rule %s", rule.Name())
+		names[rule.Name()] = true
+	}
+}
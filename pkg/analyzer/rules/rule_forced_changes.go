@@ -0,0 +1,295 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// ForcedChangesRule detects parameters that will be forcibly changed during upgrade
+// The changes come from upgrade_logic.json and are filtered by bootstrap version range
+// (sourceBootstrapVersion, targetBootstrapVersion] (see BootstrapVersionInRange)
+// Parameters with a forced change are not reported by UPGRADE_DIFFERENCES
+type ForcedChangesRule struct {
+	*BaseRule
+}
+
+// defaultForcedChangeSuggestions are used when the upgrade logic entry has no custom suggestions
+var defaultForcedChangeSuggestions = []string{
+	"This parameter will be forcibly changed during upgrade",
+	"Review the forced change and its impact",
+	"Test the new value in a staging environment",
+	"Plan for the change before upgrading",
+}
+
+// NewForcedChangesRule creates a new forced changes rule
+func NewForcedChangesRule() Rule {
+	return &ForcedChangesRule{
+		BaseRule: NewBaseRule(
+			"FORCED_CHANGES",
+			"Detect parameters that will be forcibly changed by the upgrade logic of the target version",
+			"upgrade_difference",
+		),
+	}
+}
+
+// upgradeScoped marks the rule as upgrade-scoped: a forced change is applied by the upgrade whether or not
+// the parameter changed since a previous snapshot, so its findings are never restricted by --changed-since
+func (r *ForcedChangesRule) upgradeScoped() {}
+
+// DataRequirements returns the data requirements for this rule
+func (r *ForcedChangesRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:          []string{"tidb", "pd", "tikv", "tiflash"},
+			NeedConfig:          true,
+			NeedSystemVariables: true,
+			NeedAllTikvNodes:    false,
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          []string{"tidb", "pd", "tikv", "tiflash"},
+			NeedConfigDefaults:  true,
+			NeedSystemVariables: true,
+			NeedUpgradeLogic:    true,
+		},
+	}
+}
+
+// resolveForcedValue returns the value a parameter will be forced to, and whether it has a forced change
+// The entry whose from_value matches the current value wins; entries without from_value are the fallback
+func resolveForcedValue(ruleCtx *RuleContext, forcedChanges map[string]interface{}, component, paramName string, currentValue interface{}) (interface{}, bool) {
+	fallbackForcedValue, hasForcedChange := forcedChanges[paramName]
+	if !hasForcedChange {
+		return nil, false
+	}
+
+	// Get the forced value that matches the current value (using from_value matching)
+	forcedValue := ruleCtx.GetForcedChangeForValue(component, paramName, currentValue)
+
+	// If no matching from_value found, use fallback value (for entries without from_value)
+	if forcedValue == nil {
+		forcedValue = fallbackForcedValue
+	}
+	return forcedValue, forcedValue != nil
+}
+
+// Evaluate performs the rule check
+// Logic, for each parameter of the target version that has a forced change in range and exists in the cluster:
+//   - If forced value != current value (or the change deletes the variable): error for TiDB, warning otherwise,
+//     unless the upgrade logic entry overrides the severity
+//   - If forced value == current value: info (default value changed)
+//
+// Deployment-specific parameters (knowledge/deployment_specific.json) are skipped
+func (r *ForcedChangesRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+
+	if ruleCtx.SourceClusterSnapshot == nil {
+		return results, nil
+	}
+
+	// Check one instance per component type: forced changes apply to the whole component, so every node
+	// would report the same findings
+	for _, compType := range []string{"tidb", "pd", "tikv", "tiflash"} {
+		component, ok := ruleCtx.SourceClusterSnapshot.ComponentByType(defaultsTypes.ComponentType(compType))
+		if !ok {
+			continue
+		}
+
+		forcedChanges := ruleCtx.GetForcedChanges(compType)
+		if len(forcedChanges) == 0 {
+			continue
+		}
+
+		// Missing target defaults are reported by UPGRADE_DIFFERENCES
+		targetDefaults := ruleCtx.TargetDefaults[compType]
+
+		for paramName, targetDefaultValue := range targetDefaults {
+			if ruleCtx.DeploymentSpecificParams.Contains(compType, paramName) {
+				continue
+			}
+
+			targetDefault := extractValueFromDefault(targetDefaultValue)
+			if targetDefault == nil {
+				continue
+			}
+
+			// Get current cluster value, parameters missing from the cluster are new parameters
+			var currentValue interface{}
+			var paramType string
+			var displayName string
+			if strings.HasPrefix(paramName, "sysvar:") {
+				displayName = strings.TrimPrefix(paramName, "sysvar:")
+				paramType = "system_variable"
+				varValue, ok := component.Variables[displayName]
+				if !ok || varValue.Value == nil {
+					continue
+				}
+				currentValue = varValue.Value
+			} else {
+				displayName = paramName
+				paramType = "config"
+				paramValue, ok := component.Config[paramName]
+				if !ok || paramValue.Value == nil {
+					continue
+				}
+				currentValue = paramValue.Value
+			}
+
+			forcedValue, ok := resolveForcedValue(ruleCtx, forcedChanges, compType, displayName, currentValue)
+			if !ok {
+				continue
+			}
+			results = append(results, r.forcedChangeResult(ruleCtx, compType, displayName, paramType, currentValue, targetDefault, forcedValue))
+		}
+	}
+
+	return results, nil
+}
+
+// forcedChangeResult builds the result of a parameter with a forced change
+func (r *ForcedChangesRule) forcedChangeResult(ruleCtx *RuleContext, compType, displayName, paramType string, currentValue, targetDefault, forcedValue interface{}) CheckResult {
+	// Get special handling metadata from knowledge base
+	metadata := ruleCtx.GetForcedChangeMetadata(compType, displayName, currentValue)
+	removed := metadata != nil && metadata.Removed
+	resultMetadata := forcedChangeResultMetadata(ruleCtx, compType, displayName, currentValue)
+
+	// Canonicalize boolean-like values before comparing and reporting them:
+	// upgrade logic stores "1"/"0" while the runtime reports "ON"/"OFF"
+	normalized := NormalizeBoolLikeValues(forcedValue, currentValue, targetDefault)
+	forcedValue, currentValue, targetDefault = normalized[0], normalized[1], normalized[2]
+
+	// Forced changes that delete the variable never match the current value
+	// Use proper value comparison to avoid scientific notation issues
+	if !removed && CompareValues(forcedValue, currentValue) {
+		// Forced value equals current value: info (default value changed)
+		details := fmt.Sprintf("Current value matches forced value.\n\nCurrent: %s\nTarget Default: %s", FormatValue(currentValue), FormatValue(targetDefault))
+		details += describeForcedChangeRelease(resultMetadata)
+		return CheckResult{
+			RuleID:        r.Name(),
+			Category:      r.Category(),
+			Component:     compType,
+			ParameterName: displayName,
+			ParamType:     paramType,
+			Severity:      "info",
+			RiskLevel:     RiskLevelLow,
+			Message:       fmt.Sprintf("Parameter %s in %s: default value changed (forced change matches current value)", displayName, compType),
+			Details:       details,
+			CurrentValue:  currentValue,
+			TargetDefault: targetDefault,
+			ForcedValue:   forcedValue,
+			Suggestions: []string{
+				"Default value has changed in target version",
+				"Your current value matches the forced value, so no change will occur",
+			},
+			Metadata: resultMetadata,
+		}
+	}
+
+	// Determine severity: use metadata override if available, otherwise use default logic
+	severity := "warning"
+	riskLevel := RiskLevelMedium
+	if metadata != nil && metadata.ReportSeverity != "" {
+		// Use severity from knowledge base
+		severity = metadata.ReportSeverity
+		switch severity {
+		case "error":
+			riskLevel = RiskLevelHigh
+		case "info":
+			riskLevel = RiskLevelLow
+		default:
+			riskLevel = RiskLevelMedium
+		}
+	} else if compType == "tidb" {
+		// Default: Most TiDB forced changes are error
+		severity = "error"
+		riskLevel = RiskLevelHigh
+	}
+
+	// Build details for forced change
+	forcedStr := FormatValue(forcedValue)
+	if removed {
+		forcedStr = ForcedRemovalDisplay
+	}
+	details := fmt.Sprintf("Will be forced to: %s\n\nCurrent: %s\nTarget Default: %s", forcedStr, FormatValue(currentValue), FormatValue(targetDefault))
+	details += describeForcedChangeRelease(resultMetadata)
+
+	// Add details note from knowledge base if available
+	if metadata != nil && metadata.DetailsNote != "" {
+		details += "\n\n" + metadata.DetailsNote
+	}
+	// Explain why the upgrade makes the change, and when
+	if metadata != nil && metadata.FunctionDocstring != "" {
+		details += "\n\nWhy: " + metadata.FunctionDocstring
+	}
+	if metadata != nil && metadata.Condition != "" {
+		details += "\nOnly applied if: " + metadata.Condition
+	}
+
+	// Get suggestions: use metadata if available, otherwise use default
+	suggestions := defaultForcedChangeSuggestions
+	if metadata != nil && len(metadata.Suggestions) > 0 {
+		suggestions = metadata.Suggestions
+	}
+
+	resultMetadata["forced_removal"] = removed
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     compType,
+		ParameterName: displayName,
+		ParamType:     paramType,
+		Severity:      severity,
+		RiskLevel:     riskLevel,
+		Message:       fmt.Sprintf("Parameter %s in %s will be forcibly changed during upgrade (forced value differs from current)", displayName, compType),
+		Details:       details,
+		CurrentValue:  currentValue,
+		TargetDefault: targetDefault,
+		ForcedValue:   forcedValue,
+		Suggestions:   suggestions,
+		Metadata:      resultMetadata,
+	}
+}
+
+// forcedChangeResultMetadata returns the metadata of a forced change result: the bootstrap version and
+// the name of the upgrade function making the change, if the change is found in the upgrade logic,
+// and the TiDB release that introduced it, if the upgrade history knows it
+func forcedChangeResultMetadata(ruleCtx *RuleContext, compType, displayName string, currentValue interface{}) map[string]interface{} {
+	metadata := make(map[string]interface{})
+	if change, ok := ruleCtx.GetForcedChange(compType, displayName, currentValue); ok {
+		metadata["bootstrap_version"] = change.BootstrapVersion
+		metadata["function_name"] = change.FunctionName()
+		if compType == "tidb" {
+			if release := ruleCtx.UpgradeHistory.ReleaseVersion(change.BootstrapVersion); release != "" {
+				metadata["release_version"] = release
+			}
+		}
+	}
+	return metadata
+}
+
+// describeForcedChangeRelease describes the release that introduced a forced change (e.g., "Introduced in: v8.1.0 (upgradeToVer198)"),
+// an empty string if the result metadata has no release version
+func describeForcedChangeRelease(resultMetadata map[string]interface{}) string {
+	release, ok := resultMetadata["release_version"].(string)
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf("\nIntroduced in: %s (%s)", release, resultMetadata["function_name"])
+}
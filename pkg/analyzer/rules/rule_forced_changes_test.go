@@ -0,0 +1,674 @@
+package rules
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewForcedChangesRule(t *testing.T) {
+	rule := NewForcedChangesRule()
+	assert.Equal(t, "FORCED_CHANGES", rule.Name())
+	assert.Equal(t, "upgrade_difference", rule.Category())
+	assert.True(t, rule.DataRequirements().TargetKBRequirements.NeedUpgradeLogic)
+}
+
+func TestBootstrapVersionInRange(t *testing.T) {
+	const source, target = 140, 160
+	tests := []struct {
+		name    string
+		version int64
+		want    bool
+	}{
+		{"before source", 139, false},
+		{"equal to source (exclusive)", 140, false},
+		{"just after source", 141, true},
+		{"inside range", 150, true},
+		{"equal to target (inclusive)", 160, true},
+		{"just after target", 161, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, BootstrapVersionInRange(tt.version, source, target))
+		})
+	}
+
+	// Empty range: upgrading between versions with the same bootstrap version runs nothing
+	assert.False(t, BootstrapVersionInRange(140, 140, 140))
+}
+
+// syntheticUpgradeLogic builds upgrade logic with one change per bootstrap version, named param<version>
+func syntheticUpgradeLogic(versions ...string) []types.UpgradeParamChange {
+	changes := make([]types.UpgradeParamChange, 0, len(versions))
+	for _, version := range versions {
+		changes = append(changes, types.UpgradeParamChange{Version: version, Name: "param" + version, Value: "forced"})
+	}
+	return changes
+}
+
+// withUpgradeLogic sets the upgrade logic of ruleCtx, filtered by its bootstrap versions, and returns ruleCtx
+func withUpgradeLogic(ruleCtx *RuleContext, logic map[string][]types.UpgradeParamChange) *RuleContext {
+	ruleCtx.SetUpgradeLogic(logic)
+	return ruleCtx
+}
+
+func TestNewUpgradeLogicChanges(t *testing.T) {
+	changes := NewUpgradeLogicChanges([]types.UpgradeParamChange{
+		{Version: "68", Name: "a", Value: 1, FromValue: 0},
+		{Version: "71", VarName: "b", Value: "ON"},
+		{Version: "72", Name: "c"},
+		{Version: "not-a-number", Name: "d", Value: 1},
+		{Version: "73", Value: 1},
+		{Name: "e", Value: 1},
+	})
+	if assert.Len(t, changes, 3) {
+		assert.Equal(t, int64(68), changes[0].BootstrapVersion)
+		assert.Equal(t, "a", changes[0].Name)
+		assert.Equal(t, 1, changes[0].Value)
+		assert.True(t, changes[0].HasFromValue())
+		assert.Equal(t, 0, changes[0].FromValue)
+
+		assert.Equal(t, int64(71), changes[1].BootstrapVersion)
+		assert.Equal(t, "b", changes[1].Name)
+		assert.Equal(t, "ON", changes[1].Value)
+		assert.False(t, changes[1].HasFromValue())
+
+		assert.Equal(t, "c", changes[2].Name)
+		assert.False(t, changes[2].HasValue())
+	}
+
+	assert.Empty(t, NewUpgradeLogicChanges(nil))
+}
+
+func TestUpgradeLogicChange_FunctionName(t *testing.T) {
+	changes := NewUpgradeLogicChanges([]types.UpgradeParamChange{
+		{Version: "177", FuncName: "upgradeToVer177", VarName: "tidb_enable_async_merge_global_stats", Value: "OFF"},
+		{Version: "68", Name: "tidb_enable_clustered_index", Value: "OFF"},
+	})
+	require.Len(t, changes, 2)
+	assert.Equal(t, "upgradeToVer177", changes[0].FunctionName())
+	assert.Equal(t, "upgradeToVer68", changes[1].FunctionName())
+}
+
+func TestFilterChangesByBootstrapRange(t *testing.T) {
+	changes := NewUpgradeLogicChanges(syntheticUpgradeLogic("139", "140", "141", "150", "160", "161"))
+
+	var names []string
+	for _, change := range FilterChangesByBootstrapRange(changes, 140, 160) {
+		names = append(names, change.Name)
+	}
+	assert.Equal(t, []string{"param141", "param150", "param160"}, names)
+
+	assert.Empty(t, FilterChangesByBootstrapRange(changes, 160, 160))
+	assert.Len(t, FilterChangesByBootstrapRange(changes, 0, 200), 6)
+}
+
+func TestSetUpgradeLogic_HalfOpenWindow(t *testing.T) {
+	ruleCtx := &RuleContext{SourceVersion: "v7.5.0", TargetVersion: "v8.5.0", SourceBootstrapVersion: 140, TargetBootstrapVersion: 160}
+	ruleCtx.SetUpgradeLogic(map[string][]types.UpgradeParamChange{
+		"tidb": syntheticUpgradeLogic("139", "140", "141", "159", "160", "161"),
+	})
+
+	// Only the changes of (140, 160] are kept: the change at exactly the source bootstrap version already ran
+	var versions []int64
+	for _, change := range ruleCtx.GetUpgradeLogicChanges("tidb") {
+		versions = append(versions, change.BootstrapVersion)
+	}
+	assert.Equal(t, []int64{141, 159, 160}, versions)
+	assert.NotContains(t, ruleCtx.GetForcedChanges("tidb"), "param140")
+	assert.Empty(t, ruleCtx.GetForcedChangeForValue("tidb", "param140", "current"))
+	assert.Nil(t, ruleCtx.GetUpgradeLogicChanges("pd"))
+
+	// Upgrading between versions with the same bootstrap version runs nothing
+	ruleCtx = &RuleContext{SourceBootstrapVersion: 160, TargetBootstrapVersion: 160}
+	ruleCtx.SetUpgradeLogic(map[string][]types.UpgradeParamChange{"tidb": syntheticUpgradeLogic("159", "160", "161")})
+	assert.Empty(t, ruleCtx.GetUpgradeLogicChanges("tidb"))
+}
+
+func TestForcedChangesRule_Evaluate_BootstrapVersionBoundaries(t *testing.T) {
+	params := []string{"param139", "param140", "param141", "param160", "param161"}
+	config := types.ParameterMap{}
+	targetDefaults := map[string]interface{}{}
+	for _, param := range params {
+		config[param] = types.ParameterValue{Value: "current", Type: "string"}
+		targetDefaults[param] = "current"
+	}
+
+	ruleCtx := withUpgradeLogic(&RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {Type: types.ComponentTiDB, Config: config},
+			},
+		},
+		SourceVersion:          "v7.5.0",
+		TargetVersion:          "v8.5.0",
+		SourceBootstrapVersion: 140,
+		TargetBootstrapVersion: 160,
+		TargetDefaults:         map[string]map[string]interface{}{"tidb": targetDefaults},
+	}, map[string][]types.UpgradeParamChange{
+		"tidb": syntheticUpgradeLogic("139", "140", "141", "160", "161"),
+	})
+
+	results, err := NewForcedChangesRule().Evaluate(context.Background(), ruleCtx)
+	assert.NoError(t, err)
+
+	forced := make(map[string]bool)
+	for _, result := range results {
+		assert.Equal(t, "FORCED_CHANGES", result.RuleID)
+		assert.Equal(t, "forced", result.ForcedValue)
+		forced[result.ParameterName] = true
+	}
+	assert.False(t, forced["param139"], "change before the source bootstrap version must be excluded")
+	assert.False(t, forced["param140"], "change at the source bootstrap version already ran and must be excluded")
+	assert.True(t, forced["param141"], "first change after the source bootstrap version must be included")
+	assert.True(t, forced["param160"], "change at the target bootstrap version must be included")
+	assert.False(t, forced["param161"], "change after the target bootstrap version must be excluded")
+	assert.Len(t, results, 2)
+}
+
+func TestForcedChangesRule_Evaluate_ForcedConfigChange(t *testing.T) {
+	rule := NewForcedChangesRule()
+	ctx := context.Background()
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type: types.ComponentTiDB,
+					Config: types.ParameterMap{
+						"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
+					},
+				},
+			},
+		},
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		SourceDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"max-connections": 1000,
+			},
+		},
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"max-connections": 2000,
+			},
+		},
+	}
+
+	// Mock bootstrap version for source and target
+	ruleCtx.SourceBootstrapVersion = 140
+	ruleCtx.TargetBootstrapVersion = 160
+	ruleCtx.SetUpgradeLogic(map[string][]types.UpgradeParamChange{
+		"tidb": {
+			// Bootstrap version in range (140 < 150 <= 160)
+			{Version: "150", Name: "max-connections", Value: 3000, Method: "UPDATE", Severity: "medium"},
+		},
+	})
+
+	results, err := rule.Evaluate(ctx, ruleCtx)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, results)
+
+	// Should detect forced change
+	found := false
+	for _, result := range results {
+		if result.ParameterName == "max-connections" && result.ForcedValue != nil {
+			found = true
+			assert.Equal(t, "error", result.Severity) // TiDB forced changes are error
+			assert.Equal(t, 3000, result.ForcedValue)
+			assert.Equal(t, 1000, result.CurrentValue)
+			// The entry has no func_name, the function name is derived from the bootstrap version
+			assert.Equal(t, int64(150), result.Metadata["bootstrap_version"])
+			assert.Equal(t, "upgradeToVer150", result.Metadata["function_name"])
+			assert.Equal(t, false, result.Metadata["forced_removal"])
+			break
+		}
+	}
+	assert.True(t, found, "Should detect forced config change")
+}
+
+func TestForcedChangesRule_Evaluate_MultiNodeCluster(t *testing.T) {
+	rule := NewForcedChangesRule()
+
+	tidb := func(maxConnections int) collector.ComponentState {
+		return collector.ComponentState{
+			Type:   types.ComponentTiDB,
+			Config: types.ParameterMap{"max-connections": types.ParameterValue{Value: maxConnections, Type: "int"}},
+		}
+	}
+	tikv := collector.ComponentState{
+		Type:   types.ComponentTiKV,
+		Config: types.ParameterMap{"raftstore.store-pool-size": types.ParameterValue{Value: 2, Type: "int"}},
+	}
+	// The collector keys every node by address and aliases the first node of each type by the type name
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb":                tidb(1000),
+				"tidb-10-0-0-1-4000":  tidb(1000),
+				"tidb-10-0-0-2-4000":  tidb(1000),
+				"tikv":                tikv,
+				"tikv-10-0-0-1-20160": tikv,
+				"tikv-10-0-0-2-20160": tikv,
+				"tikv-10-0-0-3-20160": tikv,
+			},
+		},
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {"max-connections": 2000},
+			"tikv": {"raftstore.store-pool-size": 4},
+		},
+		SourceBootstrapVersion:     140,
+		TargetBootstrapVersion:     160,
+		ComponentBootstrapVersions: map[string]BootstrapVersionRange{"tikv": {Source: 1, Target: 2}},
+	}
+	ruleCtx.SetUpgradeLogic(map[string][]types.UpgradeParamChange{
+		"tidb": {{Version: "150", Name: "max-connections", Value: 3000, Method: "UPDATE"}},
+		"tikv": {{Version: "2", Name: "raftstore.store-pool-size", Value: 4, Method: "UPDATE"}},
+	})
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+
+	// One finding per parameter and component type, not one per node
+	counts := make(map[string]int)
+	for _, result := range results {
+		counts[result.Component+"/"+result.ParameterName]++
+	}
+	assert.Equal(t, map[string]int{"tidb/max-connections": 1, "tikv/raftstore.store-pool-size": 1}, counts)
+}
+
+func TestForcedChangesRule_Evaluate_ReleaseVersion(t *testing.T) {
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type:      types.ComponentTiDB,
+					Variables: types.ParameterMap{"tidb_enable_foo": types.ParameterValue{Value: "OFF", Type: "bool"}},
+				},
+			},
+		},
+		SourceVersion:          "v7.5.0",
+		TargetVersion:          "v8.5.0",
+		SourceBootstrapVersion: 179,
+		TargetBootstrapVersion: 218,
+		SourceDefaults:         map[string]map[string]interface{}{"tidb": {"sysvar:tidb_enable_foo": "OFF"}},
+		TargetDefaults:         map[string]map[string]interface{}{"tidb": {"sysvar:tidb_enable_foo": "ON"}},
+		UpgradeHistory:         collector.UpgradeHistory{179: "v7.5.0", 198: "v8.1.0", 218: "v8.5.0"},
+	}
+	ruleCtx.SetUpgradeLogic(map[string][]types.UpgradeParamChange{
+		"tidb": {{Version: "190", Name: "tidb_enable_foo", Value: "ON", Type: "system_variable", Method: "UPDATE"}},
+	})
+
+	results, err := NewForcedChangesRule().Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	results = withoutStatistics(results)
+	require.Len(t, results, 1)
+	assert.Equal(t, "v8.1.0", results[0].Metadata["release_version"])
+	assert.Contains(t, results[0].Details, "Introduced in: v8.1.0 (upgradeToVer190)")
+
+	// Without the upgrade history, only the bootstrap version is known
+	ruleCtx.UpgradeHistory = nil
+	results, err = NewForcedChangesRule().Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	results = withoutStatistics(results)
+	require.Len(t, results, 1)
+	assert.NotContains(t, results[0].Metadata, "release_version")
+	assert.NotContains(t, results[0].Details, "Introduced in")
+}
+
+func TestForcedChangesRule_Evaluate_ForcedSystemVariableChange(t *testing.T) {
+	rule := NewForcedChangesRule()
+	ctx := context.Background()
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type: types.ComponentTiDB,
+					Variables: types.ParameterMap{
+						"tidb_mem_quota_query": types.ParameterValue{Value: 1073741824, Type: "int"},
+					},
+				},
+			},
+		},
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		SourceDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"sysvar:tidb_mem_quota_query": 1073741824,
+			},
+		},
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"sysvar:tidb_mem_quota_query": 2147483648,
+			},
+		},
+	}
+
+	ruleCtx.SourceBootstrapVersion = 140
+	ruleCtx.TargetBootstrapVersion = 160
+	ruleCtx.SetUpgradeLogic(map[string][]types.UpgradeParamChange{
+		"tidb": {
+			// Bootstrap version in range (140 < 150 <= 160), system variable name without sysvar: prefix
+			{Version: "150", Name: "tidb_mem_quota_query", Value: 4294967296, Method: "SET @@GLOBAL", Severity: "medium"},
+		},
+	})
+
+	results, err := rule.Evaluate(ctx, ruleCtx)
+
+	assert.NoError(t, err)
+	assert.NotEmpty(t, results)
+
+	// Should detect forced system variable change
+	found := false
+	for _, result := range results {
+		if result.ParameterName == "tidb_mem_quota_query" && result.ForcedValue != nil {
+			found = true
+			assert.Equal(t, "error", result.Severity) // Forced system variable changes are critical
+			assert.Equal(t, "system_variable", result.ParamType)
+			assert.Equal(t, 4294967296, result.ForcedValue)
+			break
+		}
+	}
+	assert.True(t, found, "Should detect forced system variable change")
+}
+
+func TestForcedChangesRule_Evaluate_BootstrapVersionFiltering(t *testing.T) {
+	rule := NewForcedChangesRule()
+	ctx := context.Background()
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type: types.ComponentTiDB,
+					Config: types.ParameterMap{
+						"param1": types.ParameterValue{Value: 100, Type: "int"},
+						"param2": types.ParameterValue{Value: 200, Type: "int"},
+					},
+				},
+			},
+		},
+		SourceVersion:          "v7.5.0",
+		TargetVersion:          "v8.5.0",
+		SourceBootstrapVersion: 140,
+		TargetBootstrapVersion: 160,
+		SourceDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"param1": 100,
+				"param2": 200,
+			},
+		},
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"param1": 100,
+				"param2": 200,
+			},
+		},
+	}
+	ruleCtx.SetUpgradeLogic(map[string][]types.UpgradeParamChange{
+		"tidb": {
+			// This change is before source bootstrap version (should be filtered out)
+			{Version: "130", Name: "param1", Value: 150, Method: "UPDATE", Severity: "medium"},
+			// This change is in range (140 < 150 <= 160) (should be included)
+			{Version: "150", Name: "param2", Value: 250, Method: "UPDATE", Severity: "medium"},
+			// This change is after target bootstrap version (should be filtered out)
+			{Version: "170", Name: "param3", Value: 300, Method: "UPDATE", Severity: "medium"},
+		},
+	})
+
+	results, err := rule.Evaluate(ctx, ruleCtx)
+
+	assert.NoError(t, err)
+
+	// Should only detect param2 (bootstrap version 150 is in range)
+	param2Found := false
+	param1Found := false
+	param3Found := false
+
+	for _, result := range results {
+		if result.ParameterName == "param1" && result.ForcedValue != nil {
+			param1Found = true
+		}
+		if result.ParameterName == "param2" && result.ForcedValue != nil {
+			param2Found = true
+			assert.Equal(t, 250, result.ForcedValue)
+		}
+		if result.ParameterName == "param3" && result.ForcedValue != nil {
+			param3Found = true
+		}
+	}
+
+	assert.False(t, param1Found, "param1 change should be filtered out (before source bootstrap version)")
+	assert.True(t, param2Found, "param2 change should be included (in bootstrap version range)")
+	assert.False(t, param3Found, "param3 change should be filtered out (after target bootstrap version)")
+}
+
+func TestForcedChangesRule_Evaluate_BooleanForcedChangeNormalization(t *testing.T) {
+	// Upgrade logic stores boolean system variables as "1"/"0" (or "" for DELETE),
+	// while the runtime reports "ON"/"OFF"
+	tests := []struct {
+		name          string
+		paramName     string
+		currentValue  interface{}
+		targetDefault interface{}
+		change        types.UpgradeParamChange
+		wantSeverity  string
+		wantForced    interface{}
+		wantRemoved   bool
+		wantDisplay   string
+	}{
+		{
+			name:          "forced 1 matches runtime ON",
+			paramName:     "tidb_enable_async_merge_global_stats",
+			currentValue:  "ON",
+			targetDefault: "ON",
+			change:        types.UpgradeParamChange{Value: "1", Method: "initGlobalVariableIfNotExists"},
+			wantSeverity:  "info",
+			wantForced:    "ON",
+			wantDisplay:   `"ON"`,
+		},
+		{
+			name:          "forced 1 differs from runtime OFF",
+			paramName:     "tidb_enable_async_merge_global_stats",
+			currentValue:  "OFF",
+			targetDefault: "ON",
+			change:        types.UpgradeParamChange{Value: "1", Method: "initGlobalVariableIfNotExists"},
+			wantSeverity:  "error",
+			wantForced:    "ON",
+			wantDisplay:   `"ON"`,
+		},
+		{
+			name:          "forced OFF matches runtime 0",
+			paramName:     "tidb_enable_async_merge_global_stats",
+			currentValue:  "0",
+			targetDefault: "ON",
+			change:        types.UpgradeParamChange{Value: "OFF", Method: "setGlobalSysVar"},
+			wantSeverity:  "info",
+			wantForced:    "OFF",
+			wantDisplay:   `"OFF"`,
+		},
+		{
+			name:          "numeric variable is not treated as boolean",
+			paramName:     "tidb_analyze_version",
+			currentValue:  "2",
+			targetDefault: "2",
+			change:        types.UpgradeParamChange{Value: "1", Method: "initGlobalVariableIfNotExists"},
+			wantSeverity:  "error",
+			wantForced:    "1",
+			wantDisplay:   "1",
+		},
+		{
+			name:          "DELETE removes the variable",
+			paramName:     "tidb_enable_clustered_index",
+			currentValue:  "ON",
+			targetDefault: "ON",
+			change:        types.UpgradeParamChange{Value: "", Method: "mustExecute-DELETE"},
+			wantSeverity:  "error",
+			wantForced:    "",
+			wantRemoved:   true,
+			wantDisplay:   ForcedRemovalDisplay,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			change := tt.change
+			change.Version, change.Name = "150", tt.paramName
+
+			ruleCtx := withUpgradeLogic(&RuleContext{
+				SourceClusterSnapshot: &collector.ClusterSnapshot{
+					Components: map[string]collector.ComponentState{
+						"tidb": {
+							Type: types.ComponentTiDB,
+							Variables: types.ParameterMap{
+								tt.paramName: types.ParameterValue{Value: tt.currentValue, Type: "string"},
+							},
+						},
+					},
+				},
+				SourceVersion:          "v7.5.0",
+				TargetVersion:          "v8.5.0",
+				SourceBootstrapVersion: 140,
+				TargetBootstrapVersion: 160,
+				TargetDefaults: map[string]map[string]interface{}{
+					"tidb": {"sysvar:" + tt.paramName: tt.targetDefault},
+				},
+			}, map[string][]types.UpgradeParamChange{"tidb": {change}})
+
+			results, err := NewForcedChangesRule().Evaluate(context.Background(), ruleCtx)
+			assert.NoError(t, err)
+
+			var found *CheckResult
+			for i := range results {
+				if results[i].ParameterName == tt.paramName {
+					found = &results[i]
+				}
+			}
+			if assert.NotNil(t, found) {
+				assert.Equal(t, tt.wantSeverity, found.Severity)
+				assert.Equal(t, tt.wantForced, found.ForcedValue)
+				assert.Equal(t, tt.wantRemoved, IsForcedRemoval(*found))
+				assert.Equal(t, tt.wantDisplay, FormatForcedValue(*found))
+				if tt.wantSeverity != "info" {
+					assert.Contains(t, found.Details, "Will be forced to: "+tt.wantDisplay)
+				}
+			}
+		})
+	}
+}
+
+func TestGetUpgradeLogicChanges_ComponentBootstrapVersions(t *testing.T) {
+	pdLogic := map[string][]types.UpgradeParamChange{"pd": {
+		{Version: "1", Name: "schedule.max-merge-region-size", Value: float64(54)},
+		{Version: "2", Name: "schedule.leader-schedule-policy", Value: "size"},
+	}}
+	ruleCtx := withUpgradeLogic(&RuleContext{
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		// TiDB's bootstrap versions do not number PD's upgrade functions
+		SourceBootstrapVersion: 140,
+		TargetBootstrapVersion: 160,
+	}, pdLogic)
+
+	// Without PD's own bootstrap versions, PD changes cannot be placed
+	assert.Empty(t, ruleCtx.GetUpgradeLogicChanges("pd"))
+
+	ruleCtx.ComponentBootstrapVersions = map[string]BootstrapVersionRange{"pd": {Source: 1, Target: 2}}
+	ruleCtx.SetUpgradeLogic(pdLogic)
+	changes := ruleCtx.GetUpgradeLogicChanges("pd")
+	if assert.Len(t, changes, 1) {
+		assert.Equal(t, "schedule.leader-schedule-policy", changes[0].Name)
+	}
+	assert.Equal(t, map[string]interface{}{"schedule.leader-schedule-policy": "size"}, ruleCtx.GetForcedChanges("pd"))
+}
+
+func TestGetBootstrapVersionForSourceCluster(t *testing.T) {
+	// Offline analysis: no query, the bootstrap version is unknown
+	ruleCtx := &RuleContext{}
+	assert.Equal(t, int64(0), ruleCtx.GetBootstrapVersionForSourceCluster())
+
+	// The cluster is queried once
+	calls := 0
+	ruleCtx = &RuleContext{BootstrapVersionQuery: func() (int64, error) {
+		calls++
+		return 150, nil
+	}}
+	assert.Equal(t, int64(150), ruleCtx.GetBootstrapVersionForSourceCluster())
+	assert.Equal(t, int64(150), ruleCtx.GetBootstrapVersionForSourceCluster())
+	assert.Equal(t, 1, calls)
+
+	// A failed query is not retried
+	calls = 0
+	ruleCtx = &RuleContext{BootstrapVersionQuery: func() (int64, error) {
+		calls++
+		return 0, errors.New("access denied")
+	}}
+	assert.Equal(t, int64(0), ruleCtx.GetBootstrapVersionForSourceCluster())
+	assert.Equal(t, int64(0), ruleCtx.GetBootstrapVersionForSourceCluster())
+	assert.Equal(t, 1, calls)
+}
+
+func TestGetUpgradeLogicChanges_ClusterBootstrapVersion(t *testing.T) {
+	changeNames := func(query func() (int64, error)) []string {
+		ruleCtx := withUpgradeLogic(&RuleContext{
+			SourceVersion:          "v7.5.0",
+			TargetVersion:          "v8.5.0",
+			SourceBootstrapVersion: 140,
+			TargetBootstrapVersion: 160,
+			BootstrapVersionQuery:  query,
+		}, map[string][]types.UpgradeParamChange{"tidb": syntheticUpgradeLogic("141", "145", "150", "160")})
+		var names []string
+		for _, change := range ruleCtx.GetUpgradeLogicChanges("tidb") {
+			names = append(names, change.Name)
+		}
+		return names
+	}
+
+	// Without a query, the bootstrap version of the source KB is used
+	assert.Equal(t, []string{"param141", "param145", "param150", "param160"}, changeNames(nil))
+	// The upgrade functions up to the bootstrap version of the cluster (e.g., backported to its patch release) already ran
+	assert.Equal(t, []string{"param150", "param160"}, changeNames(func() (int64, error) { return 145, nil }))
+	// The query failed: the bootstrap version of the source KB is used
+	assert.Equal(t, []string{"param141", "param145", "param150", "param160"},
+		changeNames(func() (int64, error) { return 0, errors.New("timeout") }))
+}
+
+func TestForcedChangesRule_Evaluate_UpgradeFunctionContext(t *testing.T) {
+	ruleCtx := withUpgradeLogic(&RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type:      types.ComponentTiDB,
+					Variables: types.ParameterMap{"tidb_enable_paging": {Value: "OFF", Type: "string"}},
+				},
+			},
+		},
+		SourceVersion:          "v7.5.0",
+		TargetVersion:          "v8.5.0",
+		SourceDefaults:         map[string]map[string]interface{}{"tidb": {"sysvar:tidb_enable_paging": "OFF"}},
+		TargetDefaults:         map[string]map[string]interface{}{"tidb": {"sysvar:tidb_enable_paging": "ON"}},
+		SourceBootstrapVersion: 140,
+		TargetBootstrapVersion: 160,
+	}, map[string][]types.UpgradeParamChange{"tidb": {{
+		Version:           "150",
+		Name:              "tidb_enable_paging",
+		Value:             "ON",
+		FunctionDocstring: "upgradeToVer150 enables paging for clusters upgraded from versions without it.",
+		Condition:         "ver < version92",
+	}}})
+
+	results, err := NewForcedChangesRule().Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Details, "Why: upgradeToVer150 enables paging for clusters upgraded from versions without it.")
+	assert.Contains(t, results[0].Details, "Only applied if: ver < version92")
+}
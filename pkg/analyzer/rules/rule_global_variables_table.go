@@ -0,0 +1,203 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Issues reported by GlobalVariablesTableRule (Metadata["issue"])
+const (
+	// GlobalVariablesIssueValueMismatch is a row whose value differs from SHOW GLOBAL VARIABLES
+	GlobalVariablesIssueValueMismatch = "value_mismatch"
+	// GlobalVariablesIssueUnknownVariable is a row of a variable SHOW GLOBAL VARIABLES does not return
+	GlobalVariablesIssueUnknownVariable = "unknown_variable"
+	// GlobalVariablesIssueDuplicateRows is a variable with several rows (names differing in case)
+	GlobalVariablesIssueDuplicateRows = "duplicate_rows"
+)
+
+// GlobalVariablesTableRule cross-checks the mysql.global_variables table against SHOW GLOBAL VARIABLES
+// Rule: The upgrade bootstrap reads and rewrites the rows of mysql.global_variables, not the in-memory global values.
+// Stale or orphaned rows make the values after the upgrade (and the forced changes predicted for it) differ from
+// what SHOW GLOBAL VARIABLES suggests. Reports:
+// - rows whose value differs from the in-memory global value (warning)
+// - rows of variables the running version does not know, left over from removed variables (info)
+// - variables with several rows (warning)
+// The table is only collected when this rule is enabled; without the SELECT privilege on it nothing is reported
+type GlobalVariablesTableRule struct {
+	*BaseRule
+}
+
+// NewGlobalVariablesTableRule creates a new mysql.global_variables consistency rule
+func NewGlobalVariablesTableRule() Rule {
+	return &GlobalVariablesTableRule{
+		BaseRule: NewBaseRule(
+			"GLOBAL_VARIABLES_TABLE",
+			"Cross-check the rows of mysql.global_variables, read by the upgrade bootstrap, against SHOW GLOBAL VARIABLES",
+			"global_variables_table",
+		),
+	}
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *GlobalVariablesTableRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:               []string{"tidb"},
+			NeedConfig:               false,
+			NeedSystemVariables:      true, // SHOW GLOBAL VARIABLES is the reference
+			NeedAllTikvNodes:         false,
+			NeedGlobalVariablesTable: true,
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          []string{}, // This rule doesn't need knowledge base data
+			NeedConfigDefaults:  false,
+			NeedSystemVariables: false,
+			NeedUpgradeLogic:    false,
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          []string{},
+			NeedConfigDefaults:  false,
+			NeedSystemVariables: false,
+			NeedUpgradeLogic:    false,
+		},
+	}
+}
+
+// Evaluate performs the rule check
+// Results are sorted by variable name
+func (r *GlobalVariablesTableRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil || ruleCtx.SourceClusterSnapshot.GlobalVariablesTable == nil {
+		return results, nil
+	}
+	tidb, ok := ruleCtx.SourceClusterSnapshot.ComponentByType(collector.TiDBComponent)
+	if !ok || len(tidb.Variables) == 0 {
+		// Without SHOW GLOBAL VARIABLES every row would look unknown
+		return results, nil
+	}
+
+	// System variable names are case-insensitive
+	globalValues := make(map[string]defaultsTypes.ParameterValue, len(tidb.Variables))
+	for name, value := range tidb.Variables {
+		globalValues[strings.ToLower(name)] = value
+	}
+	rowsByName := make(map[string][]defaultsTypes.GlobalVariableRow)
+	for _, row := range ruleCtx.SourceClusterSnapshot.GlobalVariablesTable {
+		name := strings.ToLower(row.Name)
+		rowsByName[name] = append(rowsByName[name], row)
+	}
+	names := make([]string, 0, len(rowsByName))
+	for name := range rowsByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		rows := rowsByName[name]
+		if len(rows) > 1 {
+			results = append(results, r.duplicateRowsResult(name, rows))
+			continue
+		}
+		row := rows[0]
+		global, known := globalValues[name]
+		if !known {
+			results = append(results, r.unknownVariableResult(row))
+			continue
+		}
+		normalized := NormalizeBoolLikeValues(row.Value, global.Value)
+		if !CompareValues(normalized[0], normalized[1]) {
+			results = append(results, r.valueMismatchResult(row, global.Value))
+		}
+	}
+
+	return results, nil
+}
+
+// newResult creates a result of the rule for a system variable
+func (r *GlobalVariablesTableRule) newResult(name, issue, severity, message, details string, suggestions []string) CheckResult {
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     "tidb",
+		ParameterName: name,
+		ParamType:     "system_variable",
+		Description:   r.Description(),
+		Severity:      severity,
+		Message:       message,
+		Details:       details,
+		Suggestions:   suggestions,
+		Metadata:      map[string]interface{}{"issue": issue},
+	}
+}
+
+// valueMismatchResult reports a row whose value differs from the in-memory global value
+func (r *GlobalVariablesTableRule) valueMismatchResult(row defaultsTypes.GlobalVariableRow, globalValue interface{}) CheckResult {
+	result := r.newResult(row.Name, GlobalVariablesIssueValueMismatch, "warning",
+		fmt.Sprintf("mysql.global_variables stores %s = %s, but SHOW GLOBAL VARIABLES returns %s",
+			row.Name, FormatValue(row.Value), FormatValue(globalValue)),
+		"The upgrade bootstrap reads the value stored in mysql.global_variables, not the in-memory global value. "+
+			"After the upgrade the cluster runs with the stored value, and the forced changes predicted from the current value may not apply.",
+		[]string{
+			fmt.Sprintf("Run SET GLOBAL %s = <intended value> before the upgrade so that the table and the in-memory value agree", row.Name),
+		})
+	result.CurrentValue = globalValue
+	result.Metadata["table_value"] = row.Value
+	return result
+}
+
+// unknownVariableResult reports a row of a variable the running version does not return
+func (r *GlobalVariablesTableRule) unknownVariableResult(row defaultsTypes.GlobalVariableRow) CheckResult {
+	result := r.newResult(row.Name, GlobalVariablesIssueUnknownVariable, "info",
+		fmt.Sprintf("mysql.global_variables has a row for %s, which SHOW GLOBAL VARIABLES does not return", row.Name),
+		"The row is likely left over from a variable removed in an earlier version (or the variable is hidden). "+
+			"The upgrade bootstrap may rename or convert such rows, so a row unexpectedly present can turn into a value of a current variable after the upgrade.",
+		[]string{
+			"Check whether the variable was removed in an earlier version; if so, the row can be deleted before the upgrade after taking a backup of the table",
+		})
+	result.Metadata["table_value"] = row.Value
+	return result
+}
+
+// duplicateRowsResult reports a variable stored in several rows
+func (r *GlobalVariablesTableRule) duplicateRowsResult(name string, rows []defaultsTypes.GlobalVariableRow) CheckResult {
+	var stored []string
+	tableValues := make([]interface{}, 0, len(rows))
+	for _, row := range rows {
+		stored = append(stored, fmt.Sprintf("%s = %s", row.Name, FormatValue(row.Value)))
+		tableValues = append(tableValues, row.Value)
+	}
+	result := r.newResult(name, GlobalVariablesIssueDuplicateRows, "warning",
+		fmt.Sprintf("mysql.global_variables has %d rows for %s", len(rows), name),
+		fmt.Sprintf("Stored rows: %s. Which row the upgrade bootstrap reads and rewrites is undefined, "+
+			"so the value of the variable after the upgrade is unpredictable.", strings.Join(stored, ", ")),
+		[]string{
+			"Keep a single row with the intended value (deleting the others) before the upgrade, after taking a backup of the table",
+		})
+	result.Metadata["table_values"] = tableValues
+	return result
+}
@@ -0,0 +1,86 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newGlobalVariablesSnapshot(variables map[string]string, table []defaultsTypes.GlobalVariableRow) *collector.ClusterSnapshot {
+	return &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{
+			"tidb": {
+				Type:      defaultsTypes.ComponentTiDB,
+				Variables: defaultsTypes.ConvertVariablesToSystemVariables(variables),
+			},
+		},
+		GlobalVariablesTable: table,
+	}
+}
+
+func TestGlobalVariablesTableRule_Evaluate(t *testing.T) {
+	snapshot := newGlobalVariablesSnapshot(
+		map[string]string{
+			"tidb_enable_async_commit": "ON",
+			"tidb_mem_quota_query":     "1073741824",
+			"tidb_txn_mode":            "pessimistic",
+			"max_connections":          "0",
+		},
+		[]defaultsTypes.GlobalVariableRow{
+			{Name: "tidb_enable_async_commit", Value: "1"},       // Same value, boolean form
+			{Name: "tidb_mem_quota_query", Value: "34359738368"}, // Stale value
+			{Name: "tidb_txn_mode", Value: "pessimistic"},
+			{Name: "tidb_enable_streaming", Value: "OFF"}, // Removed variable
+			{Name: "max_connections", Value: "0"},
+			{Name: "MAX_CONNECTIONS", Value: "100"}, // Duplicate row
+		},
+	)
+
+	results, err := NewGlobalVariablesTableRule().Evaluate(context.Background(), &RuleContext{SourceClusterSnapshot: snapshot})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "max_connections", results[0].ParameterName)
+	assert.Equal(t, GlobalVariablesIssueDuplicateRows, results[0].Metadata["issue"])
+	assert.Equal(t, "warning", results[0].Severity)
+	assert.Contains(t, results[0].Details, "MAX_CONNECTIONS = 100")
+
+	assert.Equal(t, "tidb_enable_streaming", results[1].ParameterName)
+	assert.Equal(t, GlobalVariablesIssueUnknownVariable, results[1].Metadata["issue"])
+	assert.Equal(t, "info", results[1].Severity)
+
+	assert.Equal(t, "tidb_mem_quota_query", results[2].ParameterName)
+	assert.Equal(t, GlobalVariablesIssueValueMismatch, results[2].Metadata["issue"])
+	assert.Equal(t, "warning", results[2].Severity)
+	assert.Equal(t, "1073741824", results[2].CurrentValue)
+	assert.Equal(t, "34359738368", results[2].Metadata["table_value"])
+	assert.Contains(t, results[2].Details, "upgrade bootstrap")
+
+	for _, result := range results {
+		assert.Equal(t, "GLOBAL_VARIABLES_TABLE", result.RuleID)
+		assert.Equal(t, "global_variables_table", result.Category)
+		assert.Equal(t, "tidb", result.Component)
+		assert.Equal(t, "system_variable", result.ParamType)
+	}
+}
+
+func TestGlobalVariablesTableRule_NotCollected(t *testing.T) {
+	rule := NewGlobalVariablesTableRule()
+	assert.True(t, rule.DataRequirements().SourceClusterRequirements.NeedGlobalVariablesTable)
+
+	// No SELECT privilege on mysql.global_variables: the table is not in the snapshot
+	snapshot := newGlobalVariablesSnapshot(map[string]string{"tidb_txn_mode": "pessimistic"}, nil)
+	results, err := rule.Evaluate(context.Background(), &RuleContext{SourceClusterSnapshot: snapshot})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	// System variables not collected: rows cannot be checked
+	snapshot = newGlobalVariablesSnapshot(nil, []defaultsTypes.GlobalVariableRow{{Name: "tidb_txn_mode", Value: "optimistic"}})
+	results, err = rule.Evaluate(context.Background(), &RuleContext{SourceClusterSnapshot: snapshot})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
@@ -0,0 +1,263 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// GoldenConfigRule reports drift of the runtime configuration from an operator-maintained golden profile
+// Rule: For each parameter of the profile, compare the runtime value of every instance with the golden value
+// Findings use the "golden_drift" category and are reported separately from upgrade differences
+// Profile entries that reference a parameter unknown to both the cluster and the knowledge base are reported as stale
+type GoldenConfigRule struct {
+	*BaseRule
+	profile GoldenProfile
+}
+
+// NewGoldenConfigRule creates a new golden config rule for a profile loaded with LoadGoldenProfile
+func NewGoldenConfigRule(profile GoldenProfile) Rule {
+	if profile == nil {
+		profile = GoldenProfile{}
+	}
+	return &GoldenConfigRule{
+		BaseRule: NewBaseRule(
+			"GOLDEN_CONFIG",
+			"Check runtime configuration against the golden configuration profile",
+			"golden_drift",
+		),
+		profile: profile,
+	}
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *GoldenConfigRule) DataRequirements() DataSourceRequirement {
+	components := r.sortedComponents()
+	_, hasTiKV := r.profile["tikv"]
+	needSystemVars := false
+	for _, compProfile := range r.profile {
+		if len(compProfile.SystemVariables) > 0 {
+			needSystemVars = true
+		}
+	}
+
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:          components,
+			NeedConfig:          true,
+			NeedSystemVariables: needSystemVars,
+			NeedAllTikvNodes:    hasTiKV, // Drift is checked on every node
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          components,
+			NeedConfigDefaults:  true, // Used to tell stale profile entries from parameters that are not collected
+			NeedSystemVariables: needSystemVars,
+			NeedUpgradeLogic:    false,
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          components,
+			NeedConfigDefaults:  true,
+			NeedSystemVariables: needSystemVars,
+			NeedUpgradeLogic:    false,
+		},
+	}
+}
+
+// goldenNode is a component instance checked by GoldenConfigRule
+type goldenNode struct {
+	address   string
+	config    defaultsTypes.ParameterMap
+	variables defaultsTypes.ParameterMap
+}
+
+// Evaluate performs the rule check
+func (r *GoldenConfigRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil {
+		return results, nil
+	}
+
+	for _, component := range r.sortedComponents() {
+		compProfile := r.profile[component]
+		nodes := r.collectNodes(ruleCtx, component)
+		results = append(results, r.checkParams(ruleCtx, component, "config", compProfile.ConfigDefaults, nodes)...)
+		results = append(results, r.checkParams(ruleCtx, component, "system_variable", compProfile.SystemVariables, nodes)...)
+	}
+	return results, nil
+}
+
+// checkParams checks the golden values of one parameter type of a component
+func (r *GoldenConfigRule) checkParams(ruleCtx *RuleContext, component, paramType string, golden map[string]GoldenValue, nodes []goldenNode) []CheckResult {
+	var results []CheckResult
+
+	names := make([]string, 0, len(golden))
+	for name := range golden {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		expected := golden[name]
+		var collected, deviating []string
+		var currentValue interface{}
+		for _, node := range nodes {
+			value, ok := node.value(paramType, name)
+			if !ok {
+				continue
+			}
+			collected = append(collected, node.address)
+			if CompareValues(value, expected.Value) {
+				continue
+			}
+			if currentValue == nil {
+				currentValue = value
+			}
+			deviating = append(deviating, fmt.Sprintf("  %s: %s", node.address, FormatValue(value)))
+		}
+
+		if len(collected) == 0 {
+			if !r.knownInKB(ruleCtx, component, paramType, name) {
+				results = append(results, r.staleResult(component, paramType, name, expected, len(nodes) > 0))
+			}
+			continue
+		}
+		if len(deviating) == 0 {
+			continue
+		}
+
+		severity := expected.Severity
+		if severity == "" {
+			severity = "warning"
+		}
+		message := fmt.Sprintf("%s deviates from the golden configuration on %d of %d %s instances", name, len(deviating), len(collected), component)
+		details := fmt.Sprintf("Golden value: %s\nDeviating instances:\n%s", FormatValue(expected.Value), strings.Join(deviating, "\n"))
+		if expected.Description != "" {
+			details = fmt.Sprintf("%s\nReason: %s", details, expected.Description)
+		}
+
+		results = append(results, CheckResult{
+			RuleID:        r.Name(),
+			Category:      r.Category(),
+			Component:     component,
+			ParameterName: name,
+			ParamType:     paramType,
+			Severity:      severity,
+			RiskLevel:     GetRiskLevel(severity),
+			Message:       message,
+			Details:       details,
+			CurrentValue:  currentValue,
+			Suggestions: []string{
+				"Align the parameter with the golden configuration, or update the profile if the deviation is intended",
+			},
+			Metadata: map[string]interface{}{
+				"golden_value":    expected.Value,
+				"deviating_count": len(deviating),
+				"instance_count":  len(collected),
+				"stale":           false,
+			},
+		})
+	}
+	return results
+}
+
+// staleResult reports a profile entry that references a parameter unknown to the cluster and the knowledge base
+func (r *GoldenConfigRule) staleResult(component, paramType, name string, expected GoldenValue, deployed bool) CheckResult {
+	reason := fmt.Sprintf("%s is not collected from the cluster and not defined in the source or target knowledge base", name)
+	if !deployed {
+		reason = fmt.Sprintf("%s is not deployed and %s is not defined in the source or target knowledge base", component, name)
+	}
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     component,
+		ParameterName: name,
+		ParamType:     paramType,
+		Severity:      "info",
+		RiskLevel:     RiskLevelLow,
+		Message:       fmt.Sprintf("Golden configuration entry %s references an unknown %s parameter (stale)", name, component),
+		Details:       fmt.Sprintf("Golden value: %s\n%s", FormatValue(expected.Value), reason),
+		Suggestions: []string{
+			"Remove the entry from the golden configuration profile, or fix the parameter name",
+		},
+		Metadata: map[string]interface{}{
+			"golden_value": expected.Value,
+			"stale":        true,
+		},
+	}
+}
+
+// knownInKB checks if a parameter is defined in the source or target knowledge base
+func (r *GoldenConfigRule) knownInKB(ruleCtx *RuleContext, component, paramType, name string) bool {
+	lookupName := name
+	if paramType == "system_variable" {
+		lookupName = "sysvar:" + name
+	}
+	return ruleCtx.GetSourceDefault(component, lookupName) != nil || ruleCtx.GetTargetDefault(component, lookupName) != nil
+}
+
+// collectNodes returns the instances of a component, one per address, sorted by address
+func (r *GoldenConfigRule) collectNodes(ruleCtx *RuleContext, component string) []goldenNode {
+	byAddress := make(map[string]goldenNode)
+	for _, comp := range ruleCtx.SourceClusterSnapshot.ComponentsByType(defaultsTypes.ComponentType(component)) {
+		address := component
+		if addr, ok := comp.Status["address"].(string); ok && addr != "" {
+			address = addr
+		}
+		byAddress[address] = goldenNode{address: address, config: comp.Config, variables: comp.Variables}
+	}
+
+	nodes := make([]goldenNode, 0, len(byAddress))
+	for _, node := range byAddress {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].address < nodes[j].address })
+	return nodes
+}
+
+// value returns the runtime value of a parameter on the node
+func (n goldenNode) value(paramType, name string) (interface{}, bool) {
+	var pv defaultsTypes.ParameterValue
+	var ok bool
+	if paramType == "system_variable" {
+		pv, ok = n.variables[name]
+	} else {
+		pv, ok = n.config[name]
+	}
+	if !ok || pv.Value == nil {
+		return nil, false
+	}
+	return pv.Value, true
+}
+
+// sortedComponents returns the components of the profile, sorted
+func (r *GoldenConfigRule) sortedComponents() []string {
+	components := make([]string, 0, len(r.profile))
+	for component := range r.profile {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	return components
+}
@@ -0,0 +1,124 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadGoldenProfile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "profile.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "tidb": {
+    "config_defaults": {"log.level": {"value": "info", "type": "string"}},
+    "system_variables": {"tidb_txn_mode": {"value": "pessimistic", "severity": "error"}}
+  }
+}`), 0644))
+
+	profile, err := LoadGoldenProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "info", profile["tidb"].ConfigDefaults["log.level"].Value)
+	assert.Equal(t, "error", profile["tidb"].SystemVariables["tidb_txn_mode"].Severity)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"tikv": {"config_defaults": {"a": {"value": 1, "severity": "fatal"}}}}`), 0644))
+	_, err = LoadGoldenProfile(path)
+	assert.ErrorContains(t, err, "invalid severity")
+}
+
+func TestGoldenConfigRule_Evaluate(t *testing.T) {
+	profile := GoldenProfile{
+		"tidb": {
+			ConfigDefaults: map[string]GoldenValue{
+				"log.level": {Value: "info"},
+			},
+			SystemVariables: map[string]GoldenValue{
+				"tidb_txn_mode":       {Value: "pessimistic", Severity: "error"},
+				"tidb_removed_option": {Value: "ON"},
+				"tidb_not_collected":  {Value: "ON"},
+			},
+		},
+		"tikv": {
+			ConfigDefaults: map[string]GoldenValue{
+				"storage.reserve-space": {Value: "5GiB", Description: "Keep headroom for compaction"},
+			},
+		},
+	}
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type:   defaultsTypes.ComponentTiDB,
+					Config: defaultsTypes.ParameterMap{"log.level": {Value: "info"}},
+					Variables: defaultsTypes.ParameterMap{
+						"tidb_txn_mode": {Value: "optimistic"},
+					},
+				},
+				"tikv-10.0.0.1:20160": {
+					Type:   defaultsTypes.ComponentTiKV,
+					Config: defaultsTypes.ParameterMap{"storage.reserve-space": {Value: "5GiB"}},
+					Status: map[string]interface{}{"address": "10.0.0.1:20160"},
+				},
+				"tikv-10.0.0.2:20160": {
+					Type:   defaultsTypes.ComponentTiKV,
+					Config: defaultsTypes.ParameterMap{"storage.reserve-space": {Value: "0KiB"}},
+					Status: map[string]interface{}{"address": "10.0.0.2:20160"},
+				},
+			},
+		},
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		// Known to the knowledge base but not collected: not stale
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {"sysvar:tidb_not_collected": "OFF"},
+		},
+	}
+
+	results, err := NewGoldenConfigRule(profile).Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	byName := make(map[string]CheckResult)
+	for _, result := range results {
+		assert.Equal(t, "GOLDEN_CONFIG", result.RuleID)
+		assert.Equal(t, "golden_drift", result.Category)
+		byName[result.ParameterName] = result
+	}
+
+	txnMode := byName["tidb_txn_mode"]
+	assert.Equal(t, "error", txnMode.Severity)
+	assert.Equal(t, RiskLevelHigh, txnMode.RiskLevel)
+	assert.Equal(t, "system_variable", txnMode.ParamType)
+	assert.Equal(t, "optimistic", txnMode.CurrentValue)
+	assert.Equal(t, false, txnMode.Metadata["stale"])
+
+	reserveSpace := byName["storage.reserve-space"]
+	assert.Equal(t, "warning", reserveSpace.Severity)
+	assert.Contains(t, reserveSpace.Message, "1 of 2 tikv instances")
+	assert.Contains(t, reserveSpace.Details, `10.0.0.2:20160: "0KiB"`)
+	assert.NotContains(t, reserveSpace.Details, "10.0.0.1:20160")
+	assert.Contains(t, reserveSpace.Details, "Reason: Keep headroom for compaction")
+
+	removed := byName["tidb_removed_option"]
+	assert.Equal(t, "info", removed.Severity)
+	assert.Equal(t, true, removed.Metadata["stale"])
+}
+
+func TestGoldenConfigRule_DataRequirements(t *testing.T) {
+	rule := NewGoldenConfigRule(GoldenProfile{
+		"tikv": {ConfigDefaults: map[string]GoldenValue{"a": {Value: 1}}},
+		"tidb": {SystemVariables: map[string]GoldenValue{"b": {Value: "ON"}}},
+	})
+	req := rule.DataRequirements()
+	assert.Equal(t, []string{"tidb", "tikv"}, req.SourceClusterRequirements.Components)
+	assert.True(t, req.SourceClusterRequirements.NeedSystemVariables)
+	assert.True(t, req.SourceClusterRequirements.NeedAllTikvNodes)
+	assert.Equal(t, []string{"tidb", "tikv"}, req.TargetKBRequirements.Components)
+}
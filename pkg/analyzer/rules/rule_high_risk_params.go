@@ -2,9 +2,14 @@
 package rules
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
@@ -35,6 +40,18 @@ type HighRiskParamConfig struct {
 	// If empty, applies to all versions after FromVersion
 	// The rule will only check this parameter if sourceVersion <= ToVersion (if specified)
 	ToVersion string `json:"to_version,omitempty"`
+	// UnsafeValues is an optional list of values that contradict the safety guidance
+	// A parameter set to one of these values is reported as critical
+	UnsafeValues []interface{} `json:"unsafe_values,omitempty"`
+	// Tolerance is the relative distance from the target default (e.g. 0.25 for 25%)
+	// within which a numeric value is considered acceptable and reported as info
+	Tolerance float64 `json:"tolerance,omitempty"`
+	// CriticalDistance is the relative distance from the target default (e.g. 4 for 400%)
+	// from which a numeric value is reported as critical. If 0, numeric values are never escalated
+	CriticalDistance float64 `json:"critical_distance,omitempty"`
+	// Disabled suppresses the entry of the same parameter in a lower-priority config file
+	// (see high_risk_params.MergeLayers). A disabled entry is never checked
+	Disabled bool `json:"disabled,omitempty"`
 }
 
 // HighRiskParamsConfig defines the structure for high-risk parameters configuration
@@ -59,11 +76,43 @@ type HighRiskParamsConfig struct {
 	} `json:"tiflash,omitempty"`
 }
 
+// HighRiskParamsFile is the name of the high-risk parameters file of a version family in the knowledge base
+const HighRiskParamsFile = "high_risk_params.json"
+
+// LoadHighRiskParamsFromKB loads the high-risk parameters of the version family of targetVersion,
+// stored next to the versions' defaults.json (e.g., knowledge/v8.5/high_risk_params.json for v8.5.1)
+// It returns nil if the version family has no high-risk parameters file
+// Unknown fields are rejected, so that misspelled keys are not silently ignored
+func LoadHighRiskParamsFromKB(kbPath, targetVersion string) (*HighRiskParamsConfig, error) {
+	family := versionGroup(targetVersion)
+	if family == "" {
+		return nil, fmt.Errorf("invalid target version %q", targetVersion)
+	}
+	path := filepath.Join(kbPath, family, HighRiskParamsFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read high-risk params %s: %w", path, err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	config := &HighRiskParamsConfig{}
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("invalid high-risk params %s: %w", path, err)
+	}
+	return config, nil
+}
+
 // HighRiskParamsRule checks for high-risk parameters that have been manually specified
 // This rule allows developers to define custom high-risk parameters for each component
 type HighRiskParamsRule struct {
 	*BaseRule
 	config *HighRiskParamsConfig
+	// layers are the config files config was merged from, reported in the statistics of the rule
+	layers []ConfigLayer
 }
 
 // NewHighRiskParamsRule creates a new high-risk parameters rule
@@ -88,6 +137,17 @@ func NewHighRiskParamsRule(config *HighRiskParamsConfig) (Rule, error) {
 	return rule, nil
 }
 
+// NewHighRiskParamsRuleWithLayers creates a high-risk parameters rule whose configuration was merged
+// from several config files (see high_risk_params.LoadLayers), reporting the layers in its statistics
+func NewHighRiskParamsRuleWithLayers(config *HighRiskParamsConfig, layers []ConfigLayer) (Rule, error) {
+	rule, err := NewHighRiskParamsRule(config)
+	if err != nil {
+		return nil, err
+	}
+	rule.(*HighRiskParamsRule).layers = layers
+	return rule, nil
+}
+
 // DataRequirements returns the data requirements for this rule
 func (r *HighRiskParamsRule) DataRequirements() DataSourceRequirement {
 	// Determine which components are needed based on config
@@ -109,10 +169,14 @@ func (r *HighRiskParamsRule) DataRequirements() DataSourceRequirement {
 
 	return DataSourceRequirement{
 		SourceClusterRequirements: struct {
-			Components          []string `json:"components"`
-			NeedConfig          bool     `json:"need_config"`
-			NeedSystemVariables bool     `json:"need_system_variables"`
-			NeedAllTikvNodes    bool     `json:"need_all_tikv_nodes"`
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
 		}{
 			Components:          components,
 			NeedConfig:          true,
@@ -185,9 +249,30 @@ func (r *HighRiskParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleContext)
 		results = append(results, tiflashResults...)
 	}
 
+	if len(r.layers) > 0 {
+		results = append(results, NewStatisticsResult(r, RuleStatistics{
+			ParametersExamined: r.entryCount(),
+			ConfigLayers:       r.layers,
+		}))
+	}
 	return results, nil
 }
 
+// entryCount returns the number of high-risk parameters checked by the rule
+func (r *HighRiskParamsRule) entryCount() int {
+	count := 0
+	for _, params := range []map[string]HighRiskParamConfig{
+		r.config.TiDB.Config, r.config.TiDB.SystemVariables, r.config.PD.Config, r.config.TiKV.Config, r.config.TiFlash.Config,
+	} {
+		for _, param := range params {
+			if !param.Disabled {
+				count++
+			}
+		}
+	}
+	return count
+}
+
 // checkComponent checks high-risk parameters for a specific component
 func (r *HighRiskParamsRule) checkComponent(
 	ruleCtx *RuleContext,
@@ -198,38 +283,19 @@ func (r *HighRiskParamsRule) checkComponent(
 	var results []CheckResult
 
 	// Find the component in cluster snapshot
-	var component *collector.ComponentState
-	var compName string
-
-	for name, comp := range ruleCtx.SourceClusterSnapshot.Components {
-		if string(comp.Type) == compType {
-			component = &comp
-			compName = name
-			break
-		}
-		// Also check by name prefix for TiKV/TiFlash nodes
-		if (compType == "tikv" && strings.HasPrefix(name, "tikv")) ||
-			(compType == "tiflash" && strings.HasPrefix(name, "tiflash")) {
-			if component == nil {
-				component = &comp
-				compName = name
-			}
-		}
-	}
-
-	if component == nil {
+	// For TiKV, only the first instance is checked to avoid duplicates
+	component, ok := ruleCtx.SourceClusterSnapshot.ComponentByType(collector.ComponentType(compType))
+	if !ok {
 		// Component not found, skip
 		return results
 	}
 
-	// For TiKV, only check the first instance to avoid duplicates
-	if compType == "tikv" && compName != "tikv" && !strings.HasPrefix(compName, "tikv-") {
-		return results
-	}
-
 	// Check config parameters
 	for paramName, paramConfig := range configParams {
-		// Convert ConfigDefaults to map for checkParameter
+		if paramConfig.Disabled {
+			continue
+		}
+		// Convert the config ParameterMap to map for checkParameter
 		configMap := make(map[string]interface{})
 		if paramValue, ok := component.Config[paramName]; ok {
 			configMap[paramName] = map[string]interface{}{
@@ -258,7 +324,10 @@ func (r *HighRiskParamsRule) checkComponent(
 	// Check system variables (for TiDB)
 	if systemVarParams != nil && compType == "tidb" {
 		for varName, varConfig := range systemVarParams {
-			// Convert SystemVariables to map for checkParameter
+			if varConfig.Disabled {
+				continue
+			}
+			// Convert the system variables ParameterMap to map for checkParameter
 			varMap := make(map[string]interface{})
 			if varValue, ok := component.Variables[varName]; ok {
 				varMap[varName] = map[string]interface{}{
@@ -385,11 +454,16 @@ func (r *HighRiskParamsRule) checkParameter(
 		details = fmt.Sprintf("%s\nAllowed values: %v", details, paramConfig.AllowedValues)
 	}
 
-	// Determine severity (use config severity or default to warning)
-	severity := paramConfig.Severity
-	if severity == "" {
-		severity = "warning"
+	// Determine severity from the distance between the current value and the target default
+	targetLookupName := paramName
+	if paramType == "system_variable" {
+		targetLookupName = "sysvar:" + paramName
+	}
+	targetDefault := ruleCtx.GetTargetDefault(compType, targetLookupName)
+	if targetDefault != nil {
+		details = fmt.Sprintf("%s\nTarget default: %v", details, targetDefault)
 	}
+	severity := r.computeSeverity(paramConfig, currentValue, targetDefault)
 
 	return &CheckResult{
 		RuleID:        r.Name(),
@@ -401,6 +475,7 @@ func (r *HighRiskParamsRule) checkParameter(
 		Message:       message,
 		Details:       details,
 		CurrentValue:  currentValue,
+		TargetDefault: targetDefault,
 		Suggestions: []string{
 			"Review this high-risk parameter and its current value",
 			"Ensure the value is appropriate for your workload",
@@ -417,6 +492,51 @@ func (r *HighRiskParamsRule) checkParameter(
 	}
 }
 
+// computeSeverity determines the severity of a high-risk parameter from how far its current value is from the target default
+//   - critical: the value is one of UnsafeValues, or a numeric value at least CriticalDistance away from the target default
+//   - info: the value equals the target default, or a numeric value within Tolerance of it
+//   - otherwise the configured severity (warning if not set): the value differs from the recommendation
+//
+// Booleans and strings only use exact matching. If the target default is unknown, the configured severity is used
+func (r *HighRiskParamsRule) computeSeverity(paramDef HighRiskParamConfig, currentVal, targetDefault interface{}) string {
+	severity := paramDef.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+
+	for _, unsafeValue := range paramDef.UnsafeValues {
+		if CompareValues(currentVal, unsafeValue) {
+			return "critical"
+		}
+	}
+
+	if targetDefault == nil {
+		return severity
+	}
+	if CompareValues(currentVal, targetDefault) {
+		return "info"
+	}
+
+	current, currentOK := ToNumeric(currentVal)
+	target, targetOK := ToNumeric(targetDefault)
+	if !currentOK || !targetOK {
+		return severity
+	}
+
+	// Relative distance from the target default, e.g. 128MB vs 1GB is 0.875
+	distance := math.Abs(current - target)
+	if target != 0 {
+		distance /= math.Abs(target)
+	}
+	if paramDef.CriticalDistance > 0 && distance >= paramDef.CriticalDistance {
+		return "critical"
+	}
+	if distance <= paramDef.Tolerance {
+		return "info"
+	}
+	return severity
+}
+
 // isVersionApplicableForUpgrade checks if the parameter configuration is applicable for the upgrade path
 // Returns true if the upgrade path (sourceVersion -> targetVersion) overlaps with the configured version range (fromVersion -> toVersion)
 //
@@ -183,7 +183,7 @@ func TestHighRiskParamsRule_Evaluate_ModifiedConfig(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"max-connections": types.ParameterValue{Value: 2000, Type: "int"},
 					},
 				},
@@ -240,7 +240,7 @@ func TestHighRiskParamsRule_Evaluate_UnmodifiedConfig(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 					},
 				},
@@ -287,7 +287,7 @@ func TestHighRiskParamsRule_Evaluate_AllowedValues(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"max-connections": types.ParameterValue{Value: 2000, Type: "int"}, // Allowed value
 					},
 				},
@@ -334,7 +334,7 @@ func TestHighRiskParamsRule_Evaluate_NotAllowedValue(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"max-connections": types.ParameterValue{Value: 5000, Type: "int"}, // Not in allowed list
 					},
 				},
@@ -417,7 +417,7 @@ func TestHighRiskParamsRule_Evaluate_VersionRange(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tidb": {
 							Type: types.ComponentTiDB,
-							Config: types.ConfigDefaults{
+							Config: types.ParameterMap{
 								"param1": types.ParameterValue{Value: 100, Type: "int"},
 							},
 						},
@@ -470,7 +470,7 @@ func TestHighRiskParamsRule_Evaluate_SystemVariable(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Variables: types.SystemVariables{
+					Variables: types.ParameterMap{
 						"tidb_enable_async_commit": types.ParameterValue{Value: "ON", Type: "string"},
 					},
 				},
@@ -611,7 +611,7 @@ func TestHighRiskParamsRule_VersionRange(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tidb": {
 							Type: types.ComponentTiDB,
-							Config: types.ConfigDefaults{
+							Config: types.ParameterMap{
 								"param1": types.ParameterValue{Value: 100, Type: "int"},
 							},
 						},
@@ -636,3 +636,136 @@ func TestHighRiskParamsRule_VersionRange(t *testing.T) {
 		})
 	}
 }
+
+func TestHighRiskParamsRule_ComputeSeverity(t *testing.T) {
+	rule := &HighRiskParamsRule{BaseRule: NewBaseRule("HIGH_RISK_PARAMS", "Test", "high_risk"), config: &HighRiskParamsConfig{}}
+	memQuota := HighRiskParamConfig{
+		Severity:         "warning",
+		UnsafeValues:     []interface{}{0},
+		Tolerance:        0.25,
+		CriticalDistance: 0.8,
+	}
+
+	tests := []struct {
+		name          string
+		paramDef      HighRiskParamConfig
+		currentVal    interface{}
+		targetDefault interface{}
+		want          string
+	}{
+		{"numeric equal to target default", memQuota, 1073741824, 1073741824, "info"},
+		{"numeric within tolerance", memQuota, 1073741824 * 0.8, 1073741824, "info"},
+		{"numeric differs", memQuota, 536870912, 1073741824, "warning"},
+		{"numeric far from target default", memQuota, 134217728, 1073741824, "critical"},
+		{"numeric string far from target default", memQuota, "134217728", 1073741824, "critical"},
+		{"unsafe value", memQuota, 0, 1073741824, "critical"},
+		{"no critical distance configured", HighRiskParamConfig{Severity: "error"}, 1, 1000, "error"},
+		{"target default unknown", memQuota, 134217728, nil, "warning"},
+		{"boolean matches", HighRiskParamConfig{Severity: "error"}, true, true, "info"},
+		{"boolean differs", HighRiskParamConfig{Severity: "error"}, false, true, "error"},
+		{"string differs", HighRiskParamConfig{}, "pessimistic", "optimistic", "warning"},
+		{"string unsafe", HighRiskParamConfig{UnsafeValues: []interface{}{"OFF"}}, "OFF", "ON", "critical"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, rule.computeSeverity(tt.paramDef, tt.currentVal, tt.targetDefault))
+		})
+	}
+}
+
+func TestHighRiskParamsRule_Evaluate_SeverityFromTargetDefault(t *testing.T) {
+	rule, err := NewHighRiskParamsRule(&HighRiskParamsConfig{})
+	require.NoError(t, err)
+	rule.(*HighRiskParamsRule).config.TiDB.SystemVariables = map[string]HighRiskParamConfig{
+		"tidb_mem_quota_query": {Severity: "warning", Tolerance: 0.25, CriticalDistance: 0.8},
+	}
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type: types.ComponentTiDB,
+					Variables: types.ParameterMap{
+						"tidb_mem_quota_query": types.ParameterValue{Value: "134217728", Type: "int"},
+					},
+				},
+			},
+		},
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {"sysvar:tidb_mem_quota_query": 1073741824},
+		},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "critical", results[0].Severity)
+	assert.Equal(t, 1073741824, results[0].TargetDefault)
+	assert.Contains(t, results[0].Details, "Target default: 1073741824")
+}
+
+func TestLoadHighRiskParamsFromKB(t *testing.T) {
+	kbPath := t.TempDir()
+
+	// No file for the version family
+	config, err := LoadHighRiskParamsFromKB(kbPath, "v8.5.1")
+	require.NoError(t, err)
+	assert.Nil(t, config)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(kbPath, "v8.5"), 0755))
+	path := filepath.Join(kbPath, "v8.5", HighRiskParamsFile)
+	require.NoError(t, os.WriteFile(path, []byte(`{"tikv": {"config": {"storage.engine": {"severity": "error"}}}}`), 0644))
+	config, err = LoadHighRiskParamsFromKB(kbPath, "v8.5.1")
+	require.NoError(t, err)
+	require.NotNil(t, config)
+	assert.Equal(t, "error", config.TiKV.Config["storage.engine"].Severity)
+
+	// Other version families don't use it
+	config, err = LoadHighRiskParamsFromKB(kbPath, "v8.1.0")
+	require.NoError(t, err)
+	assert.Nil(t, config)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"tikv": {"config": {"storage.engine": {"severty": "error"}}}}`), 0644))
+	_, err = LoadHighRiskParamsFromKB(kbPath, "v8.5.1")
+	assert.ErrorContains(t, err, "invalid high-risk params")
+}
+
+func TestHighRiskParamsRule_Evaluate_DisabledAndLayers(t *testing.T) {
+	config := &HighRiskParamsConfig{}
+	config.TiDB.Config = map[string]HighRiskParamConfig{
+		"max-connections": {Severity: "error", Description: "Max connections is critical"},
+		"mem-quota-query": {Disabled: true},
+	}
+	layers := []ConfigLayer{{Source: "default.json", Entries: 2}, {Source: "cluster.json", Entries: 1, Disabled: 1}}
+	rule, err := NewHighRiskParamsRuleWithLayers(config, layers)
+	require.NoError(t, err)
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type: types.ComponentTiDB,
+					Config: types.ParameterMap{
+						"max-connections": types.ParameterValue{Value: 2000, Type: "int"},
+						"mem-quota-query": types.ParameterValue{Value: 2147483648, Type: "int"},
+					},
+				},
+			},
+		},
+		SourceVersion: "v7.5.0",
+	}
+
+	runner := NewRuleRunner([]Rule{rule})
+	results, err := runner.Run(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.NotEqual(t, "mem-quota-query", result.ParameterName, "disabled entries are not checked")
+	}
+	executions := runner.Executions()
+	require.Len(t, executions, 1)
+	require.NotNil(t, executions[0].Statistics)
+	assert.Equal(t, 1, executions[0].Statistics.ParametersExamined)
+	assert.Equal(t, layers, executions[0].Statistics.ConfigLayers)
+}
@@ -0,0 +1,318 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Issues reported by OperationalConflictsRule (Metadata["issue"])
+const (
+	// OperationalIssueBlockingService is an active service safepoint of a backup, restore or import task
+	OperationalIssueBlockingService = "blocking_service_safe_point"
+	// OperationalIssueStaleGCSafePoint is a GC safepoint older than the configured maximum age
+	OperationalIssueStaleGCSafePoint = "stale_gc_safe_point"
+	// OperationalIssueServiceSafePoints lists the service safepoints registered in PD
+	OperationalIssueServiceSafePoints = "service_safe_points"
+)
+
+// DefaultGCSafePointMaxAge is the default age above which the GC safepoint is reported as stale
+const DefaultGCSafePointMaxAge = 24 * time.Hour
+
+// defaultBlockingServices are the service ID prefixes of the tasks an upgrade must not run concurrently with:
+// BR backup and restore ("br-<uuid>"), log backup ("log-backup-coordinator") and TiDB Lightning imports ("lightning-<uuid>")
+var defaultBlockingServices = []string{"br", "log-backup", "lightning"}
+
+// gcWorkerServiceID is the service safepoint of TiDB's own GC worker
+const gcWorkerServiceID = "gc_worker"
+
+// OperationalConflictsConfig is the rules config section of OperationalConflictsRule
+type OperationalConflictsConfig struct {
+	// GCSafePointMaxAge is the age (Go duration, e.g., "24h") above which the GC safepoint is reported as stale
+	GCSafePointMaxAge string `json:"gc_safe_point_max_age,omitempty"`
+	// BlockingServices are the service ID prefixes of backup, restore and import tasks, replacing the defaults
+	BlockingServices []string `json:"blocking_services,omitempty"`
+}
+
+// OperationalConflictsRule reports backup, import and GC activity that should block an upgrade window
+// Rule:
+// - an active PD service safepoint of a backup, restore or import task (BR, log backup, Lightning): critical
+// - a GC safepoint (mysql.tidb tikv_gc_safe_point, or PD's if unavailable) older than the maximum age: warning
+// - the service safepoints registered in PD: info
+//
+// PD versions without the service safepoint list API only get the GC safepoint check
+type OperationalConflictsRule struct {
+	*BaseRule
+	gcSafePointMaxAge time.Duration
+	blockingServices  []string
+}
+
+// NewOperationalConflictsRule creates a new operational conflicts rule with the default thresholds
+func NewOperationalConflictsRule() Rule {
+	return &OperationalConflictsRule{
+		BaseRule: NewBaseRule(
+			"OPERATIONAL_CONFLICTS",
+			"Detect running backups, imports and a stale GC safepoint that should block the upgrade window",
+			"operational_conflict",
+		),
+		gcSafePointMaxAge: DefaultGCSafePointMaxAge,
+		blockingServices:  defaultBlockingServices,
+	}
+}
+
+// Configure applies the operational_conflicts section of the rules config
+func (r *OperationalConflictsRule) Configure(config *RulesConfig) error {
+	if config == nil || config.OperationalConflicts == nil {
+		return nil
+	}
+	section := config.OperationalConflicts
+	if section.GCSafePointMaxAge != "" {
+		maxAge, err := time.ParseDuration(section.GCSafePointMaxAge)
+		if err != nil {
+			return fmt.Errorf("invalid gc_safe_point_max_age: %w", err)
+		}
+		if maxAge <= 0 {
+			return fmt.Errorf("gc_safe_point_max_age must be positive, got %s", section.GCSafePointMaxAge)
+		}
+		r.gcSafePointMaxAge = maxAge
+	}
+	if len(section.BlockingServices) > 0 {
+		r.blockingServices = section.BlockingServices
+	}
+	return nil
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *OperationalConflictsRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:       []string{"tidb", "pd"},
+			NeedGCSafePoints: true,
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{}, // This rule doesn't need knowledge base data
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{},
+		},
+	}
+}
+
+// Evaluate performs the rule check
+// Safepoints are evaluated at the time the snapshot was collected
+func (r *OperationalConflictsRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil || ruleCtx.SourceClusterSnapshot.GCSafePoints == nil {
+		return results, nil
+	}
+	state := ruleCtx.SourceClusterSnapshot.GCSafePoints
+	now := ruleCtx.SourceClusterSnapshot.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	// Service safepoints, sorted by service ID
+	safePoints := append([]defaultsTypes.ServiceSafePoint(nil), state.ServiceSafePoints...)
+	sort.Slice(safePoints, func(i, j int) bool { return safePoints[i].ServiceID < safePoints[j].ServiceID })
+
+	for _, safePoint := range safePoints {
+		if safePoint.Active(now) && r.isBlockingService(safePoint.ServiceID) {
+			results = append(results, r.blockingServiceResult(safePoint))
+		}
+	}
+
+	if result, ok := r.staleGCSafePointResult(state, safePoints, now); ok {
+		results = append(results, result)
+	}
+
+	results = append(results, r.serviceSafePointsResult(state, safePoints, now))
+	return results, nil
+}
+
+// isBlockingService checks if a service ID belongs to a backup, restore or import task
+// A prefix matches the whole ID or the part before a "-" or "_" separator
+func (r *OperationalConflictsRule) isBlockingService(serviceID string) bool {
+	id := strings.ToLower(serviceID)
+	for _, prefix := range r.blockingServices {
+		prefix = strings.ToLower(prefix)
+		if id == prefix || strings.HasPrefix(id, prefix+"-") || strings.HasPrefix(id, prefix+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// newResult creates a result of the rule
+func (r *OperationalConflictsRule) newResult(component, name, issue, severity string, riskLevel RiskLevel, message, details string, suggestions []string) CheckResult {
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     component,
+		ParameterName: name,
+		ParamType:     "gc_safe_point",
+		Description:   r.Description(),
+		Severity:      severity,
+		RiskLevel:     riskLevel,
+		Message:       message,
+		Details:       details,
+		Suggestions:   suggestions,
+		Metadata:      map[string]interface{}{"issue": issue},
+	}
+}
+
+// blockingServiceResult reports an active service safepoint of a backup, restore or import task
+func (r *OperationalConflictsRule) blockingServiceResult(safePoint defaultsTypes.ServiceSafePoint) CheckResult {
+	result := r.newResult("pd", safePoint.ServiceID, OperationalIssueBlockingService, "critical", RiskLevelHigh,
+		fmt.Sprintf("A backup, restore or import task holds the service GC safepoint %s", safePoint.ServiceID),
+		fmt.Sprintf("Service safepoint: %s\nExpires: %s\n\n"+
+			"Upgrading restarts TiDB, TiKV and PD while the task runs: the task fails or leaves a partial backup or import, "+
+			"and the upgrade bootstrap may conflict with the data being restored or imported.",
+			formatSafePointTime(safePoint.SafePoint), formatExpiry(safePoint.ExpiredAt)),
+		[]string{
+			"Wait for the backup, restore or import task to finish before starting the upgrade",
+			"If the task was aborted, remove its service safepoint (e.g., with tikv-ctl or by letting it expire) and check again",
+		})
+	result.Metadata["service_id"] = safePoint.ServiceID
+	result.Metadata["expired_at"] = safePoint.ExpiredAt
+	return result
+}
+
+// staleGCSafePointResult reports a GC safepoint older than the maximum age
+// The safepoint of mysql.tidb is used, or PD's if mysql.tidb could not be read
+func (r *OperationalConflictsRule) staleGCSafePointResult(state *defaultsTypes.GCSafePointState, safePoints []defaultsTypes.ServiceSafePoint, now time.Time) (CheckResult, bool) {
+	var gcSafePoint time.Time
+	source := "mysql.tidb tikv_gc_safe_point"
+	if state.GCSafePoint != "" {
+		parsed, err := defaultsTypes.ParseGCTime(state.GCSafePoint)
+		if err == nil {
+			gcSafePoint = parsed
+		}
+	}
+	if gcSafePoint.IsZero() && state.PDGCSafePoint > 0 {
+		gcSafePoint = defaultsTypes.TSOPhysicalTime(state.PDGCSafePoint)
+		source = "PD GC safepoint"
+	}
+	if gcSafePoint.IsZero() {
+		return CheckResult{}, false
+	}
+
+	age := now.Sub(gcSafePoint)
+	if age <= r.gcSafePointMaxAge {
+		return CheckResult{}, false
+	}
+
+	details := fmt.Sprintf("GC safepoint (%s): %s, %s old (threshold: %s)",
+		source, gcSafePoint.Format(time.RFC3339), age.Round(time.Minute), r.gcSafePointMaxAge)
+	if state.GCLastRunTime != "" {
+		details += fmt.Sprintf("\nLast GC run: %s", state.GCLastRunTime)
+	}
+	if holder, ok := oldestServiceSafePoint(safePoints, now); ok {
+		details += fmt.Sprintf("\nOldest active service safepoint: %s (%s), it may be holding GC back", holder.ServiceID, formatSafePointTime(holder.SafePoint))
+	}
+	details += "\n\nGC has not advanced for a long time: MVCC versions pile up, and the upgrade (which restarts TiKV and runs DDL in the bootstrap) " +
+		"may be slow or time out. A GC safepoint held back by a task also means that task may still be running."
+
+	result := r.newResult("tidb", "tikv_gc_safe_point", OperationalIssueStaleGCSafePoint, "warning", RiskLevelMedium,
+		fmt.Sprintf("The GC safepoint is %s old, more than %s", age.Round(time.Minute), r.gcSafePointMaxAge),
+		details,
+		[]string{
+			"Check that GC is enabled (tidb_gc_enable) and the GC worker runs (tikv_gc_last_run_time in mysql.tidb)",
+			"Find the service safepoint holding GC back (pd-ctl service-gc-safepoint) and finish or remove the task that registered it",
+		})
+	result.CurrentValue = gcSafePoint.Format(time.RFC3339)
+	result.Metadata["age_seconds"] = int64(age.Seconds())
+	result.Metadata["max_age_seconds"] = int64(r.gcSafePointMaxAge.Seconds())
+	result.Metadata["source"] = source
+	return result, true
+}
+
+// serviceSafePointsResult lists the service safepoints registered in PD, or notes that PD doesn't list them
+func (r *OperationalConflictsRule) serviceSafePointsResult(state *defaultsTypes.GCSafePointState, safePoints []defaultsTypes.ServiceSafePoint, now time.Time) CheckResult {
+	if !state.ServiceSafePointsAvailable {
+		return r.newResult("pd", "service_gc_safe_points", OperationalIssueServiceSafePoints, "info", RiskLevelLow,
+			"The service GC safepoints could not be read from PD, running backups and imports were not checked",
+			"PD does not provide the service GC safepoint list (/pd/api/v1/gc/safepoint) or it could not be reached. "+
+				"Only the GC safepoint stored in mysql.tidb was checked.",
+			[]string{
+				"Make sure no BR backup or restore and no TiDB Lightning import is running before starting the upgrade",
+			})
+	}
+
+	var lines []string
+	ids := make([]string, 0, len(safePoints))
+	for _, safePoint := range safePoints {
+		state := "active"
+		if !safePoint.Active(now) {
+			state = "expired"
+		}
+		lines = append(lines, fmt.Sprintf("- %s: safepoint %s, expires %s (%s)",
+			safePoint.ServiceID, formatSafePointTime(safePoint.SafePoint), formatExpiry(safePoint.ExpiredAt), state))
+		ids = append(ids, safePoint.ServiceID)
+	}
+	details := "No service GC safepoint is registered in PD."
+	if len(lines) > 0 {
+		details = "Service GC safepoints registered in PD:\n" + strings.Join(lines, "\n")
+	}
+
+	result := r.newResult("pd", "service_gc_safe_points", OperationalIssueServiceSafePoints, "info", RiskLevelLow,
+		fmt.Sprintf("%d service GC safepoint(s) registered in PD", len(safePoints)),
+		details,
+		[]string{
+			"Review the services holding a safepoint (TiCDC changefeeds, backups, imports) before starting the upgrade",
+		})
+	result.Metadata["service_ids"] = ids
+	return result
+}
+
+// oldestServiceSafePoint returns the active service safepoint with the lowest safepoint, TiDB's GC worker excluded
+func oldestServiceSafePoint(safePoints []defaultsTypes.ServiceSafePoint, now time.Time) (defaultsTypes.ServiceSafePoint, bool) {
+	var oldest defaultsTypes.ServiceSafePoint
+	found := false
+	for _, safePoint := range safePoints {
+		if safePoint.ServiceID == gcWorkerServiceID || !safePoint.Active(now) {
+			continue
+		}
+		if !found || safePoint.SafePoint < oldest.SafePoint {
+			oldest, found = safePoint, true
+		}
+	}
+	return oldest, found
+}
+
+// formatSafePointTime formats the physical time of a safepoint TSO
+func formatSafePointTime(tso uint64) string {
+	return defaultsTypes.TSOPhysicalTime(tso).UTC().Format(time.RFC3339)
+}
+
+// formatExpiry formats the expiry of a service safepoint (Unix seconds, math.MaxInt64 if it never expires)
+func formatExpiry(expiredAt int64) string {
+	if expiredAt == math.MaxInt64 {
+		return "never"
+	}
+	return time.Unix(expiredAt, 0).UTC().Format(time.RFC3339)
+}
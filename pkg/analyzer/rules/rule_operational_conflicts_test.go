@@ -0,0 +1,126 @@
+package rules
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// snapshotTime is the collection time of the operational conflicts test snapshots
+var snapshotTime = time.Date(2024, 1, 2, 12, 0, 0, 0, time.UTC)
+
+// tsoAt returns the TSO of a physical time
+func tsoAt(t time.Time) uint64 {
+	return uint64(t.UnixMilli()) << 18
+}
+
+func newGCSafePointsSnapshot(state *defaultsTypes.GCSafePointState) *collector.ClusterSnapshot {
+	return &collector.ClusterSnapshot{
+		Timestamp:    snapshotTime,
+		Components:   map[string]collector.ComponentState{},
+		GCSafePoints: state,
+	}
+}
+
+func TestOperationalConflictsRule_Evaluate(t *testing.T) {
+	snapshot := newGCSafePointsSnapshot(&defaultsTypes.GCSafePointState{
+		GCSafePoint:                "20240101-08:00:00.000 +0000", // 28h old
+		GCLastRunTime:              "20240102-11:50:00.000 +0000",
+		ServiceSafePointsAvailable: true,
+		ServiceSafePoints: []defaultsTypes.ServiceSafePoint{
+			{ServiceID: "gc_worker", ExpiredAt: math.MaxInt64, SafePoint: tsoAt(snapshotTime.Add(-28 * time.Hour))},
+			{ServiceID: "br-5f3c", ExpiredAt: snapshotTime.Add(time.Hour).Unix(), SafePoint: tsoAt(snapshotTime.Add(-30 * time.Hour))},
+			{ServiceID: "lightning-9a1b", ExpiredAt: snapshotTime.Add(-time.Hour).Unix(), SafePoint: tsoAt(snapshotTime.Add(-2 * time.Hour))}, // Expired
+			{ServiceID: "ticdc-default-changefeed", ExpiredAt: snapshotTime.Add(time.Hour).Unix(), SafePoint: tsoAt(snapshotTime)},
+		},
+	})
+
+	results, err := NewOperationalConflictsRule().Evaluate(context.Background(), &RuleContext{SourceClusterSnapshot: snapshot})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, "br-5f3c", results[0].ParameterName)
+	assert.Equal(t, OperationalIssueBlockingService, results[0].Metadata["issue"])
+	assert.Equal(t, "critical", results[0].Severity)
+	assert.Equal(t, "pd", results[0].Component)
+
+	assert.Equal(t, "tikv_gc_safe_point", results[1].ParameterName)
+	assert.Equal(t, OperationalIssueStaleGCSafePoint, results[1].Metadata["issue"])
+	assert.Equal(t, "warning", results[1].Severity)
+	assert.Equal(t, int64(28*3600), results[1].Metadata["age_seconds"])
+	assert.Equal(t, "mysql.tidb tikv_gc_safe_point", results[1].Metadata["source"])
+	assert.Contains(t, results[1].Details, "Oldest active service safepoint: br-5f3c")
+
+	assert.Equal(t, "service_gc_safe_points", results[2].ParameterName)
+	assert.Equal(t, "info", results[2].Severity)
+	assert.Equal(t, []string{"br-5f3c", "gc_worker", "lightning-9a1b", "ticdc-default-changefeed"}, results[2].Metadata["service_ids"])
+	assert.Contains(t, results[2].Details, "lightning-9a1b")
+	assert.Contains(t, results[2].Details, "(expired)")
+
+	for _, result := range results {
+		assert.Equal(t, "OPERATIONAL_CONFLICTS", result.RuleID)
+		assert.Equal(t, "operational_conflict", result.Category)
+	}
+}
+
+func TestOperationalConflictsRule_PDFallback(t *testing.T) {
+	// mysql.tidb not readable, older PD without the service safepoint list
+	snapshot := newGCSafePointsSnapshot(&defaultsTypes.GCSafePointState{
+		PDGCSafePoint: tsoAt(snapshotTime.Add(-48 * time.Hour)),
+	})
+
+	results, err := NewOperationalConflictsRule().Evaluate(context.Background(), &RuleContext{SourceClusterSnapshot: snapshot})
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, OperationalIssueStaleGCSafePoint, results[0].Metadata["issue"])
+	assert.Equal(t, "PD GC safepoint", results[0].Metadata["source"])
+	assert.Equal(t, OperationalIssueServiceSafePoints, results[1].Metadata["issue"])
+	assert.Contains(t, results[1].Message, "could not be read from PD")
+}
+
+func TestOperationalConflictsRule_NotCollected(t *testing.T) {
+	rule := NewOperationalConflictsRule()
+	assert.True(t, rule.DataRequirements().SourceClusterRequirements.NeedGCSafePoints)
+
+	results, err := rule.Evaluate(context.Background(), &RuleContext{SourceClusterSnapshot: newGCSafePointsSnapshot(nil)})
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestOperationalConflictsRule_Configure(t *testing.T) {
+	config, err := ParseRulesConfig([]byte(`{"operational_conflicts": {"gc_safe_point_max_age": "72h", "blocking_services": ["dumpling"]}}`))
+	require.NoError(t, err)
+
+	rule := NewOperationalConflictsRule()
+	require.NoError(t, ApplyRulesConfig([]Rule{rule}, config))
+
+	snapshot := newGCSafePointsSnapshot(&defaultsTypes.GCSafePointState{
+		GCSafePoint:                "20240101-08:00:00.000 +0000",
+		ServiceSafePointsAvailable: true,
+		ServiceSafePoints: []defaultsTypes.ServiceSafePoint{
+			{ServiceID: "br-5f3c", ExpiredAt: math.MaxInt64, SafePoint: tsoAt(snapshotTime)},
+			{ServiceID: "dumpling_export", ExpiredAt: math.MaxInt64, SafePoint: tsoAt(snapshotTime)},
+		},
+	})
+	results, err := rule.Evaluate(context.Background(), &RuleContext{SourceClusterSnapshot: snapshot})
+	require.NoError(t, err)
+	// The blocking services replace the defaults, and a 28h old safepoint is below the threshold
+	require.Len(t, results, 2)
+	assert.Equal(t, "dumpling_export", results[0].ParameterName)
+	assert.Equal(t, OperationalIssueServiceSafePoints, results[1].Metadata["issue"])
+
+	_, err = ParseRulesConfig([]byte(`{"operational_conflicts": {"gc_safepoint_max_age": "72h"}}`))
+	assert.Error(t, err)
+
+	for _, maxAge := range []string{"3 days", "-1h"} {
+		config := &RulesConfig{OperationalConflicts: &OperationalConflictsConfig{GCSafePointMaxAge: maxAge}}
+		err := ApplyRulesConfig([]Rule{NewOperationalConflictsRule()}, config)
+		assert.ErrorContains(t, err, "OPERATIONAL_CONFLICTS")
+	}
+}
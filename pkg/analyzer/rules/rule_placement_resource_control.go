@@ -0,0 +1,273 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Issues reported by PlacementResourceControlRule (Metadata["issue"])
+const (
+	// PlacementIssueRUAccounting is a resource group with an RU quota on an upgrade crossing an RU accounting change
+	PlacementIssueRUAccounting = "ru_accounting_change"
+	// PlacementIssueMissingLabel is a placement policy referencing store labels no store has
+	PlacementIssueMissingLabel = "missing_store_label"
+	// PlacementIssueIncomplete notes the resource control and placement data that could not be collected
+	PlacementIssueIncomplete = "incomplete_data"
+)
+
+// regionLabelKey is the store label matched by the PRIMARY_REGION and REGIONS options of placement policies
+const regionLabelKey = "region"
+
+// requiredConstraint matches the "+key=value" constraints of placement policies, in both the list
+// ("[+disk=ssd,+zone=z1]") and the dictionary ("{\"+zone=z1\": 1}") forms
+// "-key=value" constraints only exclude stores and don't need a matching store
+var requiredConstraint = regexp.MustCompile(`\+\s*([^=,"\s\[\]{}]+)\s*=\s*([^,"\s\[\]{}:]+)`)
+
+// PlacementResourceControlRule reviews the resource groups and placement policies of the cluster
+// Rule:
+// - a resource group with an RU_PER_SEC quota, when the upgrade crosses a version of
+// knowledge/resource_control_changes.json (RU accounting changed): warning
+// - a placement policy referencing store labels (constraints, PRIMARY_REGION, REGIONS) no PD store has: warning
+// - resource groups, placement policies or store labels that could not be collected: info
+type PlacementResourceControlRule struct {
+	*BaseRule
+}
+
+// NewPlacementResourceControlRule creates a new placement and resource control rule
+func NewPlacementResourceControlRule() Rule {
+	return &PlacementResourceControlRule{
+		BaseRule: NewBaseRule(
+			"PLACEMENT_RESOURCE_CONTROL",
+			"Review resource groups affected by RU accounting changes and placement policies referencing missing store labels",
+			"placement",
+		),
+	}
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *PlacementResourceControlRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:    []string{"tidb", "pd"},
+			NeedPlacement: true,
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{}, // resource_control_changes is a global knowledge file
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{},
+		},
+	}
+}
+
+// Evaluate performs the rule check
+func (r *PlacementResourceControlRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil || ruleCtx.SourceClusterSnapshot.Placement == nil {
+		return results, nil
+	}
+	state := ruleCtx.SourceClusterSnapshot.Placement
+
+	changes := crossedResourceControlChanges(ruleCtx.ResourceControlChanges, ruleCtx.SourceVersion, ruleCtx.TargetVersion)
+	if len(changes) > 0 {
+		for _, group := range state.ResourceGroups {
+			if hasRUQuota(group) {
+				results = append(results, r.ruAccountingResult(group, changes))
+			}
+		}
+	}
+
+	if state.StoresAvailable {
+		labels := storeLabelValues(state.Stores)
+		for _, policy := range state.PlacementPolicies {
+			if missing := missingPolicyLabels(policy, labels); len(missing) > 0 {
+				results = append(results, r.missingLabelResult(policy, missing))
+			}
+		}
+	}
+
+	if len(state.Notes) > 0 {
+		results = append(results, r.incompleteResult(state.Notes))
+	}
+	return results, nil
+}
+
+// newResult creates a result of the rule
+func (r *PlacementResourceControlRule) newResult(name, paramType, issue, severity string, riskLevel RiskLevel, message, details string, suggestions []string) CheckResult {
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     "tidb",
+		ParameterName: name,
+		ParamType:     paramType,
+		Description:   r.Description(),
+		Severity:      severity,
+		RiskLevel:     riskLevel,
+		Message:       message,
+		Details:       details,
+		Suggestions:   suggestions,
+		Metadata:      map[string]interface{}{"issue": issue},
+	}
+}
+
+// ruAccountingResult reports a resource group whose RU quota may not fit the RU accounting of the target version
+func (r *PlacementResourceControlRule) ruAccountingResult(group defaultsTypes.ResourceGroup, changes []ResourceControlChange) CheckResult {
+	var lines, versions, suggestions []string
+	seen := make(map[string]bool)
+	for _, change := range changes {
+		lines = append(lines, fmt.Sprintf("- %s: %s", change.Version, change.Description))
+		versions = append(versions, change.Version)
+		for _, suggestion := range change.Suggestions {
+			if !seen[suggestion] {
+				seen[suggestion] = true
+				suggestions = append(suggestions, suggestion)
+			}
+		}
+	}
+	details := fmt.Sprintf("Resource group %s: RU_PER_SEC=%s, PRIORITY=%s, BURSTABLE=%s\n\n"+
+		"The upgrade crosses versions where the accounting of request units changed:\n%s\n\n"+
+		"The quota was sized for the current accounting: the same workload may consume a different number of RUs "+
+		"after the upgrade and be throttled, or no longer be limited as intended.",
+		group.Name, group.RUPerSec, valueOrDash(group.Priority), valueOrDash(group.Burstable), strings.Join(lines, "\n"))
+
+	result := r.newResult(group.Name, "resource_group", PlacementIssueRUAccounting, "warning", RiskLevelMedium,
+		fmt.Sprintf("Resource group %s has an RU quota (%s RU/s) and the RU accounting changes in %s",
+			group.Name, group.RUPerSec, strings.Join(versions, ", ")),
+		details, suggestions)
+	result.CurrentValue = group.RUPerSec
+	result.Metadata["change_versions"] = versions
+	return result
+}
+
+// missingLabelResult reports a placement policy referencing store labels no store has
+func (r *PlacementResourceControlRule) missingLabelResult(policy defaultsTypes.PlacementPolicy, missing []string) CheckResult {
+	result := r.newResult(policy.Name, "placement_policy", PlacementIssueMissingLabel, "warning", RiskLevelMedium,
+		fmt.Sprintf("Placement policy %s references store labels no store has: %s", policy.Name, strings.Join(missing, ", ")),
+		fmt.Sprintf("Placement policy %s:\n%s\n\n"+
+			"PD cannot place the replicas of the tables using this policy on stores with these labels. "+
+			"Their regions stay unscheduled or misplaced, and the upgrade (which restarts every TiKV store) may "+
+			"leave them with fewer healthy replicas than intended.",
+			policy.Name, describePolicy(policy)),
+		[]string{
+			"Check the labels of the stores (pd-ctl store) and the location-labels of PD against the policy",
+			fmt.Sprintf("Fix the store labels, or the policy with ALTER PLACEMENT POLICY %s, before upgrading", policy.Name),
+		})
+	result.Metadata["missing_labels"] = missing
+	return result
+}
+
+// incompleteResult notes the resource control and placement data that could not be collected
+func (r *PlacementResourceControlRule) incompleteResult(notes []string) CheckResult {
+	return r.newResult("placement", "placement", PlacementIssueIncomplete, "info", RiskLevelLow,
+		"Resource groups, placement policies or store labels could not all be collected, they were only partly checked",
+		"- "+strings.Join(notes, "\n- "),
+		[]string{
+			"Grant the precheck user the privileges to read information_schema.resource_groups and information_schema.placement_policies",
+			"Otherwise review the resource groups (SELECT * FROM information_schema.resource_groups) and placement policies (SHOW PLACEMENT) manually",
+		})
+}
+
+// crossedResourceControlChanges returns the RU accounting changes in the upgrade range (source, target]
+func crossedResourceControlChanges(changes []ResourceControlChange, sourceVersion, targetVersion string) []ResourceControlChange {
+	var crossed []ResourceControlChange
+	for _, change := range changes {
+		if isVersionInRange(change.Version, sourceVersion, targetVersion) {
+			crossed = append(crossed, change)
+		}
+	}
+	return crossed
+}
+
+// hasRUQuota checks if a resource group limits its RU rate
+func hasRUQuota(group defaultsTypes.ResourceGroup) bool {
+	ruPerSec := strings.TrimSpace(group.RUPerSec)
+	return ruPerSec != "" && !strings.EqualFold(ruPerSec, "UNLIMITED")
+}
+
+// storeLabelValues returns the label key=value pairs of the stores
+func storeLabelValues(stores []defaultsTypes.StoreLabels) map[string]bool {
+	labels := make(map[string]bool)
+	for _, store := range stores {
+		for key, value := range store.Labels {
+			labels[key+"="+value] = true
+		}
+	}
+	return labels
+}
+
+// missingPolicyLabels returns the labels (key=value, sorted) a placement policy requires that no store has
+func missingPolicyLabels(policy defaultsTypes.PlacementPolicy, labels map[string]bool) []string {
+	required := make(map[string]bool)
+	for _, constraints := range []string{policy.Constraints, policy.LeaderConstraints, policy.FollowerConstraints, policy.LearnerConstraints} {
+		for _, match := range requiredConstraint.FindAllStringSubmatch(constraints, -1) {
+			required[match[1]+"="+match[2]] = true
+		}
+	}
+	for _, region := range append([]string{policy.PrimaryRegion}, strings.Split(policy.Regions, ",")...) {
+		if region = strings.TrimSpace(region); region != "" {
+			required[regionLabelKey+"="+region] = true
+		}
+	}
+
+	var missing []string
+	for label := range required {
+		if !labels[label] {
+			missing = append(missing, label)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// describePolicy lists the placement options of a policy that are set
+func describePolicy(policy defaultsTypes.PlacementPolicy) string {
+	options := []struct{ name, value string }{
+		{"PRIMARY_REGION", policy.PrimaryRegion},
+		{"REGIONS", policy.Regions},
+		{"CONSTRAINTS", policy.Constraints},
+		{"LEADER_CONSTRAINTS", policy.LeaderConstraints},
+		{"FOLLOWER_CONSTRAINTS", policy.FollowerConstraints},
+		{"LEARNER_CONSTRAINTS", policy.LearnerConstraints},
+		{"SCHEDULE", policy.Schedule},
+	}
+	var lines []string
+	for _, option := range options {
+		if option.value != "" {
+			lines = append(lines, fmt.Sprintf("- %s=%s", option.name, option.value))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// valueOrDash returns value, or "-" if it is empty
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,117 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testResourceControlChanges are RU accounting changes in v7.1.0 and v7.4.0
+var testResourceControlChanges = []ResourceControlChange{
+	{Version: "v7.1.0", Description: "RU model recalibrated", Suggestions: []string{"Run CALIBRATE RESOURCE"}},
+	{Version: "v7.4.0", Description: "TiFlash requests accounted", Suggestions: []string{"Run CALIBRATE RESOURCE", "Raise the quota"}},
+}
+
+func newPlacementRuleContext(state *defaultsTypes.PlacementState, sourceVersion, targetVersion string) *RuleContext {
+	return &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{},
+			Placement:  state,
+		},
+		SourceVersion:          sourceVersion,
+		TargetVersion:          targetVersion,
+		ResourceControlChanges: testResourceControlChanges,
+	}
+}
+
+func TestPlacementResourceControlRule_Evaluate(t *testing.T) {
+	state := &defaultsTypes.PlacementState{
+		ResourceGroupsAvailable: true,
+		ResourceGroups: []defaultsTypes.ResourceGroup{
+			{Name: "default", RUPerSec: "UNLIMITED", Priority: "MEDIUM", Burstable: "YES"},
+			{Name: "rg_oltp", RUPerSec: "2000", Priority: "HIGH", Burstable: "NO"},
+		},
+		PlacementPoliciesAvailable: true,
+		PlacementPolicies: []defaultsTypes.PlacementPolicy{
+			{Name: "p_regions", PrimaryRegion: "us-east-1", Regions: "us-east-1, us-west-1"},
+			{Name: "p_ssd", Constraints: "[+disk=ssd,-zone=z3]", LeaderConstraints: `{"+zone=z1": 1}`},
+			{Name: "p_ok", Constraints: "[+zone=z1]"},
+		},
+		StoresAvailable: true,
+		Stores: []defaultsTypes.StoreLabels{
+			{ID: 1, Address: "10.0.1.1:20160", Labels: map[string]string{"region": "us-east-1", "zone": "z1"}},
+			{ID: 2, Address: "10.0.1.2:20160", Labels: map[string]string{"region": "us-east-1", "zone": "z2"}},
+		},
+	}
+
+	results, err := NewPlacementResourceControlRule().Evaluate(context.Background(), newPlacementRuleContext(state, "v7.1.0", "v8.5.0"))
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	// Only the v7.4.0 change is crossed, the unlimited default group is not reported
+	assert.Equal(t, "rg_oltp", results[0].ParameterName)
+	assert.Equal(t, PlacementIssueRUAccounting, results[0].Metadata["issue"])
+	assert.Equal(t, []string{"v7.4.0"}, results[0].Metadata["change_versions"])
+	assert.Equal(t, "warning", results[0].Severity)
+	assert.Equal(t, []string{"Run CALIBRATE RESOURCE", "Raise the quota"}, results[0].Suggestions)
+
+	assert.Equal(t, "p_regions", results[1].ParameterName)
+	assert.Equal(t, PlacementIssueMissingLabel, results[1].Metadata["issue"])
+	assert.Equal(t, []string{"region=us-west-1"}, results[1].Metadata["missing_labels"])
+
+	// Exclusion constraints don't need a matching store
+	assert.Equal(t, "p_ssd", results[2].ParameterName)
+	assert.Equal(t, []string{"disk=ssd"}, results[2].Metadata["missing_labels"])
+}
+
+func TestPlacementResourceControlRule_Evaluate_NoChangeCrossed(t *testing.T) {
+	state := &defaultsTypes.PlacementState{
+		ResourceGroupsAvailable: true,
+		ResourceGroups:          []defaultsTypes.ResourceGroup{{Name: "rg_oltp", RUPerSec: "2000"}},
+	}
+	results, err := NewPlacementResourceControlRule().Evaluate(context.Background(), newPlacementRuleContext(state, "v7.5.0", "v8.5.0"))
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestPlacementResourceControlRule_Evaluate_Incomplete(t *testing.T) {
+	// Without store labels, placement policies are not checked and the notes are reported
+	state := &defaultsTypes.PlacementState{
+		PlacementPoliciesAvailable: true,
+		PlacementPolicies:          []defaultsTypes.PlacementPolicy{{Name: "p_ssd", Constraints: "[+disk=ssd]"}},
+		Notes: []string{
+			"resource_groups skipped, not supported by TiDB v6.5.0",
+			"store labels are not collected: connection refused",
+		},
+	}
+	results, err := NewPlacementResourceControlRule().Evaluate(context.Background(), newPlacementRuleContext(state, "v6.5.0", "v8.5.0"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, PlacementIssueIncomplete, results[0].Metadata["issue"])
+	assert.Equal(t, "info", results[0].Severity)
+	assert.Contains(t, results[0].Details, "not supported by TiDB v6.5.0")
+
+	// Nothing is reported if the data was not collected
+	results, err = NewPlacementResourceControlRule().Evaluate(context.Background(), newPlacementRuleContext(nil, "v6.5.0", "v8.5.0"))
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestParseResourceControlChanges(t *testing.T) {
+	changes, err := ParseResourceControlChanges(map[string]interface{}{
+		"ru_accounting_changes": []interface{}{
+			map[string]interface{}{"version": "v7.4.0", "description": "TiFlash requests accounted"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []ResourceControlChange{{Version: "v7.4.0", Description: "TiFlash requests accounted"}}, changes)
+
+	_, err = ParseResourceControlChanges(map[string]interface{}{
+		"ru_accounting_changes": []interface{}{map[string]interface{}{"description": "no version"}},
+	})
+	assert.ErrorContains(t, err, "has no version")
+}
@@ -0,0 +1,289 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// DeprecatedStartupFlag is a command-line flag deprecated or removed in some version of a component
+// Loaded from knowledge/deprecated_startup_flags.json (global, version-agnostic)
+// Example: {"component": "tidb", "flag": "enable-binlog", "deprecated_in": "v7.5.0", "removed_in": "v8.4.0"}
+type DeprecatedStartupFlag struct {
+	// Component is the component of the flag (tidb, pd, tikv or tiflash)
+	Component string `json:"component"`
+	// Flag is the flag name without dashes (e.g., "enable-binlog")
+	Flag string `json:"flag"`
+	// DeprecatedIn is the first version the flag is deprecated in, the flag still works
+	DeprecatedIn string `json:"deprecated_in,omitempty"`
+	// RemovedIn is the first version that no longer supports the flag
+	RemovedIn string `json:"removed_in,omitempty"`
+	// Replacement tells what to use instead (e.g., a config item or another component)
+	Replacement string `json:"replacement,omitempty"`
+	// Explanation tells what the flag did and what changed
+	Explanation string `json:"explanation"`
+}
+
+// validate checks the deprecated flag
+func (f DeprecatedStartupFlag) validate() error {
+	switch f.Component {
+	case "tidb", "pd", "tikv", "tiflash":
+	default:
+		return fmt.Errorf("flag %s: invalid component %q (tidb, pd, tikv or tiflash)", f.Flag, f.Component)
+	}
+	if f.Flag == "" || strings.HasPrefix(f.Flag, "-") {
+		return fmt.Errorf("%s flag: flag name without dashes is required, got %q", f.Component, f.Flag)
+	}
+	if f.DeprecatedIn == "" && f.RemovedIn == "" {
+		return fmt.Errorf("%s flag %s: deprecated_in or removed_in is required", f.Component, f.Flag)
+	}
+	if f.Explanation == "" {
+		return fmt.Errorf("%s flag %s: explanation is required", f.Component, f.Flag)
+	}
+	return nil
+}
+
+// ParseDeprecatedStartupFlags converts deprecated_startup_flags loaded from the knowledge base (generic JSON map)
+// into the list of deprecated flags
+func ParseDeprecatedStartupFlags(raw interface{}) ([]DeprecatedStartupFlag, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal deprecated_startup_flags: %w", err)
+	}
+	var file struct {
+		DeprecatedStartupFlags []DeprecatedStartupFlag `json:"deprecated_startup_flags"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse deprecated_startup_flags: %w", err)
+	}
+	for _, flag := range file.DeprecatedStartupFlags {
+		if err := flag.validate(); err != nil {
+			return nil, fmt.Errorf("invalid deprecated_startup_flags: %w", err)
+		}
+	}
+	return file.DeprecatedStartupFlags, nil
+}
+
+// startupArgsEqual compares a declared and an effective flag value: lists (e.g., PD addresses) are compared
+// ignoring the order of their items, paths ignoring a trailing slash
+func startupArgsEqual(declared, effective string) bool {
+	normalize := func(value string) string {
+		items := strings.Split(value, ",")
+		for i, item := range items {
+			item = strings.TrimSpace(item)
+			if len(item) > 1 && strings.HasPrefix(item, "/") {
+				item = path.Clean(item)
+			}
+			items[i] = item
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	}
+	return normalize(declared) == normalize(effective)
+}
+
+// StartupArgsRule checks the command-line flags of the instances
+// Rule: Report a warning for each flag the topology declares with another value than the one the instance
+// runs with (flags the instance doesn't expose are skipped), and report the flags of
+// knowledge/deprecated_startup_flags.json the instances run with: an error if the target version no longer
+// accepts the flag, a warning if it is deprecated in the target version
+type StartupArgsRule struct {
+	*BaseRule
+}
+
+// NewStartupArgsRule creates a new startup args rule
+func NewStartupArgsRule() Rule {
+	return &StartupArgsRule{
+		BaseRule: NewBaseRule(
+			"STARTUP_ARGS",
+			"Compare the startup flags declared in the topology with the effective ones and detect deprecated flags",
+			"startup_args",
+		),
+	}
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *StartupArgsRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:       []string{"tidb", "pd", "tikv"},
+			NeedConfig:       true, // Startup flags are collected with the configuration
+			NeedAllTikvNodes: true, // Flags are checked on every node
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{},
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{}, // deprecated_startup_flags is a global knowledge file
+		},
+	}
+}
+
+// startupArgsInstance is an instance with its startup flags
+type startupArgsInstance struct {
+	component string
+	address   string
+	state     *defaultsTypes.ComponentState
+}
+
+// Evaluate performs the rule check
+func (r *StartupArgsRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil {
+		return results, nil
+	}
+
+	var instances []startupArgsInstance
+	for _, component := range []string{"tidb", "pd", "tikv", "tiflash"} {
+		for _, state := range ruleCtx.SourceClusterSnapshot.ComponentsByType(defaultsTypes.ComponentType(component)) {
+			address := component
+			if addr, ok := state.Status["address"].(string); ok && addr != "" {
+				address = addr
+			}
+			instances = append(instances, startupArgsInstance{component: component, address: address, state: state})
+		}
+	}
+
+	examined := 0
+	for _, instance := range instances {
+		flags := make([]string, 0, len(instance.state.DeclaredStartupArgs))
+		for flag := range instance.state.DeclaredStartupArgs {
+			flags = append(flags, flag)
+		}
+		sort.Strings(flags)
+		for _, flag := range flags {
+			effective, ok := instance.state.StartupArgs[flag]
+			if !ok {
+				continue
+			}
+			examined++
+			if declared := instance.state.DeclaredStartupArgs[flag]; !startupArgsEqual(declared, effective) {
+				results = append(results, r.newMismatchResult(instance, flag, declared, effective))
+			}
+		}
+	}
+
+	for _, deprecated := range ruleCtx.DeprecatedStartupFlags {
+		severity, riskLevel, ok := deprecatedFlagSeverity(deprecated, ruleCtx.TargetVersion)
+		if !ok {
+			continue
+		}
+		var addresses []string
+		var current interface{}
+		for _, instance := range instances {
+			if instance.component != deprecated.Component {
+				continue
+			}
+			value, ok := instance.state.StartupArgs[deprecated.Flag]
+			if !ok {
+				value, ok = instance.state.DeclaredStartupArgs[deprecated.Flag]
+			}
+			if !ok {
+				continue
+			}
+			if current == nil {
+				current = value
+			}
+			addresses = append(addresses, instance.address)
+		}
+		examined++
+		if len(addresses) > 0 {
+			results = append(results, r.newDeprecatedResult(ruleCtx, deprecated, severity, riskLevel, addresses, current))
+		}
+	}
+
+	results = append(results, NewStatisticsResult(r, RuleStatistics{ParametersExamined: examined}))
+	return results, nil
+}
+
+// deprecatedFlagSeverity returns the severity of a deprecated flag for the target version
+// Returns false if the flag is neither deprecated nor removed in the target version
+func deprecatedFlagSeverity(flag DeprecatedStartupFlag, targetVersion string) (string, RiskLevel, bool) {
+	if flag.RemovedIn != "" && (VersionRange{Min: flag.RemovedIn}).Contains(targetVersion) {
+		return "error", RiskLevelHigh, true
+	}
+	if flag.DeprecatedIn != "" && (VersionRange{Min: flag.DeprecatedIn}).Contains(targetVersion) {
+		return "warning", RiskLevelMedium, true
+	}
+	return "", "", false
+}
+
+// newMismatchResult creates the warning of a flag declared with another value than the effective one
+func (r *StartupArgsRule) newMismatchResult(instance startupArgsInstance, flag, declared, effective string) CheckResult {
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     instance.component,
+		ParameterName: flag,
+		ParamType:     "startup_flag",
+		Description:   r.Description(),
+		Severity:      "warning",
+		RiskLevel:     RiskLevelMedium,
+		Message: fmt.Sprintf("%s %s runs with --%s=%s, but the topology declares --%s=%s",
+			instance.component, instance.address, flag, effective, flag, declared),
+		Details: fmt.Sprintf("Declared in the topology: %s\nEffective on %s: %s\n\n"+
+			"The upgrade restarts the instance with the flags of the topology, so the effective value changes to the declared one",
+			declared, instance.address, effective),
+		CurrentValue: effective,
+		Suggestions: []string{
+			"Update the topology (tiup cluster edit-config) to the effective value, or confirm the declared value is the intended one before upgrading",
+		},
+		Metadata: map[string]interface{}{"address": instance.address, "declared": declared},
+	}
+}
+
+// newDeprecatedResult creates the finding of a deprecated flag the instances at addresses run with
+func (r *StartupArgsRule) newDeprecatedResult(ruleCtx *RuleContext, flag DeprecatedStartupFlag, severity string, riskLevel RiskLevel,
+	addresses []string, current interface{}) CheckResult {
+	status := fmt.Sprintf("deprecated since %s", flag.DeprecatedIn)
+	if severity == "error" {
+		status = fmt.Sprintf("removed in %s", flag.RemovedIn)
+	}
+	details := fmt.Sprintf("%s\n\nInstances started with --%s:\n- %s", flag.Explanation, flag.Flag, strings.Join(addresses, "\n- "))
+	suggestions := []string{fmt.Sprintf("Remove --%s from the startup flags of %s before upgrading", flag.Flag, flag.Component)}
+	if flag.Replacement != "" {
+		suggestions = append(suggestions, "Use instead: "+flag.Replacement)
+	}
+
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     flag.Component,
+		ParameterName: flag.Flag,
+		ParamType:     "startup_flag",
+		Description:   r.Description(),
+		Severity:      severity,
+		RiskLevel:     riskLevel,
+		Message: fmt.Sprintf("%s startup flag --%s is %s (target version %s), used by %d instance(s)",
+			flag.Component, flag.Flag, status, ruleCtx.TargetVersion, len(addresses)),
+		Details:      details,
+		CurrentValue: current,
+		Suggestions:  suggestions,
+		Metadata:     map[string]interface{}{"addresses": addresses},
+	}
+}
@@ -0,0 +1,141 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDeprecatedStartupFlags(t *testing.T) {
+	parse := func(flag map[string]interface{}) error {
+		_, err := ParseDeprecatedStartupFlags(map[string]interface{}{"deprecated_startup_flags": []interface{}{flag}})
+		return err
+	}
+	assert.NoError(t, parse(map[string]interface{}{"component": "tidb", "flag": "enable-binlog", "removed_in": "v8.4.0", "explanation": "removed"}))
+
+	tests := []struct {
+		name   string
+		flag   map[string]interface{}
+		errMsg string
+	}{
+		{"invalid component", map[string]interface{}{"component": "ticdc", "flag": "f", "removed_in": "v8.4.0", "explanation": "e"}, `invalid component "ticdc"`},
+		{"no flag", map[string]interface{}{"component": "tidb", "removed_in": "v8.4.0", "explanation": "e"}, "flag name without dashes is required"},
+		{"dashes", map[string]interface{}{"component": "tidb", "flag": "--f", "removed_in": "v8.4.0", "explanation": "e"}, "flag name without dashes is required"},
+		{"no version", map[string]interface{}{"component": "tidb", "flag": "f", "explanation": "e"}, "deprecated_in or removed_in is required"},
+		{"no explanation", map[string]interface{}{"component": "tidb", "flag": "f", "deprecated_in": "v7.5.0"}, "explanation is required"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorContains(t, parse(tt.flag), tt.errMsg)
+		})
+	}
+}
+
+func TestParseDeprecatedStartupFlags_KnowledgeFile(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "knowledge", "deprecated_startup_flags.json"))
+	require.NoError(t, err)
+	var raw interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+
+	flags, err := ParseDeprecatedStartupFlags(raw)
+	require.NoError(t, err)
+	assert.NotEmpty(t, flags)
+}
+
+func TestStartupArgsEqual(t *testing.T) {
+	assert.True(t, startupArgsEqual("10.0.0.2:2379,10.0.0.3:2379", "10.0.0.3:2379, 10.0.0.2:2379"))
+	assert.True(t, startupArgsEqual("/data/tikv/", "/data/tikv"))
+	assert.False(t, startupArgsEqual("/data/tikv", "/data1/tikv"))
+	assert.False(t, startupArgsEqual("4000", "4001"))
+}
+
+// newStartupArgsContext returns a rule context of an upgrade to targetVersion with a TiDB instance and two TiKV nodes
+func newStartupArgsContext(targetVersion string, tidbArgs map[string]string) *RuleContext {
+	snapshot := &collector.ClusterSnapshot{Components: map[string]collector.ComponentState{
+		"tidb": {
+			Type:                defaultsTypes.ComponentTiDB,
+			Status:              map[string]interface{}{"address": "10.0.0.1:4000"},
+			StartupArgs:         tidbArgs,
+			DeclaredStartupArgs: map[string]string{"P": "4000", "path": "10.0.0.2:2379,10.0.0.3:2379", "status": "10080"},
+		},
+		"tikv-10.0.0.4:20180": {
+			Type:                defaultsTypes.ComponentTiKV,
+			Status:              map[string]interface{}{"address": "10.0.0.4:20180"},
+			StartupArgs:         map[string]string{"data-dir": "/data/tikv-20160", "pd": "10.0.0.2:2379"},
+			DeclaredStartupArgs: map[string]string{"data-dir": "/data/tikv-20160/"},
+		},
+		"tikv-10.0.0.5:20180": {
+			Type:                defaultsTypes.ComponentTiKV,
+			Status:              map[string]interface{}{"address": "10.0.0.5:20180"},
+			StartupArgs:         map[string]string{"data-dir": "/data1/tikv-20160"},
+			DeclaredStartupArgs: map[string]string{"data-dir": "/data/tikv-20160"},
+		},
+	}}
+	return &RuleContext{
+		SourceClusterSnapshot: snapshot,
+		SourceVersion:         "v7.1.0",
+		TargetVersion:         targetVersion,
+		DeprecatedStartupFlags: []DeprecatedStartupFlag{
+			{Component: "tidb", Flag: "enable-binlog", DeprecatedIn: "v7.5.0", RemovedIn: "v8.4.0", Replacement: "TiCDC", Explanation: "TiDB Binlog is removed"},
+			{Component: "tikv", Flag: "enable-binlog", RemovedIn: "v8.4.0", Explanation: "not a TiKV flag"},
+		},
+	}
+}
+
+func TestStartupArgsRule_Evaluate(t *testing.T) {
+	rule := NewStartupArgsRule()
+	tidbArgs := map[string]string{"P": "4000", "path": "10.0.0.3:2379,10.0.0.2:2379", "store": "tikv", "enable-binlog": "true"}
+
+	results, err := rule.Evaluate(context.Background(), newStartupArgsContext("v8.5.0", tidbArgs))
+	require.NoError(t, err)
+	findings := withoutStatistics(results)
+	require.Len(t, findings, 2)
+
+	// Only the second TiKV node runs with another data directory; "status" is not exposed by TiDB here
+	assert.Equal(t, "warning", findings[0].Severity)
+	assert.Equal(t, "tikv", findings[0].Component)
+	assert.Equal(t, "data-dir", findings[0].ParameterName)
+	assert.Equal(t, "startup_flag", findings[0].ParamType)
+	assert.Equal(t, "/data1/tikv-20160", findings[0].CurrentValue)
+	assert.Contains(t, findings[0].Message, "10.0.0.5:20180")
+
+	// Removed in the target version
+	assert.Equal(t, "error", findings[1].Severity)
+	assert.Equal(t, RiskLevelHigh, findings[1].RiskLevel)
+	assert.Equal(t, "enable-binlog", findings[1].ParameterName)
+	assert.Contains(t, findings[1].Message, "removed in v8.4.0")
+	assert.Contains(t, findings[1].Details, "- 10.0.0.1:4000")
+	assert.Contains(t, findings[1].Suggestions, "Use instead: TiCDC")
+
+	// Deprecated only
+	results, err = rule.Evaluate(context.Background(), newStartupArgsContext("v8.1.0", tidbArgs))
+	require.NoError(t, err)
+	findings = withoutStatistics(results)
+	require.Len(t, findings, 2)
+	assert.Equal(t, "warning", findings[1].Severity)
+	assert.Contains(t, findings[1].Message, "deprecated since v7.5.0")
+
+	// Before the deprecation
+	results, err = rule.Evaluate(context.Background(), newStartupArgsContext("v7.1.5", tidbArgs))
+	require.NoError(t, err)
+	assert.Len(t, withoutStatistics(results), 1)
+}
+
+func TestStartupArgsRule_Evaluate_NoStartupArgs(t *testing.T) {
+	rule := NewStartupArgsRule()
+	ruleCtx := newStartupArgsContext("v8.5.0", nil)
+	for key, state := range ruleCtx.SourceClusterSnapshot.Components {
+		state.StartupArgs = nil
+		ruleCtx.SourceClusterSnapshot.Components[key] = state
+	}
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	assert.Empty(t, withoutStatistics(results))
+}
@@ -0,0 +1,353 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// FormatChange describes an on-disk format change that happens when upgrading across a version
+// Format changes make a downgrade impossible once data has been written in the new format
+// They are loaded from knowledge/format_changes.json
+type FormatChange struct {
+	// ID uniquely identifies the format change (e.g., "titan-blob-storage")
+	ID string `json:"id"`
+	// Component is the component whose on-disk format changes (e.g., "tikv")
+	Component string `json:"component"`
+	// FromVersion is the oldest source version the entry was written for (informational)
+	FromVersion string `json:"from_version"`
+	// ToVersion is the version that introduces the format change
+	// The change applies if sourceVersion < ToVersion <= targetVersion
+	ToVersion string `json:"to_version"`
+	// Feature is the human-readable name of the affected feature
+	Feature string `json:"feature"`
+	// ConfigKeys are the config keys that control the feature
+	// A "*" segment matches any single segment (e.g., "rocksdb.*.format-version")
+	ConfigKeys []string `json:"config_keys"`
+	// Requires are config values that must match for the change to apply to a node
+	// (e.g., raft-engine.enable = true for Raft Engine format changes)
+	Requires map[string]interface{} `json:"requires,omitempty"`
+	// EnabledValues are values of ConfigKeys that mean the feature is on
+	EnabledValues []interface{} `json:"enabled_values,omitempty"`
+	// DisabledValues are values of ConfigKeys that mean the feature is off, any other value means on
+	// Used instead of EnabledValues for non-boolean keys (e.g., format versions)
+	DisabledValues []interface{} `json:"disabled_values,omitempty"`
+	// Description explains the format change and why it is irreversible
+	Description string `json:"description"`
+	// NoteWhenEnabled is added to the details if the feature is already on
+	NoteWhenEnabled string `json:"note_when_enabled,omitempty"`
+	// Suggestions are reported with the finding
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// formatChangesFile is the structure of knowledge/format_changes.json
+type formatChangesFile struct {
+	FormatChanges []FormatChange `json:"format_changes"`
+}
+
+// ParseFormatChanges converts format_changes loaded from the knowledge base (generic JSON map)
+// into a list of FormatChange
+func ParseFormatChanges(raw interface{}) ([]FormatChange, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var file formatChangesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return file.FormatChanges, nil
+}
+
+// AppliesTo checks if the format change happens when upgrading from sourceVersion to targetVersion
+func (c FormatChange) AppliesTo(sourceVersion, targetVersion string) bool {
+	toVersion := strings.TrimPrefix(c.ToVersion, "v")
+	if toVersion == "" {
+		return false
+	}
+	return compareVersions(strings.TrimPrefix(sourceVersion, "v"), toVersion) < 0 &&
+		compareVersions(strings.TrimPrefix(targetVersion, "v"), toVersion) >= 0
+}
+
+// matchConfigKey checks if a config key matches a pattern where "*" matches any single segment
+func matchConfigKey(pattern, key string) bool {
+	if !strings.Contains(pattern, "*") {
+		return pattern == key
+	}
+	patternParts := strings.Split(pattern, ".")
+	keyParts := strings.Split(key, ".")
+	if len(patternParts) != len(keyParts) {
+		return false
+	}
+	for i, part := range patternParts {
+		if part != "*" && part != keyParts[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsValue checks if values contains a value equal to v (using CompareValues)
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, candidate := range values {
+		if CompareValues(candidate, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// isEnabledValue checks if a config value means the feature is on
+func (c FormatChange) isEnabledValue(value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	if len(c.EnabledValues) > 0 {
+		return containsValue(c.EnabledValues, value)
+	}
+	return !containsValue(c.DisabledValues, value)
+}
+
+// StorageFormatRule warns about upgrades that change on-disk storage formats irreversibly
+// Rule: For each format change between source and target version (knowledge/format_changes.json),
+// check whether each TiKV node has the affected feature enabled
+// Feature off: warning, the upgrade (or enabling the feature on the target version) changes the format
+// Feature on: info with a note, the format is already in use
+type StorageFormatRule struct {
+	*BaseRule
+}
+
+// NewStorageFormatRule creates a new storage format rule
+func NewStorageFormatRule() Rule {
+	return &StorageFormatRule{
+		BaseRule: NewBaseRule(
+			"STORAGE_FORMAT",
+			"Warn about upgrades that change on-disk storage formats (RocksDB, Raft Engine, Titan, encryption at rest) and make downgrade impossible",
+			"storage_format",
+		),
+	}
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *StorageFormatRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:          []string{"tikv"},
+			NeedConfig:          true,
+			NeedSystemVariables: false,
+			NeedAllTikvNodes:    true, // Storage format settings are per node
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          []string{}, // format_changes.json is global, loaded with any knowledge base
+			NeedConfigDefaults:  false,
+			NeedSystemVariables: false,
+			NeedUpgradeLogic:    false,
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components:          []string{},
+			NeedConfigDefaults:  false,
+			NeedSystemVariables: false,
+			NeedUpgradeLogic:    false,
+		},
+	}
+}
+
+// storageFormatNode is a component instance checked by StorageFormatRule
+type storageFormatNode struct {
+	address string
+	config  defaultsTypes.ParameterMap
+}
+
+// Evaluate performs the rule check
+func (r *StorageFormatRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil || len(ruleCtx.FormatChanges) == 0 {
+		return results, nil
+	}
+
+	for _, change := range ruleCtx.FormatChanges {
+		if !change.AppliesTo(ruleCtx.SourceVersion, ruleCtx.TargetVersion) {
+			continue
+		}
+		nodes := r.collectNodes(ruleCtx, change.Component)
+		if len(nodes) == 0 {
+			continue
+		}
+		if result, ok := r.evaluateChange(change, nodes, ruleCtx); ok {
+			results = append(results, result)
+		}
+	}
+
+	return results, nil
+}
+
+// collectNodes returns the instances of a component, one per address, sorted by address
+// The "tikv" entry of the snapshot aliases the first TiKV node and is skipped if the node is also listed separately
+func (r *StorageFormatRule) collectNodes(ruleCtx *RuleContext, component string) []storageFormatNode {
+	byAddress := make(map[string]storageFormatNode)
+	for _, comp := range ruleCtx.SourceClusterSnapshot.ComponentsByType(defaultsTypes.ComponentType(component)) {
+		address := component
+		if addr, ok := comp.Status["address"].(string); ok && addr != "" {
+			address = addr
+		}
+		byAddress[address] = storageFormatNode{address: address, config: comp.Config}
+	}
+
+	nodes := make([]storageFormatNode, 0, len(byAddress))
+	for _, node := range byAddress {
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].address < nodes[j].address })
+	return nodes
+}
+
+// evaluateChange checks a format change against all nodes
+// Returns false if the change does not apply to any node (e.g., its Requires do not match)
+func (r *StorageFormatRule) evaluateChange(change FormatChange, nodes []storageFormatNode, ruleCtx *RuleContext) (CheckResult, bool) {
+	var enabledNodes, disabledNodes []string
+	var nodeDetails []string
+
+	for _, node := range nodes {
+		if !r.nodeMatchesRequires(change, node) {
+			continue
+		}
+
+		enabled := false
+		var values []string
+		for _, pattern := range change.ConfigKeys {
+			for _, key := range r.matchingKeys(pattern, node.config) {
+				value := node.config[key].Value
+				values = append(values, fmt.Sprintf("%s = %s", key, FormatValue(value)))
+				if change.isEnabledValue(value) {
+					enabled = true
+				}
+			}
+		}
+		if len(values) == 0 {
+			values = append(values, "not collected, assuming the feature is off")
+		}
+
+		state := "off"
+		if enabled {
+			state = "on"
+			enabledNodes = append(enabledNodes, node.address)
+		} else {
+			disabledNodes = append(disabledNodes, node.address)
+		}
+		nodeDetails = append(nodeDetails, fmt.Sprintf("  %s (%s): %s", node.address, state, strings.Join(values, ", ")))
+	}
+
+	if len(enabledNodes) == 0 && len(disabledNodes) == 0 {
+		return CheckResult{}, false
+	}
+
+	featureOn := len(disabledNodes) == 0
+	severity := "warning"
+	riskLevel := RiskLevelMedium
+	message := fmt.Sprintf("%s: upgrading to %s changes the on-disk format irreversibly (downgrade will not be possible)", change.Feature, ruleCtx.TargetVersion)
+	if featureOn {
+		severity = "info"
+		riskLevel = RiskLevelLow
+		message = fmt.Sprintf("%s is enabled on all %s nodes: the on-disk format introduced in %s is irreversible", change.Feature, change.Component, change.ToVersion)
+	} else if len(enabledNodes) > 0 {
+		message = fmt.Sprintf("%s is enabled on only %d of %d %s nodes: upgrading to %s changes the on-disk format irreversibly",
+			change.Feature, len(enabledNodes), len(enabledNodes)+len(disabledNodes), change.Component, ruleCtx.TargetVersion)
+	}
+
+	var details strings.Builder
+	details.WriteString(change.Description)
+	if featureOn && change.NoteWhenEnabled != "" {
+		details.WriteString("\n\nNote: ")
+		details.WriteString(change.NoteWhenEnabled)
+	}
+	details.WriteString("\n\nPer-node settings:\n")
+	details.WriteString(strings.Join(nodeDetails, "\n"))
+
+	suggestions := change.Suggestions
+	if len(suggestions) == 0 {
+		suggestions = []string{
+			"Take a full backup (BR) before upgrading, restoring from backup is the only way back",
+		}
+	}
+
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     change.Component,
+		ParameterName: change.ID,
+		ParamType:     "storage_format",
+		Severity:      severity,
+		RiskLevel:     riskLevel,
+		Message:       message,
+		Details:       details.String(),
+		Suggestions:   suggestions,
+		Metadata: map[string]interface{}{
+			"format_change_id": change.ID,
+			"feature":          change.Feature,
+			"feature_enabled":  featureOn,
+			"irreversible":     true,
+			"from_version":     change.FromVersion,
+			"to_version":       change.ToVersion,
+			"config_keys":      change.ConfigKeys,
+			"enabled_nodes":    enabledNodes,
+			"disabled_nodes":   disabledNodes,
+		},
+	}, true
+}
+
+// nodeMatchesRequires checks the Requires conditions of a format change on a node
+// A required key that was not collected is treated as matching, so that the finding is not lost
+func (r *StorageFormatRule) nodeMatchesRequires(change FormatChange, node storageFormatNode) bool {
+	for key, expected := range change.Requires {
+		value, ok := node.config[key]
+		if !ok || value.Value == nil {
+			continue
+		}
+		if !CompareValues(value.Value, expected) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchingKeys returns the config keys of a node matching a pattern, sorted
+func (r *StorageFormatRule) matchingKeys(pattern string, config defaultsTypes.ParameterMap) []string {
+	if !strings.Contains(pattern, "*") {
+		if _, ok := config[pattern]; ok {
+			return []string{pattern}
+		}
+		return nil
+	}
+	var keys []string
+	for key := range config {
+		if matchConfigKey(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
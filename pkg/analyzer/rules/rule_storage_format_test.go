@@ -0,0 +1,199 @@
+package rules
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTitanChange = FormatChange{
+	ID:              "titan-blob-storage",
+	Component:       "tikv",
+	FromVersion:     "v7.5.0",
+	ToVersion:       "v8.5.0",
+	Feature:         "Titan",
+	ConfigKeys:      []string{"rocksdb.titan.enabled"},
+	EnabledValues:   []interface{}{true},
+	Description:     "Titan moves large values to blob files",
+	NoteWhenEnabled: "Titan is already enabled",
+}
+
+var testFormatVersionChange = FormatChange{
+	ID:             "rocksdb-sst-format-version",
+	Component:      "tikv",
+	FromVersion:    "v6.5.0",
+	ToVersion:      "v7.5.0",
+	Feature:        "RocksDB SST format version",
+	ConfigKeys:     []string{"rocksdb.*.format-version"},
+	DisabledValues: []interface{}{0, 1, 2},
+	Description:    "Newer SST format",
+}
+
+func newStorageFormatSnapshot(nodes map[string]map[string]interface{}) *collector.ClusterSnapshot {
+	snapshot := &collector.ClusterSnapshot{Components: make(map[string]collector.ComponentState)}
+	for addr, config := range nodes {
+		snapshot.Components["tikv-"+addr] = collector.ComponentState{
+			Type:   defaultsTypes.ComponentTiKV,
+			Config: defaultsTypes.ConvertConfigToDefaults(config),
+			Status: map[string]interface{}{"address": addr},
+		}
+	}
+	return snapshot
+}
+
+func TestFormatChange_AppliesTo(t *testing.T) {
+	tests := []struct {
+		source, target string
+		want           bool
+	}{
+		{"v7.5.0", "v8.5.0", true},
+		{"v7.1.0", "v8.5.1", true},
+		{"v8.1.0", "v8.5.0", true},
+		{"v8.5.0", "v8.5.1", false},
+		{"v7.5.0", "v8.1.0", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, testTitanChange.AppliesTo(tt.source, tt.target), "%s -> %s", tt.source, tt.target)
+	}
+}
+
+func TestStorageFormatRule_Evaluate(t *testing.T) {
+	tests := []struct {
+		name          string
+		change        FormatChange
+		nodes         map[string]map[string]interface{}
+		wantResult    bool
+		wantSeverity  string
+		wantEnabled   bool
+		wantInDetails string
+	}{
+		{
+			name:          "feature off on all nodes",
+			change:        testTitanChange,
+			nodes:         map[string]map[string]interface{}{"10.0.0.1:20160": {"rocksdb.titan.enabled": false}, "10.0.0.2:20160": {"rocksdb.titan.enabled": false}},
+			wantResult:    true,
+			wantSeverity:  "warning",
+			wantInDetails: "10.0.0.2:20160 (off): rocksdb.titan.enabled = false",
+		},
+		{
+			name:          "feature on on all nodes",
+			change:        testTitanChange,
+			nodes:         map[string]map[string]interface{}{"10.0.0.1:20160": {"rocksdb.titan.enabled": true}},
+			wantResult:    true,
+			wantSeverity:  "info",
+			wantEnabled:   true,
+			wantInDetails: "Note: Titan is already enabled",
+		},
+		{
+			name:   "feature on some nodes",
+			change: testTitanChange,
+			nodes: map[string]map[string]interface{}{
+				"10.0.0.1:20160": {"rocksdb.titan.enabled": true},
+				"10.0.0.2:20160": {"rocksdb.titan.enabled": "false"},
+			},
+			wantResult:    true,
+			wantSeverity:  "warning",
+			wantInDetails: "10.0.0.1:20160 (on)",
+		},
+		{
+			name:          "key not collected",
+			change:        testTitanChange,
+			nodes:         map[string]map[string]interface{}{"10.0.0.1:20160": {}},
+			wantResult:    true,
+			wantSeverity:  "warning",
+			wantInDetails: "not collected",
+		},
+		{
+			name:   "wildcard keys with disabled values",
+			change: testFormatVersionChange,
+			nodes: map[string]map[string]interface{}{
+				"10.0.0.1:20160": {"rocksdb.defaultcf.format-version": 5, "rocksdb.writecf.format-version": 2},
+			},
+			wantResult:    true,
+			wantSeverity:  "info",
+			wantEnabled:   true,
+			wantInDetails: "rocksdb.defaultcf.format-version = 5, rocksdb.writecf.format-version = 2",
+		},
+		{
+			name: "requires not met",
+			change: FormatChange{
+				ID: "raft-engine-log-recycle", Component: "tikv", ToVersion: "v8.5.0", Feature: "Raft Engine log recycling",
+				ConfigKeys: []string{"raft-engine.enable-log-recycle"}, EnabledValues: []interface{}{true},
+				Requires: map[string]interface{}{"raft-engine.enable": true},
+			},
+			nodes:      map[string]map[string]interface{}{"10.0.0.1:20160": {"raft-engine.enable": false, "raft-engine.enable-log-recycle": false}},
+			wantResult: false,
+		},
+	}
+
+	rule := NewStorageFormatRule()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ruleCtx := &RuleContext{
+				SourceClusterSnapshot: newStorageFormatSnapshot(tt.nodes),
+				SourceVersion:         "v7.5.0",
+				TargetVersion:         "v8.5.0",
+				FormatChanges:         []FormatChange{tt.change},
+			}
+			// testFormatVersionChange is introduced in v7.5.0
+			if tt.change.ToVersion == "v7.5.0" {
+				ruleCtx.SourceVersion = "v6.5.0"
+			}
+
+			results, err := rule.Evaluate(context.Background(), ruleCtx)
+			require.NoError(t, err)
+			if !tt.wantResult {
+				assert.Empty(t, results)
+				return
+			}
+			require.Len(t, results, 1)
+			result := results[0]
+			assert.Equal(t, "STORAGE_FORMAT", result.RuleID)
+			assert.Equal(t, tt.change.ID, result.ParameterName)
+			assert.Equal(t, "storage_format", result.ParamType)
+			assert.Equal(t, tt.wantSeverity, result.Severity)
+			assert.Equal(t, tt.wantEnabled, result.Metadata["feature_enabled"])
+			assert.Equal(t, true, result.Metadata["irreversible"])
+			assert.Contains(t, result.Details, tt.wantInDetails)
+		})
+	}
+}
+
+func TestStorageFormatRule_Evaluate_NotApplicable(t *testing.T) {
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: newStorageFormatSnapshot(map[string]map[string]interface{}{"10.0.0.1:20160": {"rocksdb.titan.enabled": false}}),
+		SourceVersion:         "v8.5.0",
+		TargetVersion:         "v8.5.1",
+		FormatChanges:         []FormatChange{testTitanChange},
+	}
+	results, err := NewStorageFormatRule().Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestParseFormatChanges_KnowledgeBase(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "..", "knowledge", "format_changes.json"))
+	require.NoError(t, err)
+	var raw interface{}
+	require.NoError(t, json.Unmarshal(data, &raw))
+
+	changes, err := ParseFormatChanges(raw)
+	require.NoError(t, err)
+
+	paths := make(map[string]bool)
+	for _, change := range changes {
+		assert.NotEmpty(t, change.ID)
+		assert.NotEmpty(t, change.ConfigKeys, change.ID)
+		assert.True(t, len(change.EnabledValues) > 0 || len(change.DisabledValues) > 0, change.ID)
+		paths[change.FromVersion+"->"+change.ToVersion] = true
+	}
+	assert.True(t, paths["v6.5.0->v7.5.0"])
+	assert.True(t, paths["v7.5.0->v8.5.0"])
+}
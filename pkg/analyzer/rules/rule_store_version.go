@@ -0,0 +1,246 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Issues reported by StoreVersionRule (Metadata["issue"])
+const (
+	// StoreIssueVersionMismatch is a store whose version recorded in PD differs from the version its TiKV node reports
+	StoreIssueVersionMismatch = "version_mismatch"
+	// StoreIssueRemovedInTopology is an Offline or Tombstone store whose address the topology still lists
+	StoreIssueRemovedInTopology = "removed_store_in_topology"
+	// StoreIssueMissingStore is a TiKV node of the topology PD has no store for
+	StoreIssueMissingStore = "missing_store"
+)
+
+// StoreVersionRule compares PD's store metadata with the TiKV nodes of the topology
+// The rolling upgrade relies on the store metadata in PD, which gets stale when a node is patched manually
+// or a store is being removed
+// Rule:
+// - a store whose version in PD differs from the version its TiKV node reports: warning
+// - an Offline or Tombstone store whose address the topology still lists (and no other store serves): warning
+// - a TiKV node of the topology PD has no store for: warning (only checked if PD reports the status addresses)
+type StoreVersionRule struct {
+	*BaseRule
+}
+
+// NewStoreVersionRule creates a new store version rule
+func NewStoreVersionRule() Rule {
+	return &StoreVersionRule{
+		BaseRule: NewBaseRule(
+			"STORE_VERSION",
+			"Check that the store versions and states recorded in PD agree with the TiKV nodes",
+			"store_metadata",
+		),
+	}
+}
+
+// DataRequirements returns the data requirements for this rule
+func (r *StoreVersionRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{
+		SourceClusterRequirements: struct {
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
+		}{
+			Components:       []string{"pd", "tikv"},
+			NeedConfig:       true, // TiKV versions are read with the configuration of the nodes
+			NeedAllTikvNodes: true, // The version of every node is compared
+			NeedStores:       true,
+		},
+		SourceKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{},
+		},
+		TargetKBRequirements: struct {
+			Components          []string `json:"components"`
+			NeedConfigDefaults  bool     `json:"need_config_defaults"`
+			NeedSystemVariables bool     `json:"need_system_variables"`
+			NeedUpgradeLogic    bool     `json:"need_upgrade_logic"`
+		}{
+			Components: []string{},
+		},
+	}
+}
+
+// Evaluate performs the rule check
+func (r *StoreVersionRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	if ruleCtx.SourceClusterSnapshot == nil || ruleCtx.SourceClusterSnapshot.Stores == nil {
+		return results, nil
+	}
+	state := ruleCtx.SourceClusterSnapshot.Stores
+	stores := append([]defaultsTypes.StoreInfo(nil), state.Stores...)
+	sort.Slice(stores, func(i, j int) bool { return stores[i].ID < stores[j].ID })
+
+	// Version reported by each TiKV node, by address
+	nodeVersions := make(map[string]string)
+	var nodeAddrs []string
+	for _, node := range ruleCtx.SourceClusterSnapshot.ComponentsByType(defaultsTypes.ComponentTiKV) {
+		if addr, ok := node.Status["address"].(string); ok && addr != "" && node.Version != "" {
+			if _, seen := nodeVersions[addr]; !seen {
+				nodeAddrs = append(nodeAddrs, addr)
+			}
+			nodeVersions[addr] = node.Version
+		}
+	}
+	sort.Strings(nodeAddrs)
+
+	for _, store := range stores {
+		if isRemovedStore(store) {
+			continue
+		}
+		for _, addr := range nodeAddrs {
+			if nodeVersion := nodeVersions[addr]; store.MatchesAddress(addr) && !sameVersion(store.Version, nodeVersion) {
+				results = append(results, r.versionMismatchResult(store, addr, nodeVersion))
+			}
+		}
+	}
+
+	topologyAddresses := append([]string(nil), state.TopologyAddresses...)
+	sort.Strings(topologyAddresses)
+	for _, addr := range topologyAddresses {
+		var removed []defaultsTypes.StoreInfo
+		served := false
+		for _, store := range stores {
+			if !store.MatchesAddress(addr) {
+				continue
+			}
+			if isRemovedStore(store) {
+				removed = append(removed, store)
+			} else {
+				served = true
+			}
+		}
+		if served {
+			continue
+		}
+		for _, store := range removed {
+			results = append(results, r.removedStoreResult(store, addr))
+		}
+		if len(removed) == 0 && hasStatusAddresses(stores) {
+			results = append(results, r.missingStoreResult(addr))
+		}
+	}
+	return results, nil
+}
+
+// newResult creates a result of the rule about a store (or a TiKV node without a store)
+func (r *StoreVersionRule) newResult(name, issue, message, details string, suggestions []string) CheckResult {
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     "tikv",
+		ParameterName: name,
+		ParamType:     "store",
+		Description:   r.Description(),
+		Severity:      "warning",
+		RiskLevel:     RiskLevelMedium,
+		Message:       message,
+		Details:       details,
+		Suggestions:   suggestions,
+		Metadata:      map[string]interface{}{"issue": issue},
+	}
+}
+
+// versionMismatchResult reports a store whose version in PD differs from the version its node reports
+func (r *StoreVersionRule) versionMismatchResult(store defaultsTypes.StoreInfo, addr, nodeVersion string) CheckResult {
+	result := r.newResult(storeName(store), StoreIssueVersionMismatch,
+		fmt.Sprintf("Store %d (%s) is recorded in PD with version %s, but the TiKV node reports %s",
+			store.ID, addr, valueOrDash(store.Version), nodeVersion),
+		fmt.Sprintf("Store ID: %d\nAddress: %s\nStatus address: %s\nState in PD: %s\nVersion in PD: %s\nVersion reported by the node: %s\n\n"+
+			"The rolling upgrade reads the store versions from PD. A version PD recorded before the node was replaced or "+
+			"patched manually may make it skip, reorder or wrongly verify the upgrade of this store.",
+			store.ID, valueOrDash(store.Address), valueOrDash(store.StatusAddress), valueOrDash(store.StateName),
+			valueOrDash(store.Version), nodeVersion),
+		[]string{
+			fmt.Sprintf("Compare pd-ctl store %d with the /status of the node at %s, and find out how the node was changed", store.ID, addr),
+			"Restart the TiKV node so that it reports its version to PD again, and re-run the precheck before upgrading",
+		})
+	result.CurrentValue = nodeVersion
+	result.Metadata["store_id"] = store.ID
+	result.Metadata["address"] = addr
+	result.Metadata["pd_version"] = store.Version
+	result.Metadata["node_version"] = nodeVersion
+	return result
+}
+
+// removedStoreResult reports an Offline or Tombstone store whose address the topology still lists
+func (r *StoreVersionRule) removedStoreResult(store defaultsTypes.StoreInfo, addr string) CheckResult {
+	result := r.newResult(storeName(store), StoreIssueRemovedInTopology,
+		fmt.Sprintf("Store %d (%s) is %s in PD, but the topology still lists it", store.ID, addr, store.StateName),
+		fmt.Sprintf("Store ID: %d\nAddress: %s\nState in PD: %s\nVersion in PD: %s\n\n"+
+			"The upgrade restarts every TiKV node of the topology: a node whose store is being removed (Offline) "+
+			"or was removed (Tombstone) is restarted and waited for although PD no longer schedules it.",
+			store.ID, valueOrDash(store.Address), store.StateName, valueOrDash(store.Version)),
+		[]string{
+			fmt.Sprintf("If the scale-in of store %d is done, remove the node from the topology (tiup cluster prune)", store.ID),
+			fmt.Sprintf("If it is still Offline, wait for its regions to be moved (pd-ctl store %d) before upgrading", store.ID),
+		})
+	result.CurrentValue = store.StateName
+	result.Metadata["store_id"] = store.ID
+	result.Metadata["address"] = addr
+	result.Metadata["state"] = store.StateName
+	result.Metadata["pd_version"] = store.Version
+	return result
+}
+
+// missingStoreResult reports a TiKV node of the topology PD has no store for
+func (r *StoreVersionRule) missingStoreResult(addr string) CheckResult {
+	result := r.newResult(addr, StoreIssueMissingStore,
+		fmt.Sprintf("TiKV node %s of the topology has no store in PD", addr),
+		fmt.Sprintf("Address: %s\n\nNo store registered in PD has this status or gRPC address. "+
+			"The node may not belong to this cluster, or it has not joined it, and the rolling upgrade cannot track it.", addr),
+		[]string{
+			"Check the address in the topology against pd-ctl store",
+			"Remove the node from the topology, or start it so that it registers its store, before upgrading",
+		})
+	result.Metadata["address"] = addr
+	return result
+}
+
+// storeName is the parameter name of the findings about a store
+func storeName(store defaultsTypes.StoreInfo) string {
+	return fmt.Sprintf("store-%d", store.ID)
+}
+
+// isRemovedStore checks if a store is being removed (Offline) or was removed (Tombstone)
+func isRemovedStore(store defaultsTypes.StoreInfo) bool {
+	return store.StateName == defaultsTypes.StoreStateOffline || store.StateName == defaultsTypes.StoreStateTombstone
+}
+
+// hasStatusAddresses checks if PD reports the status address of every store
+// Old versions don't, and the status addresses of the topology can't be matched then
+func hasStatusAddresses(stores []defaultsTypes.StoreInfo) bool {
+	for _, store := range stores {
+		if store.StatusAddress == "" {
+			return false
+		}
+	}
+	return len(stores) > 0
+}
+
+// sameVersion compares the versions reported by PD and a TiKV node, in their normalized form if both have one
+func sameVersion(v1, v2 string) bool {
+	n1, n2 := defaultsTypes.NormalizeVersion(v1), defaultsTypes.NormalizeVersion(v2)
+	if n1 != "" && n2 != "" {
+		return n1 == n2
+	}
+	return strings.TrimPrefix(strings.TrimSpace(v1), "v") == strings.TrimPrefix(strings.TrimSpace(v2), "v")
+}
@@ -0,0 +1,94 @@
+package rules
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStoreSnapshot creates a snapshot with a TiKV node per address (status address -> version) and PD's stores
+func newStoreSnapshot(nodes map[string]string, stores []defaultsTypes.StoreInfo) *collector.ClusterSnapshot {
+	snapshot := &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{},
+		Stores:     &defaultsTypes.StoreState{Stores: stores},
+	}
+	for addr, version := range nodes {
+		snapshot.Components["tikv-"+addr] = collector.ComponentState{
+			Type:    defaultsTypes.ComponentTiKV,
+			Version: version,
+			Status:  map[string]interface{}{"address": addr},
+		}
+		snapshot.Stores.TopologyAddresses = append(snapshot.Stores.TopologyAddresses, addr)
+	}
+	return snapshot
+}
+
+func evaluateStoreVersion(t *testing.T, snapshot *collector.ClusterSnapshot) []CheckResult {
+	results, err := NewStoreVersionRule().Evaluate(context.Background(), &RuleContext{SourceClusterSnapshot: snapshot})
+	require.NoError(t, err)
+	return results
+}
+
+func TestStoreVersionRule_Agreeing(t *testing.T) {
+	snapshot := newStoreSnapshot(map[string]string{"10.0.1.1:20180": "v7.5.0", "10.0.1.2:20180": "7.5.0"}, []defaultsTypes.StoreInfo{
+		{ID: 1, Address: "10.0.1.1:20160", StatusAddress: "10.0.1.1:20180", Version: "7.5.0", StateName: "Up"},
+		{ID: 2, Address: "10.0.1.2:20160", StatusAddress: "10.0.1.2:20180", Version: "v7.5.0", StateName: "Up"},
+		// A store removed earlier, no longer in the topology
+		{ID: 3, Address: "10.0.1.3:20160", StatusAddress: "10.0.1.3:20180", Version: "7.1.0", StateName: "Tombstone"},
+	})
+	assert.Empty(t, evaluateStoreVersion(t, snapshot))
+
+	// Without the stores collected, nothing to check
+	snapshot.Stores = nil
+	assert.Empty(t, evaluateStoreVersion(t, snapshot))
+}
+
+func TestStoreVersionRule_Disagreeing(t *testing.T) {
+	snapshot := newStoreSnapshot(map[string]string{"10.0.1.1:20180": "v7.5.1", "10.0.1.2:20180": "v7.5.0"}, []defaultsTypes.StoreInfo{
+		{ID: 1, Address: "10.0.1.1:20160", StatusAddress: "10.0.1.1:20180", Version: "7.5.0", StateName: "Up"},
+		{ID: 2, Address: "10.0.1.2:20160", StatusAddress: "10.0.1.2:20180", Version: "7.5.0", StateName: "Up"},
+	})
+	results := evaluateStoreVersion(t, snapshot)
+	require.Len(t, results, 1)
+	result := results[0]
+	assert.Equal(t, "STORE_VERSION", result.RuleID)
+	assert.Equal(t, "warning", result.Severity)
+	assert.Equal(t, "store-1", result.ParameterName)
+	assert.Equal(t, StoreIssueVersionMismatch, result.Metadata["issue"])
+	assert.Equal(t, uint64(1), result.Metadata["store_id"])
+	assert.Equal(t, "10.0.1.1:20180", result.Metadata["address"])
+	assert.Equal(t, "7.5.0", result.Metadata["pd_version"])
+	assert.Equal(t, "v7.5.1", result.Metadata["node_version"])
+	assert.Contains(t, result.Message, "version 7.5.0, but the TiKV node reports v7.5.1")
+	assert.Contains(t, result.Suggestions[0], "pd-ctl store 1")
+}
+
+func TestStoreVersionRule_RemovedAndMissingStores(t *testing.T) {
+	snapshot := newStoreSnapshot(map[string]string{"10.0.1.1:20180": "v7.5.0", "10.0.1.2:20180": "v7.5.0", "10.0.1.4:20180": "v7.5.0"},
+		[]defaultsTypes.StoreInfo{
+			{ID: 1, Address: "10.0.1.1:20160", StatusAddress: "10.0.1.1:20180", Version: "7.5.0", StateName: "Up"},
+			{ID: 2, Address: "10.0.1.2:20160", StatusAddress: "10.0.1.2:20180", Version: "7.1.0", StateName: "Offline"},
+			// A node redeployed on the address of a Tombstone store is served by its new store
+			{ID: 3, Address: "10.0.1.1:20160", StatusAddress: "10.0.1.1:20180", Version: "7.1.0", StateName: "Tombstone"},
+		})
+	results := evaluateStoreVersion(t, snapshot)
+	require.Len(t, results, 2)
+	assert.Equal(t, StoreIssueRemovedInTopology, results[0].Metadata["issue"])
+	assert.Equal(t, "store-2", results[0].ParameterName)
+	assert.Equal(t, "Offline", results[0].CurrentValue)
+	assert.Equal(t, "Store 2 (10.0.1.2:20180) is Offline in PD, but the topology still lists it", results[0].Message)
+	assert.Equal(t, StoreIssueMissingStore, results[1].Metadata["issue"])
+	assert.Equal(t, "10.0.1.4:20180", results[1].ParameterName)
+
+	// PD versions without status addresses: the status addresses of the topology can't be told missing
+	for i := range snapshot.Stores.Stores {
+		snapshot.Stores.Stores[i].StatusAddress = ""
+	}
+	for _, result := range evaluateStoreVersion(t, snapshot) {
+		assert.NotEqual(t, StoreIssueMissingStore, result.Metadata["issue"])
+	}
+}
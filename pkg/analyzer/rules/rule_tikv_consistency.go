@@ -5,17 +5,20 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
 	tidbCollector "github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	tikvCollector "github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tikv"
 	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 )
 
 // TikvConsistencyRule compares all TiKV node parameters for consistency
 // Rule: Compare all TiKV node parameters with the first TiKV node (baseline)
+// Every parameter set on any node is checked, except those listed in knowledge/consistency_ignore.json
 // Reports differences as medium risk (warning)
 // This rule is used for TiKV scale out precheck to ensure all TiKV nodes have consistent parameters
 type TikvConsistencyRule struct {
@@ -37,10 +40,14 @@ func NewTikvConsistencyRule() Rule {
 func (r *TikvConsistencyRule) DataRequirements() DataSourceRequirement {
 	return DataSourceRequirement{
 		SourceClusterRequirements: struct {
-			Components          []string `json:"components"`
-			NeedConfig          bool     `json:"need_config"`
-			NeedSystemVariables bool     `json:"need_system_variables"`
-			NeedAllTikvNodes    bool     `json:"need_all_tikv_nodes"`
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
 		}{
 			Components:          []string{"tikv"},
 			NeedConfig:          true,
@@ -76,9 +83,10 @@ func (r *TikvConsistencyRule) DataRequirements() DataSourceRequirement {
 // Logic:
 // 1. Collect all TiKV node parameters (last_tikv.toml + SHOW CONFIG, merged with runtime priority)
 // 2. Use the first TiKV node as baseline
-// 3. Compare all other TiKV nodes with the baseline node
+// 3. Compare all other TiKV nodes with the baseline node to find the differing parameters
 // 4. Report differences as medium risk (warning)
-// 5. Each node-parameter combination is one entry
+// 5. Each differing parameter is one entry, with the value of every node and the majority value
+// Parameters that legitimately differ between nodes (RuleContext.ConsistencyIgnore) are not reported
 func (r *TikvConsistencyRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
 	var results []CheckResult
 
@@ -86,40 +94,44 @@ func (r *TikvConsistencyRule) Evaluate(ctx context.Context, ruleCtx *RuleContext
 		return results, nil
 	}
 
+	// Single-node clusters (typically developer setups) cannot have inconsistent TiKV parameters
+	// Skip early to avoid connecting to TiDB for nothing
+	if clusterInfo := ruleCtx.GetClusterInfo(); clusterInfo.TiKVNodeCount == 1 {
+		return results, nil
+	}
+
 	// Find TiDB component to get connection info
 	var tidbAddr string
 	var tidbUser, tidbPassword string
-	for compName, component := range ruleCtx.SourceClusterSnapshot.Components {
-		if component.Type == collector.TiDBComponent || strings.HasPrefix(compName, "tidb") {
-			if addr, ok := component.Status["address"].(string); ok {
-				tidbAddr = addr
-			} else {
-				tidbAddr = compName
-			}
-			// Try to get user and password from status
-			if user, ok := component.Status["user"].(string); ok {
-				tidbUser = user
-			} else {
-				tidbUser = "root" // Default
-			}
-			if password, ok := component.Status["password"].(string); ok {
-				tidbPassword = password
-			} else {
-				tidbPassword = "" // Default
-			}
-			break
+	if component, ok := ruleCtx.SourceClusterSnapshot.ComponentByType(collector.TiDBComponent); ok {
+		if addr, ok := component.Status["address"].(string); ok {
+			tidbAddr = addr
+		}
+		// Try to get user and password from status
+		if user, ok := component.Status["user"].(string); ok {
+			tidbUser = user
+		} else {
+			tidbUser = "root" // Default
+		}
+		if password, ok := component.Status["password"].(string); ok {
+			tidbPassword = password
+		} else {
+			tidbPassword = "" // Default
 		}
 	}
 
 	// Collect all TiKV nodes with their instance addresses (IP:port) and merged configs
 	type tikvNodeInfo struct {
 		name         string
-		address      string                       // HTTP address (from status)
-		instance     string                       // Instance format: IP:port (for SHOW CONFIG)
-		mergedConfig defaultsTypes.ConfigDefaults // Merged config (last_tikv.toml + SHOW CONFIG)
+		address      string                     // HTTP address (from status)
+		instance     string                     // Instance format: IP:port (for SHOW CONFIG)
+		mergedConfig defaultsTypes.ParameterMap // Merged config (last_tikv.toml + SHOW CONFIG)
+		proxied      bool                       // Config read through TiDB's information_schema.cluster_config
 	}
 
 	var tikvNodes []tikvNodeInfo
+	// States of the nodes with their merged configs, for the cluster-wide statistics of the parameters
+	states := make(map[string]*defaultsTypes.ComponentState)
 
 	// Connect to TiDB to get runtime configs via SHOW CONFIG (if available)
 	var db *sql.DB
@@ -144,10 +156,15 @@ func (r *TikvConsistencyRule) Evaluate(ctx context.Context, ruleCtx *RuleContext
 			}
 
 			// Extract instance (IP:port) from address
+			// Nodes collected through TiDB are identified by the INSTANCE column of information_schema
 			instance := address
+			if inst, ok := component.Status[tikvCollector.StatusKeyInstance].(string); ok && inst != "" {
+				instance = inst
+			}
+			proxied := component.Status[tikvCollector.StatusKeyCollectedVia] == tikvCollector.CollectedViaTiDBProxy
 
 			// Step 1: Start with user-set values from last_tikv.toml
-			mergedConfig := make(defaultsTypes.ConfigDefaults)
+			mergedConfig := make(defaultsTypes.ParameterMap)
 			for k, v := range component.Config {
 				mergedConfig[k] = v
 			}
@@ -166,15 +183,25 @@ func (r *TikvConsistencyRule) Evaluate(ctx context.Context, ruleCtx *RuleContext
 				}
 			}
 
+			states[compName] = &defaultsTypes.ComponentState{Type: defaultsTypes.ComponentTiKV, Config: mergedConfig, Status: component.Status}
 			tikvNodes = append(tikvNodes, tikvNodeInfo{
 				name:         compName,
 				address:      address,
 				instance:     instance,
 				mergedConfig: mergedConfig,
+				proxied:      proxied,
 			})
 		}
 	}
 
+	// Order nodes by instance so that the baseline doesn't depend on map iteration
+	sort.SliceStable(tikvNodes, func(i, j int) bool {
+		if tikvNodes[i].instance != tikvNodes[j].instance {
+			return tikvNodes[i].instance < tikvNodes[j].instance
+		}
+		return tikvNodes[i].name < tikvNodes[j].name
+	})
+
 	if len(tikvNodes) == 0 {
 		return results, nil
 	}
@@ -188,171 +215,215 @@ func (r *TikvConsistencyRule) Evaluate(ctx context.Context, ruleCtx *RuleContext
 	baselineNode := tikvNodes[0]
 	baselineConfig := baselineNode.mergedConfig
 
-	// Compare all other TiKV nodes with the baseline node
+	// Find the parameters that differ between nodes by comparing all other TiKV nodes with the baseline node
 	// Note: Deployment-specific parameters have already been filtered in preprocessor
 	// This rule only processes parameters that passed the preprocessor filter
+	differing := make(map[string]tikvDifferingParam)
+	addDiffering := func(paramName, fieldPath string) {
+		name := paramName
+		if fieldPath != "" {
+			name = paramName + "." + fieldPath
+		}
+		if ruleCtx.ConsistencyIgnore.Contains("tikv", name) {
+			return
+		}
+		differing[name] = tikvDifferingParam{name: name, paramName: paramName, fieldPath: fieldPath}
+	}
 	for i := 1; i < len(tikvNodes); i++ {
 		node := tikvNodes[i]
 		nodeConfig := node.mergedConfig
+		// Proxied nodes lack the node-local fields of last_tikv.toml, so a parameter missing on one side
+		// is not a difference when only one of the nodes was collected through TiDB
+		comparePresence := node.proxied == baselineNode.proxied
 
-		// Compare each parameter in the node with the baseline
 		for paramName, paramValue := range nodeConfig {
-			nodeValue := paramValue.Value
-
-			// Get baseline value
 			baselineParamValue, existsInBaseline := baselineConfig[paramName]
 			if !existsInBaseline {
-				// Parameter exists in this node but not in baseline - report as difference
-				results = append(results, CheckResult{
-					RuleID:        r.Name(),
-					Category:      r.Category(),
-					Component:     "tikv",
-					ParameterName: paramName,
-					ParamType:     "config",
-					Severity:      "warning",
-					RiskLevel:     RiskLevelMedium,
-					Message:       fmt.Sprintf("Parameter %s exists in TiKV node %s but not in baseline node %s", paramName, node.name, baselineNode.name),
-					Details:       fmt.Sprintf("Node: %s (instance: %s)\nBaseline Node: %s (instance: %s)\n\nThis parameter is present in node %s but missing in the baseline node.\nCurrent Value: %v", node.name, node.instance, baselineNode.name, baselineNode.instance, node.name, FormatValue(nodeValue)),
-					CurrentValue:  nodeValue,
-					Suggestions: []string{
-						"This parameter exists in this node but not in the baseline node",
-						"Review if this parameter should be added to the baseline node or removed from this node",
-						"Ensure all TiKV nodes have consistent parameters for scale out",
-					},
-					Metadata: map[string]interface{}{
-						"node_name":         node.name,
-						"node_instance":     node.instance,
-						"baseline_name":     baselineNode.name,
-						"baseline_instance": baselineNode.instance,
-						"config_sources":    []string{"last_tikv.toml", "SHOW CONFIG WHERE type='tikv' AND instance='...'"},
-					},
-				})
+				if comparePresence {
+					addDiffering(paramName, "")
+				}
 				continue
 			}
 
-			baselineValue := baselineParamValue.Value
-
-			// For map types, use deep comparison to show only differing fields
-			nodeMap := ConvertToMapStringInterface(nodeValue)
-			baselineMap := ConvertToMapStringInterface(baselineValue)
-
-			if nodeMap != nil && baselineMap != nil {
-				// Both are maps, use deep comparison to show only differing fields
-				opts := CompareOptions{
-					BasePath: paramName,
+			// For map types, use deep comparison to report only the differing fields
+			nodeValue, baselineValue := paramValue.Value, baselineParamValue.Value
+			if ConvertToMapStringInterface(nodeValue) != nil && ConvertToMapStringInterface(baselineValue) != nil {
+				for fieldPath := range CompareMapsDeep(nodeValue, baselineValue, CompareOptions{BasePath: paramName}) {
+					addDiffering(paramName, fieldPath)
 				}
-				diffs := CompareMapsDeep(nodeValue, baselineValue, opts)
-
-				// Only report if there are differences
-				if len(diffs) > 0 {
-					// Create a separate CheckResult for each differing field
-					for fieldPath, diff := range diffs {
-						fieldDetails := FormatValueDiff(diff.Current, diff.Source) // Current (node) vs Source (baseline)
-
-						results = append(results, CheckResult{
-							RuleID:        r.Name(),
-							Category:      r.Category(),
-							Component:     "tikv",
-							ParameterName: fmt.Sprintf("%s.%s", paramName, fieldPath),
-							ParamType:     "config",
-							Severity:      "warning",
-							RiskLevel:     RiskLevelMedium,
-							Message:       fmt.Sprintf("Parameter %s.%s in TiKV node %s differs from baseline node %s", paramName, fieldPath, node.name, baselineNode.name),
-							Details:       fmt.Sprintf("Node: %s (instance: %s)\nBaseline Node: %s (instance: %s)\n%s", node.name, node.instance, baselineNode.name, baselineNode.instance, fieldDetails),
-							CurrentValue:  diff.Current,
-							SourceDefault: diff.Source, // Baseline value
-							Suggestions: []string{
-								"This parameter differs between TiKV nodes",
-								"Review if this difference is intentional",
-								"Ensure all TiKV nodes have consistent parameters for scale out",
-							},
-							Metadata: map[string]interface{}{
-								"node_name":         node.name,
-								"node_instance":     node.instance,
-								"baseline_name":     baselineNode.name,
-								"baseline_instance": baselineNode.instance,
-								"config_sources":    []string{"last_tikv.toml", "SHOW CONFIG WHERE type='tikv' AND instance='...'"},
-							},
-						})
-					}
-				}
-				// Skip reporting the entire map - we only report individual fields
 				continue
-			} else {
-				// For non-map types, use simple comparison
-				// Use proper value comparison to avoid scientific notation issues
-				differs := !CompareValues(nodeValue, baselineValue)
-
-				if differs {
-					// Difference found: medium risk (warning)
-					details := FormatValueDiff(nodeValue, baselineValue)
-
-					results = append(results, CheckResult{
-						RuleID:        r.Name(),
-						Category:      r.Category(),
-						Component:     "tikv",
-						ParameterName: paramName,
-						ParamType:     "config",
-						Severity:      "warning",
-						RiskLevel:     RiskLevelMedium,
-						Message:       fmt.Sprintf("Parameter %s in TiKV node %s differs from baseline node %s", paramName, node.name, baselineNode.name),
-						Details:       fmt.Sprintf("Node: %s (instance: %s)\nBaseline Node: %s (instance: %s)\n%s", node.name, node.instance, baselineNode.name, baselineNode.instance, details),
-						CurrentValue:  nodeValue,
-						SourceDefault: baselineValue, // Baseline value
-						Suggestions: []string{
-							"This parameter differs between TiKV nodes",
-							"Review if this difference is intentional",
-							"Ensure all TiKV nodes have consistent parameters for scale out",
-						},
-						Metadata: map[string]interface{}{
-							"node_name":         node.name,
-							"node_instance":     node.instance,
-							"baseline_name":     baselineNode.name,
-							"baseline_instance": baselineNode.instance,
-							"config_sources":    []string{"last_tikv.toml", "SHOW CONFIG WHERE type='tikv' AND instance='...'"},
-						},
-					})
-				}
+			}
+			// Compare guided by the parameter type (numbers, sizes and durations in any unit)
+			if !paramValue.Equal(baselineParamValue) {
+				addDiffering(paramName, "")
 			}
 		}
 
 		// Also check for parameters that exist in baseline but not in this node
-		for paramName, baselineParamValue := range baselineConfig {
-			if _, existsInNode := nodeConfig[paramName]; !existsInNode {
-				// Parameter exists in baseline but not in this node - report as difference
-				baselineValue := baselineParamValue.Value
-				results = append(results, CheckResult{
-					RuleID:        r.Name(),
-					Category:      r.Category(),
-					Component:     "tikv",
-					ParameterName: paramName,
-					ParamType:     "config",
-					Severity:      "warning",
-					RiskLevel:     RiskLevelMedium,
-					Message:       fmt.Sprintf("Parameter %s exists in baseline node %s but not in TiKV node %s", paramName, baselineNode.name, node.name),
-					Details:       fmt.Sprintf("Node: %s (instance: %s)\nBaseline Node: %s (instance: %s)\n\nThis parameter is present in the baseline node but missing in node %s.\nBaseline Value: %v", node.name, node.instance, baselineNode.name, baselineNode.instance, node.name, FormatValue(baselineValue)),
-					CurrentValue:  nil,
-					SourceDefault: baselineValue,
-					Suggestions: []string{
-						"This parameter exists in the baseline node but not in this node",
-						"Review if this parameter should be added to this node or removed from the baseline node",
-						"Ensure all TiKV nodes have consistent parameters for scale out",
-					},
-					Metadata: map[string]interface{}{
-						"node_name":         node.name,
-						"node_instance":     node.instance,
-						"baseline_name":     baselineNode.name,
-						"baseline_instance": baselineNode.instance,
-						"config_sources":    []string{"last_tikv.toml", "SHOW CONFIG WHERE type='tikv' AND instance='...'"},
-					},
-				})
+		for paramName := range baselineConfig {
+			if _, existsInNode := nodeConfig[paramName]; !existsInNode && comparePresence {
+				addDiffering(paramName, "")
 			}
 		}
 	}
 
+	// Report each differing parameter once, with its statistics and the value of every node
+	stats := tikvCollector.ComputeTiKVStats(states)
+	names := make([]string, 0, len(differing))
+	for name := range differing {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	nodes := make([]tikvConsistencyNode, 0, len(tikvNodes))
+	for _, node := range tikvNodes {
+		nodes = append(nodes, tikvConsistencyNode{name: node.name, instance: node.instance, config: node.mergedConfig, proxied: node.proxied})
+	}
+	for _, name := range names {
+		if result, ok := r.inconsistencyResult(differing[name], stats[name], nodes); ok {
+			results = append(results, result)
+		}
+	}
+
 	return results, nil
 }
 
+// tikvDifferingParam is a parameter whose value differs between TiKV nodes
+type tikvDifferingParam struct {
+	// name is the reported parameter name (paramName, or paramName.fieldPath for a field of a map parameter)
+	name      string
+	paramName string
+	fieldPath string
+}
+
+// tikvConsistencyNode is a TiKV node compared by TikvConsistencyRule, in instance order
+type tikvConsistencyNode struct {
+	name     string
+	instance string
+	config   defaultsTypes.ParameterMap
+	proxied  bool
+}
+
+// TikvNodeValue is the value of a parameter on a TiKV node
+// TikvConsistencyRule reports the value of every node in the "nodes" metadata of a finding
+type TikvNodeValue struct {
+	// Name is the name of the node in the snapshot (e.g., "tikv-0")
+	Name string `json:"name"`
+	// Instance is the address of the node (IP:port)
+	Instance string `json:"instance"`
+	// Value is the parameter value on the node (nil if Missing)
+	Value interface{} `json:"value"`
+	// Missing is set when the parameter is not set on the node
+	Missing bool `json:"missing,omitempty"`
+	// Majority is set when the node has the value of the majority of the nodes
+	Majority bool `json:"majority"`
+}
+
+// nodeValue returns the value of a differing parameter on a node
+func (p tikvDifferingParam) nodeValue(config defaultsTypes.ParameterMap) (interface{}, bool) {
+	paramValue, ok := config[p.paramName]
+	if !ok {
+		return nil, false
+	}
+	if p.fieldPath == "" {
+		return paramValue.Value, true
+	}
+	value := getNestedMapValue(ConvertToMapStringInterface(paramValue.Value), strings.Split(p.fieldPath, "."))
+	return value, value != nil
+}
+
+// inconsistencyResult builds the summary finding of a parameter that differs between nodes from its statistics
+// (see tikvCollector.ComputeTiKVStats): the majority value, the number of nodes that have it and the deviating nodes
+// A parameter missing from a node collected through TiDB is shown but does not count, since such nodes
+// lack the node-local fields of last_tikv.toml
+// Returns false if every counted node has the majority value
+func (r *TikvConsistencyRule) inconsistencyResult(param tikvDifferingParam, stats tikvCollector.TiKVStats, nodes []tikvConsistencyNode) (CheckResult, bool) {
+	if stats.OutlierCount == 0 {
+		return CheckResult{}, false
+	}
+	// The majority nodes come from the statistics so that the per-node rows agree with the counts of the message
+	majorityNodes := make(map[string]bool, len(stats.MajorityNodes))
+	for _, name := range stats.MajorityNodes {
+		majorityNodes[name] = true
+	}
+	majorityValue := FormatValue(stats.MajorityValue)
+	if stats.MajorityValue == nil {
+		majorityValue = tikvCollector.NotSetValue
+	}
+
+	rows := make([]TikvNodeValue, len(nodes))
+	anyProxied := false
+	var deviating []string
+	var currentValue interface{}
+	var nodeLines []string
+	nodeValues := make(map[string]interface{})
+	for i, node := range nodes {
+		anyProxied = anyProxied || node.proxied
+		value, ok := param.nodeValue(node.config)
+		row := TikvNodeValue{Name: node.name, Instance: node.instance, Value: value, Missing: !ok, Majority: majorityNodes[node.name]}
+		display := FormatValue(value)
+		if ok {
+			nodeValues[node.name] = value
+		} else {
+			display = tikvCollector.NotSetValue
+		}
+		switch {
+		case !ok && node.proxied:
+			display += " (not collected through TiDB, ignored)"
+		case !row.Majority:
+			if len(deviating) == 0 {
+				currentValue = value
+			}
+			deviating = append(deviating, node.name)
+			display += "  <- deviates"
+		}
+		rows[i] = row
+		nodeLines = append(nodeLines, fmt.Sprintf("  %s (%s): %s", node.name, node.instance, display))
+	}
+
+	counted := stats.NodeCount()
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     "tikv",
+		ParameterName: param.name,
+		ParamType:     "config",
+		Severity:      "warning",
+		RiskLevel:     RiskLevelMedium,
+		Message: fmt.Sprintf("Parameter %s has majority value %s on %d/%d TiKV nodes; %d node(s) differ",
+			param.name, majorityValue, counted-stats.OutlierCount, counted, stats.OutlierCount),
+		Details: fmt.Sprintf("Majority value: %s (%.0f%% of the nodes)\n\nPer-node values:\n%s",
+			majorityValue, stats.MajorityPercent, strings.Join(nodeLines, "\n")),
+		CurrentValue:  currentValue,
+		SourceDefault: stats.MajorityValue, // Majority value
+		Suggestions: []string{
+			"This parameter differs between TiKV nodes",
+			"Review if this difference is intentional",
+			"Ensure all TiKV nodes have consistent parameters for scale out",
+		},
+		Metadata: map[string]interface{}{
+			"nodes":             rows,
+			"majority_value":    stats.MajorityValue,
+			"majority_percent":  stats.MajorityPercent,
+			"value_counts":      stats.ValueCounts,
+			"outlier_count":     stats.OutlierCount,
+			"deviating_nodes":   deviating,
+			"baseline_name":     nodes[0].name,
+			"baseline_instance": nodes[0].instance,
+			"config_sources":    tikvConfigSources(anyProxied, false),
+			"node_values":       nodeValues,
+		},
+	}, true
+}
+
+// tikvConfigSources returns the sources of the configs compared for a node and the baseline
+func tikvConfigSources(nodeProxied, baselineProxied bool) []string {
+	sources := []string{"last_tikv.toml", "SHOW CONFIG WHERE type='tikv' AND instance='...'"}
+	if nodeProxied || baselineProxied {
+		sources = append(sources, "information_schema.cluster_config (through TiDB)")
+	}
+	return sources
+}
+
 // determineValueType determines the type of a value
 func determineValueType(v interface{}) string {
 	switch v.(type) {
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	tikvCollector "github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tikv"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -78,7 +79,7 @@ func TestTikvConsistencyRule_Evaluate_SingleTiKVNode(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tikv-0": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage.reserve-space": types.ParameterValue{Value: "2GB", Type: "string"},
 					},
 					Status: map[string]interface{}{
@@ -96,6 +97,44 @@ func TestTikvConsistencyRule_Evaluate_SingleTiKVNode(t *testing.T) {
 	assert.Empty(t, results)
 }
 
+func TestTikvConsistencyRule_Evaluate_SingleNodeCluster(t *testing.T) {
+	rule := NewTikvConsistencyRule()
+	ctx := context.Background()
+
+	// Differing values under the two keys of the same node must not be reported,
+	// since a single-node cluster cannot be inconsistent
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type:   types.ComponentTiDB,
+					Status: map[string]interface{}{"address": "127.0.0.1:4000"},
+				},
+				"tikv": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"raftstore.messages-per-tick": types.ParameterValue{Value: 4096, Type: "int"},
+					},
+					Status: map[string]interface{}{"address": "127.0.0.1:20160"},
+				},
+				"tikv-127-0-0-1-20160": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"raftstore.messages-per-tick": types.ParameterValue{Value: 1024, Type: "int"},
+					},
+					Status: map[string]interface{}{"address": "127.0.0.1:20160"},
+				},
+			},
+			ClusterInfo: collector.ClusterInfo{TiKVNodeCount: 1},
+		},
+	}
+
+	results, err := rule.Evaluate(ctx, ruleCtx)
+
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+}
+
 func TestTikvConsistencyRule_Evaluate_NoTiDBConnection(t *testing.T) {
 	rule := NewTikvConsistencyRule()
 	ctx := context.Background()
@@ -105,13 +144,13 @@ func TestTikvConsistencyRule_Evaluate_NoTiDBConnection(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tikv-0": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage.reserve-space": types.ParameterValue{Value: "2GB", Type: "string"},
 					},
 				},
 				"tikv-1": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage.reserve-space": types.ParameterValue{Value: "2GB", Type: "string"},
 					},
 				},
@@ -144,7 +183,7 @@ func TestTikvConsistencyRule_Evaluate_ConsistentValues(t *testing.T) {
 				},
 				"tikv-0": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage.reserve-space": types.ParameterValue{Value: "2GB", Type: "string"},
 					},
 					Status: map[string]interface{}{
@@ -153,7 +192,7 @@ func TestTikvConsistencyRule_Evaluate_ConsistentValues(t *testing.T) {
 				},
 				"tikv-1": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage.reserve-space": types.ParameterValue{Value: "2GB", Type: "string"},
 					},
 					Status: map[string]interface{}{
@@ -192,7 +231,7 @@ func TestTikvConsistencyRule_Evaluate_InconsistentValues(t *testing.T) {
 				},
 				"tikv-0": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage.reserve-space": types.ParameterValue{Value: "2GB", Type: "string"},
 					},
 					Status: map[string]interface{}{
@@ -201,7 +240,7 @@ func TestTikvConsistencyRule_Evaluate_InconsistentValues(t *testing.T) {
 				},
 				"tikv-1": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage.reserve-space": types.ParameterValue{Value: "4GB", Type: "string"},
 					},
 					Status: map[string]interface{}{
@@ -222,6 +261,175 @@ func TestTikvConsistencyRule_Evaluate_InconsistentValues(t *testing.T) {
 	_ = results
 }
 
+func TestTikvConsistencyRule_Evaluate_ProxiedNodes(t *testing.T) {
+	rule := NewTikvConsistencyRule()
+
+	// tikv-b answered over HTTP, tikv-a only through TiDB's information_schema.cluster_config
+	// No TiDB component: the rule compares the collected configs without querying SHOW CONFIG
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv-b": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"storage.reserve-space":     types.ParameterValue{Value: "2GB", Type: "string"},
+						"raftstore.store-pool-size": types.ParameterValue{Value: 2, Type: "int"},
+					},
+					Status: map[string]interface{}{"address": "10.0.0.2:20180"},
+				},
+				"tikv-a": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"storage.reserve-space": types.ParameterValue{Value: "4GB", Type: "string"},
+					},
+					Status: map[string]interface{}{
+						"address":                           "10.0.0.1:20180",
+						tikvCollector.StatusKeyInstance:     "10.0.0.1:20160",
+						tikvCollector.StatusKeyCollectedVia: tikvCollector.CollectedViaTiDBProxy,
+					},
+				},
+			},
+		},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	assert.NoError(t, err)
+
+	// The parameter missing from the proxied node is not reported, only the differing value
+	if assert.Len(t, results, 1) {
+		result := results[0]
+		assert.Equal(t, "storage.reserve-space", result.ParameterName)
+		// Nodes are ordered by instance: the proxied node 10.0.0.1:20160 is the baseline
+		assert.Equal(t, "10.0.0.1:20160", result.Metadata["baseline_instance"])
+		// With one node per value, the baseline value is the majority
+		assert.Equal(t, []string{"tikv-b"}, result.Metadata["deviating_nodes"])
+		assert.Equal(t, "4GB", result.Metadata["majority_value"])
+		assert.Contains(t, result.Metadata["config_sources"], "information_schema.cluster_config (through TiDB)")
+		assert.Equal(t, map[string]interface{}{"tikv-a": "4GB", "tikv-b": "2GB"}, result.Metadata["node_values"])
+	}
+}
+
+func TestTikvConsistencyRule_Evaluate_DeviatingNode(t *testing.T) {
+	rule := NewTikvConsistencyRule()
+
+	node := func(address, reserveSpace string) collector.ComponentState {
+		return collector.ComponentState{
+			Type: types.ComponentTiKV,
+			Config: types.ParameterMap{
+				"storage.reserve-space":     types.ParameterValue{Value: reserveSpace, Type: "string"},
+				"raftstore.store-pool-size": types.ParameterValue{Value: 2, Type: "int"},
+			},
+			Status: map[string]interface{}{"address": address},
+		}
+	}
+	// The baseline (first by instance) is the deviating node
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv-0": node("10.0.0.1:20180", "2GB"),
+				"tikv-1": node("10.0.0.2:20180", "5GB"),
+				"tikv-2": node("10.0.0.3:20180", "5GB"),
+			},
+		},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	assert.NoError(t, err)
+
+	// One finding for the parameter, not one per deviating node
+	if assert.Len(t, results, 1) {
+		result := results[0]
+		assert.Equal(t, "storage.reserve-space", result.ParameterName)
+		assert.Equal(t, "5GB", result.SourceDefault)
+		assert.Equal(t, "2GB", result.CurrentValue)
+		assert.Equal(t, []string{"tikv-0"}, result.Metadata["deviating_nodes"])
+		assert.Contains(t, result.Message, "has majority value \"5GB\" on 2/3 TiKV nodes; 1 node(s) differ")
+		assert.Equal(t, []TikvNodeValue{
+			{Name: "tikv-0", Instance: "10.0.0.1:20180", Value: "2GB", Majority: false},
+			{Name: "tikv-1", Instance: "10.0.0.2:20180", Value: "5GB", Majority: true},
+			{Name: "tikv-2", Instance: "10.0.0.3:20180", Value: "5GB", Majority: true},
+		}, result.Metadata["nodes"])
+	}
+}
+
+func TestTikvConsistencyRule_Evaluate_EmptyValueDeviatesFromNotSet(t *testing.T) {
+	rule := NewTikvConsistencyRule()
+
+	node := func(address string, config types.ParameterMap) collector.ComponentState {
+		return collector.ComponentState{Type: types.ComponentTiKV, Config: config, Status: map[string]interface{}{"address": address}}
+	}
+	// Most nodes don't set the parameter, one sets it to an empty string
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv-0": node("10.0.0.1:20180", types.ParameterMap{}),
+				"tikv-1": node("10.0.0.2:20180", types.ParameterMap{}),
+				"tikv-2": node("10.0.0.3:20180", types.ParameterMap{
+					"log.file.filename": types.ParameterValue{Value: "", Type: "string"},
+				}),
+			},
+		},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	assert.NoError(t, err)
+
+	if assert.Len(t, results, 1) {
+		result := results[0]
+		assert.Contains(t, result.Message, "has majority value (not set) on 2/3 TiKV nodes; 1 node(s) differ")
+		assert.Equal(t, []string{"tikv-2"}, result.Metadata["deviating_nodes"])
+		assert.Equal(t, []TikvNodeValue{
+			{Name: "tikv-0", Instance: "10.0.0.1:20180", Missing: true, Majority: true},
+			{Name: "tikv-1", Instance: "10.0.0.2:20180", Missing: true, Majority: true},
+			{Name: "tikv-2", Instance: "10.0.0.3:20180", Value: "", Majority: false},
+		}, result.Metadata["nodes"])
+	}
+}
+
+func TestTikvConsistencyRule_Evaluate_AllParameters(t *testing.T) {
+	rule := NewTikvConsistencyRule()
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv-0": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"server.addr":                       types.ParameterValue{Value: "10.0.0.1:20160", Type: "string"},
+						"server.labels":                     types.ParameterValue{Value: map[string]interface{}{"zone": "z1"}, Type: "map"},
+						"storage.block-cache.capacity":      types.ParameterValue{Value: "8GiB", Type: "string"},
+						"readpool.unified.max-thread-count": types.ParameterValue{Value: 8, Type: "int"},
+					},
+					Status: map[string]interface{}{"address": "10.0.0.1:20180"},
+				},
+				"tikv-1": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"server.addr":                  types.ParameterValue{Value: "10.0.0.2:20160", Type: "string"},
+						"server.labels":                types.ParameterValue{Value: map[string]interface{}{"zone": "z2"}, Type: "map"},
+						"storage.block-cache.capacity": types.ParameterValue{Value: "16GiB", Type: "string"},
+						// Set on this node only
+						"coprocessor.region-split-size":     types.ParameterValue{Value: "256MiB", Type: "string"},
+						"readpool.unified.max-thread-count": types.ParameterValue{Value: 8, Type: "int"},
+					},
+					Status: map[string]interface{}{"address": "10.0.0.2:20180"},
+				},
+			},
+		},
+		ConsistencyIgnore: ConsistencyIgnore{"tikv": {"server.addr", "server.labels"}},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, result := range results {
+		names = append(names, result.ParameterName)
+	}
+	// Every parameter of any node is compared, except the ignored ones (including the fields of server.labels)
+	assert.Equal(t, []string{"coprocessor.region-split-size", "storage.block-cache.capacity"}, names)
+}
+
 func TestDetermineValueType(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -5,21 +5,29 @@ import (
 	"context"
 	"fmt"
 	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 )
 
 // UpgradeDifferencesRule detects parameters that will differ after upgrade
 // Rule 2.2: Compare current cluster values with target version defaults
-// and identify forced changes from upgrade logic
+// Forced changes from upgrade logic are reported by ForcedChangesRule
 type UpgradeDifferencesRule struct {
 	*BaseRule
 }
 
+// defaultChangedSuggestions are shared by all default-changed results instead of allocating a slice per parameter
+var defaultChangedSuggestions = []string{
+	"Default value has changed in target version",
+	"Review if the new default is acceptable",
+}
+
 // NewUpgradeDifferencesRule creates a new upgrade differences rule
 func NewUpgradeDifferencesRule() Rule {
 	return &UpgradeDifferencesRule{
 		BaseRule: NewBaseRule(
 			"UPGRADE_DIFFERENCES",
-			"Detect parameters that will differ after upgrade, including forced changes",
+			"Detect parameters that will differ after upgrade because their default changed",
 			"upgrade_difference",
 		),
 	}
@@ -29,10 +37,14 @@ func NewUpgradeDifferencesRule() Rule {
 func (r *UpgradeDifferencesRule) DataRequirements() DataSourceRequirement {
 	return DataSourceRequirement{
 		SourceClusterRequirements: struct {
-			Components          []string `json:"components"`
-			NeedConfig          bool     `json:"need_config"`
-			NeedSystemVariables bool     `json:"need_system_variables"`
-			NeedAllTikvNodes    bool     `json:"need_all_tikv_nodes"`
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
 		}{
 			Components:          []string{"tidb", "pd", "tikv", "tiflash"},
 			NeedConfig:          true,
@@ -48,7 +60,7 @@ func (r *UpgradeDifferencesRule) DataRequirements() DataSourceRequirement {
 			Components:          []string{"tidb", "pd", "tikv", "tiflash"},
 			NeedConfigDefaults:  true,
 			NeedSystemVariables: true,
-			NeedUpgradeLogic:    true, // Need upgrade logic for forced changes
+			NeedUpgradeLogic:    true, // Need upgrade logic to skip forced changes
 		},
 	}
 }
@@ -56,13 +68,11 @@ func (r *UpgradeDifferencesRule) DataRequirements() DataSourceRequirement {
 // Evaluate performs the rule check
 // Logic:
 // 1. Compare target version defaults with current cluster values
-//   - If in upgrade_logic.json (forced change):
-//   - If forced value != current value: warning (medium risk)
-//   - If forced value == current value: info (default value changed)
-//   - If not in upgrade_logic.json:
-//   - If target default != current value: info (default value changed)
+//   - If in upgrade_logic.json (forced change): skip, reported by FORCED_CHANGES
+//   - If target default != current value: warning or info (default value changed)
 //
-// 2. If parameter exists in target version but not in current cluster: info (new parameter)
+// 2. If parameter exists in target version but neither in source version nor in current cluster: info (new_in_target)
+// Deployment-specific parameters (knowledge/deployment_specific.json) are skipped in both steps
 // Note: Source version comparison is handled by USER_MODIFIED_PARAMS rule, not here
 func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
 	var results []CheckResult
@@ -74,37 +84,14 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 		return results, nil
 	}
 
-	// Get forced changes for each component
-	forcedChangesByComponent := make(map[string]map[string]interface{})
-	for _, comp := range []string{"tidb", "pd", "tikv", "tiflash"} {
-		forcedChanges := ruleCtx.GetForcedChanges(comp)
-		forcedChangesByComponent[comp] = forcedChanges
-	}
-
-	// Process each component
-	for compName, component := range ruleCtx.SourceClusterSnapshot.Components {
-		// Determine component type
-		compType := string(component.Type)
-		if compType == "" {
-			if strings.HasPrefix(compName, "tidb") {
-				compType = "tidb"
-			} else if strings.HasPrefix(compName, "pd") {
-				compType = "pd"
-			} else if strings.HasPrefix(compName, "tikv") {
-				compType = "tikv"
-			} else if strings.HasPrefix(compName, "tiflash") {
-				compType = "tiflash"
-			} else {
-				continue
-			}
-		}
-
-		// For TiKV, only check the first instance to avoid duplicates
-		if compType == "tikv" && compName != "tikv" && !strings.HasPrefix(compName, "tikv-") {
+	// Process one instance per component type, the target defaults apply to the whole component
+	for _, compType := range []string{"tidb", "pd", "tikv", "tiflash"} {
+		component, ok := ruleCtx.SourceClusterSnapshot.ComponentByType(defaultsTypes.ComponentType(compType))
+		if !ok {
 			continue
 		}
 
-		forcedChanges := forcedChangesByComponent[compType]
+		forcedChanges := ruleCtx.GetForcedChanges(compType)
 
 		// Get all parameters from target version knowledge base
 		targetDefaults := ruleCtx.TargetDefaults[compType]
@@ -112,14 +99,30 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 			return nil, fmt.Errorf("targetDefaults for component %s is nil - this indicates a knowledge base loading issue. Please check if the target version knowledge base was loaded correctly. Component: %s, SourceVersion: %s, TargetVersion: %s", compType, compType, ruleCtx.SourceVersion, ruleCtx.TargetVersion)
 		}
 
-		// Track which parameters we've processed
-		processedParams := make(map[string]bool)
+		// Target parameters not set in the cluster, candidates for the parameters introduced by the target version
+		var notInCluster []string
+
+		// Parameters that move to another section (knowledge/section_migrations.json) are reported once with
+		// both keys, instead of the old key disappearing and the new key appearing
+		movedParams := ruleCtx.GetMovedParameters(compType, component.Config)
+		movedOldKeys := make(map[string]bool, len(movedParams))
+		for _, moved := range movedParams {
+			movedOldKeys[moved.OldKey] = true
+		}
+		// Parameters renamed in the target version (knowledge/rename_map.json) are reported with their new name
+		renamedParams := ruleCtx.GetRenamedParameters(compType)
 
 		// 1. Check parameters that exist in target version (compare with current cluster)
 		for paramName, targetDefaultValue := range targetDefaults {
-			processedParams[paramName] = true
 			totalCompared++
 
+			// Skip deployment-specific parameters (addresses, directories, log files, ...)
+			// Their values legitimately differ from the knowledge base defaults
+			if ruleCtx.DeploymentSpecificParams.Contains(compType, paramName) {
+				totalFiltered++
+				continue
+			}
+
 			// Extract actual value from ParameterValue structure
 			targetDefault := extractValueFromDefault(targetDefaultValue)
 
@@ -146,8 +149,12 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 						return nil, fmt.Errorf("system variable %s in component %s has nil value - this indicates a data collection issue. Component: %s, Parameter: %s, SourceVersion: %s, TargetVersion: %s", varName, compType, compType, varName, ruleCtx.SourceVersion, ruleCtx.TargetVersion)
 					}
 				} else {
-					// System variable not in current cluster - this is a new parameter, will be handled in Step 2
-					// Skip here to avoid duplicate reporting
+					if renamed, ok := renamedParams[paramName]; ok {
+						results = append(results, r.renamedParameterResult(ruleCtx, compType, renamed, *component, targetDefault))
+					} else {
+						// System variable not in current cluster - it may be a new parameter, handled in Step 2
+						notInCluster = append(notInCluster, paramName)
+					}
 					continue
 				}
 			} else {
@@ -159,122 +166,30 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 						return nil, fmt.Errorf("config parameter %s in component %s has nil value - this indicates a data collection issue. Component: %s, Parameter: %s, SourceVersion: %s, TargetVersion: %s", paramName, compType, compType, paramName, ruleCtx.SourceVersion, ruleCtx.TargetVersion)
 					}
 				} else {
-					// Config parameter not in current cluster - this is a new parameter, will be handled in Step 2
-					// Skip here to avoid duplicate reporting
+					if moved, ok := movedParams[paramName]; ok {
+						results = append(results, r.sectionMigrationResult(ruleCtx, moved, targetDefault))
+					} else if renamed, ok := renamedParams[paramName]; ok {
+						results = append(results, r.renamedParameterResult(ruleCtx, compType, renamed, *component, targetDefault))
+					} else {
+						// Config parameter not in current cluster - it may be a new parameter, handled in Step 2
+						notInCluster = append(notInCluster, paramName)
+					}
 					continue
 				}
 			}
 
 			// Compare target default with current cluster value
+			// The current value is normalized to the type of the default ("true" vs true, "1024" vs 1024)
 			// Use proper value comparison to avoid scientific notation issues
-			targetDiffersFromCurrent := !CompareValues(targetDefault, currentValue)
-
-			// Check if this parameter is in upgrade_logic.json (forced change)
-			// First check if there's a forced change entry for this parameter
-			fallbackForcedValue, hasForcedChange := forcedChanges[displayName]
-
-			// Get the forced value that matches the current value (using from_value matching)
-			forcedValue := ruleCtx.GetForcedChangeForValue(compType, displayName, currentValue)
+			normalizedCurrent := defaultsTypes.NormalizeParamValue(currentValue, extractTypeFromDefault(targetDefaultValue))
+			targetDiffersFromCurrent := !CompareValues(targetDefault, normalizedCurrent)
 
-			// If no matching from_value found, use fallback value (for entries without from_value)
-			if forcedValue == nil && hasForcedChange {
-				forcedValue = fallbackForcedValue
+			// Parameters with a forced change in upgrade_logic.json are reported by FORCED_CHANGES
+			if _, forced := resolveForcedValue(ruleCtx, forcedChanges, compType, displayName, currentValue); forced {
+				continue
 			}
 
-			if hasForcedChange && forcedValue != nil {
-				// This parameter is in upgrade_logic.json and we found a matching entry
-				// Use proper value comparison to avoid scientific notation issues
-				if !CompareValues(forcedValue, currentValue) {
-					// Get special handling metadata from knowledge base
-					metadata := ruleCtx.GetForcedChangeMetadata(compType, displayName, currentValue)
-
-					// Determine severity: use metadata override if available, otherwise use default logic
-					severity := "warning"
-					riskLevel := RiskLevelMedium
-					if metadata != nil && metadata.ReportSeverity != "" {
-						// Use severity from knowledge base
-						severity = metadata.ReportSeverity
-						switch severity {
-						case "error":
-							riskLevel = RiskLevelHigh
-						case "warning":
-							riskLevel = RiskLevelMedium
-						case "info":
-							riskLevel = RiskLevelLow
-						default:
-							riskLevel = RiskLevelMedium
-						}
-					} else if compType == "tidb" {
-						// Default: Most TiDB forced changes are error
-						severity = "error"
-						riskLevel = RiskLevelHigh
-					}
-
-					// Build details for forced change
-					forcedStr := FormatValue(forcedValue)
-					currentStr := FormatValue(currentValue)
-					targetStr := FormatValue(targetDefault)
-					details := fmt.Sprintf("Will be forced to: %s\n\nCurrent: %s\nTarget Default: %s", forcedStr, currentStr, targetStr)
-
-					// Add details note from knowledge base if available
-					if metadata != nil && metadata.DetailsNote != "" {
-						details += "\n\n" + metadata.DetailsNote
-					}
-
-					// Get suggestions: use metadata if available, otherwise use default
-					var suggestions []string
-					if metadata != nil && len(metadata.Suggestions) > 0 {
-						suggestions = metadata.Suggestions
-					} else {
-						// Default suggestions for forced changes
-						suggestions = []string{
-							"This parameter will be forcibly changed during upgrade",
-							"Review the forced change and its impact",
-							"Test the new value in a staging environment",
-							"Plan for the change before upgrading",
-						}
-					}
-
-					results = append(results, CheckResult{
-						RuleID:        r.Name(),
-						Category:      r.Category(),
-						Component:     compType,
-						ParameterName: displayName,
-						ParamType:     paramType,
-						Severity:      severity,
-						RiskLevel:     riskLevel,
-						Message:       fmt.Sprintf("Parameter %s in %s will be forcibly changed during upgrade (forced value differs from current)", displayName, compType),
-						Details:       details,
-						CurrentValue:  currentValue,
-						TargetDefault: targetDefault,
-						ForcedValue:   forcedValue,
-						Suggestions:   suggestions,
-					})
-				} else {
-					// Forced value equals current value: info (default value changed)
-					currentStr := FormatValue(currentValue)
-					targetStr := FormatValue(targetDefault)
-					details := fmt.Sprintf("Current value matches forced value.\n\nCurrent: %s\nTarget Default: %s", currentStr, targetStr)
-					results = append(results, CheckResult{
-						RuleID:        r.Name(),
-						Category:      r.Category(),
-						Component:     compType,
-						ParameterName: displayName,
-						ParamType:     paramType,
-						Severity:      "info",
-						RiskLevel:     RiskLevelLow,
-						Message:       fmt.Sprintf("Parameter %s in %s: default value changed (forced change matches current value)", displayName, compType),
-						Details:       details,
-						CurrentValue:  currentValue,
-						TargetDefault: targetDefault,
-						ForcedValue:   forcedValue,
-						Suggestions: []string{
-							"Default value has changed in target version",
-							"Your current value matches the forced value, so no change will occur",
-						},
-					})
-				}
-			} else if targetDiffersFromCurrent {
+			if targetDiffersFromCurrent {
 				// Not in upgrade_logic.json, but target default differs from current
 				// Special handling for PD and system variables
 				severity := "warning"
@@ -295,6 +210,8 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 					baseMessage = "default value changed (current value will be kept)"
 				}
 
+				sourceDefault := ruleCtx.GetSourceDefault(compType, paramName)
+
 				// For map types, create separate CheckResult for each differing field
 				if IsMapType(currentValue) && IsMapType(targetDefault) {
 					opts := CompareOptions{
@@ -305,8 +222,18 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 					// Convert currentValue to map for field extraction
 					currentMap := ConvertToMapStringInterface(currentValue)
 					targetMap := ConvertToMapStringInterface(targetDefault)
+					sourceMap := ConvertToMapStringInterface(sourceDefault)
 
 					for fieldPath := range currentTargetDiffs {
+						if ruleCtx.DeploymentSpecificParams.Contains(compType, paramName+"."+fieldPath) {
+							totalFiltered++
+							continue
+						}
+						// Moved fields are reported with their new key
+						if movedOldKeys[displayName+"."+fieldPath] {
+							continue
+						}
+
 						// Extract current value for this specific field from the map
 						var currentFieldValue interface{}
 						if currentMap != nil {
@@ -325,6 +252,12 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 							targetFieldValue = targetDefault
 						}
 
+						// Extract source default value for this field (nil if the source default is not a map)
+						var sourceFieldValue interface{}
+						if sourceMap != nil {
+							sourceFieldValue = getNestedMapValue(sourceMap, strings.Split(fieldPath, "."))
+						}
+
 						fieldMessage := fmt.Sprintf("Parameter %s.%s in %s: %s", displayName, fieldPath, compType, baseMessage)
 						// Format details: Current vs Target
 						fieldDetails := fmt.Sprintf("Current: %s\nTarget Default: %s", FormatValue(currentFieldValue), FormatValue(targetFieldValue))
@@ -360,23 +293,26 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 							Details:       fieldDetails,
 							CurrentValue:  currentFieldValue,
 							TargetDefault: targetFieldValue,
-							Suggestions: []string{
-								"Default value has changed in target version",
-								"Review if the new default is acceptable",
-							},
+							Suggestions:   defaultChangedSuggestions,
+							Metadata:      defaultsMetadata(sourceFieldValue, targetFieldValue),
 						})
 					}
 				} else {
 					// For non-map types, use simple format
 					currentStr := FormatValue(currentValue)
 					targetStr := FormatValue(targetDefault)
-					details := fmt.Sprintf("Current: %s\nTarget Default: %s", currentStr, targetStr)
+					details := "Current: " + currentStr + "\nTarget Default: " + targetStr
 					if compType == "pd" && paramType == "config" {
 						details += "\n\nCurrent value will be kept.\n\nPD maintains existing configuration"
 					} else if compType == "tidb" && paramType == "system_variable" {
 						details += "\n\nCurrent value will be kept.\n\nTiDB system variables keep old values"
 					}
 
+					// Tell in which version the default changed, if the parameter history is available
+					if defaultHistory := ruleCtx.DescribeDefaultHistory(compType, paramName); defaultHistory != "" {
+						details += "\n\n" + defaultHistory
+					}
+
 					// Get special note from knowledge base
 					paramNote := ruleCtx.GetParameterNote(compType, displayName, paramType, targetDefault)
 					if paramNote != "" {
@@ -391,118 +327,163 @@ func (r *UpgradeDifferencesRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 						ParamType:     paramType,
 						Severity:      severity,
 						RiskLevel:     riskLevel,
-						Message:       fmt.Sprintf("Parameter %s in %s: %s", displayName, compType, baseMessage),
+						Message:       "Parameter " + displayName + " in " + compType + ": " + baseMessage,
 						Details:       details,
 						CurrentValue:  currentValue,
 						TargetDefault: targetDefault,
-						Suggestions: []string{
-							"Default value has changed in target version",
-							"Review if the new default is acceptable",
-						},
+						Suggestions:   defaultChangedSuggestions,
+						Metadata:      defaultsMetadata(sourceDefault, targetDefault),
 					})
 				}
 			}
 			// Otherwise: target default == current value, skip (no difference)
 		}
 
-		// 2. Check parameters that exist in target version but not in current cluster (new parameter)
-		// Note: Deprecated parameters (exist in source but not in target) are not checked here
-		// as source version comparison is handled by USER_MODIFIED_PARAMS rule
-		for paramName, targetDefaultValue := range targetDefaults {
-			// Skip if already processed in step 1 (exists in current cluster)
-			if processedParams[paramName] {
+		// 2. Report the parameters introduced by the target version: absent from both the source KB and the cluster
+		// Parameters of the source KB that were not collected are not reported, nothing is known of their value
+		// Without source KB for the component, new parameters cannot be told apart from the others
+		if ruleCtx.SourceDefaults[compType] == nil || ruleCtx.IsMissingInSourceKB(compType) {
+			continue
+		}
+		for _, paramName := range notInCluster {
+			if ruleCtx.GetSourceDefault(compType, paramName) != nil {
 				continue
 			}
-
-			// Extract target default value
-			targetDefault := extractValueFromDefault(targetDefaultValue)
-			if targetDefault == nil {
-				return nil, fmt.Errorf("targetDefault for parameter %s in component %s is nil - this indicates a knowledge base loading issue. Component: %s, Parameter: %s, SourceVersion: %s, TargetVersion: %s. Please check if the target version knowledge base was loaded correctly", paramName, compType, compType, paramName, ruleCtx.SourceVersion, ruleCtx.TargetVersion)
+			targetDefaultValue := targetDefaults[paramName]
+			displayName, paramType := paramName, "config"
+			if strings.HasPrefix(paramName, "sysvar:") {
+				displayName, paramType = strings.TrimPrefix(paramName, "sysvar:"), "system_variable"
 			}
+			results = append(results, newInTargetResult(r, ruleCtx, compType, displayName, paramType, targetDefaultValue, extractValueFromDefault(targetDefaultValue)))
+		}
+	}
 
-			var paramType string
-			var displayName string
+	// Report statistics (stripped from the findings by RuleRunner)
+	results = append(results, NewStatisticsResult(r, RuleStatistics{
+		ParametersExamined: totalCompared,
+		ParametersCompared: totalCompared,
+		ParametersFiltered: totalFiltered,
+	}))
 
-			isSystemVar := strings.HasPrefix(paramName, "sysvar:")
-			if isSystemVar {
-				displayName = strings.TrimPrefix(paramName, "sysvar:")
-				paramType = "system_variable"
-			} else {
-				displayName = paramName
-				paramType = "config"
-			}
-
-			// Note: Deployment-specific parameters have already been filtered in preprocessor
-			// This rule only processes parameters that passed the preprocessor filter
+	return results, nil
+}
 
-			// Check if this new parameter exists in current cluster
-			var currentValue interface{}
-			if isSystemVar {
-				if varValue, ok := component.Variables[displayName]; ok {
-					currentValue = varValue.Value
-				}
-			} else {
-				if paramValue, ok := component.Config[displayName]; ok {
-					currentValue = paramValue.Value
-				}
-			}
+// newInTargetResult reports a parameter introduced by the target version, with the new_in_target category
+// The type of the parameter in the target KB is kept in the metadata
+func newInTargetResult(r *UpgradeDifferencesRule, ruleCtx *RuleContext, compType, displayName, paramType string, targetDefaultValue, targetDefault interface{}) CheckResult {
+	metadata := defaultsMetadata(nil, targetDefault)
+	if valueType := extractTypeFromDefault(targetDefaultValue); valueType != "" {
+		metadata["type"] = valueType
+	}
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      "new_in_target",
+		Component:     compType,
+		ParameterName: displayName,
+		ParamType:     paramType,
+		Severity:      "info",
+		RiskLevel:     RiskLevelLow,
+		Message:       fmt.Sprintf("Parameter %s in %s is new in %s", displayName, compType, ruleCtx.TargetVersion),
+		Details:       fmt.Sprintf("Default: %s. This parameter does not exist in %s.", FormatValue(targetDefault), ruleCtx.SourceVersion),
+		TargetDefault: targetDefault,
+		Suggestions: []string{
+			"Review the new parameter and its default value",
+			"Consider configuring it if needed",
+		},
+		Metadata: metadata,
+	}
+}
 
-			// Filter: If current value equals target default, skip (no action needed after upgrade)
-			if currentValue != nil && targetDefault != nil {
-				if CompareValues(currentValue, targetDefault) {
-					// For PD component, still report new parameters even if current == target
-					if compType == "pd" && paramType == "config" {
-						// Don't filter PD new parameters, let them be reported
-					} else {
-						// Current value equals target default, no action needed
-						totalFiltered++
-						continue
-					}
-				}
-			}
+// sectionMigrationResult reports a parameter that moves from its old key to its new key
+// targetDefault is the target default of the new key
+func (r *UpgradeDifferencesRule) sectionMigrationResult(ruleCtx *RuleContext, migration MovedParameter, targetDefault interface{}) CheckResult {
+	oldValue := migration.OldValue
+	details := fmt.Sprintf("Old key: %s (current: %s)\nNew key: %s (target default: %s)\n\n%s is replaced by %s in %s. A value set under the old key in the configuration file must be set under the new key to be kept",
+		migration.OldKey, FormatValue(oldValue), migration.NewKey, FormatValue(targetDefault),
+		migration.OldKey, migration.NewKey, migration.ToVersion)
+
+	metadata := defaultsMetadata(ruleCtx.GetSourceDefault(migration.Component, migration.OldKey), targetDefault)
+	metadata["old_key"] = migration.OldKey
+	metadata["new_key"] = migration.NewKey
+
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     migration.Component,
+		ParameterName: migration.NewKey,
+		ParamType:     "config",
+		Severity:      "warning",
+		RiskLevel:     RiskLevelMedium,
+		Message:       fmt.Sprintf("Parameter %s in %s moved to %s", migration.OldKey, migration.Component, migration.NewKey),
+		Details:       details,
+		CurrentValue:  oldValue,
+		TargetDefault: targetDefault,
+		Suggestions: []string{
+			fmt.Sprintf("Move the setting from %s to %s in the configuration file", migration.OldKey, migration.NewKey),
+			"Check that the value of the new key is the expected one after upgrade",
+		},
+		Metadata: metadata,
+	}
+}
 
-			// New parameter: info
-			message := fmt.Sprintf("Parameter %s in %s is new (added in target version)", displayName, compType)
-			details := fmt.Sprintf("Target default: %v. This is a new parameter in target version.", targetDefault)
-			if currentValue != nil {
-				message = fmt.Sprintf("Parameter %s in %s is new (added in target version, already configured in cluster)", displayName, compType)
-				details = fmt.Sprintf("Current cluster value: %v, Target default: %v. This is a new parameter in target version.", currentValue, targetDefault)
-			}
+// renamedParameterResult reports a parameter renamed in the target version, instead of its old name disappearing
+// targetDefault is the target default of the new name
+func (r *UpgradeDifferencesRule) renamedParameterResult(ruleCtx *RuleContext, compType string, renamed RenamedParameter, component defaultsTypes.ComponentState, targetDefault interface{}) CheckResult {
+	oldName, paramType := displayParameterName(renamed.OldName)
+	newName, _ := displayParameterName(renamed.NewName)
 
-			results = append(results, CheckResult{
-				RuleID:        r.Name(),
-				Category:      r.Category(),
-				Component:     compType,
-				ParameterName: displayName,
-				ParamType:     paramType,
-				Severity:      "info",
-				RiskLevel:     RiskLevelLow,
-				Message:       message,
-				Details:       details,
-				CurrentValue:  currentValue,
-				TargetDefault: targetDefault,
-				Suggestions: []string{
-					"This is a new parameter in target version",
-					"Review the new parameter and its default value",
-					"Consider configuring it if needed",
-				},
-			})
+	var currentValue interface{}
+	if paramType == "system_variable" {
+		if value, ok := component.Variables[oldName]; ok {
+			currentValue = value.Value
 		}
+	} else if value, ok := lookupConfigValue(component.Config, oldName); ok {
+		currentValue = value
 	}
 
-	// Add a special CheckResult to pass statistics (if we compared any parameters)
-	// This will be filtered out in the reporter, but analyzer can extract it
-	if totalCompared > 0 {
-		results = append(results, CheckResult{
-			RuleID:        r.Name() + "_STATS",
-			Category:      r.Category(),
-			Component:     "",
-			ParameterName: "__statistics__",
-			Description:   fmt.Sprintf("Compared %d parameters, filtered %d (deployment-specific)", totalCompared, totalFiltered),
-			Severity:      "info",
-			RiskLevel:     RiskLevelLow,
-		})
+	oldDescription := oldName
+	suggestion := fmt.Sprintf("Rename %s to %s in the configuration file (e.g., with tiup cluster edit-config)", oldName, newName)
+	if paramType == "system_variable" {
+		suggestion = fmt.Sprintf("Use %s instead of %s in scripts and applications", newName, oldName)
 	}
+	if currentValue != nil {
+		oldDescription = fmt.Sprintf("%s (current: %s)", oldName, FormatValue(currentValue))
+		if paramType == "system_variable" {
+			suggestion = fmt.Sprintf("SET GLOBAL %s = '%v'", newName, currentValue)
+		}
+	}
+	details := fmt.Sprintf("Old name: %s\nNew name: %s (target default: %s)\n\n%s is renamed to %s in %s",
+		oldDescription, newName, FormatValue(targetDefault), oldName, newName, ruleCtx.TargetVersion)
+
+	metadata := defaultsMetadata(ruleCtx.GetSourceDefault(compType, renamed.OldName), targetDefault)
+	metadata["old_name"] = oldName
+	metadata["new_name"] = newName
+
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     compType,
+		ParameterName: newName,
+		ParamType:     paramType,
+		Severity:      "info",
+		RiskLevel:     RiskLevelLow,
+		Message:       fmt.Sprintf("Parameter %s in %s is renamed to %s", oldName, compType, newName),
+		Details:       details,
+		CurrentValue:  currentValue,
+		TargetDefault: targetDefault,
+		Suggestions: []string{
+			suggestion,
+			"Check that the value of the new name is the expected one after upgrade",
+		},
+		Metadata: metadata,
+	}
+}
 
-	return results, nil
+// defaultsMetadata returns the metadata of an upgrade difference: the source and target defaults
+// The source default is nil for parameters missing from the source version knowledge base
+func defaultsMetadata(sourceDefault, targetDefault interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"source_default": sourceDefault,
+		"target_default": targetDefault,
+	}
 }
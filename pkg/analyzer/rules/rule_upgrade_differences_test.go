@@ -7,6 +7,7 @@ import (
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // Note: These tests use cleaned defaults (filtered parameters already removed).
@@ -38,7 +39,7 @@ func TestUpgradeDifferencesRule_DataRequirements(t *testing.T) {
 	assert.True(t, req.TargetKBRequirements.NeedUpgradeLogic)
 }
 
-func TestUpgradeDifferencesRule_Evaluate_ForcedConfigChange(t *testing.T) {
+func TestUpgradeDifferencesRule_Evaluate_DefaultValueChanged(t *testing.T) {
 	rule := NewUpgradeDifferencesRule()
 	ctx := context.Background()
 
@@ -47,7 +48,7 @@ func TestUpgradeDifferencesRule_Evaluate_ForcedConfigChange(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 					},
 				},
@@ -65,46 +66,30 @@ func TestUpgradeDifferencesRule_Evaluate_ForcedConfigChange(t *testing.T) {
 				"max-connections": 2000,
 			},
 		},
-		UpgradeLogic: map[string]interface{}{
-			"tidb": map[string]interface{}{
-				"changes": []interface{}{
-					map[string]interface{}{
-						"version":           "150", // Bootstrap version as string
-						"bootstrap_version": 150,   // Bootstrap version in range (140 < 150 <= 160)
-						"name":              "max-connections",
-						"value":             3000,
-						"operation":         "UPDATE",
-						"severity":          "medium",
-					},
-				},
-			},
-		},
+		UpgradeLogic: map[string][]UpgradeLogicChange{},
 	}
 
-	// Mock bootstrap version for source and target
-	ruleCtx.SourceBootstrapVersion = 140
-	ruleCtx.TargetBootstrapVersion = 160
-
 	results, err := rule.Evaluate(ctx, ruleCtx)
 
 	assert.NoError(t, err)
 	assert.NotEmpty(t, results)
 	
-	// Should detect forced change
+	// Should detect that value will differ from target default
 	found := false
 	for _, result := range results {
-		if result.ParameterName == "max-connections" && result.ForcedValue != nil {
+		if result.ParameterName == "max-connections" && result.ForcedValue == nil {
 			found = true
-			assert.Equal(t, "error", result.Severity) // TiDB forced changes are error
-			assert.Equal(t, 3000, result.ForcedValue)
+			assert.Equal(t, "warning", result.Severity)
 			assert.Equal(t, 1000, result.CurrentValue)
+			assert.Equal(t, 2000, result.TargetDefault)
+			assert.Equal(t, map[string]interface{}{"source_default": 1000, "target_default": 2000}, result.Metadata)
 			break
 		}
 	}
-	assert.True(t, found, "Should detect forced config change")
+	assert.True(t, found, "Should detect default value change")
 }
 
-func TestUpgradeDifferencesRule_Evaluate_ForcedSystemVariableChange(t *testing.T) {
+func TestUpgradeDifferencesRule_Evaluate_SkipsForcedChanges(t *testing.T) {
 	rule := NewUpgradeDifferencesRule()
 	ctx := context.Background()
 
@@ -113,63 +98,71 @@ func TestUpgradeDifferencesRule_Evaluate_ForcedSystemVariableChange(t *testing.T
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Variables: types.SystemVariables{
-						"tidb_mem_quota_query": types.ParameterValue{Value: 1073741824, Type: "int"},
+					Config: types.ParameterMap{
+						"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 					},
 				},
 			},
 		},
-		SourceVersion: "v7.5.0",
-		TargetVersion: "v8.5.0",
-		SourceDefaults: map[string]map[string]interface{}{
-			"tidb": {
-				"sysvar:tidb_mem_quota_query": 1073741824,
-			},
-		},
+		SourceVersion:          "v7.5.0",
+		TargetVersion:          "v8.5.0",
+		SourceBootstrapVersion: 140,
+		TargetBootstrapVersion: 160,
 		TargetDefaults: map[string]map[string]interface{}{
 			"tidb": {
-				"sysvar:tidb_mem_quota_query": 2147483648,
-			},
-		},
-		UpgradeLogic: map[string]interface{}{
-			"tidb": map[string]interface{}{
-				"changes": []interface{}{
-					map[string]interface{}{
-						"version":           "150", // Bootstrap version as string
-						"bootstrap_version": 150,   // Bootstrap version in range (140 < 150 <= 160)
-						"name":              "tidb_mem_quota_query", // System variable name (without sysvar: prefix)
-						"value":             4294967296,
-						"operation":         "SET @@GLOBAL",
-						"severity":          "medium",
-					},
-				},
+				"max-connections": 2000,
 			},
 		},
 	}
-
-	ruleCtx.SourceBootstrapVersion = 140
-	ruleCtx.TargetBootstrapVersion = 160
+	ruleCtx.SetUpgradeLogic(map[string][]types.UpgradeParamChange{"tidb": {{Version: "150", Name: "max-connections", Value: 3000}}})
 
 	results, err := rule.Evaluate(ctx, ruleCtx)
 
 	assert.NoError(t, err)
-	assert.NotEmpty(t, results)
-	
-	// Should detect forced system variable change
-	found := false
+	// The forced change is reported by FORCED_CHANGES, not as a default value change
 	for _, result := range results {
-		if result.ParameterName == "tidb_mem_quota_query" && result.ForcedValue != nil {
-			found = true
-			assert.Equal(t, "error", result.Severity) // Forced system variable changes are critical
-			assert.Equal(t, "system_variable", result.ParamType)
-			assert.Equal(t, 4294967296, result.ForcedValue)
-			break
+		assert.NotEqual(t, "max-connections", result.ParameterName)
+	}
+}
+
+func TestUpgradeDifferencesRule_Evaluate_MultiNodeCluster(t *testing.T) {
+	rule := NewUpgradeDifferencesRule()
+
+	tikv := collector.ComponentState{
+		Type:   types.ComponentTiKV,
+		Config: types.ParameterMap{"raftstore.store-pool-size": types.ParameterValue{Value: 2, Type: "int"}},
+	}
+	// The collector keys every node by address and aliases the first node by the type name
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv":                tikv,
+				"tikv-10-0-0-1-20160": tikv,
+				"tikv-10-0-0-2-20160": tikv,
+				"tikv-10-0-0-3-20160": tikv,
+			},
+		},
+		SourceVersion:  "v7.5.0",
+		TargetVersion:  "v8.5.0",
+		SourceDefaults: map[string]map[string]interface{}{"tikv": {"raftstore.store-pool-size": 2}},
+		TargetDefaults: map[string]map[string]interface{}{"tikv": {"raftstore.store-pool-size": 4}},
+		UpgradeLogic:   map[string][]UpgradeLogicChange{},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+
+	// One finding for the parameter, not one per node
+	counts := make(map[string]int)
+	for _, result := range results {
+		if result.ParameterName != "" {
+			counts[result.Component+"/"+result.ParameterName]++
 		}
 	}
-	assert.True(t, found, "Should detect forced system variable change")
+	assert.Equal(t, map[string]int{"tikv/raftstore.store-pool-size": 1}, counts)
 }
 
-func TestUpgradeDifferencesRule_Evaluate_DefaultValueChanged(t *testing.T) {
+func TestUpgradeDifferencesRule_Evaluate_DefaultHistory(t *testing.T) {
 	rule := NewUpgradeDifferencesRule()
 	ctx := context.Background()
 
@@ -178,41 +171,43 @@ func TestUpgradeDifferencesRule_Evaluate_DefaultValueChanged(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 					},
 				},
 			},
 		},
-		SourceVersion: "v7.5.0",
+		SourceVersion: "v6.5.0",
 		TargetVersion: "v8.5.0",
-		SourceDefaults: map[string]map[string]interface{}{
+		TargetDefaults: map[string]map[string]interface{}{
 			"tidb": {
-				"max-connections": 1000,
+				"max-connections": 4000,
 			},
 		},
-		TargetDefaults: map[string]map[string]interface{}{
+		ParameterHistory: map[string]*collector.ParameterHistory{
 			"tidb": {
-				"max-connections": 2000,
+				Component: "tidb",
+				Parameters: map[string][]collector.ParameterDefaultChange{
+					"max-connections": {
+						{Version: "v6.5.0", PreviousVersion: "v6.1.0", OldValue: 500, NewValue: 1000},
+						{Version: "v7.1.0", PreviousVersion: "v6.5.12", OldValue: 1000, NewValue: 2000},
+						{Version: "v8.1.0", PreviousVersion: "v7.5.7", OldValue: 2000, NewValue: 4000},
+					},
+				},
 			},
 		},
-		UpgradeLogic: map[string]interface{}{},
 	}
 
 	results, err := rule.Evaluate(ctx, ruleCtx)
 
 	assert.NoError(t, err)
-	assert.NotEmpty(t, results)
-	
-	// Should detect that value will differ from target default
 	found := false
 	for _, result := range results {
-		if result.ParameterName == "max-connections" && result.ForcedValue == nil {
+		if result.ParameterName == "max-connections" {
 			found = true
-			assert.Equal(t, "warning", result.Severity)
-			assert.Equal(t, 1000, result.CurrentValue)
-			assert.Equal(t, 2000, result.TargetDefault)
-			break
+			// Only the changes made after the source version are listed
+			assert.NotContains(t, result.Details, "v6.5.0")
+			assert.Contains(t, result.Details, "Default changed in v7.1.0 from 1000 to 2000\nDefault changed in v8.1.0 from 2000 to 4000")
 		}
 	}
 	assert.True(t, found, "Should detect default value change")
@@ -227,7 +222,7 @@ func TestUpgradeDifferencesRule_Evaluate_PDCompatibilityHandling(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"pd": {
 					Type: types.ComponentPD,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"max-request-size": types.ParameterValue{Value: 100, Type: "int"},
 					},
 				},
@@ -245,7 +240,7 @@ func TestUpgradeDifferencesRule_Evaluate_PDCompatibilityHandling(t *testing.T) {
 				"max-request-size": 200,
 			},
 		},
-		UpgradeLogic: map[string]interface{}{},
+		UpgradeLogic: map[string][]UpgradeLogicChange{},
 	}
 
 	results, err := rule.Evaluate(ctx, ruleCtx)
@@ -276,7 +271,7 @@ func TestUpgradeDifferencesRule_Evaluate_SystemVariableKeepsValue(t *testing.T)
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Variables: types.SystemVariables{
+					Variables: types.ParameterMap{
 						"tidb_mem_quota_query": types.ParameterValue{Value: 1073741824, Type: "int"},
 					},
 				},
@@ -294,7 +289,7 @@ func TestUpgradeDifferencesRule_Evaluate_SystemVariableKeepsValue(t *testing.T)
 				"sysvar:tidb_mem_quota_query": 2147483648,
 			},
 		},
-		UpgradeLogic: map[string]interface{}{}, // No forced change
+		UpgradeLogic: map[string][]UpgradeLogicChange{}, // No forced change
 	}
 
 	results, err := rule.Evaluate(ctx, ruleCtx)
@@ -330,7 +325,7 @@ func TestUpgradeDifferencesRule_Evaluate_EmptySnapshot(t *testing.T) {
 	assert.Empty(t, results)
 }
 
-func TestUpgradeDifferencesRule_Evaluate_BootstrapVersionFiltering(t *testing.T) {
+func TestUpgradeDifferencesRule_Evaluate_SkipsDeploymentSpecificParams(t *testing.T) {
 	rule := NewUpgradeDifferencesRule()
 	ctx := context.Background()
 
@@ -339,88 +334,323 @@ func TestUpgradeDifferencesRule_Evaluate_BootstrapVersionFiltering(t *testing.T)
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Config: types.ConfigDefaults{
-						"param1": types.ParameterValue{Value: 100, Type: "int"},
-						"param2": types.ParameterValue{Value: 200, Type: "int"},
+					Config: types.ParameterMap{
+						"advertise-address": types.ParameterValue{Value: "10.0.0.1", Type: "string"},
+						"log":               types.ParameterValue{Value: map[string]interface{}{"file": map[string]interface{}{"filename": "/data/tidb.log"}, "level": "warn"}, Type: "map"},
+						"max-connections":   types.ParameterValue{Value: 1000, Type: "int"},
 					},
 				},
 			},
 		},
 		SourceVersion: "v7.5.0",
 		TargetVersion: "v8.5.0",
-		SourceBootstrapVersion: 140,
-		TargetBootstrapVersion: 160,
-		SourceDefaults: map[string]map[string]interface{}{
+		TargetDefaults: map[string]map[string]interface{}{
 			"tidb": {
-				"param1": 100,
-				"param2": 200,
+				"advertise-address":  "",
+				"log":                map[string]interface{}{"file": map[string]interface{}{"filename": ""}, "level": "info"},
+				"max-connections":    2000,
+				"status.status-host": "0.0.0.0",
+			},
+		},
+		UpgradeLogic: map[string][]UpgradeLogicChange{},
+		DeploymentSpecificParams: DeploymentSpecificParams{
+			"tidb": {"advertise-address", "log.file.filename", "status.*"},
+		},
+	}
+
+	results, err := rule.Evaluate(ctx, ruleCtx)
+	assert.NoError(t, err)
+
+	reported := make(map[string]bool)
+	for _, result := range results {
+		reported[result.ParameterName] = true
+		if result.Statistics != nil {
+			assert.Equal(t, 3, result.Statistics.ParametersFiltered)
+		}
+	}
+	assert.True(t, reported["max-connections"])
+	assert.True(t, reported["log.level"])
+	assert.False(t, reported["advertise-address"], "deployment-specific parameter should be skipped")
+	assert.False(t, reported["log.file.filename"], "deployment-specific nested field should be skipped")
+	assert.False(t, reported["status.status-host"], "deployment-specific new parameter should be skipped")
+}
+
+func TestDeploymentSpecificParams_Contains(t *testing.T) {
+	params := DeploymentSpecificParams{
+		"tikv": {"server.addr", "log.file", "rocksdb.*.wal-dir"},
+		"tidb": {"sysvar:hostname"},
+	}
+
+	assert.True(t, params.Contains("tikv", "server.addr"))
+	assert.True(t, params.Contains("tikv", "log.file.filename"))
+	assert.True(t, params.Contains("tikv", "rocksdb.defaultcf.wal-dir"))
+	assert.True(t, params.Contains("tidb", "sysvar:hostname"))
+	assert.False(t, params.Contains("tikv", "server.addr-extra"))
+	assert.False(t, params.Contains("tidb", "server.addr"))
+	assert.False(t, params.Contains("tidb", "hostname"))
+
+	var empty DeploymentSpecificParams
+	assert.False(t, empty.Contains("tikv", "server.addr"))
+}
+
+func TestUpgradeDifferencesRule_Evaluate_NormalizesRuntimeValues(t *testing.T) {
+	rule := NewUpgradeDifferencesRule()
+
+	// The runtime reports strings while the knowledge base defaults are typed
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"raftstore.sync-log":     {Value: "ON", Type: "string"},
+						"raftstore.apply-pool":   {Value: "1024", Type: "string"},
+						"raftstore.store-pool":   {Value: "off", Type: "string"},
+						"coprocessor.batch-size": {Value: "256", Type: "string"},
+					},
+				},
 			},
 		},
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
 		TargetDefaults: map[string]map[string]interface{}{
-			"tidb": {
-				"param1": 100,
-				"param2": 200,
+			"tikv": {
+				"raftstore.sync-log":     map[string]interface{}{"value": true, "type": "bool"},
+				"raftstore.apply-pool":   types.ParameterValue{Value: float64(1024), Type: "int"},
+				"raftstore.store-pool":   map[string]interface{}{"value": true, "type": "bool"},
+				"coprocessor.batch-size": types.ParameterValue{Value: float64(512), Type: "int"},
 			},
 		},
-		UpgradeLogic: map[string]interface{}{
-			"tidb": map[string]interface{}{
-				"changes": []interface{}{
-					// This change is before source bootstrap version (should be filtered out)
-					map[string]interface{}{
-						"version":           "130",
-						"bootstrap_version": 130,
-						"name":              "param1",
-						"value":             150,
-						"operation":         "UPDATE",
-						"severity":          "medium",
-					},
-					// This change is in range (140 < 150 <= 160) (should be included)
-					map[string]interface{}{
-						"version":           "150",
-						"bootstrap_version": 150,
-						"name":              "param2",
-						"value":             250,
-						"operation":         "UPDATE",
-						"severity":          "medium",
+		UpgradeLogic: map[string][]UpgradeLogicChange{},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	assert.NoError(t, err)
+
+	var reported []string
+	for _, result := range withoutStatistics(results) {
+		reported = append(reported, result.ParameterName)
+		if result.ParameterName == "raftstore.store-pool" {
+			// The raw runtime value is reported
+			assert.Equal(t, "off", result.CurrentValue)
+		}
+	}
+	assert.ElementsMatch(t, []string{"raftstore.store-pool", "coprocessor.batch-size"}, reported)
+}
+
+func TestUpgradeDifferencesRule_Evaluate_SectionMigrations(t *testing.T) {
+	rule := NewUpgradeDifferencesRule()
+
+	migrations, err := ParseSectionMigrations(map[string]interface{}{
+		"section_migrations": []interface{}{
+			map[string]interface{}{"old_key": "log.slow-threshold", "new_key": "instance.tidb_slow_log_threshold", "component": "tidb", "from_version": "v5.4.0", "to_version": "v6.1.0"},
+			map[string]interface{}{"old_key": "run-ddl", "new_key": "instance.tidb_enable_ddl", "component": "tidb", "from_version": "v5.4.0", "to_version": "v6.3.0"},
+			map[string]interface{}{"old_key": "plugin.dir", "new_key": "instance.plugin_dir", "component": "tidb", "from_version": "v5.4.0", "to_version": "v7.1.0"},
+		},
+	})
+	require.NoError(t, err)
+	require.Len(t, migrations, 3)
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type: types.ComponentTiDB,
+					Config: types.ParameterMap{
+						// The old key is stored as a field of the map-valued "log" parameter
+						"log":        {Value: map[string]interface{}{"slow-threshold": float64(500)}},
+						"run-ddl":    {Value: true},
+						"plugin.dir": {Value: "/data/plugins"},
 					},
-					// This change is after target bootstrap version (should be filtered out)
-					map[string]interface{}{
-						"version":           "170",
-						"bootstrap_version": 170,
-						"name":              "param3",
-						"value":             300,
-						"operation":         "UPDATE",
-						"severity":          "medium",
+				},
+			},
+		},
+		SourceVersion: "v5.4.0",
+		TargetVersion: "v6.5.0",
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"log":                              types.ParameterValue{Value: map[string]interface{}{}, Type: "map"},
+				"instance.tidb_slow_log_threshold": types.ParameterValue{Value: float64(300), Type: "int"},
+				"instance.tidb_enable_ddl":         types.ParameterValue{Value: true, Type: "bool"},
+				"instance.plugin_dir":              types.ParameterValue{Value: "/data/deploy/plugin", Type: "string"},
+				// run-ddl is still known to the target version, so it did not move
+				"run-ddl": types.ParameterValue{Value: true, Type: "bool"},
+			},
+		},
+		UpgradeLogic:      map[string][]UpgradeLogicChange{},
+		SectionMigrations: migrations,
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	results = withoutStatistics(results)
+
+	// plugin.dir moves in v7.1.0, after the target version
+	require.Len(t, results, 1)
+	result := results[0]
+	assert.Equal(t, "instance.tidb_slow_log_threshold", result.ParameterName)
+	assert.Equal(t, "warning", result.Severity)
+	assert.Equal(t, "Parameter log.slow-threshold in tidb moved to instance.tidb_slow_log_threshold", result.Message)
+	assert.Equal(t, float64(500), result.CurrentValue)
+	assert.Equal(t, float64(300), result.TargetDefault)
+	assert.Equal(t, "log.slow-threshold", result.Metadata["old_key"])
+	assert.Equal(t, "instance.tidb_slow_log_threshold", result.Metadata["new_key"])
+	assert.Contains(t, result.Details, "Old key: log.slow-threshold (current: 500)")
+}
+
+func TestUpgradeDifferencesRule_Evaluate_RenamedParameters(t *testing.T) {
+	rule := NewUpgradeDifferencesRule()
+
+	renameMap, err := ParseRenameMap(map[string]interface{}{
+		"tikv": map[string]interface{}{
+			"server.snap-max-write-bytes-per-sec": "server.snap-io-max-bytes-per-sec",
+			// Still known to the target version, so it is not renamed
+			"server.grpc-concurrency": "server.grpc-threads",
+		},
+		"tidb": map[string]interface{}{
+			"sysvar:tidb_old_switch": "sysvar:tidb_new_switch",
+		},
+	})
+	require.NoError(t, err)
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"server.snap-max-write-bytes-per-sec": {Value: "200MiB"},
+						"server.grpc-concurrency":             {Value: float64(5)},
 					},
 				},
+				"tidb": {
+					Type:      types.ComponentTiDB,
+					Config:    types.ParameterMap{},
+					Variables: types.ParameterMap{"tidb_old_switch": {Value: "ON"}},
+				},
 			},
 		},
+		SourceVersion: "v6.5.0",
+		TargetVersion: "v7.5.0",
+		SourceDefaults: map[string]map[string]interface{}{
+			"tikv": {
+				"server.snap-max-write-bytes-per-sec": types.ParameterValue{Value: "100MiB", Type: "string"},
+				"server.grpc-concurrency":             types.ParameterValue{Value: float64(5), Type: "int"},
+			},
+			"tidb": {
+				"sysvar:tidb_old_switch": types.ParameterValue{Value: "OFF", Type: "string"},
+			},
+		},
+		TargetDefaults: map[string]map[string]interface{}{
+			"tikv": {
+				"server.snap-io-max-bytes-per-sec": types.ParameterValue{Value: "100MiB", Type: "string"},
+				"server.grpc-concurrency":          types.ParameterValue{Value: float64(5), Type: "int"},
+				"server.grpc-threads":              types.ParameterValue{Value: float64(4), Type: "int"},
+			},
+			"tidb": {
+				"sysvar:tidb_new_switch": types.ParameterValue{Value: "OFF", Type: "string"},
+			},
+		},
+		UpgradeLogic: map[string][]UpgradeLogicChange{},
+		RenameMap:    renameMap,
 	}
 
-	results, err := rule.Evaluate(ctx, ruleCtx)
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	results = withoutStatistics(results)
 
-	assert.NoError(t, err)
-	
-	// Should only detect param2 (bootstrap version 150 is in range)
-	param2Found := false
-	param1Found := false
-	param3Found := false
-	
+	renamed := make(map[string]CheckResult)
 	for _, result := range results {
-		if result.ParameterName == "param1" && result.ForcedValue != nil {
-			param1Found = true
-		}
-		if result.ParameterName == "param2" && result.ForcedValue != nil {
-			param2Found = true
-			assert.Equal(t, 250, result.ForcedValue)
-		}
-		if result.ParameterName == "param3" && result.ForcedValue != nil {
-			param3Found = true
+		if result.Metadata["new_name"] != nil {
+			renamed[result.ParameterName] = result
 		}
 	}
-	
-	assert.False(t, param1Found, "param1 change should be filtered out (before source bootstrap version)")
-	assert.True(t, param2Found, "param2 change should be included (in bootstrap version range)")
-	assert.False(t, param3Found, "param3 change should be filtered out (after target bootstrap version)")
+	require.Len(t, renamed, 2)
+
+	tikv := renamed["server.snap-io-max-bytes-per-sec"]
+	assert.Equal(t, "info", tikv.Severity)
+	assert.Equal(t, "config", tikv.ParamType)
+	assert.Equal(t, "Parameter server.snap-max-write-bytes-per-sec in tikv is renamed to server.snap-io-max-bytes-per-sec", tikv.Message)
+	assert.Equal(t, "200MiB", tikv.CurrentValue)
+	assert.Equal(t, "server.snap-max-write-bytes-per-sec", tikv.Metadata["old_name"])
+	assert.Contains(t, tikv.Suggestions[0], "Rename server.snap-max-write-bytes-per-sec to server.snap-io-max-bytes-per-sec")
+
+	tidb := renamed["tidb_new_switch"]
+	assert.Equal(t, "system_variable", tidb.ParamType)
+	assert.Equal(t, "ON", tidb.CurrentValue)
+	assert.Equal(t, "SET GLOBAL tidb_new_switch = 'ON'", tidb.Suggestions[0])
 }
 
+
+func TestUpgradeDifferencesRule_Evaluate_NewInTarget(t *testing.T) {
+	rule := NewUpgradeDifferencesRule()
+
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type: types.ComponentTiDB,
+					Config: types.ParameterMap{
+						"max-connections":     types.ParameterValue{Value: 1000, Type: "int"},
+						"instance.new-option": types.ParameterValue{Value: 5, Type: "int"},
+					},
+				},
+			},
+		},
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		SourceDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"max-connections":   1000,
+				"performance.stats": "on",
+			},
+		},
+		TargetDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"max-connections":          1000,
+				"performance.stats":        "off",
+				"instance.new-option":      2,
+				"sysvar:tidb_new_variable": map[string]interface{}{"value": "ON", "type": "string"},
+				"status.status-host":       "0.0.0.0",
+			},
+		},
+		UpgradeLogic: map[string][]UpgradeLogicChange{},
+		DeploymentSpecificParams: DeploymentSpecificParams{
+			"tidb": {"status.*"},
+		},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+
+	byName := make(map[string]CheckResult)
+	for _, result := range results {
+		if result.Statistics == nil {
+			byName[result.ParameterName] = result
+		}
+	}
+	// Absent from both the source KB and the cluster
+	newVariable, ok := byName["tidb_new_variable"]
+	require.True(t, ok)
+	assert.Equal(t, "new_in_target", newVariable.Category)
+	assert.Equal(t, "system_variable", newVariable.ParamType)
+	assert.Equal(t, "info", newVariable.Severity)
+	assert.Equal(t, "ON", newVariable.TargetDefault)
+	assert.Equal(t, "string", newVariable.Metadata["type"])
+	assert.Contains(t, newVariable.Message, "is new in v8.5.0")
+
+	// Already configured in the cluster: compared with the target default
+	assert.Equal(t, "upgrade_difference", byName["instance.new-option"].Category)
+	// In the source KB but not collected: not new in the target version
+	assert.NotContains(t, byName, "performance.stats")
+	// Deployment-specific parameters are not listed
+	assert.NotContains(t, byName, "status.status-host")
+
+	// Without source KB for the component, the parameters cannot be told apart from new ones
+	ruleCtx.MissingSourceKBComponents = map[string]bool{"tidb": true}
+	results, err = rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	for _, result := range results {
+		assert.NotEqual(t, "new_in_target", result.Category, result.ParameterName)
+	}
+}
@@ -0,0 +1,342 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// UpgradeMatrixFile is the name of the upgrade matrix in the knowledge base directory
+const UpgradeMatrixFile = "upgrade_matrix.json"
+
+// UpgradeMatrix describes the supported direct upgrade paths between version groups (e.g., "v7.5")
+// It is loaded from knowledge/upgrade_matrix.json
+// A direct upgrade from a source group listed in Transitions is supported only to the listed target groups
+// Any other direct upgrade is supported if it crosses at most MaxLTSHops LTS groups
+type UpgradeMatrix struct {
+	// LTS lists the LTS version groups, in ascending order
+	// They are the intermediate versions recommended for unsupported upgrades
+	LTS []LTSVersion `json:"lts"`
+	// MaxLTSHops is the number of LTS groups a direct upgrade may cross (the target group counts as a hop)
+	MaxLTSHops int `json:"max_lts_hops"`
+	// Transitions are the allowed target groups of specific source groups, overriding MaxLTSHops
+	Transitions []UpgradeTransition `json:"transitions,omitempty"`
+}
+
+// LTSVersion is an LTS version group of the upgrade matrix
+type LTSVersion struct {
+	// Group is the version group (e.g., "v7.5")
+	Group string `json:"group"`
+	// RecommendedVersion is the version of the group to upgrade through (e.g., "v7.5.7")
+	// If empty, the group is recommended
+	RecommendedVersion string `json:"recommended_version,omitempty"`
+}
+
+// UpgradeTransition lists the groups a source group can be upgraded to directly
+type UpgradeTransition struct {
+	// From is the source version group (e.g., "v5.4")
+	From string `json:"from"`
+	// To are the target version groups From can be upgraded to directly
+	To []string `json:"to"`
+}
+
+// versionGroupRe matches a version group (e.g., "v7.5")
+var versionGroupRe = regexp.MustCompile(`^v\d+\.\d+$`)
+
+// LoadUpgradeMatrix loads and validates an upgrade matrix file
+// It returns nil if the file does not exist, so that a knowledge base without matrix disables the check
+func LoadUpgradeMatrix(path string) (*UpgradeMatrix, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upgrade matrix %s: %w", path, err)
+	}
+	matrix, err := decodeUpgradeMatrix(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid upgrade matrix %s: %w", path, err)
+	}
+	return matrix, nil
+}
+
+// ParseUpgradeMatrix converts upgrade_matrix loaded from the knowledge base (generic JSON map)
+// into a validated UpgradeMatrix
+func ParseUpgradeMatrix(raw interface{}) (*UpgradeMatrix, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	return decodeUpgradeMatrix(data)
+}
+
+// decodeUpgradeMatrix decodes and validates an upgrade matrix
+// Unknown fields are rejected, so that a misspelled policy is not silently ignored
+func decodeUpgradeMatrix(data []byte) (*UpgradeMatrix, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	var matrix UpgradeMatrix
+	if err := decoder.Decode(&matrix); err != nil {
+		return nil, err
+	}
+	if err := matrix.Validate(); err != nil {
+		return nil, err
+	}
+	return &matrix, nil
+}
+
+// Validate checks the version groups and the policy of the matrix
+func (m *UpgradeMatrix) Validate() error {
+	if len(m.LTS) == 0 {
+		return errors.New("lts is empty")
+	}
+	for i, lts := range m.LTS {
+		if !versionGroupRe.MatchString(lts.Group) {
+			return fmt.Errorf("lts[%d]: invalid version group %q (expected e.g. \"v7.5\")", i, lts.Group)
+		}
+		if i > 0 && compareGroups(m.LTS[i-1].Group, lts.Group) >= 0 {
+			return fmt.Errorf("lts[%d]: %s is not after %s, lts must be in ascending order", i, lts.Group, m.LTS[i-1].Group)
+		}
+		if lts.RecommendedVersion != "" && versionGroup(lts.RecommendedVersion) != lts.Group {
+			return fmt.Errorf("lts[%d]: recommended version %s is not in group %s", i, lts.RecommendedVersion, lts.Group)
+		}
+	}
+	if m.MaxLTSHops < 1 {
+		return fmt.Errorf("max_lts_hops must be at least 1, got %d", m.MaxLTSHops)
+	}
+	sources := make(map[string]bool, len(m.Transitions))
+	for i, transition := range m.Transitions {
+		if !versionGroupRe.MatchString(transition.From) {
+			return fmt.Errorf("transitions[%d]: invalid source group %q", i, transition.From)
+		}
+		if sources[transition.From] {
+			return fmt.Errorf("transitions[%d]: source group %s listed twice", i, transition.From)
+		}
+		sources[transition.From] = true
+		if len(transition.To) == 0 {
+			return fmt.Errorf("transitions[%d]: no target group for %s", i, transition.From)
+		}
+		for _, to := range transition.To {
+			if !versionGroupRe.MatchString(to) {
+				return fmt.Errorf("transitions[%d]: invalid target group %q", i, to)
+			}
+			if compareGroups(transition.From, to) >= 0 {
+				return fmt.Errorf("transitions[%d]: target group %s is not after %s", i, to, transition.From)
+			}
+		}
+	}
+	return nil
+}
+
+// versionGroup returns the group of a version (e.g., "v7.5.1" -> "v7.5"), or "" if the version can't be parsed
+func versionGroup(version string) string {
+	parts := strings.Split(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return ""
+	}
+	return "v" + parts[0] + "." + parts[1]
+}
+
+// compareGroups compares two version groups
+func compareGroups(g1, g2 string) int {
+	return compareVersions(strings.TrimPrefix(g1, "v"), strings.TrimPrefix(g2, "v"))
+}
+
+// CrossedLTS returns the LTS groups crossed by a direct upgrade from group from to group to,
+// followed by the target group if it is not an LTS group
+func (m *UpgradeMatrix) CrossedLTS(from, to string) []string {
+	var crossed []string
+	for _, lts := range m.LTS {
+		if compareGroups(lts.Group, from) > 0 && compareGroups(lts.Group, to) < 0 {
+			crossed = append(crossed, lts.Group)
+		}
+	}
+	if compareGroups(from, to) < 0 {
+		crossed = append(crossed, to)
+	}
+	return crossed
+}
+
+// Supports checks if a direct upgrade from group from to group to is supported
+// Upgrades within a group and downgrades are not the matrix's concern and are reported as supported
+func (m *UpgradeMatrix) Supports(from, to string) bool {
+	if compareGroups(from, to) >= 0 {
+		return true
+	}
+	for _, transition := range m.Transitions {
+		if transition.From == from {
+			for _, allowed := range transition.To {
+				if allowed == to {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return len(m.CrossedLTS(from, to)) <= m.MaxLTSHops
+}
+
+// IntermediateGroups returns the fewest LTS groups to upgrade through to go from group from to group to,
+// preferring the most recent groups, or false if the matrix allows no path
+// It returns an empty list if the direct upgrade is supported
+func (m *UpgradeMatrix) IntermediateGroups(from, to string) ([]string, bool) {
+	if m.Supports(from, to) {
+		return []string{}, true
+	}
+
+	// Steps are the LTS groups between from and to, most recent first, so that the breadth-first
+	// search picks the most recent group when several paths have the same length
+	var steps []string
+	for i := len(m.LTS) - 1; i >= 0; i-- {
+		group := m.LTS[i].Group
+		if compareGroups(group, from) > 0 && compareGroups(group, to) < 0 {
+			steps = append(steps, group)
+		}
+	}
+
+	previous := map[string]string{}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if m.Supports(current, to) && current != from {
+			var path []string
+			for group := current; group != from; group = previous[group] {
+				path = append([]string{group}, path...)
+			}
+			return path, true
+		}
+		for _, next := range steps {
+			if _, seen := previous[next]; seen || compareGroups(next, current) <= 0 || !m.Supports(current, next) {
+				continue
+			}
+			previous[next] = current
+			queue = append(queue, next)
+		}
+	}
+	return nil, false
+}
+
+// RecommendedVersion returns the version to upgrade through for an LTS group
+func (m *UpgradeMatrix) RecommendedVersion(group string) string {
+	for _, lts := range m.LTS {
+		if lts.Group == group && lts.RecommendedVersion != "" {
+			return lts.RecommendedVersion
+		}
+	}
+	return group
+}
+
+// UpgradePathRule reports source -> target upgrades that are not supported directly
+// Rule: Look up the source and target version groups in the upgrade matrix (knowledge/upgrade_matrix.json)
+// An unsupported pair is a critical finding that lists the intermediate versions to upgrade through
+// The rest of the analysis still runs, so that the report can be used to plan the upgrade
+type UpgradePathRule struct {
+	*BaseRule
+}
+
+// NewUpgradePathRule creates a new upgrade path rule
+func NewUpgradePathRule() Rule {
+	return &UpgradePathRule{
+		BaseRule: NewBaseRule(
+			"UPGRADE_PATH",
+			"Check that the source to target version upgrade is supported directly (knowledge/upgrade_matrix.json)",
+			"upgrade_path",
+		),
+	}
+}
+
+// DataRequirements returns the data requirements for this rule
+// Only the source and target versions are needed
+func (r *UpgradePathRule) DataRequirements() DataSourceRequirement {
+	return DataSourceRequirement{}
+}
+
+// Evaluate performs the rule check
+func (r *UpgradePathRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
+	var results []CheckResult
+	matrix := ruleCtx.UpgradeMatrix
+	if matrix == nil {
+		return results, nil
+	}
+
+	from := versionGroup(ruleCtx.SourceVersion)
+	to := versionGroup(ruleCtx.TargetVersion)
+	if from == "" || to == "" || matrix.Supports(from, to) {
+		return results, nil
+	}
+
+	return append(results, r.unsupportedPathResult(ruleCtx, matrix, from, to)), nil
+}
+
+// unsupportedPathResult builds the critical finding of an unsupported upgrade path
+func (r *UpgradePathRule) unsupportedPathResult(ruleCtx *RuleContext, matrix *UpgradeMatrix, from, to string) CheckResult {
+	var details strings.Builder
+	allowed, restricted := matrix.allowedTargets(from)
+	if restricted {
+		fmt.Fprintf(&details, "%s can only be upgraded directly to: %s", from, strings.Join(allowed, ", "))
+	} else {
+		crossed := matrix.CrossedLTS(from, to)
+		fmt.Fprintf(&details, "A direct upgrade can cross at most %d LTS versions, %s to %s crosses %d (%s)",
+			matrix.MaxLTSHops, from, to, len(crossed), strings.Join(crossed, ", "))
+	}
+
+	intermediates, ok := matrix.IntermediateGroups(from, to)
+	var intermediateVersions []string
+	var suggestions []string
+	if ok {
+		for _, group := range intermediates {
+			intermediateVersions = append(intermediateVersions, matrix.RecommendedVersion(group))
+		}
+		path := append(append([]string{ruleCtx.SourceVersion}, intermediateVersions...), ruleCtx.TargetVersion)
+		fmt.Fprintf(&details, "\n\nRecommended path: %s", strings.Join(path, " -> "))
+		suggestions = []string{
+			fmt.Sprintf("Upgrade through %s before upgrading to %s", strings.Join(intermediateVersions, ", then "), ruleCtx.TargetVersion),
+			"Run precheck again before each step, with the intermediate version as target",
+		}
+	} else {
+		details.WriteString("\n\nThe upgrade matrix allows no path through LTS versions")
+		suggestions = []string{
+			"Contact PingCAP support to plan the upgrade",
+		}
+	}
+
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		ParameterName: "upgrade_path",
+		ParamType:     "upgrade_path",
+		Severity:      "critical",
+		RiskLevel:     RiskLevelHigh,
+		Message:       fmt.Sprintf("Direct upgrade from %s to %s is not supported", ruleCtx.SourceVersion, ruleCtx.TargetVersion),
+		Details:       details.String(),
+		Suggestions:   suggestions,
+		Metadata: map[string]interface{}{
+			"source_group":          from,
+			"target_group":          to,
+			"crossed_lts":           matrix.CrossedLTS(from, to),
+			"max_lts_hops":          matrix.MaxLTSHops,
+			"intermediate_versions": intermediateVersions,
+		},
+	}
+}
+
+// allowedTargets returns the target groups of a source group listed in the transitions, sorted,
+// and whether the source group is listed
+func (m *UpgradeMatrix) allowedTargets(from string) ([]string, bool) {
+	for _, transition := range m.Transitions {
+		if transition.From == from {
+			targets := append([]string(nil), transition.To...)
+			sort.Slice(targets, func(i, j int) bool { return compareGroups(targets[i], targets[j]) < 0 })
+			return targets, true
+		}
+	}
+	return nil, false
+}
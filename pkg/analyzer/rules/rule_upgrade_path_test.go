@@ -0,0 +1,127 @@
+package rules
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testUpgradeMatrix = &UpgradeMatrix{
+	LTS: []LTSVersion{
+		{Group: "v5.4", RecommendedVersion: "v5.4.3"},
+		{Group: "v6.1"},
+		{Group: "v6.5", RecommendedVersion: "v6.5.12"},
+		{Group: "v7.1", RecommendedVersion: "v7.1.6"},
+		{Group: "v7.5", RecommendedVersion: "v7.5.7"},
+		{Group: "v8.1", RecommendedVersion: "v8.1.2"},
+		{Group: "v8.5", RecommendedVersion: "v8.5.4"},
+	},
+	MaxLTSHops: 3,
+	Transitions: []UpgradeTransition{
+		{From: "v5.4", To: []string{"v6.1", "v6.5", "v7.1"}},
+	},
+}
+
+func newUpgradePathContext(sourceVersion, targetVersion string) *RuleContext {
+	ruleCtx := NewRuleContext(&collector.ClusterSnapshot{}, sourceVersion, targetVersion, nil, nil, 0, 0, nil)
+	ruleCtx.UpgradeMatrix = testUpgradeMatrix
+	return ruleCtx
+}
+
+func TestUpgradeMatrix_Supports(t *testing.T) {
+	assert.True(t, testUpgradeMatrix.Supports("v7.5", "v8.5"))
+	// v7.1 -> v8.5 crosses v7.5, v8.1 and v8.5
+	assert.True(t, testUpgradeMatrix.Supports("v7.1", "v8.5"))
+	assert.False(t, testUpgradeMatrix.Supports("v6.5", "v8.5"))
+	// Non-LTS target groups count as a hop
+	assert.Equal(t, []string{"v7.5", "v8.1", "v8.3"}, testUpgradeMatrix.CrossedLTS("v7.1", "v8.3"))
+	// Transitions override the hop policy
+	assert.True(t, testUpgradeMatrix.Supports("v5.4", "v7.1"))
+	assert.False(t, testUpgradeMatrix.Supports("v5.4", "v6.2"))
+	// Patch upgrades and downgrades are not checked
+	assert.True(t, testUpgradeMatrix.Supports("v7.5", "v7.5"))
+	assert.True(t, testUpgradeMatrix.Supports("v8.5", "v7.5"))
+}
+
+func TestUpgradeMatrix_IntermediateGroups(t *testing.T) {
+	path, ok := testUpgradeMatrix.IntermediateGroups("v7.5", "v8.5")
+	require.True(t, ok)
+	assert.Empty(t, path)
+
+	// The most recent intermediate group is preferred
+	path, ok = testUpgradeMatrix.IntermediateGroups("v6.5", "v8.5")
+	require.True(t, ok)
+	assert.Equal(t, []string{"v8.1"}, path)
+
+	path, ok = testUpgradeMatrix.IntermediateGroups("v5.4", "v8.5")
+	require.True(t, ok)
+	assert.Equal(t, []string{"v7.1"}, path)
+
+	matrix := &UpgradeMatrix{LTS: testUpgradeMatrix.LTS, MaxLTSHops: 1,
+		Transitions: []UpgradeTransition{{From: "v5.4", To: []string{"v6.1"}}, {From: "v6.1", To: []string{"v6.2"}}}}
+	_, ok = matrix.IntermediateGroups("v5.4", "v8.5")
+	assert.False(t, ok)
+}
+
+func TestUpgradePathRule_Evaluate(t *testing.T) {
+	rule := NewUpgradePathRule()
+
+	results, err := rule.Evaluate(context.Background(), newUpgradePathContext("v7.5.1", "v8.5.0"))
+	require.NoError(t, err)
+	assert.Empty(t, results)
+
+	results, err = rule.Evaluate(context.Background(), newUpgradePathContext("v5.4.0", "v8.5.0"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	result := results[0]
+	assert.Equal(t, "UPGRADE_PATH", result.RuleID)
+	assert.Equal(t, "critical", result.Severity)
+	assert.Equal(t, "Direct upgrade from v5.4.0 to v8.5.0 is not supported", result.Message)
+	assert.Contains(t, result.Details, "v5.4 can only be upgraded directly to: v6.1, v6.5, v7.1")
+	assert.Contains(t, result.Details, "Recommended path: v5.4.0 -> v7.1.6 -> v8.5.0")
+	assert.Equal(t, []string{"v7.1.6"}, result.Metadata["intermediate_versions"])
+
+	results, err = rule.Evaluate(context.Background(), newUpgradePathContext("v6.5.3", "v8.5.0"))
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Contains(t, results[0].Details, "A direct upgrade can cross at most 3 LTS versions, v6.5 to v8.5 crosses 4 (v7.1, v7.5, v8.1, v8.5)")
+	assert.Contains(t, results[0].Details, "Recommended path: v6.5.3 -> v8.1.2 -> v8.5.0")
+
+	// Without matrix, nothing is checked
+	ruleCtx := newUpgradePathContext("v5.4.0", "v8.5.0")
+	ruleCtx.UpgradeMatrix = nil
+	results, err = rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestLoadUpgradeMatrix(t *testing.T) {
+	matrix, err := LoadUpgradeMatrix(filepath.Join("..", "..", "..", "knowledge", UpgradeMatrixFile))
+	require.NoError(t, err)
+	require.NotNil(t, matrix)
+	assert.True(t, matrix.Supports("v7.5", "v8.5"))
+	assert.False(t, matrix.Supports("v5.4", "v8.5"))
+
+	matrix, err = LoadUpgradeMatrix(filepath.Join(t.TempDir(), UpgradeMatrixFile))
+	require.NoError(t, err)
+	assert.Nil(t, matrix)
+
+	for name, content := range map[string]string{
+		"syntax":        `{"lts": [`,
+		"unknown field": `{"lts": [{"group": "v7.5"}], "max_hops": 2}`,
+		"no hops":       `{"lts": [{"group": "v7.5"}]}`,
+		"bad group":     `{"lts": [{"group": "7.5"}], "max_lts_hops": 2}`,
+		"unordered":     `{"lts": [{"group": "v8.1"}, {"group": "v7.5"}], "max_lts_hops": 2}`,
+		"backwards":     `{"lts": [{"group": "v7.5"}], "max_lts_hops": 2, "transitions": [{"from": "v7.5", "to": ["v6.5"]}]}`,
+	} {
+		path := filepath.Join(t.TempDir(), UpgradeMatrixFile)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		_, err := LoadUpgradeMatrix(path)
+		assert.ErrorContains(t, err, "invalid upgrade matrix", name)
+	}
+}
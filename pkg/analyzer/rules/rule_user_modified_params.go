@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 )
 
 // UserModifiedParamsRule detects parameters that have been modified by the user
@@ -16,6 +17,44 @@ type UserModifiedParamsRule struct {
 	*BaseRule
 }
 
+// userModifiedSuggestions are shared by all user-modified results instead of allocating a slice per parameter
+var userModifiedSuggestions = []string{
+	"This parameter has been modified from the source version default",
+	"Review if this modification is intentional and appropriate",
+	"Ensure the modified value is compatible with target version",
+}
+
+// versionDriftSuggestions are shared by all results for parameters that differ from the source version default by version drift
+var versionDriftSuggestions = []string{
+	"This value is the default of an earlier patch release of the source version, kept when the cluster was patched",
+	"Set the parameter explicitly if the old default is intended, or reset it to the current default",
+}
+
+// missingConfigInSourceKBSuggestions are shared by all results for runtime parameters missing in the source KB
+var missingConfigInSourceKBSuggestions = []string{
+	"This parameter exists in runtime cluster but is missing in source version knowledge base",
+	"Verify if this parameter was added in a newer version or is a custom parameter",
+	"Check if this is expected behavior or a knowledge base collection issue",
+}
+
+// missingVariableInSourceKBSuggestions are shared by all results for runtime system variables missing in the source KB
+var missingVariableInSourceKBSuggestions = []string{
+	"This system variable exists in runtime cluster but is missing in source version knowledge base",
+	"Verify if this variable was added in a newer version or is a custom variable",
+	"Check if this is expected behavior or a knowledge base collection issue",
+}
+
+// Origins of modified PD parameters, reported in the "config_origin" metadata of the results
+// They are determined by comparing the effective config (persisted in etcd) with the defaults of the running PD
+const (
+	// PDConfigOriginConfigured means the value differs from the default of the running PD:
+	// it was set in the PD config file or changed at runtime with pd-ctl
+	PDConfigOriginConfigured = "configured"
+	// PDConfigOriginPDDefault means the value is the default of the running PD,
+	// which differs from the knowledge base default (no configuration change)
+	PDConfigOriginPDDefault = "pd_default"
+)
+
 // NewUserModifiedParamsRule creates a new user modified parameters rule
 func NewUserModifiedParamsRule() Rule {
 	return &UserModifiedParamsRule{
@@ -31,10 +70,14 @@ func NewUserModifiedParamsRule() Rule {
 func (r *UserModifiedParamsRule) DataRequirements() DataSourceRequirement {
 	return DataSourceRequirement{
 		SourceClusterRequirements: struct {
-			Components          []string `json:"components"`
-			NeedConfig          bool     `json:"need_config"`
-			NeedSystemVariables bool     `json:"need_system_variables"`
-			NeedAllTikvNodes    bool     `json:"need_all_tikv_nodes"`
+			Components               []string `json:"components"`
+			NeedConfig               bool     `json:"need_config"`
+			NeedSystemVariables      bool     `json:"need_system_variables"`
+			NeedAllTikvNodes         bool     `json:"need_all_tikv_nodes"`
+			NeedGlobalVariablesTable bool     `json:"need_global_variables_table"`
+			NeedGCSafePoints         bool     `json:"need_gc_safe_points"`
+			NeedPlacement            bool     `json:"need_placement"`
+			NeedStores               bool     `json:"need_stores"`
 		}{
 			Components:          []string{"tidb", "pd", "tikv", "tiflash"},
 			NeedConfig:          true,
@@ -60,44 +103,29 @@ func (r *UserModifiedParamsRule) DataRequirements() DataSourceRequirement {
 // by iterating through the source defaults map and comparing with runtime values
 func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleContext) ([]CheckResult, error) {
 	var results []CheckResult
+	// Number of parameters examined, including the runtime parameters missing from the source KB
+	examined := 0
+	// Number of parameters whose modified-versus-default check was skipped because they are machine-derived
+	machineDerivedSkipped := 0
 
 	if ruleCtx.SourceClusterSnapshot == nil {
 		return results, nil
 	}
+	drift := NewVersionDriftDetector(ruleCtx.ParameterHistory)
 
 	// Iterate through all components in source defaults
-	for compType, sourceDefaults := range ruleCtx.SourceDefaults {
-		// Find the corresponding component in the cluster snapshot
-		var component *collector.ComponentState
-		var compName string
-
-		// Try to find component by type
-		for name, comp := range ruleCtx.SourceClusterSnapshot.Components {
-			if string(comp.Type) == compType {
-				component = &comp
-				compName = name
-				break
-			}
-			// Also check by name prefix for TiKV/TiFlash nodes
-			if (compType == "tikv" && strings.HasPrefix(name, "tikv")) ||
-				(compType == "tiflash" && strings.HasPrefix(name, "tiflash")) {
-				// For TiKV/TiFlash, use the first instance found
-				if component == nil {
-					component = &comp
-					compName = name
-				}
-			}
-		}
-
-		if component == nil {
-			// Component not found in cluster snapshot, skip
+	// For TiKV, only the first instance is checked to avoid duplicate results, except for mixed-version clusters
+	// where the first instance of each version is checked against the defaults of its version
+	for _, comparison := range ruleCtx.GetSourceComparisons() {
+		compType, component, sourceDefaults := comparison.Component, comparison.Instance, comparison.Defaults
+		// Without source defaults, every runtime parameter would be reported as missing in the source KB
+		// The component is only compared with the target defaults (see UPGRADE_DIFFERENCES)
+		if ruleCtx.IsMissingInSourceKB(compType) {
 			continue
 		}
 
-		// For TiKV, only check the first instance to avoid duplicate results
-		if compType == "tikv" && compName != "tikv" && !strings.HasPrefix(compName, "tikv-") {
-			continue
-		}
+		// Source defaults may come from a different version for mixed-version clusters
+		sourceVersion := comparison.SourceVersion
 
 		// Build runtime parameter maps for reverse lookup (cluster → KB)
 		runtimeConfigMap := make(map[string]bool)
@@ -117,6 +145,7 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 			if sourceDefault == nil {
 				continue
 			}
+			examined++
 
 			// Determine if this is a system variable (prefixed with "sysvar:")
 			isSystemVar := strings.HasPrefix(paramName, "sysvar:")
@@ -129,6 +158,9 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 					currentValue = varValue.Value
 					// Mark as processed
 					delete(runtimeVarsMap, varName)
+				} else if GetValueState(sourceDefault, true).IsUnsetOrEmpty() {
+					// An unset variable is equivalent to its empty default
+					continue
 				} else {
 					// Variable exists in KB but not in runtime - report as mismatch
 					displayName := varName
@@ -142,7 +174,7 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 						ParamType:     "system_variable",
 						Severity:      "warning",
 						RiskLevel:     RiskLevelMedium,
-						Message:       fmt.Sprintf("System variable %s exists in source KB (v%s) but not found in runtime cluster", displayName, ruleCtx.SourceVersion),
+						Message:       fmt.Sprintf("System variable %s exists in source KB (v%s) but not found in runtime cluster", displayName, sourceVersion),
 						Details:       fmt.Sprintf("Source KB default: %s | Runtime: <not found>", FormatValue(sourceDefault)),
 						SourceDefault: sourceDefault,
 						Suggestions: []string{
@@ -159,6 +191,9 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 					currentValue = paramValue.Value
 					// Mark as processed
 					delete(runtimeConfigMap, paramName)
+				} else if GetValueState(sourceDefault, true).IsUnsetOrEmpty() {
+					// Components omit string parameters left to their empty default (e.g., log.file.filename)
+					continue
 				} else {
 					// Parameter exists in KB but not in runtime - report as mismatch
 					// Note: Filtering of ignored parameters is done at report generation time, not here
@@ -171,7 +206,7 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 						ParamType:     "config",
 						Severity:      "warning",
 						RiskLevel:     RiskLevelMedium,
-						Message:       fmt.Sprintf("Parameter %s exists in source KB (v%s) but not found in runtime cluster", paramName, ruleCtx.SourceVersion),
+						Message:       fmt.Sprintf("Parameter %s exists in source KB (v%s) but not found in runtime cluster", paramName, sourceVersion),
 						Details:       fmt.Sprintf("Source KB default: %s | Runtime: <not found>", FormatValue(sourceDefault)),
 						SourceDefault: sourceDefault,
 						Suggestions: []string{
@@ -190,6 +225,16 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 				displayName = strings.TrimPrefix(paramName, "sysvar:")
 			}
 
+			// Machine-derived parameters default to values computed from the host resources,
+			// so the KB default (from the playground host) cannot be compared with the current value
+			if machineDerived, ok := ruleCtx.GetMachineDerivedParam(compType, displayName); ok {
+				machineDerivedSkipped++
+				if check, outOfRange := r.checkMachineDerivedParam(compType, displayName, isSystemVar, currentValue, machineDerived, component.Status); outOfRange {
+					results = append(results, check)
+				}
+				continue
+			}
+
 			// For map types, do deep comparison to find only differing fields
 			if IsMapType(currentValue) && IsMapType(sourceDefault) {
 				opts := CompareOptions{
@@ -200,12 +245,22 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 					// Note: Resource-dependent parameter filtering is done at report generation time, not here
 					// This ensures all parameters are properly categorized before filtering
 
+					// Nested fields may be machine-derived as well (e.g., storage.block-cache.capacity)
+					fieldName := fmt.Sprintf("%s.%s", displayName, fieldPath)
+					if machineDerived, ok := ruleCtx.GetMachineDerivedParam(compType, fieldName); ok {
+						machineDerivedSkipped++
+						if check, outOfRange := r.checkMachineDerivedParam(compType, fieldName, isSystemVar, diff.Current, machineDerived, component.Status); outOfRange {
+							results = append(results, check)
+						}
+						continue
+					}
+
 					// Show all differences in map, don't ignore nested fields
 					paramType := "config"
 					if isSystemVar {
 						paramType = "system_variable"
 					}
-					results = append(results, CheckResult{
+					check := CheckResult{
 						RuleID:        r.Name(),
 						Category:      r.Category(),
 						Component:     compType,
@@ -217,24 +272,24 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 						Details:       FormatValueDiff(diff.Current, diff.Source),
 						CurrentValue:  diff.Current,
 						SourceDefault: diff.Source,
-						Suggestions: []string{
-							"This parameter has been modified from the source version default",
-							"Review if this modification is intentional and appropriate",
-							"Ensure the modified value is compatible with target version",
-						},
-					})
+						Suggestions:   userModifiedSuggestions,
+					}
+					addPDConfigOrigin(&check, component, paramName, fieldPath)
+					classifyModification(&check, drift, compType, paramName+"."+fieldPath, sourceVersion, "")
+					results = append(results, check)
 				}
 			} else {
 				// For non-map types, do simple comparison
-				// Use proper value comparison to avoid scientific notation issues
-				differs := !CompareValues(currentValue, sourceDefault)
+				// The current value is compared guided by the type of the default ("true" vs true, "1024" vs 1024, "1GiB" vs "1024MiB")
+				current := defaultsTypes.ParameterValue{Value: currentValue, Type: extractTypeFromDefault(sourceDefaultValue)}
+				differs := !current.Equal(defaultsTypes.ParameterValue{Value: sourceDefault})
 
 				if differs {
 					paramType := "config"
 					if isSystemVar {
 						paramType = "system_variable"
 					}
-					results = append(results, CheckResult{
+					check := CheckResult{
 						RuleID:        r.Name(),
 						Category:      r.Category(),
 						Component:     compType,
@@ -242,16 +297,17 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 						ParamType:     paramType,
 						Severity:      "info",
 						RiskLevel:     RiskLevelLow,
-						Message:       fmt.Sprintf("Parameter %s in %s has been modified by user (differs from source version default)", displayName, compType),
+						Message:       "Parameter " + displayName + " in " + compType + " has been modified by user (differs from source version default)",
 						Details:       FormatValueDiff(currentValue, sourceDefault),
 						CurrentValue:  currentValue,
 						SourceDefault: sourceDefault,
-						Suggestions: []string{
-							"This parameter has been modified from the source version default",
-							"Review if this modification is intentional and appropriate",
-							"Ensure the modified value is compatible with target version",
-						},
-					})
+						Suggestions:   userModifiedSuggestions,
+					}
+					if !isSystemVar {
+						addPDConfigOrigin(&check, component, paramName, "")
+					}
+					classifyModification(&check, drift, compType, paramName, sourceVersion, current.Type)
+					results = append(results, check)
 				}
 			}
 		}
@@ -264,6 +320,7 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 			if !ok {
 				continue
 			}
+			examined++
 			results = append(results, CheckResult{
 				RuleID:        r.Name(),
 				Category:      r.Category(),
@@ -272,14 +329,10 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 				ParamType:     "config",
 				Severity:      "warning",
 				RiskLevel:     RiskLevelMedium,
-				Message:       fmt.Sprintf("Parameter %s exists in runtime cluster but not found in source KB (v%s)", paramName, ruleCtx.SourceVersion),
-				Details:       fmt.Sprintf("Runtime value: %s | Source KB: <not found>", FormatValue(paramValue.Value)),
+				Message:       fmt.Sprintf("Parameter %s exists in runtime cluster but not found in source KB (v%s)", paramName, sourceVersion),
+				Details:       "Runtime value: " + FormatValue(paramValue.Value) + " | Source KB: <not found>",
 				CurrentValue:  paramValue.Value,
-				Suggestions: []string{
-					"This parameter exists in runtime cluster but is missing in source version knowledge base",
-					"Verify if this parameter was added in a newer version or is a custom parameter",
-					"Check if this is expected behavior or a knowledge base collection issue",
-				},
+				Suggestions:   missingConfigInSourceKBSuggestions,
 			})
 		}
 
@@ -289,6 +342,7 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 			if !ok {
 				continue
 			}
+			examined++
 			results = append(results, CheckResult{
 				RuleID:        r.Name(),
 				Category:      r.Category(),
@@ -297,17 +351,142 @@ func (r *UserModifiedParamsRule) Evaluate(ctx context.Context, ruleCtx *RuleCont
 				ParamType:     "system_variable",
 				Severity:      "warning",
 				RiskLevel:     RiskLevelMedium,
-				Message:       fmt.Sprintf("System variable %s exists in runtime cluster but not found in source KB (v%s)", varName, ruleCtx.SourceVersion),
-				Details:       fmt.Sprintf("Runtime value: %s | Source KB: <not found>", FormatValue(varValue.Value)),
+				Message:       fmt.Sprintf("System variable %s exists in runtime cluster but not found in source KB (v%s)", varName, sourceVersion),
+				Details:       "Runtime value: " + FormatValue(varValue.Value) + " | Source KB: <not found>",
 				CurrentValue:  varValue.Value,
-				Suggestions: []string{
-					"This system variable exists in runtime cluster but is missing in source version knowledge base",
-					"Verify if this variable was added in a newer version or is a custom variable",
-					"Check if this is expected behavior or a knowledge base collection issue",
-				},
+				Suggestions:   missingVariableInSourceKBSuggestions,
 			})
 		}
 	}
 
+	// Report statistics (stripped from the findings by RuleRunner)
+	results = append(results, NewStatisticsResult(r, RuleStatistics{
+		ParametersExamined:       examined,
+		ParametersMachineDerived: machineDerivedSkipped,
+	}))
+
 	return results, nil
 }
+
+// classifyModification sets the severity of a modified parameter (paramName in the knowledge base naming):
+// a warning if the user set it on purpose, info if it differs by version drift, i.e. it is the default of an earlier
+// patch release of the source version family (see VersionDriftDetector), or if it is the default of the running PD
+func classifyModification(check *CheckResult, drift *VersionDriftDetector, component, paramName, sourceVersion, paramType string) {
+	if version, ok := drift.Detect(component, paramName, sourceVersion, check.CurrentValue, paramType); ok {
+		check.Message = fmt.Sprintf("Parameter %s in %s differs from the source version default by version drift (default of %s)",
+			check.ParameterName, component, version)
+		check.Details += fmt.Sprintf("\nOrigin: default of %s, kept when the cluster was patched (version drift)", version)
+		check.Suggestions = versionDriftSuggestions
+		if check.Metadata == nil {
+			check.Metadata = make(map[string]interface{})
+		}
+		check.Metadata["version_drift"] = version
+		return
+	}
+	if check.Metadata["config_origin"] == PDConfigOriginPDDefault {
+		return
+	}
+	check.Severity = "warning"
+	check.RiskLevel = RiskLevelMedium
+}
+
+// addPDConfigOrigin reports where a modified PD parameter comes from, comparing its effective value
+// with the default of the running PD (fieldPath is the path of a nested field of the parameter, or "")
+// Nothing is reported for other components, or if the running PD did not report its defaults
+func addPDConfigOrigin(check *CheckResult, component *collector.ComponentState, paramName, fieldPath string) {
+	if component.Type != collector.PDComponent || component.DefaultConfig == nil {
+		return
+	}
+	pdDefault, ok := component.DefaultConfig[paramName]
+	if !ok {
+		return
+	}
+	defaultValue := pdDefault.Value
+	if fieldPath != "" {
+		for _, key := range strings.Split(fieldPath, ".") {
+			fields := ConvertToMapStringInterface(defaultValue)
+			if fields == nil {
+				return
+			}
+			if defaultValue, ok = fields[key]; !ok {
+				return
+			}
+		}
+	}
+
+	origin := PDConfigOriginConfigured
+	if len(CompareMapsDeep(check.CurrentValue, defaultValue, CompareOptions{})) == 0 {
+		origin = PDConfigOriginPDDefault
+		check.Details += "\nOrigin: default of the running PD, which differs from the knowledge base default (no configuration change)"
+	} else {
+		check.Details += fmt.Sprintf("\nOrigin: set in the PD config file or changed at runtime with pd-ctl (running PD default: %s)", FormatValue(defaultValue))
+	}
+	if check.Metadata == nil {
+		check.Metadata = make(map[string]interface{})
+	}
+	check.Metadata["config_origin"] = origin
+	check.Metadata["pd_default"] = defaultValue
+}
+
+// checkMachineDerivedParam checks a machine-derived parameter against the resources of the node
+// Returns (result, true) only if resource info is available and the value is outside the sane range
+func (r *UserModifiedParamsRule) checkMachineDerivedParam(
+	compType, paramName string,
+	isSystemVar bool,
+	currentValue interface{},
+	param MachineDerivedParam,
+	status map[string]interface{},
+) (CheckResult, bool) {
+	outOfRange, ratio, ok := param.IsOutOfResourceRange(currentValue, status)
+	if !ok || !outOfRange {
+		return CheckResult{}, false
+	}
+
+	paramType := "config"
+	if isSystemVar {
+		paramType = "system_variable"
+	}
+	resourceAmount, _ := NodeResources(status, param.Resource)
+	resourceDesc := fmt.Sprintf("%.0f CPU cores", resourceAmount)
+	if param.Resource == ResourceMemory {
+		resourceDesc = fmt.Sprintf("%.1fGiB memory", resourceAmount/(1<<30))
+	}
+
+	node := compType
+	if addr, ok := status["address"].(string); ok && addr != "" {
+		node = addr
+	}
+
+	details := fmt.Sprintf("Current Value: %s\nNode Resources: %s (%s)\nValue / Resource Ratio: %.2f", FormatValue(currentValue), resourceDesc, node, ratio)
+	if param.MinRatio > 0 {
+		details += fmt.Sprintf("\nExpected Minimum Ratio: %.2f", param.MinRatio)
+	}
+	if param.MaxRatio > 0 {
+		details += fmt.Sprintf("\nExpected Maximum Ratio: %.2f", param.MaxRatio)
+	}
+	if param.Description != "" {
+		details += fmt.Sprintf("\n\nNote: %s", param.Description)
+	}
+
+	return CheckResult{
+		RuleID:        r.Name(),
+		Category:      r.Category(),
+		Component:     compType,
+		ParameterName: paramName,
+		ParamType:     paramType,
+		Severity:      "warning",
+		RiskLevel:     RiskLevelMedium,
+		Message:       fmt.Sprintf("Parameter %s in %s is outside the expected range for the node resources", paramName, compType),
+		Details:       details,
+		CurrentValue:  currentValue,
+		Suggestions: []string{
+			"The default of this parameter is derived from the host CPU/memory, so it is not compared with the KB default",
+			"Review whether the configured value still fits the resources of the node",
+		},
+		Metadata: map[string]interface{}{
+			"machine_derived": true,
+			"resource":        param.Resource,
+			"resource_ratio":  ratio,
+		},
+	}, true
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewUserModifiedParamsRule(t *testing.T) {
@@ -66,7 +67,7 @@ func TestUserModifiedParamsRule_Evaluate(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tidb": {
 							Type: types.ComponentTiDB,
-							Config: types.ConfigDefaults{
+							Config: types.ParameterMap{
 								"max-connections": types.ParameterValue{Value: 2000, Type: "int"},
 							},
 						},
@@ -88,7 +89,7 @@ func TestUserModifiedParamsRule_Evaluate(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tidb": {
 							Type: types.ComponentTiDB,
-							Config: types.ConfigDefaults{
+							Config: types.ParameterMap{
 								"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 							},
 						},
@@ -110,7 +111,7 @@ func TestUserModifiedParamsRule_Evaluate(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tidb": {
 							Type: types.ComponentTiDB,
-							Variables: types.SystemVariables{
+							Variables: types.ParameterMap{
 								"tidb_mem_quota_query": types.ParameterValue{Value: 2147483648, Type: "int"},
 							},
 						},
@@ -132,7 +133,7 @@ func TestUserModifiedParamsRule_Evaluate(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tikv": {
 							Type: types.ComponentTiKV,
-							Config: types.ConfigDefaults{
+							Config: types.ParameterMap{
 								"storage": types.ParameterValue{
 									Value: map[string]interface{}{
 										"data-dir":           "/data/tikv",
@@ -173,7 +174,7 @@ func TestUserModifiedParamsRule_Evaluate(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tikv": {
 							Type: types.ComponentTiKV,
-							Config: types.ConfigDefaults{
+							Config: types.ParameterMap{
 								"storage": types.ParameterValue{
 									Value: map[string]interface{}{
 										"reserve-space":      "5GiB",
@@ -204,7 +205,7 @@ func TestUserModifiedParamsRule_Evaluate(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tidb": {
 							Type: types.ComponentTiDB,
-							Config: types.ConfigDefaults{
+							Config: types.ParameterMap{
 								// Note: In the new architecture, path parameters are filtered in preprocessing stage
 								// Rules receive cleaned defaults (path parameters already removed)
 								// This test simulates that: data-dir is not in runtime config (filtered)
@@ -231,7 +232,7 @@ func TestUserModifiedParamsRule_Evaluate(t *testing.T) {
 					Components: map[string]collector.ComponentState{
 						"tikv": {
 							Type: types.ComponentTiKV,
-							Config: types.ConfigDefaults{
+							Config: types.ParameterMap{
 								"storage": types.ParameterValue{
 									Value: map[string]interface{}{
 										"data-dir": "/custom/tikv/data",
@@ -261,6 +262,7 @@ func TestUserModifiedParamsRule_Evaluate(t *testing.T) {
 			ctx := context.Background()
 
 			results, err := rule.Evaluate(ctx, tt.ruleCtx)
+			results = withoutStatistics(results)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -287,7 +289,7 @@ func TestUserModifiedParamsRule_RealWorldScenario(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tikv-0": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage": types.ParameterValue{
 							Value: map[string]interface{}{
 								"data-dir":           "/data/tidb-data/tikv-20160",
@@ -373,7 +375,7 @@ func TestUserModifiedParamsRule_FormatOutput(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tidb": {
 					Type: types.ComponentTiDB,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"max-connections": types.ParameterValue{Value: 2000, Type: "int"},
 					},
 				},
@@ -387,6 +389,7 @@ func TestUserModifiedParamsRule_FormatOutput(t *testing.T) {
 	}
 
 	results, err := rule.Evaluate(ctx, ruleCtx)
+	results = withoutStatistics(results)
 	assert.NoError(t, err)
 	assert.Equal(t, 1, len(results))
 
@@ -408,7 +411,7 @@ func TestUserModifiedParamsRule_ComplexNestedMap(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tikv": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage": types.ParameterValue{
 							Value: map[string]interface{}{
 								"block-cache": map[string]interface{}{
@@ -445,6 +448,7 @@ func TestUserModifiedParamsRule_ComplexNestedMap(t *testing.T) {
 	}
 
 	results, err := rule.Evaluate(ctx, ruleCtx)
+	results = withoutStatistics(results)
 	assert.NoError(t, err)
 
 	// Should report: block-cache.capacity, block-cache.high-pri-pool-ratio, io-rate-limit.max-bytes-per-sec
@@ -470,7 +474,7 @@ func TestUserModifiedParamsRule_OnlyDifferingFields(t *testing.T) {
 			Components: map[string]collector.ComponentState{
 				"tikv": {
 					Type: types.ComponentTiKV,
-					Config: types.ConfigDefaults{
+					Config: types.ParameterMap{
 						"storage": types.ParameterValue{
 							Value: map[string]interface{}{
 								"api-version":                       1,
@@ -517,9 +521,320 @@ func TestUserModifiedParamsRule_OnlyDifferingFields(t *testing.T) {
 	}
 
 	results, err := rule.Evaluate(ctx, ruleCtx)
+	results = withoutStatistics(results)
 	assert.NoError(t, err)
 
 	// Should only report reserve-space difference, not all the other fields
 	assert.Equal(t, 1, len(results), "Should only report the one differing field (reserve-space)")
 	assert.Contains(t, results[0].ParameterName, "reserve-space", "Should report reserve-space")
 }
+
+func TestUserModifiedParamsRule_MachineDerivedParams(t *testing.T) {
+	rule := NewUserModifiedParamsRule().(*UserModifiedParamsRule)
+	ctx := context.Background()
+
+	newRuleCtx := func(status map[string]interface{}) *RuleContext {
+		return &RuleContext{
+			SourceClusterSnapshot: &collector.ClusterSnapshot{
+				Components: map[string]collector.ComponentState{
+					"tikv": {
+						Type: types.ComponentTiKV,
+						Config: types.ParameterMap{
+							"readpool.unified.max-thread-count": types.ParameterValue{Value: 32, Type: "int"},
+							"server.grpc-concurrency":           types.ParameterValue{Value: 4, Type: "int"},
+							"raftstore.messages-per-tick":       types.ParameterValue{Value: 4096, Type: "int"},
+						},
+						Status: status,
+					},
+				},
+			},
+			SourceDefaults: map[string]map[string]interface{}{
+				"tikv": {
+					"readpool.unified.max-thread-count": 10,
+					"server.grpc-concurrency":           5,
+					"raftstore.messages-per-tick":       1024,
+				},
+			},
+			MachineDerivedParams: DefaultMachineDerivedParams(),
+		}
+	}
+
+	findResult := func(results []CheckResult, paramName string) *CheckResult {
+		for i := range results {
+			if results[i].ParameterName == paramName {
+				return &results[i]
+			}
+		}
+		return nil
+	}
+	findStatistics := func(results []CheckResult) *RuleStatistics {
+		for _, result := range results {
+			if result.Statistics != nil {
+				return result.Statistics
+			}
+		}
+		return nil
+	}
+
+	t.Run("without resource info", func(t *testing.T) {
+		results, err := rule.Evaluate(ctx, newRuleCtx(map[string]interface{}{}))
+		assert.NoError(t, err)
+
+		// Machine-derived parameters are not compared with KB defaults
+		assert.Nil(t, findResult(results, "readpool.unified.max-thread-count"))
+		assert.Nil(t, findResult(results, "server.grpc-concurrency"))
+		assert.NotNil(t, findResult(results, "raftstore.messages-per-tick"))
+
+		stats := findStatistics(results)
+		if assert.NotNil(t, stats) {
+			assert.Equal(t, 3, stats.ParametersExamined)
+			assert.Equal(t, 2, stats.ParametersMachineDerived)
+		}
+	})
+
+	t.Run("with resource info", func(t *testing.T) {
+		results, err := rule.Evaluate(ctx, newRuleCtx(map[string]interface{}{StatusKeyCPUCores: float64(16)}))
+		assert.NoError(t, err)
+
+		// 32 threads on a 16-core node is outside the sane range
+		outOfRange := findResult(results, "readpool.unified.max-thread-count")
+		if assert.NotNil(t, outOfRange) {
+			assert.Equal(t, "warning", outOfRange.Severity)
+			assert.Equal(t, true, outOfRange.Metadata["machine_derived"])
+		}
+		// 4 gRPC threads on a 16-core node is fine
+		assert.Nil(t, findResult(results, "server.grpc-concurrency"))
+	})
+
+	t.Run("classification not configured", func(t *testing.T) {
+		ruleCtx := newRuleCtx(map[string]interface{}{})
+		ruleCtx.MachineDerivedParams = nil
+		results, err := rule.Evaluate(ctx, ruleCtx)
+		assert.NoError(t, err)
+
+		assert.NotNil(t, findResult(results, "readpool.unified.max-thread-count"))
+		stats := findStatistics(results)
+		if assert.NotNil(t, stats) {
+			assert.Equal(t, 0, stats.ParametersMachineDerived)
+		}
+	})
+}
+
+func TestUserModifiedParamsRule_NormalizesRuntimeValues(t *testing.T) {
+	rule := NewUserModifiedParamsRule()
+
+	// The runtime reports strings while the knowledge base defaults are typed
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"pd": {
+					Type: types.ComponentPD,
+					Config: types.ParameterMap{
+						"schedule.enable-cross-table-merge":  {Value: "true", Type: "string"},
+						"schedule.max-merge-region-keys":     {Value: "200000", Type: "string"},
+						"replication.enable-placement-rules": {Value: "0", Type: "string"},
+					},
+				},
+			},
+		},
+		SourceVersion: "v7.5.0",
+		SourceDefaults: map[string]map[string]interface{}{
+			"pd": {
+				"schedule.enable-cross-table-merge":  map[string]interface{}{"value": true, "type": "bool"},
+				"schedule.max-merge-region-keys":     types.ParameterValue{Value: float64(200000), Type: "int"},
+				"replication.enable-placement-rules": map[string]interface{}{"value": true, "type": "bool"},
+			},
+		},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	assert.NoError(t, err)
+
+	findings := withoutStatistics(results)
+	if assert.Len(t, findings, 1) {
+		assert.Equal(t, "replication.enable-placement-rules", findings[0].ParameterName)
+		assert.Equal(t, "0", findings[0].CurrentValue)
+	}
+}
+
+func TestUserModifiedParamsRule_PDConfigOrigin(t *testing.T) {
+	rule := NewUserModifiedParamsRule()
+
+	pd := collector.ComponentState{
+		Type: types.ComponentPD,
+		// Effective config persisted in etcd
+		Config: types.ParameterMap{
+			"schedule": {Value: map[string]interface{}{
+				"leader-schedule-limit": float64(8), // changed with pd-ctl
+				"region-schedule-limit": float64(2048),
+			}},
+			"lease": {Value: float64(5)},
+		},
+		// Defaults of the running PD
+		DefaultConfig: types.ParameterMap{
+			"schedule": {Value: map[string]interface{}{
+				"leader-schedule-limit": float64(4),
+				"region-schedule-limit": float64(2048),
+			}},
+			"lease": {Value: float64(3)},
+		},
+	}
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{"pd": pd},
+		},
+		SourceVersion: "v7.5.0",
+		SourceDefaults: map[string]map[string]interface{}{
+			"pd": {
+				"schedule": map[string]interface{}{
+					"leader-schedule-limit": float64(4),
+					// The knowledge base default differs from the default of the running PD
+					"region-schedule-limit": float64(1024),
+				},
+				"lease": map[string]interface{}{"value": float64(3), "type": "int"},
+			},
+		},
+	}
+
+	results, err := rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	origins := make(map[string]interface{})
+	for _, result := range withoutStatistics(results) {
+		origins[result.ParameterName] = result.Metadata["config_origin"]
+		assert.Contains(t, result.Details, "Origin: ")
+	}
+	assert.Equal(t, map[string]interface{}{
+		"schedule.leader-schedule-limit": PDConfigOriginConfigured,
+		"schedule.region-schedule-limit": PDConfigOriginPDDefault,
+		"lease":                          PDConfigOriginConfigured,
+	}, origins)
+
+	// Without the defaults of the running PD (e.g., TiDB Operator), the origin is not reported
+	pd.DefaultConfig = nil
+	ruleCtx.SourceClusterSnapshot.Components["pd"] = pd
+	results, err = rule.Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	findings := withoutStatistics(results)
+	assert.Len(t, findings, 3)
+	for _, result := range findings {
+		assert.NotContains(t, result.Details, "Origin: ")
+		assert.Nil(t, result.Metadata)
+	}
+}
+
+func TestUserModifiedParamsRule_EmptyDefaults(t *testing.T) {
+	// Parameters misreported before empty defaults and unset values were equivalent:
+	// the status API omits log.file.filename and returns metric.address as null
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tikv": {
+					Type: types.ComponentTiKV,
+					Config: types.ParameterMap{
+						"metric.address":     types.ParameterValue{Value: nil},
+						"security.ca-path":   types.ParameterValue{Value: "", Type: "string"},
+						"security.cert-path": types.ParameterValue{Value: "/etc/tls/tikv.pem", Type: "string"},
+					},
+				},
+			},
+		},
+		SourceDefaults: map[string]map[string]interface{}{
+			"tikv": {
+				"log.file.filename":  types.ParameterValue{Value: "", Type: "string"},
+				"metric.address":     types.ParameterValue{Value: "", Type: "string"},
+				"security.ca-path":   types.ParameterValue{Value: "", Type: "string"},
+				"security.cert-path": types.ParameterValue{Value: "", Type: "string"},
+				"security.key-path":  types.ParameterValue{Value: "", Type: "string"},
+				"log.level":          types.ParameterValue{Value: "info", Type: "string"},
+			},
+		},
+		SourceVersion: "v7.5.0",
+	}
+
+	results, err := NewUserModifiedParamsRule().Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	reported := make(map[string]string)
+	for _, result := range results {
+		if result.ParameterName != "" {
+			reported[result.ParameterName] = result.Message
+		}
+	}
+	assert.Len(t, reported, 2, reported)
+	assert.Contains(t, reported["security.cert-path"], "modified by user")
+	// A parameter with a non-empty default is still reported when it is missing
+	assert.Contains(t, reported["log.level"], "not found in runtime")
+}
+
+func TestUserModifiedParamsRule_VersionDrift(t *testing.T) {
+	ruleCtx := &RuleContext{
+		SourceClusterSnapshot: &collector.ClusterSnapshot{
+			Components: map[string]collector.ComponentState{
+				"tidb": {
+					Type: types.ComponentTiDB,
+					Config: types.ParameterMap{
+						"max-connections": {Value: 2000, Type: "int"},
+					},
+					Variables: types.ParameterMap{
+						// Persisted when the cluster was deployed with v7.5.0, kept when it was patched to v7.5.3
+						"tidb_opt_x": {Value: "OFF", Type: "string"},
+						"tidb_opt_y": {Value: "CUSTOM", Type: "string"},
+					},
+				},
+			},
+		},
+		SourceVersion: "v7.5.3",
+		SourceDefaults: map[string]map[string]interface{}{
+			"tidb": {
+				"max-connections":   1000,
+				"sysvar:tidb_opt_x": types.ParameterValue{Value: "AUTO", Type: "string"},
+				"sysvar:tidb_opt_y": types.ParameterValue{Value: "AUTO", Type: "string"},
+			},
+		},
+		ParameterHistory: map[string]*collector.ParameterHistory{
+			"tidb": {
+				Component: "tidb",
+				Versions:  []string{"v7.1.0", "v7.5.0", "v7.5.1", "v7.5.2", "v7.5.3"},
+				Parameters: map[string][]collector.ParameterDefaultChange{
+					"sysvar:tidb_opt_x": {
+						{Version: "v7.5.1", PreviousVersion: "v7.5.0", OldValue: "OFF", NewValue: "ON"},
+						{Version: "v7.5.3", PreviousVersion: "v7.5.2", OldValue: "ON", NewValue: "AUTO"},
+					},
+					"sysvar:tidb_opt_y": {
+						{Version: "v7.5.3", PreviousVersion: "v7.5.2", OldValue: "ON", NewValue: "AUTO"},
+					},
+				},
+			},
+		},
+	}
+
+	results, err := NewUserModifiedParamsRule().Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	findings := make(map[string]CheckResult)
+	for _, result := range withoutStatistics(results) {
+		findings[result.ParameterName] = result
+	}
+	require.Len(t, findings, 3)
+
+	// The default of an earlier patch release is version drift
+	drifted := findings["tidb_opt_x"]
+	assert.Equal(t, "info", drifted.Severity)
+	assert.Equal(t, RiskLevelLow, drifted.RiskLevel)
+	assert.Contains(t, drifted.Message, "version drift (default of v7.5.0)")
+	assert.Equal(t, "v7.5.0", drifted.Metadata["version_drift"])
+
+	// Values no release of the family defaults to are set on purpose
+	for _, name := range []string{"tidb_opt_y", "max-connections"} {
+		assert.Equal(t, "warning", findings[name].Severity, name)
+		assert.Equal(t, RiskLevelMedium, findings[name].RiskLevel, name)
+		assert.Contains(t, findings[name].Message, "has been modified by user", name)
+		assert.Nil(t, findings[name].Metadata, name)
+	}
+
+	// Without the parameter history, every modification is reported as set on purpose
+	ruleCtx.ParameterHistory = nil
+	results, err = NewUserModifiedParamsRule().Evaluate(context.Background(), ruleCtx)
+	require.NoError(t, err)
+	for _, result := range withoutStatistics(results) {
+		assert.Equal(t, "warning", result.Severity, result.ParameterName)
+	}
+}
@@ -0,0 +1,68 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// RulesConfig holds the tunable thresholds of the rules, read from the file given with --rules-config
+// Each rule has its own section; a missing section or field keeps the rule's default
+// Example:
+//
+//	{"operational_conflicts": {"gc_safe_point_max_age": "12h"}}
+type RulesConfig struct {
+	// OperationalConflicts configures OperationalConflictsRule
+	OperationalConflicts *OperationalConflictsConfig `json:"operational_conflicts,omitempty"`
+}
+
+// ConfigurableRule is a rule whose thresholds can be set from a RulesConfig
+type ConfigurableRule interface {
+	Rule
+	// Configure applies the rule's section of config, it returns an error if the section is invalid
+	Configure(config *RulesConfig) error
+}
+
+// ParseRulesConfig parses a rules config, rejecting unknown fields
+// so that misspelled keys are not silently ignored
+func ParseRulesConfig(data []byte) (*RulesConfig, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	config := &RulesConfig{}
+	if err := decoder.Decode(config); err != nil {
+		return nil, fmt.Errorf("invalid rules config: %w", err)
+	}
+	return config, nil
+}
+
+// LoadRulesConfig reads and parses a rules config file (see ParseRulesConfig)
+func LoadRulesConfig(path string) (*RulesConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	config, err := ParseRulesConfig(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return config, nil
+}
+
+// ApplyRulesConfig configures the rules that implement ConfigurableRule
+// A nil config leaves every rule with its defaults
+func ApplyRulesConfig(rules []Rule, config *RulesConfig) error {
+	if config == nil {
+		return nil
+	}
+	for _, rule := range rules {
+		configurable, ok := rule.(ConfigurableRule)
+		if !ok {
+			continue
+		}
+		if err := configurable.Configure(config); err != nil {
+			return fmt.Errorf("invalid configuration of rule %s: %w", rule.Name(), err)
+		}
+	}
+	return nil
+}
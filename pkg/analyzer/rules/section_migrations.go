@@ -0,0 +1,108 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"encoding/json"
+	"strings"
+
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// SectionMigration describes a config parameter that moved to another section or key
+// (e.g., TiDB log.slow-threshold became instance.tidb_slow_log_threshold)
+// They are loaded from knowledge/section_migrations.json
+type SectionMigration struct {
+	// OldKey is the key of the parameter before the migration (e.g., "log.slow-threshold")
+	OldKey string `json:"old_key"`
+	// NewKey is the key of the parameter after the migration (e.g., "instance.tidb_slow_log_threshold")
+	NewKey string `json:"new_key"`
+	// Component is the component of the parameter (e.g., "tidb")
+	Component string `json:"component"`
+	// FromVersion is the oldest source version the entry was written for (informational)
+	FromVersion string `json:"from_version"`
+	// ToVersion is the version that moves the parameter
+	// The migration applies if sourceVersion < ToVersion <= targetVersion
+	ToVersion string `json:"to_version"`
+}
+
+// sectionMigrationsFile is the structure of knowledge/section_migrations.json
+type sectionMigrationsFile struct {
+	SectionMigrations []SectionMigration `json:"section_migrations"`
+}
+
+// ParseSectionMigrations converts section_migrations loaded from the knowledge base (generic JSON map)
+// into a list of SectionMigration
+func ParseSectionMigrations(raw interface{}) ([]SectionMigration, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var file sectionMigrationsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return file.SectionMigrations, nil
+}
+
+// AppliesTo checks if the parameter moves when upgrading from sourceVersion to targetVersion
+func (m SectionMigration) AppliesTo(sourceVersion, targetVersion string) bool {
+	toVersion := strings.TrimPrefix(m.ToVersion, "v")
+	if toVersion == "" {
+		return false
+	}
+	return compareVersions(strings.TrimPrefix(sourceVersion, "v"), toVersion) < 0 &&
+		compareVersions(strings.TrimPrefix(targetVersion, "v"), toVersion) >= 0
+}
+
+// MovedParameter is a section migration that applies to the source cluster
+type MovedParameter struct {
+	SectionMigration
+	// OldValue is the current value of the old key
+	OldValue interface{}
+}
+
+// GetMovedParameters returns the parameters of a component that move to another section during the upgrade
+// A migration applies if the old key is set in the current config and is gone in the target version, and
+// the new key is new in the target version, i.e. if the upgrade would otherwise show the old key disappearing
+// and the new key appearing
+// The result is keyed by the new key
+func (ctx *RuleContext) GetMovedParameters(component string, config defaultsTypes.ParameterMap) map[string]MovedParameter {
+	moved := make(map[string]MovedParameter)
+	for _, migration := range ctx.SectionMigrations {
+		if migration.Component != component || !migration.AppliesTo(ctx.GetComponentSourceVersion(component), ctx.TargetVersion) {
+			continue
+		}
+		if _, ok := ctx.TargetDefaults[component][migration.NewKey]; !ok {
+			continue
+		}
+		if _, ok := ctx.TargetDefaults[component][migration.OldKey]; ok {
+			continue
+		}
+		if _, ok := config[migration.NewKey]; ok {
+			continue
+		}
+		if oldValue, ok := lookupConfigValue(config, migration.OldKey); ok {
+			moved[migration.NewKey] = MovedParameter{SectionMigration: migration, OldValue: oldValue}
+		}
+	}
+	return moved
+}
+
+// lookupConfigValue returns the value of a dotted config key, either stored under the full key
+// or as a field of a map-valued parameter (e.g., "log.slow-threshold" in the "log" map)
+func lookupConfigValue(config defaultsTypes.ParameterMap, key string) (interface{}, bool) {
+	if value, ok := config[key]; ok {
+		return value.Value, value.Value != nil
+	}
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		parent, ok := config[strings.Join(parts[:i], ".")]
+		if !ok {
+			continue
+		}
+		if value := getNestedMapValue(ConvertToMapStringInterface(parent.Value), parts[i:]); value != nil {
+			return value, true
+		}
+	}
+	return nil, false
+}
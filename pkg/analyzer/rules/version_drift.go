@@ -0,0 +1,34 @@
+// Package rules provides standardized rule definitions for upgrade precheck
+package rules
+
+import (
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// VersionDriftDetector tells parameters the user set on purpose from parameters that only differ from the source
+// version default because the default changed in an earlier patch release of the source version family: a cluster
+// deployed with v7.5.0 and patched to v7.5.3 keeps the v7.5.0 default of persisted settings (e.g., system variables)
+// The historical defaults come from the parameter history of the knowledge base (knowledge/<component>/parameter_history.json)
+type VersionDriftDetector struct {
+	history map[string]*collector.ParameterHistory
+}
+
+// NewVersionDriftDetector creates a detector from the parameter history of each component (nil detects no drift)
+func NewVersionDriftDetector(history map[string]*collector.ParameterHistory) *VersionDriftDetector {
+	return &VersionDriftDetector{history: history}
+}
+
+// Detect checks if the current value of a parameter is the default of an earlier patch release of the family of
+// sourceVersion, and returns the first such release
+// paramName uses the knowledge base naming (system variables have the "sysvar:" prefix), paramType guides the
+// comparison of the values (see types.ParameterValue.Equal)
+func (d *VersionDriftDetector) Detect(component, paramName, sourceVersion string, current interface{}, paramType string) (string, bool) {
+	value := defaultsTypes.ParameterValue{Value: current, Type: paramType}
+	for _, historical := range d.history[component].FamilyDefaults(paramName, sourceVersion) {
+		if historical.Value != nil && value.Equal(defaultsTypes.ParameterValue{Value: historical.Value}) {
+			return historical.Version, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,20 @@
+package analyzer
+
+import (
+	_ "embed"
+)
+
+// AnalysisResultSchemaFileName is the file name of the JSON Schema of AnalysisResult
+const AnalysisResultSchemaFileName = "analysis_result.schema.json"
+
+// analysisResultSchema is the JSON Schema (draft-07) of the JSON report (AnalysisResult)
+// It is generated from the Go types by TestAnalysisResultSchemaInSync and embedded so it is available offline
+//
+//go:embed analysis_result.schema.json
+var analysisResultSchema []byte
+
+// AnalysisResultSchema returns the JSON Schema (draft-07) describing AnalysisResult, as produced by --format json
+// Downstream tools can use it to validate reports
+func AnalysisResultSchema() []byte {
+	return analysisResultSchema
+}
@@ -0,0 +1,64 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/internal/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// draft07SchemaURI is the $schema of the generated schema
+const draft07SchemaURI = "http://json-schema.org/draft-07/schema#"
+
+// generateAnalysisResultSchema generates the JSON Schema of AnalysisResult from the Go types
+// Descriptions are taken from the doc comments of the pkg/ sources, read from the module root
+// (comments are keyed by import path, which is derived from the walked directories)
+func generateAnalysisResultSchema(t *testing.T) []byte {
+	reflector := &jsonschema.Reflector{
+		// Fields are only required when the jsonschema tag says so: most fields are omitempty
+		RequiredFromJSONSchemaTags: true,
+		// Reports may be extended with new fields, consumers should not reject them
+		AllowAdditionalProperties: true,
+	}
+	t.Chdir("../..")
+	require.NoError(t, reflector.AddGoComments("github.com/pingcap/tidb-upgrade-precheck", "pkg"))
+
+	schema := reflector.Reflect(&AnalysisResult{})
+	schema.Version = draft07SchemaURI
+	schema.ID = "https://github.com/pingcap/tidb-upgrade-precheck/" + AnalysisResultSchemaFileName
+	schema.Title = "TiDB upgrade precheck report"
+	schema.Description = "Analysis result of tidb-upgrade-precheck, as produced by --format json"
+
+	data, err := json.MarshalIndent(schema, "", "  ")
+	require.NoError(t, err)
+	return append(data, '\n')
+}
+
+// TestAnalysisResultSchemaInSync checks the embedded schema against the Go types
+// To regenerate it after changing AnalysisResult or the types it contains, run:
+//
+//	go test -tags update_golden ./pkg/analyzer/ -run TestAnalysisResultSchemaInSync
+func TestAnalysisResultSchemaInSync(t *testing.T) {
+	schemaPath, err := filepath.Abs(AnalysisResultSchemaFileName)
+	require.NoError(t, err)
+	generated := generateAnalysisResultSchema(t)
+	golden.Check(t, schemaPath, generated, "go test -tags update_golden ./pkg/analyzer/ -run TestAnalysisResultSchemaInSync")
+	if golden.Update {
+		// The embedded schema is the one of the build, not the regenerated file
+		return
+	}
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(AnalysisResultSchema(), &schema))
+	assert.Equal(t, draft07SchemaURI, schema["$schema"])
+
+	defs, ok := schema["$defs"].(map[string]interface{})
+	require.True(t, ok)
+	for _, name := range []string{"AnalysisResult", "CheckResult", "ModifiedParamInfo", "UpgradeDifference", "ForcedChange", "Statistics"} {
+		assert.Contains(t, defs, name)
+	}
+}
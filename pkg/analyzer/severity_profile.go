@@ -0,0 +1,161 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// Built-in severity profile names (see --profile)
+const (
+	SeverityProfileDefault = "default"
+	SeverityProfileStrict  = "strict"
+	SeverityProfileLenient = "lenient"
+)
+
+// SeverityProfile transforms the severity of the check results after deduplication, so that the same
+// precheck can be stricter on production clusters than on staging ones (e.g., warnings become errors)
+// Example of a profile file:
+//
+//	{"name": "production", "severities": {"consistency": {"warning": "error"}, "*": {"info": "warning"}}}
+type SeverityProfile struct {
+	// Name is the name of the profile (the file path if not set)
+	Name string `json:"name"`
+	// Severities maps a rule ID (e.g., "FORCED_CHANGES") or a category (e.g., "consistency"), or "*" for
+	// every result, to the original severity -> effective severity matrix
+	// A rule ID takes precedence over a category, which takes precedence over "*"
+	Severities map[string]map[string]string `json:"severities"`
+}
+
+// severityLevels are the valid severities of a check result
+var severityLevels = map[string]bool{
+	"info":     true,
+	"warning":  true,
+	"error":    true,
+	"critical": true,
+}
+
+// builtinSeverityProfiles are the profiles selected by name with --profile
+var builtinSeverityProfiles = map[string]*SeverityProfile{
+	SeverityProfileDefault: {Name: SeverityProfileDefault},
+	// strict is meant for production clusters: forced changes and inconsistent TiKV nodes must be resolved
+	SeverityProfileStrict: {
+		Name: SeverityProfileStrict,
+		Severities: map[string]map[string]string{
+			"FORCED_CHANGES": {"warning": "error"},
+			"consistency":    {"warning": "error"},
+		},
+	},
+	// lenient is meant for staging clusters, whose configuration is expected to diverge
+	SeverityProfileLenient: {
+		Name: SeverityProfileLenient,
+		Severities: map[string]map[string]string{
+			"user_modified": {"warning": "info"},
+			"golden_drift":  {"warning": "info"},
+		},
+	},
+}
+
+// SeverityProfileNames returns the names of the built-in profiles, sorted
+func SeverityProfileNames() []string {
+	names := make([]string, 0, len(builtinSeverityProfiles))
+	for name := range builtinSeverityProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadSeverityProfile returns the built-in profile of that name, or else loads the profile file at that path
+// An empty name selects the default profile, which keeps every severity
+func LoadSeverityProfile(nameOrPath string) (*SeverityProfile, error) {
+	if nameOrPath == "" {
+		nameOrPath = SeverityProfileDefault
+	}
+	if profile, ok := builtinSeverityProfiles[nameOrPath]; ok {
+		return profile, nil
+	}
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q is neither a built-in profile (%s) nor a readable file: %w",
+			nameOrPath, strings.Join(SeverityProfileNames(), ", "), err)
+	}
+	profile, err := ParseSeverityProfile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", nameOrPath, err)
+	}
+	if profile.Name == "" {
+		profile.Name = nameOrPath
+	}
+	return profile, nil
+}
+
+// ParseSeverityProfile parses and validates a severity profile, rejecting unknown fields
+// so that misspelled keys are not silently ignored
+func ParseSeverityProfile(data []byte) (*SeverityProfile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	profile := &SeverityProfile{}
+	if err := decoder.Decode(profile); err != nil {
+		return nil, fmt.Errorf("invalid severity profile: %w", err)
+	}
+	if err := profile.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid severity profile: %w", err)
+	}
+	return profile, nil
+}
+
+// Validate checks that the matrices of the profile only use valid severities
+func (p *SeverityProfile) Validate() error {
+	for key, matrix := range p.Severities {
+		if key == "" {
+			return fmt.Errorf("empty rule ID or category")
+		}
+		for from, to := range matrix {
+			if !severityLevels[from] {
+				return fmt.Errorf("%s: invalid severity %q", key, from)
+			}
+			if !severityLevels[to] {
+				return fmt.Errorf("%s: invalid severity %q for %s", key, to, from)
+			}
+		}
+	}
+	return nil
+}
+
+// effectiveSeverity returns the severity of a check result under the profile
+// The matrices of the rule ID, the category and "*" are looked up in that order, the first one
+// mapping the severity of the result wins
+func (p *SeverityProfile) effectiveSeverity(check rules.CheckResult) string {
+	for _, key := range []string{check.RuleID, check.Category, "*"} {
+		if severity, ok := p.Severities[key][check.Severity]; ok {
+			return severity
+		}
+	}
+	return check.Severity
+}
+
+// Apply transforms the severity of the results in place
+// The severity set by the rule is kept in OriginalSeverity when it changes, and the risk level follows
+// the effective severity
+func (p *SeverityProfile) Apply(results []rules.CheckResult) {
+	if p == nil {
+		return
+	}
+	for i := range results {
+		severity := p.effectiveSeverity(results[i])
+		if severity == results[i].Severity {
+			continue
+		}
+		if results[i].OriginalSeverity == "" {
+			results[i].OriginalSeverity = results[i].Severity
+		}
+		results[i].Severity = severity
+		results[i].RiskLevel = rules.GetRiskLevel(severity)
+	}
+}
@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSeverityProfile_Builtin(t *testing.T) {
+	for _, name := range []string{"", "default", "strict", "lenient"} {
+		profile, err := LoadSeverityProfile(name)
+		require.NoError(t, err, name)
+		require.NoError(t, profile.Validate(), name)
+	}
+
+	profile, err := LoadSeverityProfile("")
+	require.NoError(t, err)
+	assert.Equal(t, SeverityProfileDefault, profile.Name)
+}
+
+func TestLoadSeverityProfile_File(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "production.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"severities": {"*": {"warning": "error"}}}`), 0644))
+
+	profile, err := LoadSeverityProfile(path)
+	require.NoError(t, err)
+	assert.Equal(t, path, profile.Name)
+	assert.Equal(t, map[string]string{"warning": "error"}, profile.Severities["*"])
+
+	_, err = LoadSeverityProfile(filepath.Join(dir, "missing.json"))
+	assert.ErrorContains(t, err, "neither a built-in profile")
+}
+
+func TestParseSeverityProfile_Invalid(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown field":       `{"severity": {"*": {"warning": "error"}}}`,
+		"unknown severity":    `{"severities": {"*": {"warn": "error"}}}`,
+		"unknown target":      `{"severities": {"consistency": {"warning": "fatal"}}}`,
+		"empty key":           `{"severities": {"": {"warning": "error"}}}`,
+		"not a severity map":  `{"severities": {"*": "error"}}`,
+		"malformed json file": `{"severities":`,
+	} {
+		_, err := ParseSeverityProfile([]byte(data))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestSeverityProfile_Apply(t *testing.T) {
+	profile := &SeverityProfile{Severities: map[string]map[string]string{
+		"FORCED_CHANGES": {"warning": "critical"},
+		"consistency":    {"warning": "error"},
+		"*":              {"warning": "info", "info": "warning"},
+	}}
+	results := []rules.CheckResult{
+		{RuleID: "FORCED_CHANGES", Category: "upgrade_difference", Severity: "warning", RiskLevel: rules.RiskLevelMedium},
+		{RuleID: "TIKV_CONSISTENCY", Category: "consistency", Severity: "warning", RiskLevel: rules.RiskLevelMedium},
+		// The category only maps warnings, "*" applies to the other severities
+		{RuleID: "TIKV_CONSISTENCY", Category: "consistency", Severity: "info", RiskLevel: rules.RiskLevelLow},
+		{RuleID: "USER_MODIFIED_PARAMS", Category: "user_modified", Severity: "warning", RiskLevel: rules.RiskLevelMedium},
+		{RuleID: "UPGRADE_PATH", Category: "upgrade_path", Severity: "critical", RiskLevel: rules.RiskLevelHigh},
+	}
+	profile.Apply(results)
+
+	expected := []struct {
+		severity, original string
+		risk               rules.RiskLevel
+	}{
+		{"critical", "warning", rules.RiskLevelHigh},
+		{"error", "warning", rules.RiskLevelHigh},
+		{"warning", "info", rules.RiskLevelMedium},
+		{"info", "warning", rules.RiskLevelLow},
+		{"critical", "", rules.RiskLevelHigh},
+	}
+	for i, e := range expected {
+		assert.Equal(t, e.severity, results[i].Severity, results[i].RuleID)
+		assert.Equal(t, e.original, results[i].OriginalSeverity, results[i].RuleID)
+		assert.Equal(t, e.risk, results[i].RiskLevel, results[i].RuleID)
+	}
+}
+
+func TestAnalyzer_organizeResults_SeverityProfile(t *testing.T) {
+	strict, err := LoadSeverityProfile(SeverityProfileStrict)
+	require.NoError(t, err)
+	checkResults := []rules.CheckResult{
+		{RuleID: "TIKV_CONSISTENCY", Component: "tikv", ParameterName: "raftstore.x", ParamType: "config", Category: "consistency", Severity: "warning"},
+		{RuleID: "USER_MODIFIED_PARAMS", Component: "tidb", ParameterName: "config_b", ParamType: "config", Category: "user_modified", Severity: "warning"},
+	}
+
+	result := NewAnalyzer(&AnalysisOptions{SeverityProfile: strict}).organizeResults(checkResults, nil, "v7.5.0", "v8.5.0")
+	// Results are sorted by component: tidb first
+	require.Len(t, result.CheckResults, 2)
+	assert.Equal(t, "warning", result.CheckResults[0].Severity)
+	assert.Equal(t, "error", result.CheckResults[1].Severity)
+	assert.Equal(t, "warning", result.CheckResults[1].OriginalSeverity)
+	// Statistics and critical findings use the effective severity
+	assert.Equal(t, SeverityBreakdown{"tidb": {"warning": 1}, "tikv": {"error": 1}}, result.Statistics.SeverityByComponent)
+	require.Len(t, result.CriticalFindings(), 1)
+	assert.Equal(t, "raftstore.x", result.CriticalFindings()[0].ParameterName)
+}
@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze_SQLOnlySnapshot(t *testing.T) {
+	// TiKV configuration only comes from the cluster_config rows read through TiDB
+	tikvInstance := func(instance, statusAddr, reserveSpace string) tidb.ClusterInstance {
+		return tidb.ClusterInstance{
+			Type:          types.ComponentTiKV,
+			Instance:      instance,
+			StatusAddress: statusAddr,
+			Version:       "v7.5.0",
+			Config:        map[string]interface{}{"storage.reserve-space": reserveSpace},
+		}
+	}
+	cluster := &tidb.ClusterViaSQL{
+		Instances: []tidb.ClusterInstance{
+			{Type: types.ComponentTiDB, Instance: "10.0.0.1:4000", StatusAddress: "10.0.0.1:10080", Version: "v7.5.0"},
+			tikvInstance("10.0.0.2:20160", "10.0.0.2:20180", "5GiB"),
+			tikvInstance("10.0.0.3:20160", "10.0.0.3:20180", "2GiB"),
+		},
+	}
+	tidbState := &collector.ComponentState{
+		Type:      types.ComponentTiDB,
+		Version:   "v7.5.0",
+		Config:    types.ParameterMap{},
+		Variables: types.ParameterMap{},
+	}
+	snapshot := collector.NewSQLOnlySnapshot(tidbState, cluster, collector.CollectDataRequirements{
+		Components:       []string{"tidb", "tikv"},
+		NeedConfig:       true,
+		NeedAllTikvNodes: true,
+	})
+
+	kb := map[string]interface{}{
+		"tikv": map[string]interface{}{
+			"config_defaults": map[string]interface{}{
+				"storage.reserve-space": map[string]interface{}{"value": "5GiB", "type": "string"},
+			},
+		},
+	}
+	analyzer := NewAnalyzer(&AnalysisOptions{
+		Rules: []rules.Rule{rules.NewTikvConsistencyRule()},
+	})
+	result, err := analyzer.Analyze(context.Background(), snapshot, "v7.5.0", "v8.5.0", kb, kb)
+	require.NoError(t, err)
+
+	require.Contains(t, result.TikvInconsistencies, "storage.reserve-space")
+	var values []interface{}
+	for _, node := range result.TikvInconsistencies["storage.reserve-space"] {
+		values = append(values, node.Value)
+	}
+	assert.Contains(t, values, "5GiB")
+	assert.Contains(t, values, "2GiB")
+
+	require.NotNil(t, result.Collection)
+	assert.Equal(t, types.CollectionModeSQLOnly, result.Collection.Mode)
+	assert.NotEmpty(t, result.Collection.Limitations)
+}
+
+func TestAnalyze_MissingPrivileges(t *testing.T) {
+	snapshot := &collector.ClusterSnapshot{
+		Components: map[string]collector.ComponentState{
+			"tidb": {Type: types.ComponentTiDB, Version: "v7.5.0", Config: types.ParameterMap{}, Variables: types.ParameterMap{}},
+		},
+	}
+	snapshot.AddMissingPrivilege(types.MissingPrivilege{Query: "SHOW CONFIG", Privilege: "CONFIG", DataClass: types.DataClassConfig})
+
+	result, err := NewAnalyzer(&AnalysisOptions{Rules: []rules.Rule{}}).Analyze(context.Background(), snapshot, "v7.5.0", "v8.5.0", nil, nil)
+	require.NoError(t, err)
+	require.NotNil(t, result.Collection, "a full collection with denied queries is still limited")
+	assert.Empty(t, result.Collection.Mode)
+	assert.Equal(t, snapshot.MissingPrivileges, result.Collection.MissingPrivileges)
+}
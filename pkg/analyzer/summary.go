@@ -0,0 +1,193 @@
+// Package analyzer provides risk analysis logic for upgrade precheck
+package analyzer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// summarySeverities are the severity columns of the summary table, from most to least severe
+var summarySeverities = []string{"critical", "error", "warning", "info"}
+
+// summaryComponentOrder is the row order of the summary table; other components follow in sorted order
+var summaryComponentOrder = []string{"tidb", "pd", "tikv", "tiflash"}
+
+// summaryClusterComponent is the row of findings that do not belong to a component (e.g., mixed versions)
+const summaryClusterComponent = "cluster"
+
+// DefaultSummaryCriticalLimit is the number of critical findings listed in the summary
+const DefaultSummaryCriticalLimit = 10
+
+// summaryWidth is the maximum line width of the critical findings of the summary
+const summaryWidth = 80
+
+// SeverityBreakdown counts check results per component and severity
+// Structure: map[component]map[severity]count
+type SeverityBreakdown map[string]map[string]int
+
+// newSeverityBreakdown counts the (deduplicated) check results per component and severity
+func newSeverityBreakdown(checkResults []rules.CheckResult) SeverityBreakdown {
+	breakdown := make(SeverityBreakdown)
+	for _, check := range checkResults {
+		component := check.Component
+		if component == "" {
+			component = summaryClusterComponent
+		}
+		if breakdown[component] == nil {
+			breakdown[component] = make(map[string]int)
+		}
+		breakdown[component][check.Severity]++
+	}
+	return breakdown
+}
+
+// SortedComponents returns the components in summary order: tidb, pd, tikv, tiflash, then others sorted
+func (b SeverityBreakdown) SortedComponents() []string {
+	components := make([]string, 0, len(b))
+	for _, component := range summaryComponentOrder {
+		if _, ok := b[component]; ok {
+			components = append(components, component)
+		}
+	}
+	var others []string
+	for component := range b {
+		if !containsString(summaryComponentOrder, component) {
+			others = append(others, component)
+		}
+	}
+	sort.Strings(others)
+	return append(components, others...)
+}
+
+// Total returns the number of results of a component, all severities included
+func (b SeverityBreakdown) Total(component string) int {
+	total := 0
+	for _, count := range b[component] {
+		total += count
+	}
+	return total
+}
+
+// isCriticalSeverity checks if a severity counts as a critical issue in the summary
+func isCriticalSeverity(severity string) bool {
+	return severity == "critical" || severity == "error"
+}
+
+// CriticalFindings returns the critical and error check results,
+// sorted by severity (critical first), component (summary order) and parameter name
+func (r *AnalysisResult) CriticalFindings() []rules.CheckResult {
+	var findings []rules.CheckResult
+	for _, check := range r.CheckResults {
+		if isCriticalSeverity(check.Severity) {
+			findings = append(findings, check)
+		}
+	}
+
+	componentRank := func(component string) int {
+		for i, c := range summaryComponentOrder {
+			if c == component {
+				return i
+			}
+		}
+		return len(summaryComponentOrder)
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		if findings[i].Severity != findings[j].Severity {
+			return findings[i].Severity == "critical"
+		}
+		if ri, rj := componentRank(findings[i].Component), componentRank(findings[j].Component); ri != rj {
+			return ri < rj
+		}
+		if findings[i].Component != findings[j].Component {
+			return findings[i].Component < findings[j].Component
+		}
+		return findings[i].ParameterName < findings[j].ParameterName
+	})
+	return findings
+}
+
+// WriteSeveritySummary writes the per-component severity table followed by one line per critical finding
+// At most limit critical findings are listed, the rest are counted ("and N more, see report")
+// Lines fit in 80 columns
+func (r *AnalysisResult) WriteSeveritySummary(w io.Writer, limit int) error {
+	breakdown := r.Statistics.SeverityByComponent
+	if breakdown == nil {
+		breakdown = newSeverityBreakdown(r.CheckResults)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	header := []string{"COMPONENT"}
+	for _, severity := range summarySeverities {
+		header = append(header, strings.ToUpper(severity))
+	}
+	fmt.Fprintln(tw, strings.Join(append(header, "TOTAL"), "\t"))
+	for _, component := range breakdown.SortedComponents() {
+		row := []string{component}
+		for _, severity := range summarySeverities {
+			row = append(row, fmt.Sprintf("%d", breakdown[component][severity]))
+		}
+		row = append(row, fmt.Sprintf("%d", breakdown.Total(component)))
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	findings := r.CriticalFindings()
+	if len(findings) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintf(w, "\nCritical findings:\n"); err != nil {
+		return err
+	}
+	for i, check := range findings {
+		if i == limit {
+			_, err := fmt.Fprintf(w, "  ... and %d more, see report\n", len(findings)-limit)
+			return err
+		}
+		component := check.Component
+		if component == "" {
+			component = summaryClusterComponent
+		}
+		line := fmt.Sprintf("  [%s] %s", component, firstLine(check.Message))
+		if check.ParameterName != "" {
+			line = fmt.Sprintf("  [%s] %s: %s", component, check.ParameterName, firstLine(check.Message))
+		}
+		if _, err := fmt.Fprintln(w, truncateRunes(line, summaryWidth)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstLine returns the first line of a message
+func firstLine(message string) string {
+	if i := strings.IndexByte(message, '\n'); i >= 0 {
+		return message[:i]
+	}
+	return message
+}
+
+// truncateRunes truncates s to width runes, ending with "..." if it was truncated
+func truncateRunes(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-3]) + "..."
+}
+
+// containsString checks if a slice contains a string
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package analyzer
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyzer_organizeResults_SeverityByComponent(t *testing.T) {
+	checkResults := []rules.CheckResult{
+		{Component: "tidb", ParameterName: "sysvar_a", ParamType: "system_variable", Category: "upgrade_difference", Severity: "error", Message: "forced"},
+		{Component: "tidb", ParameterName: "config_b", ParamType: "config", Category: "user_modified", Severity: "info"},
+		{Component: "tikv", ParameterName: "storage.engine", ParamType: "config", Category: "upgrade_difference", Severity: "critical", Message: "storage format"},
+		{Component: "tikv", ParameterName: "raftstore.x", ParamType: "config", Category: "consistency", Severity: "warning"},
+		{Component: "pd", ParameterName: "schedule.y", ParamType: "config", Category: "upgrade_difference", Severity: "info"},
+	}
+
+	result := NewAnalyzer(nil).organizeResults(checkResults, nil, "v7.5.0", "v8.5.0")
+	assert.Equal(t, SeverityBreakdown{
+		"tidb": {"error": 1, "info": 1},
+		"tikv": {"critical": 1, "warning": 1},
+		"pd":   {"info": 1},
+	}, result.Statistics.SeverityByComponent)
+}
+
+func TestAnalysisResult_WriteSeveritySummary(t *testing.T) {
+	result := &AnalysisResult{
+		CheckResults: []rules.CheckResult{
+			{Component: "tiflash", ParameterName: "profiles.default.max_memory_usage", Severity: "warning", Message: "default changed"},
+			{Component: "tidb", ParameterName: "tidb_enable_async_commit", Severity: "error", Message: "Parameter tidb_enable_async_commit in tidb will be forcibly changed during upgrade (forced value differs from current)\nmore details"},
+			{Component: "tidb", ParameterName: "max-connections", Severity: "info", Message: "default changed"},
+			{Component: "tikv", ParameterName: "storage.engine", Severity: "critical", Message: "storage format change"},
+			{Component: "tikv", ParameterName: "raftstore.store-pool-size", Severity: "warning", Message: "inconsistent"},
+		},
+	}
+	result.Statistics.SeverityByComponent = newSeverityBreakdown(result.CheckResults)
+
+	var buf bytes.Buffer
+	require.NoError(t, result.WriteSeveritySummary(&buf, DefaultSummaryCriticalLimit))
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+
+	assert.Equal(t, []string{"COMPONENT", "CRITICAL", "ERROR", "WARNING", "INFO", "TOTAL"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"tidb", "0", "1", "0", "1", "2"}, strings.Fields(lines[1]))
+	assert.Equal(t, []string{"tikv", "1", "0", "1", "0", "2"}, strings.Fields(lines[2]))
+	assert.Equal(t, []string{"tiflash", "0", "0", "1", "0", "1"}, strings.Fields(lines[3]))
+	assert.Equal(t, "", lines[4])
+	assert.Equal(t, "Critical findings:", lines[5])
+	// Critical findings come before errors; messages are cut at the first line and at 80 columns
+	assert.Equal(t, "  [tikv] storage.engine: storage format change", lines[6])
+	assert.True(t, strings.HasPrefix(lines[7], "  [tidb] tidb_enable_async_commit: Parameter"))
+	assert.True(t, strings.HasSuffix(lines[7], "..."))
+	assert.Len(t, lines, 8)
+
+	for _, line := range lines {
+		assert.LessOrEqual(t, len([]rune(line)), 80, line)
+	}
+}
+
+func TestAnalysisResult_WriteSeveritySummary_CriticalLimit(t *testing.T) {
+	result := &AnalysisResult{}
+	for i := 0; i < 13; i++ {
+		result.CheckResults = append(result.CheckResults, rules.CheckResult{
+			Component:     "tikv",
+			ParameterName: fmt.Sprintf("param-%02d", i),
+			Severity:      "error",
+			Message:       "forced",
+		})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, result.WriteSeveritySummary(&buf, DefaultSummaryCriticalLimit))
+	output := buf.String()
+	assert.Contains(t, output, "  [tikv] param-09: forced\n")
+	assert.NotContains(t, output, "param-10")
+	assert.True(t, strings.HasSuffix(output, "  ... and 3 more, see report\n"))
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OpenAPIVersion is the version of the API described by openapi.json
+// Bump it when a request or response struct changes incompatibly
+const OpenAPIVersion = "1.0.0"
+
+// schemaTypes are the structs documented under components/schemas
+var schemaTypes = []interface{}{
+	CheckRequest{},
+	CheckResponse{},
+	FindingsSummary{},
+	Finding{},
+	ErrorResponse{},
+}
+
+// analysisResultSchema documents the full AnalysisResult
+// It is not generated from the struct: it is the detail view and follows the JSON report format
+var analysisResultSchema = map[string]interface{}{
+	"type":                 "object",
+	"description":          "Full analysis result, same structure as the JSON report (see pkg/analyzer.AnalysisResult)",
+	"additionalProperties": true,
+}
+
+var pathParamRe = regexp.MustCompile(`\{([^}]+)\}`)
+
+// GenerateOpenAPI generates the OpenAPI 3 document of the serve mode routes from the request/response structs
+// The output is deterministic, so it can be compared with the checked-in openapi.json
+func GenerateOpenAPI() ([]byte, error) {
+	schemas := map[string]interface{}{
+		"AnalysisResult": analysisResultSchema,
+	}
+	for _, v := range schemaTypes {
+		t := reflect.TypeOf(v)
+		schemas[t.Name()] = structSchema(t)
+	}
+
+	paths := make(map[string]interface{})
+	for _, r := range routes {
+		operation := map[string]interface{}{
+			"operationId": r.OperationID,
+			"summary":     r.Summary,
+			"responses":   routeResponses(r),
+		}
+		if r.Request != "" {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content": map[string]interface{}{
+					"application/json": map[string]interface{}{"schema": schemaRef(r.Request)},
+				},
+			}
+		}
+		var params []interface{}
+		for _, m := range pathParamRe.FindAllStringSubmatch(r.Path, -1) {
+			params = append(params, map[string]interface{}{
+				"name":     m[1],
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]interface{}{"type": "string"},
+			})
+		}
+		if len(params) > 0 {
+			operation["parameters"] = params
+		}
+
+		item, ok := paths[r.Path].(map[string]interface{})
+		if !ok {
+			item = make(map[string]interface{})
+			paths[r.Path] = item
+		}
+		item[strings.ToLower(r.Method)] = operation
+	}
+
+	doc := map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "TiDB Upgrade Precheck API",
+			"description": "Served by 'precheck serve'. Generated from pkg/api, do not edit.",
+			"version":     OpenAPIVersion,
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OpenAPI document: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+func routeResponses(r route) map[string]interface{} {
+	responses := make(map[string]interface{})
+	for status, schema := range r.Responses {
+		response := map[string]interface{}{
+			"description": http.StatusText(status),
+		}
+		if schema != "" {
+			response["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": schemaRef(schema)},
+			}
+		} else {
+			response["content"] = map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": map[string]interface{}{"type": "object"}},
+			}
+		}
+		responses[strconv.Itoa(status)] = response
+	}
+	return responses
+}
+
+func schemaRef(name string) map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+// structSchema builds the object schema of a struct from its json and description tags
+// Fields without omitempty are required
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := typeSchema(field.Type)
+		if description := field.Tag.Get("description"); description != "" {
+			if _, isRef := schema["$ref"]; isRef {
+				// Siblings of $ref are ignored in OpenAPI 3.0, wrap the reference instead
+				schema = map[string]interface{}{"allOf": []interface{}{schema}, "description": description}
+			} else {
+				schema["description"] = description
+			}
+		}
+		properties[name] = schema
+		if !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func typeSchema(t reflect.Type) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		for _, v := range schemaTypes {
+			if reflect.TypeOf(v) == t {
+				return schemaRef(t.Name())
+			}
+		}
+		return structSchema(t)
+	default:
+		// interface{} and other types accept any value
+		return map[string]interface{}{}
+	}
+}
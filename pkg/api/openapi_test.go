@@ -0,0 +1,53 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/internal/golden"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// openAPIPath is the checked-in OpenAPI document
+// To regenerate it after changing the request/response structs or routes, run:
+//
+//	go test -tags update_golden ./pkg/api/
+const openAPIPath = "openapi.json"
+
+func TestOpenAPIDocumentInSync(t *testing.T) {
+	generated, err := GenerateOpenAPI()
+	require.NoError(t, err)
+
+	golden.Check(t, openAPIPath, generated, "go test -tags update_golden ./pkg/api/")
+}
+
+func TestGenerateOpenAPI_DocumentsRoutesAndSchemas(t *testing.T) {
+	data, err := GenerateOpenAPI()
+	require.NoError(t, err)
+
+	var doc struct {
+		OpenAPI    string                                       `json:"openapi"`
+		Paths      map[string]map[string]map[string]interface{} `json:"paths"`
+		Components struct {
+			Schemas map[string]map[string]interface{} `json:"schemas"`
+		} `json:"components"`
+	}
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+
+	for _, r := range routes {
+		item, ok := doc.Paths[r.Path]
+		require.True(t, ok, "route %s is not documented", r.Path)
+		assert.Contains(t, item, strings.ToLower(r.Method), "route %s %s is not documented", r.Method, r.Path)
+	}
+
+	request := doc.Components.Schemas["CheckRequest"]
+	assert.Equal(t, []interface{}{"target_version"}, request["required"])
+	summary := doc.Components.Schemas["FindingsSummary"]["properties"].(map[string]interface{})
+	assert.Contains(t, summary, "by_severity")
+	assert.Contains(t, summary, "by_component")
+	assert.Contains(t, summary, "top_critical")
+	assert.Contains(t, doc.Components.Schemas, "AnalysisResult")
+}
@@ -0,0 +1,202 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+)
+
+// defaultMaxResults is the number of finished runs kept in memory for drill-down
+const defaultMaxResults = 100
+
+// Runner runs a precheck (collection and analysis) for a request
+type Runner func(ctx context.Context, req CheckRequest) (*analyzer.AnalysisResult, error)
+
+// Server serves the routes documented in openapi.json
+// Results of finished runs are kept in memory (the oldest are dropped after defaultMaxResults runs)
+type Server struct {
+	runner Runner
+
+	mu      sync.Mutex
+	results map[string]*analyzer.AnalysisResult
+	order   []string
+}
+
+// NewServer creates a server that runs prechecks with runner
+func NewServer(runner Runner) *Server {
+	return &Server{
+		runner:  runner,
+		results: make(map[string]*analyzer.AnalysisResult),
+	}
+}
+
+// route describes an HTTP route and its OpenAPI documentation
+type route struct {
+	Method      string
+	Path        string
+	OperationID string
+	Summary     string
+	// Request is the request body schema name, empty if the route has no body
+	Request string
+	// Responses maps status code to response schema name, empty for a free-form JSON object
+	Responses map[int]string
+}
+
+// routes are registered by RegisterRoutes and documented by GenerateOpenAPI
+var routes = []route{
+	{
+		Method:      http.MethodPost,
+		Path:        "/api/v1/checks",
+		OperationID: "runCheck",
+		Summary:     "Run a precheck and return the findings summary",
+		Request:     "CheckRequest",
+		Responses:   map[int]string{http.StatusOK: "CheckResponse", http.StatusBadRequest: "ErrorResponse", http.StatusInternalServerError: "ErrorResponse"},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/checks/{id}/summary",
+		OperationID: "getCheckSummary",
+		Summary:     "Get the findings summary of a finished precheck",
+		Responses:   map[int]string{http.StatusOK: "CheckResponse", http.StatusNotFound: "ErrorResponse"},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/checks/{id}/result",
+		OperationID: "getCheckResult",
+		Summary:     "Get the full analysis result of a finished precheck",
+		Responses:   map[int]string{http.StatusOK: "AnalysisResult", http.StatusNotFound: "ErrorResponse"},
+	},
+	{
+		Method:      http.MethodGet,
+		Path:        "/api/v1/openapi.json",
+		OperationID: "getOpenAPI",
+		Summary:     "Get this OpenAPI document",
+		Responses:   map[int]string{http.StatusOK: ""},
+	},
+}
+
+// RegisterRoutes registers the documented routes on mux
+func (s *Server) RegisterRoutes(mux *http.ServeMux) {
+	handlers := map[string]http.HandlerFunc{
+		"runCheck":        s.handleRunCheck,
+		"getCheckSummary": s.handleGetSummary,
+		"getCheckResult":  s.handleGetResult,
+		"getOpenAPI":      s.handleOpenAPI,
+	}
+	for _, r := range routes {
+		mux.HandleFunc(r.Method+" "+r.Path, handlers[r.OperationID])
+	}
+}
+
+func (s *Server) handleRunCheck(w http.ResponseWriter, r *http.Request) {
+	var req CheckRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+	if strings.TrimSpace(req.TargetVersion) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("target_version is required"))
+		return
+	}
+
+	result, err := s.runner(r.Context(), req)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if result == nil {
+		writeError(w, http.StatusInternalServerError, errors.New("precheck returned no result"))
+		return
+	}
+
+	id, err := s.store(result)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, newCheckResponse(id, result, req.TopN))
+}
+
+func (s *Server) handleGetSummary(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	result, ok := s.lookup(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("check %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, newCheckResponse(id, result, 0))
+}
+
+func (s *Server) handleGetResult(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	result, ok := s.lookup(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("check %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	doc, err := GenerateOpenAPI()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(doc)
+}
+
+// store keeps a finished result and returns its ID
+func (s *Server) store(result *analyzer.AnalysisResult) (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate check ID: %w", err)
+	}
+	id := hex.EncodeToString(buf)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[id] = result
+	s.order = append(s.order, id)
+	for len(s.order) > defaultMaxResults {
+		delete(s.results, s.order[0])
+		s.order = s.order[1:]
+	}
+	return id, nil
+}
+
+func (s *Server) lookup(id string) (*analyzer.AnalysisResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[id]
+	return result, ok
+}
+
+func newCheckResponse(id string, result *analyzer.AnalysisResult, topN int) CheckResponse {
+	return CheckResponse{
+		ID:            id,
+		SourceVersion: result.SourceVersion,
+		TargetVersion: result.TargetVersion,
+		Summary:       Summarize(result, topN),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ErrorResponse{Error: err.Error()})
+}
@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestAnalysisResult() *analyzer.AnalysisResult {
+	return &analyzer.AnalysisResult{
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.5.0",
+		CheckResults: []rules.CheckResult{
+			{RuleID: "UPGRADE_DIFFERENCES", Component: "tidb", ParameterName: "tidb_mem_quota_query", Severity: "error",
+				Message: "forced change", CurrentValue: 1073741824, ForcedValue: 4294967296, TargetDefault: 1073741824,
+				Suggestions: []string{"SET GLOBAL tidb_mem_quota_query = 4294967296"}},
+			{RuleID: "HIGH_RISK_PARAMS", Component: "tikv", ParameterName: "raftstore.sync-log", Severity: "critical",
+				Message: "sync-log disabled", CurrentValue: false, TargetDefault: true},
+			{RuleID: "UPGRADE_DIFFERENCES", Component: "pd", ParameterName: "schedule.max-merge-region-keys", Severity: "warning", Message: "default changed"},
+			{RuleID: "USER_MODIFIED_PARAMS", Component: "tikv", ParameterName: "storage.reserve-space", Severity: "info", Message: "modified"},
+			{RuleID: "UPGRADE_DIFFERENCES", Component: "tidb", ParameterName: "tidb_enable_paging", Severity: "error", Message: "forced change"},
+		},
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	summary := Summarize(newTestAnalysisResult(), 2)
+
+	assert.Equal(t, 5, summary.Total)
+	assert.Equal(t, map[string]int{"critical": 1, "error": 2, "warning": 1, "info": 1}, summary.BySeverity)
+	assert.Equal(t, map[string]int{"tidb": 2, "tikv": 2, "pd": 1}, summary.ByComponent)
+
+	require.Len(t, summary.TopCritical, 2)
+	assert.Equal(t, "raftstore.sync-log", summary.TopCritical[0].ParameterName)
+	assert.Equal(t, "false", summary.TopCritical[0].CurrentValue)
+	assert.Equal(t, "true", summary.TopCritical[0].TargetValue)
+
+	// Errors are ordered by component and parameter name, the forced value is the value after upgrade
+	assert.Equal(t, "tidb_enable_paging", summary.TopCritical[1].ParameterName)
+	all := Summarize(newTestAnalysisResult(), 0)
+	require.Len(t, all.TopCritical, 3)
+	assert.Equal(t, "4294967296", all.TopCritical[2].TargetValue)
+	assert.Equal(t, []string{"SET GLOBAL tidb_mem_quota_query = 4294967296"}, all.TopCritical[2].Remediation)
+}
+
+func TestSummarize_NilResult(t *testing.T) {
+	summary := Summarize(nil, 0)
+	assert.Equal(t, 0, summary.Total)
+	assert.NotNil(t, summary.TopCritical)
+}
+
+func TestServer_RunCheckAndDrillDown(t *testing.T) {
+	var received CheckRequest
+	server := NewServer(func(ctx context.Context, req CheckRequest) (*analyzer.AnalysisResult, error) {
+		received = req
+		return newTestAnalysisResult(), nil
+	})
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+
+	body, _ := json.Marshal(CheckRequest{TargetVersion: "v8.5.0", PDAddrs: []string{"127.0.0.1:2379"}, TopN: 1})
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/checks", bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, []string{"127.0.0.1:2379"}, received.PDAddrs)
+
+	var resp CheckResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.NotEmpty(t, resp.ID)
+	assert.Equal(t, "v7.5.0", resp.SourceVersion)
+	assert.Len(t, resp.Summary.TopCritical, 1)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/checks/"+resp.ID+"/summary", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/checks/"+resp.ID+"/result", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var full analyzer.AnalysisResult
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &full))
+	assert.Len(t, full.CheckResults, 5)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/checks/unknown/result", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestServer_RunCheckErrors(t *testing.T) {
+	server := NewServer(func(ctx context.Context, req CheckRequest) (*analyzer.AnalysisResult, error) {
+		return nil, errors.New("failed to collect cluster configuration")
+	})
+	mux := http.NewServeMux()
+	server.RegisterRoutes(mux)
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantError  string
+	}{
+		{"invalid body", "{", http.StatusBadRequest, "invalid request body"},
+		{"missing target version", `{"tidb_addr":"127.0.0.1:4000"}`, http.StatusBadRequest, "target_version is required"},
+		{"runner error", `{"target_version":"v8.5.0"}`, http.StatusInternalServerError, "failed to collect cluster configuration"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/checks", bytes.NewBufferString(tt.body)))
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			var resp ErrorResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Contains(t, resp.Error, tt.wantError)
+		})
+	}
+}
+
+func TestServer_ServesOpenAPI(t *testing.T) {
+	mux := http.NewServeMux()
+	NewServer(nil).RegisterRoutes(mux)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	expected, err := GenerateOpenAPI()
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), rec.Body.String())
+}
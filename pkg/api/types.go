@@ -0,0 +1,141 @@
+// Package api defines the programmatic contract of the precheck serve mode
+// The request/response structs are the stable interface consumed by TiUP (cluster check),
+// openapi.json is generated from them and checked in next to this file
+package api
+
+import (
+	"sort"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+)
+
+// DefaultTopN is the number of critical findings returned in a summary if CheckRequest.TopN is not set
+const DefaultTopN = 10
+
+// CheckRequest starts a precheck run
+// It mirrors the options of the precheck command (report output options are not applicable)
+type CheckRequest struct {
+	SourceVersion        string   `json:"source_version,omitempty" description:"Source TiDB version. If empty or 'auto', it is taken from the topology file or detected from the cluster"`
+	TargetVersion        string   `json:"target_version" description:"Target TiDB version for upgrade"`
+	TopologyFile         string   `json:"topology_file,omitempty" description:"Path to a TiUP/TiDB Operator topology YAML file on the server. Takes precedence over the individual addresses"`
+	TiDBAddr             string   `json:"tidb_addr,omitempty" description:"TiDB MySQL protocol endpoint (host:port)"`
+	TiDBUser             string   `json:"tidb_user,omitempty" description:"TiDB MySQL username"`
+	TiDBPassword         string   `json:"tidb_password,omitempty" description:"TiDB MySQL password"`
+	TiKVAddrs            []string `json:"tikv_addrs,omitempty" description:"TiKV HTTP API endpoints"`
+	PDAddrs              []string `json:"pd_addrs,omitempty" description:"PD HTTP API endpoints"`
+	HighRiskParamsConfig string   `json:"high_risk_params_config,omitempty" description:"Paths to high-risk parameters configuration files on the server, comma-separated, later files overriding earlier ones"`
+	GoldenConfig         string   `json:"golden_config,omitempty" description:"Path to a golden configuration profile on the server. Drift from it is reported with the golden_drift category"`
+	RulesConfig          string   `json:"rules_config,omitempty" description:"Path to a rules configuration file on the server, setting the thresholds of the rules"`
+	TopN                 int      `json:"top_n,omitempty" description:"Number of critical findings to include in the summary (default 10)"`
+}
+
+// CheckResponse is returned for a finished precheck run
+// The full AnalysisResult is available under /api/v1/checks/{id}/result
+type CheckResponse struct {
+	ID            string          `json:"id" description:"Identifier of the run, used to fetch the full result"`
+	SourceVersion string          `json:"source_version" description:"Source version the cluster was checked against"`
+	TargetVersion string          `json:"target_version" description:"Target version of the upgrade"`
+	Summary       FindingsSummary `json:"summary"`
+}
+
+// FindingsSummary is a trimmed view of an AnalysisResult, suitable for rendering a compact table
+type FindingsSummary struct {
+	Total       int            `json:"total" description:"Total number of findings"`
+	BySeverity  map[string]int `json:"by_severity" description:"Number of findings per severity (critical, error, warning, info)"`
+	ByComponent map[string]int `json:"by_component" description:"Number of findings per component (tidb, pd, tikv, tiflash)"`
+	TopCritical []Finding      `json:"top_critical" description:"The most severe findings (critical and error), most severe first"`
+}
+
+// Finding is a single check result in a FindingsSummary
+type Finding struct {
+	RuleID        string   `json:"rule_id" description:"Rule that produced the finding"`
+	Component     string   `json:"component,omitempty" description:"Component the finding relates to"`
+	ParameterName string   `json:"parameter_name,omitempty" description:"Parameter or system variable name"`
+	Severity      string   `json:"severity" description:"critical, error, warning or info"`
+	Message       string   `json:"message" description:"One-line description of the finding"`
+	CurrentValue  string   `json:"current_value,omitempty" description:"Current cluster value, formatted for display"`
+	TargetValue   string   `json:"target_value,omitempty" description:"Value after upgrade (forced value or target default), formatted for display"`
+	Remediation   []string `json:"remediation,omitempty" description:"Suggested remediation steps"`
+}
+
+// ErrorResponse is returned with a non-2xx status code
+type ErrorResponse struct {
+	Error string `json:"error" description:"Error message"`
+}
+
+// severityRank orders severities from most to least severe
+var severityRank = map[string]int{
+	"critical": 0,
+	"error":    1,
+	"warning":  2,
+	"info":     3,
+}
+
+// Summarize builds the FindingsSummary of an analysis result
+// TopCritical holds at most topN critical/error findings (DefaultTopN if topN <= 0),
+// ordered by severity, component and parameter name
+func Summarize(result *analyzer.AnalysisResult, topN int) FindingsSummary {
+	if topN <= 0 {
+		topN = DefaultTopN
+	}
+	summary := FindingsSummary{
+		BySeverity:  make(map[string]int),
+		ByComponent: make(map[string]int),
+		TopCritical: []Finding{},
+	}
+	if result == nil {
+		return summary
+	}
+
+	var critical []rules.CheckResult
+	for _, check := range result.CheckResults {
+		summary.Total++
+		summary.BySeverity[check.Severity]++
+		if check.Component != "" {
+			summary.ByComponent[check.Component]++
+		}
+		if check.Severity == "critical" || check.Severity == "error" {
+			critical = append(critical, check)
+		}
+	}
+
+	sort.SliceStable(critical, func(i, j int) bool {
+		a, b := critical[i], critical[j]
+		if severityRank[a.Severity] != severityRank[b.Severity] {
+			return severityRank[a.Severity] < severityRank[b.Severity]
+		}
+		if a.Component != b.Component {
+			return a.Component < b.Component
+		}
+		return a.ParameterName < b.ParameterName
+	})
+	if len(critical) > topN {
+		critical = critical[:topN]
+	}
+	for _, check := range critical {
+		summary.TopCritical = append(summary.TopCritical, newFinding(check))
+	}
+	return summary
+}
+
+// newFinding converts a check result into a Finding
+func newFinding(check rules.CheckResult) Finding {
+	finding := Finding{
+		RuleID:        check.RuleID,
+		Component:     check.Component,
+		ParameterName: check.ParameterName,
+		Severity:      check.Severity,
+		Message:       check.Message,
+		Remediation:   check.Suggestions,
+	}
+	if check.CurrentValue != nil {
+		finding.CurrentValue = rules.FormatValue(check.CurrentValue)
+	}
+	if check.ForcedValue != nil {
+		finding.TargetValue = rules.FormatValue(check.ForcedValue)
+	} else if check.TargetDefault != nil {
+		finding.TargetValue = rules.FormatValue(check.TargetDefault)
+	}
+	return finding
+}
@@ -0,0 +1,48 @@
+// Package buildinfo provides build metadata of the upgrade precheck binaries
+// The variables are populated at link time, e.g.:
+//
+//	go build -ldflags "-X github.com/pingcap/tidb-upgrade-precheck/pkg/buildinfo.Version=v1.0.0"
+//
+// See the Makefile for the flags used by release builds.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Build metadata populated at link time via -ldflags "-X ..."
+var (
+	// Version is the release version of the binary
+	Version = "dev"
+	// Commit is the git commit hash the binary was built from
+	Commit = "unknown"
+	// BuildTime is the UTC timestamp of the build
+	BuildTime = "unknown"
+)
+
+// Info contains the full build metadata of the binary
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build metadata of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		BuildTime: BuildTime,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+}
+
+// String formats the build metadata for display, one field per line
+func (i Info) String() string {
+	return fmt.Sprintf("Version:    %s\nGit Commit: %s\nBuild Time: %s\nGo Version: %s\nPlatform:   %s",
+		i.Version, i.Commit, i.BuildTime, i.GoVersion, i.Platform)
+}
@@ -0,0 +1,27 @@
+package buildinfo
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	oldVersion, oldCommit, oldBuildTime := Version, Commit, BuildTime
+	defer func() { Version, Commit, BuildTime = oldVersion, oldCommit, oldBuildTime }()
+
+	Version, Commit, BuildTime = "v1.2.3", "abc123", "2024-01-02T03:04:05Z"
+	info := Get()
+
+	assert.Equal(t, "v1.2.3", info.Version)
+	assert.Equal(t, "abc123", info.Commit)
+	assert.Equal(t, "2024-01-02T03:04:05Z", info.BuildTime)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+	assert.Equal(t, runtime.GOOS+"/"+runtime.GOARCH, info.Platform)
+
+	str := info.String()
+	assert.Contains(t, str, "Version:    v1.2.3")
+	assert.Contains(t, str, "Git Commit: abc123")
+	assert.Contains(t, str, "Go Version: "+runtime.Version())
+}
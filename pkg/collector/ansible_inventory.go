@@ -0,0 +1,289 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Default ports of TiDB Ansible, used when the inventory doesn't set the port variables
+const (
+	ansibleDefaultTiDBPort       = 4000
+	ansibleDefaultTiDBStatusPort = 10080
+	ansibleDefaultPDClientPort   = 2379
+	ansibleDefaultTiKVStatusPort = 20180
+)
+
+// ansibleInventoryMarker is the group header identifying a TiDB Ansible inventory (INI format)
+const ansibleInventoryMarker = "[tidb_servers]"
+
+// ansibleHost is a host line of an Ansible inventory group: an alias or address, followed by its variables
+type ansibleHost struct {
+	name string
+	vars map[string]string
+	line int
+}
+
+// ansibleInventory is a parsed Ansible inventory (INI format)
+type ansibleInventory struct {
+	// hosts are the host lines of each group, in file order
+	hosts map[string][]ansibleHost
+	// vars are the variables of each group ([group:vars]), "all" included
+	vars map[string]map[string]string
+	// children are the child groups of each group ([group:children])
+	children map[string][]string
+}
+
+// isAnsibleInventory checks if a topology file is a TiDB Ansible inventory rather than a TiUP topology
+func isAnsibleInventory(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if strings.TrimSpace(scanner.Text()) == ansibleInventoryMarker {
+			return true
+		}
+	}
+	return false
+}
+
+// LoadTopologyFromAnsibleInventory loads a TiDB Ansible inventory file (INI format, e.g., inventory.ini)
+// and converts it to ClusterEndpoints
+// The hosts of the [tidb_servers], [pd_servers] and [tikv_servers] groups are read, with their ports from the
+// host, group or [all:vars] variables of TiDB Ansible (tidb_port, pd_client_port, tikv_status_port, ...) or their defaults
+func LoadTopologyFromAnsibleInventory(inventoryPath string) (*ClusterEndpoints, error) {
+	data, err := os.ReadFile(inventoryPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Ansible inventory: %w", err)
+	}
+	return loadAnsibleInventory(data)
+}
+
+// loadAnsibleInventory converts the content of a TiDB Ansible inventory to ClusterEndpoints
+func loadAnsibleInventory(data []byte) (*ClusterEndpoints, error) {
+	inventory, err := parseAnsibleInventory(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Ansible inventory: %w", err)
+	}
+
+	endpoints := &ClusterEndpoints{
+		TiKVAddrs:     []string{},
+		PDAddrs:       []string{},
+		TiFlashAddrs:  []string{},
+		TiKVDataDirs:  make(map[string]string),
+		SourceVersion: inventory.vars["all"]["tidb_version"],
+	}
+
+	// Extract TiDB connection info from the first TiDB instance
+	if tidbHosts := inventory.groupHosts("tidb_servers"); len(tidbHosts) > 0 {
+		tidb := tidbHosts[0]
+		host := inventory.address(tidb)
+		port, err := inventory.port(tidb, "tidb_servers", "tidb_port", ansibleDefaultTiDBPort)
+		if err != nil {
+			return nil, err
+		}
+		statusPort, err := inventory.port(tidb, "tidb_servers", "tidb_status_port", ansibleDefaultTiDBStatusPort)
+		if err != nil {
+			return nil, err
+		}
+		endpoints.TiDBAddr = fmt.Sprintf("%s:%d", host, port)
+		endpoints.TiDBStatusAddr = fmt.Sprintf("%s:%d", host, statusPort)
+		// ansible_user is the SSH user of the deployment, not a database user
+		endpoints.TiDBUser = "root"
+	}
+
+	// Extract PD addresses
+	for _, pd := range inventory.groupHosts("pd_servers") {
+		port, err := inventory.port(pd, "pd_servers", "pd_client_port", ansibleDefaultPDClientPort)
+		if err != nil {
+			return nil, err
+		}
+		endpoints.PDAddrs = append(endpoints.PDAddrs, fmt.Sprintf("%s:%d", inventory.address(pd), port))
+	}
+
+	// Extract TiKV addresses (status API, as for TiUP topologies) and data directories
+	for _, tikv := range inventory.groupHosts("tikv_servers") {
+		port, err := inventory.port(tikv, "tikv_servers", "tikv_status_port", ansibleDefaultTiKVStatusPort)
+		if err != nil {
+			return nil, err
+		}
+		addr := fmt.Sprintf("%s:%d", inventory.address(tikv), port)
+		endpoints.TiKVAddrs = append(endpoints.TiKVAddrs, addr)
+
+		// TiDB Ansible places the data of TiKV under deploy_dir/data unless tikv_data_dir is set
+		dataDir := inventory.variable(tikv, "tikv_servers", "tikv_data_dir")
+		if deployDir := inventory.variable(tikv, "tikv_servers", "deploy_dir"); dataDir == "" && deployDir != "" {
+			dataDir = path.Join(deployDir, "data")
+		}
+		if dataDir != "" {
+			endpoints.TiKVDataDirs[addr] = dataDir
+		}
+	}
+
+	if endpoints.TiDBAddr == "" && len(endpoints.PDAddrs) == 0 && len(endpoints.TiKVAddrs) == 0 {
+		return nil, fmt.Errorf("no host in the [tidb_servers], [pd_servers] or [tikv_servers] groups of the Ansible inventory")
+	}
+	return endpoints, nil
+}
+
+// parseAnsibleInventory parses an Ansible inventory in INI format
+func parseAnsibleInventory(data []byte) (*ansibleInventory, error) {
+	inventory := &ansibleInventory{
+		hosts:    make(map[string][]ansibleHost),
+		vars:     make(map[string]map[string]string),
+		children: make(map[string][]string),
+	}
+
+	// Hosts before any group header belong to the "ungrouped" group
+	group, section := "ungrouped", ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return nil, fmt.Errorf("line %d: invalid group header %q", line, text)
+			}
+			group, section, _ = strings.Cut(strings.TrimSpace(text[1:len(text)-1]), ":")
+			switch section {
+			case "", "vars", "children":
+			default:
+				return nil, fmt.Errorf("line %d: invalid group section %q", line, text)
+			}
+			continue
+		}
+
+		switch section {
+		case "vars":
+			name, value, ok := strings.Cut(text, "=")
+			if !ok {
+				return nil, fmt.Errorf("line %d: invalid variable %q in [%s:vars]", line, text, group)
+			}
+			if inventory.vars[group] == nil {
+				inventory.vars[group] = make(map[string]string)
+			}
+			inventory.vars[group][strings.TrimSpace(name)] = unquoteAnsibleValue(strings.TrimSpace(value))
+		case "children":
+			inventory.children[group] = append(inventory.children[group], text)
+		default:
+			fields, err := splitAnsibleFields(text)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			host := ansibleHost{name: fields[0], vars: make(map[string]string), line: line}
+			for _, field := range fields[1:] {
+				name, value, ok := strings.Cut(field, "=")
+				if !ok {
+					return nil, fmt.Errorf("line %d: invalid host variable %q of %s", line, field, host.name)
+				}
+				host.vars[name] = value
+			}
+			inventory.hosts[group] = append(inventory.hosts[group], host)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// splitAnsibleFields splits a host line into its fields, on spaces outside quotes
+// Quotes are removed (e.g., labels="zone=z1,host=h1" gives labels=zone=z1,host=h1)
+func splitAnsibleFields(text string) ([]string, error) {
+	var fields []string
+	var field strings.Builder
+	var quote rune
+	inField := false
+	for _, r := range text {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			field.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inField = true
+		case r == ' ' || r == '\t':
+			if inField {
+				fields = append(fields, field.String())
+				field.Reset()
+				inField = false
+			}
+		default:
+			field.WriteRune(r)
+			inField = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in %q", text)
+	}
+	if inField {
+		fields = append(fields, field.String())
+	}
+	return fields, nil
+}
+
+// unquoteAnsibleValue removes the quotes around a group variable value
+func unquoteAnsibleValue(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// groupHosts returns the hosts of a group and of its child groups, in file order
+func (inv *ansibleInventory) groupHosts(group string) []ansibleHost {
+	return inv.collectGroupHosts(group, make(map[string]bool))
+}
+
+func (inv *ansibleInventory) collectGroupHosts(group string, visited map[string]bool) []ansibleHost {
+	if visited[group] {
+		return nil
+	}
+	visited[group] = true
+	hosts := append([]ansibleHost{}, inv.hosts[group]...)
+	for _, child := range inv.children[group] {
+		hosts = append(hosts, inv.collectGroupHosts(child, visited)...)
+	}
+	return hosts
+}
+
+// variable returns a variable of a host of group: host variables take precedence over the variables of
+// the group, then of [all:vars]. Jinja2 templates (e.g., "{{ deploy_dir }}/data") are not rendered, they are unset
+func (inv *ansibleInventory) variable(host ansibleHost, group, name string) string {
+	for _, vars := range []map[string]string{host.vars, inv.vars[group], inv.vars["all"]} {
+		if value, ok := vars[name]; ok {
+			if strings.Contains(value, "{{") {
+				return ""
+			}
+			return value
+		}
+	}
+	return ""
+}
+
+// address returns the address of a host: ansible_host if set, else the host name of the line
+func (inv *ansibleInventory) address(host ansibleHost) string {
+	if addr := host.vars["ansible_host"]; addr != "" {
+		return addr
+	}
+	return host.name
+}
+
+// port returns a port variable of a host of group, defaultPort if unset
+func (inv *ansibleInventory) port(host ansibleHost, group, name string, defaultPort int) (int, error) {
+	value := inv.variable(host, group, name)
+	if value == "" {
+		return defaultPort, nil
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, fmt.Errorf("invalid %s %q of %s (line %d of the Ansible inventory)", name, value, host.name, host.line)
+	}
+	return port, nil
+}
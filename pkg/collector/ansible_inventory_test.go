@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testAnsibleInventory is an inventory.ini of TiDB Ansible
+const testAnsibleInventory = `
+## TiDB Cluster Part
+[tidb_servers]
+TiDB1 ansible_host=10.0.0.1 deploy_dir=/data/deploy tidb_port=4001 tidb_status_port=10081
+10.0.0.2
+
+[tikv_servers]
+TiKV1-1 ansible_host=10.0.0.4 deploy_dir=/data1/deploy tikv_port=20171 tikv_status_port=20181 labels="host=tikv1"
+TiKV1-2 ansible_host=10.0.0.4 deploy_dir=/data2/deploy tikv_port=20172 tikv_status_port=20182 labels="host=tikv1"
+10.0.0.5 tikv_data_dir=/ssd/tikv
+
+[pd_servers]
+10.0.0.1
+10.0.0.2 pd_client_port=2381
+
+[spark_master]
+
+; Monitoring Part
+[monitored_servers:children]
+tidb_servers
+tikv_servers
+pd_servers
+
+[pd_servers:vars]
+# location_labels = ["zone","rack","host"]
+
+[all:vars]
+deploy_dir = /home/tidb/deploy
+ansible_user = tidb
+cluster_name = test-cluster
+tidb_version = "v4.0.16"
+data_dir = "{{ deploy_dir }}/data"
+`
+
+func TestLoadTopologyFromAnsibleInventory(t *testing.T) {
+	inventoryFile := filepath.Join(t.TempDir(), "inventory.ini")
+	require.NoError(t, os.WriteFile(inventoryFile, []byte(testAnsibleInventory), 0644))
+
+	for name, load := range map[string]func(string) (*ClusterEndpoints, error){
+		"LoadTopologyFromAnsibleInventory": LoadTopologyFromAnsibleInventory,
+		// The format is detected from the [tidb_servers] group
+		"LoadTopologyFromFile": LoadTopologyFromFile,
+	} {
+		t.Run(name, func(t *testing.T) {
+			endpoints, err := load(inventoryFile)
+			require.NoError(t, err)
+
+			assert.Equal(t, "10.0.0.1:4001", endpoints.TiDBAddr)
+			assert.Equal(t, "10.0.0.1:10081", endpoints.TiDBStatusAddr)
+			assert.Equal(t, "root", endpoints.TiDBUser)
+			assert.Equal(t, []string{"10.0.0.1:2379", "10.0.0.2:2381"}, endpoints.PDAddrs)
+			assert.Equal(t, []string{"10.0.0.4:20181", "10.0.0.4:20182", "10.0.0.5:20180"}, endpoints.TiKVAddrs)
+			assert.Equal(t, map[string]string{
+				"10.0.0.4:20181": "/data1/deploy/data",
+				"10.0.0.4:20182": "/data2/deploy/data",
+				"10.0.0.5:20180": "/ssd/tikv",
+			}, endpoints.TiKVDataDirs)
+			assert.Equal(t, "v4.0.16", endpoints.SourceVersion)
+		})
+	}
+}
+
+func TestLoadTopologyFromAnsibleInventory_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		inventory string
+		errMsg    string
+	}{
+		{"invalid port", "[tidb_servers]\n10.0.0.1 tidb_port=4k\n", `invalid tidb_port "4k" of 10.0.0.1 (line 2`},
+		{"unterminated quote", "[tikv_servers]\n10.0.0.4 labels=\"host=tikv1\n", "line 2: unterminated quote"},
+		{"invalid host variable", "[pd_servers]\n10.0.0.1 pd_client_port\n", `invalid host variable "pd_client_port" of 10.0.0.1`},
+		{"invalid header", "[tidb_servers\n", "line 1: invalid group header"},
+		{"no hosts", "[tidb_servers]\n[all:vars]\ndeploy_dir = /data/deploy\n", "no host in the [tidb_servers], [pd_servers] or [tikv_servers] groups"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadAnsibleInventory([]byte(tt.inventory))
+			assert.ErrorContains(t, err, tt.errMsg)
+		})
+	}
+}
+
+func TestIsAnsibleInventory(t *testing.T) {
+	assert.True(t, isAnsibleInventory([]byte(testAnsibleInventory)))
+	assert.False(t, isAnsibleInventory([]byte("tidb_servers:\n  - host: 10.0.0.1\n")))
+}
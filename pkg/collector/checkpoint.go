@@ -0,0 +1,167 @@
+package collector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultCheckpointTTL is the maximum age of a checkpoint file a collection resumes from
+const DefaultCheckpointTTL = 24 * time.Hour
+
+// CheckpointOptions make an interrupted collection resumable (see Collector.SetCheckpoint)
+type CheckpointOptions struct {
+	// Path is the checkpoint file (JSON lines), checkpointing is disabled if empty
+	Path string
+	// TTL is the maximum age of a checkpoint file to resume from (DefaultCheckpointTTL if <= 0)
+	TTL time.Duration
+}
+
+// checkpointHeader is the first line of a checkpoint file
+type checkpointHeader struct {
+	// ClusterID is the PD cluster ID of the collected cluster
+	ClusterID string    `json:"cluster_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// checkpointEntry is a line of a checkpoint file after the header: a collected component (TiDB, PD) or node (TiKV, TiFlash)
+type checkpointEntry struct {
+	Component ComponentType `json:"component"`
+	// Address is the endpoint a TiKV or TiFlash node was collected from, empty for TiDB and PD
+	Address string         `json:"address,omitempty"`
+	State   ComponentState `json:"state"`
+}
+
+// checkpoint records the components of a collection in a file as soon as they are collected, so that a
+// collection that is interrupted (e.g., a lost connection at the 80th of 100 TiKV nodes) can resume from it
+// A nil checkpoint records nothing
+type checkpoint struct {
+	path string
+	mu   sync.Mutex
+	file *os.File
+	// collected are the states recorded by the previous runs, by checkpointKey
+	collected map[string]ComponentState
+}
+
+// checkpointKey is the key of a component or node in a checkpoint
+func checkpointKey(component ComponentType, addr string) string {
+	if addr == "" {
+		return string(component)
+	}
+	return string(component) + "@" + addr
+}
+
+// openCheckpoint opens the checkpoint file of a collection of the cluster clusterID, and loads the states it records
+// The file is created if it doesn't exist. An existing file is refused if it was recorded for another cluster,
+// or more than opts.TTL before now: it must be removed to start a new collection
+func openCheckpoint(opts CheckpointOptions, clusterID string, now time.Time) (*checkpoint, error) {
+	ttl := opts.TTL
+	if ttl <= 0 {
+		ttl = DefaultCheckpointTTL
+	}
+	data, err := os.ReadFile(opts.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read checkpoint %s: %w", opts.Path, err)
+	}
+	// A line without its newline is an entry whose write was interrupted, it is dropped
+	data = data[:bytes.LastIndexByte(data, '\n')+1]
+
+	cp := &checkpoint{path: opts.Path, collected: make(map[string]ComponentState)}
+	if len(data) == 0 {
+		header, err := json.Marshal(checkpointHeader{ClusterID: clusterID, CreatedAt: now.UTC()})
+		if err != nil {
+			return nil, err
+		}
+		data = append(header, '\n')
+		if err := os.WriteFile(opts.Path, data, 0600); err != nil {
+			return nil, fmt.Errorf("failed to create checkpoint %s: %w", opts.Path, err)
+		}
+	} else if err := cp.load(data, clusterID, now, ttl); err != nil {
+		return nil, err
+	} else if err := os.Truncate(opts.Path, int64(len(data))); err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint %s: %w", opts.Path, err)
+	}
+
+	cp.file, err = os.OpenFile(opts.Path, os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint %s: %w", opts.Path, err)
+	}
+	return cp, nil
+}
+
+// load checks the header of the checkpoint file data and loads its entries
+func (cp *checkpoint) load(data []byte, clusterID string, now time.Time, ttl time.Duration) error {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// Entries hold the whole configuration of a component
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data))
+	if !scanner.Scan() {
+		return fmt.Errorf("checkpoint %s has no header", cp.path)
+	}
+	var header checkpointHeader
+	if err := json.Unmarshal(scanner.Bytes(), &header); err != nil || header.CreatedAt.IsZero() {
+		return fmt.Errorf("%s is not a checkpoint file, remove it to start a new collection", cp.path)
+	}
+	if header.ClusterID != clusterID {
+		return fmt.Errorf("checkpoint %s was recorded for cluster %s, not for cluster %s: remove it to start a new collection",
+			cp.path, header.ClusterID, clusterID)
+	}
+	if age := now.Sub(header.CreatedAt); age > ttl {
+		return fmt.Errorf("checkpoint %s was recorded %s ago, more than %s ago: remove it to start a new collection",
+			cp.path, age.Round(time.Second), ttl)
+	}
+
+	for line := 2; scanner.Scan(); line++ {
+		var entry checkpointEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("checkpoint %s: invalid entry at line %d: %w", cp.path, line, err)
+		}
+		cp.collected[checkpointKey(entry.Component, entry.Address)] = entry.State
+	}
+	return scanner.Err()
+}
+
+// lookup returns the state of a component (addr empty) or node recorded by a previous run
+func (cp *checkpoint) lookup(component ComponentType, addr string) (ComponentState, bool) {
+	if cp == nil {
+		return ComponentState{}, false
+	}
+	state, ok := cp.collected[checkpointKey(component, addr)]
+	return state, ok
+}
+
+// record appends the state of a collected component (addr empty) or node to the checkpoint file
+// A failure is only a warning: the collection goes on, it is just not resumable past this point
+// It may be called concurrently
+func (cp *checkpoint) record(component ComponentType, addr string, state ComponentState) {
+	if cp == nil {
+		return
+	}
+	line, err := json.Marshal(checkpointEntry{Component: component, Address: addr, State: state})
+	if err == nil {
+		cp.mu.Lock()
+		if _, err = cp.file.Write(append(line, '\n')); err == nil {
+			err = cp.file.Sync()
+		}
+		cp.mu.Unlock()
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to record %s in checkpoint %s: %v\n", checkpointKey(component, addr), cp.path, err)
+	}
+}
+
+// close closes the checkpoint file, removing it if the collection completed
+func (cp *checkpoint) close(completed bool) {
+	if cp == nil {
+		return
+	}
+	cp.file.Close()
+	if completed {
+		if err := os.Remove(cp.path); err != nil {
+			fmt.Printf("Warning: failed to remove checkpoint %s: %v\n", cp.path, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/pd"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tikv"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakePDCollector is a PD of the cluster clusterID, counting the collections
+type fakePDCollector struct {
+	pd.PDCollector
+	clusterID   string
+	collections int
+}
+
+func (f *fakePDCollector) ClusterID(addrs []string) (string, error) {
+	return f.clusterID, nil
+}
+
+func (f *fakePDCollector) Collect(_ context.Context, addrs []string) (*ComponentState, error) {
+	f.collections++
+	return &ComponentState{
+		Type:    PDComponent,
+		Version: "v7.5.0",
+		Config:  defaultsTypes.ParameterMap{"schedule.leader-schedule-limit": {Value: float64(4), Type: "float"}},
+		Status:  map[string]interface{}{"address": addrs[0], "cluster_id": f.clusterID},
+	}, nil
+}
+
+// fakeTiKVCollector collects TiKV nodes one after the other, the connection being lost after failAfter nodes (0 for never)
+type fakeTiKVCollector struct {
+	tikv.TiKVCollector
+	failAfter int
+	// requested are the addresses collection was asked for
+	requested []string
+}
+
+func (f *fakeTiKVCollector) CollectWithTiDBProgress(_ context.Context, addrs []string, dataDirs map[string]string, tidbAddr, tidbUser, tidbPassword string,
+	onCollected func(addr string, state defaultsTypes.ComponentState)) ([]defaultsTypes.ComponentState, error) {
+	f.requested = append(f.requested, addrs...)
+	var states []defaultsTypes.ComponentState
+	for i, addr := range addrs {
+		if f.failAfter > 0 && i == f.failAfter {
+			return nil, errors.New("connection lost")
+		}
+		state := defaultsTypes.ComponentState{
+			Type:    TiKVComponent,
+			Version: "v7.5.0",
+			Config: defaultsTypes.ParameterMap{
+				"storage.block-cache.capacity": {Value: "1GiB", Type: "string"},
+				"raftstore.store-pool-size":    {Value: float64(2), Type: "float"},
+			},
+			Status: map[string]interface{}{"address": addr, "cpu_cores": float64(16)},
+		}
+		onCollected(addr, state)
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// newFakeCollector creates a collector of the fake PD and TiKV
+func newFakeCollector(pdCollector *fakePDCollector, tikvCollector *fakeTiKVCollector, checkpointPath string) *Collector {
+	c := NewCollector()
+	c.pdCollector = pdCollector
+	c.tikvCollector = tikvCollector
+	c.SetCheckpoint(CheckpointOptions{Path: checkpointPath})
+	return c
+}
+
+func checkpointTestEndpoints(tikvNodes int) ClusterEndpoints {
+	endpoints := ClusterEndpoints{TiDBAddr: "10.0.0.1:4000", PDAddrs: []string{"10.0.0.1:2379"}}
+	for i := 1; i <= tikvNodes; i++ {
+		endpoints.TiKVAddrs = append(endpoints.TiKVAddrs, fmt.Sprintf("10.0.1.%d:20160", i))
+	}
+	return endpoints
+}
+
+var checkpointTestReq = &CollectDataRequirements{Components: []string{"pd", "tikv"}, NeedConfig: true, NeedAllTikvNodes: true}
+
+// snapshotJSON returns the snapshot as saved with --save-snapshot, without its timestamp
+func snapshotJSON(t *testing.T, snapshot *ClusterSnapshot) string {
+	snapshot.Timestamp = time.Time{}
+	data, err := json.Marshal(snapshot)
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestCollect_ResumeFromCheckpoint(t *testing.T) {
+	endpoints := checkpointTestEndpoints(10)
+	uninterrupted, err := newFakeCollector(&fakePDCollector{clusterID: "7001"}, &fakeTiKVCollector{}, "").
+		Collect(context.Background(), endpoints, checkpointTestReq)
+	require.NoError(t, err)
+	require.Len(t, uninterrupted.Components, 12)
+
+	// The connection is lost after 7 TiKV nodes
+	path := filepath.Join(t.TempDir(), "collection.ckpt")
+	_, err = newFakeCollector(&fakePDCollector{clusterID: "7001"}, &fakeTiKVCollector{failAfter: 7}, path).
+		Collect(context.Background(), endpoints, checkpointTestReq)
+	require.ErrorContains(t, err, "connection lost")
+	require.FileExists(t, path)
+
+	// The next run only collects the 3 remaining nodes
+	pdCollector := &fakePDCollector{clusterID: "7001"}
+	tikvCollector := &fakeTiKVCollector{}
+	resumed, err := newFakeCollector(pdCollector, tikvCollector, path).Collect(context.Background(), endpoints, checkpointTestReq)
+	require.NoError(t, err)
+	assert.Equal(t, endpoints.TiKVAddrs[7:], tikvCollector.requested)
+	assert.Zero(t, pdCollector.collections)
+	assert.Equal(t, snapshotJSON(t, uninterrupted), snapshotJSON(t, resumed))
+
+	// The checkpoint is removed once the collection completes
+	assert.NoFileExists(t, path)
+}
+
+func TestCollect_ResumeFromCheckpoint_Interrupted(t *testing.T) {
+	endpoints := checkpointTestEndpoints(4)
+	path := filepath.Join(t.TempDir(), "collection.ckpt")
+	_, err := newFakeCollector(&fakePDCollector{clusterID: "7001"}, &fakeTiKVCollector{failAfter: 2}, path).
+		Collect(context.Background(), endpoints, checkpointTestReq)
+	require.Error(t, err)
+
+	// The process was killed while writing the next entry
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0600)
+	require.NoError(t, err)
+	_, err = file.WriteString(`{"component":"tikv","address":"10.0.1.3:20160","state":{"ty`)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	tikvCollector := &fakeTiKVCollector{}
+	snapshot, err := newFakeCollector(&fakePDCollector{clusterID: "7001"}, tikvCollector, path).
+		Collect(context.Background(), endpoints, checkpointTestReq)
+	require.NoError(t, err)
+	assert.Equal(t, endpoints.TiKVAddrs[2:], tikvCollector.requested)
+	assert.Len(t, snapshot.Components, 6)
+}
+
+func TestOpenCheckpoint_Refused(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	path := filepath.Join(t.TempDir(), "collection.ckpt")
+	cp, err := openCheckpoint(CheckpointOptions{Path: path}, "7001", now)
+	require.NoError(t, err)
+	cp.record(PDComponent, "", ComponentState{Type: PDComponent, Version: "v7.5.0"})
+	cp.close(false)
+
+	// Another cluster
+	_, err = openCheckpoint(CheckpointOptions{Path: path}, "7002", now.Add(time.Hour))
+	assert.ErrorContains(t, err, "was recorded for cluster 7001, not for cluster 7002")
+
+	// Too old
+	_, err = openCheckpoint(CheckpointOptions{Path: path}, "7001", now.Add(DefaultCheckpointTTL+time.Minute))
+	assert.ErrorContains(t, err, "more than 24h0m0s ago")
+	_, err = openCheckpoint(CheckpointOptions{Path: path, TTL: 30 * time.Minute}, "7001", now.Add(time.Hour))
+	assert.ErrorContains(t, err, "more than 30m0s ago")
+
+	cp, err = openCheckpoint(CheckpointOptions{Path: path}, "7001", now.Add(time.Hour))
+	require.NoError(t, err)
+	state, ok := cp.lookup(PDComponent, "")
+	assert.True(t, ok)
+	assert.Equal(t, "v7.5.0", state.Version)
+	cp.close(false)
+
+	// Not a checkpoint
+	other := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, os.WriteFile(other, []byte("{\"components\": {}}\n"), 0600))
+	_, err = openCheckpoint(CheckpointOptions{Path: other}, "7001", now)
+	assert.ErrorContains(t, err, "is not a checkpoint file")
+}
+
+func TestRecordTiDBMissingPrivileges_FromCheckpoint(t *testing.T) {
+	snapshot := &ClusterSnapshot{}
+	state := &ComponentState{Status: map[string]interface{}{"missing_privileges": []interface{}{"global_variables_table"}}}
+	recordTiDBMissingPrivileges(snapshot, state)
+	fresh := &ClusterSnapshot{}
+	recordTiDBMissingPrivileges(fresh, &ComponentState{Status: map[string]interface{}{"missing_privileges": []string{"global_variables_table"}}})
+	assert.Equal(t, fresh.MissingPrivileges, snapshot.MissingPrivileges)
+}
@@ -29,7 +29,7 @@ type ConfigExtractor struct {
 	// Only used for Go source files
 	DefaultPrefix string
 	// Output stores extracted defaults
-	Output types.ConfigDefaults
+	Output types.ParameterMap
 	// Current prefix for nested configs (e.g., "storage.", "raftstore.")
 	// Only used for Rust source files
 	currentPrefix string
@@ -37,15 +37,27 @@ type ConfigExtractor struct {
 	currentFile *ast.File
 	// tomlTagMap stores field name -> toml tag mapping extracted from struct definitions
 	tomlTagMap map[string]string
+	// MachineDerived maps the keys of Output whose default is computed from host resources
+	// to the resource ("cpu" or "memory"); their value is the Rust expression of the default
+	// Only set for Rust source files (readpool_config! defaults)
+	MachineDerived map[string]string
+	// rustConsts stores the integer constants (const NAME: type = value;) of the Rust files parsed so far
+	rustConsts map[string]int64
+	// readPoolDefaults stores the default expression of each field generated by the readpool_config! macro
+	readPoolDefaults map[string]string
+	// readPoolPools stores the display names of the readpool_config! invocations (e.g., "storage")
+	readPoolPools []string
 }
 
 // NewConfigExtractor creates a new config extractor
 func NewConfigExtractor(configVarName, defaultPrefix string) *ConfigExtractor {
 	return &ConfigExtractor{
-		ConfigVarName: configVarName,
-		DefaultPrefix: defaultPrefix,
-		Output:        make(types.ConfigDefaults),
-		tomlTagMap:    make(map[string]string),
+		ConfigVarName:  configVarName,
+		DefaultPrefix:  defaultPrefix,
+		Output:         make(types.ParameterMap),
+		tomlTagMap:     make(map[string]string),
+		MachineDerived: make(map[string]string),
+		rustConsts:     make(map[string]int64),
 	}
 }
 
@@ -650,6 +662,10 @@ func (e *ConfigExtractor) extractFromRustCode(content string) error {
 	// Reset prefix
 	e.currentPrefix = ""
 
+	// Read pool configs are generated by a macro, which the impl Default scan below can't see
+	// The macro definition is removed so that its generic default() isn't extracted with a wrong prefix
+	content = e.extractReadPoolConfigs(content)
+
 	// Find all "impl Default for" blocks
 	// Pattern: impl Default for ConfigName {
 	defaultImplRe := regexp.MustCompile(`impl\s+Default\s+for\s+(\w+)\s*\{`)
@@ -1030,6 +1046,64 @@ func (e *ConfigExtractor) parseRustValue(valueStr string) interface{} {
 	return valueStr
 }
 
+// rustConfigPrefixes maps TiKV config struct names to the prefix of their parameters (the prefix map)
+var rustConfigPrefixes = map[string]string{
+	"tikvconfig":             "",
+	"storageconfig":          "storage.",
+	"raftstoreconfig":        "raftstore.",
+	"raftstore":              "raftstore.",
+	"serverconfig":           "server.",
+	"rocksdbconfig":          "rocksdb.",
+	"dbconfig":               "rocksdb.",
+	"logconfig":              "log.",
+	"metricconfig":           "metric.",
+	"securityconfig":         "security.",
+	"pdconfig":               "pd.",
+	"copconfig":              "coprocessor.",
+	"coprocessorconfig":      "coprocessor.",
+	"coprocessorv2config":    "coprocessor-v2.",
+	"gcconfig":               "gc.",
+	"splitconfig":            "split.",
+	"cdcconfig":              "cdc.",
+	"importconfig":           "import.",
+	"backupconfig":           "backup.",
+	"pessimistictxnconfig":   "pessimistic-txn.",
+	"resolvedtsconfig":       "resolved-ts.",
+	"resourcemeteringconfig": "resource-metering.",
+	"backupstreamconfig":     "log-backup.",
+	"causaltsconfig":         "causal-ts.",
+	"resourcecontrolconfig":  "resource-control.",
+	"inmemoryengineconfig":   "in-memory-engine.",
+	"memoryconfig":           "memory.",
+	"quotaconfig":            "quota.",
+	"readpoolconfig":         "readpool.",
+	"defaultcfconfig":        "rocksdb.defaultcf.",
+	"writecfconfig":          "rocksdb.writecf.",
+	"lockcfconfig":           "rocksdb.lockcf.",
+	"raftcfconfig":           "rocksdb.raftcf.",
+	"titanconfig":            "rocksdb.titan.",
+	"raftdbconfig":           "raftdb.",
+	"raftengineconfig":       "raft-engine.",
+}
+
+// cppConfigPrefixes maps TiFlash config struct/class names to the prefix of their parameters (the prefix map)
+var cppConfigPrefixes = map[string]string{
+	"spillconfig":        "spill.",
+	"storageconfig":      "storage.",
+	"storages3config":    "storage.s3.",
+	"raftconfig":         "raft.",
+	"serverconfig":       "server.",
+	"userconfig":         "users.",
+	"logconfig":          "log.",
+	"metricconfig":       "metric.",
+	"securityconfig":     "security.",
+	"profilesconfig":     "profiles.",
+	"quotasconfig":       "quotas.",
+	"flashconfig":        "flash.",
+	"flashproxyconfig":   "flash.proxy.",
+	"flashserviceconfig": "flash.service.",
+}
+
 // determineRustPrefix determines the config prefix based on config struct name
 func (e *ConfigExtractor) determineRustPrefix(configName string) string {
 	configName = strings.ToLower(configName)
@@ -1039,47 +1113,7 @@ func (e *ConfigExtractor) determineRustPrefix(configName string) string {
 		return ""
 	}
 
-	// Map common TiKV config struct names to prefixes
-	prefixMap := map[string]string{
-		"tikvconfig":             "",
-		"storageconfig":          "storage.",
-		"raftstoreconfig":        "raftstore.",
-		"raftstore":              "raftstore.",
-		"serverconfig":           "server.",
-		"rocksdbconfig":          "rocksdb.",
-		"dbconfig":               "rocksdb.",
-		"logconfig":              "log.",
-		"metricconfig":           "metric.",
-		"securityconfig":         "security.",
-		"pdconfig":               "pd.",
-		"copconfig":              "coprocessor.",
-		"coprocessorconfig":      "coprocessor.",
-		"coprocessorv2config":    "coprocessor-v2.",
-		"gcconfig":               "gc.",
-		"splitconfig":            "split.",
-		"cdcconfig":              "cdc.",
-		"importconfig":           "import.",
-		"backupconfig":           "backup.",
-		"pessimistictxnconfig":   "pessimistic-txn.",
-		"resolvedtsconfig":       "resolved-ts.",
-		"resourcemeteringconfig": "resource-metering.",
-		"backupstreamconfig":     "log-backup.",
-		"causaltsconfig":         "causal-ts.",
-		"resourcecontrolconfig":  "resource-control.",
-		"inmemoryengineconfig":   "in-memory-engine.",
-		"memoryconfig":           "memory.",
-		"quotaconfig":            "quota.",
-		"readpoolconfig":         "readpool.",
-		"defaultcfconfig":        "rocksdb.defaultcf.",
-		"writecfconfig":          "rocksdb.writecf.",
-		"lockcfconfig":           "rocksdb.lockcf.",
-		"raftcfconfig":           "rocksdb.raftcf.",
-		"titanconfig":            "rocksdb.titan.",
-		"raftdbconfig":           "raftdb.",
-		"raftengineconfig":       "raft-engine.",
-	}
-
-	for key, prefix := range prefixMap {
+	for key, prefix := range rustConfigPrefixes {
 		if strings.Contains(configName, key) {
 			return prefix
 		}
@@ -1088,6 +1122,157 @@ func (e *ConfigExtractor) determineRustPrefix(configName string) string {
 	return ""
 }
 
+// readPoolFields are the fields of the structs generated by the readpool_config! macro
+var readPoolFields = []string{"min_thread_count", "max_thread_count", "stack_size", "max_tasks_per_worker"}
+
+var (
+	readPoolMacroRe      = regexp.MustCompile(`macro_rules!\s*readpool_config\s*\{`)
+	readPoolInvocationRe = regexp.MustCompile(`(?m)^\s*readpool_config!\s*\(\s*(\w+)\s*,\s*(\w+)\s*,\s*"([\w-]+)"\s*\)`)
+	rustConstRe          = regexp.MustCompile(`(?m)^\s*(?:pub(?:\([\w:]+\))?\s+)?const\s+([A-Z_][A-Z0-9_]*)\s*:\s*\w+\s*=\s*([^;]+);`)
+	rustFieldDefaultRe   = regexp.MustCompile(`(?m)^\s*(\w+):\s*(.+?),?\s*$`)
+	rustSelfLiteralRe    = regexp.MustCompile(`(?m)^\s*Self\s*\{`)
+	rustFieldDeclRe      = regexp.MustCompile(`(?m)^\s*pub\s+(\w+):\s*([\w:<>]+),`)
+	rustConstRefRe       = regexp.MustCompile(`\b[A-Z_][A-Z0-9_]*\b`)
+	// cpuDerivedRe matches default expressions computed from the number of CPU cores of the host
+	cpuDerivedRe = regexp.MustCompile(`cpu_cores_quota|cpu_num|num_cpus`)
+)
+
+// extractReadPoolConfigs extracts the defaults of the read pools (readpool.unified, readpool.storage,
+// readpool.coprocessor), whose config structs are generated by the readpool_config! macro
+// The macro definition gives the default expression of each field, and each invocation
+// (readpool_config!(StorageReadPoolConfig, storage_read_pool_test, "storage")) the pool it is generated for
+// Both may be in different files: they are remembered across files, and the defaults are emitted under
+// "readpool.<pool>." once both are known
+// Defaults derived from the number of CPU cores are kept as their Rust expression and recorded in MachineDerived
+// Returns content without the macro definition
+func (e *ConfigExtractor) extractReadPoolConfigs(content string) string {
+	for _, match := range rustConstRe.FindAllStringSubmatch(content, -1) {
+		if value, ok := evalRustIntExpr(match[2]); ok {
+			e.rustConsts[match[1]] = value
+		}
+	}
+
+	if loc := readPoolMacroRe.FindStringIndex(content); loc != nil {
+		macroBody, ok := rustBlockBody(content, loc[1]-1)
+		if !ok {
+			return content
+		}
+		e.readPoolDefaults = make(map[string]string)
+		declaredTypes := make(map[string]string)
+		for _, match := range rustFieldDeclRe.FindAllStringSubmatch(macroBody, -1) {
+			declaredTypes[match[1]] = match[2]
+		}
+		if loc := rustSelfLiteralRe.FindStringIndex(macroBody); loc != nil {
+			selfBody, _ := rustBlockBody(macroBody, loc[1]-1)
+			for _, match := range rustFieldDefaultRe.FindAllStringSubmatch(selfBody, -1) {
+				if _, declared := declaredTypes[match[1]]; declared {
+					e.readPoolDefaults[match[1]] = strings.TrimSpace(match[2])
+				}
+			}
+		}
+		content = content[:loc[0]] + content[loc[1]+len(macroBody)+1:]
+	}
+
+	for _, match := range readPoolInvocationRe.FindAllStringSubmatch(content, -1) {
+		if !containsString(e.readPoolPools, match[3]) {
+			e.readPoolPools = append(e.readPoolPools, match[3])
+		}
+	}
+
+	for _, pool := range e.readPoolPools {
+		for _, field := range readPoolFields {
+			expr, ok := e.readPoolDefaults[field]
+			if !ok {
+				continue
+			}
+			key := "readpool." + pool + "." + strings.ReplaceAll(field, "_", "-")
+			if cpuDerivedRe.MatchString(expr) {
+				e.Output[key] = types.ParameterValue{
+					Value:       expr,
+					Type:        rustFieldType(field),
+					Description: "Machine-derived: computed from the number of CPU cores of the host",
+				}
+				e.MachineDerived[key] = "cpu"
+				continue
+			}
+			if value := e.parseRustValue(e.resolveRustConsts(expr)); value != nil {
+				e.Output[key] = types.ParameterValue{
+					Value: value,
+					Type:  e.determineValueType(value),
+				}
+			}
+		}
+	}
+
+	return content
+}
+
+// rustBlockBody returns the content between the brace at index open and its matching closing brace
+// Braces in string literals are ignored
+func rustBlockBody(content string, open int) (string, bool) {
+	depth := 0
+	inString := false
+	for i := open; i < len(content); i++ {
+		switch char := content[i]; {
+		case inString && char == '\\':
+			i++
+		case char == '"':
+			inString = !inString
+		case inString:
+		case char == '{':
+			depth++
+		case char == '}':
+			depth--
+			if depth == 0 {
+				return content[open+1 : i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// resolveRustConsts replaces the references to known integer constants in a Rust expression by their value
+func (e *ConfigExtractor) resolveRustConsts(expr string) string {
+	return rustConstRefRe.ReplaceAllStringFunc(expr, func(name string) string {
+		if value, ok := e.rustConsts[name]; ok {
+			return strconv.FormatInt(value, 10)
+		}
+		return name
+	})
+}
+
+// evalRustIntExpr evaluates an integer literal or a product of integer literals (e.g., "2 * 1000", "1_024")
+func evalRustIntExpr(expr string) (int64, bool) {
+	result := int64(1)
+	for _, factor := range strings.Split(expr, "*") {
+		factor = strings.ReplaceAll(strings.TrimSpace(factor), "_", "")
+		value, err := strconv.ParseInt(factor, 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		result *= value
+	}
+	return result, true
+}
+
+// rustFieldType returns the knowledge base type of a read pool field
+func rustFieldType(field string) string {
+	if field == "stack_size" {
+		return "size"
+	}
+	return "number"
+}
+
+// containsString reports whether values contains value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 // ============================================================================
 // TiFlash Component-Specific Functions (C++ Source Code Extraction)
 // ============================================================================
@@ -1359,25 +1544,7 @@ func (e *ConfigExtractor) parseCppValue(valueStr, fieldType string) interface{}
 func (e *ConfigExtractor) determineCppPrefix(configName string) string {
 	configName = strings.ToLower(configName)
 
-	// Map common TiFlash config struct/class names to prefixes
-	prefixMap := map[string]string{
-		"spillconfig":        "spill.",
-		"storageconfig":      "storage.",
-		"storages3config":    "storage.s3.",
-		"raftconfig":         "raft.",
-		"serverconfig":       "server.",
-		"userconfig":         "users.",
-		"logconfig":          "log.",
-		"metricconfig":       "metric.",
-		"securityconfig":     "security.",
-		"profilesconfig":     "profiles.",
-		"quotasconfig":       "quotas.",
-		"flashconfig":        "flash.",
-		"flashproxyconfig":   "flash.proxy.",
-		"flashserviceconfig": "flash.service.",
-	}
-
-	for key, prefix := range prefixMap {
+	for key, prefix := range cppConfigPrefixes {
 		if strings.Contains(configName, key) {
 			return prefix
 		}
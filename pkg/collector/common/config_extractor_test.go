@@ -0,0 +1,61 @@
+package common
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigExtractor_ReadPoolConfig(t *testing.T) {
+	e := NewConfigExtractor("", "")
+	require.NoError(t, e.ExtractFromFile(filepath.Join("testdata", "tikv_readpool", "mod.rs")))
+
+	for _, pool := range []string{"unified", "storage", "coprocessor"} {
+		prefix := "readpool." + pool + "."
+		assert.Equal(t, types.ParameterValue{Value: float64(1), Type: "number"}, e.Output[prefix+"min-thread-count"], pool)
+		assert.Equal(t, types.ParameterValue{Value: "10MB", Type: "size"}, e.Output[prefix+"stack-size"], pool)
+		assert.Equal(t, types.ParameterValue{Value: float64(2000), Type: "number"}, e.Output[prefix+"max-tasks-per-worker"], pool)
+
+		// The CPU-derived default is kept as its expression and classified as machine-derived
+		maxThreadCount, ok := e.Output[prefix+"max-thread-count"]
+		require.True(t, ok, pool)
+		assert.Equal(t, "cmp::max(UNIFIED_READPOOL_MIN_CONCURRENCY, (cpu_num * 0.8) as usize)", maxThreadCount.Value)
+		assert.Equal(t, "cpu", e.MachineDerived[prefix+"max-thread-count"])
+	}
+	assert.Len(t, e.MachineDerived, 3)
+
+	// Nothing is extracted from the generic default() of the macro definition
+	for key := range e.Output {
+		assert.Contains(t, key, "readpool.", key)
+	}
+}
+
+func TestConfigExtractor_ReadPoolConfigAcrossFiles(t *testing.T) {
+	// The macro is defined in one file and invoked in another
+	source, err := os.ReadFile(filepath.Join("testdata", "tikv_readpool", "mod.rs"))
+	require.NoError(t, err)
+	dir := t.TempDir()
+	definition := filepath.Join(dir, "readpool.rs")
+	invocation := filepath.Join(dir, "config.rs")
+	require.NoError(t, os.WriteFile(definition, source[:len(source)-len(readPoolInvocations(t, source))], 0644))
+	require.NoError(t, os.WriteFile(invocation, []byte(`readpool_config!(StorageReadPoolConfig, storage_read_pool_test, "storage");`+"\n"), 0644))
+
+	e := NewConfigExtractor("", "")
+	require.NoError(t, e.ExtractFromFile(invocation))
+	assert.Empty(t, e.Output)
+	require.NoError(t, e.ExtractFromFile(definition))
+	assert.Equal(t, "10MB", e.Output["readpool.storage.stack-size"].Value)
+	assert.Equal(t, "cpu", e.MachineDerived["readpool.storage.max-thread-count"])
+	assert.NotContains(t, e.Output, "readpool.unified.stack-size")
+}
+
+// readPoolInvocations returns the end of the fixture, from the first readpool_config! invocation
+func readPoolInvocations(t *testing.T, source []byte) []byte {
+	loc := readPoolInvocationRe.FindIndex(source)
+	require.NotNil(t, loc)
+	return source[loc[0]:]
+}
@@ -0,0 +1,108 @@
+package common
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// prefixMapSections are the sections of the prefix maps of the config extractor (e.g., "raftstore.", "flash.proxy.")
+var prefixMapSections = func() map[string]bool {
+	sections := make(map[string]bool)
+	for _, prefixes := range []map[string]string{rustConfigPrefixes, cppConfigPrefixes} {
+		for _, prefix := range prefixes {
+			if prefix != "" {
+				sections[prefix] = true
+			}
+		}
+	}
+	return sections
+}()
+
+// ResolveKeyCollisions detects parameters collected under two keys by both the prefix-map path and the field-name
+// fallback of the config extractor (e.g., "raftstore.store-pool-size" and "store-pool-size"): the orphan key is a
+// dotted suffix of the prefixed key, and either the orphan has no section of its own or the prefixed key only adds
+// a section of the prefix maps. Distinct parameters nested under another section (e.g., TiFlash "logger.level" and
+// "engine-store.logger.level") are not collisions
+// Collisions are resolved deterministically: the prefixed key is kept and the orphan key is deleted from config
+// Returns every collision, sorted by orphan key then prefixed key
+func ResolveKeyCollisions(config types.ParameterMap) []types.KeyCollision {
+	// Index the dotted suffixes of every key that can be orphans of it: "a.b.c" -> "b.c" (if "a." is a section
+	// of the prefix maps), "c"
+	bySuffix := make(map[string][]string)
+	for key := range config {
+		for i := 0; i < len(key); i++ {
+			if key[i] != '.' {
+				continue
+			}
+			suffix := key[i+1:]
+			if !strings.Contains(suffix, ".") || prefixMapSections[key[:i+1]] {
+				bySuffix[suffix] = append(bySuffix[suffix], key)
+			}
+		}
+	}
+
+	var collisions []types.KeyCollision
+	for orphan, orphanValue := range config {
+		prefixedKeys := bySuffix[orphan]
+		sort.Strings(prefixedKeys)
+		for _, prefixed := range prefixedKeys {
+			prefixedValue := config[prefixed]
+			collisions = append(collisions, types.KeyCollision{
+				PrefixedKey:   prefixed,
+				OrphanKey:     orphan,
+				PrefixedValue: prefixedValue.Value,
+				OrphanValue:   orphanValue.Value,
+				Conflict:      !reflect.DeepEqual(prefixedValue.Value, orphanValue.Value),
+			})
+		}
+	}
+	sort.Slice(collisions, func(i, j int) bool {
+		if collisions[i].OrphanKey != collisions[j].OrphanKey {
+			return collisions[i].OrphanKey < collisions[j].OrphanKey
+		}
+		return collisions[i].PrefixedKey < collisions[j].PrefixedKey
+	})
+
+	for _, collision := range collisions {
+		if _, ok := config[collision.OrphanKey]; !ok {
+			continue
+		}
+		delete(config, collision.OrphanKey)
+		fmt.Printf("[DEBUG ResolveKeyCollisions] Dropped duplicate key %s (kept %s)\n", collision.OrphanKey, collision.PrefixedKey)
+	}
+	return collisions
+}
+
+// CountKeyConflicts returns the number of collisions whose keys had different values
+func CountKeyConflicts(collisions []types.KeyCollision) int {
+	count := 0
+	for _, collision := range collisions {
+		if collision.Conflict {
+			count++
+		}
+	}
+	return count
+}
+
+// FormatKeyCollisionReport formats the validation report of the collisions of a component, one line per collision
+func FormatKeyCollisionReport(component string, collisions []types.KeyCollision) string {
+	if len(collisions) == 0 {
+		return fmt.Sprintf("%s: no duplicate keys\n", component)
+	}
+
+	var report strings.Builder
+	report.WriteString(fmt.Sprintf("%s: %d duplicate keys (%d with conflicting values)\n", component, len(collisions), CountKeyConflicts(collisions)))
+	for _, collision := range collisions {
+		status := "same value"
+		if collision.Conflict {
+			status = "CONFLICT"
+		}
+		report.WriteString(fmt.Sprintf("  [%s] %s=%v (kept) vs %s=%v (dropped)\n", status,
+			collision.PrefixedKey, collision.PrefixedValue, collision.OrphanKey, collision.OrphanValue))
+	}
+	return report.String()
+}
@@ -0,0 +1,74 @@
+package common
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveKeyCollisions(t *testing.T) {
+	// Generated TiKV defaults where both the prefix-map path and the field-name fallback produced a key
+	data, err := os.ReadFile("testdata/tikv_double_keys.json")
+	require.NoError(t, err)
+	var snapshot types.KBSnapshot
+	require.NoError(t, json.Unmarshal(data, &snapshot))
+	config := snapshot.ConfigDefaults
+
+	collisions := ResolveKeyCollisions(config)
+	require.Len(t, collisions, 5)
+	assert.Equal(t, types.KeyCollision{
+		PrefixedKey: "raftstore.apply-pool-size", OrphanKey: "apply-pool-size",
+		PrefixedValue: float64(2), OrphanValue: float64(2), Conflict: false,
+	}, collisions[0])
+	assert.Equal(t, "block-cache.capacity", collisions[1].OrphanKey)
+	assert.Equal(t, "storage.block-cache.capacity", collisions[1].PrefixedKey)
+	// "capacity" is a suffix of two keys
+	assert.Equal(t, "capacity", collisions[2].OrphanKey)
+	assert.Equal(t, "block-cache.capacity", collisions[2].PrefixedKey)
+	assert.True(t, collisions[2].Conflict)
+	assert.Equal(t, "capacity", collisions[3].OrphanKey)
+	assert.Equal(t, "storage.block-cache.capacity", collisions[3].PrefixedKey)
+	assert.Equal(t, types.KeyCollision{
+		PrefixedKey: "raftstore.store-pool-size", OrphanKey: "store-pool-size",
+		PrefixedValue: float64(2), OrphanValue: float64(4), Conflict: true,
+	}, collisions[4])
+	assert.Equal(t, 3, CountKeyConflicts(collisions))
+
+	// Orphans are dropped, prefixed keys and keys that only share a leaf name are kept
+	assert.Len(t, config, 5)
+	for _, key := range []string{"raftstore.store-pool-size", "raftstore.apply-pool-size", "rocksdb.defaultcf.block-size", "rocksdb.writecf.block-size", "storage.block-cache.capacity"} {
+		assert.Contains(t, config, key)
+	}
+
+	report := FormatKeyCollisionReport("tikv", collisions)
+	assert.Contains(t, report, "tikv: 5 duplicate keys (3 with conflicting values)")
+	assert.Contains(t, report, "[CONFLICT] raftstore.store-pool-size=2 (kept) vs store-pool-size=4 (dropped)")
+
+	// Resolving again finds nothing
+	assert.Empty(t, ResolveKeyCollisions(config))
+	assert.Equal(t, "tikv: no duplicate keys\n", FormatKeyCollisionReport("tikv", nil))
+}
+
+func TestResolveKeyCollisions_NestedDistinctKeys(t *testing.T) {
+	// TiFlash defaults where keys nested under another section are distinct parameters
+	data, err := os.ReadFile("testdata/tiflash_nested_keys.json")
+	require.NoError(t, err)
+	var snapshot types.KBSnapshot
+	require.NoError(t, json.Unmarshal(data, &snapshot))
+	config := snapshot.ConfigDefaults
+
+	// Only the key the prefix map ("flash.") and the field-name fallback both produced collides
+	collisions := ResolveKeyCollisions(config)
+	require.Len(t, collisions, 1)
+	assert.Equal(t, "proxy.engine-addr", collisions[0].OrphanKey)
+	assert.Equal(t, "flash.proxy.engine-addr", collisions[0].PrefixedKey)
+
+	assert.Len(t, config, 5)
+	for _, key := range []string{"logger.level", "engine-store.logger.level", "flash.proxy.config", "engine-store.flash.proxy.config", "flash.proxy.engine-addr"} {
+		assert.Contains(t, config, key)
+	}
+}
@@ -3,6 +3,7 @@ package common
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -13,24 +14,125 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// ErrPlaygroundUnsupported is returned by the playground functions on platforms tiup playground doesn't run on (Windows)
+// Knowledge base generation needs a Linux or macOS host; the offline analysis doesn't use the playground
+var ErrPlaygroundUnsupported = errors.New("tiup playground is not supported on this platform, generate the knowledge base on Linux or macOS")
+
+// ErrTiUPComponentNotFound is returned when tiup can't find a component of the version, which is not cached
+// and can't be installed from the mirror (unknown version, offline mirror)
+type ErrTiUPComponentNotFound struct {
+	// Version is the version of the playground
+	Version string
+	// Err is the error of the tiup command
+	Err error
+}
+
+func (e *ErrTiUPComponentNotFound) Error() string {
+	return fmt.Sprintf("tiup component not found for version %s: check that the version exists (tiup list tidb) "+
+		"and install it with `tiup install playground:%s`, or its components with `tiup install tidb:%s pd:%s tikv:%s tiflash:%s`: %v",
+		e.Version, e.Version, e.Version, e.Version, e.Version, e.Version, e.Err)
+}
+
+func (e *ErrTiUPComponentNotFound) Unwrap() error {
+	return e.Err
+}
+
+// ErrClusterTimeout is returned by WaitForClusterReady when TiDB doesn't accept connections in time
+type ErrClusterTimeout struct {
+	// Tag is the tag of the playground
+	Tag string
+	// Port is the TiDB port that was waited for
+	Port int
+	// Timeout is how long it was waited
+	Timeout time.Duration
+	// Err is the last connection error
+	Err error
+}
+
+func (e *ErrClusterTimeout) Error() string {
+	msg := fmt.Sprintf("cluster %s did not become ready on port %d within %s", e.Tag, e.Port, e.Timeout)
+	if e.Err != nil {
+		msg += fmt.Sprintf(" (last error: %v)", e.Err)
+	}
+	return msg + ": check that the host has enough free CPU, memory and disk space, that no other process uses the port, " +
+		"and the logs of the instances in the playground data directory (~/.tiup/data/" + e.Tag + ")"
+}
+
+func (e *ErrClusterTimeout) Unwrap() error {
+	return e.Err
+}
+
+// wrapTiUPError adds an actionable message to the error of a tiup command run for version, given its stderr
+// A missing tiup binary and a component tiup can't find (exit code 1, "component not found") are recognized,
+// other errors are returned as is
+func wrapTiUPError(err error, stderr, version string) error {
+	if errors.Is(err, exec.ErrNotFound) {
+		return fmt.Errorf("tiup is not installed or not in PATH, install it with "+
+			"`curl --proto '=https' --tlsv1.2 -sSf https://tiup-mirrors.pingcap.com/install.sh | sh`: %w", err)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 && strings.Contains(strings.ToLower(stderr), "component not found") {
+		return &ErrTiUPComponentNotFound{Version: version, Err: err}
+	}
+	return err
+}
+
+// limitedBuffer keeps the first bytes written to it (up to limit) and discards the rest
+// Used to read the error messages of tiup while its stderr is still printed
+type limitedBuffer struct {
+	buf   []byte
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - len(b.buf); room > 0 {
+		if len(p) < room {
+			room = len(p)
+		}
+		b.buf = append(b.buf, p[:room]...)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return string(b.buf)
+}
+
+// TiUPHome returns the TiUP home directory: TIUP_HOME, or ~/.tiup
+func TiUPHome() (string, error) {
+	if tiupHome := os.Getenv("TIUP_HOME"); tiupHome != "" {
+		return tiupHome, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".tiup"), nil
+}
+
+// playgroundTmpStoragePath returns the TiDB tmp-storage-path of a playground instance
+func playgroundTmpStoragePath(tag string) string {
+	return filepath.Join(os.TempDir(), "tidb-tmp-storage-"+tag)
+}
+
+// playgroundConfigFile returns the temporary TiDB config file of a playground instance
+func playgroundConfigFile(tag string) string {
+	return filepath.Join(os.TempDir(), fmt.Sprintf("tidb-config-%s.toml", tag))
+}
+
 // FindPlaygroundInstanceAddr finds component instance address from playground directory
 // Extracts port from directory name ({component}-{port}) and constructs address as 127.0.0.1:{port}
-// component should be "tikv" or "tiflash"
+// component should be "pd", "tikv" or "tiflash"
 func FindPlaygroundInstanceAddr(component, tag string) (string, error) {
 	// Validate component name
 	component = strings.ToLower(component)
-	if component != "tikv" && component != "tiflash" {
-		return "", fmt.Errorf("unsupported component: %s (must be 'tikv' or 'tiflash')", component)
+	if component != "pd" && component != "tikv" && component != "tiflash" {
+		return "", fmt.Errorf("unsupported component: %s (must be 'pd', 'tikv' or 'tiflash')", component)
 	}
 
-	// Get TIUP_HOME from environment or use default
-	tiupHome := os.Getenv("TIUP_HOME")
-	if tiupHome == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get user home directory: %w", err)
-		}
-		tiupHome = filepath.Join(homeDir, ".tiup")
+	tiupHome, err := TiUPHome()
+	if err != nil {
+		return "", err
 	}
 
 	// Try to find component directory
@@ -66,339 +168,13 @@ const (
 	clusterStartTimeout = 300 // seconds
 )
 
-// StartPlayground starts a tiup playground cluster
-func StartPlayground(version, tag string) error {
-	// Pre-check: ensure components are installed and complete before starting
-	// This helps avoid "no such file or directory" errors
-	fmt.Printf("Checking if components are installed for version %s...\n", version)
-
-	// Get tiup home directory
-	tiupHome := os.Getenv("TIUP_HOME")
-	if tiupHome == "" {
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			tiupHome = filepath.Join(homeDir, ".tiup")
-		}
-	}
-
-	// Check all required components for completeness
-	// Define component name to binary name mapping
-	components := map[string]string{
-		"tidb":    "tidb-server",
-		"pd":      "pd-server",
-		"tikv":    "tikv-server",
-		"tiflash": "tiflash",
-	}
-
-	missingComponents := []string{}
-	useForce := false
-
-	for compName, binaryName := range components {
-		compDir := filepath.Join(tiupHome, "components", compName, version)
-		binaryPath := filepath.Join(compDir, binaryName)
-
-		if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
-			missingComponents = append(missingComponents, compName)
-			// If directory exists but binary is missing, we need to force re-download
-			if _, err := os.Stat(compDir); err == nil {
-				useForce = true
-				fmt.Printf("Component %s directory exists but binary %s is missing\n", compName, binaryName)
-			}
-		}
-	}
-
-	// If any component is missing, install all components
-	if len(missingComponents) > 0 {
-		fmt.Printf("Missing components: %v, installing components for version %s...\n", missingComponents, version)
-
-		// Build install command with --force if needed
-		installArgs := []string{"install"}
-		if useForce {
-			installArgs = append(installArgs, "--force")
-			fmt.Printf("Using --force to re-download incomplete components...\n")
-		}
-		installArgs = append(installArgs,
-			fmt.Sprintf("tidb:%s", version),
-			fmt.Sprintf("pd:%s", version),
-			fmt.Sprintf("tikv:%s", version),
-			fmt.Sprintf("tiflash:%s", version),
-		)
-
-		installCmd := exec.Command("tiup", installArgs...)
-		installCmd.Stdout = os.Stdout
-		installCmd.Stderr = os.Stderr
-		if err := installCmd.Run(); err != nil {
-			return fmt.Errorf("failed to install components for version %s: %w", version, err)
-		}
-		fmt.Printf("Components installed successfully\n")
-
-		// Verify all components are installed correctly
-		stillMissing := []string{}
-		for compName, binaryName := range components {
-			binaryPath := filepath.Join(tiupHome, "components", compName, version, binaryName)
-			if _, err := os.Stat(binaryPath); os.IsNotExist(err) {
-				stillMissing = append(stillMissing, fmt.Sprintf("%s/%s", compName, binaryName))
-			}
-		}
-
-		if len(stillMissing) > 0 {
-			return fmt.Errorf("component installation completed but some binaries are still missing: %v. This may indicate a network issue or insufficient disk space.", stillMissing)
-		}
-
-		fmt.Printf("All component binaries verified successfully\n")
-	} else {
-		fmt.Printf("All components are already installed and complete\n")
-	}
-
-	// Clean up any stale temporary storage locks before starting
-	// This helps avoid "fslock: lock is held" errors when multiple instances start concurrently
-	cleanupTempStorageLocks(tag)
-
-	// Create a unique temporary storage path for this instance to avoid file lock conflicts
-	tmpStoragePath := fmt.Sprintf("/tmp/tidb-tmp-storage-%s", tag)
-	os.MkdirAll(tmpStoragePath, 0755)
-
-	// Create a temporary TiDB config file with unique tmp-storage-path
-	tmpConfigFile := filepath.Join(os.TempDir(), fmt.Sprintf("tidb-config-%s.toml", tag))
-	configContent := fmt.Sprintf(`# Temporary TiDB configuration for playground instance %s
-# This file is auto-generated to avoid tmp-storage-path conflicts
-
-tmp-storage-path = "%s"
-`, tag, tmpStoragePath)
-
-	if err := os.WriteFile(tmpConfigFile, []byte(configContent), 0644); err != nil {
-		// If we can't create config file, continue without it (cleanup should help)
-		fmt.Printf("Warning: failed to create temp config file: %v\n", err)
-	} else {
-		// Clean up config file after playground starts (defer won't work here, so we'll clean it in StopPlayground)
-		defer func() {
-			// Try to clean up after a delay (playground needs time to read it)
-			go func() {
-				time.Sleep(30 * time.Second)
-				os.Remove(tmpConfigFile)
-				os.RemoveAll(tmpStoragePath)
-			}()
-		}()
-	}
-
-	cmdArgs := []string{
-		"playground", version,
-		"--tag", tag,
-		"--without-monitor",
-		"--db", "1",
-		"--kv", "1",
-		"--pd", "1",
-		"--tiflash", "1",
-	}
-
-	// Add config file if we created it successfully
-	if _, err := os.Stat(tmpConfigFile); err == nil {
-		cmdArgs = append(cmdArgs, "--db.config", tmpConfigFile)
-	}
-
-	cmd := exec.Command("tiup", cmdArgs...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	if err := cmd.Start(); err != nil {
-		return fmt.Errorf("failed to start tiup playground: %w", err)
-	}
-
-	// Give it a moment to start
-	// Add extra delay to ensure previous instances have released locks
-	// This is especially important when starting multiple instances concurrently
-	time.Sleep(8 * time.Second)
-
-	return nil
-}
-
-// cleanupTempStorageLocks cleans up stale temporary storage locks
-// This helps avoid "fslock: lock is held" errors when multiple TiDB instances start concurrently
-// TiDB generates tmp-storage-path based on connection addresses, so multiple instances with same
-// addresses will try to use the same path, causing lock conflicts.
-func cleanupTempStorageLocks(tag string) {
-	// Find and remove stale lock files in /var/folders (macOS temporary directory)
-	// The lock path pattern is: /var/folders/.../T/501_tidb/{base64_encoded}/tmp-storage
-	// TiDB uses base64-encoded connection addresses to generate unique paths, but if multiple
-	// instances have the same addresses, they'll generate the same path.
-
-	// Strategy 1: Clean up all tmp-storage directories older than 30 seconds
-	// This is more aggressive but safer for concurrent starts
-	cmd := exec.Command("find", "/var/folders", "-type", "d", "-name", "tmp-storage", "-mmin", "+0.5", "-exec", "rm", "-rf", "{}", "+")
-	_ = cmd.Run() // Ignore errors
-
-	// Strategy 2: Clean up based on parent directory pattern
-	// TiDB creates: /var/folders/.../T/501_tidb/{encoded}/tmp-storage
-	// We can clean up old encoded directories
-	cmd = exec.Command("find", "/var/folders", "-type", "d", "-path", "*/501_tidb/*", "-mmin", "+1", "-exec", "sh", "-c", "rm -rf \"$1/tmp-storage\" 2>/dev/null || true", "_", "{}", "+")
-	_ = cmd.Run() // Ignore errors
-
-	// Strategy 3: Clean up /tmp/tidb-* directories (alternative location)
-	cmd = exec.Command("find", "/tmp", "-type", "d", "-name", "tidb-*", "-mmin", "+1", "-exec", "rm", "-rf", "{}", "+")
-	_ = cmd.Run() // Ignore errors
-
-	// Strategy 4: More aggressive cleanup for very old locks (older than 5 minutes)
-	// These are definitely stale
-	cmd = exec.Command("find", "/var/folders", "-type", "d", "-name", "tmp-storage", "-mmin", "+5", "-exec", "rm", "-rf", "{}", "+")
-	_ = cmd.Run() // Ignore errors
-}
-
-// StopPlayground stops a tiup playground cluster and cleans up its data directory
-// tiup playground doesn't have a direct stop command, so we kill the process by tag
-// This function kills all related processes including child processes
-// For serial generation, this ensures complete cleanup after each version
-func StopPlayground(tag string) error {
-	fmt.Printf("Forcefully stopping and cleaning up playground cluster (tag: %s)...\n", tag)
-
-	// Get tiup home directory first
-	tiupHome := os.Getenv("TIUP_HOME")
-	if tiupHome == "" {
-		homeDir, err := os.UserHomeDir()
-		if err == nil {
-			tiupHome = filepath.Join(homeDir, ".tiup")
-		}
-	}
-
-	// Step 1: Find all PIDs related to this tag and kill them
-	// This is more aggressive and ensures we catch all processes
-	findCmd := exec.Command("pgrep", "-f", tag)
-	output, err := findCmd.Output()
-	if err == nil && len(output) > 0 {
-		pids := strings.Split(strings.TrimSpace(string(output)), "\n")
-		for _, pidStr := range pids {
-			pidStr = strings.TrimSpace(pidStr)
-			if pidStr != "" {
-				// Kill the process and its children
-				exec.Command("kill", "-TERM", pidStr).Run()
-				exec.Command("kill", "-9", pidStr).Run()
-			}
-		}
-	}
-
-	// Step 2: Kill the main tiup playground process with the specific tag
-	// Use SIGTERM first for graceful shutdown
-	cmd := exec.Command("pkill", "-TERM", "-f", fmt.Sprintf("tiup playground.*%s", tag))
-	_ = cmd.Run() // Ignore errors, process might already be stopped
-
-	// Wait a bit for graceful shutdown
-	time.Sleep(2 * time.Second)
-
-	// Step 3: Get the main playground process PID and kill its process tree
-	findCmd = exec.Command("pgrep", "-f", fmt.Sprintf("tiup playground.*%s", tag))
-	output, err = findCmd.Output()
-	if err == nil && len(output) > 0 {
-		pid := strings.TrimSpace(string(output))
-		if pid != "" {
-			// Kill the process tree (parent and all children)
-			exec.Command("pkill", "-TERM", "-P", pid).Run()
-			exec.Command("kill", "-TERM", pid).Run()
-		}
-	}
-
-	// Step 4: Kill all child processes that might still be running
-	// These are the actual server processes started by playground
-	childProcesses := []string{
-		"tidb-server",
-		"tikv-server",
-		"pd-server",
-		"tiflash",
-		"tikv-cdc",
-		"tiproxy",
-	}
-
-	// Kill processes that have the tag in their command line or working directory
-	for _, proc := range childProcesses {
-		// Kill by tag in command line
-		exec.Command("pkill", "-TERM", "-f", fmt.Sprintf("%s.*%s", proc, tag)).Run()
-		// Also try to kill by process name if it's in the data directory
-		if tiupHome != "" {
-			dataDir := filepath.Join(tiupHome, "data", tag)
-			exec.Command("pkill", "-TERM", "-f", fmt.Sprintf("%s.*%s", proc, dataDir)).Run()
-		}
-	}
-
-	// Step 5: Force kill everything (SIGKILL) - more aggressive cleanup
-	time.Sleep(1 * time.Second)
-	exec.Command("pkill", "-9", "-f", fmt.Sprintf("tiup playground.*%s", tag)).Run()
-
-	// Force kill all child processes related to this tag
-	for _, proc := range childProcesses {
-		exec.Command("pkill", "-9", "-f", fmt.Sprintf("%s.*%s", proc, tag)).Run()
-		if tiupHome != "" {
-			dataDir := filepath.Join(tiupHome, "data", tag)
-			exec.Command("pkill", "-9", "-f", fmt.Sprintf("%s.*%s", proc, dataDir)).Run()
-		}
-	}
-
-	// Step 6: Kill any remaining processes by port (if we can identify them)
-	// This is a last resort to ensure ports are freed
-	// Note: We don't kill by port directly as it might affect other processes
-	// Instead, we rely on process killing above
-
-	// Wait a bit for all processes to terminate
-	time.Sleep(3 * time.Second)
-
-	// Step 7: Clean up data directory for this tag
-	if tiupHome == "" {
-		tiupHome = os.Getenv("TIUP_HOME")
-		if tiupHome == "" {
-			homeDir, err := os.UserHomeDir()
-			if err != nil {
-				// If we can't get home dir, continue with other cleanup
-				tiupHome = ""
-			} else {
-				tiupHome = filepath.Join(homeDir, ".tiup")
-			}
-		}
-	}
-
-	if tiupHome != "" {
-		dataDir := filepath.Join(tiupHome, "data", tag)
-		if _, err := os.Stat(dataDir); err == nil {
-			// Try multiple times to remove (in case files are still locked)
-			for i := 0; i < 3; i++ {
-				if err := os.RemoveAll(dataDir); err != nil {
-					if i < 2 {
-						// Wait a bit and try again
-						time.Sleep(1 * time.Second)
-						continue
-					}
-					fmt.Printf("Warning: failed to remove data directory %s after 3 attempts: %v\n", dataDir, err)
-				} else {
-					fmt.Printf("✓ Cleaned up data directory: %s\n", dataDir)
-					break
-				}
-			}
-		}
-	}
-
-	// Step 8: Clean up temporary config file and storage paths
-	tmpConfigFile := filepath.Join(os.TempDir(), fmt.Sprintf("tidb-config-%s.toml", tag))
-	if _, err := os.Stat(tmpConfigFile); err == nil {
-		os.Remove(tmpConfigFile)
-		fmt.Printf("✓ Cleaned up temp config file: %s\n", tmpConfigFile)
-	}
-
-	tmpStoragePath := fmt.Sprintf("/tmp/tidb-tmp-storage-%s", tag)
-	if _, err := os.Stat(tmpStoragePath); err == nil {
-		os.RemoveAll(tmpStoragePath)
-		fmt.Printf("✓ Cleaned up temp storage path: %s\n", tmpStoragePath)
-	}
-
-	// Step 9: Clean up any remaining tmp-storage locks in /var/folders
-	// These might be left behind even after process termination
-	cleanupTempStorageLocks(tag)
-
-	fmt.Printf("✓ Playground cluster cleanup completed for tag: %s\n", tag)
-	return nil
-}
-
 // WaitForClusterReady waits for the cluster to be ready
 func WaitForClusterReady(tag string, port int) error {
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/", defaultTiDBUser, defaultTiDBPass, defaultTiDBHost, port)
 
 	deadline := time.Now().Add(clusterStartTimeout * time.Second)
 
+	var lastErr error
 	for time.Now().Before(deadline) {
 		db, err := sql.Open("mysql", dsn)
 		if err == nil {
@@ -411,10 +187,66 @@ func WaitForClusterReady(tag string, port int) error {
 			}
 			db.Close()
 		}
+		lastErr = err
 
 		fmt.Printf("Waiting for cluster... (%d seconds remaining)\n", int(time.Until(deadline).Seconds()))
 		time.Sleep(5 * time.Second)
 	}
 
-	return fmt.Errorf("cluster did not become ready within %d seconds", clusterStartTimeout)
+	return &ErrClusterTimeout{Tag: tag, Port: port, Timeout: clusterStartTimeout * time.Second, Err: lastErr}
+}
+
+// playgroundBinaryNames maps the playground components to the name of their binary
+// tidb, pd and tikv are required to start a playground, tiflash is optional
+var playgroundBinaryNames = []struct {
+	component string
+	binary    string
+	required  bool
+}{
+	{"tidb", "tidb-server", true},
+	{"pd", "pd-server", true},
+	{"tikv", "tikv-server", true},
+	{"tiflash", "tiflash", false},
+}
+
+// FindPlaygroundBinaries finds the component binaries of a directory (e.g., the binaries of a custom build)
+// Returns the path of each component's binary, keyed by component (tidb, pd, tikv, tiflash)
+// TiFlash is optional: it is looked up as tiflash or tiflash/tiflash (the layout of TiFlash release tarballs)
+func FindPlaygroundBinaries(dir string) (map[string]string, error) {
+	binaries := make(map[string]string)
+	var missing []string
+	for _, b := range playgroundBinaryNames {
+		candidates := []string{filepath.Join(dir, b.binary), filepath.Join(dir, b.component, b.binary)}
+		for _, candidate := range candidates {
+			if stat, err := os.Stat(candidate); err == nil && !stat.IsDir() {
+				binaries[b.component] = candidate
+				break
+			}
+		}
+		if _, ok := binaries[b.component]; !ok && b.required {
+			missing = append(missing, b.binary)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("binaries not found in %s: %s", dir, strings.Join(missing, ", "))
+	}
+	return binaries, nil
+}
+
+// playgroundBinPathArgs returns the tiup playground arguments starting the given binaries
+// instead of the binaries of the mirror (--db.binpath, --pd.binpath, --kv.binpath, --tiflash.binpath)
+func playgroundBinPathArgs(binaries map[string]string) []string {
+	flags := []struct{ component, flag string }{
+		{"tidb", "--db.binpath"},
+		{"pd", "--pd.binpath"},
+		{"tikv", "--kv.binpath"},
+		{"tiflash", "--tiflash.binpath"},
+	}
+	var args []string
+	for _, f := range flags {
+		if path, ok := binaries[f.component]; ok {
+			args = append(args, f.flag, path)
+		}
+	}
+	return args
 }
@@ -0,0 +1,67 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPlaygroundBinaries(t *testing.T) {
+	dir := t.TempDir()
+	for _, binary := range []string{"tidb-server", "pd-server", "tikv-server", filepath.Join("tiflash", "tiflash")} {
+		path := filepath.Join(dir, binary)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, nil, 0755))
+	}
+
+	binaries, err := FindPlaygroundBinaries(dir)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"tidb":    filepath.Join(dir, "tidb-server"),
+		"pd":      filepath.Join(dir, "pd-server"),
+		"tikv":    filepath.Join(dir, "tikv-server"),
+		"tiflash": filepath.Join(dir, "tiflash", "tiflash"),
+	}, binaries)
+	assert.Equal(t, []string{
+		"--db.binpath", filepath.Join(dir, "tidb-server"),
+		"--pd.binpath", filepath.Join(dir, "pd-server"),
+		"--kv.binpath", filepath.Join(dir, "tikv-server"),
+		"--tiflash.binpath", filepath.Join(dir, "tiflash", "tiflash"),
+	}, playgroundBinPathArgs(binaries))
+
+	// TiFlash is optional, the other binaries are required
+	require.NoError(t, os.RemoveAll(filepath.Join(dir, "tiflash")))
+	binaries, err = FindPlaygroundBinaries(dir)
+	require.NoError(t, err)
+	assert.NotContains(t, binaries, "tiflash")
+
+	require.NoError(t, os.Remove(filepath.Join(dir, "pd-server")))
+	_, err = FindPlaygroundBinaries(dir)
+	assert.ErrorContains(t, err, "pd-server")
+}
+
+func TestErrClusterTimeout(t *testing.T) {
+	var err error = &ErrClusterTimeout{Tag: "kb-gen-v8.1.0", Port: 4000, Timeout: 300 * time.Second, Err: errors.New("connection refused")}
+	assert.Equal(t, "cluster kb-gen-v8.1.0 did not become ready on port 4000 within 5m0s (last error: connection refused): "+
+		"check that the host has enough free CPU, memory and disk space, that no other process uses the port, "+
+		"and the logs of the instances in the playground data directory (~/.tiup/data/kb-gen-v8.1.0)", err.Error())
+	var timeoutErr *ErrClusterTimeout
+	require.True(t, errors.As(fmt.Errorf("cluster failed to become ready: %w", err), &timeoutErr))
+	assert.Equal(t, 4000, timeoutErr.Port)
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	buf := &limitedBuffer{limit: 8}
+	n, err := buf.Write([]byte("Error: "))
+	require.NoError(t, err)
+	assert.Equal(t, 7, n)
+	n, _ = buf.Write([]byte("component not found"))
+	assert.Equal(t, 19, n, "the writes are not short")
+	assert.Equal(t, "Error: c", buf.String())
+}
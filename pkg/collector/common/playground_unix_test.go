@@ -0,0 +1,35 @@
+//go:build !windows
+
+package common
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrapTiUPError(t *testing.T) {
+	stderr := "Error: component not found: tidb:v8.1.9 (not cached, mirror unreachable)"
+	err := exec.Command("sh", "-c", "exit 1").Run()
+	require.Error(t, err)
+
+	wrapped := wrapTiUPError(err, stderr, "v8.1.9")
+	var notFound *ErrTiUPComponentNotFound
+	require.True(t, errors.As(wrapped, &notFound))
+	assert.Equal(t, "v8.1.9", notFound.Version)
+	assert.Contains(t, wrapped.Error(), "tiup install playground:v8.1.9")
+	assert.ErrorIs(t, wrapped, err)
+
+	// Other failures are returned as is
+	assert.Equal(t, err, wrapTiUPError(err, "Error: no space left on device", "v8.1.9"))
+	exit2 := exec.Command("sh", "-c", "exit 2").Run()
+	assert.Equal(t, exit2, wrapTiUPError(exit2, stderr, "v8.1.9"))
+
+	_, err = exec.LookPath("tiup-not-installed")
+	wrapped = wrapTiUPError(err, "", "v8.1.9")
+	assert.ErrorIs(t, wrapped, exec.ErrNotFound)
+	assert.Contains(t, wrapped.Error(), "tiup is not installed or not in PATH")
+}
@@ -0,0 +1,21 @@
+//go:build windows
+
+package common
+
+// StartPlayground starts a tiup playground cluster
+// tiup playground doesn't run on Windows: ErrPlaygroundUnsupported is returned
+func StartPlayground(version, tag string) error {
+	return ErrPlaygroundUnsupported
+}
+
+// StartPlaygroundFromBinaries starts a tiup playground cluster from local binaries
+// tiup playground doesn't run on Windows: ErrPlaygroundUnsupported is returned
+func StartPlaygroundFromBinaries(version, tag string, binaries map[string]string) error {
+	return ErrPlaygroundUnsupported
+}
+
+// StopPlayground stops a tiup playground cluster
+// tiup playground doesn't run on Windows: ErrPlaygroundUnsupported is returned
+func StopPlayground(tag string) error {
+	return ErrPlaygroundUnsupported
+}
@@ -0,0 +1,155 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// CommandLinePath is the pprof endpoint serving the command line of Go components (TiDB status port, PD client port),
+// as NUL-separated arguments
+const CommandLinePath = "/debug/pprof/cmdline"
+
+// RedactedValue replaces the values of startup flags that look like credentials
+const RedactedValue = "******"
+
+// GetStartupArgs reads the command line of a Go component via CommandLinePath and parses its flags (see ParseStartupArgs)
+// Credentials are redacted (see RedactStartupArgs)
+func GetStartupArgs(ctx context.Context, client *http.Client, addr string) (map[string]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s%s", addr, CommandLinePath), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	args := ParseStartupArgs(strings.Split(strings.TrimRight(string(body), "\x00"), "\x00"))
+	RedactStartupArgs(args)
+	return args, nil
+}
+
+// ParseStartupArgs parses the flags of a command line (the binary first) into flag name (without dashes) -> value
+// Go flag syntax is accepted: -flag, --flag, -flag=value and -flag value. A flag followed by another flag is
+// a boolean flag set to "true". Arguments that are not flags are ignored
+func ParseStartupArgs(args []string) map[string]string {
+	parsed := make(map[string]string)
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") || arg == "-" || arg == "--" {
+			continue
+		}
+		name := strings.TrimLeft(arg, "-")
+		if eq := strings.Index(name, "="); eq >= 0 {
+			parsed[name[:eq]] = name[eq+1:]
+			continue
+		}
+		if i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			parsed[name] = args[i+1]
+			i++
+			continue
+		}
+		parsed[name] = "true"
+	}
+	return parsed
+}
+
+// sensitiveFlagPattern matches the names of startup flags holding credentials
+var sensitiveFlagPattern = regexp.MustCompile(`(?i)pass(word|wd)?|secret|token|credential|auth`)
+
+// urlCredentialsPattern matches the user:password part of URLs (e.g., http://root:pw@pd:2379)
+var urlCredentialsPattern = regexp.MustCompile(`://([^:/@\s]+):[^@/\s]+@`)
+
+// RedactStartupArgs replaces the values of the flags that look like credentials with RedactedValue:
+// flags named after passwords, secrets, tokens and credentials, and passwords embedded in URLs
+func RedactStartupArgs(args map[string]string) {
+	for name, value := range args {
+		if sensitiveFlagPattern.MatchString(name) {
+			args[name] = RedactedValue
+		} else {
+			args[name] = urlCredentialsPattern.ReplaceAllString(value, "://$1:"+RedactedValue+"@")
+		}
+	}
+}
+
+// StartupArgsFromConfig derives the effective command-line flags of a component that doesn't expose its command line
+// from its configuration: flags maps a flag name to the config key it sets (e.g., TiKV's "data-dir" sets
+// "storage.data-dir"), flat or nested in config. Lists are joined with ",", flags whose key is missing or empty are
+// not returned. Credentials are redacted (see RedactStartupArgs)
+func StartupArgsFromConfig(config types.ParameterMap, flags map[string]string) map[string]string {
+	args := make(map[string]string)
+	for flag, key := range flags {
+		value, ok := lookupConfigKey(config, key)
+		if !ok {
+			continue
+		}
+		if display := formatStartupArg(value); display != "" {
+			args[flag] = display
+		}
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	RedactStartupArgs(args)
+	return args
+}
+
+// lookupConfigKey looks up a dotted key in config, either flat ("storage.data-dir") or nested in a section
+func lookupConfigKey(config types.ParameterMap, key string) (interface{}, bool) {
+	if param, ok := config[key]; ok {
+		return param.Value, true
+	}
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		param, ok := config[strings.Join(parts[:i], ".")]
+		if !ok {
+			continue
+		}
+		value := param.Value
+		for _, part := range parts[i:] {
+			section, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			if value, ok = section[part]; !ok {
+				return nil, false
+			}
+		}
+		return value, true
+	}
+	return nil, false
+}
+
+// formatStartupArg formats a config value as written on a command line
+func formatStartupArg(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			items = append(items, formatStartupArg(item))
+		}
+		return strings.Join(items, ",")
+	case []string:
+		return strings.Join(v, ",")
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -22,17 +22,24 @@ type SysVarExtractor struct {
 	// VardefDir is the directory containing vardef constants
 	VardefDir string
 	// Output stores extracted system variables
-	Output types.SystemVariables
+	Output types.ParameterMap
 	// vardefConsts caches parsed vardef constants
 	vardefConsts map[string]string
+	// setDirectlyVars records the variables defined with SetDirectly: true
+	// The value is the default of their Value field, or nil if it can't be extracted
+	setDirectlyVars map[string]*types.ParameterValue
+	// initVarValues stores the values passed to setGlobal in initVars, by variable name
+	initVarValues map[string]types.ParameterValue
 }
 
 // NewSysVarExtractor creates a new system variable extractor
 func NewSysVarExtractor(vardefDir string) *SysVarExtractor {
 	extractor := &SysVarExtractor{
-		VardefDir:    vardefDir,
-		Output:       make(types.SystemVariables),
-		vardefConsts: make(map[string]string),
+		VardefDir:       vardefDir,
+		Output:          make(types.ParameterMap),
+		vardefConsts:    make(map[string]string),
+		setDirectlyVars: make(map[string]*types.ParameterValue),
+		initVarValues:   make(map[string]types.ParameterValue),
 	}
 	// Parse vardef constants
 	extractor.parseVardefConstants()
@@ -76,11 +83,17 @@ func (e *SysVarExtractor) ExtractFromFile(filePath string) error {
 
 	// Use AST parsing for sysvar.go files
 	ast.Walk(e, node)
+	e.parseSetDirectlyVars(node)
+	e.parseInitVars(node)
 
 	// Use regex as fallback, but with strict filtering to avoid incorrect values
 	// Only add if not already extracted by AST (to avoid overwriting correct values)
 	e.extractSysVarsWithRegex(string(data))
 
+	// SetDirectly variables take their initial value from initVars, which may be in another file
+	// Apply after every file so that the result doesn't depend on the order of the files
+	e.applyInitVars()
+
 	return nil
 }
 
@@ -470,6 +483,121 @@ func (e *SysVarExtractor) extractSysVarFromComposite(compLit *ast.CompositeLit)
 	}
 }
 
+// parseSetDirectlyVars records the global system variables defined with SetDirectly: true
+// Such variables set their value through a custom SetGlobal function, and their initial value is the one
+// passed to setGlobal in initVars rather than the Value field
+// Pattern: {Scope: vardef.ScopeGlobal, Name: vardef.TiDBGCLifetime, Value: ..., SetDirectly: true, SetGlobal: ...}
+func (e *SysVarExtractor) parseSetDirectlyVars(file *ast.File) {
+	ast.Inspect(file, func(n ast.Node) bool {
+		compLit, ok := n.(*ast.CompositeLit)
+		if !ok {
+			return true
+		}
+		var nameExpr, valueExpr ast.Expr
+		setDirectly, hasGlobalScope := false, false
+		for _, elt := range compLit.Elts {
+			kv, ok := elt.(*ast.KeyValueExpr)
+			if !ok {
+				continue
+			}
+			key, ok := kv.Key.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			switch key.Name {
+			case "Scope":
+				hasGlobalScope = e.checkGlobalScope(kv.Value)
+			case "Name":
+				nameExpr = kv.Value
+			case "Value":
+				valueExpr = kv.Value
+			case "SetDirectly":
+				ident, ok := kv.Value.(*ast.Ident)
+				setDirectly = ok && ident.Name == "true"
+			}
+		}
+		if !setDirectly || !hasGlobalScope || nameExpr == nil {
+			return true
+		}
+		varName, ok := e.resolveVarName(nameExpr)
+		if !ok {
+			return true
+		}
+		e.setDirectlyVars[varName] = nil
+		if valueExpr != nil {
+			if val, paramType := e.extractValue(valueExpr); val != nil {
+				e.setDirectlyVars[varName] = &types.ParameterValue{Value: fmt.Sprintf("%v", val), Type: paramType}
+			}
+		}
+		return true
+	})
+}
+
+// parseInitVars extracts the setGlobal(varName, value) calls of the initVars function
+// Pattern: func initVars() { setGlobal(vardef.TiDBGCLifetime, "10m0s") }
+func (e *SysVarExtractor) parseInitVars(file *ast.File) {
+	for _, decl := range file.Decls {
+		funcDecl, ok := decl.(*ast.FuncDecl)
+		if !ok || funcDecl.Name.Name != "initVars" || funcDecl.Body == nil {
+			continue
+		}
+		ast.Inspect(funcDecl.Body, func(n ast.Node) bool {
+			call, ok := n.(*ast.CallExpr)
+			if !ok || len(call.Args) < 2 || !isSetGlobalCall(call.Fun) {
+				return true
+			}
+			varName, ok := e.resolveVarName(call.Args[0])
+			if !ok {
+				return true
+			}
+			if val, paramType := e.extractValue(call.Args[1]); val != nil {
+				e.initVarValues[varName] = types.ParameterValue{Value: fmt.Sprintf("%v", val), Type: paramType}
+			}
+			return true
+		})
+	}
+}
+
+// isSetGlobalCall checks if a call is setGlobal(...) or x.setGlobal(...)
+func isSetGlobalCall(fun ast.Expr) bool {
+	switch f := fun.(type) {
+	case *ast.Ident:
+		return f.Name == "setGlobal"
+	case *ast.SelectorExpr:
+		return f.Sel.Name == "setGlobal"
+	}
+	return false
+}
+
+// resolveVarName resolves a system variable name given as a string literal or a vardef constant
+func (e *SysVarExtractor) resolveVarName(expr ast.Expr) (string, bool) {
+	switch v := expr.(type) {
+	case *ast.BasicLit:
+		if v.Kind == token.STRING {
+			return strings.Trim(v.Value, `"`), true
+		}
+	case *ast.Ident:
+		name, ok := e.vardefConsts[v.Name]
+		return name, ok
+	case *ast.SelectorExpr:
+		name, ok := e.vardefConsts[v.Sel.Name]
+		return name, ok
+	}
+	return "", false
+}
+
+// applyInitVars sets the values of SetDirectly variables from initVars
+// Variables without an initVars entry fall back to their Value field default
+func (e *SysVarExtractor) applyInitVars() {
+	for varName, fallback := range e.setDirectlyVars {
+		if value, ok := e.initVarValues[varName]; ok {
+			e.Output[varName] = value
+		} else if _, exists := e.Output[varName]; !exists && fallback != nil {
+			e.Output[varName] = *fallback
+		}
+	}
+}
+
 // extractValue extracts a value and its type from an AST expression
 func (e *SysVarExtractor) extractValue(expr ast.Expr) (interface{}, string) {
 	switch v := expr.(type) {
@@ -867,6 +995,7 @@ func FindSysVarFiles(tidbRoot, version string) []string {
 		}
 	} else {
 		// TiDB < 7.5 uses sessionctx/ directory (no pkg/)
+		// v5.4 and v6.5 define system variables in sessionctx/variable/sysvar.go and tidb_vars.go (no vardef)
 		searchPaths = []string{
 			filepath.Join(tidbRoot, "sessionctx", "variable", "sysvar.go"),
 			filepath.Join(tidbRoot, "sessionctx", "variable", "tidb_vars.go"),
@@ -0,0 +1,27 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSysVarExtractor_SetDirectly(t *testing.T) {
+	// initVars is parsed before the definitions to check that the file order doesn't matter
+	extractor := NewSysVarExtractor("testdata/sysvar_set_directly/vardef")
+	require.NoError(t, extractor.ExtractFromFile("testdata/sysvar_set_directly/init_vars.go"))
+	require.NoError(t, extractor.ExtractFromFile("testdata/sysvar_set_directly/sysvar.go"))
+
+	// SetDirectly with an initVars entry
+	assert.Equal(t, types.ParameterValue{Value: "30m0s", Type: "string"}, extractor.Output["tidb_gc_life_time"])
+	// SetDirectly with an initVars entry calling strconv
+	assert.Equal(t, types.ParameterValue{Value: "8", Type: "int"}, extractor.Output["tidb_ddl_reorg_worker_cnt"])
+	// SetDirectly with a Value computed at runtime
+	assert.Equal(t, types.ParameterValue{Value: "80%", Type: "string"}, extractor.Output["tidb_server_memory_limit"])
+	// SetDirectly without an initVars entry falls back to the Value field
+	assert.Equal(t, types.ParameterValue{Value: "10m0s", Type: "string"}, extractor.Output["tidb_gc_run_interval"])
+	// Not SetDirectly: setGlobal in initVars is ignored
+	assert.NotEqual(t, "OFF", extractor.Output["tidb_enable_auto_analyze"].Value)
+}
@@ -0,0 +1,9 @@
+package variable
+
+// initVars sets the initial global values of the SetDirectly system variables
+func initVars() {
+	setGlobal(vardef.TiDBGCLifetime, "30m0s")
+	setGlobal(vardef.TiDBServerMemoryLimit, "80%")
+	setGlobal(vardef.TiDBEnableAutoAnalyze, "OFF")
+	vars.setGlobal(vardef.TiDBDDLReorgWorkerCount, strconv.Itoa(vardef.DefTiDBDDLReorgWorkerInit))
+}
@@ -0,0 +1,24 @@
+package variable
+
+var defaultSysVars = []*SysVar{
+	// SetDirectly with an initVars entry: the initVars value is the default
+	{Scope: vardef.ScopeGlobal, Name: vardef.TiDBGCLifetime, Value: vardef.DefTiDBGCLifetime, Type: vardef.TypeDuration, MinValue: int64(time.Minute * 10), MaxValue: uint64(time.Hour * 24 * 365), SetDirectly: true, SetGlobal: func(_ context.Context, s *SessionVars, val string) error {
+		return setTiDBTableValue(s, "tikv_gc_life_time", val, "All versions within life time will not be collected by GC, at least 10m, in Go format.")
+	}},
+	// SetDirectly without an initVars entry: falls back to the Value field
+	{Scope: vardef.ScopeGlobal, Name: vardef.TiDBGCRunInterval, Value: vardef.DefTiDBGCRunInterval, Type: vardef.TypeDuration, MinValue: int64(time.Minute * 10), MaxValue: uint64(time.Hour * 24 * 365), SetDirectly: true, SetGlobal: func(_ context.Context, s *SessionVars, val string) error {
+		return setTiDBTableValue(s, "tikv_gc_run_interval", val, "GC run interval, at least 10m, in Go format.")
+	}},
+	// SetDirectly with a Value computed at runtime: only initVars gives the default
+	{Scope: vardef.ScopeGlobal, Name: vardef.TiDBServerMemoryLimit, Value: memory.ServerMemoryLimitOriginText.Load(), Type: vardef.TypeStr, SetDirectly: true, SetGlobal: func(_ context.Context, s *SessionVars, val string) error {
+		memory.ServerMemoryLimitOriginText.Store(val)
+		return nil
+	}},
+	// Not SetDirectly: initVars doesn't override the Value field
+	{Scope: vardef.ScopeGlobal, Name: vardef.TiDBEnableAutoAnalyze, Value: BoolToOnOff(vardef.DefTiDBEnableAutoAnalyze), Type: vardef.TypeBool, GetGlobal: func(_ context.Context, s *SessionVars) (string, error) {
+		return BoolToOnOff(vardef.RunAutoAnalyze.Load()), nil
+	}},
+	{Scope: vardef.ScopeGlobal, Name: vardef.TiDBDDLReorgWorkerCount, Value: strconv.Itoa(vardef.DefTiDBDDLReorgWorkerCount), Type: vardef.TypeUnsigned, MinValue: 1, MaxValue: vardef.MaxConfigurableConcurrency, SetDirectly: true, SetGlobal: func(_ context.Context, s *SessionVars, val string) error {
+		return setDDLReorgWorkerCounter(s, val)
+	}},
+}
@@ -0,0 +1,19 @@
+package vardef
+
+// System variable names
+const (
+	TiDBGCLifetime          = "tidb_gc_life_time"
+	TiDBGCRunInterval       = "tidb_gc_run_interval"
+	TiDBEnableAutoAnalyze   = "tidb_enable_auto_analyze"
+	TiDBServerMemoryLimit   = "tidb_server_memory_limit"
+	TiDBDDLReorgWorkerCount = "tidb_ddl_reorg_worker_cnt"
+)
+
+// Default values
+const (
+	DefTiDBGCLifetime          = "10m0s"
+	DefTiDBGCRunInterval       = "10m0s"
+	DefTiDBEnableAutoAnalyze   = true
+	DefTiDBDDLReorgWorkerCount = 4
+	DefTiDBDDLReorgWorkerInit  = 8
+)
@@ -0,0 +1,86 @@
+// Trimmed copy of the read pool configs of TiKV src/config/mod.rs
+
+use tikv_util::{
+    config::{ReadableDuration, ReadableSize},
+    sys::SysQuota,
+};
+
+const DEFAULT_READPOOL_MIN_THREAD_COUNT: usize = 1;
+const UNIFIED_READPOOL_MIN_CONCURRENCY: usize = 4;
+
+// Assume a request can be finished in 1ms, a request at position x will wait about
+// 0.001 * x secs to be actual started. A server-is-busy error will trigger 2 seconds
+// backoff. So when it needs to wait for more than 2 seconds, return error won't causse
+// larger latency.
+const DEFAULT_READPOOL_MAX_TASKS_PER_WORKER: usize = 2 * 1000;
+
+const DEFAULT_READPOOL_STACK_SIZE_MB: u64 = 10;
+
+macro_rules! readpool_config {
+    ($struct_name:ident, $test_mod_name:ident, $display_name:expr) => {
+        #[derive(Clone, Copy, Serialize, Deserialize, PartialEq, Debug, OnlineConfig)]
+        #[serde(default)]
+        #[serde(rename_all = "kebab-case")]
+        pub struct $struct_name {
+            pub min_thread_count: usize,
+            pub max_thread_count: usize,
+            pub stack_size: ReadableSize,
+            pub max_tasks_per_worker: usize,
+        }
+
+        impl Default for $struct_name {
+            fn default() -> Self {
+                let cpu_num = SysQuota::cpu_cores_quota();
+                Self {
+                    min_thread_count: DEFAULT_READPOOL_MIN_THREAD_COUNT,
+                    max_thread_count: cmp::max(UNIFIED_READPOOL_MIN_CONCURRENCY, (cpu_num * 0.8) as usize),
+                    stack_size: ReadableSize::mb(DEFAULT_READPOOL_STACK_SIZE_MB),
+                    max_tasks_per_worker: DEFAULT_READPOOL_MAX_TASKS_PER_WORKER,
+                }
+            }
+        }
+
+        impl $struct_name {
+            pub fn validate(&self) -> Result<(), Box<dyn Error>> {
+                if self.min_thread_count == 0 {
+                    return Err(format!("readpool.{}.min-thread-count should be > 0", $display_name).into());
+                }
+                if self.max_thread_count < self.min_thread_count {
+                    return Err(format!(
+                        "readpool.{}.max-thread-count should be >= min-thread-count",
+                        $display_name
+                    )
+                    .into());
+                }
+                Ok(())
+            }
+        }
+
+        #[cfg(test)]
+        mod $test_mod_name {
+            use super::*;
+
+            #[test]
+            fn test_validate() {
+                let cfg = $struct_name::default();
+                cfg.validate().unwrap();
+            }
+        }
+    };
+}
+
+readpool_config!(UnifiedReadPoolConfig, unified_read_pool_test, "unified");
+readpool_config!(StorageReadPoolConfig, storage_read_pool_test, "storage");
+readpool_config!(CoprReadPoolConfig, coprocessor_read_pool_test, "coprocessor");
+
+#[derive(Clone, Serialize, Deserialize, Default, PartialEq, Debug, OnlineConfig)]
+#[serde(default)]
+#[serde(rename_all = "kebab-case")]
+pub struct ReadPoolConfig {
+    #[online_config(submodule)]
+    pub unified: UnifiedReadPoolConfig,
+    #[online_config(skip)]
+    pub storage: StorageReadPoolConfig,
+    #[online_config(skip)]
+    pub coprocessor: CoprReadPoolConfig,
+}
@@ -0,0 +1,105 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultCollectionRateLimit is the default maximum number of HTTP requests per second sent to PD and TiKV
+	DefaultCollectionRateLimit = 50
+	// DefaultCollectionConcurrency is the default maximum number of nodes collected from concurrently
+	DefaultCollectionConcurrency = 10
+)
+
+// Throttle limits the load collection puts on a cluster: the rate of HTTP requests sent to the
+// component APIs, and the number of nodes collected from concurrently
+// A nil Throttle does not limit anything
+type Throttle struct {
+	limiter *rate.Limiter
+	slots   chan struct{}
+}
+
+// NewThrottle creates a throttle allowing requestsPerSecond HTTP requests per second (unlimited if <= 0)
+// and concurrency nodes collected at the same time (unlimited if <= 0)
+func NewThrottle(requestsPerSecond float64, concurrency int) *Throttle {
+	t := &Throttle{}
+	if requestsPerSecond > 0 {
+		// A burst of 1 spreads the requests evenly instead of sending a second's worth at once
+		t.limiter = rate.NewLimiter(rate.Limit(requestsPerSecond), 1)
+	}
+	if concurrency > 0 {
+		t.slots = make(chan struct{}, concurrency)
+	}
+	return t
+}
+
+// NewDefaultThrottle creates a throttle with the default rate limit and concurrency
+func NewDefaultThrottle() *Throttle {
+	return NewThrottle(DefaultCollectionRateLimit, DefaultCollectionConcurrency)
+}
+
+// Wait blocks until the rate limit allows one more request, or ctx is done
+func (t *Throttle) Wait(ctx context.Context) error {
+	if t == nil || t.limiter == nil {
+		return nil
+	}
+	return t.limiter.Wait(ctx)
+}
+
+// Acquire blocks until a node collection slot is free
+// Every Acquire must be followed by a Release
+func (t *Throttle) Acquire() {
+	if t == nil || t.slots == nil {
+		return
+	}
+	t.slots <- struct{}{}
+}
+
+// Release frees a node collection slot taken by Acquire
+func (t *Throttle) Release() {
+	if t == nil || t.slots == nil {
+		return
+	}
+	<-t.slots
+}
+
+// Concurrency returns the maximum number of nodes collected at the same time (0 if unlimited)
+func (t *Throttle) Concurrency() int {
+	if t == nil {
+		return 0
+	}
+	return cap(t.slots)
+}
+
+// NewThrottledHTTPClient creates an HTTP client that waits for the throttle's rate limit before each request
+func NewThrottledHTTPClient(timeout time.Duration, throttle *Throttle) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &throttledTransport{
+			base:     http.DefaultTransport,
+			throttle: throttle,
+		},
+	}
+}
+
+// throttledTransport is an http.RoundTripper applying a Throttle's rate limit
+type throttledTransport struct {
+	base     http.RoundTripper
+	throttle *Throttle
+}
+
+// RoundTrip waits for the rate limit, then sends the request with the base transport
+func (t *throttledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.throttle.Wait(req.Context()); err != nil {
+		// RoundTrippers must close the request body, even on errors
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	return t.base.RoundTrip(req)
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestThrottledHTTPClient_RateLimit(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	// 20 requests per second: 5 requests take at least 4 intervals of 50ms
+	client := NewThrottledHTTPClient(5*time.Second, NewThrottle(20, 0))
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 190*time.Millisecond)
+	assert.Equal(t, int32(5), requests.Load())
+}
+
+func TestThrottledHTTPClient_ContextCanceled(t *testing.T) {
+	throttle := NewThrottle(0.001, 0)
+	// The first request uses the burst, the next one would wait for ~1000s
+	require.NoError(t, throttle.Wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://127.0.0.1:1", nil)
+	require.NoError(t, err)
+	_, err = NewThrottledHTTPClient(time.Second, throttle).Do(req)
+	assert.Error(t, err)
+}
+
+func TestThrottle_Concurrency(t *testing.T) {
+	throttle := NewThrottle(0, 2)
+	assert.Equal(t, 2, throttle.Concurrency())
+
+	var running, maxRunning atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			throttle.Acquire()
+			defer throttle.Release()
+			n := running.Add(1)
+			for {
+				max := maxRunning.Load()
+				if n <= max || maxRunning.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			running.Add(-1)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, maxRunning.Load(), int32(2))
+}
+
+func TestThrottle_Nil(t *testing.T) {
+	var throttle *Throttle
+	assert.NoError(t, throttle.Wait(context.Background()))
+	throttle.Acquire()
+	throttle.Release()
+	assert.Equal(t, 0, throttle.Concurrency())
+}
@@ -0,0 +1,129 @@
+package collector
+
+import (
+	"container/list"
+	"os"
+	"strconv"
+	"sync"
+)
+
+const (
+	// KBCacheSizeEnv is the environment variable that overrides the knowledge base cache size
+	// A size of 0 disables caching
+	KBCacheSizeEnv = "TIDB_UPGRADE_PRECHECK_KB_CACHE_SIZE"
+	// defaultKBCacheSize is the maximum number of cached knowledge bases (path + version)
+	defaultKBCacheSize = 10
+)
+
+// knowledgeBaseCache is an in-process LRU cache of loaded knowledge bases
+// Entries are stored in a sync.Map so that cache hits do not contend with loads of other versions,
+// the mutex only protects the recency list used for eviction
+type knowledgeBaseCache struct {
+	size    int
+	entries sync.Map // key -> map[string]interface{}
+
+	mu       sync.Mutex
+	order    *list.List // front is the most recently used key
+	elements map[string]*list.Element
+}
+
+func newKnowledgeBaseCache(size int) *knowledgeBaseCache {
+	return &knowledgeBaseCache{
+		size:     size,
+		order:    list.New(),
+		elements: make(map[string]*list.Element),
+	}
+}
+
+var (
+	kbCacheMu sync.RWMutex
+	kbCache   = newKnowledgeBaseCache(kbCacheSizeFromEnv())
+)
+
+// kbCacheSizeFromEnv returns the cache size configured by KBCacheSizeEnv, or the default size
+func kbCacheSizeFromEnv() int {
+	if value := os.Getenv(KBCacheSizeEnv); value != "" {
+		if size, err := strconv.Atoi(value); err == nil && size >= 0 {
+			return size
+		}
+	}
+	return defaultKBCacheSize
+}
+
+func (c *knowledgeBaseCache) get(key string) (map[string]interface{}, bool) {
+	value, ok := c.entries.Load(key)
+	if !ok {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	if element, exists := c.elements[key]; exists {
+		c.order.MoveToFront(element)
+	}
+	c.mu.Unlock()
+	return value.(map[string]interface{}), true
+}
+
+func (c *knowledgeBaseCache) put(key string, kb map[string]interface{}) {
+	if c.size <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries.Store(key, kb)
+	if element, exists := c.elements[key]; exists {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.elements[key] = c.order.PushFront(key)
+
+	// Evict the least recently used entries
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		oldestKey := oldest.Value.(string)
+		c.order.Remove(oldest)
+		delete(c.elements, oldestKey)
+		c.entries.Delete(oldestKey)
+	}
+}
+
+func (c *knowledgeBaseCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// LoadKnowledgeBase loads knowledge base for all components (tidb, pd, tikv, tiflash) for a specific version
+// Returns a map with component keys containing config_defaults, system_variables, and upgrade_logic
+// Also loads global high_risk_params configuration (high_risk_params.json)
+// This function loads the knowledge base that was generated by the kbgenerator
+//
+// Loaded knowledge bases are cached in-process (keyed on knowledgeBasePath+version), so repeated calls
+// for the same version do not re-parse the JSON files. The cached map is returned directly and must not be modified.
+func LoadKnowledgeBase(knowledgeBasePath, version string) (map[string]interface{}, error) {
+	kbCacheMu.RLock()
+	cache := kbCache
+	kbCacheMu.RUnlock()
+
+	key := knowledgeBasePath + "@" + version
+	if kb, ok := cache.get(key); ok {
+		return kb, nil
+	}
+
+	kb, err := loadKnowledgeBaseFromDisk(knowledgeBasePath, version)
+	if err != nil {
+		return nil, err
+	}
+	cache.put(key, kb)
+	return kb, nil
+}
+
+// ClearKBCache drops all cached knowledge bases
+// The cache size is re-read from KBCacheSizeEnv, so tests can change it
+func ClearKBCache() {
+	kbCacheMu.Lock()
+	defer kbCacheMu.Unlock()
+	kbCache = newKnowledgeBaseCache(kbCacheSizeFromEnv())
+}
@@ -0,0 +1,135 @@
+package collector
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sameMap reports whether two maps are the same map instance
+func sameMap(a, b map[string]interface{}) bool {
+	return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+func TestLoadKnowledgeBase_Cache(t *testing.T) {
+	ClearKBCache()
+	defer ClearKBCache()
+
+	kbPath := t.TempDir()
+	writeTestDefaults(t, kbPath, "v7.5.0", "tidb", map[string]interface{}{
+		"config_defaults": map[string]interface{}{"a": 1},
+	})
+
+	first, err := LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+	require.Contains(t, first, "tidb")
+
+	// Changes on disk are not seen until the cache is cleared
+	writeTestDefaults(t, kbPath, "v7.5.0", "pd", map[string]interface{}{
+		"config_defaults": map[string]interface{}{"b": 2},
+	})
+	second, err := LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+	assert.True(t, sameMap(first, second))
+	assert.NotContains(t, second, "pd")
+
+	ClearKBCache()
+	third, err := LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+	assert.False(t, sameMap(first, third))
+	assert.Contains(t, third, "pd")
+}
+
+func TestLoadKnowledgeBase_CacheEviction(t *testing.T) {
+	t.Setenv(KBCacheSizeEnv, "2")
+	ClearKBCache()
+	defer ClearKBCache()
+
+	kbPath := t.TempDir()
+	v1, err := LoadKnowledgeBase(kbPath, "v7.1.0")
+	require.NoError(t, err)
+	_, err = LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+
+	// Use v7.1.0 so that v7.5.0 is the least recently used entry
+	again, err := LoadKnowledgeBase(kbPath, "v7.1.0")
+	require.NoError(t, err)
+	assert.True(t, sameMap(v1, again))
+
+	_, err = LoadKnowledgeBase(kbPath, "v8.5.0")
+	require.NoError(t, err)
+	assert.Equal(t, 2, kbCache.len())
+
+	_, ok := kbCache.get(kbPath + "@v7.1.0")
+	assert.True(t, ok)
+	_, ok = kbCache.get(kbPath + "@v7.5.0")
+	assert.False(t, ok, "least recently used entry is evicted")
+}
+
+func TestLoadKnowledgeBase_CacheDisabled(t *testing.T) {
+	t.Setenv(KBCacheSizeEnv, "0")
+	ClearKBCache()
+	defer ClearKBCache()
+
+	kbPath := t.TempDir()
+	first, err := LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+	second, err := LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+	assert.False(t, sameMap(first, second))
+	assert.Equal(t, 0, kbCache.len())
+}
+
+// benchKnowledgeBasePath is the knowledge base shipped with the repository
+var benchKnowledgeBasePath = filepath.Join("..", "..", "knowledge")
+
+func BenchmarkLoadKnowledgeBase_CacheMiss(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ClearKBCache()
+		if _, err := LoadKnowledgeBase(benchKnowledgeBasePath, "v7.5.0"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoadKnowledgeBase_CacheHit(b *testing.B) {
+	ClearKBCache()
+	if _, err := LoadKnowledgeBase(benchKnowledgeBasePath, "v7.5.0"); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := LoadKnowledgeBase(benchKnowledgeBasePath, "v7.5.0"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestLoadKnowledgeBase_PortablePath(t *testing.T) {
+	ClearKBCache()
+	defer ClearKBCache()
+
+	// A knowledge base copied to a directory with a space, as under a Windows or macOS user profile
+	kbPath := filepath.Join(t.TempDir(), "upgrade precheck", "knowledge")
+	writeTestDefaults(t, kbPath, "v7.5.0", "tidb", map[string]interface{}{
+		"config_defaults":  map[string]interface{}{"log.level": "info"},
+		"system_variables": map[string]interface{}{"tidb_txn_mode": "pessimistic"},
+	})
+	writeTestDefaults(t, kbPath, "v7.5.0", "tikv", map[string]interface{}{
+		"config_defaults": map[string]interface{}{"storage.reserve-space": "5GiB"},
+	})
+
+	kb, err := LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+	require.Contains(t, kb, "tidb")
+	require.Contains(t, kb, "tikv")
+	tidb, ok := kb["tidb"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Contains(t, tidb, "config_defaults")
+	assert.Contains(t, tidb, "system_variables")
+}
@@ -0,0 +1,299 @@
+package collector
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// KBAudit compares the defaults of a component in the knowledge base with its documented defaults
+type KBAudit struct {
+	Component string `json:"component"`
+	Version   string `json:"version"`
+	// DocumentedCount and KBCount are the number of documented and knowledge base parameters
+	DocumentedCount int `json:"documented_count"`
+	KBCount         int `json:"kb_count"`
+	// Mismatched are the parameters whose documented default differs from the extracted one
+	Mismatched []KBAuditEntry `json:"mismatched"`
+	// DocumentedOnly are the documented parameters not found in the knowledge base
+	DocumentedOnly []KBAuditEntry `json:"documented_only"`
+	// Undocumented are the knowledge base parameters no documented parameter matched
+	Undocumented []KBAuditEntry `json:"undocumented"`
+}
+
+// KBAuditEntry is a parameter of the audit
+type KBAuditEntry struct {
+	// Name is the knowledge base name (system variables use the "sysvar:" prefix), empty if not found
+	Name string `json:"name,omitempty"`
+	// DocName is the name in the documented defaults, empty if undocumented
+	DocName string `json:"doc_name,omitempty"`
+	// Type is the value type recorded in defaults.json
+	Type       string      `json:"type,omitempty"`
+	KBValue    interface{} `json:"kb_value,omitempty"`
+	DocValue   interface{} `json:"doc_value,omitempty"`
+	Candidates []string    `json:"candidates,omitempty"`
+}
+
+// Clean checks if the knowledge base and the documentation agree on every parameter
+func (a *KBAudit) Clean() bool {
+	return len(a.Mismatched) == 0 && len(a.DocumentedOnly) == 0 && len(a.Undocumented) == 0
+}
+
+// LoadDocumentedDefaults reads documented defaults, a name -> value map exported by the docs team
+// A .csv file has one "name,value" row per parameter (an optional "name,value" header, extra columns
+// and # comments are ignored), other files are a JSON object
+func LoadDocumentedDefaults(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read documented defaults %s: %w", path, err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		reader := csv.NewReader(strings.NewReader(string(data)))
+		reader.Comment = '#'
+		reader.FieldsPerRecord = -1
+		reader.TrimLeadingSpace = true
+		documented := make(map[string]interface{})
+		for line := 1; ; line++ {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse documented defaults %s: %w", path, err)
+			}
+			if len(record) < 2 {
+				return nil, fmt.Errorf("documented defaults %s: row %d has no value column", path, line)
+			}
+			name := strings.TrimSpace(record[0])
+			if line == 1 && strings.EqualFold(name, "name") {
+				continue
+			}
+			if name != "" {
+				documented[name] = strings.TrimSpace(record[1])
+			}
+		}
+		return documented, nil
+	}
+
+	var documented map[string]interface{}
+	if err := json.Unmarshal(data, &documented); err != nil {
+		return nil, fmt.Errorf("failed to parse documented defaults %s: %w", path, err)
+	}
+	return documented, nil
+}
+
+// kbAuditParam is a knowledge base parameter matched against the documented names
+type kbAuditParam struct {
+	name     string // with the "sysvar:" prefix for system variables
+	bareName string
+	sysvar   bool
+	value    kbDefaultValue
+	matched  bool
+}
+
+// AuditKnowledgeBaseDefaults compares the defaults.json of a component with its documented defaults
+// Documented names are matched with the knowledge base names, in order:
+//   - the exact name ("sysvar:" restricts the match to system variables, a "<component>." prefix is ignored)
+//   - the name ignoring case and dashes vs underscores
+//   - the name without its section prefix (e.g., "max-procs" for "performance.max-procs"), if only one parameter has it
+//
+// Values are compared after normalization to the type of the knowledge base parameter (see types.NormalizeParamValue)
+func AuditKnowledgeBaseDefaults(knowledgeBasePath, component, version string, documented map[string]interface{}) (*KBAudit, error) {
+	defaults, err := readKBDefaultsValues(knowledgeBasePath, version, component)
+	if err != nil {
+		return nil, err
+	}
+	if defaults == nil {
+		return nil, fmt.Errorf("knowledge base for %s %s not found in %s", component, version, knowledgeBasePath)
+	}
+
+	var params []*kbAuditParam
+	for _, name := range sortedKeys(defaults.ConfigDefaults) {
+		params = append(params, &kbAuditParam{name: name, bareName: name, value: defaults.ConfigDefaults[name]})
+	}
+	for _, name := range sortedKeys(defaults.SystemVariables) {
+		params = append(params, &kbAuditParam{name: "sysvar:" + name, bareName: name, sysvar: true, value: defaults.SystemVariables[name]})
+	}
+
+	audit := &KBAudit{Component: component, Version: version, DocumentedCount: len(documented), KBCount: len(params)}
+	docNames := make([]string, 0, len(documented))
+	for name := range documented {
+		docNames = append(docNames, name)
+	}
+	sort.Strings(docNames)
+
+	for _, docName := range docNames {
+		docValue := documented[docName]
+		param, candidates := matchDocumentedName(docName, component, params)
+		if param == nil {
+			audit.DocumentedOnly = append(audit.DocumentedOnly, KBAuditEntry{DocName: docName, DocValue: docValue, Candidates: candidates})
+			continue
+		}
+		param.matched = true
+		if !documentedValueAgrees(param.value, docValue) {
+			audit.Mismatched = append(audit.Mismatched, KBAuditEntry{
+				Name: param.name, DocName: docName, Type: param.value.Type, KBValue: param.value.Value, DocValue: docValue,
+			})
+		}
+	}
+	for _, param := range params {
+		if !param.matched {
+			audit.Undocumented = append(audit.Undocumented, KBAuditEntry{Name: param.name, Type: param.value.Type, KBValue: param.value.Value})
+		}
+	}
+	return audit, nil
+}
+
+// matchDocumentedName finds the knowledge base parameter of a documented name
+// Returns the candidates instead if a heuristic matches several parameters
+func matchDocumentedName(docName, component string, params []*kbAuditParam) (*kbAuditParam, []string) {
+	name := strings.TrimSpace(docName)
+	sysvarOnly := false
+	if strings.HasPrefix(name, "sysvar:") {
+		name = strings.TrimPrefix(name, "sysvar:")
+		sysvarOnly = true
+	}
+	name = strings.TrimPrefix(name, component+".")
+	key := auditNameKey(name)
+
+	matchers := []func(param *kbAuditParam) bool{
+		func(param *kbAuditParam) bool { return param.bareName == name },
+		func(param *kbAuditParam) bool { return auditNameKey(param.bareName) == key },
+		func(param *kbAuditParam) bool { return strings.HasSuffix(auditNameKey(param.bareName), "."+key) },
+	}
+	for _, matches := range matchers {
+		var found []*kbAuditParam
+		for _, param := range params {
+			if (!sysvarOnly || param.sysvar) && matches(param) {
+				found = append(found, param)
+			}
+		}
+		// An exact config name wins over a system variable of the same name
+		if len(found) == 1 || (len(found) > 1 && found[0].bareName == name && !found[0].sysvar && found[1].sysvar) {
+			return found[0], nil
+		}
+		if len(found) > 1 {
+			candidates := make([]string, len(found))
+			for i, param := range found {
+				candidates[i] = param.name
+			}
+			return nil, candidates
+		}
+	}
+	return nil, nil
+}
+
+// auditNameKey is the name used to match documented and knowledge base names, ignoring case and dashes vs underscores
+func auditNameKey(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "-", "_")
+}
+
+// documentedValueAgrees checks if a documented value is the knowledge base default
+// Both values are normalized to the type of the parameter; values that cannot be normalized
+// (sizes, durations) are compared as text, ignoring case. Unset and empty values are equal
+func documentedValueAgrees(kbValue kbDefaultValue, docValue interface{}) bool {
+	if isEmptyAuditValue(kbValue.Value) && isEmptyAuditValue(docValue) {
+		return true
+	}
+	kbNormalized := types.NormalizeParamValue(kbValue.Value, kbValue.Type)
+	docNormalized := types.NormalizeParamValue(docValue, kbValue.Type)
+	if reflect.DeepEqual(kbNormalized, docNormalized) {
+		return true
+	}
+	return strings.EqualFold(FormatAuditValue(kbNormalized), FormatAuditValue(docNormalized))
+}
+
+// isEmptyAuditValue checks if a value is unset or an empty string
+func isEmptyAuditValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+	s, ok := value.(string)
+	return ok && strings.TrimSpace(s) == ""
+}
+
+// FormatAuditValue formats a default value for the audit report: strings as is, other values as JSON
+func FormatAuditValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	if s, ok := value.(string); ok {
+		return strings.TrimSpace(s)
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(data)
+}
+
+// WriteMarkdown writes the audit as a markdown report, suitable for filing issues against the docs or the extractor
+func (a *KBAudit) WriteMarkdown(w io.Writer, docsSource string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Knowledge base audit: %s %s\n\n", a.Component, a.Version)
+	fmt.Fprintf(&b, "Compared %d documented defaults (%s) with the %d parameters of the knowledge base.\n\n", a.DocumentedCount, docsSource, a.KBCount)
+	b.WriteString("| Finding | Count |\n|---|---|\n")
+	fmt.Fprintf(&b, "| Extracted and documented defaults disagree | %d |\n", len(a.Mismatched))
+	fmt.Fprintf(&b, "| Documented but missing from the knowledge base | %d |\n", len(a.DocumentedOnly))
+	fmt.Fprintf(&b, "| Not documented | %d |\n", len(a.Undocumented))
+
+	if len(a.Mismatched) > 0 {
+		b.WriteString("\n## Extracted and documented defaults disagree\n\n")
+		b.WriteString("| Parameter | Documented as | Type | Knowledge base default | Documented default |\n|---|---|---|---|---|\n")
+		for _, entry := range a.Mismatched {
+			writeAuditRow(&b, "`"+entry.Name+"`", "`"+entry.DocName+"`", entry.Type, FormatAuditValue(entry.KBValue), FormatAuditValue(entry.DocValue))
+		}
+	}
+	if len(a.DocumentedOnly) > 0 {
+		b.WriteString("\n## Documented but missing from the knowledge base\n\n")
+		b.WriteString("| Documented name | Documented default | Note |\n|---|---|---|\n")
+		for _, entry := range a.DocumentedOnly {
+			note := "no matching parameter"
+			if len(entry.Candidates) > 0 {
+				note = "ambiguous, matches " + strings.Join(entry.Candidates, ", ")
+			}
+			writeAuditRow(&b, "`"+entry.DocName+"`", FormatAuditValue(entry.DocValue), note)
+		}
+	}
+	if len(a.Undocumented) > 0 {
+		b.WriteString("\n## Not documented\n\n")
+		b.WriteString("| Parameter | Type | Knowledge base default |\n|---|---|---|\n")
+		for _, entry := range a.Undocumented {
+			writeAuditRow(&b, "`"+entry.Name+"`", entry.Type, FormatAuditValue(entry.KBValue))
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// writeAuditRow writes a markdown table row, escaping the cells
+func writeAuditRow(b *strings.Builder, cells ...string) {
+	for i, cell := range cells {
+		cell = strings.ReplaceAll(strings.ReplaceAll(cell, "|", "\\|"), "\n", " ")
+		if cell == "" {
+			cell = "-"
+		}
+		cells[i] = cell
+	}
+	b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+}
+
+// sortedKeys returns the keys of a defaults map, sorted
+func sortedKeys(values map[string]kbDefaultValue) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
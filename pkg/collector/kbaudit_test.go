@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAuditTestKB(t *testing.T) string {
+	kbPath := t.TempDir()
+	writeTestDefaults(t, kbPath, "v8.5.0", "tidb", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"log.level":             map[string]interface{}{"value": "info", "type": "string"},
+			"performance.max-procs": map[string]interface{}{"value": 0, "type": "int"},
+			"mem-quota-query":       map[string]interface{}{"value": 1073741824, "type": "int"},
+			"split-table":           map[string]interface{}{"value": true, "type": "bool"},
+			"security.ssl-ca":       map[string]interface{}{"value": "", "type": "string"},
+			"log.file.max-size":     map[string]interface{}{"value": 300, "type": "int"},
+			"log.slow-query-file":   map[string]interface{}{"value": "tidb-slow.log", "type": "string"},
+		},
+		"system_variables": map[string]interface{}{
+			"tidb_txn_mode":            map[string]interface{}{"value": "pessimistic", "type": "string"},
+			"tidb_enable_async_commit": map[string]interface{}{"value": "ON", "type": "bool"},
+			"max-size":                 map[string]interface{}{"value": 10, "type": "int"},
+		},
+	})
+	return kbPath
+}
+
+func TestAuditKnowledgeBaseDefaults(t *testing.T) {
+	kbPath := writeAuditTestKB(t)
+	documented := map[string]interface{}{
+		"log.level":                  "INFO",          // agrees, case-insensitive
+		"tidb.performance.max-procs": "0",             // component prefix, numeric string
+		"mem_quota_query":            "1073741824",    // underscores instead of dashes
+		"split-table":                "false",         // disagrees
+		"security.ssl-ca":            nil,             // unset and empty agree
+		"sysvar:tidb_txn_mode":       "optimistic",    // disagrees
+		"tidb_enable_async_commit":   1,               // bool normalization
+		"slow-query-file":            "tidb-slow.log", // section prefix omitted
+		"max-size":                   "300",           // exact system variable name wins over the suffix match
+		"max_size":                   "10",            // normalized name: the system variable
+		"removed-option":             "1",             // not in the knowledge base
+	}
+
+	audit, err := AuditKnowledgeBaseDefaults(kbPath, "tidb", "v8.5.0", documented)
+	require.NoError(t, err)
+	assert.Equal(t, 11, audit.DocumentedCount)
+	assert.Equal(t, 10, audit.KBCount)
+
+	mismatched := make(map[string]KBAuditEntry)
+	for _, entry := range audit.Mismatched {
+		mismatched[entry.Name] = entry
+	}
+	assert.Len(t, mismatched, 3)
+	assert.Equal(t, "false", mismatched["split-table"].DocValue)
+	assert.Equal(t, true, mismatched["split-table"].KBValue)
+	assert.Equal(t, "sysvar:tidb_txn_mode", mismatched["sysvar:tidb_txn_mode"].DocName)
+	assert.Equal(t, "max-size", mismatched["sysvar:max-size"].DocName)
+
+	if assert.Len(t, audit.DocumentedOnly, 1) {
+		assert.Equal(t, "removed-option", audit.DocumentedOnly[0].DocName)
+	}
+	if assert.Len(t, audit.Undocumented, 1) {
+		assert.Equal(t, "log.file.max-size", audit.Undocumented[0].Name)
+	}
+	assert.False(t, audit.Clean())
+
+	_, err = AuditKnowledgeBaseDefaults(kbPath, "tikv", "v8.5.0", documented)
+	assert.ErrorContains(t, err, "knowledge base for tikv v8.5.0 not found")
+}
+
+func TestAuditKnowledgeBaseDefaults_AmbiguousSuffix(t *testing.T) {
+	kbPath := t.TempDir()
+	writeTestDefaults(t, kbPath, "v8.5.0", "tikv", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"rocksdb.defaultcf.block-size": map[string]interface{}{"value": "32KiB", "type": "string"},
+			"rocksdb.writecf.block-size":   map[string]interface{}{"value": "32KiB", "type": "string"},
+		},
+	})
+
+	audit, err := AuditKnowledgeBaseDefaults(kbPath, "tikv", "v8.5.0", map[string]interface{}{
+		"block-size":                   "32KiB",
+		"rocksdb.defaultcf.block-size": "32kib",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, audit.Mismatched, "sizes are compared as text, ignoring case")
+	if assert.Len(t, audit.DocumentedOnly, 1) {
+		assert.Equal(t, []string{"rocksdb.defaultcf.block-size", "rocksdb.writecf.block-size"}, audit.DocumentedOnly[0].Candidates)
+	}
+	if assert.Len(t, audit.Undocumented, 1) {
+		assert.Equal(t, "rocksdb.writecf.block-size", audit.Undocumented[0].Name)
+	}
+
+	var report strings.Builder
+	require.NoError(t, audit.WriteMarkdown(&report, "docs.csv"))
+	assert.Contains(t, report.String(), "# Knowledge base audit: tikv v8.5.0")
+	assert.Contains(t, report.String(), "| `block-size` | 32KiB | ambiguous, matches rocksdb.defaultcf.block-size, rocksdb.writecf.block-size |")
+	assert.Contains(t, report.String(), "| `rocksdb.writecf.block-size` | string | 32KiB |")
+	assert.NotContains(t, report.String(), "## Extracted and documented defaults disagree")
+}
+
+func TestLoadDocumentedDefaults(t *testing.T) {
+	dir := t.TempDir()
+	csvPath := filepath.Join(dir, "docs.csv")
+	require.NoError(t, os.WriteFile(csvPath, []byte("name,value,description\n# exported from the docs\nlog.level, info,Log level\n\"tidb_txn_mode\",\"pessimistic\"\n"), 0644))
+	documented, err := LoadDocumentedDefaults(csvPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"log.level": "info", "tidb_txn_mode": "pessimistic"}, documented)
+
+	jsonPath := filepath.Join(dir, "docs.json")
+	require.NoError(t, os.WriteFile(jsonPath, []byte(`{"log.level": "info", "mem-quota-query": 1073741824, "split-table": true}`), 0644))
+	documented, err = LoadDocumentedDefaults(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"log.level": "info", "mem-quota-query": float64(1073741824), "split-table": true}, documented)
+
+	require.NoError(t, os.WriteFile(csvPath, []byte("log.level\n"), 0644))
+	_, err = LoadDocumentedDefaults(csvPath)
+	assert.ErrorContains(t, err, "row 1 has no value column")
+	_, err = LoadDocumentedDefaults(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// kbComparisonCacheSchemaVersion is the format version of cached diff entries
+// Bump it whenever KBComparison or the entry layout changes, so entries written by older builds are ignored
+const kbComparisonCacheSchemaVersion = 1
+
+// DefaultKBCacheMaxAge is the age after which cached entries are evicted
+const DefaultKBCacheMaxAge = 30 * 24 * time.Hour
+
+// kbComparisonCachePrefix is the file name prefix of cached diff entries
+const kbComparisonCachePrefix = "kb-diff-"
+
+// KBComparisonCache is a content-addressed cache of knowledge base diffs (see DiffKnowledgeBaseVersionsCached)
+// Entries are keyed by the hashes of the compared defaults.json files, so they are reused
+// as long as the knowledge base files are unchanged, whatever their path or modification time
+type KBComparisonCache struct {
+	// Dir is the cache directory
+	Dir string
+	// MaxAge is the age after which entries are ignored and evicted
+	MaxAge time.Duration
+}
+
+// kbComparisonCacheEntry is the JSON content of a cached diff
+type kbComparisonCacheEntry struct {
+	SchemaVersion int           `json:"schema_version"`
+	CreatedAt     time.Time     `json:"created_at"`
+	VersionHashes []string      `json:"version_hashes"`
+	Comparison    *KBComparison `json:"comparison"`
+}
+
+// DefaultKBCacheDir returns the default cache directory (~/.cache/tidb-upgrade-precheck on Linux)
+func DefaultKBCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(dir, "tidb-upgrade-precheck"), nil
+}
+
+// NewKBComparisonCache creates a cache in dir with the default max age
+func NewKBComparisonCache(dir string) *KBComparisonCache {
+	return &KBComparisonCache{Dir: dir, MaxAge: DefaultKBCacheMaxAge}
+}
+
+// DiffKnowledgeBaseVersionsCached compares knowledge base versions and keeps only the changed parameters
+// (see KBComparison.ChangedOnly), reusing a cached result if the defaults.json files are unchanged
+// On a cache hit the defaults.json files are only hashed, not parsed, and the (much smaller) diff is read back
+// If cache is nil, the diff is always computed
+// Cache failures are not fatal: the diff is computed and returned anyway
+func DiffKnowledgeBaseVersionsCached(knowledgeBasePath string, versions []string, cache *KBComparisonCache) (*KBComparison, error) {
+	if cache == nil {
+		comparison, err := CompareKnowledgeBaseVersions(knowledgeBasePath, versions)
+		if err != nil {
+			return nil, err
+		}
+		return comparison.ChangedOnly(), nil
+	}
+
+	versionHashes := make([]string, 0, len(versions))
+	for _, version := range versions {
+		hash, err := hashKBVersion(knowledgeBasePath, version)
+		if err != nil {
+			return nil, err
+		}
+		versionHashes = append(versionHashes, hash)
+	}
+	key := kbComparisonCacheKey(versions, versionHashes)
+
+	if diff := cache.load(key); diff != nil {
+		return diff, nil
+	}
+
+	comparison, err := CompareKnowledgeBaseVersions(knowledgeBasePath, versions)
+	if err != nil {
+		return nil, err
+	}
+	diff := comparison.ChangedOnly()
+	if err := cache.store(key, versionHashes, diff); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to write kb-diff cache: %v\n", err)
+	}
+	return diff, nil
+}
+
+// hashKBVersion hashes the defaults.json files of all components of a version
+// Missing components are part of the hash, so adding a component changes it
+func hashKBVersion(knowledgeBasePath, version string) (string, error) {
+	h := sha256.New()
+	for _, component := range kbComponents {
+		path := filepath.Join(knowledgeBasePath, getVersionGroup(version), version, component, "defaults.json")
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				fmt.Fprintf(h, "%s:absent\n", component)
+				continue
+			}
+			return "", fmt.Errorf("failed to read defaults file %s: %w", path, err)
+		}
+		sum := sha256.Sum256(data)
+		fmt.Fprintf(h, "%s:%s\n", component, hex.EncodeToString(sum[:]))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// kbComparisonCacheKey combines the schema version, the version names and their content hashes
+// Version names are part of the key because they are part of the comparison (column names)
+func kbComparisonCacheKey(versions, versionHashes []string) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "schema:%d\n", kbComparisonCacheSchemaVersion)
+	for i, version := range versions {
+		fmt.Fprintf(h, "%s:%s\n", version, versionHashes[i])
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// entryPath returns the file path of a cache entry
+func (c *KBComparisonCache) entryPath(key string) string {
+	return filepath.Join(c.Dir, kbComparisonCachePrefix+key+".json")
+}
+
+// load returns the cached diff, or nil if there is no usable entry
+func (c *KBComparisonCache) load(key string) *KBComparison {
+	data, err := os.ReadFile(c.entryPath(key))
+	if err != nil {
+		return nil
+	}
+	var entry kbComparisonCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil
+	}
+	if entry.SchemaVersion != kbComparisonCacheSchemaVersion || entry.Comparison == nil || c.expired(entry.CreatedAt) {
+		return nil
+	}
+	return entry.Comparison
+}
+
+// store writes a cache entry and evicts expired entries
+// The entry is written to a temporary file and renamed, so concurrent CI jobs never read a partial entry
+func (c *KBComparisonCache) store(key string, versionHashes []string, comparison *KBComparison) error {
+	if err := os.MkdirAll(c.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory %s: %w", c.Dir, err)
+	}
+	data, err := json.Marshal(kbComparisonCacheEntry{
+		SchemaVersion: kbComparisonCacheSchemaVersion,
+		CreatedAt:     time.Now(),
+		VersionHashes: versionHashes,
+		Comparison:    comparison,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache entry: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(c.Dir, kbComparisonCachePrefix+"*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), c.entryPath(key)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+
+	_, err = c.Clean(true)
+	return err
+}
+
+// expired checks if an entry created at the given time is older than MaxAge
+func (c *KBComparisonCache) expired(createdAt time.Time) bool {
+	return c.MaxAge > 0 && time.Since(createdAt) > c.MaxAge
+}
+
+// Clean removes cache entries and returns the number of removed entries
+// If expiredOnly is true, only entries older than MaxAge (by modification time) are removed
+// A missing cache directory is not an error
+func (c *KBComparisonCache) Clean(expiredOnly bool) (int, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to read cache directory %s: %w", c.Dir, err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), kbComparisonCachePrefix) {
+			continue
+		}
+		if expiredOnly {
+			info, err := entry.Info()
+			if err != nil || !c.expired(info.ModTime()) {
+				continue
+			}
+		}
+		if err := os.Remove(filepath.Join(c.Dir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove cache entry %s: %w", entry.Name(), err)
+		}
+		removed++
+	}
+	return removed, nil
+}
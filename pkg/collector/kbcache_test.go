@@ -0,0 +1,142 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeCacheTestKB(t testing.TB, kbPath string, params int) {
+	for _, version := range []string{"v7.5.0", "v8.1.0"} {
+		config := make(map[string]interface{}, params)
+		for i := 0; i < params; i++ {
+			value := i
+			if version == "v8.1.0" && i%10 == 0 {
+				value = i + 1
+			}
+			config[fmt.Sprintf("section.param-%d", i)] = map[string]interface{}{"value": value, "type": "int"}
+		}
+		dir := filepath.Join(kbPath, getVersionGroup(version), version, "tikv")
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		writeJSONFile(t, filepath.Join(dir, "defaults.json"), map[string]interface{}{"config_defaults": config})
+	}
+}
+
+func TestDiffKnowledgeBaseVersionsCached(t *testing.T) {
+	kbPath := t.TempDir()
+	cache := NewKBComparisonCache(t.TempDir())
+	versions := []string{"v7.5.0", "v8.1.0"}
+	writeCacheTestKB(t, kbPath, 20)
+
+	cold, err := DiffKnowledgeBaseVersionsCached(kbPath, versions, cache)
+	require.NoError(t, err)
+	require.Len(t, cold.Components, 1)
+	assert.Len(t, cold.Components[0].Params, 2)
+
+	entries, err := filepath.Glob(filepath.Join(cache.Dir, kbComparisonCachePrefix+"*.json"))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+
+	// Warm path: the cached entry is returned
+	warm, err := DiffKnowledgeBaseVersionsCached(kbPath, versions, cache)
+	require.NoError(t, err)
+	assert.Equal(t, cold, warm)
+
+	// Changing a defaults.json file changes the key
+	writeTestDefaults(t, kbPath, "v8.1.0", "tikv", map[string]interface{}{
+		"config_defaults": map[string]interface{}{"section.param-1": map[string]interface{}{"value": 1, "type": "int"}},
+	})
+	changed, err := DiffKnowledgeBaseVersionsCached(kbPath, versions, cache)
+	require.NoError(t, err)
+	assert.Equal(t, 19, changed.Components[0].ChangedCount())
+
+	uncached, err := DiffKnowledgeBaseVersionsCached(kbPath, versions, nil)
+	require.NoError(t, err)
+	assert.Equal(t, changed, uncached)
+}
+
+func TestKBComparisonCache_IgnoresStaleEntries(t *testing.T) {
+	cache := NewKBComparisonCache(t.TempDir())
+	comparison := &KBComparison{Versions: []string{"v7.5.0", "v8.1.0"}}
+	require.NoError(t, cache.store("key", nil, comparison))
+	assert.Equal(t, comparison, cache.load("key"))
+
+	// Entry written by a build with another schema version
+	writeJSONFile(t, cache.entryPath("old"), map[string]interface{}{
+		"schema_version": kbComparisonCacheSchemaVersion + 1,
+		"created_at":     time.Now(),
+		"comparison":     comparison,
+	})
+	assert.Nil(t, cache.load("old"))
+
+	// Expired entry
+	writeJSONFile(t, cache.entryPath("expired"), map[string]interface{}{
+		"schema_version": kbComparisonCacheSchemaVersion,
+		"created_at":     time.Now().Add(-DefaultKBCacheMaxAge - time.Hour),
+		"comparison":     comparison,
+	})
+	assert.Nil(t, cache.load("expired"))
+}
+
+func TestKBComparisonCache_Clean(t *testing.T) {
+	cache := NewKBComparisonCache(t.TempDir())
+	require.NoError(t, cache.store("fresh", nil, &KBComparison{}))
+	require.NoError(t, cache.store("old", nil, &KBComparison{}))
+	old := time.Now().Add(-DefaultKBCacheMaxAge - time.Hour)
+	require.NoError(t, os.Chtimes(cache.entryPath("old"), old, old))
+	// Unrelated files in the cache directory are kept
+	require.NoError(t, os.WriteFile(filepath.Join(cache.Dir, "other.json"), []byte("{}"), 0644))
+
+	removed, err := cache.Clean(true)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.NoFileExists(t, cache.entryPath("old"))
+	assert.FileExists(t, cache.entryPath("fresh"))
+
+	removed, err = cache.Clean(false)
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+	assert.FileExists(t, filepath.Join(cache.Dir, "other.json"))
+
+	removed, err = NewKBComparisonCache(filepath.Join(cache.Dir, "missing")).Clean(false)
+	require.NoError(t, err)
+	assert.Equal(t, 0, removed)
+}
+
+func benchmarkDiffKnowledgeBaseVersions(b *testing.B, cache *KBComparisonCache) {
+	kbPath := b.TempDir()
+	versions := []string{"v7.5.0", "v8.1.0"}
+	writeCacheTestKB(b, kbPath, 5000)
+	if _, err := DiffKnowledgeBaseVersionsCached(kbPath, versions, cache); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := DiffKnowledgeBaseVersionsCached(kbPath, versions, cache); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDiffKnowledgeBaseVersions_NoCache parses and diffs the defaults.json files on every run
+func BenchmarkDiffKnowledgeBaseVersions_NoCache(b *testing.B) {
+	benchmarkDiffKnowledgeBaseVersions(b, nil)
+}
+
+// BenchmarkDiffKnowledgeBaseVersions_Warm only hashes the defaults.json files and reads the cached entry
+func BenchmarkDiffKnowledgeBaseVersions_Warm(b *testing.B) {
+	benchmarkDiffKnowledgeBaseVersions(b, NewKBComparisonCache(b.TempDir()))
+}
+
+func writeJSONFile(t testing.TB, path string, value interface{}) {
+	data, err := json.Marshal(value)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0644))
+}
@@ -0,0 +1,208 @@
+package collector
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+)
+
+// KBComparison compares the parameter defaults of several knowledge base versions side by side
+type KBComparison struct {
+	// Versions are the compared versions, in the order they were requested
+	Versions []string `json:"versions"`
+	// Components are the components found in at least one version, in kbComponents order
+	Components []KBComponentComparison `json:"components"`
+}
+
+// KBComponentComparison compares the parameters of a component
+type KBComponentComparison struct {
+	Component string `json:"component"`
+	// Params are sorted by name, config parameters first, then system variables
+	Params []KBParamComparison `json:"params"`
+}
+
+// KBParamComparison is a parameter of a component across the compared versions
+type KBParamComparison struct {
+	// Name is the parameter name, system variables use the "sysvar:" prefix
+	Name string `json:"name"`
+	// ParamType is "config" or "system_variable"
+	ParamType string `json:"param_type"`
+	// Type is the value type recorded in defaults.json (taken from the last version that has the parameter)
+	Type string `json:"type"`
+	// Defaults are the default values, one per version (nil if the parameter is absent in that version)
+	Defaults []interface{} `json:"defaults"`
+	// Present tells whether the parameter exists in each version
+	Present []bool `json:"present"`
+	// Changed is true if the default differs between any two versions (including added or removed parameters)
+	Changed bool `json:"changed"`
+}
+
+// ChangedCount returns the number of changed parameters of the component
+func (c KBComponentComparison) ChangedCount() int {
+	count := 0
+	for _, param := range c.Params {
+		if param.Changed {
+			count++
+		}
+	}
+	return count
+}
+
+// ChangedOnly returns a copy of the comparison keeping only changed parameters and the components that have some
+func (c *KBComparison) ChangedOnly() *KBComparison {
+	changed := &KBComparison{Versions: c.Versions}
+	for _, component := range c.Components {
+		var params []KBParamComparison
+		for _, param := range component.Params {
+			if param.Changed {
+				params = append(params, param)
+			}
+		}
+		if len(params) > 0 {
+			changed.Components = append(changed.Components, KBComponentComparison{Component: component.Component, Params: params})
+		}
+	}
+	return changed
+}
+
+// kbDefaultsValues is the subset of defaults.json read when comparing versions
+type kbDefaultsValues struct {
+	ConfigDefaults  map[string]kbDefaultValue `json:"config_defaults"`
+	SystemVariables map[string]kbDefaultValue `json:"system_variables"`
+}
+
+// kbDefaultValue is a parameter entry of defaults.json
+type kbDefaultValue struct {
+	Value interface{} `json:"value"`
+	Type  string      `json:"type"`
+}
+
+// CompareKnowledgeBaseVersions loads the defaults.json of each version and compares them parameter by parameter
+// A version with no defaults.json for any component is an error
+func CompareKnowledgeBaseVersions(knowledgeBasePath string, versions []string) (*KBComparison, error) {
+	if len(versions) == 0 {
+		return nil, errors.New("no version to compare")
+	}
+
+	comparison := &KBComparison{Versions: versions}
+	for _, component := range kbComponents {
+		defaultsByVersion := make([]*kbDefaultsValues, len(versions))
+		found := false
+		for i, version := range versions {
+			defaults, err := readKBDefaultsValues(knowledgeBasePath, version, component)
+			if err != nil {
+				return nil, err
+			}
+			if defaults != nil {
+				defaultsByVersion[i] = defaults
+				found = true
+			}
+		}
+		if !found {
+			continue
+		}
+		comparison.Components = append(comparison.Components, KBComponentComparison{
+			Component: component,
+			Params:    compareKBDefaults(defaultsByVersion),
+		})
+	}
+
+	for _, version := range versions {
+		if !comparison.hasVersion(version) {
+			return nil, fmt.Errorf("knowledge base for version %s not found in %s", version, knowledgeBasePath)
+		}
+	}
+	return comparison, nil
+}
+
+// hasVersion checks if any component has a defaults.json for the version
+func (c *KBComparison) hasVersion(version string) bool {
+	index := -1
+	for i, v := range c.Versions {
+		if v == version {
+			index = i
+		}
+	}
+	for _, component := range c.Components {
+		for _, param := range component.Params {
+			if param.Present[index] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readKBDefaultsValues reads the defaults.json of a component, returning nil if it does not exist
+func readKBDefaultsValues(knowledgeBasePath, version, component string) (*kbDefaultsValues, error) {
+	path := filepath.Join(knowledgeBasePath, getVersionGroup(version), version, component, "defaults.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read defaults file %s: %w", path, err)
+	}
+	var defaults kbDefaultsValues
+	if err := json.Unmarshal(data, &defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse defaults file %s: %w", path, err)
+	}
+	return &defaults, nil
+}
+
+// compareKBDefaults builds the parameter rows of a component from its defaults in each version
+func compareKBDefaults(defaultsByVersion []*kbDefaultsValues) []KBParamComparison {
+	var params []KBParamComparison
+	for _, paramType := range []string{"config", "system_variable"} {
+		rows := make(map[string]*KBParamComparison)
+		for i, defaults := range defaultsByVersion {
+			if defaults == nil {
+				continue
+			}
+			values := defaults.ConfigDefaults
+			prefix := ""
+			if paramType == "system_variable" {
+				values = defaults.SystemVariables
+				prefix = "sysvar:"
+			}
+			for name, value := range values {
+				row, ok := rows[name]
+				if !ok {
+					row = &KBParamComparison{
+						Name:      prefix + name,
+						ParamType: paramType,
+						Defaults:  make([]interface{}, len(defaultsByVersion)),
+						Present:   make([]bool, len(defaultsByVersion)),
+					}
+					rows[name] = row
+				}
+				row.Defaults[i] = value.Value
+				row.Present[i] = true
+				if value.Type != "" {
+					row.Type = value.Type
+				}
+			}
+		}
+
+		names := make([]string, 0, len(rows))
+		for name := range rows {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			row := rows[name]
+			for i := 1; i < len(row.Defaults); i++ {
+				if row.Present[i] != row.Present[0] || !reflect.DeepEqual(row.Defaults[i], row.Defaults[0]) {
+					row.Changed = true
+					break
+				}
+			}
+			params = append(params, *row)
+		}
+	}
+	return params
+}
@@ -0,0 +1,67 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompareKnowledgeBaseVersions(t *testing.T) {
+	kbPath := t.TempDir()
+	writeTestDefaults(t, kbPath, "v7.5.0", "tidb", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"log.level":       map[string]interface{}{"value": "info", "type": "string"},
+			"mem-quota-query": map[string]interface{}{"value": 1073741824, "type": "int"},
+			"removed-option":  map[string]interface{}{"value": true, "type": "bool"},
+		},
+		"system_variables": map[string]interface{}{
+			"tidb_txn_mode": map[string]interface{}{"value": "pessimistic", "type": "string"},
+		},
+	})
+	writeTestDefaults(t, kbPath, "v8.1.0", "tidb", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"log.level":       map[string]interface{}{"value": "info", "type": "string"},
+			"mem-quota-query": map[string]interface{}{"value": 2147483648, "type": "int"},
+		},
+		"system_variables": map[string]interface{}{
+			"tidb_txn_mode": map[string]interface{}{"value": "pessimistic", "type": "string"},
+		},
+	})
+	writeTestDefaults(t, kbPath, "v8.1.0", "tikv", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"storage.engine": map[string]interface{}{"value": "raft-kv", "type": "string"},
+		},
+	})
+
+	comparison, err := CompareKnowledgeBaseVersions(kbPath, []string{"v7.5.0", "v8.1.0"})
+	require.NoError(t, err)
+	require.Len(t, comparison.Components, 2)
+
+	tidb := comparison.Components[0]
+	assert.Equal(t, "tidb", tidb.Component)
+	require.Len(t, tidb.Params, 4)
+	assert.Equal(t, "log.level", tidb.Params[0].Name)
+	assert.False(t, tidb.Params[0].Changed)
+	assert.Equal(t, "mem-quota-query", tidb.Params[1].Name)
+	assert.True(t, tidb.Params[1].Changed)
+	assert.Equal(t, "int", tidb.Params[1].Type)
+	assert.Equal(t, "removed-option", tidb.Params[2].Name)
+	assert.True(t, tidb.Params[2].Changed)
+	assert.Equal(t, []bool{true, false}, tidb.Params[2].Present)
+	assert.Nil(t, tidb.Params[2].Defaults[1])
+	assert.Equal(t, "sysvar:tidb_txn_mode", tidb.Params[3].Name)
+	assert.Equal(t, "system_variable", tidb.Params[3].ParamType)
+	assert.False(t, tidb.Params[3].Changed)
+	assert.Equal(t, 2, tidb.ChangedCount())
+
+	// Component only present in the newer version: every parameter is changed
+	tikv := comparison.Components[1]
+	assert.Equal(t, "tikv", tikv.Component)
+	assert.Equal(t, 1, tikv.ChangedCount())
+
+	_, err = CompareKnowledgeBaseVersions(kbPath, []string{"v7.5.0", "v9.0.0"})
+	assert.ErrorContains(t, err, "v9.0.0 not found")
+	_, err = CompareKnowledgeBaseVersions(kbPath, nil)
+	assert.Error(t, err)
+}
@@ -9,11 +9,13 @@ import (
 	"strings"
 )
 
-// LoadKnowledgeBase loads knowledge base for all components (tidb, pd, tikv, tiflash) for a specific version
-// Returns a map with component keys containing config_defaults, system_variables, and upgrade_logic
+// loadKnowledgeBaseFromDisk loads knowledge base for all components (tidb, pd, tikv, tiflash) for a specific version
+// Returns a map with component keys containing config_defaults, system_variables, upgrade_logic and parameter_history
+// (and upgrade_history for TiDB)
 // Also loads global high_risk_params configuration (high_risk_params.json)
 // This function loads the knowledge base that was generated by the kbgenerator
-func LoadKnowledgeBase(knowledgeBasePath, version string) (map[string]interface{}, error) {
+// Callers should use LoadKnowledgeBase, which caches the result
+func loadKnowledgeBaseFromDisk(knowledgeBasePath, version string) (map[string]interface{}, error) {
 	kb := make(map[string]interface{})
 
 	// Get version group from full version (e.g., v7.5.1 -> v7.5)
@@ -48,14 +50,18 @@ func LoadKnowledgeBase(knowledgeBasePath, version string) (map[string]interface{
 		// Load upgrade_logic.json (component-specific, global location)
 		// upgrade_logic.json is version-agnostic and stored at knowledgeBasePath/component/upgrade_logic.json
 		upgradeLogicPath := filepath.Join(knowledgeBasePath, component, "upgrade_logic.json")
-		if _, err := os.Stat(upgradeLogicPath); err == nil {
-			data, err := os.ReadFile(upgradeLogicPath)
-			if err == nil {
-				var upgradeLogic interface{}
-				if err := json.Unmarshal(data, &upgradeLogic); err == nil {
-					componentKB["upgrade_logic"] = upgradeLogic
-				}
-			}
+		loadGlobalJSON(componentKB, upgradeLogicPath, "upgrade_logic")
+
+		// Load parameter_history.json (component-specific, global location)
+		// The history records the versions where defaults changed, see GenerateParameterHistory
+		parameterHistoryPath := ParameterHistoryPath(knowledgeBasePath, component)
+		loadGlobalJSON(componentKB, parameterHistoryPath, "parameter_history")
+
+		// Load upgrade_history.json (TiDB only, global location)
+		// It maps the bootstrap versions of the upgrade logic to the releases that introduced them
+		if component == "tidb" {
+			upgradeHistoryPath := UpgradeHistoryPath(knowledgeBasePath)
+			loadGlobalJSON(componentKB, upgradeHistoryPath, "upgrade_history")
 		}
 
 		// Only add component to KB if it has data
@@ -67,32 +73,90 @@ func LoadKnowledgeBase(knowledgeBasePath, version string) (map[string]interface{
 	// Load high_risk_params.json (global, version-agnostic)
 	// This file contains high-risk parameters configuration for all components
 	highRiskParamsPath := filepath.Join(knowledgeBasePath, "high_risk_params", "high_risk_params.json")
-	if _, err := os.Stat(highRiskParamsPath); err == nil {
-		data, err := os.ReadFile(highRiskParamsPath)
-		if err == nil {
-			var highRiskParams interface{}
-			if err := json.Unmarshal(data, &highRiskParams); err == nil {
-				kb["high_risk_params"] = highRiskParams
-			}
-		}
-	}
+	loadGlobalJSON(kb, highRiskParamsPath, "high_risk_params")
 
 	// Load parameter_notes.json (global, version-agnostic)
 	// This file contains special notes/descriptions for parameters
 	parameterNotesPath := filepath.Join(knowledgeBasePath, "parameter_notes.json")
-	if _, err := os.Stat(parameterNotesPath); err == nil {
-		data, err := os.ReadFile(parameterNotesPath)
-		if err == nil {
-			var parameterNotes interface{}
-			if err := json.Unmarshal(data, &parameterNotes); err == nil {
-				kb["parameter_notes"] = parameterNotes
-			}
+	loadGlobalJSON(kb, parameterNotesPath, "parameter_notes")
+
+	// Load machine_derived_params.json (global, version-agnostic)
+	// This file extends the built-in list of parameters whose defaults are derived from host resources
+	machineDerivedParamsPath := filepath.Join(knowledgeBasePath, "machine_derived_params.json")
+	loadGlobalJSON(kb, machineDerivedParamsPath, "machine_derived_params")
+
+	// Load format_changes.json (global, version-agnostic)
+	// This file describes on-disk format changes that make a downgrade impossible
+	formatChangesPath := filepath.Join(knowledgeBasePath, "format_changes.json")
+	loadGlobalJSON(kb, formatChangesPath, "format_changes")
+
+	// Load section_migrations.json (global, version-agnostic)
+	// This file describes config parameters that moved to another section or key
+	sectionMigrationsPath := filepath.Join(knowledgeBasePath, "section_migrations.json")
+	loadGlobalJSON(kb, sectionMigrationsPath, "section_migrations")
+
+	// Load rename_map.json (global, version-agnostic)
+	// This file lists the parameters renamed between versions
+	renameMapPath := filepath.Join(knowledgeBasePath, "rename_map.json")
+	loadGlobalJSON(kb, renameMapPath, "rename_map")
+
+	// Load upgrade_matrix.json (global, version-agnostic)
+	// This file lists the supported direct upgrade paths. Unlike the other global files,
+	// an unreadable matrix fails the load: dropping it would hide unsupported upgrade paths
+	upgradeMatrixPath := filepath.Join(knowledgeBasePath, "upgrade_matrix.json")
+	if _, err := os.Stat(upgradeMatrixPath); err == nil {
+		data, err := os.ReadFile(upgradeMatrixPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read upgrade matrix %s: %w", upgradeMatrixPath, err)
+		}
+		var upgradeMatrix interface{}
+		if err := json.Unmarshal(data, &upgradeMatrix); err != nil {
+			return nil, fmt.Errorf("failed to parse upgrade matrix %s: %w", upgradeMatrixPath, err)
 		}
+		kb["upgrade_matrix"] = upgradeMatrix
 	}
 
+	// Load deployment_specific.json (global, version-agnostic)
+	// This file lists parameters whose values vary by deployment and are skipped in default comparisons
+	deploymentSpecificPath := filepath.Join(knowledgeBasePath, "deployment_specific.json")
+	loadGlobalJSON(kb, deploymentSpecificPath, "deployment_specific")
+
+	// Load consistency_ignore.json (global, version-agnostic)
+	// This file lists parameters that legitimately differ between the nodes of a component
+	consistencyIgnorePath := filepath.Join(knowledgeBasePath, "consistency_ignore.json")
+	loadGlobalJSON(kb, consistencyIgnorePath, "consistency_ignore")
+
+	// Load resource_control_changes.json (global, version-agnostic)
+	// This file lists the versions where the accounting of request units changed
+	resourceControlChangesPath := filepath.Join(knowledgeBasePath, "resource_control_changes.json")
+	loadGlobalJSON(kb, resourceControlChangesPath, "resource_control_changes")
+
+	// Load dangerous_combinations.json (global, version-agnostic)
+	// This file lists the configurations that become dangerous under a target version
+	dangerousCombinationsPath := filepath.Join(knowledgeBasePath, "dangerous_combinations.json")
+	loadGlobalJSON(kb, dangerousCombinationsPath, "dangerous_combinations")
+
+	// Load deprecated_startup_flags.json (global, version-agnostic)
+	// This file lists the command-line flags deprecated or removed in some version
+	deprecatedStartupFlagsPath := filepath.Join(knowledgeBasePath, "deprecated_startup_flags.json")
+	loadGlobalJSON(kb, deprecatedStartupFlagsPath, "deprecated_startup_flags")
+
 	return kb, nil
 }
 
+// loadGlobalJSON loads the version-agnostic knowledge file at path into kb[key]
+// A missing, unreadable or malformed file is skipped and leaves kb unchanged
+func loadGlobalJSON(kb map[string]interface{}, path, key string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err == nil {
+		kb[key] = value
+	}
+}
+
 // getVersionGroup extracts the version group (first two digits) from a full version string
 // Input version is always a full three-digit version (e.g., v7.5.1, v6.5.0, v8.5.2)
 // Example: v7.5.1 -> v7.5, v6.5.0 -> v6.5, v8.5.2 -> v8.5
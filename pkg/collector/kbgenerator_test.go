@@ -142,3 +142,22 @@ func TestGetVersionGroup(t *testing.T) {
 		})
 	}
 }
+
+func TestSavePDUpgradeLogic(t *testing.T) {
+	outputPath := filepath.Join(t.TempDir(), "pd", "upgrade_logic.json")
+
+	// TiDB upgrade logic is numbered by TiDB's bootstrap version and must not be saved as PD's
+	err := SavePDUpgradeLogic(&UpgradeLogicSnapshot{Component: ComponentTiDB}, outputPath)
+	assert.Error(t, err)
+
+	snapshot := &UpgradeLogicSnapshot{
+		Component: ComponentPD,
+		Changes:   []UpgradeParamChange{{Version: "1", Name: "schedule.max-merge-region-size", Value: float64(54), Force: true, Type: "config"}},
+	}
+	require.NoError(t, SavePDUpgradeLogic(snapshot, outputPath))
+	data, err := os.ReadFile(outputPath)
+	require.NoError(t, err)
+	var saved UpgradeLogicSnapshot
+	require.NoError(t, json.Unmarshal(data, &saved))
+	assert.Equal(t, *snapshot, saved)
+}
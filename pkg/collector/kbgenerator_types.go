@@ -3,6 +3,8 @@
 package collector
 
 import (
+	"fmt"
+
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 )
 
@@ -11,8 +13,7 @@ import (
 type (
 	ComponentType        = types.ComponentType
 	ParameterValue       = types.ParameterValue
-	ConfigDefaults       = types.ConfigDefaults
-	SystemVariables      = types.SystemVariables
+	ParameterMap         = types.ParameterMap
 	KBSnapshot           = types.KBSnapshot
 	UpgradeParamChange   = types.UpgradeParamChange
 	UpgradeLogicSnapshot = types.UpgradeLogicSnapshot
@@ -35,3 +36,12 @@ func SaveKBSnapshot(snapshot *KBSnapshot, outputPath string) error {
 func SaveUpgradeLogic(snapshot *UpgradeLogicSnapshot, outputPath string) error {
 	return types.SaveUpgradeLogic(snapshot, outputPath)
 }
+
+// SavePDUpgradeLogic saves PD upgrade logic to a file
+// The snapshot must be PD's: PD changes are numbered by PD's own bootstrap version
+func SavePDUpgradeLogic(snapshot *UpgradeLogicSnapshot, outputPath string) error {
+	if snapshot.Component != ComponentPD {
+		return fmt.Errorf("expected PD upgrade logic, got %q", snapshot.Component)
+	}
+	return types.SaveUpgradeLogic(snapshot, outputPath)
+}
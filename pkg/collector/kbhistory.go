@@ -0,0 +1,205 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+// ParameterHistoryFileName is the file name of a component's parameter history, stored at <kb>/<component>/parameter_history.json
+const ParameterHistoryFileName = "parameter_history.json"
+
+// ParameterHistory records the versions where parameter defaults of a component changed
+// It is generated from the defaults.json of every version in the knowledge base (see GenerateParameterHistory)
+type ParameterHistory struct {
+	// Component is the component type (tidb, pd, tikv, tiflash)
+	Component string `json:"component"`
+	// Versions are the versions the history was built from, in version order
+	Versions []string `json:"versions"`
+	// GeneratedAt is when the history was generated (RFC3339)
+	GeneratedAt string `json:"generated_at,omitempty"`
+	// Parameters maps parameter names (system variables use the "sysvar:" prefix) to their default changes,
+	// in version order. Parameters whose default never changed are omitted
+	Parameters map[string][]ParameterDefaultChange `json:"parameters"`
+}
+
+// ParameterDefaultChange is a change of a parameter default between two consecutive versions of the history
+type ParameterDefaultChange struct {
+	// Version is the first version with the new default
+	Version string `json:"version"`
+	// PreviousVersion is the version before it in the history
+	PreviousVersion string `json:"previous_version"`
+	// OldValue is the default in PreviousVersion (nil if the parameter was added)
+	OldValue interface{} `json:"old_value"`
+	// NewValue is the default in Version (nil if the parameter was removed)
+	NewValue interface{} `json:"new_value"`
+	// Added is true if the parameter does not exist in PreviousVersion
+	Added bool `json:"added,omitempty"`
+	// Removed is true if the parameter does not exist in Version
+	Removed bool `json:"removed,omitempty"`
+}
+
+// GenerateParameterHistory builds the parameter history of a component from all the versions of the knowledge base
+// that have a defaults.json for it
+// Parameters for which skip returns true (e.g., deployment-specific paths that change with every generation run)
+// are left out; a nil skip keeps every parameter
+func GenerateParameterHistory(knowledgeBasePath, component string, skip func(paramName string) bool) (*ParameterHistory, error) {
+	listing, err := ListKnowledgeBase(knowledgeBasePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	var defaultsByVersion []*kbDefaultsValues
+	for _, versionInfo := range listing.Versions {
+		defaults, err := readKBDefaultsValues(knowledgeBasePath, versionInfo.Version, component)
+		if err != nil {
+			return nil, err
+		}
+		if defaults == nil {
+			continue
+		}
+		versions = append(versions, versionInfo.Version)
+		defaultsByVersion = append(defaultsByVersion, defaults)
+	}
+	if len(versions) == 0 {
+		return nil, fmt.Errorf("no %s defaults found in knowledge base %s", component, knowledgeBasePath)
+	}
+
+	history := buildParameterHistory(component, versions, defaultsByVersion)
+	if skip != nil {
+		for name := range history.Parameters {
+			if skip(name) {
+				delete(history.Parameters, name)
+			}
+		}
+	}
+	history.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+	return history, nil
+}
+
+// buildParameterHistory folds the defaults of consecutive versions (in version order) into a history
+func buildParameterHistory(component string, versions []string, defaultsByVersion []*kbDefaultsValues) *ParameterHistory {
+	history := &ParameterHistory{
+		Component:  component,
+		Versions:   versions,
+		Parameters: make(map[string][]ParameterDefaultChange),
+	}
+	for _, param := range compareKBDefaults(defaultsByVersion) {
+		if !param.Changed {
+			continue
+		}
+		var changes []ParameterDefaultChange
+		for i := 1; i < len(versions); i++ {
+			if !param.Present[i-1] && !param.Present[i] {
+				continue
+			}
+			if param.Present[i-1] == param.Present[i] && reflect.DeepEqual(param.Defaults[i-1], param.Defaults[i]) {
+				continue
+			}
+			changes = append(changes, ParameterDefaultChange{
+				Version:         versions[i],
+				PreviousVersion: versions[i-1],
+				OldValue:        param.Defaults[i-1],
+				NewValue:        param.Defaults[i],
+				Added:           !param.Present[i-1],
+				Removed:         !param.Present[i],
+			})
+		}
+		if len(changes) > 0 {
+			history.Parameters[param.Name] = changes
+		}
+	}
+	return history
+}
+
+// ParameterHistoryPath returns the path of a component's parameter history in the knowledge base
+func ParameterHistoryPath(knowledgeBasePath, component string) string {
+	return filepath.Join(knowledgeBasePath, component, ParameterHistoryFileName)
+}
+
+// SaveParameterHistory writes the history to <kb>/<component>/parameter_history.json
+func SaveParameterHistory(history *ParameterHistory, knowledgeBasePath string) error {
+	path := ParameterHistoryPath(knowledgeBasePath, history.Component)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal parameter history: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ParseParameterHistory converts a parameter history loaded into the knowledge base map (see LoadKnowledgeBase)
+func ParseParameterHistory(raw interface{}) (*ParameterHistory, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal parameter_history: %w", err)
+	}
+	var history ParameterHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse parameter_history: %w", err)
+	}
+	return &history, nil
+}
+
+// HistoricalDefault is the default of a parameter in a version of the history
+type HistoricalDefault struct {
+	Version string
+	Value   interface{}
+}
+
+// FamilyDefaults returns the defaults of a parameter in the patch releases of the history that precede version in its
+// release family (e.g., v7.5.0 to v7.5.2 for v7.5.3), in version order. Releases without the parameter are left out
+// Returns nil if the default of the parameter never changed in the history
+func (h *ParameterHistory) FamilyDefaults(paramName, version string) []HistoricalDefault {
+	if h == nil || len(h.Parameters[paramName]) == 0 {
+		return nil
+	}
+	changes := h.Parameters[paramName]
+	key := versionKey(version)
+	var defaults []HistoricalDefault
+	for _, v := range h.Versions {
+		if vKey := versionKey(v); vKey/1000 != key/1000 || vKey >= key {
+			continue
+		}
+		if value, ok := defaultAt(changes, v); ok {
+			defaults = append(defaults, HistoricalDefault{Version: v, Value: value})
+		}
+	}
+	return defaults
+}
+
+// defaultAt returns the default of a parameter in version from its changes, false if the parameter doesn't exist in it
+func defaultAt(changes []ParameterDefaultChange, version string) (interface{}, bool) {
+	key := versionKey(version)
+	for i := len(changes) - 1; i >= 0; i-- {
+		if versionKey(changes[i].Version) <= key {
+			return changes[i].NewValue, !changes[i].Removed
+		}
+	}
+	return changes[0].OldValue, !changes[0].Added
+}
+
+// ChangesBetween returns the default changes of a parameter made in the versions (sourceVersion, targetVersion]
+// A nil history has no changes
+func (h *ParameterHistory) ChangesBetween(paramName, sourceVersion, targetVersion string) []ParameterDefaultChange {
+	if h == nil {
+		return nil
+	}
+	sourceKey, targetKey := versionKey(sourceVersion), versionKey(targetVersion)
+	var changes []ParameterDefaultChange
+	for _, change := range h.Parameters[paramName] {
+		if key := versionKey(change.Version); key > sourceKey && key <= targetKey {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
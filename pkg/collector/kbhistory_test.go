@@ -0,0 +1,161 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// paramDefault builds a defaults.json parameter entry
+func paramDefault(value interface{}) map[string]interface{} {
+	return map[string]interface{}{"value": value, "type": "string"}
+}
+
+func writeHistoryTestKB(t *testing.T) string {
+	kbPath := t.TempDir()
+	writeTestDefaults(t, kbPath, "v6.5.0", "pd", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"schedule.leader-schedule-limit": paramDefault(4),
+			"replication.max-replicas":       paramDefault(3),
+			"old-param":                      paramDefault("x"),
+			"data-dir":                       paramDefault("/tmp/run-1"),
+		},
+	})
+	writeTestDefaults(t, kbPath, "v7.1.0", "pd", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"schedule.leader-schedule-limit": paramDefault(4),
+			"replication.max-replicas":       paramDefault(3),
+			"old-param":                      paramDefault("x"),
+			"data-dir":                       paramDefault("/tmp/run-2"),
+		},
+	})
+	writeTestDefaults(t, kbPath, "v7.5.0", "pd", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"schedule.leader-schedule-limit": paramDefault(8),
+			"replication.max-replicas":       paramDefault(3),
+			"new-param":                      paramDefault(true),
+			"data-dir":                       paramDefault("/tmp/run-3"),
+		},
+	})
+	writeTestDefaults(t, kbPath, "v8.5.0", "pd", map[string]interface{}{
+		"config_defaults": map[string]interface{}{
+			"schedule.leader-schedule-limit": paramDefault(16),
+			"replication.max-replicas":       paramDefault(3),
+			"new-param":                      paramDefault(true),
+			"data-dir":                       paramDefault("/tmp/run-4"),
+		},
+	})
+	// TiDB only exists in two versions, its history ignores the others
+	writeTestDefaults(t, kbPath, "v7.1.0", "tidb", map[string]interface{}{
+		"config_defaults":  map[string]interface{}{"max-connections": paramDefault(0)},
+		"system_variables": map[string]interface{}{"tidb_enable_fast_analyze": paramDefault("OFF")},
+	})
+	writeTestDefaults(t, kbPath, "v8.5.0", "tidb", map[string]interface{}{
+		"config_defaults":  map[string]interface{}{"max-connections": paramDefault(0)},
+		"system_variables": map[string]interface{}{"tidb_enable_fast_analyze": paramDefault("ON")},
+	})
+	return kbPath
+}
+
+func TestGenerateParameterHistory_PD(t *testing.T) {
+	kbPath := writeHistoryTestKB(t)
+
+	history, err := GenerateParameterHistory(kbPath, "pd", func(paramName string) bool {
+		return paramName == "data-dir"
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "pd", history.Component)
+	assert.Equal(t, []string{"v6.5.0", "v7.1.0", "v7.5.0", "v8.5.0"}, history.Versions)
+	assert.NotEmpty(t, history.GeneratedAt)
+
+	// Unchanged and skipped parameters are omitted
+	assert.NotContains(t, history.Parameters, "replication.max-replicas")
+	assert.NotContains(t, history.Parameters, "data-dir")
+
+	assert.Equal(t, []ParameterDefaultChange{
+		{Version: "v7.5.0", PreviousVersion: "v7.1.0", OldValue: float64(4), NewValue: float64(8)},
+		{Version: "v8.5.0", PreviousVersion: "v7.5.0", OldValue: float64(8), NewValue: float64(16)},
+	}, history.Parameters["schedule.leader-schedule-limit"])
+	assert.Equal(t, []ParameterDefaultChange{
+		{Version: "v7.5.0", PreviousVersion: "v7.1.0", OldValue: "x", Removed: true},
+	}, history.Parameters["old-param"])
+	assert.Equal(t, []ParameterDefaultChange{
+		{Version: "v7.5.0", PreviousVersion: "v7.1.0", NewValue: true, Added: true},
+	}, history.Parameters["new-param"])
+}
+
+func TestGenerateParameterHistory_TiDB(t *testing.T) {
+	kbPath := writeHistoryTestKB(t)
+
+	history, err := GenerateParameterHistory(kbPath, "tidb", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v7.1.0", "v8.5.0"}, history.Versions)
+	assert.Equal(t, map[string][]ParameterDefaultChange{
+		"sysvar:tidb_enable_fast_analyze": {
+			{Version: "v8.5.0", PreviousVersion: "v7.1.0", OldValue: "OFF", NewValue: "ON"},
+		},
+	}, history.Parameters)
+
+	_, err = GenerateParameterHistory(kbPath, "tiflash", nil)
+	assert.Error(t, err)
+}
+
+func TestParameterHistory_SaveLoadAndChangesBetween(t *testing.T) {
+	kbPath := writeHistoryTestKB(t)
+	history, err := GenerateParameterHistory(kbPath, "pd", nil)
+	require.NoError(t, err)
+	require.NoError(t, SaveParameterHistory(history, kbPath))
+
+	// The history is loaded with the knowledge base of any version
+	ClearKBCache()
+	kb, err := LoadKnowledgeBase(kbPath, "v8.5.0")
+	require.NoError(t, err)
+	raw := kb["pd"].(map[string]interface{})["parameter_history"]
+	require.NotNil(t, raw)
+	loaded, err := ParseParameterHistory(raw)
+	require.NoError(t, err)
+	assert.Equal(t, history.Versions, loaded.Versions)
+
+	// Range is (source, target]
+	changes := loaded.ChangesBetween("schedule.leader-schedule-limit", "v7.1.0", "v7.5.0")
+	require.Len(t, changes, 1)
+	assert.Equal(t, "v7.5.0", changes[0].Version)
+	assert.Len(t, loaded.ChangesBetween("schedule.leader-schedule-limit", "v6.5.0", "v8.5.0"), 2)
+	assert.Empty(t, loaded.ChangesBetween("schedule.leader-schedule-limit", "v7.5.0", "v7.5.4"))
+	assert.Empty(t, loaded.ChangesBetween("replication.max-replicas", "v6.5.0", "v8.5.0"))
+
+	var nilHistory *ParameterHistory
+	assert.Empty(t, nilHistory.ChangesBetween("schedule.leader-schedule-limit", "v6.5.0", "v8.5.0"))
+}
+
+func TestParameterHistory_FamilyDefaults(t *testing.T) {
+	history := &ParameterHistory{
+		Component: "tidb",
+		Versions:  []string{"v7.1.0", "v7.5.0", "v7.5.1", "v7.5.2", "v7.5.3", "v8.1.0"},
+		Parameters: map[string][]ParameterDefaultChange{
+			"sysvar:tidb_opt_x": {
+				{Version: "v7.5.1", PreviousVersion: "v7.5.0", OldValue: "OFF", NewValue: "ON"},
+				{Version: "v7.5.3", PreviousVersion: "v7.5.2", OldValue: "ON", NewValue: "AUTO"},
+			},
+			"sysvar:tidb_new": {
+				{Version: "v7.5.2", PreviousVersion: "v7.5.1", NewValue: float64(4), Added: true},
+			},
+		},
+	}
+
+	// Patch releases of the v7.5 family before v7.5.3
+	assert.Equal(t, []HistoricalDefault{
+		{Version: "v7.5.0", Value: "OFF"},
+		{Version: "v7.5.1", Value: "ON"},
+		{Version: "v7.5.2", Value: "ON"},
+	}, history.FamilyDefaults("sysvar:tidb_opt_x", "v7.5.3"))
+	// Releases without the parameter are left out
+	assert.Equal(t, []HistoricalDefault{{Version: "v7.5.2", Value: float64(4)}}, history.FamilyDefaults("sysvar:tidb_new", "7.5.3"))
+	assert.Empty(t, history.FamilyDefaults("sysvar:tidb_opt_x", "v7.5.0"))
+	assert.Empty(t, history.FamilyDefaults("sysvar:tidb_opt_x", "v8.1.0"))
+	assert.Nil(t, history.FamilyDefaults("sysvar:tidb_unchanged", "v7.5.3"))
+
+	var nilHistory *ParameterHistory
+	assert.Nil(t, nilHistory.FamilyDefaults("sysvar:tidb_opt_x", "v7.5.3"))
+}
@@ -0,0 +1,229 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// kbComponents are the components that may have a defaults.json in a knowledge base version directory
+var kbComponents = []string{"tidb", "pd", "tikv", "tiflash"}
+
+// KBListing describes the contents of a knowledge base directory
+type KBListing struct {
+	// Path is the knowledge base directory
+	Path string `json:"path"`
+	// Versions are the versions found in the knowledge base, sorted by version
+	Versions []KBVersionInfo `json:"versions"`
+	// UpgradeLogic lists the components that have an upgrade_logic.json
+	UpgradeLogic map[string]bool `json:"upgrade_logic"`
+}
+
+// KBVersionInfo describes the knowledge base of a single version
+type KBVersionInfo struct {
+	// VersionGroup is the version group directory (e.g., v7.5)
+	VersionGroup string `json:"version_group"`
+	// Version is the full version (e.g., v7.5.1)
+	Version string `json:"version"`
+	// Components are the components that have a defaults.json for this version
+	Components []KBComponentInfo `json:"components"`
+}
+
+// KBComponentInfo describes the defaults.json of a component in a knowledge base version
+type KBComponentInfo struct {
+	// Component is the component type (tidb, pd, tikv, tiflash)
+	Component string `json:"component"`
+	// ConfigParams is the number of config parameter defaults
+	ConfigParams int `json:"config_params"`
+	// SystemVariables is the number of system variable defaults
+	SystemVariables int `json:"system_variables"`
+	// BootstrapVersion is the bootstrap version recorded in the knowledge base
+	BootstrapVersion int64 `json:"bootstrap_version"`
+	// GeneratedAt is when the defaults were generated
+	// Taken from the generated_at metadata if present, otherwise from the file modification time
+	GeneratedAt time.Time `json:"generated_at"`
+	// Error is set if defaults.json could not be parsed
+	Error string `json:"error,omitempty"`
+}
+
+// kbDefaultsSummary is the subset of defaults.json read when listing the knowledge base
+type kbDefaultsSummary struct {
+	ConfigDefaults   map[string]json.RawMessage `json:"config_defaults"`
+	SystemVariables  map[string]json.RawMessage `json:"system_variables"`
+	BootstrapVersion int64                      `json:"bootstrap_version"`
+	GeneratedAt      string                     `json:"generated_at"`
+}
+
+// ListKnowledgeBase walks a knowledge base directory and describes the versions it covers
+// Layout: <path>/<version-group>/<version>/<component>/defaults.json and <path>/<component>/upgrade_logic.json
+func ListKnowledgeBase(knowledgeBasePath string) (*KBListing, error) {
+	groups, err := os.ReadDir(knowledgeBasePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read knowledge base directory %s: %w", knowledgeBasePath, err)
+	}
+
+	listing := &KBListing{
+		Path:         knowledgeBasePath,
+		UpgradeLogic: make(map[string]bool),
+	}
+
+	for _, component := range kbComponents {
+		if _, err := os.Stat(filepath.Join(knowledgeBasePath, component, "upgrade_logic.json")); err == nil {
+			listing.UpgradeLogic[component] = true
+		}
+	}
+
+	for _, group := range groups {
+		if !group.IsDir() || !isVersionName(group.Name()) {
+			continue
+		}
+		versions, err := os.ReadDir(filepath.Join(knowledgeBasePath, group.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read version group %s: %w", group.Name(), err)
+		}
+		for _, version := range versions {
+			if !version.IsDir() || !isVersionName(version.Name()) {
+				continue
+			}
+			info := KBVersionInfo{
+				VersionGroup: group.Name(),
+				Version:      version.Name(),
+			}
+			for _, component := range kbComponents {
+				defaultsPath := filepath.Join(knowledgeBasePath, group.Name(), version.Name(), component, "defaults.json")
+				if componentInfo, ok := summarizeDefaults(component, defaultsPath); ok {
+					info.Components = append(info.Components, componentInfo)
+				}
+			}
+			listing.Versions = append(listing.Versions, info)
+		}
+	}
+
+	sort.Slice(listing.Versions, func(i, j int) bool {
+		return versionKey(listing.Versions[i].Version) < versionKey(listing.Versions[j].Version)
+	})
+	return listing, nil
+}
+
+// summarizeDefaults reads a defaults.json and returns its summary
+// Returns false if the file does not exist
+func summarizeDefaults(component, defaultsPath string) (KBComponentInfo, bool) {
+	stat, err := os.Stat(defaultsPath)
+	if err != nil {
+		return KBComponentInfo{}, false
+	}
+
+	info := KBComponentInfo{
+		Component:   component,
+		GeneratedAt: stat.ModTime().UTC(),
+	}
+
+	data, err := os.ReadFile(defaultsPath)
+	if err != nil {
+		info.Error = err.Error()
+		return info, true
+	}
+	var summary kbDefaultsSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		info.Error = fmt.Sprintf("failed to parse: %v", err)
+		return info, true
+	}
+
+	info.ConfigParams = len(summary.ConfigDefaults)
+	info.SystemVariables = len(summary.SystemVariables)
+	info.BootstrapVersion = summary.BootstrapVersion
+	if generatedAt, err := time.Parse(time.RFC3339, summary.GeneratedAt); err == nil {
+		info.GeneratedAt = generatedAt.UTC()
+	}
+	return info, true
+}
+
+// HasVersion reports whether the knowledge base has defaults for a version
+func (l *KBListing) HasVersion(version string) bool {
+	for _, v := range l.Versions {
+		if v.Version == version && len(v.Components) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// NearestVersions returns up to n available versions closest to version, sorted by version
+func (l *KBListing) NearestVersions(version string, n int) []string {
+	candidates := make([]string, 0, len(l.Versions))
+	for _, v := range l.Versions {
+		if len(v.Components) > 0 {
+			candidates = append(candidates, v.Version)
+		}
+	}
+	return ClosestVersions(candidates, version, n)
+}
+
+// LatestInGroup returns the latest available version of a version group (e.g., v8.5 -> v8.5.4),
+// or an empty string if the knowledge base has no version of the group
+func (l *KBListing) LatestInGroup(group string) string {
+	latest := ""
+	for _, v := range l.Versions {
+		if len(v.Components) > 0 && getVersionGroup(v.Version) == group {
+			latest = v.Version // Versions are sorted
+		}
+	}
+	return latest
+}
+
+// ClosestVersions returns up to n of the candidate versions closest to version, sorted by version
+func ClosestVersions(candidates []string, version string, n int) []string {
+	target := versionKey(version)
+	candidates = append([]string(nil), candidates...)
+
+	distance := func(v string) int64 {
+		d := versionKey(v) - target
+		if d < 0 {
+			return -d
+		}
+		return d
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return distance(candidates[i]) < distance(candidates[j])
+	})
+	if len(candidates) > n {
+		candidates = candidates[:n]
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return versionKey(candidates[i]) < versionKey(candidates[j])
+	})
+	return candidates
+}
+
+// isVersionName reports whether a directory name looks like a version (e.g., v7.5 or v7.5.1)
+func isVersionName(name string) bool {
+	if !strings.HasPrefix(name, "v") {
+		return false
+	}
+	for _, part := range strings.Split(strings.TrimPrefix(name, "v"), ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// versionKey converts a version (e.g., v7.5.1) to a number that sorts in version order
+func versionKey(version string) int64 {
+	var key int64
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	for i := 0; i < 3; i++ {
+		var num int64
+		if i < len(parts) {
+			num, _ = strconv.ParseInt(parts[i], 10, 64)
+		}
+		key = key*1000 + num
+	}
+	return key
+}
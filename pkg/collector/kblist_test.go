@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeTestDefaults(t *testing.T, kbPath, version, component string, defaults map[string]interface{}) {
+	dir := filepath.Join(kbPath, getVersionGroup(version), version, component)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	data, err := json.Marshal(defaults)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "defaults.json"), data, 0644))
+}
+
+func TestListKnowledgeBase(t *testing.T) {
+	kbPath := t.TempDir()
+	writeTestDefaults(t, kbPath, "v7.5.1", "tidb", map[string]interface{}{
+		"config_defaults":   map[string]interface{}{"a": 1, "b": 2},
+		"system_variables":  map[string]interface{}{"tidb_x": 1},
+		"bootstrap_version": 180,
+		"generated_at":      "2024-05-01T10:00:00Z",
+	})
+	writeTestDefaults(t, kbPath, "v7.5.1", "tikv", map[string]interface{}{
+		"config_defaults": map[string]interface{}{"c": 3},
+	})
+	writeTestDefaults(t, kbPath, "v7.5.0", "pd", map[string]interface{}{
+		"config_defaults": map[string]interface{}{"d": 4},
+	})
+	writeTestDefaults(t, kbPath, "v6.5.10", "tidb", map[string]interface{}{
+		"config_defaults": map[string]interface{}{},
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(kbPath, "tidb"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(kbPath, "tidb", "upgrade_logic.json"), []byte("{}"), 0644))
+	// Non-version directories are ignored
+	require.NoError(t, os.MkdirAll(filepath.Join(kbPath, "high_risk_params"), 0755))
+
+	listing, err := ListKnowledgeBase(kbPath)
+	require.NoError(t, err)
+
+	require.Len(t, listing.Versions, 3)
+	assert.Equal(t, "v6.5.10", listing.Versions[0].Version)
+	assert.Equal(t, "v7.5.0", listing.Versions[1].Version)
+	assert.Equal(t, "v7.5.1", listing.Versions[2].Version)
+	assert.Equal(t, "v7.5", listing.Versions[2].VersionGroup)
+	assert.Equal(t, map[string]bool{"tidb": true}, listing.UpgradeLogic)
+
+	components := listing.Versions[2].Components
+	require.Len(t, components, 2)
+	assert.Equal(t, "tidb", components[0].Component)
+	assert.Equal(t, 2, components[0].ConfigParams)
+	assert.Equal(t, 1, components[0].SystemVariables)
+	assert.Equal(t, int64(180), components[0].BootstrapVersion)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), components[0].GeneratedAt)
+	assert.Equal(t, "tikv", components[1].Component)
+	assert.False(t, components[1].GeneratedAt.IsZero(), "falls back to file modification time")
+
+	assert.True(t, listing.HasVersion("v7.5.0"))
+	assert.False(t, listing.HasVersion("v7.5.2"))
+
+	_, err = ListKnowledgeBase(filepath.Join(kbPath, "missing"))
+	assert.Error(t, err)
+}
+
+func TestKBListing_NearestVersions(t *testing.T) {
+	listing := &KBListing{}
+	for _, v := range []string{"v6.5.0", "v7.1.0", "v7.5.0", "v7.5.1", "v8.1.0", "v8.5.0"} {
+		listing.Versions = append(listing.Versions, KBVersionInfo{
+			Version:    v,
+			Components: []KBComponentInfo{{Component: "tidb"}},
+		})
+	}
+	// Versions without defaults are not suggested
+	listing.Versions = append(listing.Versions, KBVersionInfo{Version: "v7.5.2"})
+
+	assert.Equal(t, []string{"v7.1.0", "v7.5.0", "v7.5.1"}, listing.NearestVersions("v7.5.3", 3))
+	assert.Equal(t, []string{"v8.1.0", "v8.5.0"}, listing.NearestVersions("v9.0.0", 2))
+	assert.Empty(t, (&KBListing{}).NearestVersions("v7.5.0", 3))
+}
+
+func TestKBListing_LatestInGroup(t *testing.T) {
+	listing := &KBListing{}
+	for _, v := range []string{"v7.5.0", "v7.5.1", "v7.5.10", "v8.1.0"} {
+		listing.Versions = append(listing.Versions, KBVersionInfo{
+			Version:    v,
+			Components: []KBComponentInfo{{Component: "tidb"}},
+		})
+	}
+	// Versions without defaults are not selected
+	listing.Versions = append(listing.Versions, KBVersionInfo{Version: "v8.1.1"})
+
+	assert.Equal(t, "v7.5.10", listing.LatestInGroup("v7.5"))
+	assert.Equal(t, "v8.1.0", listing.LatestInGroup("v8.1"))
+	assert.Equal(t, "", listing.LatestInGroup("v8.5"))
+}
+
+func TestLoadPublishedReleases(t *testing.T) {
+	kbPath := t.TempDir()
+	_, err := LoadPublishedReleases(kbPath)
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(kbPath, ReleasesFile), []byte(`{"releases": ["v8.5.0", "8.5.1"]}`), 0644))
+	releases, err := LoadPublishedReleases(kbPath)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v8.5.0", "v8.5.1"}, releases)
+	assert.Equal(t, []string{"v8.5.1"}, ClosestVersions(releases, "v8.5.9", 1))
+
+	require.NoError(t, os.WriteFile(filepath.Join(kbPath, ReleasesFile), []byte(`{"releases": ["v8.5"]}`), 0644))
+	_, err = LoadPublishedReleases(kbPath)
+	assert.ErrorContains(t, err, "invalid release")
+
+	// The releases shipped with the knowledge base are valid
+	_, err = LoadPublishedReleases(filepath.Join("..", "..", "knowledge"))
+	assert.NoError(t, err)
+}
@@ -0,0 +1,110 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// UpgradeHistoryFileName is the file name of TiDB's upgrade history, stored at <kb>/tidb/upgrade_history.json
+const UpgradeHistoryFileName = "upgrade_history.json"
+
+// UpgradeHistory maps the bootstrap versions of TiDB to the first release at that bootstrap version
+// (e.g., 198 -> v8.1.0), so the upgrade functions of upgrade_logic.json can be told by release
+// Stored as a JSON object keyed by bootstrap version: {"198": "v8.1.0", "181": "v7.5.3", ...}
+type UpgradeHistory map[int64]string
+
+// ReleaseBootstrapVersion is the bootstrap version of a TiDB release
+type ReleaseBootstrapVersion struct {
+	Version          string
+	BootstrapVersion int64
+}
+
+// BuildUpgradeHistory maps each bootstrap version of the releases to the first release (in version order)
+// at that bootstrap version. Releases without a bootstrap version (0) are skipped
+func BuildUpgradeHistory(releases []ReleaseBootstrapVersion) UpgradeHistory {
+	sorted := append([]ReleaseBootstrapVersion(nil), releases...)
+	sort.SliceStable(sorted, func(i, j int) bool { return versionKey(sorted[i].Version) < versionKey(sorted[j].Version) })
+
+	history := make(UpgradeHistory)
+	for _, release := range sorted {
+		if release.BootstrapVersion <= 0 {
+			continue
+		}
+		if _, ok := history[release.BootstrapVersion]; !ok {
+			history[release.BootstrapVersion] = release.Version
+		}
+	}
+	return history
+}
+
+// UpgradeHistoryFromKB builds the upgrade history from the bootstrap versions recorded in the TiDB defaults
+// of the knowledge base, for when the TiDB repository is not at hand
+func UpgradeHistoryFromKB(knowledgeBasePath string) (UpgradeHistory, error) {
+	listing, err := ListKnowledgeBase(knowledgeBasePath)
+	if err != nil {
+		return nil, err
+	}
+	var releases []ReleaseBootstrapVersion
+	for _, versionInfo := range listing.Versions {
+		for _, comp := range versionInfo.Components {
+			if comp.Component == "tidb" {
+				releases = append(releases, ReleaseBootstrapVersion{Version: versionInfo.Version, BootstrapVersion: comp.BootstrapVersion})
+			}
+		}
+	}
+	history := BuildUpgradeHistory(releases)
+	if len(history) == 0 {
+		return nil, fmt.Errorf("no TiDB bootstrap version found in knowledge base %s", knowledgeBasePath)
+	}
+	return history, nil
+}
+
+// UpgradeHistoryPath returns the path of TiDB's upgrade history in the knowledge base
+func UpgradeHistoryPath(knowledgeBasePath string) string {
+	return filepath.Join(knowledgeBasePath, "tidb", UpgradeHistoryFileName)
+}
+
+// SaveUpgradeHistory writes the history to <kb>/tidb/upgrade_history.json
+func SaveUpgradeHistory(history UpgradeHistory, knowledgeBasePath string) error {
+	path := UpgradeHistoryPath(knowledgeBasePath)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.MarshalIndent(history, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal upgrade history: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ParseUpgradeHistory converts an upgrade history loaded into the knowledge base map (see LoadKnowledgeBase)
+func ParseUpgradeHistory(raw interface{}) (UpgradeHistory, error) {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal upgrade_history: %w", err)
+	}
+	var history UpgradeHistory
+	if err := json.Unmarshal(data, &history); err != nil {
+		return nil, fmt.Errorf("failed to parse upgrade_history: %w", err)
+	}
+	return history, nil
+}
+
+// ReleaseVersion returns the first release that runs the upgrade function of a bootstrap version:
+// the release of the smallest bootstrap version of the history not below it
+// Returns an empty string if no release of the history is at that bootstrap version yet, or the history is nil
+func (h UpgradeHistory) ReleaseVersion(bootstrapVersion int64) string {
+	var best int64
+	for version := range h {
+		if version >= bootstrapVersion && (best == 0 || version < best) {
+			best = version
+		}
+	}
+	return h[best]
+}
@@ -0,0 +1,50 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildUpgradeHistory(t *testing.T) {
+	history := BuildUpgradeHistory([]ReleaseBootstrapVersion{
+		{Version: "v7.5.10", BootstrapVersion: 181},
+		{Version: "v7.5.3", BootstrapVersion: 181},
+		{Version: "v8.1.0", BootstrapVersion: 198},
+		{Version: "v7.5.0", BootstrapVersion: 179},
+		{Version: "v5.0.0", BootstrapVersion: 0},
+	})
+	assert.Equal(t, UpgradeHistory{179: "v7.5.0", 181: "v7.5.3", 198: "v8.1.0"}, history)
+
+	assert.Equal(t, "v7.5.0", history.ReleaseVersion(150))
+	assert.Equal(t, "v7.5.0", history.ReleaseVersion(179))
+	assert.Equal(t, "v7.5.3", history.ReleaseVersion(180))
+	assert.Equal(t, "v8.1.0", history.ReleaseVersion(190))
+	assert.Equal(t, "", history.ReleaseVersion(199), "not released yet")
+	assert.Equal(t, "", UpgradeHistory(nil).ReleaseVersion(100))
+}
+
+func TestUpgradeHistory_FromKBSaveAndParse(t *testing.T) {
+	kbPath := t.TempDir()
+	writeTestDefaults(t, kbPath, "v7.5.0", "tidb", map[string]interface{}{"bootstrap_version": 179})
+	writeTestDefaults(t, kbPath, "v7.5.1", "tidb", map[string]interface{}{"bootstrap_version": 179})
+	writeTestDefaults(t, kbPath, "v8.1.0", "tidb", map[string]interface{}{"bootstrap_version": 198})
+	writeTestDefaults(t, kbPath, "v8.1.0", "pd", map[string]interface{}{"bootstrap_version": 5})
+
+	history, err := UpgradeHistoryFromKB(kbPath)
+	require.NoError(t, err)
+	assert.Equal(t, UpgradeHistory{179: "v7.5.0", 198: "v8.1.0"}, history)
+
+	require.NoError(t, SaveUpgradeHistory(history, kbPath))
+	kb, err := loadKnowledgeBaseFromDisk(kbPath, "v8.1.0")
+	require.NoError(t, err)
+	raw, ok := NewKnowledgeBase(kb).UpgradeHistory("tidb")
+	require.True(t, ok)
+	parsed, err := ParseUpgradeHistory(raw)
+	require.NoError(t, err)
+	assert.Equal(t, history, parsed)
+
+	_, err = UpgradeHistoryFromKB(t.TempDir())
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package collector
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// KnowledgeBase wraps a knowledge base loaded by LoadKnowledgeBase with typed accessors,
+// so that callers do not have to walk the generic JSON map themselves
+// Accessors never fail: missing components, fields or malformed values are reported as absent
+type KnowledgeBase struct {
+	raw map[string]interface{}
+}
+
+// NewKnowledgeBase wraps a knowledge base map (the map is not copied and must not be modified)
+func NewKnowledgeBase(raw map[string]interface{}) *KnowledgeBase {
+	if raw == nil {
+		raw = map[string]interface{}{}
+	}
+	return &KnowledgeBase{raw: raw}
+}
+
+// Raw returns the wrapped knowledge base map
+func (kb *KnowledgeBase) Raw() map[string]interface{} {
+	return kb.raw
+}
+
+// HasComponent returns true if the knowledge base has data for the component
+func (kb *KnowledgeBase) HasComponent(component string) bool {
+	_, ok := kb.component(component)
+	return ok
+}
+
+// component returns the data of a component (its defaults.json fields, upgrade_logic, parameter_history and upgrade_history)
+func (kb *KnowledgeBase) component(component string) (map[string]interface{}, bool) {
+	compKB, ok := kb.raw[component].(map[string]interface{})
+	return compKB, ok
+}
+
+// componentField returns a field of the data of a component
+func (kb *KnowledgeBase) componentField(component, field string) (interface{}, bool) {
+	compKB, ok := kb.component(component)
+	if !ok {
+		return nil, false
+	}
+	value, ok := compKB[field]
+	return value, ok
+}
+
+// componentMap returns a field of the data of a component that is a JSON object
+func (kb *KnowledgeBase) componentMap(component, field string) (map[string]interface{}, bool) {
+	value, ok := kb.componentField(component, field)
+	if !ok {
+		return nil, false
+	}
+	m, ok := value.(map[string]interface{})
+	return m, ok
+}
+
+// ConfigDefaults returns the configuration defaults of a component as stored in the knowledge base
+// (parameter name -> {"value": ..., "type": ...}), nil if the component has none
+func (kb *KnowledgeBase) ConfigDefaults(component string) map[string]interface{} {
+	defaults, _ := kb.componentMap(component, "config_defaults")
+	return defaults
+}
+
+// SystemVariables returns the system variables of a component as stored in the knowledge base
+// (variable name -> {"value": ..., "type": ...}), nil if the component has none
+func (kb *KnowledgeBase) SystemVariables(component string) map[string]interface{} {
+	sysVars, _ := kb.componentMap(component, "system_variables")
+	return sysVars
+}
+
+// ConfigDefault returns the default of a configuration parameter of a component
+func (kb *KnowledgeBase) ConfigDefault(component, paramName string) (types.ParameterValue, bool) {
+	raw, ok := kb.ConfigDefaults(component)[paramName]
+	if !ok {
+		return types.ParameterValue{}, false
+	}
+	return toParameterValue(raw), true
+}
+
+// SysVar returns the default of a system variable
+// System variables are owned by TiDB
+func (kb *KnowledgeBase) SysVar(varName string) (types.ParameterValue, bool) {
+	raw, ok := kb.SystemVariables("tidb")[varName]
+	if !ok {
+		return types.ParameterValue{}, false
+	}
+	return toParameterValue(raw), true
+}
+
+// toParameterValue converts a default stored in the knowledge base into a ParameterValue
+// Defaults are stored as {"value": ..., "type": ...}; a bare value is kept as the value with no type
+func toParameterValue(raw interface{}) types.ParameterValue {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return types.ParameterValue{Value: raw}
+	}
+	if _, ok := m["value"]; !ok {
+		return types.ParameterValue{Value: raw}
+	}
+	value := types.ParameterValue{Value: m["value"]}
+	value.Type, _ = m["type"].(string)
+	value.Description, _ = m["description"].(string)
+	return value
+}
+
+// BootstrapVersion returns the bootstrap version of a component, 0 if it is missing or invalid
+// JSON numbers are decoded as float64, but int, int64 and numeric strings are accepted as well
+func (kb *KnowledgeBase) BootstrapVersion(component string) int64 {
+	raw, ok := kb.componentField(component, "bootstrap_version")
+	if !ok {
+		return 0
+	}
+	switch v := raw.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case int:
+		return int64(v)
+	case string:
+		if parsed, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return parsed
+		}
+	}
+	return 0
+}
+
+// HasBootstrapVersion returns true if the knowledge base records a bootstrap version for the component
+// (which BootstrapVersion may still report as 0 if it is invalid)
+func (kb *KnowledgeBase) HasBootstrapVersion(component string) bool {
+	_, ok := kb.componentField(component, "bootstrap_version")
+	return ok
+}
+
+// UpgradeLogic returns the upgrade logic of a component as stored in the knowledge base (upgrade_logic.json)
+func (kb *KnowledgeBase) UpgradeLogic(component string) (map[string]interface{}, bool) {
+	return kb.componentMap(component, "upgrade_logic")
+}
+
+// UpgradeChanges returns the forced parameter changes of the upgrade logic of a component,
+// nil if the component has no upgrade logic or it cannot be decoded
+func (kb *KnowledgeBase) UpgradeChanges(component string) []types.UpgradeParamChange {
+	upgradeLogic, ok := kb.UpgradeLogic(component)
+	if !ok {
+		return nil
+	}
+	data, err := json.Marshal(upgradeLogic)
+	if err != nil {
+		return nil
+	}
+	var snapshot types.UpgradeLogicSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil
+	}
+	return snapshot.Changes
+}
+
+// ParameterHistory returns the parameter history of a component as stored in the knowledge base
+// (parameter_history.json), see ParseParameterHistory
+func (kb *KnowledgeBase) ParameterHistory(component string) (interface{}, bool) {
+	return kb.componentField(component, "parameter_history")
+}
+
+// UpgradeHistory returns the upgrade history of a component as stored in the knowledge base
+// (upgrade_history.json, TiDB only), see ParseUpgradeHistory
+func (kb *KnowledgeBase) UpgradeHistory(component string) (interface{}, bool) {
+	return kb.componentField(component, "upgrade_history")
+}
+
+// Global returns a global, version-agnostic entry of the knowledge base (e.g., "high_risk_params", "rename_map")
+func (kb *KnowledgeBase) Global(key string) (interface{}, bool) {
+	value, ok := kb.raw[key]
+	return value, ok
+}
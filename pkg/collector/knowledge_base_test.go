@@ -0,0 +1,70 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKnowledgeBase_Accessors(t *testing.T) {
+	kb := NewKnowledgeBase(map[string]interface{}{
+		"tidb": map[string]interface{}{
+			"config_defaults":   map[string]interface{}{"log.level": paramDefault("info")},
+			"system_variables":  map[string]interface{}{"tidb_txn_mode": paramDefault("pessimistic")},
+			"bootstrap_version": float64(218),
+			"upgrade_logic": map[string]interface{}{
+				"component": "tidb",
+				"changes": []interface{}{
+					map[string]interface{}{"version": "v7.5.0", "name": "tidb_enable_async_merge_global_stats", "value": "ON", "force": true},
+				},
+			},
+		},
+		"pd": map[string]interface{}{
+			"config_defaults":   map[string]interface{}{"schedule.leader-schedule-limit": 4.0},
+			"bootstrap_version": "12",
+		},
+		"tikv":             "not a component",
+		"high_risk_params": map[string]interface{}{},
+	})
+
+	value, ok := kb.ConfigDefault("tidb", "log.level")
+	require.True(t, ok)
+	assert.Equal(t, types.ParameterValue{Value: "info", Type: "string"}, value)
+	// Bare values are kept as the value
+	value, ok = kb.ConfigDefault("pd", "schedule.leader-schedule-limit")
+	require.True(t, ok)
+	assert.Equal(t, 4.0, value.Value)
+	_, ok = kb.ConfigDefault("tikv", "log.level")
+	assert.False(t, ok)
+
+	value, ok = kb.SysVar("tidb_txn_mode")
+	require.True(t, ok)
+	assert.Equal(t, "pessimistic", value.Value)
+	_, ok = kb.SysVar("missing")
+	assert.False(t, ok)
+
+	assert.Equal(t, int64(218), kb.BootstrapVersion("tidb"))
+	assert.Equal(t, int64(12), kb.BootstrapVersion("pd"))
+	assert.Equal(t, int64(0), kb.BootstrapVersion("tiflash"))
+	assert.False(t, kb.HasBootstrapVersion("tiflash"))
+
+	changes := kb.UpgradeChanges("tidb")
+	require.Len(t, changes, 1)
+	assert.Equal(t, "tidb_enable_async_merge_global_stats", changes[0].Name)
+	assert.True(t, changes[0].Force)
+	assert.Nil(t, kb.UpgradeChanges("pd"))
+
+	assert.True(t, kb.HasComponent("pd"))
+	assert.False(t, kb.HasComponent("tikv"))
+	_, ok = kb.Global("high_risk_params")
+	assert.True(t, ok)
+}
+
+func TestKnowledgeBase_Nil(t *testing.T) {
+	kb := NewKnowledgeBase(nil)
+	assert.Nil(t, kb.ConfigDefaults("tidb"))
+	assert.Equal(t, int64(0), kb.BootstrapVersion("tidb"))
+	assert.Nil(t, kb.UpgradeChanges("tidb"))
+}
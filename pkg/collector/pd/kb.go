@@ -29,11 +29,17 @@ func Collect(pdRoot, version string, pdAddr string) (*types.KBSnapshot, error) {
 		return nil, fmt.Errorf("failed to collect PD default config: %w", err)
 	}
 
+	// Only PD versions with bootstrap upgrade logic have a bootstrap version (see CollectPDUpgradeLogicFromSource)
+	var bootstrapVersion int64
+	if pdRoot != "" {
+		bootstrapVersion = ExtractBootstrapVersion(pdRoot)
+	}
+
 	snapshot := &types.KBSnapshot{
 		Component:        types.ComponentPD,
 		Version:          version,
 		ConfigDefaults:   state.Config, // Direct assignment - types are compatible
-		BootstrapVersion: 0,            // PD doesn't use bootstrap version for upgrade logic
+		BootstrapVersion: bootstrapVersion,
 	}
 
 	return snapshot, nil
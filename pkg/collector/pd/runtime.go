@@ -1,39 +1,63 @@
 package pd
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/tracing"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // PDCollector handles collection of PD configuration
 type PDCollector interface {
-	Collect(addrs []string) (*types.ComponentState, error)
+	// Collect collects the configuration from the first PD instance that answers, each attempt traced as a child span of ctx
+	Collect(ctx context.Context, addrs []string) (*types.ComponentState, error)
 	CollectDefaults(addrs []string) (*types.ComponentState, error) // For knowledge base generation
+	// CollectServiceSafePoints reads the GC safepoint and the service GC safepoints registered in PD
+	// Returns ErrServiceSafePointsUnsupported if PD doesn't provide the service safepoint list API
+	CollectServiceSafePoints(addrs []string) (gcSafePoint uint64, safePoints []types.ServiceSafePoint, err error)
+	// CollectStores reads the stores registered in PD with their labels
+	CollectStores(addrs []string) ([]types.StoreLabels, error)
+	// CollectStoreInfo reads the TiKV stores registered in PD with their versions and states, Tombstone stores included
+	CollectStoreInfo(addrs []string) ([]types.StoreInfo, error)
+	// ClusterID reads the ID of the cluster (e.g., to tell the checkpoints of a collection apart)
+	ClusterID(addrs []string) (string, error)
 }
 
+// ErrServiceSafePointsUnsupported is returned by CollectServiceSafePoints for PD versions without /pd/api/v1/gc/safepoint
+var ErrServiceSafePointsUnsupported = errors.New("PD does not provide the service GC safepoint list API")
+
 type pdCollector struct {
 	httpClient *http.Client
 }
 
 // NewPDCollector creates a new PD collector
 func NewPDCollector() PDCollector {
+	return NewPDCollectorWithThrottle(nil)
+}
+
+// NewPDCollectorWithThrottle creates a PD collector whose HTTP requests are rate limited by throttle (nil means no limit)
+func NewPDCollectorWithThrottle(throttle *common.Throttle) PDCollector {
 	return &pdCollector{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		httpClient: common.NewThrottledHTTPClient(30*time.Second, throttle),
 	}
 }
 
 // Collect gathers configuration from PD instances
-func (c *pdCollector) Collect(addrs []string) (*types.ComponentState, error) {
+func (c *pdCollector) Collect(ctx context.Context, addrs []string) (*types.ComponentState, error) {
 	// Try each address until one succeeds
 	var lastErr error
 	for _, addr := range addrs {
-		state, err := c.collectFromInstance(addr)
+		spanCtx, span := tracing.StartSpan(ctx, "collector.pd.instance", attribute.String("address", addr))
+		state, err := c.collectFromInstance(spanCtx, addr)
+		tracing.EndSpan(span, err)
 		if err == nil {
 			return state, nil
 		}
@@ -61,11 +85,171 @@ func (c *pdCollector) CollectDefaults(addrs []string) (*types.ComponentState, er
 	return nil, fmt.Errorf("failed to collect defaults from any PD instance: %w", lastErr)
 }
 
+// CollectServiceSafePoints reads the GC safepoint and the service GC safepoints via /pd/api/v1/gc/safepoint
+// Every PD instance serves the API, the first one that answers is used
+func (c *pdCollector) CollectServiceSafePoints(addrs []string) (uint64, []types.ServiceSafePoint, error) {
+	var lastErr error
+	for _, addr := range addrs {
+		gcSafePoint, safePoints, err := c.getServiceSafePoints(addr)
+		if err == nil || errors.Is(err, ErrServiceSafePointsUnsupported) {
+			return gcSafePoint, safePoints, err
+		}
+		lastErr = err
+		fmt.Printf("Warning: failed to get service GC safepoints from PD instance %s: %v\n", addr, err)
+	}
+
+	return 0, nil, fmt.Errorf("failed to get service GC safepoints from any PD instance: %w", lastErr)
+}
+
+// getServiceSafePoints gets the service GC safepoints of a PD instance
+func (c *pdCollector) getServiceSafePoints(addr string) (uint64, []types.ServiceSafePoint, error) {
+	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/pd/api/v1/gc/safepoint", addr))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	// PD versions without the API answer 404
+	if resp.StatusCode == http.StatusNotFound {
+		return 0, nil, ErrServiceSafePointsUnsupported
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, nil, fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+
+	var list struct {
+		ServiceGCSafePoints []types.ServiceSafePoint `json:"service_gc_safe_points"`
+		GCSafePoint         uint64                   `json:"gc_safe_point"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return 0, nil, err
+	}
+
+	return list.GCSafePoint, list.ServiceGCSafePoints, nil
+}
+
+// CollectStores reads the stores and their labels via /pd/api/v1/stores
+// Every PD instance serves the API, the first one that answers is used
+func (c *pdCollector) CollectStores(addrs []string) ([]types.StoreLabels, error) {
+	var lastErr error
+	for _, addr := range addrs {
+		stores, err := c.getStores(addr)
+		if err == nil {
+			return stores, nil
+		}
+		lastErr = err
+		fmt.Printf("Warning: failed to get stores from PD instance %s: %v\n", addr, err)
+	}
+
+	return nil, fmt.Errorf("failed to get stores from any PD instance: %w", lastErr)
+}
+
+// pdStore is a store of the /pd/api/v1/stores response
+type pdStore struct {
+	Store struct {
+		ID            uint64 `json:"id"`
+		Address       string `json:"address"`
+		StatusAddress string `json:"status_address"`
+		Version       string `json:"version"`
+		StateName     string `json:"state_name"`
+		Labels        []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"labels"`
+	} `json:"store"`
+}
+
+// label returns the value of a label of the store
+func (s pdStore) label(key string) string {
+	for _, label := range s.Store.Labels {
+		if label.Key == key {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+// listStores gets the stores of a PD instance, query selects them (e.g., "?state=2" for the Tombstone stores)
+func (c *pdCollector) listStores(addr, query string) ([]pdStore, error) {
+	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/pd/api/v1/stores%s", addr, query))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+
+	var list struct {
+		Stores []pdStore `json:"stores"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, err
+	}
+	return list.Stores, nil
+}
+
+// getStores gets the stores of a PD instance
+func (c *pdCollector) getStores(addr string) ([]types.StoreLabels, error) {
+	list, err := c.listStores(addr, "")
+	if err != nil {
+		return nil, err
+	}
+
+	stores := make([]types.StoreLabels, 0, len(list))
+	for _, item := range list {
+		store := types.StoreLabels{ID: item.Store.ID, Address: item.Store.Address}
+		if len(item.Store.Labels) > 0 {
+			store.Labels = make(map[string]string, len(item.Store.Labels))
+			for _, label := range item.Store.Labels {
+				store.Labels[label.Key] = label.Value
+			}
+		}
+		stores = append(stores, store)
+	}
+	return stores, nil
+}
+
+// allStoreStates selects the Up (0), Offline (1) and Tombstone (2) stores: without it PD leaves the Tombstone stores out
+const allStoreStates = "?state=0&state=1&state=2"
+
+// CollectStoreInfo reads the TiKV stores with their versions and states via /pd/api/v1/stores
+// TiFlash stores (label engine=tiflash or tiflash_compute) are left out
+// Every PD instance serves the API, the first one that answers is used
+func (c *pdCollector) CollectStoreInfo(addrs []string) ([]types.StoreInfo, error) {
+	var lastErr error
+	for _, addr := range addrs {
+		list, err := c.listStores(addr, allStoreStates)
+		if err != nil {
+			lastErr = err
+			fmt.Printf("Warning: failed to get stores from PD instance %s: %v\n", addr, err)
+			continue
+		}
+		stores := make([]types.StoreInfo, 0, len(list))
+		for _, item := range list {
+			if strings.HasPrefix(item.label("engine"), "tiflash") {
+				continue
+			}
+			stores = append(stores, types.StoreInfo{
+				ID:            item.Store.ID,
+				Address:       item.Store.Address,
+				StatusAddress: item.Store.StatusAddress,
+				Version:       item.Store.Version,
+				StateName:     item.Store.StateName,
+			})
+		}
+		return stores, nil
+	}
+
+	return nil, fmt.Errorf("failed to get stores from any PD instance: %w", lastErr)
+}
+
 func (c *pdCollector) collectDefaultsFromInstance(addr string) (*types.ComponentState, error) {
 	state := &types.ComponentState{
 		Type:      types.ComponentPD,
-		Config:    make(types.ConfigDefaults),
-		Variables: make(types.SystemVariables),
+		Config:    make(types.ParameterMap),
+		Variables: make(types.ParameterMap),
 		Status:    make(map[string]interface{}),
 	}
 
@@ -81,20 +265,25 @@ func (c *pdCollector) collectDefaultsFromInstance(addr string) (*types.Component
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PD default config: %w", err)
 	}
-	// Convert to pkg/types.ConfigDefaults format
+	// Convert to pkg/types.ParameterMap format
 	state.Config = types.ConvertConfigToDefaults(config)
+	// The knowledge base stores the scheduling parameters as "schedule.<name>", like the runtime configuration
+	MergeScheduleConfig(state.Config, nil)
 
 	return state, nil
 }
 
-func (c *pdCollector) collectFromInstance(addr string) (*types.ComponentState, error) {
+func (c *pdCollector) collectFromInstance(ctx context.Context, addr string) (*types.ComponentState, error) {
 	state := &types.ComponentState{
 		Type:      types.ComponentPD,
-		Config:    make(types.ConfigDefaults),
-		Variables: make(types.SystemVariables),
+		Config:    make(types.ParameterMap),
+		Variables: make(types.ParameterMap),
 		Status:    make(map[string]interface{}),
 	}
 
+	// Store the address in Status for identification
+	state.Status["address"] = addr
+
 	// Get version
 	version, err := c.getVersion(addr)
 	if err != nil {
@@ -103,17 +292,62 @@ func (c *pdCollector) collectFromInstance(addr string) (*types.ComponentState, e
 	}
 	state.Version = version
 
+	// Collect cluster metadata (best effort, used for ClusterInfo)
+	if clusterID, err := c.getClusterID(addr); err != nil {
+		fmt.Printf("Warning: failed to get cluster ID from PD %s: %v\n", addr, err)
+	} else {
+		state.Status["cluster_id"] = clusterID
+	}
+	if leaderAddr, err := c.getLeaderAddr(addr); err != nil {
+		fmt.Printf("Warning: failed to get PD leader from %s: %v\n", addr, err)
+	} else {
+		state.Status["leader_addr"] = leaderAddr
+	}
+
 	// Collect configuration
+	// /pd/api/v1/config is the effective configuration persisted in etcd (config file and pd-ctl changes)
 	config, err := c.getConfig(addr)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get PD config: %w", err)
 	}
-	// Convert to pkg/types.ConfigDefaults format
+	// Convert to pkg/types.ParameterMap format
 	state.Config = types.ConvertConfigToDefaults(config)
 
+	// The schedule section of /pd/api/v1/config may be stale when the scheduling service runs apart from PD,
+	// /pd/api/v1/config/schedule is preferred (best effort, falling back to the section of /pd/api/v1/config)
+	schedule, err := c.getScheduleConfig(ctx, addr)
+	if err != nil {
+		fmt.Printf("Warning: failed to get PD schedule config from %s, using the schedule section of the PD config: %v\n", addr, err)
+	}
+	MergeScheduleConfig(state.Config, schedule)
+
+	// Collect the defaults of the running PD (best effort), so that the rules can tell configured values
+	// from defaults of the PD binary that differ from the knowledge base
+	if defaultConfig, err := c.getDefaultConfig(addr); err != nil {
+		fmt.Printf("Warning: failed to get PD default config from %s, the origin of PD parameters is not reported: %v\n", addr, err)
+	} else {
+		state.DefaultConfig = types.ConvertConfigToDefaults(defaultConfig)
+		MergeScheduleConfig(state.DefaultConfig, nil)
+	}
+
+	// PD doesn't serve its command line, its flags are read back from the configuration they set
+	state.StartupArgs = common.StartupArgsFromConfig(state.Config, StartupFlagConfigKeys)
+
 	return state, nil
 }
 
+// StartupFlagConfigKeys maps the command-line flags of PD to the config keys they set
+var StartupFlagConfigKeys = map[string]string{
+	"name":                  "name",
+	"data-dir":              "data-dir",
+	"client-urls":           "client-urls",
+	"peer-urls":             "peer-urls",
+	"advertise-client-urls": "advertise-client-urls",
+	"advertise-peer-urls":   "advertise-peer-urls",
+	"initial-cluster":       "initial-cluster",
+	"join":                  "join",
+}
+
 func (c *pdCollector) getVersion(addr string) (string, error) {
 	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/pd/api/v1/status", addr))
 	if err != nil {
@@ -136,6 +370,76 @@ func (c *pdCollector) getVersion(addr string) (string, error) {
 	return status.Version, nil
 }
 
+// ClusterID reads the cluster ID via /pd/api/v1/cluster
+// Every PD instance serves the API, the first one that answers is used
+func (c *pdCollector) ClusterID(addrs []string) (string, error) {
+	var lastErr error
+	for _, addr := range addrs {
+		clusterID, err := c.getClusterID(addr)
+		if err == nil {
+			return clusterID, nil
+		}
+		lastErr = err
+		fmt.Printf("Warning: failed to get cluster ID from PD instance %s: %v\n", addr, err)
+	}
+	if lastErr == nil {
+		return "", errors.New("no PD address")
+	}
+
+	return "", fmt.Errorf("failed to get cluster ID from any PD instance: %w", lastErr)
+}
+
+// getClusterID gets the cluster ID via /pd/api/v1/cluster
+func (c *pdCollector) getClusterID(addr string) (string, error) {
+	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/pd/api/v1/cluster", addr))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+
+	// Cluster ID is a uint64 which does not fit into float64, so decode it as json.Number
+	var cluster struct {
+		ID json.Number `json:"id"`
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&cluster); err != nil {
+		return "", err
+	}
+
+	return cluster.ID.String(), nil
+}
+
+// getLeaderAddr gets the client address of the PD leader via /pd/api/v1/leader
+func (c *pdCollector) getLeaderAddr(addr string) (string, error) {
+	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/pd/api/v1/leader", addr))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+
+	var leader struct {
+		Name       string   `json:"name"`
+		ClientURLs []string `json:"client_urls"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&leader); err != nil {
+		return "", err
+	}
+
+	if len(leader.ClientURLs) == 0 {
+		return "", fmt.Errorf("PD leader %s has no client URLs", leader.Name)
+	}
+	return leader.ClientURLs[0], nil
+}
+
 // getConfig gets PD configuration via HTTP API
 // For knowledge base generation, use getDefaultConfig to get default values
 // For runtime collection, use this method to get current values
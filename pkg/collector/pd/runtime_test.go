@@ -0,0 +1,199 @@
+package pd
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// newPDServer starts a PD API answering /pd/api/v1/gc/safepoint with body, or 404 if body is empty
+func newPDServer(t *testing.T, body string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pd/api/v1/gc/safepoint" || body == "" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestCollectServiceSafePoints(t *testing.T) {
+	addr := newPDServer(t, `{"service_gc_safe_points":[
+		{"service_id":"gc_worker","expired_at":9223372036854775807,"safe_point":446983457187495936},
+		{"service_id":"br-5f3c","expired_at":1704160000,"safe_point":446983000000000000}
+	],"gc_safe_point":446983457187495936}`)
+
+	gcSafePoint, safePoints, err := NewPDCollector().CollectServiceSafePoints([]string{addr})
+	require.NoError(t, err)
+	// TSOs don't fit into float64, they must be decoded exactly
+	assert.Equal(t, uint64(446983457187495936), gcSafePoint)
+	assert.Equal(t, []types.ServiceSafePoint{
+		{ServiceID: "gc_worker", ExpiredAt: 9223372036854775807, SafePoint: 446983457187495936},
+		{ServiceID: "br-5f3c", ExpiredAt: 1704160000, SafePoint: 446983000000000000},
+	}, safePoints)
+}
+
+func TestCollectServiceSafePoints_Unsupported(t *testing.T) {
+	// Older PD versions don't serve the API
+	_, _, err := NewPDCollector().CollectServiceSafePoints([]string{newPDServer(t, "")})
+	assert.ErrorIs(t, err, ErrServiceSafePointsUnsupported)
+}
+
+// newPDConfigServer starts a PD API answering the paths of responses, and 404 for the others
+func newPDConfigServer(t *testing.T, responses map[string]string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestCollect_DefaultConfig(t *testing.T) {
+	responses := map[string]string{
+		"/pd/api/v1/status":         `{"version":"v7.5.0"}`,
+		"/pd/api/v1/config":         `{"schedule":{"leader-schedule-limit":8}}`,
+		"/pd/api/v1/config/default": `{"schedule":{"leader-schedule-limit":4}}`,
+	}
+	state, err := NewPDCollector().Collect(context.Background(), []string{newPDConfigServer(t, responses)})
+	require.NoError(t, err)
+	// Without /pd/api/v1/config/schedule, the schedule section of the config is used
+	assert.Equal(t, float64(8), state.Config["schedule.leader-schedule-limit"].Value)
+	assert.NotContains(t, state.Config, "schedule")
+	assert.Equal(t, float64(4), state.DefaultConfig["schedule.leader-schedule-limit"].Value)
+
+	// The default config is optional
+	delete(responses, "/pd/api/v1/config/default")
+	state, err = NewPDCollector().Collect(context.Background(), []string{newPDConfigServer(t, responses)})
+	require.NoError(t, err)
+	assert.Contains(t, state.Config, "schedule.leader-schedule-limit")
+	assert.Nil(t, state.DefaultConfig)
+}
+
+func TestCollect_ScheduleConfig(t *testing.T) {
+	addr := newPDConfigServer(t, map[string]string{
+		"/pd/api/v1/status":          `{"version":"v8.5.0"}`,
+		"/pd/api/v1/config":          `{"schedule":{"leader-schedule-limit":4,"region-schedule-limit":2048},"replication":{"max-replicas":3}}`,
+		"/pd/api/v1/config/schedule": `{"leader-schedule-limit":16,"region-schedule-limit":2048,"max-store-down-time":"1h0m0s"}`,
+	})
+
+	collector := NewPDCollector().(*pdCollector)
+	schedule, err := collector.getScheduleConfig(context.Background(), addr)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"leader-schedule-limit": float64(16),
+		"region-schedule-limit": float64(2048),
+		"max-store-down-time":   "1h0m0s",
+	}, schedule)
+
+	// The schedule endpoint takes precedence over the schedule section of the config
+	state, err := NewPDCollector().Collect(context.Background(), []string{addr})
+	require.NoError(t, err)
+	assert.Equal(t, types.ParameterValue{Value: float64(16), Type: "float"}, state.Config["schedule.leader-schedule-limit"])
+	assert.Equal(t, types.ParameterValue{Value: "1h0m0s", Type: "string"}, state.Config["schedule.max-store-down-time"])
+	assert.NotContains(t, state.Config, "schedule")
+	assert.Contains(t, state.Config, "replication")
+
+	_, err = collector.getScheduleConfig(context.Background(), newPDConfigServer(t, nil))
+	assert.Error(t, err)
+}
+
+func TestMergeScheduleConfig(t *testing.T) {
+	config := types.ConvertConfigToDefaults(map[string]interface{}{
+		"schedule":    map[string]interface{}{"leader-schedule-limit": float64(4), "store-limit": map[string]interface{}{}},
+		"replication": map[string]interface{}{"max-replicas": float64(3)},
+	})
+	MergeScheduleConfig(config, nil)
+	assert.Equal(t, types.ParameterMap{
+		"schedule.leader-schedule-limit": {Value: float64(4), Type: "float"},
+		"schedule.store-limit":           {Value: map[string]interface{}{}, Type: "map"},
+		"replication":                    {Value: map[string]interface{}{"max-replicas": float64(3)}, Type: "map"},
+	}, config)
+
+	// Nothing to flatten
+	config = types.ParameterMap{"replication": {Value: "x", Type: "string"}}
+	MergeScheduleConfig(config, nil)
+	assert.Len(t, config, 1)
+}
+
+func TestCollectStores(t *testing.T) {
+	addr := newPDConfigServer(t, map[string]string{
+		"/pd/api/v1/stores": `{"count":2,"stores":[
+			{"store":{"id":1,"address":"10.0.1.1:20160","labels":[{"key":"zone","value":"z1"},{"key":"disk","value":"ssd"}]},"status":{}},
+			{"store":{"id":4,"address":"10.0.1.2:20160"},"status":{}}
+		]}`,
+	})
+	stores, err := NewPDCollector().CollectStores([]string{addr})
+	require.NoError(t, err)
+	assert.Equal(t, []types.StoreLabels{
+		{ID: 1, Address: "10.0.1.1:20160", Labels: map[string]string{"zone": "z1", "disk": "ssd"}},
+		{ID: 4, Address: "10.0.1.2:20160"},
+	}, stores)
+
+	_, err = NewPDCollector().CollectStores([]string{newPDConfigServer(t, nil)})
+	assert.Error(t, err)
+}
+
+func TestCollectStoreInfo(t *testing.T) {
+	addr := newPDConfigServer(t, map[string]string{
+		"/pd/api/v1/stores": `{"count":3,"stores":[
+			{"store":{"id":1,"address":"10.0.1.1:20160","status_address":"10.0.1.1:20180","version":"7.5.0","state_name":"Up"},"status":{}},
+			{"store":{"id":4,"address":"10.0.1.2:20160","version":"7.1.2","state_name":"Tombstone"},"status":{}},
+			{"store":{"id":9,"address":"10.0.1.3:3930","version":"v7.5.0","state_name":"Up","labels":[{"key":"engine","value":"tiflash"}]},"status":{}}
+		]}`,
+	})
+	stores, err := NewPDCollector().CollectStoreInfo([]string{addr})
+	require.NoError(t, err)
+	assert.Equal(t, []types.StoreInfo{
+		{ID: 1, Address: "10.0.1.1:20160", StatusAddress: "10.0.1.1:20180", Version: "7.5.0", StateName: types.StoreStateUp},
+		{ID: 4, Address: "10.0.1.2:20160", Version: "7.1.2", StateName: types.StoreStateTombstone},
+	}, stores)
+	assert.True(t, stores[0].MatchesAddress("10.0.1.1:20180"))
+	assert.True(t, stores[0].MatchesAddress("10.0.1.1:20160"))
+	assert.False(t, stores[1].MatchesAddress(""))
+
+	_, err = NewPDCollector().CollectStoreInfo([]string{newPDConfigServer(t, nil)})
+	assert.Error(t, err)
+}
+
+func TestCollect_TracesEachInstance(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	defer otel.SetTracerProvider(previous)
+
+	down := newPDConfigServer(t, nil)
+	up := newPDConfigServer(t, map[string]string{
+		"/pd/api/v1/status": `{"version":"v7.5.0"}`,
+		"/pd/api/v1/config": `{"schedule":{"leader-schedule-limit":8}}`,
+	})
+	_, err := NewPDCollector().Collect(context.Background(), []string{down, up})
+	require.NoError(t, err)
+
+	// One span per PD instance tried, the failed attempt is marked as an error
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	for i, addr := range []string{down, up} {
+		assert.Equal(t, "collector.pd.instance", spans[i].Name())
+		assert.Contains(t, spans[i].Attributes(), attribute.String("address", addr))
+	}
+	assert.Equal(t, codes.Error, spans[0].Status().Code)
+	assert.NotEqual(t, codes.Error, spans[1].Status().Code)
+}
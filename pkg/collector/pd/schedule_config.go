@@ -0,0 +1,60 @@
+package pd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// ScheduleSection is the section of the PD configuration holding the scheduling parameters
+// (region-schedule-limit, leader-schedule-limit, ...). Its parameters are stored flat, as "schedule.<name>",
+// both in the collected configuration and in the knowledge base, so that they are compared one by one
+const ScheduleSection = "schedule"
+
+// getScheduleConfig gets the scheduling configuration of a PD instance via /pd/api/v1/config/schedule
+// It is the configuration in effect, changes made with pd-ctl included (forwarded to the scheduling
+// service when PD runs in microservice mode)
+func (c *pdCollector) getScheduleConfig(ctx context.Context, addr string) (map[string]interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/pd/api/v1/config/schedule", addr), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+
+	var schedule map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// MergeScheduleConfig stores the parameters of the schedule section in config as "schedule.<name>"
+// schedule replaces the nested "schedule" section of config; if nil, that section is flattened instead
+func MergeScheduleConfig(config types.ParameterMap, schedule map[string]interface{}) {
+	if schedule == nil {
+		section, ok := config[ScheduleSection].Value.(map[string]interface{})
+		if !ok {
+			return
+		}
+		schedule = section
+	}
+	delete(config, ScheduleSection)
+	prefixed := make(map[string]interface{}, len(schedule))
+	for name, value := range schedule {
+		prefixed[ScheduleSection+"."+name] = value
+	}
+	for name, value := range types.ConvertConfigToDefaults(prefixed) {
+		config[name] = value
+	}
+}
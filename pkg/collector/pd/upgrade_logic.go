@@ -0,0 +1,192 @@
+package pd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// pdUpgradeLogicCandidates are the files, relative to the PD repository root, that may hold PD's bootstrap upgrade logic
+var pdUpgradeLogicCandidates = []string{
+	filepath.Join("server", "member", "bootstrap.go"),
+	filepath.Join("pkg", "member", "bootstrap.go"),
+	filepath.Join("server", "bootstrap.go"),
+}
+
+var (
+	// pdUpgradeFuncRe matches an upgrade function definition and extracts its bootstrap version
+	// Pattern: func upgradeToVer3(...) -> "upgradeToVer3", "3"
+	pdUpgradeFuncRe = regexp.MustCompile(`^func (upgradeToVer(\d+))\b`)
+	// pdUpgradeFuncDeclRe matches any upgrade function definition of a file
+	pdUpgradeFuncDeclRe = regexp.MustCompile(`(?m)^func upgradeToVer\d+`)
+	// pdConfigAssignRe matches the assignment of a config field in an upgrade function
+	// Pattern: cfg.Schedule.MaxMergeRegionSize = 54 -> "Schedule", "MaxMergeRegionSize", "54"
+	pdConfigAssignRe = regexp.MustCompile(`^\w+\.(\w+)\.(\w+)\s*=\s*(.+?)\s*(?://.*)?$`)
+	// pdCurrentBootstrapVersionRe matches the declaration of the current bootstrap version
+	pdCurrentBootstrapVersionRe = regexp.MustCompile(`currentBootstrapVersion(?:\s+\w+)?\s*=\s*(\d+)`)
+)
+
+// pdConfigSections maps the fields of PD's config.Config to their section in the config file,
+// when the section name is not the kebab-case field name
+var pdConfigSections = map[string]string{
+	"PDServerCfg": "pd-server",
+}
+
+// findPDUpgradeLogicFile finds the file holding the upgrade functions of a PD repository
+// Returns an empty path if no candidate file has upgradeToVerXX functions
+func findPDUpgradeLogicFile(pdRepoRoot string) string {
+	for _, candidate := range pdUpgradeLogicCandidates {
+		path := filepath.Join(pdRepoRoot, candidate)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if pdUpgradeFuncDeclRe.Match(data) {
+			return path
+		}
+	}
+	return ""
+}
+
+// CollectPDUpgradeLogicFromSource parses PD's bootstrap upgrade functions (upgradeToVerXX) to extract the config
+// fields they force, like tidb.CollectUpgradeLogicFromSource does for TiDB
+// As for TiDB, it should be called on the master branch, which has all historical upgrade functions
+// The version of a change is the bootstrap version of its function, to be compared with the bootstrap_version
+// of PD's defaults.json (see ExtractBootstrapVersion)
+// PD releases without bootstrap upgrade logic produce a snapshot without changes
+func CollectPDUpgradeLogicFromSource(pdRepoRoot string) (*types.UpgradeLogicSnapshot, error) {
+	snapshot := &types.UpgradeLogicSnapshot{Component: types.ComponentPD, Changes: []types.UpgradeParamChange{}}
+
+	if _, err := os.Stat(pdRepoRoot); err != nil {
+		return nil, fmt.Errorf("PD repository not found: %w", err)
+	}
+	upgradeFilePath := findPDUpgradeLogicFile(pdRepoRoot)
+	if upgradeFilePath == "" {
+		return snapshot, nil
+	}
+
+	f, err := os.Open(upgradeFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upgrade logic file %s: %w", upgradeFilePath, err)
+	}
+	defer f.Close()
+
+	var (
+		curFunc    string
+		curVersion string
+		curComment string
+		braceDepth int
+	)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if m := pdUpgradeFuncRe.FindStringSubmatch(line); m != nil {
+			curFunc, curVersion = m[1], m[2]
+			braceDepth = strings.Count(line, "{") - strings.Count(line, "}")
+			continue
+		}
+		if curFunc == "" {
+			// Comments above a function document its changes
+			if strings.HasPrefix(line, "//") {
+				curComment = strings.TrimSpace(strings.TrimPrefix(line, "//"))
+			} else if line == "" {
+				curComment = ""
+			}
+			continue
+		}
+
+		braceDepth += strings.Count(line, "{") - strings.Count(line, "}")
+		if braceDepth <= 0 {
+			curFunc, curVersion, curComment = "", "", ""
+			continue
+		}
+
+		m := pdConfigAssignRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		section, ok := pdConfigSections[m[1]]
+		if !ok {
+			section = configFieldToKebab(m[1])
+		}
+		name := section + "." + configFieldToKebab(m[2])
+		snapshot.Changes = append(snapshot.Changes, types.UpgradeParamChange{
+			Version:     curVersion,
+			FuncName:    curFunc,
+			Name:        name,
+			Value:       parsePDLiteral(m[3]),
+			Method:      "assignment",
+			Description: curComment,
+			Force:       true,
+			Type:        "config",
+			Severity:    "medium",
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// ExtractBootstrapVersion extracts the current bootstrap version from the PD upgrade logic file
+// Returns 0 if the repository has no bootstrap upgrade logic
+func ExtractBootstrapVersion(pdRepoRoot string) int64 {
+	path := findPDUpgradeLogicFile(pdRepoRoot)
+	if path == "" {
+		return 0
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	if m := pdCurrentBootstrapVersionRe.FindSubmatch(data); m != nil {
+		version, err := strconv.ParseInt(string(m[1]), 10, 64)
+		if err == nil {
+			return version
+		}
+	}
+	return 0
+}
+
+// configFieldToKebab converts a Go config field name to its kebab-case config key
+// (e.g., MaxMergeRegionSize -> max-merge-region-size, EnableTiKVSplitRegion -> enable-ti-kv-split-region)
+// Acronyms are kept together: LeaderScheduleTTL -> leader-schedule-ttl
+func configFieldToKebab(field string) string {
+	runes := []rune(field)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (unicode.IsUpper(runes[i-1]) && nextLower) {
+				b.WriteByte('-')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+// parsePDLiteral converts a Go literal of an assignment into a JSON value
+// Quoted strings are unquoted, numbers and booleans are parsed, other expressions are kept as written
+func parsePDLiteral(raw string) interface{} {
+	raw = strings.TrimSuffix(strings.TrimSpace(raw), ";")
+	if s, err := strconv.Unquote(raw); err == nil {
+		return s
+	}
+	if b, err := strconv.ParseBool(raw); err == nil {
+		return b
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		return n
+	}
+	return raw
+}
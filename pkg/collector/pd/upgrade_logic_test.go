@@ -0,0 +1,74 @@
+package pd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testPDBootstrap = `package member
+
+const currentBootstrapVersion = 2
+
+// upgradeToVer1 enables region merge by default
+func upgradeToVer1(cfg *config.Config) {
+	cfg.Schedule.MaxMergeRegionSize = 54 // in MiB
+	cfg.Replication.LocationLabels = []string{"zone"}
+}
+
+// upgradeToVer2 switches the scheduler
+func upgradeToVer2(cfg *config.Config) {
+	if cfg.Schedule.LeaderScheduleTTL == 0 {
+		cfg.Schedule.LeaderSchedulePolicy = "size"
+	}
+	cfg.PDServerCfg.EnableTiKVSplitRegion = true
+}
+`
+
+func TestCollectPDUpgradeLogicFromSource(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, "server", "member", "bootstrap.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(testPDBootstrap), 0644))
+
+	snapshot, err := CollectPDUpgradeLogicFromSource(root)
+	require.NoError(t, err)
+	assert.Equal(t, types.ComponentPD, snapshot.Component)
+
+	type change struct{ version, funcName, name string }
+	var got []change
+	for _, c := range snapshot.Changes {
+		got = append(got, change{c.Version, c.FuncName, c.Name})
+		assert.Equal(t, "config", c.Type)
+		assert.True(t, c.Force)
+	}
+	assert.Equal(t, []change{
+		{"1", "upgradeToVer1", "schedule.max-merge-region-size"},
+		{"1", "upgradeToVer1", "replication.location-labels"},
+		{"2", "upgradeToVer2", "schedule.leader-schedule-policy"},
+		{"2", "upgradeToVer2", "pd-server.enable-ti-kv-split-region"},
+	}, got)
+	assert.Equal(t, float64(54), snapshot.Changes[0].Value)
+	assert.Equal(t, "upgradeToVer1 enables region merge by default", snapshot.Changes[0].Description)
+	assert.Equal(t, "size", snapshot.Changes[2].Value)
+	assert.Equal(t, true, snapshot.Changes[3].Value)
+
+	assert.Equal(t, int64(2), ExtractBootstrapVersion(root))
+}
+
+func TestCollectPDUpgradeLogicFromSource_NoBootstrapLogic(t *testing.T) {
+	root := t.TempDir()
+
+	snapshot, err := CollectPDUpgradeLogicFromSource(root)
+	require.NoError(t, err)
+	assert.Equal(t, types.ComponentPD, snapshot.Component)
+	assert.Empty(t, snapshot.Changes)
+	assert.Zero(t, ExtractBootstrapVersion(root))
+
+	_, err = CollectPDUpgradeLogicFromSource(filepath.Join(root, "missing"))
+	assert.Error(t, err)
+}
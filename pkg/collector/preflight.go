@@ -0,0 +1,228 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// DefaultPreflightTimeout is the default time limit of each connection check of ValidateConnection
+// The checks run concurrently, so a validation completes within about this time
+const DefaultPreflightTimeout = 3 * time.Second
+
+// PreflightOptions configures ValidateConnection
+type PreflightOptions struct {
+	// Components are the components whose endpoints are required by the enabled rules
+	// Endpoints of other components are checked but don't fail the validation
+	Components []string
+	// SourceVersion is the source version given by the user ("" to detect it from TiDB or the topology)
+	SourceVersion string
+	// KnowledgeBasePath is the knowledge base directory the source version is looked up in
+	KnowledgeBasePath string
+	// Timeout limits each connection check (DefaultPreflightTimeout if <= 0)
+	Timeout time.Duration
+}
+
+// EndpointCheck is the verdict of the connection check of an endpoint
+type EndpointCheck struct {
+	// Component is the component type of the endpoint (tidb, pd, tikv, tiflash)
+	Component string `json:"component"`
+	// Address is the checked address
+	Address string `json:"address"`
+	// Check is what was attempted: "ping" (TiDB MySQL protocol), "health" (PD) or "status" (TiKV, TiFlash)
+	Check string `json:"check"`
+	// Required is set when the enabled rules need data from the endpoint
+	Required bool `json:"required"`
+	// Reachable is set when the check succeeded
+	Reachable bool `json:"reachable"`
+	// Version is the version reported by the endpoint, if any
+	Version string `json:"version,omitempty"`
+	// DurationMs is the time spent on the check, in milliseconds
+	DurationMs float64 `json:"duration_ms"`
+	// Error explains why the check failed
+	Error string `json:"error,omitempty"`
+	// Note explains a verdict that is not an error (e.g., a fallback used during collection)
+	Note string `json:"note,omitempty"`
+}
+
+// PreflightResult is the verdict of ValidateConnection
+type PreflightResult struct {
+	// OK is set when every required endpoint is reachable and the source version has knowledge
+	OK bool `json:"ok"`
+	// Endpoints contains the verdict of each endpoint, in component order
+	Endpoints []EndpointCheck `json:"endpoints"`
+	// SourceVersion is the normalized source version ("" if it could not be determined)
+	SourceVersion string `json:"source_version,omitempty"`
+	// SourceVersionFrom tells where the source version comes from: "flag", "tidb" or "topology"
+	SourceVersionFrom string `json:"source_version_from,omitempty"`
+	// SourceVersionInKB is set when the knowledge base has defaults for the source version
+	SourceVersionInKB bool `json:"source_version_in_kb"`
+	// Problems lists the reasons the validation failed
+	Problems []string `json:"problems,omitempty"`
+}
+
+// ValidateConnection checks that the cluster can be prechecked, without collecting any configuration
+// It attempts a minimal connection to each endpoint (a TiDB ping, a PD health call, a TiKV or TiFlash
+// status call), concurrently and each limited to opts.Timeout, then verifies that the source version
+// is parseable and present in the knowledge base
+func ValidateConnection(ctx context.Context, endpoints ClusterEndpoints, opts PreflightOptions) *PreflightResult {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultPreflightTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+
+	var checks []EndpointCheck
+	var probes []func(*EndpointCheck)
+	add := func(component, addr, check string, probe func(*EndpointCheck)) {
+		checks = append(checks, EndpointCheck{
+			Component: component,
+			Address:   addr,
+			Check:     check,
+			Required:  contains(opts.Components, component),
+		})
+		probes = append(probes, probe)
+	}
+	if endpoints.TiDBAddr != "" {
+		add("tidb", endpoints.TiDBAddr, "ping", func(check *EndpointCheck) {
+			version, err := tidb.Ping(ctx, endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword, timeout)
+			check.setResult(version, err)
+		})
+	}
+	for _, addr := range endpoints.PDAddrs {
+		addr := addr
+		add("pd", addr, "health", func(check *EndpointCheck) {
+			check.setResult("", getJSON(ctx, client, fmt.Sprintf("http://%s/pd/api/v1/health", addr), nil))
+		})
+	}
+	for _, addr := range endpoints.TiKVAddrs {
+		addr := addr
+		add("tikv", addr, "status", func(check *EndpointCheck) {
+			check.setResult(statusVersion(ctx, client, addr))
+		})
+	}
+	for _, addr := range endpoints.TiFlashAddrs {
+		addr := addr
+		add("tiflash", addr, "status", func(check *EndpointCheck) {
+			check.setResult(statusVersion(ctx, client, addr))
+		})
+	}
+
+	var wg sync.WaitGroup
+	for i := range probes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start := time.Now()
+			probes[i](&checks[i])
+			checks[i].DurationMs = float64(time.Since(start).Microseconds()) / 1000
+		}(i)
+	}
+	wg.Wait()
+
+	result := &PreflightResult{Endpoints: checks}
+	result.evaluate(endpoints, opts)
+	return result
+}
+
+// evaluate checks the endpoint verdicts and the source version, and sets OK and Problems
+func (r *PreflightResult) evaluate(endpoints ClusterEndpoints, opts PreflightOptions) {
+	tidbReachable := false
+	tidbVersion := ""
+	for _, check := range r.Endpoints {
+		if check.Component == "tidb" && check.Reachable {
+			tidbReachable = true
+			tidbVersion = check.Version
+		}
+	}
+
+	configured := make(map[string]bool)
+	for i := range r.Endpoints {
+		check := &r.Endpoints[i]
+		configured[check.Component] = true
+		// TiKV nodes whose status port is unreachable are collected through TiDB (information_schema.cluster_config)
+		if check.Component == "tikv" && !check.Reachable && tidbReachable {
+			check.Required = false
+			check.Note = "status port unreachable, configuration will be read through TiDB"
+		}
+		if check.Required && !check.Reachable {
+			r.Problems = append(r.Problems, fmt.Sprintf("%s endpoint %s is not reachable: %s", check.Component, check.Address, check.Error))
+		}
+	}
+	for _, component := range opts.Components {
+		// In SQL-only collection PD is read through TiDB
+		if component == "tidb" || (component == "pd" && !endpoints.SQLOnly) {
+			if !configured[component] {
+				r.Problems = append(r.Problems, fmt.Sprintf("no %s endpoint is configured", component))
+			}
+		}
+	}
+
+	// Source version: user input > cluster detection > topology file, like the precheck itself
+	switch {
+	case opts.SourceVersion != "":
+		r.SourceVersion, r.SourceVersionFrom = defaultsTypes.NormalizeVersion(opts.SourceVersion), "flag"
+	case tidbVersion != "":
+		r.SourceVersion, r.SourceVersionFrom = defaultsTypes.NormalizeVersion(tidbVersion), "tidb"
+	case endpoints.SourceVersion != "":
+		r.SourceVersion, r.SourceVersionFrom = defaultsTypes.NormalizeVersion(endpoints.SourceVersion), "topology"
+	}
+	if r.SourceVersion == "" {
+		r.SourceVersionFrom = ""
+		r.Problems = append(r.Problems, "source version could not be determined or parsed")
+	} else if listing, err := ListKnowledgeBase(opts.KnowledgeBasePath); err != nil {
+		r.Problems = append(r.Problems, err.Error())
+	} else if r.SourceVersionInKB = listing.HasVersion(r.SourceVersion); !r.SourceVersionInKB {
+		r.Problems = append(r.Problems, fmt.Sprintf("knowledge base for source version %s not found in %s", r.SourceVersion, opts.KnowledgeBasePath))
+	}
+
+	r.OK = len(r.Problems) == 0
+}
+
+// setResult records the outcome of a probe
+func (c *EndpointCheck) setResult(version string, err error) {
+	if err != nil {
+		c.Error = err.Error()
+		return
+	}
+	c.Reachable = true
+	c.Version = version
+}
+
+// statusVersion calls the /status API of a TiKV or TiFlash instance and returns the version it reports
+func statusVersion(ctx context.Context, client *http.Client, addr string) (string, error) {
+	var status struct {
+		Version string `json:"version"`
+	}
+	err := getJSON(ctx, client, fmt.Sprintf("http://%s/status", addr), &status)
+	return status.Version, err
+}
+
+// getJSON sends a GET request and decodes the JSON response into out (the body is ignored if out is nil)
+func getJSON(ctx context.Context, client *http.Client, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("invalid response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package collector
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// unreachableAddr returns the address of a port nothing listens on
+func unreachableAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return addr
+}
+
+func TestValidateConnection(t *testing.T) {
+	kbPath := t.TempDir()
+	writeTestDefaults(t, kbPath, "v7.5.0", "tidb", map[string]interface{}{
+		"config_defaults": map[string]interface{}{"a": 1},
+	})
+
+	pd := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/pd/api/v1/health" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`[{"name": "pd-1", "health": true}]`))
+	}))
+	defer pd.Close()
+	tikv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"version": "7.5.0", "git_hash": "abc"}`))
+	}))
+	defer tikv.Close()
+
+	pdAddr := strings.TrimPrefix(pd.URL, "http://")
+	tikvAddr := strings.TrimPrefix(tikv.URL, "http://")
+	opts := PreflightOptions{
+		Components:        []string{"pd", "tikv"},
+		SourceVersion:     "7.5.0",
+		KnowledgeBasePath: kbPath,
+		Timeout:           time.Second,
+	}
+
+	t.Run("all required endpoints reachable", func(t *testing.T) {
+		result := ValidateConnection(context.Background(), ClusterEndpoints{
+			PDAddrs:   []string{pdAddr},
+			TiKVAddrs: []string{tikvAddr},
+		}, opts)
+		assert.True(t, result.OK, result.Problems)
+		require.Len(t, result.Endpoints, 2)
+		assert.Equal(t, "health", result.Endpoints[0].Check)
+		assert.True(t, result.Endpoints[0].Reachable)
+		assert.Equal(t, "7.5.0", result.Endpoints[1].Version)
+		assert.Equal(t, "v7.5.0", result.SourceVersion)
+		assert.Equal(t, "flag", result.SourceVersionFrom)
+		assert.True(t, result.SourceVersionInKB)
+	})
+
+	t.Run("required endpoint unreachable", func(t *testing.T) {
+		result := ValidateConnection(context.Background(), ClusterEndpoints{
+			PDAddrs:   []string{unreachableAddr(t)},
+			TiKVAddrs: []string{tikvAddr},
+		}, opts)
+		assert.False(t, result.OK)
+		assert.False(t, result.Endpoints[0].Reachable)
+		assert.NotEmpty(t, result.Endpoints[0].Error)
+		require.Len(t, result.Problems, 1)
+		assert.Contains(t, result.Problems[0], "pd endpoint")
+	})
+
+	t.Run("endpoints of components the rules don't need are optional", func(t *testing.T) {
+		result := ValidateConnection(context.Background(), ClusterEndpoints{
+			PDAddrs:      []string{pdAddr},
+			TiKVAddrs:    []string{tikvAddr},
+			TiFlashAddrs: []string{unreachableAddr(t)},
+		}, opts)
+		assert.True(t, result.OK, result.Problems)
+		assert.False(t, result.Endpoints[2].Required)
+		assert.False(t, result.Endpoints[2].Reachable)
+	})
+
+	t.Run("missing endpoint of a required component", func(t *testing.T) {
+		result := ValidateConnection(context.Background(), ClusterEndpoints{
+			TiKVAddrs: []string{tikvAddr},
+		}, opts)
+		assert.False(t, result.OK)
+		assert.Equal(t, []string{"no pd endpoint is configured"}, result.Problems)
+	})
+
+	t.Run("source version", func(t *testing.T) {
+		endpoints := ClusterEndpoints{PDAddrs: []string{pdAddr}, SourceVersion: "v7.5.0"}
+
+		fromTopology := opts
+		fromTopology.SourceVersion = ""
+		result := ValidateConnection(context.Background(), endpoints, fromTopology)
+		assert.True(t, result.OK, result.Problems)
+		assert.Equal(t, "topology", result.SourceVersionFrom)
+
+		notInKB := opts
+		notInKB.SourceVersion = "v8.1.0"
+		result = ValidateConnection(context.Background(), endpoints, notInKB)
+		assert.False(t, result.OK)
+		assert.False(t, result.SourceVersionInKB)
+		assert.Contains(t, result.Problems[0], "knowledge base for source version v8.1.0 not found")
+
+		result = ValidateConnection(context.Background(), ClusterEndpoints{PDAddrs: []string{pdAddr}}, fromTopology)
+		assert.False(t, result.OK)
+		assert.Equal(t, []string{"source version could not be determined or parsed"}, result.Problems)
+	})
+}
@@ -0,0 +1,41 @@
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// ReleasesFile is the name of the list of published TiDB releases in the knowledge base directory
+// It is maintained with the knowledge base and used to reject target versions that were never released
+const ReleasesFile = "releases.json"
+
+// publishedReleasesFile is the structure of knowledge/releases.json
+type publishedReleasesFile struct {
+	Releases []string `json:"releases"`
+}
+
+// LoadPublishedReleases loads the published releases listed in the knowledge base, normalized to "vX.Y.Z"
+func LoadPublishedReleases(knowledgeBasePath string) ([]string, error) {
+	path := filepath.Join(knowledgeBasePath, ReleasesFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the published releases: %w", err)
+	}
+	var file publishedReleasesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	releases := make([]string, 0, len(file.Releases))
+	for _, release := range file.Releases {
+		version, isGroup, err := types.ParseUserVersion(release)
+		if err != nil || isGroup {
+			return nil, fmt.Errorf("%s: invalid release %q", path, release)
+		}
+		releases = append(releases, version)
+	}
+	return releases, nil
+}
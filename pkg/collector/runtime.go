@@ -14,14 +14,21 @@
 package collector
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/pd"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tiflash"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tikv"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/tracing"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // CollectDataRequirements defines what data needs to be collected from the cluster
@@ -35,6 +42,39 @@ type CollectDataRequirements struct {
 	NeedSystemVariables bool `json:"need_system_variables"`
 	// NeedAllTikvNodes indicates if all TiKV nodes' data is needed (for consistency checks)
 	NeedAllTikvNodes bool `json:"need_all_tikv_nodes"`
+	// NeedGlobalVariablesTable indicates if the rows of the mysql.global_variables table are needed
+	NeedGlobalVariablesTable bool `json:"need_global_variables_table"`
+	// NeedGCSafePoints indicates if the GC safepoint (mysql.tidb) and the PD service safepoints are needed
+	NeedGCSafePoints bool `json:"need_gc_safe_points"`
+	// NeedPlacement indicates if the resource groups, placement policies (information_schema) and PD store labels are needed
+	NeedPlacement bool `json:"need_placement"`
+	// NeedStores indicates if the TiKV stores registered in PD, with their versions and states, are needed
+	NeedStores bool `json:"need_stores"`
+}
+
+// ValidateRequirements checks that the requirements are consistent: every data item they need comes
+// from a component that is listed in Components. Rules declare their requirements independently and
+// the analyzer merges them by union, so a violation means that a rule declared a need without the
+// component it is collected from, and the data would silently not be collected
+func ValidateRequirements(req CollectDataRequirements) error {
+	var errs []error
+	need := func(flag bool, name string, components ...string) {
+		if !flag {
+			return
+		}
+		for _, component := range components {
+			if !contains(req.Components, component) {
+				errs = append(errs, fmt.Errorf("%s requires %q in components %v", name, component, req.Components))
+			}
+		}
+	}
+	need(req.NeedSystemVariables, "need_system_variables", "tidb")
+	need(req.NeedAllTikvNodes, "need_all_tikv_nodes", "tikv")
+	need(req.NeedGlobalVariablesTable, "need_global_variables_table", "tidb")
+	need(req.NeedGCSafePoints, "need_gc_safe_points", "tidb", "pd")
+	need(req.NeedPlacement, "need_placement", "tidb", "pd")
+	need(req.NeedStores, "need_stores", "pd")
+	return errors.Join(errs...)
 }
 
 // Collector is responsible for collecting runtime configuration from a TiDB cluster
@@ -47,22 +87,51 @@ type Collector struct {
 	tikvCollector tikv.TiKVCollector
 	// tiflashCollector handles TiFlash collection
 	tiflashCollector tiflash.TiFlashCollector
+	// checkpointOpts makes the collection resumable, disabled if its path is empty
+	checkpointOpts CheckpointOptions
 }
 
 // NewCollector creates a new runtime collector
+// PD and TiKV requests are limited to the default rate and concurrency (see NewCollectorWithThrottle)
 func NewCollector() *Collector {
+	return NewCollectorWithThrottle(common.NewDefaultThrottle())
+}
+
+// NewCollectorWithThrottle creates a runtime collector whose PD and TiKV HTTP requests, and concurrently
+// collected TiKV instances, are limited by throttle (nil means no limit)
+// This keeps collection from overwhelming the status APIs of large or busy clusters
+func NewCollectorWithThrottle(throttle *common.Throttle) *Collector {
 	return &Collector{
 		tidbCollector:    tidb.NewTiDBCollector(),
-		pdCollector:      pd.NewPDCollector(),
-		tikvCollector:    tikv.NewTiKVCollector(),
+		pdCollector:      pd.NewPDCollectorWithThrottle(throttle),
+		tikvCollector:    tikv.NewTiKVCollectorWithThrottle(throttle),
 		tiflashCollector: tiflash.NewTiFlashCollector(),
 	}
 }
 
+// SetSQLTimeout limits each SQL statement issued to TiDB to timeout (tidb.DefaultSQLTimeout by default)
+// A timeout <= 0 means no limit
+func (c *Collector) SetSQLTimeout(timeout time.Duration) {
+	c.tidbCollector = tidb.NewTiDBCollectorWithSQLTimeout(timeout)
+}
+
+// SetCheckpoint makes the collection resumable: each component and TiKV or TiFlash node is recorded in the
+// checkpoint file as soon as it is collected, and a collection finds the ones recorded by an interrupted
+// run of the same cluster (by PD cluster ID) in the file and only collects the others
+// The file is removed once the collection completes. Cluster-wide data (GC safepoints, placement, stores,
+// mysql.global_variables) is always collected again. Checkpointing requires PD, it is ignored in SQL-only mode
+func (c *Collector) SetCheckpoint(opts CheckpointOptions) {
+	c.checkpointOpts = opts
+}
+
 // Collect collects the runtime configuration from the cluster
 // If req is nil, collects all components with all data types (default behavior)
 // If req is provided, collects only the required components and data types (optimized)
-func (c *Collector) Collect(endpoints ClusterEndpoints, req *CollectDataRequirements) (*ClusterSnapshot, error) {
+// The collection is traced as a span, with a child span per component (see pkg/tracing)
+func (c *Collector) Collect(ctx context.Context, endpoints ClusterEndpoints, req *CollectDataRequirements) (snapshot *ClusterSnapshot, err error) {
+	ctx, span := tracing.StartSpan(ctx, "collector.Collect")
+	defer func() { tracing.EndSpan(span, err) }()
+
 	// If no requirements specified, collect everything
 	if req == nil {
 		defaultReq := CollectDataRequirements{
@@ -70,42 +139,119 @@ func (c *Collector) Collect(endpoints ClusterEndpoints, req *CollectDataRequirem
 			NeedConfig:          true,
 			NeedSystemVariables: true,
 			NeedAllTikvNodes:    true, // Collect all TiKV nodes by default
+			// mysql.global_variables is only read when a rule requires it
 		}
-		return c.collectWithRequirements(endpoints, defaultReq)
+		return c.collectWithRequirements(ctx, endpoints, defaultReq)
+	}
+	if err := ValidateRequirements(*req); err != nil {
+		return nil, fmt.Errorf("invalid collection requirements: %w", err)
 	}
-	return c.collectWithRequirements(endpoints, *req)
+	return c.collectWithRequirements(ctx, endpoints, *req)
 }
 
 // collectWithRequirements is the internal implementation that collects cluster data based on requirements
 // This allows optimizing collection by only gathering necessary data
-func (c *Collector) collectWithRequirements(endpoints ClusterEndpoints, req CollectDataRequirements) (*ClusterSnapshot, error) {
+func (c *Collector) collectWithRequirements(ctx context.Context, endpoints ClusterEndpoints, req CollectDataRequirements) (*ClusterSnapshot, error) {
+	if endpoints.SQLOnly {
+		if c.checkpointOpts.Path != "" {
+			fmt.Printf("Warning: checkpointing requires PD, the checkpoint %s is ignored in SQL-only mode\n", c.checkpointOpts.Path)
+		}
+		return c.collectSQLOnly(ctx, endpoints, req)
+	}
+
+	cp, err := c.openCheckpoint(endpoints)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := c.collectComponents(ctx, endpoints, req, cp)
+	cp.close(err == nil)
+	return snapshot, err
+}
+
+// openCheckpoint opens the checkpoint file of the collection of the cluster, nil if checkpointing is disabled
+func (c *Collector) openCheckpoint(endpoints ClusterEndpoints) (*checkpoint, error) {
+	if c.checkpointOpts.Path == "" {
+		return nil, nil
+	}
+	clusterID, err := c.pdCollector.ClusterID(endpoints.PDAddrs)
+	if err != nil {
+		return nil, fmt.Errorf("checkpointing requires the cluster ID from PD: %w", err)
+	}
+	cp, err := openCheckpoint(c.checkpointOpts, clusterID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(cp.collected) > 0 {
+		fmt.Printf("Resuming collection from checkpoint %s (%d components and nodes already collected)\n", cp.path, len(cp.collected))
+	}
+	return cp, nil
+}
+
+// collectComponents collects the cluster data, skipping the components and nodes recorded by cp and recording the others
+func (c *Collector) collectComponents(ctx context.Context, endpoints ClusterEndpoints, req CollectDataRequirements, cp *checkpoint) (*ClusterSnapshot, error) {
+
 	snapshot := &ClusterSnapshot{
 		Timestamp:  time.Now(),
 		Components: make(map[string]ComponentState),
+		// Each class of data is recorded once it is collected
+		CollectedData: []defaultsTypes.DataClass{},
+	}
+	if req.NeedConfig {
+		snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassConfig)
 	}
 
 	// Collect from TiDB if needed
 	if contains(req.Components, "tidb") && endpoints.TiDBAddr != "" {
 		if req.NeedConfig || req.NeedSystemVariables {
-			tidbState, err := c.tidbCollector.Collect(endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
-			if err != nil {
-				return nil, fmt.Errorf("failed to collect from TiDB: %w", err)
+			// Configuration is read from the HTTP status API first, falling back to SHOW CONFIG
+			statusAddr := endpoints.TiDBStatusAddr
+			if statusAddr == "" {
+				statusAddr = tidb.DefaultStatusAddr(endpoints.TiDBAddr)
 			}
-			snapshot.Components["tidb"] = *tidbState
+			// Without system variables, no SQL statement is issued if the status API is reachable
+			collect := c.tidbCollector.CollectWithStatusAddr
+			if !req.NeedSystemVariables {
+				collect = c.tidbCollector.CollectConfigWithStatusAddr
+			}
+			tidbState, ok := cp.lookup(TiDBComponent, "")
+			if !ok {
+				spanCtx, span := tracing.StartSpan(ctx, "collector.tidb", attribute.String("address", endpoints.TiDBAddr))
+				collected, err := collect(spanCtx, endpoints.TiDBAddr, statusAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+				tracing.EndSpan(span, err)
+				if err != nil {
+					return nil, fmt.Errorf("failed to collect from TiDB: %w", err)
+				}
+				tidbState = *collected
+				cp.record(TiDBComponent, "", tidbState)
+			}
+			tidbState.DeclaredStartupArgs = endpoints.DeclaredStartupArgs[endpoints.TiDBAddr]
+			snapshot.Components["tidb"] = tidbState
+			recordTiDBMissingPrivileges(snapshot, &tidbState)
+			// The variables are missing if the MySQL protocol endpoint was not reachable
+			if len(tidbState.Variables) > 0 {
+				snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassSystemVariables)
+			}
+			recordNodeVersion(snapshot, tidbState.Type, endpoints.TiDBAddr, tidbState.Version)
 			if snapshot.SourceVersion == "" && tidbState.Version != "" {
 				snapshot.SourceVersion = tidbState.Version
 			}
 		}
+		if req.NeedGlobalVariablesTable {
+			c.collectGlobalVariablesTable(endpoints, snapshot)
+		}
 	}
 
 	// Collect from PD if needed
 	if contains(req.Components, "pd") && len(endpoints.PDAddrs) > 0 {
 		if req.NeedConfig {
-			pdState, err := c.pdCollector.Collect(endpoints.PDAddrs)
+			pdState, err := c.collectPD(ctx, endpoints, cp)
 			if err != nil {
 				fmt.Printf("Warning: failed to collect from PD: %v\n", err)
 			} else {
+				pdAddr, _ := pdState.Status["address"].(string)
+				pdState.DeclaredStartupArgs = endpoints.DeclaredStartupArgs[pdAddr]
 				snapshot.Components["pd"] = *pdState
+				recordNodeVersion(snapshot, pdState.Type, pdAddr, pdState.Version)
 				if snapshot.SourceVersion == "" && pdState.Version != "" {
 					snapshot.SourceVersion = pdState.Version
 				}
@@ -113,6 +259,30 @@ func (c *Collector) collectWithRequirements(endpoints ClusterEndpoints, req Coll
 		}
 	}
 
+	// Collect the GC safepoints if needed
+	if req.NeedGCSafePoints {
+		snapshot.GCSafePoints = c.collectGCSafePoints(endpoints, snapshot)
+		if snapshot.GCSafePoints != nil {
+			snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassGCSafePoints)
+		}
+	}
+
+	// Collect the resource groups, placement policies and store labels if needed
+	if req.NeedPlacement {
+		snapshot.Placement = c.collectPlacement(ctx, endpoints, snapshot)
+		if snapshot.Placement != nil {
+			snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassPlacement)
+		}
+	}
+
+	// Collect the TiKV stores registered in PD if needed
+	if req.NeedStores {
+		snapshot.Stores = c.collectStores(endpoints)
+		if snapshot.Stores != nil {
+			snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassStores)
+		}
+	}
+
 	// Collect from TiKV if needed
 	if contains(req.Components, "tikv") && len(endpoints.TiKVAddrs) > 0 {
 		if req.NeedConfig {
@@ -127,28 +297,34 @@ func (c *Collector) collectWithRequirements(endpoints ClusterEndpoints, req Coll
 			if endpoints.TiDBAddr == "" {
 				return nil, fmt.Errorf("TiDB connection is required for TiKV collection in upgrade precheck scenario")
 			}
-			tikvStates, err := c.tikvCollector.CollectWithTiDB(
-				endpoints.TiKVAddrs, dataDirs,
-				endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+			spanCtx, span := tracing.StartSpan(ctx, "collector.tikv", attribute.StringSlice("addresses", endpoints.TiKVAddrs))
+			tikvAddrs, tikvStates, err := collectNodes(cp, TiKVComponent, endpoints.TiKVAddrs,
+				func(addrs []string, onCollected func(addr string, state ComponentState)) error {
+					_, err := c.tikvCollector.CollectWithTiDBProgress(spanCtx, addrs, dataDirs,
+						endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword, onCollected)
+					return err
+				})
+			tracing.EndSpan(span, err)
 			if err != nil {
 				return nil, fmt.Errorf("failed to collect from TiKV: %w", err)
 			}
 			// Store TiKV instances
 			// If NeedAllTikvNodes is false, only store the first one
 			// If true, store all nodes
+			// Versions are recorded for every node regardless, so mixed-version clusters can be detected
 			for i, state := range tikvStates {
-				if !req.NeedAllTikvNodes && i > 0 {
-					break // Only need first instance
-				}
-
-				addr := endpoints.TiKVAddrs[i]
+				addr := tikvAddrs[i]
 				if addrFromStatus, ok := state.Status["address"].(string); ok && addrFromStatus != "" {
 					addr = addrFromStatus
 				}
+				recordNodeVersion(snapshot, state.Type, addr, state.Version)
 
-				key := fmt.Sprintf("tikv-%s", addr)
-				key = strings.ReplaceAll(key, ":", "-")
-				key = strings.ReplaceAll(key, ".", "-")
+				if !req.NeedAllTikvNodes && i > 0 {
+					continue // Only need first instance's configuration
+				}
+
+				key := instanceKey("tikv", addr)
+				state.DeclaredStartupArgs = endpoints.DeclaredStartupArgs[tikvAddrs[i]]
 
 				if i == 0 {
 					snapshot.Components["tikv"] = state
@@ -165,27 +341,32 @@ func (c *Collector) collectWithRequirements(endpoints ClusterEndpoints, req Coll
 	// Collect from TiFlash if needed
 	if contains(req.Components, "tiflash") && len(endpoints.TiFlashAddrs) > 0 {
 		if req.NeedConfig {
-			// In upgrade precheck scenario, we always have an existing cluster and topology file
 			// Use CollectWithTiDB to collect each instance's full parameters via SHOW CONFIG
 			// and merge with HTTP API config for the most complete configuration
+			// Without a TiDB connection, only the HTTP API config is collected
 			if endpoints.TiDBAddr == "" {
-				return nil, fmt.Errorf("TiDB connection is required for TiFlash collection in upgrade precheck scenario")
+				fmt.Printf("Warning: no TiDB connection, TiFlash config is only collected from the HTTP API (parameters left at their default may be missing)\n")
 			}
-			tiflashStates, err := c.tiflashCollector.CollectWithTiDB(
-				endpoints.TiFlashAddrs,
-				endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+			_, span := tracing.StartSpan(ctx, "collector.tiflash", attribute.StringSlice("addresses", endpoints.TiFlashAddrs))
+			tiflashAddrs, tiflashStates, err := collectNodes(cp, TiFlashComponent, endpoints.TiFlashAddrs,
+				func(addrs []string, onCollected func(addr string, state ComponentState)) error {
+					_, err := c.tiflashCollector.CollectWithTiDBProgress(addrs,
+						endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword, onCollected)
+					return err
+				})
+			tracing.EndSpan(span, err)
 			if err != nil {
 				return nil, fmt.Errorf("failed to collect from TiFlash: %w", err)
 			}
 			for i, state := range tiflashStates {
-				addr := endpoints.TiFlashAddrs[i]
+				addr := tiflashAddrs[i]
 				if addrFromStatus, ok := state.Status["address"].(string); ok && addrFromStatus != "" {
 					addr = addrFromStatus
 				}
 
-				key := fmt.Sprintf("tiflash-%s", addr)
-				key = strings.ReplaceAll(key, ":", "-")
-				key = strings.ReplaceAll(key, ".", "-")
+				recordNodeVersion(snapshot, state.Type, addr, state.Version)
+
+				key := instanceKey("tiflash", addr)
 
 				if i == 0 {
 					snapshot.Components["tiflash"] = state
@@ -199,9 +380,242 @@ func (c *Collector) collectWithRequirements(endpoints ClusterEndpoints, req Coll
 		}
 	}
 
+	snapshot.ClusterInfo = buildClusterInfo(endpoints, snapshot)
+
 	return snapshot, nil
 }
 
+// collectPD collects the PD configuration, or takes it from the checkpoint
+func (c *Collector) collectPD(ctx context.Context, endpoints ClusterEndpoints, cp *checkpoint) (*ComponentState, error) {
+	if state, ok := cp.lookup(PDComponent, ""); ok {
+		return &state, nil
+	}
+	spanCtx, span := tracing.StartSpan(ctx, "collector.pd", attribute.StringSlice("addresses", endpoints.PDAddrs))
+	state, err := c.pdCollector.Collect(spanCtx, endpoints.PDAddrs)
+	tracing.EndSpan(span, err)
+	if err != nil {
+		return nil, err
+	}
+	cp.record(PDComponent, "", *state)
+	return state, nil
+}
+
+// collectNodes collects the nodes of a component at addrs with collect, except the ones recorded by cp,
+// recording each node in cp as soon as collect reports it
+// Returns the addresses and states of the collected nodes, in the order of addrs (nodes that failed are left out)
+func collectNodes(cp *checkpoint, component ComponentType, addrs []string,
+	collect func(addrs []string, onCollected func(addr string, state ComponentState)) error) ([]string, []ComponentState, error) {
+	collected := make(map[string]ComponentState, len(addrs))
+	var remaining []string
+	for _, addr := range addrs {
+		if state, ok := cp.lookup(component, addr); ok {
+			collected[addr] = state
+		} else {
+			remaining = append(remaining, addr)
+		}
+	}
+
+	if len(remaining) > 0 {
+		var mu sync.Mutex
+		err := collect(remaining, func(addr string, state ComponentState) {
+			mu.Lock()
+			collected[addr] = state
+			mu.Unlock()
+			cp.record(component, addr, state)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var nodeAddrs []string
+	var states []ComponentState
+	for _, addr := range addrs {
+		if state, ok := collected[addr]; ok {
+			nodeAddrs = append(nodeAddrs, addr)
+			states = append(states, state)
+		}
+	}
+	return nodeAddrs, states, nil
+}
+
+// collectGlobalVariablesTable reads the rows of mysql.global_variables into the snapshot
+// Reading the table requires the SELECT privilege on it, which the precheck user may lack
+// The table is left out of the snapshot in that case, rules relying on it report nothing
+func (c *Collector) collectGlobalVariablesTable(endpoints ClusterEndpoints, snapshot *ClusterSnapshot) {
+	rows, err := c.tidbCollector.CollectGlobalVariablesTable(endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+	if err != nil && tidb.IsUnknownTableError(err) {
+		fmt.Printf("Note: mysql.global_variables is not available, skipping its checks: %v\n", err)
+	} else if recordPrivilegeError(snapshot, err) {
+		fmt.Printf("Warning: %v, skipping the checks of mysql.global_variables\n", err)
+	} else if err != nil {
+		fmt.Printf("Warning: failed to read mysql.global_variables, skipping its checks: %v\n", err)
+	} else {
+		snapshot.GlobalVariablesTable = rows
+		snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassGlobalVariablesTable)
+	}
+}
+
+// collectGCSafePoints reads the GC safepoint from mysql.tidb and the service safepoints from PD
+// Both are best effort: a PD without the service safepoint list API leaves only the TiDB side,
+// and nil is returned if neither could be read. A missing privilege on mysql.tidb is recorded in snapshot
+func (c *Collector) collectGCSafePoints(endpoints ClusterEndpoints, snapshot *ClusterSnapshot) *defaultsTypes.GCSafePointState {
+	var state *defaultsTypes.GCSafePointState
+	if endpoints.TiDBAddr != "" {
+		tidbState, err := c.tidbCollector.CollectGCStatus(endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+		if err != nil && tidb.IsUnknownTableError(err) {
+			fmt.Printf("Note: mysql.tidb is not available, skipping the TiDB GC safepoint check: %v\n", err)
+		} else if recordPrivilegeError(snapshot, err) {
+			fmt.Printf("Warning: %v, skipping the TiDB GC safepoint check\n", err)
+		} else if err != nil {
+			fmt.Printf("Warning: failed to read the GC safepoint from mysql.tidb: %v\n", err)
+		} else {
+			state = tidbState
+		}
+	}
+
+	if len(endpoints.PDAddrs) > 0 {
+		gcSafePoint, safePoints, err := c.pdCollector.CollectServiceSafePoints(endpoints.PDAddrs)
+		if err != nil && errors.Is(err, pd.ErrServiceSafePointsUnsupported) {
+			fmt.Println("Note: PD does not provide the service GC safepoint list, only the TiDB GC safepoint is checked")
+		} else if err != nil {
+			fmt.Printf("Warning: failed to read the service GC safepoints from PD: %v\n", err)
+		} else {
+			if state == nil {
+				state = &defaultsTypes.GCSafePointState{}
+			}
+			state.PDGCSafePoint = gcSafePoint
+			state.ServiceSafePointsAvailable = true
+			state.ServiceSafePoints = safePoints
+		}
+	}
+	return state
+}
+
+// collectPlacement reads the resource groups and placement policies from TiDB and the store labels from PD
+// Tables that are missing or not readable are recorded as notes of the state, nil is returned if TiDB could not be read
+// Tables denied for missing privileges are recorded in snapshot
+func (c *Collector) collectPlacement(ctx context.Context, endpoints ClusterEndpoints, snapshot *ClusterSnapshot) *defaultsTypes.PlacementState {
+	if endpoints.TiDBAddr == "" {
+		return nil
+	}
+	state, err := c.tidbCollector.CollectPlacement(ctx, endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+	if err != nil {
+		fmt.Printf("Warning: failed to read the resource groups and placement policies: %v\n", err)
+		return nil
+	}
+	for _, note := range state.Notes {
+		fmt.Printf("Note: %s\n", note)
+	}
+	recordMissingPrivileges(snapshot, state.MissingPrivileges)
+
+	if len(endpoints.PDAddrs) == 0 {
+		state.Notes = append(state.Notes, "no PD address, store labels are not collected")
+		return state
+	}
+	stores, err := c.pdCollector.CollectStores(endpoints.PDAddrs)
+	if err != nil {
+		fmt.Printf("Warning: failed to read the stores from PD: %v\n", err)
+		state.Notes = append(state.Notes, fmt.Sprintf("store labels are not collected: %v", err))
+	} else {
+		state.StoresAvailable = true
+		state.Stores = stores
+	}
+	return state
+}
+
+// collectStores reads the TiKV stores registered in PD, with the TiKV addresses of the topology to compare them with
+// nil is returned if PD could not be read
+func (c *Collector) collectStores(endpoints ClusterEndpoints) *defaultsTypes.StoreState {
+	if len(endpoints.PDAddrs) == 0 {
+		return nil
+	}
+	stores, err := c.pdCollector.CollectStoreInfo(endpoints.PDAddrs)
+	if err != nil {
+		fmt.Printf("Warning: failed to read the stores from PD: %v\n", err)
+		return nil
+	}
+	return &defaultsTypes.StoreState{
+		Stores:            stores,
+		TopologyAddresses: append([]string(nil), endpoints.TiKVAddrs...),
+	}
+}
+
+// buildClusterInfo builds cluster-level topology metadata from the endpoints and collected components
+// The TiKV node count is taken from the topology, so it is correct even if only the first node is collected
+func buildClusterInfo(endpoints ClusterEndpoints, snapshot *ClusterSnapshot) ClusterInfo {
+	info := ClusterInfo{
+		TiKVNodeCount: len(endpoints.TiKVAddrs),
+	}
+
+	if pdState, ok := snapshot.Components["pd"]; ok {
+		info.PDLeaderAddr, _ = pdState.Status["leader_addr"].(string)
+		info.ClusterID, _ = pdState.Status["cluster_id"].(string)
+	}
+
+	if len(endpoints.TiKVAddrs) > 0 {
+		info.StorageEngines = append(info.StorageEngines, string(TiKVComponent))
+	}
+	if len(endpoints.TiFlashAddrs) > 0 {
+		info.StorageEngines = append(info.StorageEngines, string(TiFlashComponent))
+	}
+
+	return info
+}
+
+// recordNodeVersion records the version reported by a component instance in the snapshot
+// Both the raw version string and the normalized release version (vX.Y.Z) are kept
+func recordNodeVersion(snapshot *ClusterSnapshot, compType ComponentType, addr, rawVersion string) {
+	snapshot.NodeVersions = append(snapshot.NodeVersions, NodeVersion{
+		Component:  compType,
+		Address:    addr,
+		Version:    defaultsTypes.NormalizeVersion(rawVersion),
+		RawVersion: rawVersion,
+	})
+}
+
+// recordMissingPrivileges records the queries denied during collection in the snapshot (query IDs of the TiDB collector)
+func recordMissingPrivileges(snapshot *ClusterSnapshot, queries []string) {
+	for _, query := range queries {
+		if requirement, ok := tidb.LookupPrivilegeRequirement(query); ok {
+			snapshot.AddMissingPrivilege(requirement.MissingPrivilege())
+		}
+	}
+}
+
+// recordTiDBMissingPrivileges records the queries denied during the collection of a TiDB instance
+func recordTiDBMissingPrivileges(snapshot *ClusterSnapshot, state *ComponentState) {
+	switch queries := state.Status[tidb.StatusKeyMissingPrivileges].(type) {
+	case []string:
+		recordMissingPrivileges(snapshot, queries)
+	case []interface{}:
+		// Read back from a checkpoint
+		for _, query := range queries {
+			if query, ok := query.(string); ok {
+				recordMissingPrivileges(snapshot, []string{query})
+			}
+		}
+	}
+}
+
+// recordPrivilegeError records the query of err in the snapshot if it is a tidb.PrivilegeError
+// Returns false if err is not a privilege error
+func recordPrivilegeError(snapshot *ClusterSnapshot, err error) bool {
+	var privilegeErr *tidb.PrivilegeError
+	if !errors.As(err, &privilegeErr) {
+		return false
+	}
+	recordMissingPrivileges(snapshot, []string{privilegeErr.Query})
+	return true
+}
+
+// instanceKey is the key of a component instance in the snapshot, e.g. "tikv-10-0-0-1-20180"
+func instanceKey(prefix, addr string) string {
+	key := fmt.Sprintf("%s-%s", prefix, addr)
+	key = strings.ReplaceAll(key, ":", "-")
+	return strings.ReplaceAll(key, ".", "-")
+}
+
 // Helper function to check if a string slice contains a value
 func contains(slice []string, value string) bool {
 	for _, s := range slice {
@@ -1,10 +1,15 @@
 package collector
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"testing"
 
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestNewCollector(t *testing.T) {
@@ -49,7 +54,7 @@ func TestCollector_Collect(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := NewCollector()
-			_, err := c.Collect(tt.endpoints, tt.req)
+			_, err := c.Collect(context.Background(), tt.endpoints, tt.req)
 			// We expect errors because we can't actually connect to a real cluster
 			// But we can test that the function handles nil requirements correctly
 			if tt.req == nil {
@@ -63,6 +68,38 @@ func TestCollector_Collect(t *testing.T) {
 	}
 }
 
+func TestValidateRequirements(t *testing.T) {
+	assert.NoError(t, ValidateRequirements(CollectDataRequirements{
+		Components:          []string{"tidb", "pd", "tikv"},
+		NeedConfig:          true,
+		NeedSystemVariables: true,
+		NeedAllTikvNodes:    true,
+		NeedGCSafePoints:    true,
+	}))
+	assert.NoError(t, ValidateRequirements(CollectDataRequirements{Components: []string{"pd"}, NeedConfig: true}))
+
+	err := ValidateRequirements(CollectDataRequirements{
+		Components:          []string{"pd"},
+		NeedSystemVariables: true,
+		NeedAllTikvNodes:    true,
+		NeedGCSafePoints:    true,
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `need_system_variables requires "tidb" in components [pd]`)
+	assert.Contains(t, err.Error(), `need_all_tikv_nodes requires "tikv" in components [pd]`)
+	assert.Contains(t, err.Error(), `need_gc_safe_points requires "tidb" in components [pd]`)
+	assert.NotContains(t, err.Error(), `need_gc_safe_points requires "pd"`)
+	assert.ErrorContains(t, ValidateRequirements(CollectDataRequirements{Components: []string{"tikv"}, NeedStores: true}),
+		`need_stores requires "pd" in components [tikv]`)
+
+	// Collection is refused before connecting to the cluster
+	_, err = NewCollector().Collect(context.Background(), types.ClusterEndpoints{TiDBAddr: "127.0.0.1:4000"}, &CollectDataRequirements{
+		Components:       []string{"tidb"},
+		NeedAllTikvNodes: true,
+	})
+	assert.ErrorContains(t, err, "invalid collection requirements")
+}
+
 func TestContains(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -97,3 +134,46 @@ func TestContains(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildClusterInfo(t *testing.T) {
+	endpoints := types.ClusterEndpoints{
+		TiKVAddrs:    []string{"127.0.0.1:20180", "127.0.0.1:20181", "127.0.0.1:20182"},
+		TiFlashAddrs: []string{"127.0.0.1:3930"},
+	}
+	snapshot := &ClusterSnapshot{
+		Components: map[string]ComponentState{
+			"pd": {
+				Type: types.ComponentPD,
+				Status: map[string]interface{}{
+					"leader_addr": "http://127.0.0.1:2379",
+					"cluster_id":  "7301234567890123456",
+				},
+			},
+		},
+	}
+
+	info := buildClusterInfo(endpoints, snapshot)
+	assert.Equal(t, 3, info.TiKVNodeCount)
+	assert.Equal(t, "http://127.0.0.1:2379", info.PDLeaderAddr)
+	assert.Equal(t, "7301234567890123456", info.ClusterID)
+	assert.Equal(t, []string{"tikv", "tiflash"}, info.StorageEngines)
+}
+
+func TestRecordMissingPrivileges(t *testing.T) {
+	snapshot := &ClusterSnapshot{}
+	state := &ComponentState{Status: map[string]interface{}{tidb.StatusKeyMissingPrivileges: []string{string(tidb.SQLFeatureShowConfig)}}}
+	recordTiDBMissingPrivileges(snapshot, state)
+	// Denied twice, recorded once; unknown queries are not recorded
+	recordMissingPrivileges(snapshot, []string{string(tidb.SQLFeatureShowConfig), "unknown", string(tidb.SQLFeaturePlacementPolicies)})
+
+	assert.False(t, recordPrivilegeError(snapshot, nil))
+	assert.False(t, recordPrivilegeError(snapshot, errors.New("connection refused")))
+	assert.True(t, recordPrivilegeError(snapshot, fmt.Errorf("collect: %w", &tidb.PrivilegeError{Query: tidb.QueryTiDBStatus, Err: errors.New("denied")})))
+
+	require.Len(t, snapshot.MissingPrivileges, 3)
+	assert.Equal(t, "SHOW CONFIG", snapshot.MissingPrivileges[0].Query)
+	assert.Equal(t, "CONFIG", snapshot.MissingPrivileges[0].Privilege)
+	assert.Equal(t, "PLACEMENT_ADMIN", snapshot.MissingPrivileges[1].Privilege)
+	assert.Equal(t, "SELECT ON mysql.tidb", snapshot.MissingPrivileges[2].Privilege)
+	assert.Equal(t, types.DataClassGCSafePoints, snapshot.MissingPrivileges[2].DataClass)
+}
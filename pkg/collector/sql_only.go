@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tikv"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/tracing"
+	defaultsTypes "github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// sqlOnlyLimitations are the limitations of every SQL-only collection, reported in the header of the report
+var sqlOnlyLimitations = []string{
+	"Only the TiDB SQL endpoint was used: the PD, TiKV and TiFlash configuration, versions and stores were read through TiDB's information_schema (proxied)",
+	"Per-node configuration files (last_tikv.toml) and node resources are unavailable: parameters only set in files and resource-dependent checks may be missing",
+	"PD service GC safepoints and PD store labels are not collected",
+}
+
+// collectSQLOnly collects the cluster through the MySQL protocol endpoint of TiDB only (endpoints.SQLOnly)
+// TiDB is read with SQL only (no status API), the other components through information_schema
+func (c *Collector) collectSQLOnly(ctx context.Context, endpoints ClusterEndpoints, req CollectDataRequirements) (*ClusterSnapshot, error) {
+	if endpoints.TiDBAddr == "" {
+		return nil, errors.New("SQL-only collection requires the TiDB address")
+	}
+
+	var tidbState *ComponentState
+	if contains(req.Components, "tidb") && (req.NeedConfig || req.NeedSystemVariables) {
+		collect := c.tidbCollector.CollectWithStatusAddr
+		if !req.NeedSystemVariables {
+			collect = c.tidbCollector.CollectConfigWithStatusAddr
+		}
+		spanCtx, span := tracing.StartSpan(ctx, "collector.tidb", attribute.String("address", endpoints.TiDBAddr))
+		state, err := collect(spanCtx, endpoints.TiDBAddr, "", endpoints.TiDBUser, endpoints.TiDBPassword)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect from TiDB: %w", err)
+		}
+		tidbState = state
+	}
+
+	var cluster *tidb.ClusterViaSQL
+	if needsProxiedData(req) {
+		spanCtx, span := tracing.StartSpan(ctx, "collector.cluster_via_sql", attribute.String("address", endpoints.TiDBAddr))
+		state, err := c.tidbCollector.CollectClusterViaSQL(spanCtx, endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+		tracing.EndSpan(span, err)
+		if err != nil {
+			return nil, fmt.Errorf("failed to collect the cluster through TiDB: %w", err)
+		}
+		for _, note := range state.Notes {
+			fmt.Printf("Note: %s\n", note)
+		}
+		cluster = state
+	}
+
+	snapshot := NewSQLOnlySnapshot(tidbState, cluster, req)
+	if tidbState != nil {
+		recordTiDBMissingPrivileges(snapshot, tidbState)
+	}
+	if cluster != nil {
+		recordMissingPrivileges(snapshot, cluster.MissingPrivileges)
+	}
+	if contains(req.Components, "tidb") && req.NeedGlobalVariablesTable {
+		c.collectGlobalVariablesTable(endpoints, snapshot)
+	}
+	if req.NeedGCSafePoints {
+		snapshot.GCSafePoints = c.collectGCSafePoints(endpoints, snapshot)
+		if snapshot.GCSafePoints != nil {
+			snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassGCSafePoints)
+		}
+	}
+	if req.NeedPlacement {
+		snapshot.Placement = c.collectPlacement(ctx, endpoints, snapshot)
+		if snapshot.Placement != nil {
+			snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassPlacement)
+		}
+	}
+	return snapshot, nil
+}
+
+// needsProxiedData checks if the requirements need data of PD, TiKV or TiFlash
+func needsProxiedData(req CollectDataRequirements) bool {
+	if req.NeedStores {
+		return true
+	}
+	return req.NeedConfig && (contains(req.Components, "pd") || contains(req.Components, "tikv") || contains(req.Components, "tiflash"))
+}
+
+// NewSQLOnlySnapshot builds the snapshot of a SQL-only collection from the TiDB instance the precheck is
+// connected to and the cluster as seen through its information_schema (either may be nil)
+// The PD, TiKV and TiFlash components are marked as collected through TiDB (tikv.CollectedViaTiDBProxy),
+// and are laid out as in the full collection, so that rules run on them unchanged
+func NewSQLOnlySnapshot(tidbState *ComponentState, cluster *tidb.ClusterViaSQL, req CollectDataRequirements) *ClusterSnapshot {
+	snapshot := &ClusterSnapshot{
+		Timestamp:             time.Now(),
+		Components:            make(map[string]ComponentState),
+		CollectedData:         []defaultsTypes.DataClass{},
+		CollectionMode:        defaultsTypes.CollectionModeSQLOnly,
+		CollectionLimitations: append([]string(nil), sqlOnlyLimitations...),
+	}
+	if req.NeedConfig {
+		snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassConfig)
+	}
+
+	if tidbState != nil {
+		snapshot.Components["tidb"] = *tidbState
+		if len(tidbState.Variables) > 0 {
+			snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassSystemVariables)
+		}
+		snapshot.SourceVersion = tidbState.Version
+	}
+	if cluster == nil {
+		if tidbState != nil {
+			addr, _ := tidbState.Status["address"].(string)
+			recordNodeVersion(snapshot, tidbState.Type, addr, tidbState.Version)
+		}
+		return snapshot
+	}
+	snapshot.CollectionLimitations = append(snapshot.CollectionLimitations, cluster.Notes...)
+
+	// Every instance TiDB knows of is versioned, so that mixed-version clusters are still detected
+	tidbVersioned := false
+	for _, inst := range cluster.Instances {
+		if inst.Version == "" {
+			continue
+		}
+		recordNodeVersion(snapshot, inst.Type, instanceAddress(inst), inst.Version)
+		tidbVersioned = tidbVersioned || inst.Type == defaultsTypes.ComponentTiDB
+	}
+	if !tidbVersioned && tidbState != nil {
+		addr, _ := tidbState.Status["address"].(string)
+		recordNodeVersion(snapshot, tidbState.Type, addr, tidbState.Version)
+	}
+
+	nodes := make(map[defaultsTypes.ComponentType]int)
+	for _, inst := range cluster.Instances {
+		if inst.Type == defaultsTypes.ComponentTiDB {
+			continue
+		}
+		nodes[inst.Type]++
+		if !req.NeedConfig || inst.Config == nil || !contains(req.Components, string(inst.Type)) {
+			continue
+		}
+		addr := instanceAddress(inst)
+		state := ComponentState{
+			Type:      inst.Type,
+			Version:   inst.Version,
+			Config:    defaultsTypes.ConvertConfigToDefaults(inst.Config),
+			Variables: make(defaultsTypes.ParameterMap),
+			Status: map[string]interface{}{
+				"address":                  addr,
+				tikv.StatusKeyInstance:     inst.Instance,
+				tikv.StatusKeyCollectedVia: tikv.CollectedViaTiDBProxy,
+			},
+		}
+		first := nodes[inst.Type] == 1
+		switch inst.Type {
+		case defaultsTypes.ComponentPD:
+			// A single PD configuration is collected, as from the PD API
+			if first {
+				snapshot.Components["pd"] = state
+			}
+		case defaultsTypes.ComponentTiKV:
+			if first {
+				snapshot.Components["tikv"] = state
+			}
+			if first || req.NeedAllTikvNodes {
+				snapshot.Components[instanceKey("tikv", addr)] = state
+			}
+		case defaultsTypes.ComponentTiFlash:
+			if first {
+				snapshot.Components["tiflash"] = state
+			}
+			snapshot.Components[instanceKey("tiflash", addr)] = state
+		default:
+			continue
+		}
+		if snapshot.SourceVersion == "" && inst.Version != "" {
+			snapshot.SourceVersion = inst.Version
+		}
+	}
+
+	if req.NeedStores && cluster.StoresAvailable {
+		// The topology is what TiDB reports, there are no TiKV addresses of the user to compare the stores with
+		snapshot.Stores = &defaultsTypes.StoreState{Stores: cluster.Stores}
+		snapshot.CollectedData = append(snapshot.CollectedData, defaultsTypes.DataClassStores)
+	}
+
+	snapshot.ClusterInfo.TiKVNodeCount = nodes[defaultsTypes.ComponentTiKV]
+	if nodes[defaultsTypes.ComponentTiKV] > 0 {
+		snapshot.ClusterInfo.StorageEngines = append(snapshot.ClusterInfo.StorageEngines, string(TiKVComponent))
+	}
+	if nodes[defaultsTypes.ComponentTiFlash] > 0 {
+		snapshot.ClusterInfo.StorageEngines = append(snapshot.ClusterInfo.StorageEngines, string(TiFlashComponent))
+	}
+	return snapshot
+}
+
+// instanceAddress is the address of an instance seen through TiDB, as in the full collection:
+// the MySQL protocol address (INSTANCE) for TiDB, the status address for the other components
+// (or INSTANCE if cluster_info was not read)
+func instanceAddress(inst tidb.ClusterInstance) string {
+	if inst.Type != defaultsTypes.ComponentTiDB && inst.StatusAddress != "" {
+		return inst.StatusAddress
+	}
+	return inst.Instance
+}
@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tikv"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSQLOnlySnapshot(t *testing.T) {
+	cluster := &tidb.ClusterViaSQL{
+		Instances: []tidb.ClusterInstance{
+			{Type: types.ComponentPD, Instance: "10.0.0.2:2379", StatusAddress: "10.0.0.2:2379", Version: "7.5.0",
+				Config: map[string]interface{}{"replication.max-replicas": float64(3)}},
+			{Type: types.ComponentTiDB, Instance: "10.0.0.1:4000", StatusAddress: "10.0.0.1:10080", Version: "7.5.0"},
+			{Type: types.ComponentTiKV, Instance: "10.0.0.3:20160", StatusAddress: "10.0.0.3:20180", Version: "7.5.0",
+				Config: map[string]interface{}{"storage.reserve-space": "5GiB"}},
+			{Type: types.ComponentTiKV, Instance: "10.0.0.4:20160", StatusAddress: "10.0.0.4:20180", Version: "7.1.0",
+				Config: map[string]interface{}{"storage.reserve-space": "2GiB"}},
+		},
+		StoresAvailable: true,
+		Stores:          []types.StoreInfo{{ID: 1, Address: "10.0.0.3:20160", StateName: "Up"}},
+		Notes:           []string{"cluster_info: missing privileges"},
+	}
+	tidbState := &ComponentState{Type: types.ComponentTiDB, Version: "v7.5.0", Variables: types.ParameterMap{"tidb_gc_life_time": {Value: "10m0s"}}}
+
+	snapshot := NewSQLOnlySnapshot(tidbState, cluster, CollectDataRequirements{
+		Components:       []string{"tidb", "pd", "tikv"},
+		NeedConfig:       true,
+		NeedAllTikvNodes: true,
+		NeedStores:       true,
+	})
+	assert.Equal(t, types.CollectionModeSQLOnly, snapshot.CollectionMode)
+	assert.Contains(t, snapshot.CollectionLimitations, "cluster_info: missing privileges")
+	assert.Equal(t, "v7.5.0", snapshot.SourceVersion)
+	assert.ElementsMatch(t, []string{"tidb", "pd", "tikv", "tikv-10-0-0-3-20180", "tikv-10-0-0-4-20180"}, componentNames(snapshot))
+
+	node := snapshot.Components["tikv-10-0-0-4-20180"]
+	assert.Equal(t, "2GiB", node.Config["storage.reserve-space"].Value)
+	assert.Equal(t, "10.0.0.4:20180", node.Status["address"])
+	assert.Equal(t, "10.0.0.4:20160", node.Status[tikv.StatusKeyInstance])
+	assert.Equal(t, tikv.CollectedViaTiDBProxy, node.Status[tikv.StatusKeyCollectedVia])
+	assert.Equal(t, float64(3), snapshot.Components["pd"].Config["replication.max-replicas"].Value)
+
+	// Every instance is versioned, so that a partial upgrade is still detected
+	require.Len(t, snapshot.NodeVersions, 4)
+	require.NotNil(t, snapshot.Stores)
+	assert.Len(t, snapshot.Stores.Stores, 1)
+	assert.Empty(t, snapshot.Stores.TopologyAddresses)
+	assert.Equal(t, 2, snapshot.ClusterInfo.TiKVNodeCount)
+	assert.ElementsMatch(t, []types.DataClass{types.DataClassConfig, types.DataClassSystemVariables, types.DataClassStores}, snapshot.CollectedData)
+
+	// Without the cluster (e.g., only TiDB is needed) the snapshot has TiDB only
+	snapshot = NewSQLOnlySnapshot(tidbState, nil, CollectDataRequirements{Components: []string{"tidb"}, NeedSystemVariables: true})
+	assert.Equal(t, []string{"tidb"}, componentNames(snapshot))
+	assert.Len(t, snapshot.CollectionLimitations, len(sqlOnlyLimitations))
+}
+
+func componentNames(snapshot *ClusterSnapshot) []string {
+	var names []string
+	for name := range snapshot.Components {
+		names = append(names, name)
+	}
+	return names
+}
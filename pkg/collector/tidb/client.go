@@ -0,0 +1,141 @@
+package tidb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// DefaultStatusPort is the default port of the TiDB HTTP status API
+const DefaultStatusPort = "10080"
+
+// TiDBHTTPClient reads TiDB information from the HTTP status API
+// The status API does not require MySQL credentials, so configuration can be collected
+// even when the MySQL port is restricted but the status port is reachable
+type TiDBHTTPClient struct {
+	// Addr is the status API endpoint (host:status_port)
+	Addr string
+	// HTTPClient is the client used for requests (a client with a 10s timeout is used if nil)
+	HTTPClient *http.Client
+}
+
+// NewTiDBHTTPClient creates a new client for the status API at addr
+func NewTiDBHTTPClient(addr string) *TiDBHTTPClient {
+	return &TiDBHTTPClient{
+		Addr: addr,
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// DefaultStatusAddr returns the status API address for a MySQL protocol address, using the default status port
+func DefaultStatusAddr(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.JoinHostPort(host, DefaultStatusPort)
+}
+
+// GetConfig gets the running configuration via /config
+// The nested configuration is returned as is (use FlattenConfig to get SHOW CONFIG style names)
+// Numbers are decoded as int64 when they are integral, float64 otherwise, as with SHOW CONFIG
+func (c *TiDBHTTPClient) GetConfig(ctx context.Context) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	if err := c.get(ctx, "/config", &config); err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(config).(map[string]interface{}), nil
+}
+
+// GetStatus gets the server status via /status (connections, version, git_hash)
+func (c *TiDBHTTPClient) GetStatus(ctx context.Context) (map[string]interface{}, error) {
+	var status map[string]interface{}
+	if err := c.get(ctx, "/status", &status); err != nil {
+		return nil, err
+	}
+	return normalizeJSONNumbers(status).(map[string]interface{}), nil
+}
+
+// get performs a GET request on the status API and decodes the JSON response
+func (c *TiDBHTTPClient) get(ctx context.Context, path string, out interface{}) error {
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	url := fmt.Sprintf("http://%s%s", c.Addr, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request for %s: %w", url, err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP request %s failed with status: %d", url, resp.StatusCode)
+	}
+
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response of %s: %w", url, err)
+	}
+	return nil
+}
+
+// normalizeJSONNumbers converts json.Number values to int64 or float64, recursively
+func normalizeJSONNumbers(v interface{}) interface{} {
+	switch val := v.(type) {
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+		return val.String()
+	case map[string]interface{}:
+		for k, item := range val {
+			val[k] = normalizeJSONNumbers(item)
+		}
+		return val
+	case []interface{}:
+		for i, item := range val {
+			val[i] = normalizeJSONNumbers(item)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// FlattenConfig flattens a nested configuration using dot notation (e.g., {"log": {"level": "info"}} -> "log.level")
+// This matches the parameter names returned by SHOW CONFIG and used in the knowledge base
+func FlattenConfig(config map[string]interface{}) map[string]interface{} {
+	result := make(map[string]interface{})
+	flattenConfigInto(result, config, "")
+	return result
+}
+
+// flattenConfigInto adds the flattened entries of config to result
+func flattenConfigInto(result, config map[string]interface{}, prefix string) {
+	for k, v := range config {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]interface{}); ok && len(nested) > 0 {
+			flattenConfigInto(result, nested, key)
+			continue
+		}
+		result[key] = v
+	}
+}
@@ -0,0 +1,96 @@
+package tidb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStatusServer(t *testing.T) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/config", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"log": {"level": "info", "file": {"max-size": 300}}, "mem-quota-query": 1073741824, "oom-action": "cancel", "labels": {}, "performance": {"max-procs": 0, "cross-join": true, "feedback-probability": 0.05}}`))
+	})
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"connections": 3, "version": "8.0.11-TiDB-v7.5.0", "git_hash": "abc"}`))
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTiDBHTTPClient(t *testing.T) {
+	server := newTestStatusServer(t)
+	client := NewTiDBHTTPClient(strings.TrimPrefix(server.URL, "http://"))
+
+	config, err := client.GetConfig(context.Background())
+	require.NoError(t, err)
+	flat := FlattenConfig(config)
+	assert.Equal(t, "info", flat["log.level"])
+	assert.Equal(t, int64(300), flat["log.file.max-size"])
+	assert.Equal(t, int64(1073741824), flat["mem-quota-query"])
+	assert.Equal(t, 0.05, flat["performance.feedback-probability"])
+	assert.Equal(t, true, flat["performance.cross-join"])
+	// Empty sections are kept as values
+	assert.Equal(t, map[string]interface{}{}, flat["labels"])
+
+	status, err := client.GetStatus(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "8.0.11-TiDB-v7.5.0", status["version"])
+	assert.Equal(t, int64(3), status["connections"])
+
+	_, err = NewTiDBHTTPClient(strings.TrimPrefix(server.URL, "http://") + "/missing").GetConfig(context.Background())
+	assert.Error(t, err)
+}
+
+func TestCollectWithStatusAddr_MySQLUnavailable(t *testing.T) {
+	server := newTestStatusServer(t)
+	collector := NewTiDBCollector()
+
+	// Nothing listens on the MySQL address: configuration comes from the status API only
+	state, err := collector.CollectWithStatusAddr(context.Background(), "127.0.0.1:1", strings.TrimPrefix(server.URL, "http://"), "root", "")
+	require.NoError(t, err)
+	assert.Equal(t, "8.0.11-TiDB-v7.5.0", state.Version)
+	assert.Equal(t, "info", state.Config["log.level"].Value)
+	assert.Empty(t, state.Variables)
+
+	// Neither endpoint is reachable
+	_, err = collector.CollectWithStatusAddr(context.Background(), "127.0.0.1:1", "127.0.0.1:1", "root", "")
+	assert.ErrorContains(t, err, "failed to get TiDB version")
+}
+
+func TestCollectConfigWithStatusAddr(t *testing.T) {
+	server := newTestStatusServer(t)
+	collector := NewTiDBCollector().(*tidbCollector)
+	opened := 0
+	collector.openDB = func(dsn string) (*sql.DB, error) {
+		opened++
+		return nil, fmt.Errorf("SQL access is not allowed")
+	}
+
+	// The status API is reachable: no SQL connection is opened
+	state, err := collector.CollectConfigWithStatusAddr(context.Background(), "127.0.0.1:4000", strings.TrimPrefix(server.URL, "http://"), "root", "")
+	require.NoError(t, err)
+	assert.Equal(t, 0, opened)
+	assert.Equal(t, "8.0.11-TiDB-v7.5.0", state.Version)
+	assert.Equal(t, "info", state.Config["log.level"].Value)
+	assert.Empty(t, state.Variables)
+
+	// Otherwise the configuration is read with SQL
+	_, err = collector.CollectConfigWithStatusAddr(context.Background(), "127.0.0.1:4000", "", "root", "")
+	assert.ErrorContains(t, err, "SQL access is not allowed")
+	assert.Equal(t, 1, opened)
+}
+
+func TestDefaultStatusAddr(t *testing.T) {
+	assert.Equal(t, "10.0.0.1:10080", DefaultStatusAddr("10.0.0.1:4000"))
+	assert.Equal(t, "tidb.local:10080", DefaultStatusAddr("tidb.local"))
+	assert.Equal(t, "[::1]:10080", DefaultStatusAddr("[::1]:4000"))
+}
@@ -0,0 +1,161 @@
+package tidb
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+const (
+	clusterInfoQuery     = "SELECT `TYPE`, `INSTANCE`, `STATUS_ADDRESS`, `VERSION` FROM information_schema.cluster_info"
+	clusterConfigQuery   = "SELECT `TYPE`, `INSTANCE`, `KEY`, `VALUE` FROM information_schema.cluster_config WHERE `TYPE` IN ('pd', 'tikv', 'tiflash')"
+	tikvStoreStatusQuery = "SELECT `STORE_ID`, `ADDRESS`, `STORE_STATE_NAME`, `LABEL`, `VERSION` FROM information_schema.tikv_store_status"
+)
+
+// ClusterInstance is an instance of the cluster as reported by TiDB's information_schema
+type ClusterInstance struct {
+	// Type is the component type (TYPE column)
+	Type types.ComponentType
+	// Instance is the address the instance is identified by (INSTANCE column, ip:port)
+	Instance string
+	// StatusAddress is the status (HTTP API) address of the instance, empty if cluster_info was not read
+	StatusAddress string
+	// Version is the version the instance reports, empty if cluster_info was not read
+	Version string
+	// Config is the effective configuration of the instance (cluster_config), nil for TiDB or if it was not read
+	Config map[string]interface{}
+}
+
+// ClusterViaSQL is the cluster as seen through TiDB's information_schema, for clusters where only the
+// MySQL protocol endpoint is reachable (SQL-only collection)
+type ClusterViaSQL struct {
+	// Instances are the instances of the cluster, sorted by type and instance
+	Instances []ClusterInstance
+	// StoresAvailable is set if information_schema.tikv_store_status was read
+	StoresAvailable bool
+	// Stores are the TiKV stores registered in PD (TiFlash stores excluded)
+	Stores []types.StoreInfo
+	// Notes explain the views that could not be read
+	Notes []string
+	// MissingPrivileges are the queries denied for missing privileges (see PrivilegeRequirements)
+	MissingPrivileges []string
+}
+
+// CollectClusterViaSQL reads the instances of the cluster, the configuration of the PD, TiKV and TiFlash instances
+// and the TiKV stores from TiDB's information_schema (cluster_info, cluster_config and tikv_store_status)
+// The data is proxied by TiDB: node-local fields (last_tikv.toml, resources) are not available
+// Views the server doesn't have or the user can't read are left out with a note, as in CollectPlacement
+func (c *tidbCollector) CollectClusterViaSQL(ctx context.Context, addr, user, password string) (*ClusterViaSQL, error) {
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	version, err := c.getVersion(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TiDB version: %w", err)
+	}
+	caps := NewSQLCapabilities(version)
+
+	state := &ClusterViaSQL{}
+	instances := make(map[string]*ClusterInstance)
+	instance := func(compType, addr string) *ClusterInstance {
+		key := compType + "/" + addr
+		if instances[key] == nil {
+			instances[key] = &ClusterInstance{Type: types.ComponentType(compType), Instance: addr}
+		}
+		return instances[key]
+	}
+
+	if note := c.readFeatureTable(ctx, db, caps, SQLFeatureClusterInfo, clusterInfoQuery, &state.MissingPrivileges, func(rows *sql.Rows) error {
+		var compType, addr, statusAddr, version sql.NullString
+		if err := rows.Scan(&compType, &addr, &statusAddr, &version); err != nil {
+			return err
+		}
+		inst := instance(compType.String, addr.String)
+		inst.StatusAddress = statusAddr.String
+		inst.Version = version.String
+		return nil
+	}); note != "" {
+		state.Notes = append(state.Notes, note)
+	}
+
+	if note := c.readFeatureTable(ctx, db, caps, SQLFeatureShowConfig, clusterConfigQuery, &state.MissingPrivileges, func(rows *sql.Rows) error {
+		var compType, addr, key, value string
+		if err := rows.Scan(&compType, &addr, &key, &value); err != nil {
+			return err
+		}
+		inst := instance(compType, addr)
+		if inst.Config == nil {
+			inst.Config = make(map[string]interface{})
+		}
+		inst.Config[key] = ParseConfigValue(value)
+		return nil
+	}); note != "" {
+		state.Notes = append(state.Notes, note)
+	}
+
+	var stores []types.StoreInfo
+	if note := c.readFeatureTable(ctx, db, caps, SQLFeatureTiKVStoreStatus, tikvStoreStatusQuery, &state.MissingPrivileges, func(rows *sql.Rows) error {
+		var id sql.NullInt64
+		var addr, stateName, label, version sql.NullString
+		if err := rows.Scan(&id, &addr, &stateName, &label, &version); err != nil {
+			return err
+		}
+		if isTiFlashStoreLabel(label.String) {
+			return nil
+		}
+		stores = append(stores, types.StoreInfo{
+			ID:        uint64(id.Int64),
+			Address:   addr.String,
+			Version:   version.String,
+			StateName: stateName.String,
+		})
+		return nil
+	}); note != "" {
+		state.Notes = append(state.Notes, note)
+	} else {
+		state.StoresAvailable = true
+	}
+
+	for _, inst := range instances {
+		state.Instances = append(state.Instances, *inst)
+	}
+	sort.Slice(state.Instances, func(i, j int) bool {
+		if state.Instances[i].Type != state.Instances[j].Type {
+			return state.Instances[i].Type < state.Instances[j].Type
+		}
+		return state.Instances[i].Instance < state.Instances[j].Instance
+	})
+
+	// tikv_store_status has no status address, it is taken from the TiKV instance of the store
+	for i := range stores {
+		if inst, ok := instances[string(types.ComponentTiKV)+"/"+stores[i].Address]; ok {
+			stores[i].StatusAddress = inst.StatusAddress
+		}
+	}
+	state.Stores = stores
+	return state, nil
+}
+
+// isTiFlashStoreLabel checks if the LABEL column of tikv_store_status marks a TiFlash store (engine=tiflash)
+func isTiFlashStoreLabel(label string) bool {
+	var labels []struct {
+		Key   string `json:"key"`
+		Value string `json:"value"`
+	}
+	if label == "" || json.Unmarshal([]byte(label), &labels) != nil {
+		return false
+	}
+	for _, l := range labels {
+		if l.Key == "engine" && l.Value == "tiflash" {
+			return true
+		}
+	}
+	return false
+}
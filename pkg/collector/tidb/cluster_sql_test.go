@@ -0,0 +1,78 @@
+package tidb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectClusterViaSQL(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.11-TiDB-v7.5.0"))
+	mock.ExpectQuery(clusterInfoQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"TYPE", "INSTANCE", "STATUS_ADDRESS", "VERSION"}).
+			AddRow("tidb", "10.0.0.1:4000", "10.0.0.1:10080", "7.5.0").
+			AddRow("pd", "10.0.0.2:2379", "10.0.0.2:2379", "7.5.0").
+			AddRow("tikv", "10.0.0.4:20160", "10.0.0.4:20180", "7.5.0").
+			AddRow("tikv", "10.0.0.3:20160", "10.0.0.3:20180", "7.5.0").
+			AddRow("tiflash", "10.0.0.5:3930", "10.0.0.5:20292", "7.5.0"))
+	mock.ExpectQuery(clusterConfigQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"TYPE", "INSTANCE", "KEY", "VALUE"}).
+			AddRow("pd", "10.0.0.2:2379", "replication.max-replicas", "3").
+			AddRow("tikv", "10.0.0.3:20160", "storage.reserve-space", "5GiB").
+			AddRow("tikv", "10.0.0.4:20160", "storage.reserve-space", "2GiB"))
+	mock.ExpectQuery(tikvStoreStatusQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"STORE_ID", "ADDRESS", "STORE_STATE_NAME", "LABEL", "VERSION"}).
+			AddRow(1, "10.0.0.3:20160", "Up", "null", "7.5.0").
+			AddRow(2, "10.0.0.4:20160", "Offline", "[]", "7.5.0").
+			AddRow(3, "10.0.0.5:3930", "Up", `[{"key": "engine", "value": "tiflash"}]`, "7.5.0"))
+
+	state, err := collector.CollectClusterViaSQL(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	require.Len(t, state.Instances, 5)
+	assert.Equal(t, types.ComponentPD, state.Instances[0].Type)
+	assert.Equal(t, float64(3), state.Instances[0].Config["replication.max-replicas"])
+	assert.Equal(t, types.ComponentTiDB, state.Instances[1].Type)
+	assert.Nil(t, state.Instances[1].Config)
+	assert.Equal(t, types.ComponentTiFlash, state.Instances[2].Type)
+	// Instances of a type are sorted by address
+	assert.Equal(t, "10.0.0.3:20160", state.Instances[3].Instance)
+	assert.Equal(t, "10.0.0.3:20180", state.Instances[3].StatusAddress)
+	assert.Equal(t, "5GiB", state.Instances[3].Config["storage.reserve-space"])
+
+	assert.True(t, state.StoresAvailable)
+	require.Len(t, state.Stores, 2, "TiFlash stores are excluded")
+	assert.Equal(t, uint64(2), state.Stores[1].ID)
+	assert.Equal(t, "Offline", state.Stores[1].StateName)
+	assert.Equal(t, "10.0.0.4:20180", state.Stores[1].StatusAddress)
+	assert.Empty(t, state.Notes)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollectClusterViaSQL_Degraded(t *testing.T) {
+	// A view the user can't read leaves a note, the other views are still read
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.11-TiDB-v7.5.0"))
+	mock.ExpectQuery(clusterInfoQuery).
+		WillReturnError(&mysql.MySQLError{Number: errTableAccessDenied, Message: "SELECT command denied to user 'precheck'@'%' for table 'cluster_info'"})
+	mock.ExpectQuery(clusterConfigQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"TYPE", "INSTANCE", "KEY", "VALUE"}).
+			AddRow("tikv", "10.0.0.3:20160", "storage.reserve-space", "5GiB"))
+	mock.ExpectQuery(tikvStoreStatusQuery).
+		WillReturnError(&mysql.MySQLError{Number: errTableAccessDenied, Message: "SELECT command denied to user 'precheck'@'%' for table 'tikv_store_status'"})
+
+	state, err := collector.CollectClusterViaSQL(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	require.Len(t, state.Instances, 1)
+	assert.Empty(t, state.Instances[0].StatusAddress)
+	assert.Empty(t, state.Instances[0].Version)
+	assert.False(t, state.StoresAvailable)
+	require.Len(t, state.Notes, 2)
+	assert.Contains(t, state.Notes[0], "missing privileges")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
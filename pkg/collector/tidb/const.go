@@ -20,6 +20,7 @@ func RequiredFilesForSparseCheckout(version string) []string {
 			// System variable files
 			"pkg/sessionctx/variable/sysvar.go",
 			"sessionctx/variable/sysvar.go",
+			"sessionctx/variable/tidb_vars.go",
 			// Vardef directory (contains system variable definitions)
 			"pkg/sessionctx/vardef",
 			"sessionctx/vardef",
@@ -60,6 +61,8 @@ func RequiredFilesForSparseCheckout(version string) []string {
 		"config/config.go",
 		// System variable files
 		"sessionctx/variable/sysvar.go",
+		// Default values referenced by sysvar.go (v5.4, v6.5)
+		"sessionctx/variable/tidb_vars.go",
 		// Vardef directory
 		"sessionctx/vardef",
 		// Upgrade logic files
@@ -45,7 +45,7 @@ func ExtractBootstrapVersion(tidbRoot, version string) int64 {
 // extractBootstrapVersion extracts bootstrap version from TiDB source code
 // The currentBootstrapVersion is defined in:
 //   - pkg/session/upgrade.go (or session/upgrade.go) for newer versions
-//   - pkg/session/bootstrap.go (or session/bootstrap.go) for older versions (e.g., v6.5.0)
+//   - pkg/session/bootstrap.go (or session/bootstrap.go) for older versions (e.g., v5.4.0, v6.5.0)
 // It's defined as: var currentBootstrapVersion int64 = versionXXX
 // We need to find this assignment and resolve the versionXXX constant to its numeric value
 // IMPORTANT: This function will checkout the TiDB repository to the specified version before extraction
@@ -83,7 +83,7 @@ func extractBootstrapVersion(tidbRoot, version string) int64 {
 			filepath.Join(tidbRoot, "pkg", "session", "bootstrap.go"), // Fallback for older versions
 		}
 	} else {
-		// TiDB < 7.5 (including v6.5.x)
+		// TiDB < 7.5 (including v5.4.x and v6.5.x, v5.4 has no upgrade.go)
 		possiblePaths = []string{
 			filepath.Join(tidbRoot, "session", "upgrade.go"),
 			filepath.Join(tidbRoot, "session", "bootstrap.go"), // Fallback for older versions (e.g., v6.5.0)
@@ -96,51 +96,60 @@ func extractBootstrapVersion(tidbRoot, version string) int64 {
 			continue
 		}
 
-		content := string(data)
+		if version := parseBootstrapVersion(string(data)); version > 0 {
+			return version
+		}
+	}
 
-		// First, try direct assignment: currentBootstrapVersion = 123
-		re := regexp.MustCompile(`currentBootstrapVersion\s*=\s*(\d+)`)
-		matches := re.FindStringSubmatch(content)
-		if len(matches) > 1 {
-			if version, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
-				return version
-			}
+	return 0
+}
+
+// parseBootstrapVersion parses currentBootstrapVersion from the content of upgrade.go or bootstrap.go
+// Returns 0 if the version is not found
+// v5.4 declares it in a var block of session/bootstrap.go (currentBootstrapVersion int64 = version81)
+func parseBootstrapVersion(content string) int64 {
+	// First, try direct assignment: currentBootstrapVersion = 123 (or var currentBootstrapVersion int64 = 123)
+	re := regexp.MustCompile(`currentBootstrapVersion(?:\s+int64)?\s*=\s*(\d+)`)
+	matches := re.FindStringSubmatch(content)
+	if len(matches) > 1 {
+		if version, err := strconv.ParseInt(matches[1], 10, 64); err == nil {
+			return version
 		}
+	}
 
-		// Second, try constant assignment: var currentBootstrapVersion int64 = version109
-		// Find the assignment first (with optional var and type declaration)
-		constRe := regexp.MustCompile(`(?:var\s+)?currentBootstrapVersion(?:\s+\w+)?\s*=\s*version(\d+)`)
-		constMatches := constRe.FindStringSubmatch(content)
-		if len(constMatches) > 1 {
-			// Found assignment like: var currentBootstrapVersion int64 = version253
-			// Now find the constant definition: version253 = 253
-			constName := "version" + constMatches[1]
-			constDefRe := regexp.MustCompile(fmt.Sprintf(`%s\s*=\s*(\d+)`, regexp.QuoteMeta(constName)))
-			constDefMatches := constDefRe.FindStringSubmatch(content)
-			if len(constDefMatches) > 1 {
-				if version, err := strconv.ParseInt(constDefMatches[1], 10, 64); err == nil {
-					return version
-				}
+	// Second, try constant assignment: var currentBootstrapVersion int64 = version109
+	// Find the assignment first (with optional var and type declaration)
+	constRe := regexp.MustCompile(`(?:var\s+)?currentBootstrapVersion(?:\s+\w+)?\s*=\s*version(\d+)`)
+	constMatches := constRe.FindStringSubmatch(content)
+	if len(constMatches) > 1 {
+		// Found assignment like: var currentBootstrapVersion int64 = version253
+		// Now find the constant definition: version253 = 253
+		constName := "version" + constMatches[1]
+		constDefRe := regexp.MustCompile(fmt.Sprintf(`%s\s*=\s*(\d+)`, regexp.QuoteMeta(constName)))
+		constDefMatches := constDefRe.FindStringSubmatch(content)
+		if len(constDefMatches) > 1 {
+			if version, err := strconv.ParseInt(constDefMatches[1], 10, 64); err == nil {
+				return version
 			}
 		}
+	}
 
-		// Third, try to find the constant value directly by searching for version constants
-		// Look for pattern: versionXXX = YYY where YYY is the bootstrap version
-		// We'll find the assignment to currentBootstrapVersion and resolve the constant
-		versionConstRe := regexp.MustCompile(`version(\d+)\s*=\s*(\d+)`)
-		allMatches := versionConstRe.FindAllStringSubmatch(content, -1)
-		if len(allMatches) > 0 {
-			// Find the assignment to currentBootstrapVersion (with optional var and type)
-			assignRe := regexp.MustCompile(`(?:var\s+)?currentBootstrapVersion(?:\s+\w+)?\s*=\s*version(\d+)`)
-			assignMatches := assignRe.FindStringSubmatch(content)
-			if len(assignMatches) > 1 {
-				targetVersion := assignMatches[1]
-				// Find the constant definition for this version
-				for _, match := range allMatches {
-					if match[1] == targetVersion {
-						if version, err := strconv.ParseInt(match[2], 10, 64); err == nil {
-							return version
-						}
+	// Third, try to find the constant value directly by searching for version constants
+	// Look for pattern: versionXXX = YYY where YYY is the bootstrap version
+	// We'll find the assignment to currentBootstrapVersion and resolve the constant
+	versionConstRe := regexp.MustCompile(`version(\d+)\s*=\s*(\d+)`)
+	allMatches := versionConstRe.FindAllStringSubmatch(content, -1)
+	if len(allMatches) > 0 {
+		// Find the assignment to currentBootstrapVersion (with optional var and type)
+		assignRe := regexp.MustCompile(`(?:var\s+)?currentBootstrapVersion(?:\s+\w+)?\s*=\s*version(\d+)`)
+		assignMatches := assignRe.FindStringSubmatch(content)
+		if len(assignMatches) > 1 {
+			targetVersion := assignMatches[1]
+			// Find the constant definition for this version
+			for _, match := range allMatches {
+				if match[1] == targetVersion {
+					if version, err := strconv.ParseInt(match[2], 10, 64); err == nil {
+						return version
 					}
 				}
 			}
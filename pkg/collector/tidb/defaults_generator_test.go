@@ -0,0 +1,73 @@
+package tidb
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseBootstrapVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int64
+	}{
+		{
+			name: "v8.5 upgrade.go",
+			content: `const (
+	version252 = 252
+	version253 = 253
+)
+
+// currentBootstrapVersion is defined as a variable, so we can modify its value for testing.
+var currentBootstrapVersion int64 = version253
+`,
+			want: 253,
+		},
+		{
+			name: "v5.4 bootstrap.go var block",
+			content: `const (
+	// version80 fixes the issue https://github.com/pingcap/tidb/issues/25422.
+	version80 = 80
+	// version81 insert "tidb_enable_index_merge|off" to mysql.GLOBAL_VARIABLES if there is no tidb_enable_index_merge.
+	version81 = 81
+)
+
+var (
+	// currentBootstrapVersion is defined as a variable, so we can modify its value for testing.
+	// please make sure this is the largest version
+	currentBootstrapVersion int64 = version81
+)
+`,
+			want: 81,
+		},
+		{
+			name:    "typed numeric literal",
+			content: "var currentBootstrapVersion int64 = 81\n",
+			want:    81,
+		},
+		{
+			name:    "untyped numeric literal",
+			content: "currentBootstrapVersion = 47\n",
+			want:    47,
+		},
+		{
+			name:    "not found",
+			content: "package session\n",
+			want:    0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, parseBootstrapVersion(tt.content))
+		})
+	}
+}
+
+func TestRequiredFilesForSparseCheckout_V54(t *testing.T) {
+	files := RequiredFilesForSparseCheckout("v5.4.3")
+	assert.Contains(t, files, "sessionctx/variable/sysvar.go")
+	assert.Contains(t, files, "sessionctx/variable/tidb_vars.go")
+	assert.Contains(t, files, "session/bootstrap.go")
+	assert.NotContains(t, files, "pkg/sessionctx/variable/sysvar.go")
+}
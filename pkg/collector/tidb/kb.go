@@ -3,7 +3,10 @@
 package tidb
 
 import (
+	"database/sql"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 )
@@ -58,3 +61,66 @@ func Collect(tidbRoot, version, tag string) (*types.KBSnapshot, error) {
 
 	return snapshot, nil
 }
+
+// CollectFromCluster collects TiDB knowledge base from a running cluster without source code (runtime-only generation)
+// The cluster must run version with the default configuration, since its runtime values are saved as defaults
+// The bootstrap version is read from the tidb_server_version row of mysql.tidb instead of the source code
+func CollectFromCluster(version, addr, user, password string) (*types.KBSnapshot, error) {
+	fmt.Printf("Collecting runtime configuration and system variables from TiDB %s...\n", addr)
+	state, err := NewTiDBCollector().Collect(addr, user, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect runtime configuration: %w", err)
+	}
+
+	bootstrapVersion, err := getBootstrapVersionFromCluster(addr, user, password)
+	if err != nil {
+		fmt.Printf("Warning: failed to read bootstrap version from mysql.tidb: %v\n", err)
+	}
+
+	return &types.KBSnapshot{
+		Component:        types.ComponentTiDB,
+		Version:          version,
+		ConfigDefaults:   state.Config,
+		SystemVariables:  state.Variables,
+		BootstrapVersion: bootstrapVersion,
+	}, nil
+}
+
+// CollectFromSource collects the TiDB knowledge base that can be extracted from source code alone (source-only generation)
+// Only the bootstrap version is extracted: configuration defaults and system variables need a running cluster,
+// so ConfigDefaults and SystemVariables are empty
+func CollectFromSource(tidbRoot, version string) (*types.KBSnapshot, error) {
+	bootstrapVersion := extractBootstrapVersion(tidbRoot, version)
+	if bootstrapVersion == 0 {
+		return nil, fmt.Errorf("failed to extract bootstrap version for %s from %s", version, tidbRoot)
+	}
+	return &types.KBSnapshot{
+		Component:        types.ComponentTiDB,
+		Version:          version,
+		ConfigDefaults:   make(types.ParameterMap),
+		SystemVariables:  make(types.ParameterMap),
+		BootstrapVersion: bootstrapVersion,
+	}, nil
+}
+
+// getBootstrapVersionFromCluster reads the bootstrap version the cluster was bootstrapped (or upgraded) to
+// It is stored in mysql.tidb as tidb_server_version
+func getBootstrapVersionFromCluster(addr, user, password string) (int64, error) {
+	c := &tidbCollector{}
+	db, err := sql.Open("mysql", c.buildDSN(addr, user, password, ""))
+	if err != nil {
+		return 0, fmt.Errorf("failed to open database connection: %w", err)
+	}
+	defer db.Close()
+	db.SetConnMaxLifetime(10 * time.Second)
+
+	var value string
+	if err := db.QueryRow("SELECT VARIABLE_VALUE FROM mysql.tidb WHERE VARIABLE_NAME = 'tidb_server_version'").Scan(&value); err != nil {
+		return 0, fmt.Errorf("failed to query tidb_server_version: %w", err)
+	}
+	bootstrapVersion, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tidb_server_version %q: %w", value, err)
+	}
+	return bootstrapVersion, nil
+}
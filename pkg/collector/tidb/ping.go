@@ -0,0 +1,29 @@
+package tidb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Ping connects to a TiDB instance and returns the version it reports (e.g., "5.7.25-TiDB-v7.5.0")
+// Connecting, reading and writing are each limited to timeout, so that an unreachable
+// instance fails fast. Nothing else is queried
+func Ping(ctx context.Context, addr, user, password string, timeout time.Duration) (string, error) {
+	dsn := (&tidbCollector{}).buildDSN(addr, user, password, "")
+	dsn += fmt.Sprintf("?timeout=%s&readTimeout=%s&writeTimeout=%s", timeout, timeout, timeout)
+	db, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return "", fmt.Errorf("failed to open database connection: %w", err)
+	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	var version string
+	if err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version); err != nil {
+		return "", fmt.Errorf("failed to connect: %w", err)
+	}
+	return version, nil
+}
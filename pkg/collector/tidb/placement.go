@@ -0,0 +1,115 @@
+package tidb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+const (
+	resourceGroupsQuery    = "SELECT NAME, RU_PER_SEC, PRIORITY, BURSTABLE FROM information_schema.resource_groups"
+	placementPoliciesQuery = "SELECT POLICY_NAME, PRIMARY_REGION, REGIONS, CONSTRAINTS, LEADER_CONSTRAINTS, FOLLOWER_CONSTRAINTS, LEARNER_CONSTRAINTS, SCHEDULE, FOLLOWERS, LEARNERS FROM information_schema.placement_policies"
+)
+
+// CollectPlacement reads the resource groups and placement policies from information_schema
+// Tables the server doesn't have (by version, see SQLCapabilities) or the user can't read are
+// not an error: they are left unavailable in the returned state, with a note explaining why
+// Only the TiDB fields of the returned state are set, store labels are read from PD
+func (c *tidbCollector) CollectPlacement(ctx context.Context, addr, user, password string) (*types.PlacementState, error) {
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	version, err := c.getVersion(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TiDB version: %w", err)
+	}
+	caps := NewSQLCapabilities(version)
+
+	state := &types.PlacementState{}
+	if note := c.readFeatureTable(ctx, db, caps, SQLFeatureResourceGroups, resourceGroupsQuery, &state.MissingPrivileges, func(rows *sql.Rows) error {
+		var name, ruPerSec, priority, burstable sql.NullString
+		if err := rows.Scan(&name, &ruPerSec, &priority, &burstable); err != nil {
+			return err
+		}
+		state.ResourceGroups = append(state.ResourceGroups, types.ResourceGroup{
+			Name:      name.String,
+			RUPerSec:  ruPerSec.String,
+			Priority:  priority.String,
+			Burstable: burstable.String,
+		})
+		return nil
+	}); note != "" {
+		state.Notes = append(state.Notes, note)
+	} else {
+		state.ResourceGroupsAvailable = true
+	}
+
+	if note := c.readFeatureTable(ctx, db, caps, SQLFeaturePlacementPolicies, placementPoliciesQuery, &state.MissingPrivileges, func(rows *sql.Rows) error {
+		var name, primaryRegion, regions, constraints, leaderConstraints, followerConstraints, learnerConstraints, schedule sql.NullString
+		var followers, learners sql.NullInt64
+		if err := rows.Scan(&name, &primaryRegion, &regions, &constraints, &leaderConstraints, &followerConstraints,
+			&learnerConstraints, &schedule, &followers, &learners); err != nil {
+			return err
+		}
+		state.PlacementPolicies = append(state.PlacementPolicies, types.PlacementPolicy{
+			Name:                name.String,
+			PrimaryRegion:       primaryRegion.String,
+			Regions:             regions.String,
+			Constraints:         constraints.String,
+			LeaderConstraints:   leaderConstraints.String,
+			FollowerConstraints: followerConstraints.String,
+			LearnerConstraints:  learnerConstraints.String,
+			Schedule:            schedule.String,
+			Followers:           int(followers.Int64),
+			Learners:            int(learners.Int64),
+		})
+		return nil
+	}); note != "" {
+		state.Notes = append(state.Notes, note)
+	} else {
+		state.PlacementPoliciesAvailable = true
+	}
+
+	return state, nil
+}
+
+// readFeatureTable runs the query of a feature and scans each row
+// It is shared by the collections whose tables are optional (placement, SQL-only cluster views)
+// Returns a note if the table could not be read, an empty string if it was
+// A feature denied for missing privileges is appended to denied
+func (c *tidbCollector) readFeatureTable(ctx context.Context, db *sql.DB, caps *SQLCapabilities, feature SQLFeature, query string, denied *[]string, scan func(*sql.Rows) error) string {
+	if !caps.Supports(feature) {
+		return fmt.Sprintf("%s skipped, not supported by TiDB %s", feature, caps.Version)
+	}
+
+	queryCtx, cancel := c.queryContext(ctx)
+	defer cancel()
+	rows, err := db.QueryContext(queryCtx, query)
+	if err != nil {
+		if IsUnknownTableError(err) {
+			caps.MarkUnsupported(feature)
+			return fmt.Sprintf("%s skipped, the table is not available: %v", feature, err)
+		}
+		if IsPrivilegeError(err) {
+			*denied = append(*denied, string(feature))
+			return fmt.Sprintf("%s skipped, missing privileges: %v", feature, err)
+		}
+		return fmt.Sprintf("%s skipped, failed to query: %v", feature, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return fmt.Sprintf("%s skipped, failed to scan row: %v", feature, err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Sprintf("%s skipped, error iterating rows: %v", feature, err)
+	}
+	return ""
+}
@@ -0,0 +1,198 @@
+package tidb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Queries of the collector that need a privilege the precheck user may lack
+// SQL features reading information_schema (see SQLFeature) are identified by their feature name
+const (
+	// QueryGlobalVariablesTable reads mysql.global_variables
+	QueryGlobalVariablesTable = "global_variables_table"
+	// QueryTiDBStatus reads mysql.tidb (GC safepoint and bootstrap version)
+	QueryTiDBStatus = "tidb_status"
+)
+
+// StatusKeyMissingPrivileges is the status key of a collected TiDB state listing the queries denied
+// for missing privileges during its collection ([]string of query IDs, see PrivilegeRequirements)
+const StatusKeyMissingPrivileges = "missing_privileges"
+
+// PrivilegeRequirement is the privilege a query of the collector needs, and what is not collected without it
+type PrivilegeRequirement struct {
+	// Query identifies the query (e.g., show_config)
+	Query string
+	// Statement is the statement shown to the user (e.g., SHOW CONFIG)
+	Statement string
+	// Probe is a cheap statement denied without the privilege (see CheckPrivileges)
+	Probe string
+	// Privilege is the privilege the statement needs (e.g., CONFIG)
+	Privilege string
+	// Grant grants the privilege to the user given as %s
+	Grant string
+	// DataClass is the class of data the query collects
+	DataClass types.DataClass
+	// Impact describes the findings that may be incomplete without the data
+	Impact string
+}
+
+// PrivilegeRequirements maps each query of the collector needing a privilege to that privilege
+// Add an entry for every query of a table that ordinary users can't read
+var PrivilegeRequirements = []PrivilegeRequirement{
+	{
+		Query:     string(SQLFeatureShowConfig),
+		Statement: "SHOW CONFIG",
+		Probe:     "SELECT `TYPE` FROM information_schema.cluster_config LIMIT 1",
+		Privilege: "CONFIG",
+		Grant:     "GRANT CONFIG ON *.* TO %s",
+		DataClass: types.DataClassConfig,
+		Impact:    "TiDB configuration (if the status port is unreachable) and the configuration of nodes read through TiDB; TiKV consistency findings may be incomplete",
+	},
+	{
+		Query:     string(SQLFeatureClusterInfo),
+		Statement: "SELECT ... FROM information_schema.cluster_info",
+		Probe:     "SELECT `TYPE` FROM information_schema.cluster_info LIMIT 1",
+		Privilege: "PROCESS",
+		Grant:     "GRANT PROCESS ON *.* TO %s",
+		DataClass: types.DataClassConfig,
+		Impact:    "instance versions in SQL-only collection; mixed-version findings may be incomplete",
+	},
+	{
+		Query:     string(SQLFeatureTiKVStoreStatus),
+		Statement: "SELECT ... FROM information_schema.tikv_store_status",
+		Probe:     "SELECT `STORE_ID` FROM information_schema.tikv_store_status LIMIT 1",
+		Privilege: "PROCESS",
+		Grant:     "GRANT PROCESS ON *.* TO %s",
+		DataClass: types.DataClassStores,
+		Impact:    "TiKV stores in SQL-only collection; store version and state findings are not reported",
+	},
+	{
+		Query:     string(SQLFeatureResourceGroups),
+		Statement: "SELECT ... FROM information_schema.resource_groups",
+		Probe:     "SELECT `NAME` FROM information_schema.resource_groups LIMIT 1",
+		Privilege: "RESOURCE_GROUP_ADMIN",
+		Grant:     "GRANT RESOURCE_GROUP_ADMIN ON *.* TO %s",
+		DataClass: types.DataClassPlacement,
+		Impact:    "resource groups; resource control findings are not reported",
+	},
+	{
+		Query:     string(SQLFeaturePlacementPolicies),
+		Statement: "SELECT ... FROM information_schema.placement_policies",
+		Probe:     "SELECT `POLICY_NAME` FROM information_schema.placement_policies LIMIT 1",
+		Privilege: "PLACEMENT_ADMIN",
+		Grant:     "GRANT PLACEMENT_ADMIN ON *.* TO %s",
+		DataClass: types.DataClassPlacement,
+		Impact:    "placement policies; placement findings are not reported",
+	},
+	{
+		Query:     QueryGlobalVariablesTable,
+		Statement: "SELECT ... FROM mysql.global_variables",
+		Probe:     "SELECT VARIABLE_NAME FROM mysql.global_variables LIMIT 1",
+		Privilege: "SELECT ON mysql.global_variables",
+		Grant:     "GRANT SELECT ON mysql.global_variables TO %s",
+		DataClass: types.DataClassGlobalVariablesTable,
+		Impact:    "persisted system variables; findings about mysql.global_variables are not reported",
+	},
+	{
+		Query:     QueryTiDBStatus,
+		Statement: "SELECT ... FROM mysql.tidb",
+		Probe:     "SELECT VARIABLE_NAME FROM mysql.tidb LIMIT 1",
+		Privilege: "SELECT ON mysql.tidb",
+		Grant:     "GRANT SELECT ON mysql.tidb TO %s",
+		DataClass: types.DataClassGCSafePoints,
+		Impact:    "GC safepoint and bootstrap version; GC findings are not reported and forced changes are not filtered by the upgrade functions already run",
+	},
+}
+
+// LookupPrivilegeRequirement returns the privilege requirement of a query
+func LookupPrivilegeRequirement(query string) (PrivilegeRequirement, bool) {
+	for _, requirement := range PrivilegeRequirements {
+		if requirement.Query == query {
+			return requirement, true
+		}
+	}
+	return PrivilegeRequirement{}, false
+}
+
+// MissingPrivilege describes the data not collected because a query was denied
+func (r PrivilegeRequirement) MissingPrivilege() types.MissingPrivilege {
+	return types.MissingPrivilege{
+		Query:     r.Statement,
+		Privilege: r.Privilege,
+		DataClass: r.DataClass,
+		Impact:    r.Impact,
+	}
+}
+
+// GrantStatement returns the GRANT statement of the privilege for user (e.g., 'precheck'@'%')
+func (r PrivilegeRequirement) GrantStatement(user string) string {
+	return fmt.Sprintf(r.Grant, user) + ";"
+}
+
+// PrivilegeError is the error of a query denied for a missing privilege
+type PrivilegeError struct {
+	// Query identifies the denied query (see PrivilegeRequirements)
+	Query string
+	Err   error
+}
+
+// Error returns the error message, with the privilege the query needs
+func (e *PrivilegeError) Error() string {
+	if requirement, ok := LookupPrivilegeRequirement(e.Query); ok {
+		return fmt.Sprintf("missing privilege %s for %s: %v", requirement.Privilege, requirement.Statement, e.Err)
+	}
+	return fmt.Sprintf("missing privilege for %s: %v", e.Query, e.Err)
+}
+
+// Unwrap returns the error of the driver
+func (e *PrivilegeError) Unwrap() error {
+	return e.Err
+}
+
+// classifyPrivilegeError wraps err in a PrivilegeError if it is the error of a query the user lacks the privileges for
+func classifyPrivilegeError(query string, err error) error {
+	if err != nil && IsPrivilegeError(err) {
+		return &PrivilegeError{Query: query, Err: err}
+	}
+	return err
+}
+
+// PrivilegeCheck is the result of the probe of a privilege requirement
+type PrivilegeCheck struct {
+	Requirement PrivilegeRequirement
+	// Granted is set if the probe was not denied
+	Granted bool
+	// Err is the error of the probe (nil if it succeeded), it is not a privilege error if Granted
+	Err error
+}
+
+// CheckPrivileges runs the probe of every privilege requirement, without collecting anything
+// Queries the server doesn't support (e.g., a missing table) are reported as granted with their error
+func (c *tidbCollector) CheckPrivileges(ctx context.Context, addr, user, password string) ([]PrivilegeCheck, error) {
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	if _, err := c.getVersion(ctx, db); err != nil {
+		return nil, fmt.Errorf("failed to connect to TiDB: %w", err)
+	}
+
+	checks := make([]PrivilegeCheck, 0, len(PrivilegeRequirements))
+	for _, requirement := range PrivilegeRequirements {
+		queryCtx, cancel := c.queryContext(ctx)
+		rows, err := db.QueryContext(queryCtx, requirement.Probe)
+		if err == nil {
+			err = rows.Close()
+		}
+		cancel()
+		checks = append(checks, PrivilegeCheck{
+			Requirement: requirement,
+			Granted:     !IsPrivilegeError(err),
+			Err:         err,
+		})
+	}
+	return checks, nil
+}
@@ -0,0 +1,110 @@
+package tidb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrivilegeRequirements(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, requirement := range PrivilegeRequirements {
+		assert.False(t, seen[requirement.Query], "duplicate query %s", requirement.Query)
+		seen[requirement.Query] = true
+		assert.NotEmpty(t, requirement.Probe, requirement.Query)
+		assert.NotEmpty(t, requirement.Impact, requirement.Query)
+		assert.Contains(t, requirement.Grant, "%s", requirement.Query)
+	}
+
+	requirement, ok := LookupPrivilegeRequirement(string(SQLFeatureShowConfig))
+	require.True(t, ok)
+	assert.Equal(t, "GRANT CONFIG ON *.* TO 'precheck'@'%';", requirement.GrantStatement("'precheck'@'%'"))
+	assert.Equal(t, types.MissingPrivilege{
+		Query:     "SHOW CONFIG",
+		Privilege: "CONFIG",
+		DataClass: types.DataClassConfig,
+		Impact:    requirement.Impact,
+	}, requirement.MissingPrivilege())
+
+	_, ok = LookupPrivilegeRequirement("unknown")
+	assert.False(t, ok)
+}
+
+func TestClassifyPrivilegeError(t *testing.T) {
+	assert.NoError(t, classifyPrivilegeError(QueryTiDBStatus, nil))
+
+	for _, number := range []uint16{errDBAccessDenied, errTableAccessDenied, errSpecificAccessDenied} {
+		err := classifyPrivilegeError(QueryGlobalVariablesTable, &mysql.MySQLError{Number: number, Message: "denied"})
+		var privilegeErr *PrivilegeError
+		require.True(t, errors.As(err, &privilegeErr), "error %d", number)
+		assert.Equal(t, QueryGlobalVariablesTable, privilegeErr.Query)
+		assert.True(t, IsPrivilegeError(err), "the error of the driver is unwrapped")
+		assert.Contains(t, err.Error(), "missing privilege SELECT ON mysql.global_variables")
+	}
+
+	// Neither a missing table nor a failed login is a missing privilege of the query
+	for _, number := range []uint16{errNoSuchTable, 1045} {
+		err := classifyPrivilegeError(QueryTiDBStatus, &mysql.MySQLError{Number: number, Message: "error"})
+		var privilegeErr *PrivilegeError
+		assert.False(t, errors.As(err, &privilegeErr), "error %d", number)
+	}
+}
+
+func TestCheckPrivileges(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.11-TiDB-v7.5.0"))
+	for _, requirement := range PrivilegeRequirements {
+		expect := mock.ExpectQuery(requirement.Probe)
+		switch requirement.Query {
+		case string(SQLFeatureShowConfig):
+			expect.WillReturnError(&mysql.MySQLError{Number: errSpecificAccessDenied, Message: "Access denied; you need (at least one of) the CONFIG privilege(s) for this operation"})
+		case QueryGlobalVariablesTable:
+			expect.WillReturnError(&mysql.MySQLError{Number: errTableAccessDenied, Message: "SELECT command denied to user 'precheck'@'%' for table 'global_variables'"})
+		case string(SQLFeatureResourceGroups):
+			expect.WillReturnError(&mysql.MySQLError{Number: errNoSuchTable, Message: "Table 'information_schema.resource_groups' doesn't exist"})
+		default:
+			expect.WillReturnRows(sqlmock.NewRows([]string{"c"}).AddRow("x"))
+		}
+	}
+
+	checks, err := collector.CheckPrivileges(context.Background(), "127.0.0.1:4000", "precheck", "")
+	require.NoError(t, err)
+	require.Len(t, checks, len(PrivilegeRequirements))
+	for _, check := range checks {
+		switch check.Requirement.Query {
+		case string(SQLFeatureShowConfig), QueryGlobalVariablesTable:
+			assert.False(t, check.Granted, check.Requirement.Query)
+			assert.Error(t, check.Err, check.Requirement.Query)
+		case string(SQLFeatureResourceGroups):
+			assert.True(t, check.Granted, "a missing table is not a missing privilege")
+			assert.True(t, IsUnknownTableError(check.Err))
+		default:
+			assert.True(t, check.Granted, check.Requirement.Query)
+			assert.NoError(t, check.Err, check.Requirement.Query)
+		}
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckPrivileges_ConnectionFailure(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnError(&mysql.MySQLError{Number: 1045, Message: "Access denied for user 'precheck'@'%'"})
+	_, err := collector.CheckPrivileges(context.Background(), "127.0.0.1:4000", "precheck", "")
+	assert.ErrorContains(t, err, "failed to connect to TiDB")
+}
+
+func TestCollectGlobalVariablesTable_PrivilegeError(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VARIABLE_NAME, VARIABLE_VALUE FROM mysql.global_variables").
+		WillReturnError(&mysql.MySQLError{Number: errTableAccessDenied, Message: "SELECT command denied to user 'precheck'@'%' for table 'global_variables'"})
+	_, err := collector.CollectGlobalVariablesTable("127.0.0.1:4000", "precheck", "")
+	var privilegeErr *PrivilegeError
+	require.True(t, errors.As(err, &privilegeErr))
+	assert.Equal(t, QueryGlobalVariablesTable, privilegeErr.Query)
+}
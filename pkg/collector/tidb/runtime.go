@@ -1,6 +1,7 @@
 package tidb
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 )
 
@@ -17,25 +19,88 @@ import (
 // Connection credentials are provided by external tools (TiUP/TiDB Operator)
 type TiDBCollector interface {
 	Collect(addr, user, password string) (*types.ComponentState, error)
+	// CollectWithStatusAddr is like Collect, but reads the configuration from the HTTP status API at statusAddr first
+	// If the MySQL protocol endpoint is not reachable, the configuration from the status API is still returned
+	CollectWithStatusAddr(ctx context.Context, addr, statusAddr, user, password string) (*types.ComponentState, error)
+	// CollectConfigWithStatusAddr is like CollectWithStatusAddr, but does not read the system variables
+	// If the configuration is read from the status API, no SQL statement is issued at all
+	CollectConfigWithStatusAddr(ctx context.Context, addr, statusAddr, user, password string) (*types.ComponentState, error)
+	// DetectVersion returns the release version of a TiDB instance (e.g., "v7.5.0"), read with SQL
+	DetectVersion(ctx context.Context, addr, user, password string) (string, error)
+	// CollectConfig reads only the TiDB configuration, with SHOW CONFIG
+	CollectConfig(ctx context.Context, addr, user, password string) (types.ParameterMap, error)
+	// CollectSystemVariables reads only the global system variables, from information_schema.GLOBAL_VARIABLES
+	CollectSystemVariables(ctx context.Context, addr, user, password string) (types.ParameterMap, error)
 	// GetConfigByType gets configuration for a specific component type using SHOW CONFIG
 	// This can be used to collect PD, TiKV, and TiFlash configs
 	GetConfigByType(db *sql.DB, componentType string) (map[string]interface{}, error)
 	// GetConfigByTypeAndInstance gets configuration for a specific component type and instance
 	// instance should be in format "IP:port" (e.g., "192.168.1.101:20160")
 	GetConfigByTypeAndInstance(db *sql.DB, componentType, instance string) (map[string]interface{}, error)
+	// CollectGlobalVariablesTable reads the rows of mysql.global_variables (requires the SELECT privilege on it)
+	CollectGlobalVariablesTable(addr, user, password string) ([]types.GlobalVariableRow, error)
+	// CollectGCStatus reads the GC safepoint and last run time from mysql.tidb (requires the SELECT privilege on it)
+	CollectGCStatus(addr, user, password string) (*types.GCSafePointState, error)
+	// QueryBootstrapVersion reads the bootstrap version the cluster is at (tidb_server_version in mysql.tidb)
+	QueryBootstrapVersion(ctx context.Context, addr, user, password string) (int64, error)
+	// CollectPlacement reads the resource groups and placement policies from information_schema
+	// Missing tables and privileges are reported as notes of the returned state, not as errors
+	CollectPlacement(ctx context.Context, addr, user, password string) (*types.PlacementState, error)
+	// CollectClusterViaSQL reads the instances, the PD/TiKV/TiFlash configuration and the TiKV stores from information_schema
+	// Missing views and privileges are reported as notes of the returned state, not as errors
+	CollectClusterViaSQL(ctx context.Context, addr, user, password string) (*ClusterViaSQL, error)
+	// CheckPrivileges probes the privileges the collector needs (see PrivilegeRequirements), without collecting anything
+	CheckPrivileges(ctx context.Context, addr, user, password string) ([]PrivilegeCheck, error)
 }
 
+// DefaultSQLTimeout is the default time limit of each SQL statement issued by the collector
+const DefaultSQLTimeout = 30 * time.Second
+
 type tidbCollector struct {
 	httpClient *http.Client
+	// sqlTimeout limits each SQL statement, so that a locked system table doesn't hang the collection
+	sqlTimeout time.Duration
+	// openDB opens a database handle for a DSN (replaced in tests)
+	openDB func(dsn string) (*sql.DB, error)
 }
 
 // NewTiDBCollector creates a new TiDB collector
+// Each SQL statement is limited to DefaultSQLTimeout
 func NewTiDBCollector() TiDBCollector {
+	return NewTiDBCollectorWithSQLTimeout(DefaultSQLTimeout)
+}
+
+// NewTiDBCollectorWithSQLTimeout creates a TiDB collector whose SQL statements are each limited to timeout
+// A timeout <= 0 means no limit
+func NewTiDBCollectorWithSQLTimeout(timeout time.Duration) TiDBCollector {
 	return &tidbCollector{
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		sqlTimeout: timeout,
+		openDB: func(dsn string) (*sql.DB, error) {
+			return sql.Open("mysql", dsn)
+		},
+	}
+}
+
+// queryContext returns the context of a single SQL statement, limited to the SQL timeout
+func (c *tidbCollector) queryContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.sqlTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.sqlTimeout)
+}
+
+// open opens a database handle for a TiDB instance
+func (c *tidbCollector) open(addr, user, password string) (*sql.DB, error) {
+	db, err := c.openDB(c.buildDSN(addr, user, password, ""))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
+	// Set connection timeout
+	db.SetConnMaxLifetime(10 * time.Second)
+	return db, nil
 }
 
 // Collect gathers configuration and variables from a TiDB instance
@@ -43,66 +108,148 @@ func NewTiDBCollector() TiDBCollector {
 // user: MySQL username (provided by TiUP/Operator)
 // password: MySQL password (provided by TiUP/Operator)
 func (c *tidbCollector) Collect(addr, user, password string) (*types.ComponentState, error) {
+	return c.CollectWithStatusAddr(context.Background(), addr, "", user, password)
+}
+
+// CollectWithStatusAddr gathers configuration and variables from a TiDB instance
+// statusAddr: HTTP status API endpoint (host:status_port), configuration is read from /config if it is reachable
+// Otherwise configuration is collected with SHOW CONFIG. If statusAddr is empty, only the MySQL protocol is used
+// After connecting, the server version is read to skip the statements it doesn't support (see SQLCapabilities)
+func (c *tidbCollector) CollectWithStatusAddr(ctx context.Context, addr, statusAddr, user, password string) (*types.ComponentState, error) {
+	return c.collect(ctx, addr, statusAddr, user, password, true)
+}
+
+// CollectConfigWithStatusAddr gathers the configuration of a TiDB instance, without its system variables
+// This is used when the precheck user lacks the privileges to read them, or SQL access is not allowed:
+// if the status API at statusAddr is reachable, the version and configuration are both read from it
+func (c *tidbCollector) CollectConfigWithStatusAddr(ctx context.Context, addr, statusAddr, user, password string) (*types.ComponentState, error) {
+	return c.collect(ctx, addr, statusAddr, user, password, false)
+}
+
+// collect gathers the configuration of a TiDB instance, and its system variables if withVariables is set
+func (c *tidbCollector) collect(ctx context.Context, addr, statusAddr, user, password string, withVariables bool) (*types.ComponentState, error) {
 	state := &types.ComponentState{
 		Type:      types.ComponentTiDB,
-		Config:    make(types.ConfigDefaults),
-		Variables: make(types.SystemVariables),
+		Config:    make(types.ParameterMap),
+		Variables: make(types.ParameterMap),
 		Status:    make(map[string]interface{}),
 	}
 
+	// Store the address in Status for identification
+	state.Status["address"] = addr
+
 	// Default to root if user not provided (for backward compatibility)
 	if user == "" {
 		user = "root"
 	}
 
+	// Collect configuration using the HTTP status API (no credentials required)
+	var config map[string]interface{}
+	var httpStatus map[string]interface{}
+	if statusAddr != "" {
+		client := &TiDBHTTPClient{Addr: statusAddr, HTTPClient: c.httpClient}
+		nested, err := client.GetConfig(ctx)
+		if err != nil {
+			fmt.Printf("Warning: failed to get config via TiDB status API, falling back to SHOW CONFIG: %v\n", err)
+		} else {
+			config = FlattenConfig(nested)
+			if status, err := client.GetStatus(ctx); err == nil {
+				httpStatus = status
+			}
+			// The command line is served by the pprof handlers of the status server (best effort)
+			if args, err := common.GetStartupArgs(ctx, c.httpClient, statusAddr); err != nil {
+				fmt.Printf("Warning: failed to get TiDB startup flags from %s, they are not checked: %v\n", statusAddr, err)
+			} else {
+				state.StartupArgs = args
+			}
+		}
+	}
+
+	if config != nil && !withVariables {
+		// Everything needed was read from the status API, don't connect with the MySQL protocol
+		if v, ok := httpStatus["version"].(string); ok {
+			state.Version = v
+		}
+		state.Config = types.ConvertConfigToDefaults(config)
+		return state, nil
+	}
+
 	// Get version using MySQL protocol
-	version, err := c.getVersion(addr, user, password)
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	version, err := c.getVersion(ctx, db)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get TiDB version: %w", err)
+		if config == nil {
+			return nil, fmt.Errorf("failed to get TiDB version: %w", err)
+		}
+		// MySQL port is restricted but the status port is reachable: return the configuration only
+		fmt.Printf("Warning: failed to connect to TiDB via MySQL protocol, system variables are not collected: %v\n", err)
+		if v, ok := httpStatus["version"].(string); ok {
+			state.Version = v
+		}
+		state.Config = types.ConvertConfigToDefaults(config)
+		return state, nil
 	}
 	state.Version = version
+	caps := NewSQLCapabilities(version)
 
-	// Collect configuration using SHOW CONFIG SQL (preferred method)
-	// This can collect TiDB, TiKV, and TiFlash configs from a single TiDB connection
-	config, err := c.getConfigViaSQL(addr, user, password)
-	if err != nil {
-		// Log warning but continue - config might not be available
-		fmt.Printf("Warning: failed to get config via SHOW CONFIG: %v\n", err)
-		// Create empty config map
+	if config == nil && !caps.Supports(SQLFeatureShowConfig) {
+		noteUnsupported(SQLFeatureShowConfig, caps, "configuration is not collected")
 		config = make(map[string]interface{})
-	} else if len(config) == 0 {
-		// SHOW CONFIG executed successfully but returned no results
-		// This might happen in older versions (e.g., v6.5.0) where SHOW CONFIG is not fully supported
-		fmt.Printf("Warning: SHOW CONFIG returned empty results (may not be supported in this version)\n")
 	}
-	// Convert to pkg/types.ConfigDefaults format
+	if config == nil {
+		// Collect configuration using SHOW CONFIG SQL
+		// This can collect TiDB, TiKV, and TiFlash configs from a single TiDB connection
+		config, err = c.getConfigViaSQL(ctx, db)
+		if err != nil && IsUnknownTableError(err) {
+			// The version probe can't know every build, a missing information_schema table is not a failure
+			caps.MarkUnsupported(SQLFeatureShowConfig)
+			noteUnsupported(SQLFeatureShowConfig, caps, err.Error())
+			config = make(map[string]interface{})
+		} else if err != nil && IsPrivilegeError(err) {
+			// Collection goes on without the configuration, the denied query is reported as a limitation
+			fmt.Printf("Warning: TiDB configuration is not collected: %v\n", classifyPrivilegeError(string(SQLFeatureShowConfig), err))
+			state.Status[StatusKeyMissingPrivileges] = []string{string(SQLFeatureShowConfig)}
+			config = make(map[string]interface{})
+		} else if err != nil {
+			// Log warning but continue - config might not be available
+			fmt.Printf("Warning: failed to get config via SHOW CONFIG: %v\n", err)
+			// Create empty config map
+			config = make(map[string]interface{})
+		} else if len(config) == 0 {
+			// SHOW CONFIG executed successfully but returned no results
+			// This might happen in older versions (e.g., v6.5.0) where SHOW CONFIG is not fully supported
+			fmt.Printf("Warning: SHOW CONFIG returned empty results (may not be supported in this version)\n")
+		}
+	}
+	// Convert to pkg/types.ParameterMap format
 	state.Config = types.ConvertConfigToDefaults(config)
 
+	if !withVariables {
+		return state, nil
+	}
+
 	// Collect system variables using MySQL protocol
-	variables, err := c.getVariables(addr, user, password)
+	variables, err := c.getVariables(ctx, db)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get TiDB variables: %w", err)
 	}
-	// Convert to pkg/types.SystemVariables format
+	// Convert to pkg/types.ParameterMap format
 	state.Variables = types.ConvertVariablesToSystemVariables(variables)
 
 	return state, nil
 }
 
 // getVersion gets TiDB version using MySQL protocol
-func (c *tidbCollector) getVersion(addr, user, password string) (string, error) {
-	dsn := c.buildDSN(addr, user, password, "")
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return "", fmt.Errorf("failed to open database connection: %w", err)
-	}
-	defer db.Close()
-
-	// Set connection timeout
-	db.SetConnMaxLifetime(10 * time.Second)
+func (c *tidbCollector) getVersion(ctx context.Context, db *sql.DB) (string, error) {
+	ctx, cancel := c.queryContext(ctx)
+	defer cancel()
 
 	var version string
-	err = db.QueryRow("SELECT VERSION()").Scan(&version)
+	err := db.QueryRowContext(ctx, "SELECT VERSION()").Scan(&version)
 	if err != nil {
 		return "", fmt.Errorf("failed to query version: %w", err)
 	}
@@ -110,25 +257,46 @@ func (c *tidbCollector) getVersion(addr, user, password string) (string, error)
 	return version, nil
 }
 
-// getConfigViaSQL gets TiDB configuration using SHOW CONFIG SQL statement
-// This can collect TiDB, TiKV, and TiFlash configs from a single TiDB connection
-// Example: SHOW CONFIG WHERE type='tidb'
-func (c *tidbCollector) getConfigViaSQL(addr, user, password string) (map[string]interface{}, error) {
-	dsn := c.buildDSN(addr, user, password, "")
-	db, err := sql.Open("mysql", dsn)
+// DetectVersion returns the release version of a TiDB instance in "vX.Y.Z" form
+// The version is parsed from SELECT tidb_version() ("Release Version: v7.5.0 ..."), falling back to
+// SELECT VERSION() ("8.0.11-TiDB-v7.5.0") if the TiDB-specific function is unavailable
+func (c *tidbCollector) DetectVersion(ctx context.Context, addr, user, password string) (string, error) {
+	db, err := c.open(addr, user, password)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
+		return "", err
 	}
 	defer db.Close()
 
-	// Set connection timeout
-	db.SetConnMaxLifetime(10 * time.Second)
+	queryCtx, cancel := c.queryContext(ctx)
+	var info string
+	err = db.QueryRowContext(queryCtx, "SELECT tidb_version()").Scan(&info)
+	cancel()
+	if err == nil {
+		if version := types.NormalizeVersion(info); version != "" {
+			return version, nil
+		}
+	}
 
+	raw, err := c.getVersion(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	version := types.NormalizeVersion(raw)
+	if version == "" {
+		return "", fmt.Errorf("failed to parse TiDB version from %q", raw)
+	}
+	return version, nil
+}
+
+// getConfigViaSQL gets TiDB configuration using SHOW CONFIG SQL statement
+// This can collect TiDB, TiKV, and TiFlash configs from a single TiDB connection
+// Example: SHOW CONFIG WHERE type='tidb'
+func (c *tidbCollector) getConfigViaSQL(ctx context.Context, db *sql.DB) (map[string]interface{}, error) {
 	// Collect TiDB config
 	config := make(map[string]interface{})
 
 	// Get TiDB config
-	tidbConfig, err := c.getConfigByType(db, "tidb")
+	tidbConfig, err := c.getConfigByType(ctx, db, "tidb")
 	if err != nil {
 		return nil, fmt.Errorf("failed to get TiDB config: %w", err)
 	}
@@ -149,14 +317,17 @@ func (c *tidbCollector) getConfigViaSQL(addr, user, password string) (map[string
 // GetConfigByType gets configuration for a specific component type using SHOW CONFIG
 // This is a public method that can be used to collect TiKV, and TiFlash configs
 func (c *tidbCollector) GetConfigByType(db *sql.DB, componentType string) (map[string]interface{}, error) {
-	return c.getConfigByType(db, componentType)
+	return c.getConfigByType(context.Background(), db, componentType)
 }
 
 // GetConfigByTypeAndInstance gets configuration for a specific component type and instance using SHOW CONFIG
 // instance should be in format "IP:port" (e.g., "192.168.1.101:20160")
 func (c *tidbCollector) GetConfigByTypeAndInstance(db *sql.DB, componentType, instance string) (map[string]interface{}, error) {
+	ctx, cancel := c.queryContext(context.Background())
+	defer cancel()
+
 	query := fmt.Sprintf("SHOW CONFIG WHERE type='%s' AND instance='%s'", componentType, instance)
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query config for type %s and instance %s: %w", componentType, instance, err)
 	}
@@ -207,25 +378,7 @@ func (c *tidbCollector) GetConfigByTypeAndInstance(db *sql.DB, componentType, in
 		}
 
 		if name != "" {
-			// Try to parse value as JSON first, then as number, then as boolean, finally as string
-			var parsedValue interface{} = value
-
-			// Try to parse as JSON
-			var jsonValue interface{}
-			if err := json.Unmarshal([]byte(value), &jsonValue); err == nil {
-				parsedValue = jsonValue
-			} else {
-				// Try to parse as number
-				if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
-					parsedValue = intVal
-				} else if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
-					parsedValue = floatVal
-				} else if boolVal, err := strconv.ParseBool(value); err == nil {
-					parsedValue = boolVal
-				}
-			}
-
-			config[name] = parsedValue
+			config[name] = ParseConfigValue(value)
 		}
 	}
 
@@ -243,10 +396,32 @@ func (c *tidbCollector) GetConfigByTypeAndInstance(db *sql.DB, componentType, in
 	return config, nil
 }
 
+// ParseConfigValue parses a config value returned by SHOW CONFIG or information_schema.cluster_config
+// The value is parsed as JSON first, then as number, then as boolean, finally kept as string
+func ParseConfigValue(value string) interface{} {
+	var jsonValue interface{}
+	if err := json.Unmarshal([]byte(value), &jsonValue); err == nil {
+		return jsonValue
+	}
+	if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return intVal
+	}
+	if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
+		return floatVal
+	}
+	if boolVal, err := strconv.ParseBool(value); err == nil {
+		return boolVal
+	}
+	return value
+}
+
 // getConfigByType gets configuration for a specific component type using SHOW CONFIG
-func (c *tidbCollector) getConfigByType(db *sql.DB, componentType string) (map[string]interface{}, error) {
+func (c *tidbCollector) getConfigByType(ctx context.Context, db *sql.DB, componentType string) (map[string]interface{}, error) {
+	ctx, cancel := c.queryContext(ctx)
+	defer cancel()
+
 	query := fmt.Sprintf("SHOW CONFIG WHERE type='%s'", componentType)
-	rows, err := db.Query(query)
+	rows, err := db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query config for type %s: %w", componentType, err)
 	}
@@ -297,25 +472,7 @@ func (c *tidbCollector) getConfigByType(db *sql.DB, componentType string) (map[s
 		}
 
 		if name != "" {
-			// Try to parse value as JSON first, then as number, then as boolean, finally as string
-			var parsedValue interface{} = value
-
-			// Try to parse as JSON
-			var jsonValue interface{}
-			if err := json.Unmarshal([]byte(value), &jsonValue); err == nil {
-				parsedValue = jsonValue
-			} else {
-				// Try to parse as number
-				if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
-					parsedValue = intVal
-				} else if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
-					parsedValue = floatVal
-				} else if boolVal, err := strconv.ParseBool(value); err == nil {
-					parsedValue = boolVal
-				}
-			}
-
-			config[name] = parsedValue
+			config[name] = ParseConfigValue(value)
 		}
 	}
 
@@ -337,18 +494,11 @@ func (c *tidbCollector) getConfigByType(db *sql.DB, componentType string) (map[s
 }
 
 // getVariables gets TiDB system variables using MySQL protocol
-func (c *tidbCollector) getVariables(addr, user, password string) (map[string]string, error) {
-	dsn := c.buildDSN(addr, user, password, "")
-	db, err := sql.Open("mysql", dsn)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open database connection: %w", err)
-	}
-	defer db.Close()
-
-	// Set connection timeout
-	db.SetConnMaxLifetime(10 * time.Second)
+func (c *tidbCollector) getVariables(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	ctx, cancel := c.queryContext(ctx)
+	defer cancel()
 
-	rows, err := db.Query("SHOW GLOBAL VARIABLES")
+	rows, err := db.QueryContext(ctx, "SHOW GLOBAL VARIABLES")
 	if err != nil {
 		return nil, fmt.Errorf("failed to query variables: %w", err)
 	}
@@ -370,6 +520,107 @@ func (c *tidbCollector) getVariables(addr, user, password string) (map[string]st
 	return variables, nil
 }
 
+// CollectGlobalVariablesTable reads the rows of mysql.global_variables, where the global values of system variables
+// are persisted. Unlike SHOW GLOBAL VARIABLES, it also returns rows of variables the running version does not know
+// and rows whose value differs from the in-memory global value
+func (c *tidbCollector) CollectGlobalVariablesTable(addr, user, password string) ([]types.GlobalVariableRow, error) {
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := c.queryContext(context.Background())
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM mysql.global_variables")
+	if err != nil {
+		return nil, classifyPrivilegeError(QueryGlobalVariablesTable, fmt.Errorf("failed to query mysql.global_variables: %w", err))
+	}
+	defer rows.Close()
+
+	tableRows := []types.GlobalVariableRow{}
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan mysql.global_variables row: %w", err)
+		}
+		tableRows = append(tableRows, types.GlobalVariableRow{Name: name, Value: value.String})
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating mysql.global_variables: %w", err)
+	}
+
+	return tableRows, nil
+}
+
+// CollectGCStatus reads the rows of mysql.tidb maintained by the GC worker: tikv_gc_safe_point and tikv_gc_last_run_time
+// Only the TiDB fields of the returned state are set, service safepoints are read from PD
+func (c *tidbCollector) CollectGCStatus(addr, user, password string) (*types.GCSafePointState, error) {
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := c.queryContext(context.Background())
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM mysql.tidb WHERE VARIABLE_NAME IN ('tikv_gc_safe_point', 'tikv_gc_last_run_time')")
+	if err != nil {
+		return nil, classifyPrivilegeError(QueryTiDBStatus, fmt.Errorf("failed to query mysql.tidb: %w", err))
+	}
+	defer rows.Close()
+
+	state := &types.GCSafePointState{}
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan mysql.tidb row: %w", err)
+		}
+		switch name {
+		case "tikv_gc_safe_point":
+			state.GCSafePoint = value.String
+		case "tikv_gc_last_run_time":
+			state.GCLastRunTime = value.String
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating mysql.tidb: %w", err)
+	}
+
+	return state, nil
+}
+
+// QueryBootstrapVersion reads tidb_server_version from mysql.tidb: the bootstrap version of the last
+// upgrade function run on the cluster, which can differ from the one of its release version
+// (e.g., a cluster upgraded from a patch release whose upgrade functions were backported)
+func (c *tidbCollector) QueryBootstrapVersion(ctx context.Context, addr, user, password string) (int64, error) {
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	queryCtx, cancel := c.queryContext(ctx)
+	defer cancel()
+
+	var value string
+	err = db.QueryRowContext(queryCtx, "SELECT VARIABLE_VALUE FROM mysql.tidb WHERE VARIABLE_NAME = 'tidb_server_version'").Scan(&value)
+	if err != nil {
+		return 0, classifyPrivilegeError(QueryTiDBStatus, fmt.Errorf("failed to query tidb_server_version from mysql.tidb: %w", err))
+	}
+	version, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid tidb_server_version %q in mysql.tidb: %w", value, err)
+	}
+	return version, nil
+}
+
 // buildDSN builds MySQL DSN string
 // Connection credentials are provided by external tools (TiUP/TiDB Operator)
 func (c *tidbCollector) buildDSN(addr, user, password, database string) string {
@@ -0,0 +1,160 @@
+package tidb
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// SQLFeature is a statement issued by the collector that is not available in every TiDB version
+type SQLFeature string
+
+const (
+	// SQLFeatureShowConfig is SHOW CONFIG, which reads information_schema.cluster_config
+	SQLFeatureShowConfig SQLFeature = "show_config"
+	// SQLFeatureResourceGroups reads information_schema.resource_groups (resource control, GA in v7.1)
+	SQLFeatureResourceGroups SQLFeature = "resource_groups"
+	// SQLFeaturePlacementPolicies reads information_schema.placement_policies (placement rules in SQL, v5.3)
+	SQLFeaturePlacementPolicies SQLFeature = "placement_policies"
+	// SQLFeatureClusterInfo reads information_schema.cluster_info (the instances of the cluster, v4.0)
+	SQLFeatureClusterInfo SQLFeature = "cluster_info"
+	// SQLFeatureTiKVStoreStatus reads information_schema.tikv_store_status (the stores registered in PD)
+	SQLFeatureTiKVStoreStatus SQLFeature = "tikv_store_status"
+)
+
+// sqlCapability is the version range in which a SQL feature is available
+type sqlCapability struct {
+	Feature SQLFeature
+	// MinVersion is the first version supporting the feature ("" if all versions support it)
+	MinVersion string
+	// MaxVersion is the first version no longer supporting the feature ("" if it is still supported)
+	MaxVersion string
+}
+
+// sqlCapabilities is the capability table consulted after connecting to TiDB
+// Add an entry for every statement that reads a table or uses a syntax missing in some supported versions
+var sqlCapabilities = []sqlCapability{
+	{Feature: SQLFeatureShowConfig, MinVersion: "v4.0.0"},
+	{Feature: SQLFeatureResourceGroups, MinVersion: "v7.1.0"},
+	{Feature: SQLFeaturePlacementPolicies, MinVersion: "v5.3.0"},
+	{Feature: SQLFeatureClusterInfo, MinVersion: "v4.0.0"},
+	{Feature: SQLFeatureTiKVStoreStatus, MinVersion: "v4.0.0"},
+}
+
+// MySQL error numbers of statements reading a table that doesn't exist
+const (
+	errNoSuchTable  = 1146 // ER_NO_SUCH_TABLE
+	errUnknownTable = 1109 // ER_UNKNOWN_TABLE
+)
+
+// MySQL error numbers of statements the user lacks the privileges for
+const (
+	errDBAccessDenied       = 1044 // ER_DBACCESS_DENIED_ERROR
+	errTableAccessDenied    = 1142 // ER_TABLEACCESS_DENIED_ERROR
+	errSpecificAccessDenied = 1227 // ER_SPECIFIC_ACCESS_DENIED_ERROR
+)
+
+// SQLCapabilities are the SQL features supported by a TiDB server, derived from its version
+type SQLCapabilities struct {
+	// Version is the normalized version of the server ("" if it couldn't be parsed)
+	Version  string
+	features map[SQLFeature]bool
+}
+
+// NewSQLCapabilities returns the SQL features supported by a server reporting rawVersion (e.g., "5.7.25-TiDB-v6.5.0")
+// If the version can't be parsed, every feature is assumed to be supported: statements are still issued
+// and a missing table is reported as a note (see IsUnknownTableError)
+func NewSQLCapabilities(rawVersion string) *SQLCapabilities {
+	caps := &SQLCapabilities{
+		Version:  types.NormalizeVersion(rawVersion),
+		features: make(map[SQLFeature]bool, len(sqlCapabilities)),
+	}
+	for _, capability := range sqlCapabilities {
+		caps.features[capability.Feature] = caps.Version == "" || capability.supports(caps.Version)
+	}
+	return caps
+}
+
+// Supports checks if the server supports a SQL feature
+// Features missing from the capability table are assumed to be supported
+func (c *SQLCapabilities) Supports(feature SQLFeature) bool {
+	if c == nil {
+		return true
+	}
+	supported, ok := c.features[feature]
+	return !ok || supported
+}
+
+// MarkUnsupported records that a feature turned out to be unavailable (e.g., its table doesn't exist)
+// so that later statements relying on it are skipped
+func (c *SQLCapabilities) MarkUnsupported(feature SQLFeature) {
+	if c != nil {
+		c.features[feature] = false
+	}
+}
+
+// supports checks if the feature is available in version (normalized)
+func (c sqlCapability) supports(version string) bool {
+	if c.MinVersion != "" && compareReleaseVersions(version, c.MinVersion) < 0 {
+		return false
+	}
+	if c.MaxVersion != "" && compareReleaseVersions(version, c.MaxVersion) >= 0 {
+		return false
+	}
+	return true
+}
+
+// releaseVersionParts matches the numeric parts of a normalized version
+var releaseVersionParts = regexp.MustCompile(`^v(\d+)\.(\d+)\.(\d+)$`)
+
+// compareReleaseVersions compares two normalized versions (vX.Y.Z)
+// Returns -1 if a < b, 0 if a == b, 1 if a > b
+func compareReleaseVersions(a, b string) int {
+	partsA := releaseVersionParts.FindStringSubmatch(a)
+	partsB := releaseVersionParts.FindStringSubmatch(b)
+	if partsA == nil || partsB == nil {
+		return 0
+	}
+	for i := 1; i <= 3; i++ {
+		numA, _ := strconv.Atoi(partsA[i])
+		numB, _ := strconv.Atoi(partsB[i])
+		if numA != numB {
+			if numA < numB {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// IsUnknownTableError checks if err is the error of a statement reading a table the server doesn't have
+func IsUnknownTableError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == errNoSuchTable || mysqlErr.Number == errUnknownTable
+	}
+	return false
+}
+
+// IsPrivilegeError checks if err is the error of a statement the user lacks the privileges for
+func IsPrivilegeError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == errDBAccessDenied || mysqlErr.Number == errTableAccessDenied || mysqlErr.Number == errSpecificAccessDenied
+	}
+	return false
+}
+
+// noteUnsupported prints a note about a statement skipped because the server doesn't support it
+func noteUnsupported(feature SQLFeature, caps *SQLCapabilities, reason string) {
+	version := caps.Version
+	if version == "" {
+		version = "unknown version"
+	}
+	fmt.Printf("Note: skipping %s, not supported by TiDB %s (%s)\n", feature, version, reason)
+}
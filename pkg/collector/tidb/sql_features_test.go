@@ -0,0 +1,200 @@
+package tidb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newMockCollector creates a TiDB collector connected to a sqlmock database
+func newMockCollector(t *testing.T, sqlTimeout time.Duration) (*tidbCollector, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	collector := &tidbCollector{
+		httpClient: &http.Client{},
+		sqlTimeout: sqlTimeout,
+		openDB:     func(dsn string) (*sql.DB, error) { return db, nil },
+	}
+	return collector, mock
+}
+
+func TestNewSQLCapabilities(t *testing.T) {
+	assert.True(t, NewSQLCapabilities("5.7.25-TiDB-v6.5.0").Supports(SQLFeatureShowConfig))
+	assert.True(t, NewSQLCapabilities("8.0.11-TiDB-v8.5.0").Supports(SQLFeatureShowConfig))
+	assert.False(t, NewSQLCapabilities("5.7.25-TiDB-v3.0.20").Supports(SQLFeatureShowConfig))
+	// Unknown versions issue every statement
+	assert.True(t, NewSQLCapabilities("5.7.25").Supports(SQLFeatureShowConfig))
+	// Features outside the capability table are assumed to be supported
+	assert.True(t, NewSQLCapabilities("5.7.25-TiDB-v6.5.0").Supports(SQLFeature("unknown")))
+
+	caps := NewSQLCapabilities("5.7.25-TiDB-v6.5.0")
+	assert.Equal(t, "v6.5.0", caps.Version)
+	caps.MarkUnsupported(SQLFeatureShowConfig)
+	assert.False(t, caps.Supports(SQLFeatureShowConfig))
+}
+
+func TestIsUnknownTableError(t *testing.T) {
+	missing := &mysql.MySQLError{Number: errNoSuchTable, Message: "Table 'information_schema.cluster_config' doesn't exist"}
+	assert.True(t, IsUnknownTableError(missing))
+	assert.True(t, IsUnknownTableError(fmt.Errorf("failed to query config: %w", missing)))
+	assert.False(t, IsUnknownTableError(&mysql.MySQLError{Number: 1045, Message: "Access denied"}))
+	assert.False(t, IsUnknownTableError(context.DeadlineExceeded))
+}
+
+func TestCollect_MissingTable(t *testing.T) {
+	// A v6.5-ish server without information_schema.cluster_config: SHOW CONFIG fails, variables are still collected
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("5.7.25-TiDB-v6.5.0"))
+	mock.ExpectQuery("SHOW CONFIG WHERE type='tidb'").
+		WillReturnError(&mysql.MySQLError{Number: errNoSuchTable, Message: "Table 'information_schema.cluster_config' doesn't exist"})
+	mock.ExpectQuery("SHOW GLOBAL VARIABLES").
+		WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).AddRow("tidb_txn_mode", "pessimistic"))
+
+	state, err := collector.CollectWithStatusAddr(context.Background(), "127.0.0.1:4000", "", "root", "")
+	require.NoError(t, err)
+	assert.Equal(t, "5.7.25-TiDB-v6.5.0", state.Version)
+	assert.Empty(t, state.Config)
+	assert.Equal(t, "pessimistic", state.Variables["tidb_txn_mode"].Value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollect_UnsupportedStatementSkipped(t *testing.T) {
+	// SHOW CONFIG is not issued to versions the capability table excludes (sqlmock fails on unexpected queries)
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("5.7.25-TiDB-v3.0.20"))
+	mock.ExpectQuery("SHOW GLOBAL VARIABLES").
+		WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}).AddRow("tidb_txn_mode", "pessimistic"))
+
+	state, err := collector.CollectWithStatusAddr(context.Background(), "127.0.0.1:4000", "", "root", "")
+	require.NoError(t, err)
+	assert.Empty(t, state.Config)
+	assert.Len(t, state.Variables, 1)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollect_SQLTimeout(t *testing.T) {
+	// A statement blocked on a locked system table is canceled after the SQL timeout
+	collector, mock := newMockCollector(t, 50*time.Millisecond)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("5.7.25-TiDB-v7.5.0"))
+	mock.ExpectQuery("SHOW CONFIG WHERE type='tidb'").
+		WillReturnRows(sqlmock.NewRows([]string{"Type", "Instance", "Name", "Value"}).AddRow("tidb", "127.0.0.1:4000", "mem-quota-query", "1073741824"))
+	mock.ExpectQuery("SHOW GLOBAL VARIABLES").WillDelayFor(10 * time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "Value"}))
+
+	start := time.Now()
+	_, err := collector.CollectWithStatusAddr(context.Background(), "127.0.0.1:4000", "", "root", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to get TiDB variables")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestCollectGCStatus(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VARIABLE_NAME, VARIABLE_VALUE FROM mysql.tidb WHERE VARIABLE_NAME IN ('tikv_gc_safe_point', 'tikv_gc_last_run_time')").
+		WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_NAME", "VARIABLE_VALUE"}).
+			AddRow("tikv_gc_last_run_time", "20240102-10:00:00.000 +0800").
+			AddRow("tikv_gc_safe_point", "20240102-09:50:00.000 +0800"))
+
+	state, err := collector.CollectGCStatus("127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	assert.Equal(t, "20240102-09:50:00.000 +0800", state.GCSafePoint)
+	assert.Equal(t, "20240102-10:00:00.000 +0800", state.GCLastRunTime)
+	assert.False(t, state.ServiceSafePointsAvailable)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestDetectVersion(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT tidb_version()").
+		WillReturnRows(sqlmock.NewRows([]string{"tidb_version()"}).AddRow("Release Version: v7.5.1\nEdition: Community\nGit Commit Hash: 7d16cc79e81bbf573124df3fd9351c26963f3e70"))
+	version, err := collector.DetectVersion(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	assert.Equal(t, "v7.5.1", version)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	// tidb_version() is unavailable: the version is parsed from VERSION()
+	collector, mock = newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT tidb_version()").WillReturnError(&mysql.MySQLError{Number: 1305, Message: "FUNCTION tidb_version does not exist"})
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.11-TiDB-v8.5.0"))
+	version, err = collector.DetectVersion(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	assert.Equal(t, "v8.5.0", version)
+
+	// Not a TiDB version
+	collector, mock = newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT tidb_version()").WillReturnError(&mysql.MySQLError{Number: 1305, Message: "FUNCTION tidb_version does not exist"})
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("MariaDB"))
+	_, err = collector.DetectVersion(context.Background(), "127.0.0.1:4000", "root", "")
+	assert.ErrorContains(t, err, "failed to parse TiDB version")
+}
+
+func TestQueryBootstrapVersion(t *testing.T) {
+	const query = "SELECT VARIABLE_VALUE FROM mysql.tidb WHERE VARIABLE_NAME = 'tidb_server_version'"
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_VALUE"}).AddRow("180"))
+	version, err := collector.QueryBootstrapVersion(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	assert.Equal(t, int64(180), version)
+	require.NoError(t, mock.ExpectationsWereMet())
+
+	collector, mock = newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery(query).WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_VALUE"}).AddRow("unknown"))
+	_, err = collector.QueryBootstrapVersion(context.Background(), "127.0.0.1:4000", "root", "")
+	assert.ErrorContains(t, err, "invalid tidb_server_version")
+
+	collector, mock = newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery(query).WillReturnError(&mysql.MySQLError{Number: 1142, Message: "SELECT command denied to user 'precheck'@'%' for table 'tidb'"})
+	_, err = collector.QueryBootstrapVersion(context.Background(), "127.0.0.1:4000", "root", "")
+	assert.ErrorContains(t, err, "failed to query tidb_server_version")
+}
+
+func TestCollectPlacement(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.11-TiDB-v7.5.0"))
+	mock.ExpectQuery(resourceGroupsQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"NAME", "RU_PER_SEC", "PRIORITY", "BURSTABLE"}).
+			AddRow("default", "UNLIMITED", "MEDIUM", "YES").
+			AddRow("rg1", "2000", "HIGH", "NO"))
+	mock.ExpectQuery(placementPoliciesQuery).
+		WillReturnRows(sqlmock.NewRows([]string{"POLICY_NAME", "PRIMARY_REGION", "REGIONS", "CONSTRAINTS", "LEADER_CONSTRAINTS",
+			"FOLLOWER_CONSTRAINTS", "LEARNER_CONSTRAINTS", "SCHEDULE", "FOLLOWERS", "LEARNERS"}).
+			AddRow("p1", "us-east-1", "us-east-1,us-west-1", "", "", "", "", "", 2, 0).
+			AddRow("p2", "", "", "[+disk=ssd]", "", "", "", "", 2, nil))
+
+	state, err := collector.CollectPlacement(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	assert.True(t, state.ResourceGroupsAvailable)
+	assert.Len(t, state.ResourceGroups, 2)
+	assert.Equal(t, "2000", state.ResourceGroups[1].RUPerSec)
+	assert.True(t, state.PlacementPoliciesAvailable)
+	assert.Equal(t, "us-east-1,us-west-1", state.PlacementPolicies[0].Regions)
+	assert.Equal(t, "[+disk=ssd]", state.PlacementPolicies[1].Constraints)
+	assert.Empty(t, state.Notes)
+	require.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollectPlacement_Degraded(t *testing.T) {
+	// Resource groups are not queried before v7.1, a missing privilege leaves a note
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("8.0.11-TiDB-v6.5.0"))
+	mock.ExpectQuery(placementPoliciesQuery).
+		WillReturnError(&mysql.MySQLError{Number: errTableAccessDenied, Message: "SELECT command denied to user 'precheck'@'%' for table 'placement_policies'"})
+
+	state, err := collector.CollectPlacement(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	assert.False(t, state.ResourceGroupsAvailable)
+	assert.False(t, state.PlacementPoliciesAvailable)
+	require.Len(t, state.Notes, 2)
+	assert.Contains(t, state.Notes[0], "not supported by TiDB v6.5.0")
+	assert.Contains(t, state.Notes[1], "missing privileges")
+	require.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,59 @@
+package tidb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// CollectConfig reads the configuration of the TiDB instances with SHOW CONFIG WHERE type='tidb'
+// Unlike CollectWithStatusAddr, neither the status API nor the system variables are read
+func (c *tidbCollector) CollectConfig(ctx context.Context, addr, user, password string) (types.ParameterMap, error) {
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	config, err := c.getConfigByType(ctx, db, "tidb")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get TiDB config: %w", err)
+	}
+	return types.ConvertConfigToDefaults(config), nil
+}
+
+// CollectSystemVariables reads the global system variables from information_schema.GLOBAL_VARIABLES
+func (c *tidbCollector) CollectSystemVariables(ctx context.Context, addr, user, password string) (types.ParameterMap, error) {
+	db, err := c.open(addr, user, password)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	ctx, cancel := c.queryContext(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx, "SELECT VARIABLE_NAME, VARIABLE_VALUE FROM information_schema.GLOBAL_VARIABLES")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query information_schema.GLOBAL_VARIABLES: %w", err)
+	}
+	defer rows.Close()
+
+	variables := make(map[string]string)
+	for rows.Next() {
+		var name string
+		var value sql.NullString
+		if err := rows.Scan(&name, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan information_schema.GLOBAL_VARIABLES row: %w", err)
+		}
+		variables[name] = value.String
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating information_schema.GLOBAL_VARIABLES: %w", err)
+	}
+
+	return types.ConvertVariablesToSystemVariables(variables), nil
+}
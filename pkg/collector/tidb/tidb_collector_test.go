@@ -0,0 +1,38 @@
+package tidb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectConfig(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SHOW CONFIG WHERE type='tidb'").
+		WillReturnRows(sqlmock.NewRows([]string{"Type", "Instance", "Name", "Value"}).
+			AddRow("tidb", "127.0.0.1:4000", "mem-quota-query", "1073741824").
+			AddRow("tidb", "127.0.0.1:4000", "log.level", "info"))
+
+	config, err := collector.CollectConfig(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	assert.Len(t, config, 2)
+	assert.Equal(t, "info", config["log.level"].Value)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCollectSystemVariables(t *testing.T) {
+	collector, mock := newMockCollector(t, DefaultSQLTimeout)
+	mock.ExpectQuery("SELECT VARIABLE_NAME, VARIABLE_VALUE FROM information_schema.GLOBAL_VARIABLES").
+		WillReturnRows(sqlmock.NewRows([]string{"VARIABLE_NAME", "VARIABLE_VALUE"}).
+			AddRow("tidb_txn_mode", "pessimistic").
+			AddRow("tidb_snapshot", nil))
+
+	variables, err := collector.CollectSystemVariables(context.Background(), "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	assert.Equal(t, "pessimistic", variables["tidb_txn_mode"].Value)
+	assert.Contains(t, variables, "tidb_snapshot")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,55 @@
+package tidb
+
+import (
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// bootstrapVersionFiles are the files that may declare currentBootstrapVersion, relative to the repository root
+// v7.5+ uses the pkg/ layout; older versions may declare it in bootstrap.go (v5.4 has no upgrade.go)
+var bootstrapVersionFiles = []string{
+	"pkg/session/upgrade.go",
+	"pkg/session/bootstrap.go",
+	"session/upgrade.go",
+	"session/bootstrap.go",
+}
+
+// releaseTagPattern matches the tags of TiDB releases (e.g., v8.1.0), pre-releases (v8.1.0-alpha) excluded
+var releaseTagPattern = regexp.MustCompile(`^v\d+\.\d+\.\d+$`)
+
+// ReleaseTags lists the release tags of a TiDB repository (e.g., v8.1.0), in no particular order
+func ReleaseTags(tidbRoot string) ([]string, error) {
+	cmd := exec.Command("git", "tag", "--list", "v*")
+	cmd.Dir = tidbRoot
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the tags of %s: %w", tidbRoot, err)
+	}
+	var tags []string
+	for _, tag := range strings.Fields(string(output)) {
+		if releaseTagPattern.MatchString(tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+// BootstrapVersionAtTag extracts currentBootstrapVersion at a tag of a TiDB repository
+// The files are read with git show, so unlike ExtractBootstrapVersion the working tree is left as is
+// Returns 0 if the tag declares no bootstrap version
+func BootstrapVersionAtTag(tidbRoot, tag string) int64 {
+	for _, path := range bootstrapVersionFiles {
+		cmd := exec.Command("git", "show", tag+":"+path)
+		cmd.Dir = tidbRoot
+		output, err := cmd.Output()
+		if err != nil {
+			continue
+		}
+		if version := parseBootstrapVersion(string(output)); version > 0 {
+			return version
+		}
+	}
+	return 0
+}
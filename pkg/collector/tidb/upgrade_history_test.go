@@ -0,0 +1,54 @@
+package tidb
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// commitAndTag writes a file to the repository, commits it and tags the commit
+func commitAndTag(t *testing.T, repo, path, content, tag string) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(repo, path)), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, path), []byte(content), 0644))
+	for _, args := range [][]string{{"add", "-A"}, {"commit", "-q", "-m", tag}, {"tag", tag}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+}
+
+func TestBootstrapVersionAtTag(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	repo := t.TempDir()
+	cmd := exec.Command("git", "init", "-q")
+	cmd.Dir = repo
+	require.NoError(t, cmd.Run())
+	t.Setenv("GIT_AUTHOR_NAME", "test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+
+	// v6.5 declares it in session/bootstrap.go, v7.5 moved it to pkg/session/upgrade.go
+	commitAndTag(t, repo, "session/bootstrap.go", "var currentBootstrapVersion int64 = version109\nversion109 = 109\n", "v6.5.0")
+	commitAndTag(t, repo, "session/bootstrap.go", "var currentBootstrapVersion int64 = version110\nversion110 = 110\n", "v6.5.1-alpha")
+	require.NoError(t, os.RemoveAll(filepath.Join(repo, "session")))
+	commitAndTag(t, repo, "pkg/session/upgrade.go", "var currentBootstrapVersion int64 = version179\nversion179 = 179\n", "v7.5.0")
+
+	tags, err := ReleaseTags(repo)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"v6.5.0", "v7.5.0"}, tags)
+
+	assert.Equal(t, int64(109), BootstrapVersionAtTag(repo, "v6.5.0"))
+	assert.Equal(t, int64(179), BootstrapVersionAtTag(repo, "v7.5.0"))
+	assert.Equal(t, int64(0), BootstrapVersionAtTag(repo, "v9.9.9"))
+
+	_, err = ReleaseTags(t.TempDir())
+	assert.Error(t, err)
+}
@@ -2,10 +2,12 @@ package tidb
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
@@ -57,8 +59,9 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 		return nil, fmt.Errorf("failed to find upgrade logic file: %w", err)
 	}
 
-	// System variable names are extracted directly as user-visible names from source code
-	// No conversion needed - source code already uses user-visible names (e.g., "tidb_xxx")
+	// Variable names are either string literals (e.g., "tidb_xxx") or constant references
+	// (e.g., vardef.TiDBDDLReorgWorkerCount), which are resolved with the constants defined in the repository
+	varNameConsts := loadVarNameConsts(repoRoot)
 
 	f, err := os.Open(upgradeFilePath)
 	if err != nil {
@@ -72,10 +75,31 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 		curFunc       string
 		curVersion    string
 		curComment    string
+		curDoc        string   // Doc comment of the current upgradeToVerXX function
+		docLines      []string // Comment lines above the next function
+		conditions    []ifCondition
+		annotated     int // Number of results annotated with their function doc comment and condition
 		results       []types.UpgradeParamChange
+		unresolved    []types.UnresolvedVarName
 		braceDepth    int // Track brace depth to detect function end
+		lineNum       int
 	)
 
+	// resolveVarName converts a variable name argument into the user-visible name
+	// Constants that cannot be resolved are recorded with the function and line where they appeared
+	resolveVarName := func(raw string) string {
+		name, resolved := convertVarNameToUserVisible(raw, varNameConsts)
+		if !resolved {
+			unresolved = append(unresolved, types.UnresolvedVarName{
+				Constant: strings.TrimSpace(raw),
+				Fallback: name,
+				FuncName: curFunc,
+				Line:     lineNum,
+			})
+		}
+		return name
+	}
+
 	// Match upgradeToVerXX function definition
 	// Pattern: func upgradeToVer65(...) or func upgradeToVer75(...)
 	// Extract function name and version number (e.g., "65" from "upgradeToVer65")
@@ -84,7 +108,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 	// Match setGlobalSysVar/variable writing calls
 	// Pattern: setGlobalSysVar(varName, value) or writeGlobalSysVar(varName, value)
 	// For initGlobalVariableIfNotExists: initGlobalVariableIfNotExists(s, varName, value)
-	// Variable names are extracted directly as user-visible names from source code
+	// Variable names are converted to user-visible names by resolveVarName
 	setVarRe := regexp.MustCompile(`(setGlobalSysVar|writeGlobalSysVar)\s*\(\s*([^,]+)\s*,\s*([^,\)]+)`)
 	// Separate regex for initGlobalVariableIfNotExists (has 3 parameters: session, varName, value)
 	initGlobalVarRe := regexp.MustCompile(`initGlobalVariableIfNotExists\s*\(\s*[^,]+,\s*([^,]+)\s*,\s*([^,\)]+)`)
@@ -93,17 +117,42 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 
 	// Match SetGlobalSysVar calls
 	// Pattern: SetGlobalSysVar(varName, value) or GlobalVarsAccessor.SetGlobalSysVar(...)
-	// Variable names are extracted directly as user-visible names from source code
-	setGlobalWithVarRe := regexp.MustCompile(`SetGlobalSysVar\([^,]*,\s*([a-zA-Z0-9_"']+)`)
+	// Variable names are converted to user-visible names by resolveVarName
+	setGlobalWithVarRe := regexp.MustCompile(`SetGlobalSysVar\([^,]*,\s*([a-zA-Z0-9_."']+)`)
 
 	// Match function comments for documentation
 	commentRe := regexp.MustCompile(`^//\s*(.*)`)
 
+	// Match if statements opening a block, and else branches of the innermost one
+	ifRe := regexp.MustCompile(`^\s*if\s+(.+?)\s*\{\s*$`)
+	elseIfRe := regexp.MustCompile(`^\s*\}\s*else\s+if\s+(.+?)\s*\{\s*$`)
+	elseRe := regexp.MustCompile(`^\s*\}\s*else\s*\{\s*$`)
+
+	// annotate records the doc comment of the function and the conditions wrapping the changes
+	// appended since the last call, i.e. while processing the previous line
+	annotate := func() {
+		for ; annotated < len(results); annotated++ {
+			results[annotated].FunctionDocstring = curDoc
+			results[annotated].Condition = joinConditions(conditions)
+		}
+	}
+
 	// Read all lines to process the file
 	// This extracts all upgradeToVerXX functions from the latest version codebase
 	// All historical upgrade functions are preserved in the latest TiDB code
 	for scanner.Scan() {
+		annotate()
 		line := scanner.Text()
+		lineNum++
+
+		// Collect the comment block immediately above a function
+		if !inUpgradeFunc {
+			if m := commentRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+				docLines = append(docLines, strings.TrimSpace(m[1]))
+			} else if !funcRe.MatchString(line) {
+				docLines = nil
+			}
+		}
 
 		// Detect upgradeToVerXX function start
 		if m := funcRe.FindStringSubmatch(line); m != nil {
@@ -114,6 +163,9 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 			// Bootstrap version numbers are internal TiDB version numbers, not release versions
 			curVersion = versionNum
 			curComment = ""
+			curDoc = strings.TrimSpace(strings.Join(docLines, " "))
+			docLines = nil
+			conditions = nil
 			braceDepth = 0
 			// Count opening brace in function signature
 			braceDepth += strings.Count(line, "{")
@@ -132,15 +184,28 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 				continue
 			}
 
+			// Track the if statements wrapping the following lines
+			for len(conditions) > 0 && conditions[len(conditions)-1].depth > braceDepth {
+				conditions = conditions[:len(conditions)-1]
+			}
+			if m := elseIfRe.FindStringSubmatch(line); m != nil && len(conditions) > 0 {
+				top := &conditions[len(conditions)-1]
+				top.cond = fmt.Sprintf("!(%s) && %s", top.cond, m[1])
+			} else if elseRe.MatchString(line) && len(conditions) > 0 {
+				top := &conditions[len(conditions)-1]
+				top.cond = fmt.Sprintf("!(%s)", top.cond)
+			} else if m := ifRe.FindStringSubmatch(line); m != nil {
+				conditions = append(conditions, ifCondition{cond: m[1], depth: braceDepth})
+			}
+
 			// Extract system variable changes from various patterns
 
 			// Pattern 1a: initGlobalVariableIfNotExists(s, varName, value)
-			// Variable names are extracted directly as user-visible names from source code
+			// Variable names are converted to user-visible names by resolveVarName
 			if m := initGlobalVarRe.FindStringSubmatch(line); m != nil {
 				varNameRaw := strings.TrimSpace(m[1])
 				valueRaw := strings.TrimSpace(m[2])
-				// Extract user-visible variable name directly from source code
-				varName := strings.Trim(varNameRaw, "\" '`")
+				varName := resolveVarName(varNameRaw)
 				value := strings.Trim(valueRaw, "\" '`")
 				// Normalize boolean values
 				if strings.ToLower(value) == "off" {
@@ -165,11 +230,10 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 			}
 
 			// Pattern 1b: setGlobalSysVar, writeGlobalSysVar
-			// Variable names are extracted directly as user-visible names from source code
+			// Variable names are converted to user-visible names by resolveVarName
 			if m := setVarRe.FindStringSubmatch(line); m != nil {
 				method := m[1]
-				// Extract user-visible variable name directly from source code
-				varName := strings.Trim(m[2], "\" '`")
+				varName := resolveVarName(m[2])
 				value := strings.Trim(m[3], "\" '`")
 				results = append(results, types.UpgradeParamChange{
 					Version:     curVersion,
@@ -288,9 +352,12 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 						// Determine severity based on operation type
 						// UPDATE and REPLACE: medium risk (default value behavior may have changed)
 						// DELETE: low-medium risk (parameter is deprecated)
+						// The method records DELETE explicitly so that the analyzer can report the variable as removed
 						severity := "medium"
+						method := "mustExecute"
 						if strings.Contains(line, "DELETE") || strings.Contains(line, "delete") {
 							severity = "low-medium"
+							method = "mustExecute-DELETE"
 						}
 
 						change := types.UpgradeParamChange{
@@ -299,7 +366,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 							VarName:     varName,
 							Name:        varName,
 							Value:       value,
-							Method:      "mustExecute",
+							Method:      method,
 							Comment:     curComment,
 							Description: curComment,
 							Force:       true,
@@ -328,7 +395,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 				// Semantic differences:
 				// - INSERT IGNORE: If variable exists, ignore insert and keep existing value (user's setting or previous default)
 				// - REPLACE: Force update the variable value even if it exists
-				// Variable names are extracted directly as user-visible names from source code
+				// Variable names are converted to user-visible names by resolveVarName
 				if strings.Contains(line, "GlobalVariablesTable") {
 					// Match INSERT/REPLACE statements with VALUES clause
 					// Pattern: INSERT/REPLACE ... INTO %n.%n VALUES (%?, %?) or fmt.Sprintf("INSERT ... INTO %s.%s VALUES ...", ..., mysql.GlobalVariablesTable, ...)
@@ -339,7 +406,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 					if insertReplaceRe.MatchString(line) || fmtSprintfInsertRe.MatchString(line) {
 						// Extract variable name and value from parameters
 						// Pattern: mustExecute(s, "...", mysql.SystemDB, mysql.GlobalVariablesTable, "var_name", "value")
-						// Variable names are extracted directly as user-visible names from source code
+						// Variable names are converted to user-visible names by resolveVarName
 						varNameRe := regexp.MustCompile(`mysql\.GlobalVariablesTable\s*,\s*([^,)]+)`)
 						valueRe := regexp.MustCompile(`mysql\.GlobalVariablesTable\s*,\s*[^,]+,\s*([^,)]+)`)
 
@@ -353,8 +420,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 						}
 
 						if varNameRaw != "" {
-							// Extract user-visible variable name directly from source code
-							varName := strings.Trim(varNameRaw, "\" '`")
+							varName := resolveVarName(varNameRaw)
 							value := ""
 							if valueRaw != "" {
 								value = strings.Trim(valueRaw, "\" '`")
@@ -429,7 +495,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 							} else {
 								// Parameter, try to extract from function call
 								// Look for pattern: mysql.GlobalVariablesTable, ..., "var_name"
-								// Variable names are extracted directly as user-visible names from source code
+								// Variable names are converted to user-visible names by resolveVarName
 								paramRe := regexp.MustCompile(`mysql\.GlobalVariablesTable\s*,\s*([^,)]+)`)
 								if pm := paramRe.FindStringSubmatch(line); pm != nil {
 									varNameRaw = strings.TrimSpace(pm[1])
@@ -475,8 +541,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 						}
 
 						if varNameRaw != "" {
-							// Extract user-visible variable name directly from source code
-							varName := strings.Trim(varNameRaw, "\" '`")
+							varName := resolveVarName(varNameRaw)
 							value := ""
 							if valueRaw != "" {
 								value = strings.Trim(valueRaw, "\" '`")
@@ -530,8 +595,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 						}
 
 						if varNameRaw != "" {
-							// Extract user-visible variable name directly from source code
-							varName := strings.Trim(varNameRaw, "\" '`")
+							varName := resolveVarName(varNameRaw)
 							results = append(results, types.UpgradeParamChange{
 								Version:     curVersion,
 								FuncName:    curFunc,
@@ -553,7 +617,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 
 			// Pattern 3: SetGlobalSysVar calls (including GlobalVarsAccessor.SetGlobalSysVar)
 			// Match: SetGlobalSysVar(context.Background(), "tidb_xxx", "value")
-			// Variable names are extracted directly as user-visible names from source code
+			// Variable names are converted to user-visible names by resolveVarName
 			if strings.Contains(line, "SetGlobalSysVar") {
 				// Try to extract variable name and value
 				// Pattern: SetGlobalSysVar(..., "var_name", value)
@@ -571,8 +635,7 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 						valueRaw = "unknown"
 					}
 
-					// Extract user-visible variable name directly from source code
-					varName := strings.Trim(varNameRaw, "\" '`")
+					varName := resolveVarName(varNameRaw)
 					value := strings.Trim(valueRaw, "\" '`")
 					// Normalize boolean values
 					if strings.ToLower(value) == "off" {
@@ -607,9 +670,98 @@ func CollectUpgradeLogicFromSource(repoRoot string) (*types.UpgradeLogicSnapshot
 	if err := scanner.Err(); err != nil {
 		return nil, err
 	}
+	annotate()
 
 	return &types.UpgradeLogicSnapshot{
-		Component: types.ComponentTiDB,
-		Changes:   results,
+		Component:       types.ComponentTiDB,
+		Changes:         results,
+		UnresolvedNames: unresolved,
 	}, nil
 }
+
+// ifCondition is an if statement of an upgrade function wrapping the lines being parsed
+type ifCondition struct {
+	// cond is the condition of the branch (negated for else branches)
+	cond string
+	// depth is the brace depth inside the branch block
+	depth int
+}
+
+// joinConditions returns the conditions wrapping a line, outermost first
+func joinConditions(conditions []ifCondition) string {
+	conds := make([]string, 0, len(conditions))
+	for _, c := range conditions {
+		conds = append(conds, c.cond)
+	}
+	return strings.Join(conds, " && ")
+}
+
+// BootstrapVersionIndexFile is the file name of the bootstrap version index, next to upgrade_logic.json
+const BootstrapVersionIndexFile = "bootstrap_version_index.json"
+
+// BootstrapVersionChanges contains the forced changes made by the upgrade function of a bootstrap version
+type BootstrapVersionChanges struct {
+	// Version is the bootstrap version (e.g., 177 for upgradeToVer177)
+	Version int64 `json:"version"`
+	// FuncName is the upgrade function (e.g., "upgradeToVer177")
+	FuncName string `json:"func_name"`
+	// Docstring is the doc comment of the upgrade function, if any
+	Docstring string `json:"docstring,omitempty"`
+	// Changes are the forced changes of the function, in source order
+	Changes []types.UpgradeParamChange `json:"changes"`
+}
+
+// GroupByBootstrapVersion groups forced changes by the bootstrap version of their upgrade function
+// The version is read from the change version, or from the function name (upgradeToVerXX) if it is not a number;
+// changes with neither are left out
+func GroupByBootstrapVersion(changes []types.UpgradeParamChange) map[int64]*BootstrapVersionChanges {
+	grouped := make(map[int64]*BootstrapVersionChanges)
+	for _, change := range changes {
+		version, ok := bootstrapVersionOf(change)
+		if !ok {
+			continue
+		}
+		group, exists := grouped[version]
+		if !exists {
+			group = &BootstrapVersionChanges{Version: version, FuncName: change.FuncName}
+			grouped[version] = group
+		}
+		if group.FuncName == "" {
+			group.FuncName = change.FuncName
+		}
+		if group.Docstring == "" {
+			group.Docstring = change.FunctionDocstring
+		}
+		group.Changes = append(group.Changes, change)
+	}
+	return grouped
+}
+
+// bootstrapVersionOf returns the bootstrap version of a forced change
+func bootstrapVersionOf(change types.UpgradeParamChange) (int64, bool) {
+	if version, err := strconv.ParseInt(change.Version, 10, 64); err == nil {
+		return version, true
+	}
+	if suffix, ok := strings.CutPrefix(change.FuncName, "upgradeToVer"); ok {
+		if version, err := strconv.ParseInt(suffix, 10, 64); err == nil {
+			return version, true
+		}
+	}
+	return 0, false
+}
+
+// SaveBootstrapVersionIndex saves the forced changes grouped by bootstrap version to outputPath,
+// as a JSON object keyed by bootstrap version (knowledge/tidb/bootstrap_version_index.json)
+func SaveBootstrapVersionIndex(grouped map[int64]*BootstrapVersionChanges, outputPath string) error {
+	data, err := json.MarshalIndent(grouped, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal bootstrap version index: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := os.WriteFile(outputPath, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write bootstrap version index: %w", err)
+	}
+	return nil
+}
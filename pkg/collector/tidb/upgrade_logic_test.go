@@ -0,0 +1,90 @@
+package tidb
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectUpgradeLogicFromSource_DocstringAndCondition(t *testing.T) {
+	repoRoot := t.TempDir()
+	path := filepath.Join(repoRoot, "pkg", "session", "upgrade.go")
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(`package session
+
+// upgradeToVer92 enables paging for clusters
+// upgraded from versions without it.
+func upgradeToVer92(s sessiontypes.Session, ver int64) {
+	if ver >= version92 {
+		return
+	}
+	mustExecute(s, "SET @@GLOBAL tidb_enable_paging = 1")
+	if ver < version90 {
+		mustExecute(s, "SET @@GLOBAL tidb_enable_a = 1")
+		if isCloud {
+			mustExecute(s, "SET @@GLOBAL tidb_enable_b = 1")
+		}
+	} else {
+		mustExecute(s, "SET @@GLOBAL tidb_enable_c = 1")
+	}
+}
+
+func helper() {}
+
+func upgradeToVer93(s sessiontypes.Session, ver int64) {
+	mustExecute(s, "SET @@GLOBAL tidb_enable_d = 1")
+}
+`), 0644))
+
+	snapshot, err := CollectUpgradeLogicFromSource(repoRoot)
+	require.NoError(t, err)
+
+	type change struct{ name, doc, condition string }
+	var changes []change
+	for _, c := range snapshot.Changes {
+		changes = append(changes, change{c.Name, c.FunctionDocstring, c.Condition})
+	}
+	doc := "upgradeToVer92 enables paging for clusters upgraded from versions without it."
+	assert.Equal(t, []change{
+		{"tidb_enable_paging", doc, ""},
+		{"tidb_enable_a", doc, "ver < version90"},
+		{"tidb_enable_b", doc, "ver < version90 && isCloud"},
+		{"tidb_enable_c", doc, "!(ver < version90)"},
+		{"tidb_enable_d", "", ""},
+	}, changes)
+}
+
+func TestGroupByBootstrapVersion(t *testing.T) {
+	changes := []types.UpgradeParamChange{
+		{Version: "92", Name: "tidb_enable_paging", FuncName: "upgradeToVer92", FunctionDocstring: "upgradeToVer92 enables paging."},
+		{Version: "92", Name: "tidb_enable_a", FuncName: "upgradeToVer92", FunctionDocstring: "upgradeToVer92 enables paging.", Condition: "ver < version90"},
+		{Version: "v7.5.0", Name: "tidb_enable_b", FuncName: "upgradeToVer177"},
+		{Version: "v7.5.0", Name: "tidb_enable_c"},
+	}
+
+	grouped := GroupByBootstrapVersion(changes)
+	require.Len(t, grouped, 2)
+	assert.Equal(t, int64(92), grouped[92].Version)
+	assert.Equal(t, "upgradeToVer92", grouped[92].FuncName)
+	assert.Equal(t, "upgradeToVer92 enables paging.", grouped[92].Docstring)
+	require.Len(t, grouped[92].Changes, 2)
+	assert.Equal(t, "tidb_enable_a", grouped[92].Changes[1].Name)
+	// The version is taken from the function name if it is not a bootstrap version
+	require.Len(t, grouped[177].Changes, 1)
+	assert.Equal(t, "tidb_enable_b", grouped[177].Changes[0].Name)
+
+	path := filepath.Join(t.TempDir(), "tidb", BootstrapVersionIndexFile)
+	require.NoError(t, SaveBootstrapVersionIndex(grouped, path))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var decoded map[string]*BootstrapVersionChanges
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.Contains(t, decoded, "177")
+	assert.Equal(t, "upgradeToVer177", decoded["177"].FuncName)
+	assert.Len(t, decoded["92"].Changes, 2)
+}
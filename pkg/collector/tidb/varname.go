@@ -0,0 +1,141 @@
+package tidb
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
+)
+
+// varNameConstDirs are the directories (relative to the TiDB repository root) that define
+// system variable name constants, e.g. TiDBDDLReorgWorkerCount = "tidb_ddl_reorg_worker_count"
+var varNameConstDirs = []string{
+	filepath.Join("pkg", "sessionctx", "vardef"),
+	filepath.Join("pkg", "sessionctx", "variable"),
+	filepath.Join("sessionctx", "vardef"),
+	filepath.Join("sessionctx", "variable"),
+}
+
+// knownAcronyms are the acronyms used in TiDB system variable constant names
+// They are kept as a single word when converting a constant name to snake_case
+// Longer acronyms come first so that they are matched before their prefixes
+var knownAcronyms = []string{"TiDB", "DDL", "SQL", "TTL", "TSO", "GC"}
+
+// loadVarNameConsts parses the system variable name constants from the TiDB repository
+func loadVarNameConsts(repoRoot string) map[string]string {
+	consts := make(map[string]string)
+	for _, dir := range varNameConstDirs {
+		path := filepath.Join(repoRoot, dir)
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		for name, value := range common.NewSysVarExtractor(path).GetVardefConsts() {
+			if _, exists := consts[name]; !exists {
+				consts[name] = value
+			}
+		}
+	}
+	return consts
+}
+
+// convertVarNameToUserVisible converts a variable name argument from the upgrade logic source
+// into the user-visible system variable name
+// String literals and lower-case names are used as-is, constant references (e.g. vardef.TiDBDDLReorgWorkerCount) are looked up in consts
+// If the constant cannot be resolved, the name is derived with camelToSnake and resolved is false
+func convertVarNameToUserVisible(raw string, consts map[string]string) (name string, resolved bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", true
+	}
+	if strings.ContainsAny(raw[:1], "\"'`") {
+		return strings.Trim(raw, "\" '`"), true
+	}
+
+	// Names without a package qualifier or upper-case letters are already user-visible (e.g. extracted from SQL text)
+	if !strings.Contains(raw, ".") && strings.ToLower(raw) == raw {
+		return raw, true
+	}
+
+	ident := raw
+	if idx := strings.LastIndex(ident, "."); idx >= 0 {
+		ident = ident[idx+1:]
+	}
+	if value, ok := consts[ident]; ok && value != "" {
+		return value, true
+	}
+	return camelToSnake(ident), false
+}
+
+// camelToSnake converts a Go constant name to snake_case
+// Known acronyms (TiDB, DDL, SQL, TTL, TSO, GC) are kept as one word,
+// e.g. TiDBDDLReorgWorkerCount -> tidb_ddl_reorg_worker_count
+func camelToSnake(s string) string {
+	var words []string
+	runes := []rune(s)
+	start := 0
+
+	flush := func(end int) {
+		if end > start {
+			words = append(words, strings.ToLower(string(runes[start:end])))
+		}
+		start = end
+	}
+
+	for i := 0; i < len(runes); {
+		// A known acronym at a word boundary is one word if it is not followed by a lowercase letter
+		if i == start {
+			if n := matchAcronym(runes[i:]); n > 0 {
+				i += n
+				flush(i)
+				continue
+			}
+		}
+
+		r := runes[i]
+		if i > start {
+			prev := runes[i-1]
+			switch {
+			case r == '_':
+				flush(i)
+				i++
+				start = i
+				continue
+			case unicode.IsUpper(r) && unicode.IsLower(prev):
+				flush(i)
+				continue
+			case unicode.IsUpper(r) && unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1]):
+				// End of an acronym: "HTTPServer" -> "HTTP", "Server"
+				flush(i)
+				continue
+			case unicode.IsDigit(r) && unicode.IsLetter(prev):
+				flush(i)
+				continue
+			}
+		} else if r == '_' {
+			i++
+			start = i
+			continue
+		}
+		i++
+	}
+	flush(len(runes))
+
+	return strings.Join(words, "_")
+}
+
+// matchAcronym returns the length of the known acronym at the beginning of runes, or 0
+func matchAcronym(runes []rune) int {
+	for _, acronym := range knownAcronyms {
+		n := len(acronym)
+		if len(runes) < n || string(runes[:n]) != acronym {
+			continue
+		}
+		if len(runes) > n && unicode.IsLower(runes[n]) {
+			continue
+		}
+		return n
+	}
+	return 0
+}
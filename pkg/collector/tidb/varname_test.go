@@ -0,0 +1,105 @@
+package tidb
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCamelToSnake(t *testing.T) {
+	tests := []struct {
+		constant string
+		want     string
+	}{
+		{"TiDBEnableAsyncMergeGlobalStats", "tidb_enable_async_merge_global_stats"},
+		{"TiDBDDLReorgWorkerCount", "tidb_ddl_reorg_worker_count"},
+		{"TiDBDDLReorgBatchSize", "tidb_ddl_reorg_batch_size"},
+		{"TiDBDDLErrorCountLimit", "tidb_ddl_error_count_limit"},
+		{"TiDBTTLJobEnable", "tidb_ttl_job_enable"},
+		{"TiDBTTLDeleteWorkerCount", "tidb_ttl_delete_worker_count"},
+		{"TiDBEnableTSOFollowerProxy", "tidb_enable_tso_follower_proxy"},
+		{"TiDBGCEnable", "tidb_gc_enable"},
+		{"TiDBGCConcurrency", "tidb_gc_concurrency"},
+		{"TiDBStmtSummaryMaxSQLLength", "tidb_stmt_summary_max_sql_length"},
+		{"TiDBEnableNonPreparedPlanCache", "tidb_enable_non_prepared_plan_cache"},
+		{"TiDBSchemaCacheSize", "tidb_schema_cache_size"},
+		{"TiDBMemQuotaQuery", "tidb_mem_quota_query"},
+		{"TiDBOptRangeMaxSize", "tidb_opt_range_max_size"},
+		{"TiDBEnable1PC", "tidb_enable_1pc"},
+		{"TiDBEnableHTTPServer", "tidb_enable_http_server"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.constant, func(t *testing.T) {
+			assert.Equal(t, tt.want, camelToSnake(tt.constant))
+		})
+	}
+}
+
+func TestConvertVarNameToUserVisible(t *testing.T) {
+	consts := map[string]string{
+		"TiDBDDLReorgWorkerCount": "tidb_ddl_reorg_worker_count",
+		"TiDBGCLifetime":          "tidb_gc_life_time",
+	}
+
+	tests := []struct {
+		raw          string
+		want         string
+		wantResolved bool
+	}{
+		{`"tidb_enable_paging"`, "tidb_enable_paging", true},
+		{"`tidb_txn_mode`", "tidb_txn_mode", true},
+		{"tidb_analyze_version", "tidb_analyze_version", true},
+		{"vardef.TiDBDDLReorgWorkerCount", "tidb_ddl_reorg_worker_count", true},
+		{"variable.TiDBGCLifetime", "tidb_gc_life_time", true},
+		{"TiDBGCLifetime", "tidb_gc_life_time", true},
+		{"vardef.TiDBEnableAsyncMergeGlobalStats", "tidb_enable_async_merge_global_stats", false},
+	}
+	for _, tt := range tests {
+		name, resolved := convertVarNameToUserVisible(tt.raw, consts)
+		assert.Equal(t, tt.want, name, tt.raw)
+		assert.Equal(t, tt.wantResolved, resolved, tt.raw)
+	}
+}
+
+func TestCollectUpgradeLogicFromSource_UnresolvedNames(t *testing.T) {
+	repoRoot := t.TempDir()
+	writeFile := func(path, content string) {
+		fullPath := filepath.Join(repoRoot, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(fullPath), 0755))
+		require.NoError(t, os.WriteFile(fullPath, []byte(content), 0644))
+	}
+
+	writeFile("pkg/sessionctx/vardef/tidb_vars.go", `package vardef
+
+const (
+	TiDBDDLReorgWorkerCount = "tidb_ddl_reorg_worker_count"
+)
+`)
+	writeFile("pkg/session/upgrade.go", `package session
+
+func upgradeToVer180(s sessiontypes.Session, ver int64) {
+	initGlobalVariableIfNotExists(s, vardef.TiDBDDLReorgWorkerCount, 4)
+	initGlobalVariableIfNotExists(s, vardef.TiDBEnableAsyncMergeGlobalStats, vardef.On)
+	mustExecute(s, "SET @@GLOBAL tidb_enable_paging = 1")
+}
+`)
+
+	snapshot, err := CollectUpgradeLogicFromSource(repoRoot)
+	require.NoError(t, err)
+
+	names := make([]string, 0, len(snapshot.Changes))
+	for _, change := range snapshot.Changes {
+		names = append(names, change.Name)
+	}
+	assert.Equal(t, []string{"tidb_ddl_reorg_worker_count", "tidb_enable_async_merge_global_stats", "tidb_enable_paging"}, names)
+
+	require.Len(t, snapshot.UnresolvedNames, 1)
+	unresolved := snapshot.UnresolvedNames[0]
+	assert.Equal(t, "vardef.TiDBEnableAsyncMergeGlobalStats", unresolved.Constant)
+	assert.Equal(t, "tidb_enable_async_merge_global_stats", unresolved.Fallback)
+	assert.Equal(t, "upgradeToVer180", unresolved.FuncName)
+	assert.Equal(t, 5, unresolved.Line)
+}
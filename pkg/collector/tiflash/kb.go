@@ -25,13 +25,14 @@ import (
 // 1. Collects TiFlash configuration from tiflash.toml file (default values)
 // 2. Collects TiFlash runtime configuration via SHOW CONFIG WHERE type='tiflash' (runtime values)
 // 3. Merges them with priority: runtime values > file values
+// 4. Drops duplicate keys (see common.ResolveKeyCollisions), reported in KBSnapshot.KeyCollisions
 // System variables are collected by TiDB collector and do not need to be collected separately here.
 func Collect(tiflashRoot, version string, tidbPort int, tag string) (*types.KBSnapshot, error) {
 	fmt.Printf("Collecting TiFlash runtime configuration from playground...\n")
 
 	// Step 1: Collect default values from tiflash.toml file
 	fmt.Printf("Collecting TiFlash default configuration from tiflash.toml...\n")
-	fileConfig := make(types.ConfigDefaults)
+	fileConfig := make(types.ParameterMap)
 	configFromFile, err := collectTiFlashConfigFromFile(tag)
 	if err != nil {
 		fmt.Printf("Warning: failed to collect from tiflash.toml: %v\n", err)
@@ -47,7 +48,7 @@ func Collect(tiflashRoot, version string, tidbPort int, tag string) (*types.KBSn
 	runtimeConfig, err := collectTiFlashConfigViaSHOWCONFIGWithRetry(tidbPort, tag)
 	if err != nil {
 		fmt.Printf("Warning: failed to collect via SHOW CONFIG: %v\n", err)
-		runtimeConfig = make(types.ConfigDefaults)
+		runtimeConfig = make(types.ParameterMap)
 	} else {
 		fmt.Printf("Collected %d runtime parameters via SHOW CONFIG\n", len(runtimeConfig))
 	}
@@ -58,28 +59,55 @@ func Collect(tiflashRoot, version string, tidbPort int, tag string) (*types.KBSn
 	fmt.Printf("Merged configuration: %d total parameters (file: %d, runtime: %d)\n",
 		len(mergedConfig), len(fileConfig), len(runtimeConfig))
 
+	// Step 4: Drop duplicate keys produced by both the prefixed and the plain name of a parameter
+	collisions := common.ResolveKeyCollisions(mergedConfig)
+	fmt.Print(common.FormatKeyCollisionReport("tiflash", collisions))
+
 	snapshot := &types.KBSnapshot{
 		Component:        types.ComponentTiFlash,
 		Version:          version,
 		ConfigDefaults:   mergedConfig,
-		SystemVariables:  make(types.SystemVariables), // Empty - system variables are collected by TiDB collector
+		SystemVariables:  make(types.ParameterMap), // Empty - system variables are collected by TiDB collector
 		BootstrapVersion: 0,
+		KeyCollisions:    collisions,
 	}
 
 	return snapshot, nil
 }
 
+// CollectFromCluster collects TiFlash knowledge base from a running cluster without a playground (runtime-only generation)
+// The configuration of the TiFlash instance at tiflashAddr is read via SHOW CONFIG through the TiDB at tidbAddr
+// The cluster must run version with the default configuration, since its runtime values are saved as defaults
+func CollectFromCluster(version, tidbAddr, tidbUser, tidbPassword, tiflashAddr string) (*types.KBSnapshot, error) {
+	fmt.Printf("Collecting TiFlash runtime configuration of %s via SHOW CONFIG...\n", tiflashAddr)
+	states, err := NewTiFlashCollector().CollectWithTiDB([]string{tiflashAddr}, tidbAddr, tidbUser, tidbPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect TiFlash config via SHOW CONFIG: %w", err)
+	}
+	if len(states) == 0 || len(states[0].Config) == 0 {
+		return nil, fmt.Errorf("no TiFlash configuration collected from %s", tiflashAddr)
+	}
+	config := states[0].Config
+
+	collisions := common.ResolveKeyCollisions(config)
+	fmt.Print(common.FormatKeyCollisionReport("tiflash", collisions))
+
+	return &types.KBSnapshot{
+		Component:        types.ComponentTiFlash,
+		Version:          version,
+		ConfigDefaults:   config,
+		SystemVariables:  make(types.ParameterMap), // Empty - system variables are collected by TiDB collector
+		BootstrapVersion: 0,
+		KeyCollisions:    collisions,
+	}, nil
+}
+
 // findTiFlashConfigPath finds TiFlash config file path from playground tag
 // TiFlash config file is typically at ~/.tiup/data/{tag}/tiflash-{port}/tiflash.toml
 func findTiFlashConfigPath(tag string) (string, error) {
-	// Get TIUP_HOME from environment or use default
-	tiupHome := os.Getenv("TIUP_HOME")
-	if tiupHome == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get user home directory: %w", err)
-		}
-		tiupHome = filepath.Join(homeDir, ".tiup")
+	tiupHome, err := common.TiUPHome()
+	if err != nil {
+		return "", err
 	}
 
 	// Try to find TiFlash config directory
@@ -111,7 +139,7 @@ func findTiFlashConfigPath(tag string) (string, error) {
 }
 
 // collectTiFlashConfigFromFile collects TiFlash configuration from tiflash.toml file (default values)
-func collectTiFlashConfigFromFile(tag string) (types.ConfigDefaults, error) {
+func collectTiFlashConfigFromFile(tag string) (types.ParameterMap, error) {
 	// Find TiFlash config file path
 	configPath, err := findTiFlashConfigPath(tag)
 	if err != nil {
@@ -134,7 +162,7 @@ func collectTiFlashConfigFromFile(tag string) (types.ConfigDefaults, error) {
 	flattened := flattenConfig(config, "")
 
 	// Convert to knowledge base format
-	kbConfig := make(types.ConfigDefaults)
+	kbConfig := make(types.ParameterMap)
 	for k, v := range flattened {
 		kbConfig[k] = types.ParameterValue{
 			Value: v,
@@ -156,7 +184,7 @@ const (
 // with retry mechanism to wait for TiFlash to be registered in the cluster.
 // Uses runtime collector's method for consistency.
 // This function retries until TiFlash is registered (returns non-zero rows) or timeout.
-func collectTiFlashConfigViaSHOWCONFIGWithRetry(tidbPort int, tag string) (types.ConfigDefaults, error) {
+func collectTiFlashConfigViaSHOWCONFIGWithRetry(tidbPort int, tag string) (types.ParameterMap, error) {
 	deadline := time.Now().Add(tiflashRegistrationTimeout)
 	attempt := 0
 
@@ -204,7 +232,7 @@ func collectTiFlashConfigViaSHOWCONFIGWithRetry(tidbPort int, tag string) (types
 
 // collectTiFlashConfigViaSHOWCONFIG collects TiFlash config via SHOW CONFIG WHERE type='tiflash' AND instance='ip:port'
 // Uses runtime collector's method for consistency
-func collectTiFlashConfigViaSHOWCONFIG(tidbPort int, tag string) (types.ConfigDefaults, error) {
+func collectTiFlashConfigViaSHOWCONFIG(tidbPort int, tag string) (types.ParameterMap, error) {
 	// Find TiFlash instance address from playground directory
 	tiflashAddr, err := common.FindPlaygroundInstanceAddr("tiflash", tag)
 	if err != nil {
@@ -233,20 +261,8 @@ func collectTiFlashConfigViaSHOWCONFIG(tidbPort int, tag string) (types.ConfigDe
 
 // mergeConfigsWithPriority merges file config and runtime config with priority
 // Priority: runtime values > file values
-func mergeConfigsWithPriority(fileConfig, runtimeConfig types.ConfigDefaults) types.ConfigDefaults {
-	merged := make(types.ConfigDefaults)
-
-	// Step 1: Start with file values (lower priority)
-	for k, v := range fileConfig {
-		merged[k] = v
-	}
-
-	// Step 2: Override with runtime values (higher priority)
-	for k, v := range runtimeConfig {
-		merged[k] = v
-	}
-
-	return merged
+func mergeConfigsWithPriority(fileConfig, runtimeConfig types.ParameterMap) types.ParameterMap {
+	return fileConfig.Merge(runtimeConfig)
 }
 
 // flattenConfig flattens a nested map structure using dot notation
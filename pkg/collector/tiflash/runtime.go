@@ -1,13 +1,17 @@
 package tiflash
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
+	"github.com/pelletier/go-toml/v2"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 )
@@ -19,6 +23,13 @@ type TiFlashCollector interface {
 	// This collects from both HTTP API and SHOW CONFIG, then merges them for the most complete configuration
 	// If tidbAddr is empty, only collects from HTTP API (for knowledge base generation)
 	CollectWithTiDB(addrs []string, tidbAddr, tidbUser, tidbPassword string) ([]types.ComponentState, error)
+	// CollectWithTiDBProgress is CollectWithTiDB calling onCollected with each instance as soon as it is collected
+	// (e.g., to checkpoint the collection), addr being its address in addrs
+	CollectWithTiDBProgress(addrs []string, tidbAddr, tidbUser, tidbPassword string,
+		onCollected func(addr string, state types.ComponentState)) ([]types.ComponentState, error)
+	// CollectConfig reads the configuration of an instance from its HTTP API /config endpoint
+	// (the TiFlash status port, or the proxy status port), served as JSON or TOML depending on the version
+	CollectConfig(ctx context.Context, addr string) (types.ParameterMap, error)
 }
 
 type tiflashCollector struct {
@@ -40,6 +51,13 @@ func NewTiFlashCollector() TiFlashCollector {
 // 2. Collects runtime configuration via SHOW CONFIG WHERE type='tiflash' AND instance='ip:port' for each instance (if TiDB connection available)
 // 3. Merges them with priority: runtime values > HTTP API values
 func (c *tiflashCollector) CollectWithTiDB(addrs []string, tidbAddr, tidbUser, tidbPassword string) ([]types.ComponentState, error) {
+	return c.CollectWithTiDBProgress(addrs, tidbAddr, tidbUser, tidbPassword, nil)
+}
+
+// CollectWithTiDBProgress gathers configuration from TiFlash instances like CollectWithTiDB, calling onCollected
+// (if not nil) with each instance as soon as it is collected
+func (c *tiflashCollector) CollectWithTiDBProgress(addrs []string, tidbAddr, tidbUser, tidbPassword string,
+	onCollected func(addr string, state types.ComponentState)) ([]types.ComponentState, error) {
 	var states []types.ComponentState
 
 	for _, addr := range addrs {
@@ -50,6 +68,9 @@ func (c *tiflashCollector) CollectWithTiDB(addrs []string, tidbAddr, tidbUser, t
 			continue
 		}
 		states = append(states, *state)
+		if onCollected != nil {
+			onCollected(addr, *state)
+		}
 	}
 
 	return states, nil
@@ -58,8 +79,8 @@ func (c *tiflashCollector) CollectWithTiDB(addrs []string, tidbAddr, tidbUser, t
 func (c *tiflashCollector) collectFromInstance(addr string, tidbAddr, tidbUser, tidbPassword string) (*types.ComponentState, error) {
 	state := &types.ComponentState{
 		Type:      types.ComponentTiFlash,
-		Config:    make(types.ConfigDefaults),
-		Variables: make(types.SystemVariables),
+		Config:    make(types.ParameterMap),
+		Variables: make(types.ParameterMap),
 		Status:    make(map[string]interface{}),
 	}
 
@@ -76,25 +97,24 @@ func (c *tiflashCollector) collectFromInstance(addr string, tidbAddr, tidbUser,
 
 	// Step 1: Collect configuration from HTTP API /config endpoint
 	// This provides the current runtime configuration
-	httpConfig := make(types.ConfigDefaults)
-	config, err := c.getConfig(addr)
+	httpConfig, err := c.CollectConfig(context.Background(), addr)
 	if err != nil {
 		fmt.Printf("Warning: failed to get TiFlash config from HTTP API for %s: %v\n", addr, err)
+		httpConfig = make(types.ParameterMap)
 	} else {
-		httpConfig = types.ConvertConfigToDefaults(config)
 		fmt.Printf("Collected %d parameters from HTTP API for %s\n", len(httpConfig), addr)
 	}
 
 	// Step 2: Collect runtime configuration via SHOW CONFIG WHERE type='tiflash' AND instance='ip:port' for this specific instance
 	// This ensures we get all parameters (including optional ones) for each instance
-	var tiflashConfigFromSHOW types.ConfigDefaults
+	var tiflashConfigFromSHOW types.ParameterMap
 	if tidbAddr != "" {
 		var err error
 		tiflashConfigFromSHOW, err = c.collectTiFlashConfigViaSHOWCONFIGForInstance(tidbAddr, tidbUser, tidbPassword, addr)
 		if err != nil {
 			fmt.Printf("Warning: failed to collect TiFlash config via SHOW CONFIG for instance %s: %v\n", addr, err)
 			// Continue without SHOW CONFIG data for this instance
-			tiflashConfigFromSHOW = make(types.ConfigDefaults)
+			tiflashConfigFromSHOW = make(types.ParameterMap)
 		} else {
 			fmt.Printf("Collected %d runtime parameters via SHOW CONFIG for instance %s\n", len(tiflashConfigFromSHOW), addr)
 		}
@@ -140,9 +160,13 @@ func (c *tiflashCollector) getVersion(addr string) (string, error) {
 	return status.Version, nil
 }
 
-func (c *tiflashCollector) getConfig(addr string) (map[string]interface{}, error) {
-	// TiFlash typically exposes config via /config endpoint
-	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/config", addr))
+// CollectConfig reads the configuration of an instance from its HTTP API /config endpoint
+func (c *tiflashCollector) CollectConfig(ctx context.Context, addr string) (types.ParameterMap, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("http://%s/config", addr), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -151,12 +175,32 @@ func (c *tiflashCollector) getConfig(addr string) (map[string]interface{}, error
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
 	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config from %s: %w", addr, err)
+	}
 
-	var config map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
-		return nil, err
+	config, err := parseConfig(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config from %s: %w", addr, err)
 	}
+	// Flattened like the knowledge base and SHOW CONFIG (e.g., "flash.compact_log_min_rows")
+	return types.ConvertConfigToDefaults(flattenConfig(config, "")), nil
+}
 
+// parseConfig parses the body of the /config endpoint
+// The proxy status port serves the configuration as JSON, the TiFlash status port of some versions as TOML
+func parseConfig(body []byte) (map[string]interface{}, error) {
+	var config map[string]interface{}
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		if err := json.Unmarshal(trimmed, &config); err != nil {
+			return nil, fmt.Errorf("invalid JSON: %w", err)
+		}
+		return config, nil
+	}
+	if err := toml.Unmarshal(body, &config); err != nil {
+		return nil, fmt.Errorf("invalid TOML: %w", err)
+	}
 	return config, nil
 }
 
@@ -183,7 +227,7 @@ func (c *tiflashCollector) getStatus(addr string) (map[string]interface{}, error
 // collectTiFlashConfigViaSHOWCONFIGForInstance collects TiFlash config via SHOW CONFIG WHERE type='tiflash' AND instance='ip:port'
 // This gets the full parameter set for a specific TiFlash instance
 // instance should be in format "IP:port" (e.g., "192.168.1.101:9000")
-func (c *tiflashCollector) collectTiFlashConfigViaSHOWCONFIGForInstance(tidbAddr, tidbUser, tidbPassword, instance string) (types.ConfigDefaults, error) {
+func (c *tiflashCollector) collectTiFlashConfigViaSHOWCONFIGForInstance(tidbAddr, tidbUser, tidbPassword, instance string) (types.ParameterMap, error) {
 	// Build DSN for TiDB connection
 	dsn := fmt.Sprintf("%s:%s@tcp(%s)/", tidbUser, tidbPassword, tidbAddr)
 	if tidbUser == "" {
@@ -204,25 +248,13 @@ func (c *tiflashCollector) collectTiFlashConfigViaSHOWCONFIGForInstance(tidbAddr
 		return nil, fmt.Errorf("failed to get TiFlash config via SHOW CONFIG for instance %s: %w", instance, err)
 	}
 
-	// Convert map[string]interface{} to types.ConfigDefaults
+	// Convert map[string]interface{} to types.ParameterMap
 	return types.ConvertConfigToDefaults(config), nil
 }
 
 // mergeConfigsWithPriority merges HTTP API config and SHOW CONFIG with priority
 // Priority: SHOW CONFIG values > HTTP API values
 // This matches the knowledge base generation approach
-func (c *tiflashCollector) mergeConfigsWithPriority(httpConfig, runtimeConfig types.ConfigDefaults) types.ConfigDefaults {
-	merged := make(types.ConfigDefaults)
-
-	// Step 1: Start with HTTP API values (lower priority)
-	for k, v := range httpConfig {
-		merged[k] = v
-	}
-
-	// Step 2: Override with runtime values from SHOW CONFIG (higher priority)
-	for k, v := range runtimeConfig {
-		merged[k] = v
-	}
-
-	return merged
+func (c *tiflashCollector) mergeConfigsWithPriority(httpConfig, runtimeConfig types.ParameterMap) types.ParameterMap {
+	return httpConfig.Merge(runtimeConfig)
 }
@@ -0,0 +1,77 @@
+package tiflash
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConfigServer starts a TiFlash status API answering /config with body and /status with version
+func newConfigServer(t *testing.T, body, version string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config":
+			w.Write([]byte(body))
+		case "/status":
+			w.Write([]byte(`{"version":"` + version + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+func TestCollectConfig_TOML(t *testing.T) {
+	addr := newConfigServer(t, `
+mark_cache_size = 1073741824
+
+[flash]
+compact_log_min_rows = 40960
+
+[profiles.default]
+max_memory_usage = 0
+`, "v7.5.0")
+
+	config, err := NewTiFlashCollector().CollectConfig(context.Background(), addr)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1073741824), config["mark_cache_size"].Value)
+	assert.Equal(t, int64(40960), config["flash.compact_log_min_rows"].Value)
+	assert.Contains(t, config, "profiles.default.max_memory_usage")
+}
+
+func TestCollectConfig_JSON(t *testing.T) {
+	addr := newConfigServer(t, `{"raftstore": {"snap-handle-pool-size": 2}, "log-level": "info"}`, "v7.5.0")
+
+	config, err := NewTiFlashCollector().CollectConfig(context.Background(), addr)
+	require.NoError(t, err)
+	assert.Equal(t, types.ParameterValue{Value: "info", Type: "string"}, config["log-level"])
+	assert.Contains(t, config, "raftstore.snap-handle-pool-size")
+}
+
+func TestCollectConfig_Errors(t *testing.T) {
+	_, err := NewTiFlashCollector().CollectConfig(context.Background(), newConfigServer(t, "[flash\nbroken", "v7.5.0"))
+	assert.ErrorContains(t, err, "invalid TOML")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = NewTiFlashCollector().CollectConfig(ctx, newConfigServer(t, "{}", "v7.5.0"))
+	assert.Error(t, err)
+}
+
+func TestCollectWithTiDB_HTTPOnly(t *testing.T) {
+	addr := newConfigServer(t, "[flash]\ncompact_log_min_rows = 40960\n", "v7.5.0")
+
+	states, err := NewTiFlashCollector().CollectWithTiDB([]string{addr}, "", "", "")
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Equal(t, types.ComponentTiFlash, states[0].Type)
+	assert.Equal(t, "v7.5.0", states[0].Version)
+	assert.Contains(t, states[0].Config, "flash.compact_log_min_rows")
+}
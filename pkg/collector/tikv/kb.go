@@ -28,12 +28,13 @@ const (
 // 1. Collects user-set configuration from last_tikv.toml (user-set values)
 // 2. Collects runtime configuration via SHOW CONFIG WHERE type='tikv' (runtime values)
 // 3. Merges them with priority: runtime values > user-set values
+// 4. Drops duplicate keys (see common.ResolveKeyCollisions), reported in KBSnapshot.KeyCollisions
 func Collect(tikvRoot, version string, tidbPort int, tag string) (*types.KBSnapshot, error) {
 	fmt.Printf("Collecting TiKV runtime configuration from playground...\n")
 
 	// Step 1: Collect user-set values from last_tikv.toml
 	fmt.Printf("Collecting TiKV user-set configuration from last_tikv.toml...\n")
-	userConfig := make(types.ConfigDefaults)
+	userConfig := make(types.ParameterMap)
 	tikvDataDir, err := findTiKVDataDir(tag)
 	if err == nil {
 		configFromFile, err := collectTiKVConfigFromFile(tikvDataDir)
@@ -53,7 +54,7 @@ func Collect(tikvRoot, version string, tidbPort int, tag string) (*types.KBSnaps
 	runtimeConfig, err := collectTiKVConfigViaSHOWCONFIG(tidbPort, tag)
 	if err != nil {
 		fmt.Printf("Warning: failed to collect via SHOW CONFIG: %v\n", err)
-		runtimeConfig = make(types.ConfigDefaults)
+		runtimeConfig = make(types.ParameterMap)
 	} else {
 		fmt.Printf("Collected %d runtime parameters via SHOW CONFIG\n", len(runtimeConfig))
 	}
@@ -64,27 +65,53 @@ func Collect(tikvRoot, version string, tidbPort int, tag string) (*types.KBSnaps
 	fmt.Printf("Merged configuration: %d total parameters (user-set: %d, runtime: %d)\n",
 		len(mergedConfig), len(userConfig), len(runtimeConfig))
 
+	// Step 4: Drop duplicate keys produced by both the prefixed and the plain name of a parameter
+	collisions := common.ResolveKeyCollisions(mergedConfig)
+	fmt.Print(common.FormatKeyCollisionReport("tikv", collisions))
+
 	snapshot := &types.KBSnapshot{
 		Component:        types.ComponentTiKV,
 		Version:          version,
 		ConfigDefaults:   mergedConfig,
 		BootstrapVersion: 0, // TiKV doesn't have explicit bootstrap version
+		KeyCollisions:    collisions,
 	}
 
 	return snapshot, nil
 }
 
+// CollectFromCluster collects TiKV knowledge base from a running cluster without a playground (runtime-only generation)
+// The configuration of the TiKV instance at tikvAddr is read via SHOW CONFIG through the TiDB at tidbAddr
+// The cluster must run version with the default configuration, since its runtime values are saved as defaults
+func CollectFromCluster(version, tidbAddr, tidbUser, tidbPassword, tikvAddr string) (*types.KBSnapshot, error) {
+	fmt.Printf("Collecting TiKV runtime configuration of %s via SHOW CONFIG...\n", tikvAddr)
+	states, err := NewTiKVCollector().CollectWithTiDB([]string{tikvAddr}, map[string]string{}, tidbAddr, tidbUser, tidbPassword)
+	if err != nil {
+		return nil, fmt.Errorf("failed to collect TiKV config via SHOW CONFIG: %w", err)
+	}
+	if len(states) == 0 || len(states[0].Config) == 0 {
+		return nil, fmt.Errorf("no TiKV configuration collected from %s", tikvAddr)
+	}
+	config := states[0].Config
+
+	collisions := common.ResolveKeyCollisions(config)
+	fmt.Print(common.FormatKeyCollisionReport("tikv", collisions))
+
+	return &types.KBSnapshot{
+		Component:        types.ComponentTiKV,
+		Version:          version,
+		ConfigDefaults:   config,
+		BootstrapVersion: 0, // TiKV doesn't have explicit bootstrap version
+		KeyCollisions:    collisions,
+	}, nil
+}
+
 // findTiKVDataDir finds TiKV data directory from playground tag
 // TiKV data directory is typically at ~/.tiup/data/{tag}/tikv-{port}/data
 func findTiKVDataDir(tag string) (string, error) {
-	// Get TIUP_HOME from environment or use default
-	tiupHome := os.Getenv("TIUP_HOME")
-	if tiupHome == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return "", fmt.Errorf("failed to get user home directory: %w", err)
-		}
-		tiupHome = filepath.Join(homeDir, ".tiup")
+	tiupHome, err := common.TiUPHome()
+	if err != nil {
+		return "", err
 	}
 
 	// Try to find TiKV data directory
@@ -112,20 +139,20 @@ func findTiKVDataDir(tag string) (string, error) {
 
 // collectTiKVConfigFromFile reads configuration from last_tikv.toml file (user-set values)
 // Uses runtime collector for consistency with real cluster collection
-func collectTiKVConfigFromFile(dataDir string) (types.ConfigDefaults, error) {
+func collectTiKVConfigFromFile(dataDir string) (types.ParameterMap, error) {
 	collector := NewTiKVCollector()
 	// Use CollectWithTiDB with empty TiDB connection to only collect from last_tikv.toml
 	states, err := collector.CollectWithTiDB([]string{"dummy"}, map[string]string{"dummy": dataDir}, "", "", "")
 	if err != nil || len(states) == 0 {
 		return nil, fmt.Errorf("failed to collect TiKV config from last_tikv.toml: %w", err)
 	}
-	// ComponentState.Config is already types.ConfigDefaults, no conversion needed
+	// ComponentState.Config is already types.ParameterMap, no conversion needed
 	return states[0].Config, nil
 }
 
 // collectTiKVConfigViaSHOWCONFIG collects TiKV config via SHOW CONFIG WHERE type='tikv' AND instance='ip:port'
 // Uses runtime collector's method for consistency
-func collectTiKVConfigViaSHOWCONFIG(tidbPort int, tag string) (types.ConfigDefaults, error) {
+func collectTiKVConfigViaSHOWCONFIG(tidbPort int, tag string) (types.ParameterMap, error) {
 	// Find TiKV instance address from playground directory
 	tikvAddr, err := common.FindPlaygroundInstanceAddr("tikv", tag)
 	if err != nil {
@@ -160,18 +187,6 @@ func collectTiKVConfigViaSHOWCONFIG(tidbPort int, tag string) (types.ConfigDefau
 
 // mergeConfigsWithPriority merges user-set and runtime configs with priority
 // Priority: runtime values > user-set values
-func mergeConfigsWithPriority(userConfig, runtimeConfig types.ConfigDefaults) types.ConfigDefaults {
-	merged := make(types.ConfigDefaults)
-
-	// Step 1: Start with user-set values (lower priority)
-	for k, v := range userConfig {
-		merged[k] = v
-	}
-
-	// Step 2: Override with runtime values (higher priority)
-	for k, v := range runtimeConfig {
-		merged[k] = v
-	}
-
-	return merged
+func mergeConfigsWithPriority(userConfig, runtimeConfig types.ParameterMap) types.ParameterMap {
+	return userConfig.Merge(runtimeConfig)
 }
@@ -0,0 +1,135 @@
+package tikv
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// Status keys and values describing how the state of a TiKV node was collected
+const (
+	// StatusKeyCollectedVia is set on the nodes whose config was not read from their own status API
+	StatusKeyCollectedVia = "collected_via"
+	// StatusKeyInstance is the INSTANCE (gRPC address, ip:port) the node is identified by in information_schema
+	StatusKeyInstance = "instance"
+	// CollectedViaTiDBProxy marks nodes whose effective config was read through TiDB's information_schema.cluster_config
+	// The data is refreshed by TiDB from the TiKV nodes and lacks node-local fields (resources, last_tikv.toml)
+	CollectedViaTiDBProxy = "tidb-proxy"
+)
+
+// proxyNode is a TiKV node as reported by TiDB's information_schema.cluster_info
+type proxyNode struct {
+	instance      string
+	statusAddress string
+	version       string
+}
+
+// collectViaTiDBProxy builds the states of the TiKV nodes at addrs from TiDB's information_schema
+// It is the fallback for nodes whose status API can't be reached (e.g., only the gRPC port is open):
+// nodes are identified by the INSTANCE column, addrs are matched against STATUS_ADDRESS first, then INSTANCE
+// Returns the states keyed by the matched address; addresses without config rows are missing
+func collectViaTiDBProxy(ctx context.Context, db *sql.DB, addrs []string) (map[string]*types.ComponentState, error) {
+	configs, err := queryClusterConfig(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := queryClusterInfo(ctx, db)
+	if err != nil {
+		// Without cluster_info, addresses can only be matched against INSTANCE and versions are unknown
+		fmt.Printf("Warning: failed to read TiKV nodes from information_schema.cluster_info: %v\n", err)
+	}
+	for instance := range configs {
+		if _, ok := nodes[instance]; !ok {
+			nodes[instance] = proxyNode{instance: instance}
+		}
+	}
+
+	states := make(map[string]*types.ComponentState)
+	for _, addr := range addrs {
+		node, ok := matchProxyNode(nodes, addr)
+		if !ok {
+			continue
+		}
+		config, ok := configs[node.instance]
+		if !ok {
+			continue
+		}
+		states[addr] = &types.ComponentState{
+			Type:      types.ComponentTiKV,
+			Version:   node.version,
+			Config:    types.ConvertConfigToDefaults(config),
+			Variables: make(types.ParameterMap),
+			Status: map[string]interface{}{
+				"address":             addr,
+				StatusKeyInstance:     node.instance,
+				StatusKeyCollectedVia: CollectedViaTiDBProxy,
+			},
+		}
+	}
+	return states, nil
+}
+
+// matchProxyNode finds the node of a TiKV address, matched against STATUS_ADDRESS, then INSTANCE
+// Instances are checked in sorted order so that the match doesn't depend on map iteration
+func matchProxyNode(nodes map[string]proxyNode, addr string) (proxyNode, bool) {
+	instances := make([]string, 0, len(nodes))
+	for instance := range nodes {
+		instances = append(instances, instance)
+	}
+	sort.Strings(instances)
+	for _, instance := range instances {
+		if nodes[instance].statusAddress == addr {
+			return nodes[instance], true
+		}
+	}
+	node, ok := nodes[addr]
+	return node, ok
+}
+
+// queryClusterConfig reads the effective config of every TiKV node, keyed by INSTANCE
+func queryClusterConfig(ctx context.Context, db *sql.DB) (map[string]map[string]interface{}, error) {
+	rows, err := db.QueryContext(ctx, "SELECT `INSTANCE`, `KEY`, `VALUE` FROM information_schema.cluster_config WHERE `TYPE` = 'tikv'")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query information_schema.cluster_config: %w", err)
+	}
+	defer rows.Close()
+
+	configs := make(map[string]map[string]interface{})
+	for rows.Next() {
+		var instance, key, value string
+		if err := rows.Scan(&instance, &key, &value); err != nil {
+			return nil, fmt.Errorf("failed to scan cluster_config row: %w", err)
+		}
+		if configs[instance] == nil {
+			configs[instance] = make(map[string]interface{})
+		}
+		configs[instance][key] = tidb.ParseConfigValue(value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating cluster_config rows: %w", err)
+	}
+	return configs, nil
+}
+
+// queryClusterInfo reads the TiKV nodes known to TiDB, keyed by INSTANCE
+func queryClusterInfo(ctx context.Context, db *sql.DB) (map[string]proxyNode, error) {
+	nodes := make(map[string]proxyNode)
+	rows, err := db.QueryContext(ctx, "SELECT `INSTANCE`, `STATUS_ADDRESS`, `VERSION` FROM information_schema.cluster_info WHERE `TYPE` = 'tikv'")
+	if err != nil {
+		return nodes, fmt.Errorf("failed to query information_schema.cluster_info: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var node proxyNode
+		if err := rows.Scan(&node.instance, &node.statusAddress, &node.version); err != nil {
+			return nodes, fmt.Errorf("failed to scan cluster_info row: %w", err)
+		}
+		nodes[node.instance] = node
+	}
+	return nodes, rows.Err()
+}
@@ -0,0 +1,107 @@
+package tikv
+
+import (
+	"database/sql"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newStatusServer starts a TiKV status API answering /status with version
+func newStatusServer(t *testing.T, version string) string {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/status":
+			w.Write([]byte(`{"version":"` + version + `"}`))
+		case "/config":
+			w.Write([]byte(`{}`))
+		default:
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return strings.TrimPrefix(server.URL, "http://")
+}
+
+// unreachableAddr returns the address of a closed port, as a firewalled status API
+func unreachableAddr(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+	return addr
+}
+
+func TestCollectWithTiDB_ProxyFallback(t *testing.T) {
+	httpAddr := newStatusServer(t, "6.5.0")
+	firewalledAddr := unreachableAddr(t)
+	unknownAddr := unreachableAddr(t)
+
+	// Every connection to TiDB gets a fresh mock answering the statements of both paths
+	collector := NewTiKVCollector().(*tikvCollector)
+	collector.openDB = func(dsn string) (*sql.DB, error) {
+		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		if err != nil {
+			return nil, err
+		}
+		mock.MatchExpectationsInOrder(false)
+		mock.ExpectQuery("SHOW CONFIG WHERE type='tikv' AND instance='" + httpAddr + "'").
+			WillReturnRows(sqlmock.NewRows([]string{"Type", "Instance", "Name", "Value"}).
+				AddRow("tikv", httpAddr, "storage.reserve-space", "2GB"))
+		mock.ExpectQuery("SELECT `INSTANCE`, `KEY`, `VALUE` FROM information_schema.cluster_config WHERE `TYPE` = 'tikv'").
+			WillReturnRows(sqlmock.NewRows([]string{"INSTANCE", "KEY", "VALUE"}).
+				AddRow("10.0.0.2:20160", "storage.reserve-space", "4GB").
+				AddRow("10.0.0.2:20160", "raftstore.store-pool-size", "2").
+				AddRow("10.0.0.1:20160", "storage.reserve-space", "2GB"))
+		mock.ExpectQuery("SELECT `INSTANCE`, `STATUS_ADDRESS`, `VERSION` FROM information_schema.cluster_info WHERE `TYPE` = 'tikv'").
+			WillReturnRows(sqlmock.NewRows([]string{"INSTANCE", "STATUS_ADDRESS", "VERSION"}).
+				AddRow("10.0.0.1:20160", httpAddr, "6.5.0").
+				AddRow("10.0.0.2:20160", firewalledAddr, "6.5.0"))
+		return db, nil
+	}
+
+	states, err := collector.CollectWithTiDB([]string{httpAddr, firewalledAddr, unknownAddr}, nil, "127.0.0.1:4000", "root", "")
+	require.NoError(t, err)
+	// The node missing from information_schema can't be collected at all
+	require.Len(t, states, 2)
+
+	// The reachable node is collected over HTTP and SHOW CONFIG
+	assert.Equal(t, httpAddr, states[0].Status["address"])
+	assert.Nil(t, states[0].Status[StatusKeyCollectedVia])
+	assert.Equal(t, "6.5.0", states[0].Version)
+	assert.Equal(t, "2GB", states[0].Config["storage.reserve-space"].Value)
+
+	// The firewalled node is identified by the INSTANCE whose STATUS_ADDRESS matches, and marked as proxied
+	proxied := states[1]
+	assert.Equal(t, types.ComponentTiKV, proxied.Type)
+	assert.Equal(t, firewalledAddr, proxied.Status["address"])
+	assert.Equal(t, "10.0.0.2:20160", proxied.Status[StatusKeyInstance])
+	assert.Equal(t, CollectedViaTiDBProxy, proxied.Status[StatusKeyCollectedVia])
+	assert.Equal(t, "6.5.0", proxied.Version)
+	assert.Equal(t, "4GB", proxied.Config["storage.reserve-space"].Value)
+	assert.Contains(t, proxied.Config, "raftstore.store-pool-size")
+}
+
+func TestCollectViaTiDBProxy_WithoutClusterInfo(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	require.NoError(t, err)
+	defer db.Close()
+	mock.ExpectQuery("SELECT `INSTANCE`, `KEY`, `VALUE` FROM information_schema.cluster_config WHERE `TYPE` = 'tikv'").
+		WillReturnRows(sqlmock.NewRows([]string{"INSTANCE", "KEY", "VALUE"}).AddRow("10.0.0.2:20160", "storage.reserve-space", "4GB"))
+	mock.ExpectQuery("SELECT `INSTANCE`, `STATUS_ADDRESS`, `VERSION` FROM information_schema.cluster_info WHERE `TYPE` = 'tikv'").
+		WillReturnError(sql.ErrConnDone)
+
+	// Without cluster_info, addresses are matched against INSTANCE only
+	states, err := collectViaTiDBProxy(t.Context(), db, []string{"10.0.0.2:20160", "10.0.0.2:20180"})
+	require.NoError(t, err)
+	require.Len(t, states, 1)
+	assert.Equal(t, "10.0.0.2:20160", states["10.0.0.2:20160"].Status[StatusKeyInstance])
+	assert.Empty(t, states["10.0.0.2:20160"].Version)
+}
@@ -1,18 +1,28 @@
 package tikv
 
 import (
+	"bufio"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/pelletier/go-toml/v2"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/tracing"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // TiKVCollector handles collection of TiKV configuration
@@ -22,17 +32,36 @@ type TiKVCollector interface {
 	// This collects from both last_tikv.toml and SHOW CONFIG, then merges them for the most complete configuration
 	// If tidbAddr is empty, only collects from last_tikv.toml (for knowledge base generation)
 	CollectWithTiDB(addrs []string, dataDirs map[string]string, tidbAddr, tidbUser, tidbPassword string) ([]types.ComponentState, error)
+	// CollectWithTiDBProgress is CollectWithTiDB calling onCollected with each instance as soon as it is collected
+	// (e.g., to checkpoint the collection), addr being its address in addrs
+	// onCollected may be called concurrently from several goroutines. The instances are traced as child spans of ctx
+	CollectWithTiDBProgress(ctx context.Context, addrs []string, dataDirs map[string]string, tidbAddr, tidbUser, tidbPassword string,
+		onCollected func(addr string, state types.ComponentState)) ([]types.ComponentState, error)
 }
 
 type tikvCollector struct {
 	httpClient *http.Client
+	throttle   *common.Throttle
+	// openDB opens the connection to TiDB (sql.Open by default, replaced in tests)
+	openDB func(dsn string) (*sql.DB, error)
 }
 
+// errStatusAPIUnreachable is returned for a TiKV instance whose status API can't be reached
+var errStatusAPIUnreachable = errors.New("status API unreachable")
+
 // NewTiKVCollector creates a new TiKV collector
 func NewTiKVCollector() TiKVCollector {
+	return NewTiKVCollectorWithThrottle(nil)
+}
+
+// NewTiKVCollectorWithThrottle creates a TiKV collector whose HTTP requests and concurrently collected
+// instances are limited by throttle (nil means no limit)
+func NewTiKVCollectorWithThrottle(throttle *common.Throttle) TiKVCollector {
 	return &tikvCollector{
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+		httpClient: common.NewThrottledHTTPClient(30*time.Second, throttle),
+		throttle:   throttle,
+		openDB: func(dsn string) (*sql.DB, error) {
+			return sql.Open("mysql", dsn)
 		},
 	}
 }
@@ -43,28 +72,101 @@ func NewTiKVCollector() TiKVCollector {
 // 2. Collects runtime configuration via SHOW CONFIG WHERE type='tikv' AND instance='ip:port' for each instance (if TiDB connection available)
 // 3. Merges them with priority: runtime values > user-set values
 // dataDirs maps TiKV address to its data_dir path (from topology file)
+// Instances are collected concurrently, as many at a time as the throttle allows; states keep the order of addrs
+// Instances whose status API can't be reached (e.g., a firewall only opens the gRPC port) are collected
+// through TiDB's information_schema.cluster_config instead, see collectViaTiDBProxy
 func (c *tikvCollector) CollectWithTiDB(addrs []string, dataDirs map[string]string, tidbAddr, tidbUser, tidbPassword string) ([]types.ComponentState, error) {
-	var states []types.ComponentState
+	return c.CollectWithTiDBProgress(context.Background(), addrs, dataDirs, tidbAddr, tidbUser, tidbPassword, nil)
+}
 
-	for _, addr := range addrs {
-		dataDir := dataDirs[addr]
-		state, err := c.collectFromInstance(addr, dataDir, tidbAddr, tidbUser, tidbPassword)
+// CollectWithTiDBProgress gathers configuration from TiKV instances like CollectWithTiDB, calling onCollected
+// (if not nil) with each instance as soon as it is collected
+func (c *tikvCollector) CollectWithTiDBProgress(ctx context.Context, addrs []string, dataDirs map[string]string, tidbAddr, tidbUser, tidbPassword string,
+	onCollected func(addr string, state types.ComponentState)) ([]types.ComponentState, error) {
+	results := make([]*types.ComponentState, len(addrs))
+	unreachable := make([]bool, len(addrs))
+	var wg sync.WaitGroup
+	for i, addr := range addrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.throttle.Acquire()
+			defer c.throttle.Release()
+
+			_, span := tracing.StartSpan(ctx, "collector.tikv.instance", attribute.String("address", addr))
+			state, err := c.collectFromInstance(addr, dataDirs[addr], tidbAddr, tidbUser, tidbPassword)
+			tracing.EndSpan(span, err)
+			if errors.Is(err, errStatusAPIUnreachable) {
+				unreachable[i] = true
+				return
+			}
+			if err != nil {
+				// Log error but continue with other instances
+				fmt.Printf("Warning: failed to collect from TiKV instance %s: %v\n", addr, err)
+				return
+			}
+			state.StartupArgs = common.StartupArgsFromConfig(state.Config, StartupFlagConfigKeys)
+			results[i] = state
+			if onCollected != nil {
+				onCollected(addr, *state)
+			}
+		}()
+	}
+	wg.Wait()
+
+	var proxyAddrs []string
+	for i, addr := range addrs {
+		if unreachable[i] {
+			proxyAddrs = append(proxyAddrs, addr)
+		}
+	}
+	if len(proxyAddrs) > 0 {
+		proxied, err := c.collectProxiedInstances(proxyAddrs, tidbAddr, tidbUser, tidbPassword)
 		if err != nil {
-			// Log error but continue with other instances
-			fmt.Printf("Warning: failed to collect from TiKV instance %s: %v\n", addr, err)
-			continue
+			fmt.Printf("Warning: failed to collect TiKV config through TiDB: %v\n", err)
+		}
+		for i, addr := range addrs {
+			if !unreachable[i] {
+				continue
+			}
+			if state, ok := proxied[addr]; ok {
+				fmt.Printf("Collected %d parameters for TiKV instance %s through TiDB (instance %s, status API unreachable)\n", len(state.Config), addr, state.Status[StatusKeyInstance])
+				state.StartupArgs = common.StartupArgsFromConfig(state.Config, StartupFlagConfigKeys)
+				results[i] = state
+				if onCollected != nil {
+					onCollected(addr, *state)
+				}
+			} else {
+				fmt.Printf("Warning: failed to collect from TiKV instance %s: status API unreachable and not found in information_schema.cluster_config\n", addr)
+			}
 		}
-		states = append(states, *state)
 	}
 
+	var states []types.ComponentState
+	for _, state := range results {
+		if state != nil {
+			states = append(states, *state)
+		}
+	}
 	return states, nil
 }
 
+// StartupFlagConfigKeys maps the command-line flags of TiKV to the config keys they set
+// TiKV doesn't serve its command line, its flags are read back from the collected configuration
+var StartupFlagConfigKeys = map[string]string{
+	"data-dir":              "storage.data-dir",
+	"addr":                  "server.addr",
+	"advertise-addr":        "server.advertise-addr",
+	"status-addr":           "server.status-addr",
+	"advertise-status-addr": "server.advertise-status-addr",
+	"pd":                    "pd.endpoints",
+}
+
 func (c *tikvCollector) collectFromInstance(addr string, dataDir string, tidbAddr, tidbUser, tidbPassword string) (*types.ComponentState, error) {
 	state := &types.ComponentState{
 		Type:      types.ComponentTiKV,
-		Config:    make(types.ConfigDefaults),
-		Variables: make(types.SystemVariables),
+		Config:    make(types.ParameterMap),
+		Variables: make(types.ParameterMap),
 		Status:    make(map[string]interface{}),
 	}
 
@@ -73,15 +175,34 @@ func (c *tikvCollector) collectFromInstance(addr string, dataDir string, tidbAdd
 
 	// Get version (still use HTTP API for version, as it's lightweight)
 	version, err := c.getVersion(addr)
+	var urlErr *url.Error
+	if err != nil && tidbAddr != "" && errors.As(err, &urlErr) {
+		// The status API can't be reached at all, the instance is collected through TiDB instead
+		return nil, fmt.Errorf("%w: %v", errStatusAPIUnreachable, err)
+	}
 	if err != nil {
 		// If we can't get version, we still try to get config
 		fmt.Printf("Warning: failed to get TiKV version from %s: %v\n", addr, err)
 	}
 	state.Version = version
 
+	// Get node resources (CPU cores, memory) from the status API
+	// They are used to sanity-check parameters whose defaults are derived from host resources
+	cpuCores, memoryBytes, err := c.getResources(addr)
+	if err != nil {
+		fmt.Printf("Warning: failed to get TiKV resources from %s: %v\n", addr, err)
+	} else {
+		if cpuCores > 0 {
+			state.Status["cpu_cores"] = cpuCores
+		}
+		if memoryBytes > 0 {
+			state.Status["memory_bytes"] = memoryBytes
+		}
+	}
+
 	// Step 1: Collect user-set values from last_tikv.toml
 	// This file contains the actual runtime configuration used by TiKV, including all user modifications
-	userConfig := make(types.ConfigDefaults)
+	userConfig := make(types.ParameterMap)
 	if dataDir != "" {
 		config, err := c.getConfigFromFile(dataDir)
 		if err != nil {
@@ -94,14 +215,14 @@ func (c *tikvCollector) collectFromInstance(addr string, dataDir string, tidbAdd
 
 	// Step 2: Collect runtime configuration via SHOW CONFIG WHERE type='tikv' AND instance='ip:port' for this specific instance
 	// This ensures we get all parameters (including optional ones like backup.*) for each instance
-	var tikvConfigFromSHOW types.ConfigDefaults
+	var tikvConfigFromSHOW types.ParameterMap
 	if tidbAddr != "" {
 		var err error
 		tikvConfigFromSHOW, err = c.collectTiKVConfigViaSHOWCONFIGForInstance(tidbAddr, tidbUser, tidbPassword, addr)
 		if err != nil {
 			fmt.Printf("Warning: failed to collect TiKV config via SHOW CONFIG for instance %s: %v\n", addr, err)
 			// Continue without SHOW CONFIG data for this instance
-			tikvConfigFromSHOW = make(types.ConfigDefaults)
+			tikvConfigFromSHOW = make(types.ParameterMap)
 		} else {
 			fmt.Printf("Collected %d runtime parameters via SHOW CONFIG for instance %s\n", len(tikvConfigFromSHOW), addr)
 		}
@@ -112,9 +233,86 @@ func (c *tikvCollector) collectFromInstance(addr string, dataDir string, tidbAdd
 	// This matches the knowledge base generation approach
 	state.Config = c.mergeConfigsWithPriority(userConfig, tikvConfigFromSHOW)
 
+	// Step 4: Make sure the storage format settings are collected from every node
+	// last_tikv.toml only contains user-set values, so they are missing if SHOW CONFIG is unavailable
+	if err := c.fillStorageFormatConfig(addr, state.Config); err != nil {
+		fmt.Printf("Warning: failed to collect storage format settings from TiKV %s: %v\n", addr, err)
+	}
+
 	return state, nil
 }
 
+// storageFormatConfigKeys are the config keys that control on-disk storage formats
+// (RocksDB format versions, Raft Engine, Titan, encryption at rest)
+// They are needed from every TiKV node to warn about upgrades that change the format irreversibly
+var storageFormatConfigKeys = []string{
+	"rocksdb.defaultcf.format-version",
+	"rocksdb.writecf.format-version",
+	"rocksdb.lockcf.format-version",
+	"rocksdb.raftcf.format-version",
+	"raftdb.defaultcf.format-version",
+	"raft-engine.enable",
+	"raft-engine.enable-log-recycle",
+	"raft-engine.format-version",
+	"rocksdb.titan.enabled",
+	"security.encryption.data-encryption-method",
+	"security.encryption.master-key.type",
+}
+
+// fillStorageFormatConfig adds the storage format keys missing from config, read from the TiKV /config status API
+func (c *tikvCollector) fillStorageFormatConfig(addr string, config types.ParameterMap) error {
+	var missing []string
+	for _, key := range storageFormatConfigKeys {
+		if _, ok := config[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/config", addr))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+
+	var fullConfig map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&fullConfig); err != nil {
+		return err
+	}
+
+	for _, key := range missing {
+		if value, ok := lookupNestedConfig(fullConfig, key); ok {
+			config[key] = types.ConvertConfigToDefaults(map[string]interface{}{key: value})[key]
+		}
+	}
+	return nil
+}
+
+// lookupNestedConfig looks up a dotted key (e.g., "rocksdb.titan.enabled") in a nested config map
+func lookupNestedConfig(config map[string]interface{}, key string) (interface{}, bool) {
+	parts := strings.Split(key, ".")
+	current := config
+	for i, part := range parts {
+		value, ok := current[part]
+		if !ok {
+			return nil, false
+		}
+		if i == len(parts)-1 {
+			return value, true
+		}
+		if current, ok = value.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
+}
+
 func (c *tikvCollector) getVersion(addr string) (string, error) {
 	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/status", addr))
 	if err != nil {
@@ -137,6 +335,55 @@ func (c *tikvCollector) getVersion(addr string) (string, error) {
 	return status.Version, nil
 }
 
+// Metrics exposed by TiKV status API that describe the resources available to the instance
+const (
+	metricCPUCoresQuota    = "tikv_server_cpu_cores_quota"
+	metricMemoryQuotaBytes = "tikv_server_memory_quota_bytes"
+)
+
+// getResources reads CPU cores and memory quota of a TiKV instance from its /metrics endpoint
+// Returns 0 for a resource whose metric is not exposed by this TiKV version
+func (c *tikvCollector) getResources(addr string) (float64, float64, error) {
+	resp, err := c.httpClient.Get(fmt.Sprintf("http://%s/metrics", addr))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("HTTP request failed with status: %d", resp.StatusCode)
+	}
+
+	var cpuCores, memoryBytes float64
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case metricCPUCoresQuota:
+			cpuCores = value
+		case metricMemoryQuotaBytes:
+			memoryBytes = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+
+	return cpuCores, memoryBytes, nil
+}
+
 // getConfigFromFile reads configuration from last_tikv.toml file
 // This file contains the actual runtime configuration used by TiKV, including all user modifications
 // The dataDir is provided from topology file (e.g., topology.yaml)
@@ -167,14 +414,8 @@ func (c *tikvCollector) getConfigFromFile(dataDir string) (map[string]interface{
 // collectTiKVConfigViaSHOWCONFIGForInstance collects TiKV config via SHOW CONFIG WHERE type='tikv' AND instance='ip:port'
 // This gets the full parameter set for a specific TiKV instance
 // instance should be in format "IP:port" (e.g., "192.168.1.101:20160")
-func (c *tikvCollector) collectTiKVConfigViaSHOWCONFIGForInstance(tidbAddr, tidbUser, tidbPassword, instance string) (types.ConfigDefaults, error) {
-	// Build DSN for TiDB connection
-	dsn := fmt.Sprintf("%s:%s@tcp(%s)/", tidbUser, tidbPassword, tidbAddr)
-	if tidbUser == "" {
-		dsn = fmt.Sprintf("root@tcp(%s)/", tidbAddr)
-	}
-
-	db, err := sql.Open("mysql", dsn)
+func (c *tikvCollector) collectTiKVConfigViaSHOWCONFIGForInstance(tidbAddr, tidbUser, tidbPassword, instance string) (types.ParameterMap, error) {
+	db, err := c.openDB(tidbDSN(tidbAddr, tidbUser, tidbPassword))
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -188,25 +429,34 @@ func (c *tikvCollector) collectTiKVConfigViaSHOWCONFIGForInstance(tidbAddr, tidb
 		return nil, fmt.Errorf("failed to get TiKV config via SHOW CONFIG for instance %s: %w", instance, err)
 	}
 
-	// Convert map[string]interface{} to types.ConfigDefaults
+	// Convert map[string]interface{} to types.ParameterMap
 	return types.ConvertConfigToDefaults(config), nil
 }
 
-// mergeConfigsWithPriority merges user-set and runtime configs with priority
-// Priority: runtime values (from SHOW CONFIG) > user-set values (from last_tikv.toml)
-// This matches the knowledge base generation approach
-func (c *tikvCollector) mergeConfigsWithPriority(userConfig, runtimeConfig types.ConfigDefaults) types.ConfigDefaults {
-	merged := make(types.ConfigDefaults)
-
-	// Step 1: Start with user-set values (lower priority)
-	for k, v := range userConfig {
-		merged[k] = v
+// collectProxiedInstances collects the TiKV instances at addrs through TiDB's information_schema (see collectViaTiDBProxy)
+func (c *tikvCollector) collectProxiedInstances(addrs []string, tidbAddr, tidbUser, tidbPassword string) (map[string]*types.ComponentState, error) {
+	db, err := c.openDB(tidbDSN(tidbAddr, tidbUser, tidbPassword))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
+	defer db.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), tidb.DefaultSQLTimeout)
+	defer cancel()
+	return collectViaTiDBProxy(ctx, db, addrs)
+}
 
-	// Step 2: Override with runtime values (higher priority)
-	for k, v := range runtimeConfig {
-		merged[k] = v
+// tidbDSN builds the DSN of the TiDB connection (root without password if tidbUser is empty)
+func tidbDSN(tidbAddr, tidbUser, tidbPassword string) string {
+	if tidbUser == "" {
+		return fmt.Sprintf("root@tcp(%s)/", tidbAddr)
 	}
+	return fmt.Sprintf("%s:%s@tcp(%s)/", tidbUser, tidbPassword, tidbAddr)
+}
 
-	return merged
+// mergeConfigsWithPriority merges user-set and runtime configs with priority
+// Priority: runtime values (from SHOW CONFIG) > user-set values (from last_tikv.toml)
+// This matches the knowledge base generation approach
+func (c *tikvCollector) mergeConfigsWithPriority(userConfig, runtimeConfig types.ParameterMap) types.ParameterMap {
+	return userConfig.Merge(runtimeConfig)
 }
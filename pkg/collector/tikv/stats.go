@@ -0,0 +1,173 @@
+package tikv
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// NotSetValue is the ValueCounts key of the nodes a parameter is not set on
+const NotSetValue = "(not set)"
+
+// TiKVStats summarizes the values of a parameter across the TiKV nodes of a cluster
+type TiKVStats struct {
+	// ParamName is the parameter name, the fields of map parameters are flattened (e.g., "storage.block-cache.capacity")
+	ParamName string
+	// ValueCounts is the number of nodes of each value, keyed by the display of the value (NotSetValue for the nodes
+	// the parameter is not set on). Values that are equal for their type (e.g., sizes "1GiB" and "1024MiB") count as one
+	ValueCounts map[string]int
+	// MajorityValue is the value of most nodes, nil if most nodes don't set the parameter
+	// On a tie, the value of the first node in instance order wins
+	MajorityValue interface{}
+	// MajorityNodes are the names of the nodes that have MajorityValue, in instance order
+	MajorityNodes []string
+	// MajorityPercent is the percentage of the counted nodes that have MajorityValue
+	MajorityPercent float64
+	// OutlierCount is the number of counted nodes that don't have MajorityValue
+	OutlierCount int
+}
+
+// NodeCount returns the number of nodes counted for the parameter
+func (s TiKVStats) NodeCount() int {
+	count := 0
+	for _, n := range s.ValueCounts {
+		count += n
+	}
+	return count
+}
+
+// statsValueGroup is the group of nodes sharing a value of a parameter
+type statsValueGroup struct {
+	value   types.ParameterValue
+	missing bool
+	nodes   []string
+}
+
+// ComputeTiKVStats computes the statistics of every parameter set on any of the TiKV nodes (node name -> state)
+// A parameter missing from a node collected through TiDB (see CollectedViaTiDBProxy) is not counted, since such
+// nodes lack the node-local fields of last_tikv.toml
+func ComputeTiKVStats(nodes map[string]*types.ComponentState) map[string]TiKVStats {
+	names := SortNodeNames(nodes)
+	configs := make(map[string]types.ParameterMap, len(nodes))
+	params := make(map[string]bool)
+	for _, name := range names {
+		configs[name] = flattenConfig(nodes[name].Config)
+		for param := range configs[name] {
+			params[param] = true
+		}
+	}
+
+	stats := make(map[string]TiKVStats, len(params))
+	for param := range params {
+		var groups []*statsValueGroup
+		for _, name := range names {
+			value, ok := configs[name][param]
+			if !ok && nodes[name].Status[StatusKeyCollectedVia] == CollectedViaTiDBProxy {
+				continue
+			}
+			var group *statsValueGroup
+			for _, candidate := range groups {
+				if candidate.missing == !ok && (!ok || candidate.value.Equal(value)) {
+					group = candidate
+					break
+				}
+			}
+			if group == nil {
+				group = &statsValueGroup{value: value, missing: !ok}
+				groups = append(groups, group)
+			}
+			group.nodes = append(group.nodes, name)
+		}
+		if len(groups) == 0 {
+			continue
+		}
+
+		paramStats := TiKVStats{ParamName: param, ValueCounts: make(map[string]int)}
+		majority, counted := groups[0], 0
+		for _, group := range groups {
+			counted += len(group.nodes)
+			if len(group.nodes) > len(majority.nodes) {
+				majority = group
+			}
+			key := NotSetValue
+			if !group.missing {
+				key = formatStatsValue(group.value.Value)
+			}
+			paramStats.ValueCounts[key] += len(group.nodes)
+		}
+		paramStats.MajorityValue = majority.value.Value
+		paramStats.MajorityNodes = majority.nodes
+		paramStats.MajorityPercent = float64(len(majority.nodes)) * 100 / float64(counted)
+		paramStats.OutlierCount = counted - len(majority.nodes)
+		stats[param] = paramStats
+	}
+	return stats
+}
+
+// SortNodeNames returns the names of the TiKV nodes ordered by instance (see StatusKeyInstance, the status address
+// otherwise), then by name
+func SortNodeNames(nodes map[string]*types.ComponentState) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		instanceI, instanceJ := NodeInstance(names[i], nodes[names[i]]), NodeInstance(names[j], nodes[names[j]])
+		if instanceI != instanceJ {
+			return instanceI < instanceJ
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// NodeInstance returns the instance (IP:port) a TiKV node is identified by: the INSTANCE of information_schema for
+// nodes collected through TiDB, the status address otherwise, the node name if neither is known
+func NodeInstance(name string, state *types.ComponentState) string {
+	if instance, ok := state.Status[StatusKeyInstance].(string); ok && instance != "" {
+		return instance
+	}
+	if addr, ok := state.Status["address"].(string); ok {
+		return addr
+	}
+	return name
+}
+
+// flattenConfig flattens the map parameters of a config into dotted parameters of their leaf fields
+func flattenConfig(config types.ParameterMap) types.ParameterMap {
+	flat := make(types.ParameterMap, len(config))
+	for name, param := range config {
+		if section, ok := param.Value.(map[string]interface{}); ok {
+			flattenSection(flat, name, section)
+			continue
+		}
+		flat[name] = param
+	}
+	return flat
+}
+
+func flattenSection(flat types.ParameterMap, prefix string, section map[string]interface{}) {
+	for key, value := range section {
+		name := prefix + "." + key
+		if nested, ok := value.(map[string]interface{}); ok {
+			flattenSection(flat, name, nested)
+			continue
+		}
+		flat[name] = types.ParameterValue{Value: value}
+	}
+}
+
+// formatStatsValue returns the ValueCounts key of a value
+func formatStatsValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}, []string:
+		if data, err := json.Marshal(v); err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,91 @@
+package tikv
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeTiKVStats(t *testing.T) {
+	// 50 nodes: 48 share the config, 2 differ
+	nodes := make(map[string]*types.ComponentState)
+	for i := 0; i < 50; i++ {
+		capacity := "8GiB"
+		if i == 7 || i == 31 {
+			capacity = "4GiB"
+		}
+		nodes[fmt.Sprintf("tikv-%d", i)] = &types.ComponentState{
+			Type: types.ComponentTiKV,
+			Config: types.ParameterMap{
+				"storage": {Value: map[string]interface{}{
+					"block-cache": map[string]interface{}{"capacity": capacity},
+				}},
+				"raftstore.store-pool-size": {Value: 2, Type: "int"},
+			},
+			Status: map[string]interface{}{"address": fmt.Sprintf("10.0.0.%d:20180", i)},
+		}
+	}
+	// Equal values in another form are the same value
+	nodes["tikv-3"].Config["raftstore.store-pool-size"] = types.ParameterValue{Value: "2", Type: "int"}
+
+	var majorityNodes []string
+	for _, name := range SortNodeNames(nodes) {
+		if name != "tikv-7" && name != "tikv-31" {
+			majorityNodes = append(majorityNodes, name)
+		}
+	}
+
+	stats := ComputeTiKVStats(nodes)
+	require.Len(t, stats, 2)
+	assert.Equal(t, TiKVStats{
+		ParamName:       "storage.block-cache.capacity",
+		ValueCounts:     map[string]int{"8GiB": 48, "4GiB": 2},
+		MajorityValue:   "8GiB",
+		MajorityNodes:   majorityNodes,
+		MajorityPercent: 96,
+		OutlierCount:    2,
+	}, stats["storage.block-cache.capacity"])
+	assert.Equal(t, 50, stats["storage.block-cache.capacity"].NodeCount())
+	assert.Equal(t, 0, stats["raftstore.store-pool-size"].OutlierCount)
+	assert.Equal(t, map[string]int{"2": 50}, stats["raftstore.store-pool-size"].ValueCounts)
+}
+
+func TestComputeTiKVStats_MissingAndTies(t *testing.T) {
+	nodes := map[string]*types.ComponentState{
+		"tikv-b": {Config: types.ParameterMap{"log.level": {Value: "warn"}}, Status: map[string]interface{}{"address": "10.0.0.2:20180"}},
+		"tikv-a": {Config: types.ParameterMap{"log.level": {Value: "info"}}, Status: map[string]interface{}{"address": "10.0.0.1:20180"}},
+		"tikv-c": {Config: types.ParameterMap{}, Status: map[string]interface{}{"address": "10.0.0.3:20180"}},
+		// Nodes collected through TiDB lack the node-local fields: a missing parameter is not counted
+		"tikv-d": {Config: types.ParameterMap{}, Status: map[string]interface{}{
+			StatusKeyInstance:     "10.0.0.4:20160",
+			StatusKeyCollectedVia: CollectedViaTiDBProxy,
+		}},
+	}
+
+	stats := ComputeTiKVStats(nodes)["log.level"]
+	// On a tie, the value of the first node in instance order wins
+	assert.Equal(t, "info", stats.MajorityValue)
+	assert.Equal(t, []string{"tikv-a"}, stats.MajorityNodes)
+	assert.Equal(t, map[string]int{"info": 1, "warn": 1, NotSetValue: 1}, stats.ValueCounts)
+	assert.Equal(t, 2, stats.OutlierCount)
+	assert.InDelta(t, 33.3, stats.MajorityPercent, 0.1)
+
+	assert.Equal(t, []string{"tikv-a", "tikv-b", "tikv-c", "tikv-d"}, SortNodeNames(nodes))
+}
+
+func TestComputeTiKVStats_MissingMajority(t *testing.T) {
+	nodes := map[string]*types.ComponentState{
+		"tikv-a": {Config: types.ParameterMap{}, Status: map[string]interface{}{"address": "10.0.0.1:20180"}},
+		"tikv-b": {Config: types.ParameterMap{}, Status: map[string]interface{}{"address": "10.0.0.2:20180"}},
+		"tikv-c": {Config: types.ParameterMap{"log.file.filename": {Value: "", Type: "string"}}, Status: map[string]interface{}{"address": "10.0.0.3:20180"}},
+	}
+
+	stats := ComputeTiKVStats(nodes)["log.file.filename"]
+	// An empty value is not the same as a missing parameter
+	assert.Nil(t, stats.MajorityValue)
+	assert.Equal(t, []string{"tikv-a", "tikv-b"}, stats.MajorityNodes)
+	assert.Equal(t, 1, stats.OutlierCount)
+}
@@ -3,7 +3,8 @@ package collector
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"path"
+	"strconv"
 	"strings"
 
 	"gopkg.in/yaml.v3"
@@ -68,7 +69,9 @@ type Topology struct {
 		Host       string                 `yaml:"host"`
 		ClientPort int                    `yaml:"client_port"` // PD HTTP API port
 		PeerPort   int                    `yaml:"peer_port,omitempty"`
+		Name       string                 `yaml:"name,omitempty"` // PD member name (--name)
 		DeployDir  string                 `yaml:"deploy_dir,omitempty"`
+		DataDir    string                 `yaml:"data_dir,omitempty"`
 		Config     map[string]interface{} `yaml:"config,omitempty"`
 	} `yaml:"pd_servers,omitempty"`
 
@@ -82,12 +85,16 @@ type Topology struct {
 }
 
 // LoadTopologyFromFile loads a topology file and converts it to ClusterEndpoints
-// Supports TiUP topology YAML format
+// Supports TiUP topology YAML format, and TiDB Ansible inventories (detected by their [tidb_servers] group,
+// see LoadTopologyFromAnsibleInventory)
 func LoadTopologyFromFile(topologyPath string) (*ClusterEndpoints, error) {
 	data, err := os.ReadFile(topologyPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read topology file: %w", err)
 	}
+	if isAnsibleInventory(data) {
+		return loadAnsibleInventory(data)
+	}
 
 	var topo Topology
 	if err := yaml.Unmarshal(data, &topo); err != nil {
@@ -117,6 +124,9 @@ func LoadTopologyFromFile(topologyPath string) (*ClusterEndpoints, error) {
 		// Use the first TiDB instance
 		tidb := topo.TiDBServers[0]
 		endpoints.TiDBAddr = fmt.Sprintf("%s:%d", tidb.Host, tidb.Port)
+		if tidb.StatusPort > 0 {
+			endpoints.TiDBStatusAddr = fmt.Sprintf("%s:%d", tidb.Host, tidb.StatusPort)
+		}
 
 		// Extract user from global options if available
 		if topo.GlobalOptions.User != "" {
@@ -152,7 +162,8 @@ func LoadTopologyFromFile(topologyPath string) (*ClusterEndpoints, error) {
 		// If still empty, try to construct from deploy_dir (common pattern)
 		if dataDir == "" && tikv.DeployDir != "" {
 			// Common pattern: data_dir is deploy_dir/data or deploy_dir/tikv-{port}/data
-			dataDir = filepath.Join(tikv.DeployDir, "data")
+			// Topology directories are paths on the (Linux) cluster hosts, joined with "/" whatever the local OS
+			dataDir = path.Join(tikv.DeployDir, "data")
 		}
 
 		if dataDir != "" {
@@ -175,6 +186,8 @@ func LoadTopologyFromFile(topologyPath string) (*ClusterEndpoints, error) {
 		endpoints.TiFlashAddrs = append(endpoints.TiFlashAddrs, fmt.Sprintf("%s:%d", tiflash.Host, port))
 	}
 
+	endpoints.DeclaredStartupArgs = declaredStartupArgs(&topo, endpoints)
+
 	return endpoints, nil
 }
 
@@ -236,7 +249,8 @@ func LoadTopologyFromYAML(yamlContent string) (*ClusterEndpoints, error) {
 		// If still empty, try to construct from deploy_dir (common pattern)
 		if dataDir == "" && tikv.DeployDir != "" {
 			// Common pattern: data_dir is deploy_dir/data or deploy_dir/tikv-{port}/data
-			dataDir = filepath.Join(tikv.DeployDir, "data")
+			// Topology directories are paths on the (Linux) cluster hosts, joined with "/" whatever the local OS
+			dataDir = path.Join(tikv.DeployDir, "data")
 		}
 
 		if dataDir != "" {
@@ -258,9 +272,71 @@ func LoadTopologyFromYAML(yamlContent string) (*ClusterEndpoints, error) {
 		endpoints.TiFlashAddrs = append(endpoints.TiFlashAddrs, fmt.Sprintf("%s:%d", tiflash.Host, port))
 	}
 
+	endpoints.DeclaredStartupArgs = declaredStartupArgs(&topo, endpoints)
+
 	return endpoints, nil
 }
 
+// declaredStartupArgs returns the command-line flags TiUP starts the instances with, as declared by the topology,
+// keyed by the address each instance is collected from (see ClusterEndpoints.DeclaredStartupArgs)
+// Only the flags set from explicit topology fields are declared: values TiUP computes itself (e.g., the default
+// name of a PD member) can't be told from the topology alone
+func declaredStartupArgs(topo *Topology, endpoints *ClusterEndpoints) map[string]map[string]string {
+	declared := make(map[string]map[string]string)
+
+	if len(topo.TiDBServers) > 0 && topo.TiDBServers[0].Port > 0 {
+		tidb := topo.TiDBServers[0]
+		args := map[string]string{"P": strconv.Itoa(tidb.Port), "store": "tikv"}
+		if tidb.StatusPort > 0 {
+			args["status"] = strconv.Itoa(tidb.StatusPort)
+		}
+		if len(endpoints.PDAddrs) > 0 {
+			args["path"] = strings.Join(endpoints.PDAddrs, ",")
+		}
+		declared[endpoints.TiDBAddr] = args
+	}
+
+	for i, pd := range topo.PDServers {
+		args := make(map[string]string)
+		if pd.Name != "" {
+			args["name"] = pd.Name
+		}
+		if pd.DataDir != "" {
+			args["data-dir"] = instanceDir(pd.DeployDir, pd.DataDir)
+		}
+		if len(args) > 0 {
+			declared[endpoints.PDAddrs[i]] = args
+		}
+	}
+
+	for i, tikv := range topo.TiKVServers {
+		args := make(map[string]string)
+		if tikv.Port > 0 {
+			args["advertise-addr"] = fmt.Sprintf("%s:%d", tikv.Host, tikv.Port)
+		}
+		// The data directory guessed from deploy_dir is not declared, only an explicit data_dir
+		if tikv.DataDir != "" {
+			args["data-dir"] = instanceDir(tikv.DeployDir, tikv.DataDir)
+		}
+		if len(args) > 0 {
+			declared[endpoints.TiKVAddrs[i]] = args
+		}
+	}
+
+	if len(declared) == 0 {
+		return nil
+	}
+	return declared
+}
+
+// instanceDir resolves a directory of an instance: TiUP places relative directories under the deploy directory
+func instanceDir(deployDir, dir string) string {
+	if path.IsAbs(dir) || deployDir == "" {
+		return dir
+	}
+	return path.Join(deployDir, dir)
+}
+
 // ParseTopologyEndpointString parses a simple endpoint string format
 // Format: "tidb=host:port;tikv=host1:port1,host2:port2;pd=host1:port1,host2:port2"
 // This is a fallback format for simple integrations
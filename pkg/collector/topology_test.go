@@ -46,6 +46,22 @@ tiflash_servers:
 				assert.NotEmpty(t, endpoints.PDAddrs)
 				assert.NotEmpty(t, endpoints.TiKVAddrs)
 				assert.NotEmpty(t, endpoints.TiFlashAddrs)
+				// No status_port: the collector falls back to the default status port
+				assert.Empty(t, endpoints.TiDBStatusAddr)
+			},
+		},
+		{
+			name: "TiDB status port",
+			content: `
+tidb_servers:
+  - host: 10.0.0.1
+    port: 4000
+    status_port: 10081
+`,
+			wantErr: false,
+			validate: func(t *testing.T, endpoints *types.ClusterEndpoints) {
+				assert.Equal(t, "10.0.0.1:4000", endpoints.TiDBAddr)
+				assert.Equal(t, "10.0.0.1:10081", endpoints.TiDBStatusAddr)
 			},
 		},
 		{
@@ -61,19 +77,38 @@ tidb_servers:
 				assert.Equal(t, "v7.5.0", endpoints.SourceVersion)
 			},
 		},
+		{
+			name: "TiKV data directories",
+			content: `
+tikv_servers:
+  - host: 10.0.0.1
+    port: 20160
+    deploy_dir: /tidb-deploy/tikv-20160
+  - host: 10.0.0.2
+    port: 20160
+    data_dir: /tidb-data/tikv-20160
+`,
+			wantErr: false,
+			validate: func(t *testing.T, endpoints *types.ClusterEndpoints) {
+				// Paths of the cluster hosts keep their "/" separators on every OS
+				assert.Equal(t, "/tidb-deploy/tikv-20160/data", endpoints.TiKVDataDirs["10.0.0.1:20160"])
+				assert.Equal(t, "/tidb-data/tikv-20160", endpoints.TiKVDataDirs["10.0.0.2:20160"])
+			},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.content == "" {
 				// Test non-existent file
-				_, err := LoadTopologyFromFile("/non/existent/path")
+				_, err := LoadTopologyFromFile(filepath.Join(t.TempDir(), "missing.yaml"))
 				assert.Error(t, err)
 				return
 			}
 
-			// Create temporary file
-			tmpDir := t.TempDir()
+			// Create temporary file, in a directory with a space as on Windows and macOS user profiles
+			tmpDir := filepath.Join(t.TempDir(), "cluster configs")
+			require.NoError(t, os.MkdirAll(tmpDir, 0755))
 			topologyFile := filepath.Join(tmpDir, "topology.yaml")
 			err := os.WriteFile(topologyFile, []byte(tt.content), 0644)
 			require.NoError(t, err)
@@ -93,6 +128,42 @@ tidb_servers:
 	}
 }
 
+func TestLoadTopologyFromYAML_DeclaredStartupArgs(t *testing.T) {
+	endpoints, err := LoadTopologyFromYAML(`
+tidb_servers:
+  - host: 10.0.0.1
+    port: 4000
+    status_port: 10080
+pd_servers:
+  - host: 10.0.0.2
+    client_port: 2379
+    name: pd-1
+    deploy_dir: /deploy/pd-2379
+    data_dir: data
+  - host: 10.0.0.3
+    client_port: 2379
+tikv_servers:
+  - host: 10.0.0.4
+    port: 20160
+    status_port: 20180
+    data_dir: /data/tikv-20160/
+  - host: 10.0.0.5
+    port: 20160
+    deploy_dir: /deploy/tikv-20160
+`)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]map[string]string{
+		"10.0.0.1:4000": {"P": "4000", "status": "10080", "store": "tikv", "path": "10.0.0.2:2379,10.0.0.3:2379"},
+		// Relative directories are under the deploy directory
+		"10.0.0.2:2379":  {"name": "pd-1", "data-dir": "/deploy/pd-2379/data"},
+		"10.0.0.4:20180": {"advertise-addr": "10.0.0.4:20160", "data-dir": "/data/tikv-20160/"},
+		// The data directory guessed from deploy_dir is not declared
+		"10.0.0.5:20160": {"advertise-addr": "10.0.0.5:20160"},
+	}, endpoints.DeclaredStartupArgs)
+	assert.Equal(t, "/deploy/tikv-20160/data", endpoints.TiKVDataDirs["10.0.0.5:20160"])
+}
+
 func TestParseTopologyEndpointString(t *testing.T) {
 	tests := []struct {
 		name     string
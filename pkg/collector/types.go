@@ -27,16 +27,18 @@ type (
 	ClusterState     = defaultsTypes.ClusterState
 	ClusterSnapshot  = defaultsTypes.ClusterSnapshot
 	ClusterEndpoints = defaultsTypes.ClusterEndpoints
+	NodeVersion      = defaultsTypes.NodeVersion
+	ClusterInfo      = defaultsTypes.ClusterInfo
 )
 
-// ConvertConfigToDefaults converts a map[string]interface{} to pkg/types.ConfigDefaults
+// ConvertConfigToDefaults converts a map[string]interface{} to pkg/types.ParameterMap
 // This is used when collecting runtime configuration to maintain consistency with knowledge base format
-func ConvertConfigToDefaults(config map[string]interface{}) types.ConfigDefaults {
+func ConvertConfigToDefaults(config map[string]interface{}) types.ParameterMap {
 	return defaultsTypes.ConvertConfigToDefaults(config)
 }
 
-// ConvertVariablesToSystemVariables converts a map[string]string to pkg/types.SystemVariables
+// ConvertVariablesToSystemVariables converts a map[string]string to pkg/types.ParameterMap
 // This is used when collecting runtime system variables to maintain consistency with knowledge base format
-func ConvertVariablesToSystemVariables(variables map[string]string) types.SystemVariables {
+func ConvertVariablesToSystemVariables(variables map[string]string) types.ParameterMap {
 	return defaultsTypes.ConvertVariablesToSystemVariables(variables)
 }
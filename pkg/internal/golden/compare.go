@@ -0,0 +1,6 @@
+//go:build !update_golden
+
+package golden
+
+// Update makes Check rewrite the golden files instead of comparing against them
+const Update = false
@@ -0,0 +1,32 @@
+// Package golden compares the output of tests with golden files
+// To regenerate the golden files after an intended change, run the tests with the update_golden build tag
+// (e.g., go test -tags update_golden ./pkg/reporter/) and review the resulting diff
+package golden
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Check compares actual with the golden file at path, or rewrites the golden file if Update is set
+// regenerate is the command that regenerates the golden file, shown when it is missing or out of sync
+func Check(t *testing.T, path string, actual []byte, regenerate string) bool {
+	t.Helper()
+
+	if Update {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, actual, 0644))
+		t.Logf("updated golden file %s", path)
+		return true
+	}
+
+	expected, err := os.ReadFile(path)
+	require.NoError(t, err, "golden file %s missing, run: %s", path, regenerate)
+	// assert.Equal prints a unified diff for mismatching strings
+	return assert.Equal(t, string(expected), string(actual),
+		"%s is out of sync, run: %s if the change is intended", path, regenerate)
+}
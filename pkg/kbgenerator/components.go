@@ -0,0 +1,400 @@
+package kbgenerator
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
+	pdkb "github.com/pingcap/tidb-upgrade-precheck/pkg/collector/pd"
+	tidbkb "github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tidb"
+	tiflashkb "github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tiflash"
+	tikvkb "github.com/pingcap/tidb-upgrade-precheck/pkg/collector/tikv"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// generateFromSource generates the knowledge of a version that can be extracted from source code alone (--source source-only)
+// Only the TiDB bootstrap version can be extracted: it is merged into an existing defaults.json, so that defaults collected
+// earlier are kept. Configuration defaults and system variables need a running cluster (playground or runtime-only)
+func (g *generator) generateFromSource(version string) error {
+	for _, comp := range []string{"pd", "tikv", "tiflash"} {
+		if g.components[comp] {
+			log.Printf("Warning: %s defaults cannot be extracted from source code, skipping %s for %s (use --source %s or %s)\n",
+				comp, comp, version, SourcePlayground, SourceRuntimeOnly)
+		}
+	}
+	if !g.components["tidb"] {
+		return nil
+	}
+	if g.TiDBRepo == "" {
+		return fmt.Errorf("--tidb-repo is required with --source %s", SourceSourceOnly)
+	}
+
+	fmt.Printf("Extracting TiDB knowledge for version %s from source code...\n", version)
+	snapshot, err := tidbkb.CollectFromSource(g.TiDBRepo, version)
+	if err != nil {
+		return err
+	}
+
+	outputPath := g.defaultsPath(version, "tidb")
+	if data, err := os.ReadFile(outputPath); err == nil {
+		var existing collector.KBSnapshot
+		if err := json.Unmarshal(data, &existing); err != nil {
+			return fmt.Errorf("failed to parse %s: %w", outputPath, err)
+		}
+		existing.BootstrapVersion = snapshot.BootstrapVersion
+		snapshot = &existing
+		fmt.Printf("Updating bootstrap version of existing %s\n", outputPath)
+	} else {
+		log.Printf("Warning: %s has no configuration defaults or system variables, collect them with --source %s or %s\n",
+			outputPath, SourcePlayground, SourceRuntimeOnly)
+	}
+	if err := collector.SaveKBSnapshot(snapshot, outputPath); err != nil {
+		return fmt.Errorf("failed to save TiDB knowledge base: %w", err)
+	}
+	fmt.Printf("Saved TiDB knowledge for version %s to %s (bootstrap version %d)\n", version, outputPath, snapshot.BootstrapVersion)
+	return nil
+}
+
+// generateFromBinaries generates the knowledge base of a version from local binaries (--from-binaries)
+// A playground is started from the binaries and the defaults are collected from its runtime APIs,
+// as with --source runtime-only. No source code is read: upgrade_logic.json is not generated
+func (g *generator) generateFromBinaries(version string) error {
+	binDir := g.FromBinaries
+	binaries, err := common.FindPlaygroundBinaries(binDir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Note: upgrade logic is not extracted with --from-binaries, it needs the TiDB source code (use --source %s --tidb-repo)\n", SourceSourceOnly)
+	if _, ok := binaries["tiflash"]; !ok && g.components["tiflash"] {
+		log.Printf("Warning: no tiflash binary in %s, skipping TiFlash\n", binDir)
+	}
+
+	tag := fmt.Sprintf("kb-gen-%s-%d", version, time.Now().Unix())
+	fmt.Printf("Starting tiup playground from the binaries of %s (tag: %s)...\n", binDir, tag)
+	if err := common.StartPlaygroundFromBinaries(version, tag, binaries); err != nil {
+		return fmt.Errorf("failed to start playground cluster: %w", err)
+	}
+	defer func() {
+		if err := common.StopPlayground(tag); err != nil {
+			log.Printf("Warning: failed to stop playground cluster: %v\n", err)
+		}
+	}()
+	if err := common.WaitForClusterReady(tag, defaultTiDBPort); err != nil {
+		return fmt.Errorf("cluster failed to become ready: %w", err)
+	}
+
+	endpoints := ClusterEndpoints{
+		TiDBAddr: fmt.Sprintf("127.0.0.1:%d", defaultTiDBPort),
+		TiDBUser: "root",
+		PDAddr:   playgroundPDAddr(tag),
+	}
+	for _, comp := range []string{"tikv", "tiflash"} {
+		if _, ok := binaries[comp]; !ok || !g.components[comp] {
+			continue
+		}
+		addr, err := common.FindPlaygroundInstanceAddr(comp, tag)
+		if err != nil {
+			log.Printf("Warning: %v, skipping %s\n", err, comp)
+			continue
+		}
+		if comp == "tikv" {
+			endpoints.TiKVAddr = addr
+		} else {
+			endpoints.TiFlashAddr = addr
+		}
+	}
+	return g.generateFromCluster(version, endpoints)
+}
+
+// generateFromCluster generates the knowledge base of a version from a running cluster (--source runtime-only)
+// No playground is started and no source code is read: upgrade_logic.json is not generated,
+// and the TiDB bootstrap version is read from the cluster. The cluster must run with the default configuration
+// The defaults are saved with the runtime-only provenance, in the same format as the defaults of the other sources
+// If strict is true, ErrKeyConflicts is returned (after saving the files) when duplicate keys had different values
+func (g *generator) generateFromCluster(version string, endpoints ClusterEndpoints) error {
+	save := func(snapshot *collector.KBSnapshot, comp string) error {
+		snapshot.Provenance = types.ProvenanceRuntimeOnly
+		outputPath := g.defaultsPath(version, comp)
+		if err := collector.SaveKBSnapshot(snapshot, outputPath); err != nil {
+			return fmt.Errorf("failed to save %s knowledge base: %w", comp, err)
+		}
+		fmt.Printf("Saved %s knowledge for version %s to %s\n", comp, version, outputPath)
+		return nil
+	}
+
+	if g.components["tidb"] {
+		snapshot, err := tidbkb.CollectFromCluster(version, endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword)
+		if err != nil {
+			return fmt.Errorf("failed to collect TiDB knowledge: %w", err)
+		}
+		if err := save(snapshot, "tidb"); err != nil {
+			return err
+		}
+	}
+
+	if g.components["pd"] {
+		snapshot, err := pdkb.Collect("", version, endpoints.PDAddr)
+		if err != nil {
+			return fmt.Errorf("failed to collect PD knowledge: %w", err)
+		}
+		if err := save(snapshot, "pd"); err != nil {
+			return err
+		}
+	}
+
+	conflicts := 0
+	if g.components["tikv"] && endpoints.TiKVAddr != "" {
+		snapshot, err := tikvkb.CollectFromCluster(version, endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword, endpoints.TiKVAddr)
+		if err != nil {
+			log.Printf("Warning: failed to generate TiKV knowledge base: %v\n", err)
+		} else if err := save(snapshot, "tikv"); err != nil {
+			return err
+		} else {
+			conflicts += common.CountKeyConflicts(snapshot.KeyCollisions)
+		}
+	}
+
+	if g.components["tiflash"] && endpoints.TiFlashAddr != "" {
+		snapshot, err := tiflashkb.CollectFromCluster(version, endpoints.TiDBAddr, endpoints.TiDBUser, endpoints.TiDBPassword, endpoints.TiFlashAddr)
+		if err != nil {
+			log.Printf("Warning: failed to generate TiFlash knowledge base: %v\n", err)
+		} else if err := save(snapshot, "tiflash"); err != nil {
+			return err
+		} else {
+			conflicts += common.CountKeyConflicts(snapshot.KeyCollisions)
+		}
+	}
+
+	if g.Strict && conflicts > 0 {
+		return fmt.Errorf("%w: %d in %s", ErrKeyConflicts, conflicts, version)
+	}
+	return nil
+}
+
+// generateParameterHistory generates the parameter history of a component from the versions in the knowledge base
+// The history records each version where a default changed, with the old and new values
+func (g *generator) generateParameterHistory(component string) error {
+	// Deployment-specific parameters (paths, addresses) differ in every generation run, they are not default changes
+	var deploymentSpecific rules.DeploymentSpecificParams
+	if data, err := os.ReadFile(filepath.Join(g.KnowledgeDir, "deployment_specific.json")); err == nil {
+		if err := json.Unmarshal(data, &deploymentSpecific); err != nil {
+			return fmt.Errorf("failed to parse deployment_specific.json: %w", err)
+		}
+	}
+
+	history, err := collector.GenerateParameterHistory(g.KnowledgeDir, component, func(paramName string) bool {
+		return deploymentSpecific.Contains(component, paramName)
+	})
+	if err != nil {
+		return err
+	}
+	if err := collector.SaveParameterHistory(history, g.KnowledgeDir); err != nil {
+		return err
+	}
+	fmt.Printf("Saved %s parameter history (%d versions, %d parameters with default changes) to %s\n",
+		component, len(history.Versions), len(history.Parameters), collector.ParameterHistoryPath(g.KnowledgeDir, component))
+	return nil
+}
+
+// generateUpgradeHistory generates TiDB's upgrade history from the release tags of the repository,
+// or from the knowledge base if no repository is given
+func (g *generator) generateUpgradeHistory() error {
+	var history collector.UpgradeHistory
+	source := "knowledge base " + g.KnowledgeDir
+	if g.TiDBRepo != "" {
+		tags, err := tidbkb.ReleaseTags(g.TiDBRepo)
+		if err != nil {
+			return err
+		}
+		releases := make([]collector.ReleaseBootstrapVersion, 0, len(tags))
+		for _, tag := range tags {
+			releases = append(releases, collector.ReleaseBootstrapVersion{Version: tag, BootstrapVersion: tidbkb.BootstrapVersionAtTag(g.TiDBRepo, tag)})
+		}
+		history = collector.BuildUpgradeHistory(releases)
+		if len(history) == 0 {
+			return fmt.Errorf("no bootstrap version found at the %d release tags of %s", len(tags), g.TiDBRepo)
+		}
+		source = fmt.Sprintf("%d release tags of %s", len(tags), g.TiDBRepo)
+	} else {
+		var err error
+		if history, err = collector.UpgradeHistoryFromKB(g.KnowledgeDir); err != nil {
+			return err
+		}
+	}
+	if err := collector.SaveUpgradeHistory(history, g.KnowledgeDir); err != nil {
+		return err
+	}
+	fmt.Printf("Saved TiDB upgrade history (%d bootstrap versions, from %s) to %s\n", len(history), source, collector.UpgradeHistoryPath(g.KnowledgeDir))
+	return nil
+}
+
+// generateSingleVersionTiDB generates TiDB knowledge base
+func (g *generator) generateSingleVersionTiDB(version string, tag string) error {
+	snapshot, err := tidbkb.Collect(g.TiDBRepo, version, tag)
+	if err != nil {
+		return fmt.Errorf("failed to collect TiDB knowledge for version %s: %v", version, err)
+	}
+
+	outputPath := g.defaultsPath(version, "tidb")
+	if err := collector.SaveKBSnapshot(snapshot, outputPath); err != nil {
+		return fmt.Errorf("failed to save TiDB knowledge for version %s: %v", version, err)
+	}
+
+	fmt.Printf("Saved TiDB knowledge for version %s to %s\n", version, outputPath)
+
+	return nil
+}
+
+// generatePDUpgradeLogic generates upgrade_logic.json from PD source code
+// Like TiDB's, it is version-agnostic and should be extracted from the master branch
+func generatePDUpgradeLogic(pdRepoRoot, outputPath string) error {
+	fmt.Printf("Generating upgrade_logic.json (PD) from %s\n", pdRepoRoot)
+
+	upgradeLogic, err := pdkb.CollectPDUpgradeLogicFromSource(pdRepoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to collect PD upgrade logic: %w", err)
+	}
+	if err := collector.SavePDUpgradeLogic(upgradeLogic, outputPath); err != nil {
+		return fmt.Errorf("failed to save PD upgrade logic: %w", err)
+	}
+
+	fmt.Printf("✓ Successfully generated PD upgrade_logic.json with %d forced changes\n", len(upgradeLogic.Changes))
+	fmt.Printf("  Saved to: %s\n\n", outputPath)
+	return nil
+}
+
+// generateSingleVersionPD generates PD knowledge base from the PD instance at pdAddr
+func (g *generator) generateSingleVersionPD(version string, pdAddr string) error {
+	fmt.Printf("Generating PD knowledge base for version %s...\n", version)
+
+	// Collect from playground (using the same playground instance started by TiDB)
+	snapshot, err := pdkb.Collect(g.PDRepo, version, pdAddr)
+	if err != nil {
+		return fmt.Errorf("failed to collect PD knowledge for version %s: %v", version, err)
+	}
+
+	outputPath := g.defaultsPath(version, "pd")
+	if err := collector.SaveKBSnapshot(snapshot, outputPath); err != nil {
+		return fmt.Errorf("failed to save PD knowledge for version %s: %v", version, err)
+	}
+
+	fmt.Printf("Saved PD knowledge for version %s to %s\n", version, outputPath)
+
+	return nil
+}
+
+// generateSingleVersionTiKV generates TiKV knowledge base
+// If strict is true, ErrKeyConflicts is returned (after saving the file) when duplicate keys had different values
+func (g *generator) generateSingleVersionTiKV(version string, tag string) error {
+	fmt.Printf("Generating TiKV knowledge base for version %s...\n", version)
+
+	// Collect from playground (using the same playground instance started by TiDB)
+	snapshot, err := tikvkb.Collect(g.TiKVRepo, version, defaultTiDBPort, tag)
+	if err != nil {
+		return fmt.Errorf("failed to collect TiKV knowledge for version %s: %v", version, err)
+	}
+
+	outputPath := g.defaultsPath(version, "tikv")
+	if err := collector.SaveKBSnapshot(snapshot, outputPath); err != nil {
+		return fmt.Errorf("failed to save TiKV knowledge for version %s: %v", version, err)
+	}
+
+	fmt.Printf("Saved TiKV knowledge for version %s to %s\n", version, outputPath)
+
+	if conflicts := common.CountKeyConflicts(snapshot.KeyCollisions); g.Strict && conflicts > 0 {
+		return fmt.Errorf("%w: %d in tikv %s", ErrKeyConflicts, conflicts, version)
+	}
+	return nil
+}
+
+// generateSingleVersionTiFlash generates TiFlash knowledge base
+// If strict is true, ErrKeyConflicts is returned (after saving the file) when duplicate keys had different values
+func (g *generator) generateSingleVersionTiFlash(version string, tag string) error {
+	fmt.Printf("Generating TiFlash knowledge base for version %s...\n", version)
+
+	// Collect from playground (using the same playground instance started by TiDB)
+	snapshot, err := tiflashkb.Collect(g.TiFlashRepo, version, defaultTiDBPort, tag)
+	if err != nil {
+		return fmt.Errorf("failed to collect TiFlash knowledge for version %s: %v", version, err)
+	}
+
+	outputPath := g.defaultsPath(version, "tiflash")
+	if err := collector.SaveKBSnapshot(snapshot, outputPath); err != nil {
+		return fmt.Errorf("failed to save TiFlash knowledge for version %s: %v", version, err)
+	}
+
+	fmt.Printf("Saved TiFlash knowledge for version %s to %s\n", version, outputPath)
+
+	if conflicts := common.CountKeyConflicts(snapshot.KeyCollisions); g.Strict && conflicts > 0 {
+		return fmt.Errorf("%w: %d in tiflash %s", ErrKeyConflicts, conflicts, version)
+	}
+	return nil
+}
+
+// generateTiDBUpgradeLogic generates upgrade_logic.json from TiDB source code
+// This should be called once before processing versions, as upgrade_logic.json is version-agnostic
+// and contains all historical upgradeToVerXX functions from master branch
+// If strict is true, ErrUnresolvedVarNames is returned (after saving the file) when any variable name could not be resolved
+func generateTiDBUpgradeLogic(tidbRepoRoot, outputPath string, strict bool) error {
+	fmt.Printf("========================================\n")
+	fmt.Printf("Generating upgrade_logic.json (TiDB)\n")
+	fmt.Printf("========================================\n")
+	fmt.Printf("This file contains all historical upgrade logic and is generated once for all versions.\n")
+	fmt.Printf("IMPORTANT: Should be extracted from master branch to get all historical upgradeToVerXX functions.\n")
+	fmt.Printf("Repository: %s\n", tidbRepoRoot)
+	fmt.Printf("Output: %s\n", outputPath)
+	fmt.Printf("\n")
+
+	// Ensure output directory exists
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// Collect upgrade logic from source code
+	upgradeLogic, err := tidbkb.CollectUpgradeLogicFromSource(tidbRepoRoot)
+	if err != nil {
+		return fmt.Errorf("failed to collect TiDB upgrade logic: %w", err)
+	}
+
+	// Save upgrade logic
+	if err := collector.SaveUpgradeLogic(upgradeLogic, outputPath); err != nil {
+		return fmt.Errorf("failed to save TiDB upgrade logic: %w", err)
+	}
+
+	totalChanges := 0
+	if upgradeLogic != nil && upgradeLogic.Changes != nil {
+		totalChanges = len(upgradeLogic.Changes)
+	}
+
+	fmt.Printf("✓ Successfully generated upgrade_logic.json with %d total forced changes\n", totalChanges)
+	fmt.Printf("  Saved to: %s\n", outputPath)
+
+	// Changes grouped by bootstrap version, for tools looking up what a given upgrade function does
+	grouped := tidbkb.GroupByBootstrapVersion(upgradeLogic.Changes)
+	indexPath := filepath.Join(filepath.Dir(outputPath), tidbkb.BootstrapVersionIndexFile)
+	if err := tidbkb.SaveBootstrapVersionIndex(grouped, indexPath); err != nil {
+		return fmt.Errorf("failed to save TiDB bootstrap version index: %w", err)
+	}
+	fmt.Printf("✓ Indexed %d bootstrap versions in %s\n", len(grouped), indexPath)
+
+	// Unresolved variable names produce forced changes that never match the runtime or the knowledge base
+	if len(upgradeLogic.UnresolvedNames) > 0 {
+		fmt.Printf("\n⚠ %d variable name(s) could not be resolved, the following forced changes use a derived name:\n", len(upgradeLogic.UnresolvedNames))
+		for _, unresolved := range upgradeLogic.UnresolvedNames {
+			fmt.Printf("  - %s -> %s (%s, line %d)\n", unresolved.Constant, unresolved.Fallback, unresolved.FuncName, unresolved.Line)
+		}
+		fmt.Printf("  Check that the vardef/variable packages are present in the TiDB repository\n")
+	}
+	fmt.Printf("========================================\n\n")
+
+	if strict && len(upgradeLogic.UnresolvedNames) > 0 {
+		return fmt.Errorf("%w: %d name(s)", ErrUnresolvedVarNames, len(upgradeLogic.UnresolvedNames))
+	}
+	return nil
+}
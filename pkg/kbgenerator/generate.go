@@ -0,0 +1,416 @@
+// Package kbgenerator generates the knowledge base: per-version defaults, upgrade logic and parameter history
+// It is run by "precheck kb generate" and by the legacy kb-generator binary
+package kbgenerator
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector/common"
+)
+
+// Values of Options.Source
+const (
+	// SourcePlayground collects runtime defaults from a tiup playground and extracts the rest from source code
+	SourcePlayground = "playground"
+	// SourceSourceOnly extracts what is available from source code only (upgrade logic, TiDB bootstrap version)
+	SourceSourceOnly = "source-only"
+	// SourceRuntimeOnly collects defaults from an already running cluster, without source code
+	SourceRuntimeOnly = "runtime-only"
+)
+
+// DefaultKnowledgeDir is the knowledge base directory the files are generated in, relative to the working directory
+const DefaultKnowledgeDir = "knowledge"
+
+// AllComponents are the components generated by default
+var AllComponents = []string{"tidb", "pd", "tikv", "tiflash"}
+
+// ErrUnresolvedVarNames is returned in strict mode when variable names of the TiDB upgrade logic could not be resolved
+var ErrUnresolvedVarNames = errors.New("unresolved variable names in upgrade logic")
+
+// ErrKeyConflicts is returned in strict mode when TiKV/TiFlash defaults had duplicate keys with different values
+var ErrKeyConflicts = errors.New("duplicate keys with conflicting values in defaults")
+
+const (
+	defaultTiDBPort = 4000
+	defaultPDPort   = 2379
+)
+
+// ClusterEndpoints are the endpoints of the running cluster the defaults are collected from (SourceRuntimeOnly)
+type ClusterEndpoints struct {
+	TiDBAddr     string
+	TiDBUser     string
+	TiDBPassword string
+	PDAddr       string
+	// TiKVAddr and TiFlashAddr are the instances (host:port, as in SHOW CONFIG) to read the configuration of,
+	// the component is skipped if empty
+	TiKVAddr    string
+	TiFlashAddr string
+}
+
+// Options are the options of a knowledge base generation
+type Options struct {
+	// KnowledgeDir is the knowledge base directory (DefaultKnowledgeDir if empty)
+	KnowledgeDir string
+	// Repositories the code definitions and the upgrade logic are extracted from
+	TiDBRepo    string
+	PDRepo      string
+	TiKVRepo    string
+	TiFlashRepo string
+	// Version is the version to generate (single version mode)
+	Version string
+	// FromTag and ToTag are the two versions to generate (version range mode)
+	FromTag string
+	ToTag   string
+	// Components are the components to generate
+	Components []string
+	// Strict fails the generation on unresolved variable names in the upgrade logic and on conflicting duplicate keys
+	Strict bool
+	// Source is where the defaults are extracted from (SourcePlayground, SourceSourceOnly or SourceRuntimeOnly)
+	Source string
+	// Cluster is the cluster the defaults are collected from with SourceRuntimeOnly
+	Cluster ClusterEndpoints
+	// FromBinaries is a directory with the binaries of a custom build to start the playground from
+	FromBinaries string
+	// FailFast stops the generation of a version as soon as one component fails (SourcePlayground)
+	FailFast bool
+}
+
+// DefaultOptions returns the options with their default values
+func DefaultOptions() Options {
+	return Options{
+		KnowledgeDir: DefaultKnowledgeDir,
+		Components:   append([]string(nil), AllComponents...),
+		Source:       SourcePlayground,
+		Cluster: ClusterEndpoints{
+			TiDBAddr: fmt.Sprintf("127.0.0.1:%d", defaultTiDBPort),
+			TiDBUser: "root",
+			PDAddr:   fmt.Sprintf("127.0.0.1:%d", defaultPDPort),
+		},
+	}
+}
+
+// ParseComponents parses a comma-separated list of components
+func ParseComponents(list string) []string {
+	var components []string
+	for _, comp := range strings.Split(list, ",") {
+		if comp = strings.TrimSpace(comp); comp != "" {
+			components = append(components, comp)
+		}
+	}
+	return components
+}
+
+// Versions validates the version options and returns the versions to generate
+func (o Options) Versions() ([]string, error) {
+	if o.FromTag != "" || o.ToTag != "" {
+		if o.Version != "" {
+			return nil, fmt.Errorf("cannot specify both version range (--from-tag/--to-tag) and single version (--version)")
+		}
+		if o.FromTag == "" || o.ToTag == "" {
+			return nil, fmt.Errorf("version range mode requires both --from-tag and --to-tag")
+		}
+		return []string{o.FromTag, o.ToTag}, nil
+	}
+	if o.Version == "" {
+		return nil, fmt.Errorf("must specify either --version (single version) or --from-tag/--to-tag (version range)")
+	}
+	return []string{o.Version}, nil
+}
+
+// Validate checks the options of a defaults generation
+func (o Options) Validate() error {
+	switch o.Source {
+	case SourcePlayground, SourceSourceOnly, SourceRuntimeOnly:
+	default:
+		return fmt.Errorf("invalid --source %q (expected %s, %s or %s)", o.Source, SourcePlayground, SourceSourceOnly, SourceRuntimeOnly)
+	}
+	if _, err := o.Versions(); err != nil {
+		return err
+	}
+	if o.FromBinaries != "" && o.Version == "" {
+		return fmt.Errorf("--from-binaries requires --version (the version of the binaries)")
+	}
+	if o.Source == SourceRuntimeOnly && o.Version == "" {
+		return fmt.Errorf("--source %s requires --version (the version the cluster runs)", SourceRuntimeOnly)
+	}
+	return nil
+}
+
+// generator runs a generation with its options
+type generator struct {
+	Options
+	components map[string]bool
+}
+
+func newGenerator(opts Options) *generator {
+	if opts.KnowledgeDir == "" {
+		opts.KnowledgeDir = DefaultKnowledgeDir
+	}
+	components := make(map[string]bool)
+	for _, comp := range opts.Components {
+		components[strings.TrimSpace(comp)] = true
+	}
+	return &generator{Options: opts, components: components}
+}
+
+// defaultsPath returns the path of the defaults.json of a component for a version
+func (g *generator) defaultsPath(version, component string) string {
+	return filepath.Join(g.KnowledgeDir, VersionGroup(version), version, component, "defaults.json")
+}
+
+// GenerateDefaults generates the defaults.json of the components for the versions of the options,
+// from the source given by the options (playground, source code only, running cluster or local binaries)
+func GenerateDefaults(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	return newGenerator(opts).generateDefaults()
+}
+
+// GenerateUpgradeLogic generates the upgrade_logic.json of TiDB and PD from their repositories
+// The upgrade logic is version-agnostic: it should be extracted from the master branch
+func GenerateUpgradeLogic(opts Options) error {
+	g := newGenerator(opts)
+	if !g.hasUpgradeLogicSource() {
+		return fmt.Errorf("no upgrade logic to generate: --tidb-repo or --pd-repo is required, with tidb or pd in --components")
+	}
+	return g.generateUpgradeLogic()
+}
+
+// GenerateAll generates the upgrade logic (if the repositories are given), then the defaults of the versions
+// A failure of the upgrade logic is a warning, except unresolved variable names in strict mode
+// No upgrade logic is generated from a running cluster or from local binaries, as no source code is read
+func GenerateAll(opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	g := newGenerator(opts)
+	if g.FromBinaries == "" && g.Source != SourceRuntimeOnly && g.hasUpgradeLogicSource() {
+		if err := g.generateUpgradeLogic(); err != nil {
+			if errors.Is(err, ErrUnresolvedVarNames) {
+				return err
+			}
+			log.Printf("Warning: %v\n", err)
+			log.Printf("Continuing with knowledge base generation...\n")
+		}
+	}
+	return g.generateDefaults()
+}
+
+// GenerateParameterHistory generates knowledge/<component>/parameter_history.json for the components of the options,
+// from the versions already in the knowledge base (no version or playground needed)
+func GenerateParameterHistory(opts Options) error {
+	g := newGenerator(opts)
+	for _, comp := range g.Components {
+		if err := g.generateParameterHistory(comp); err != nil {
+			return fmt.Errorf("failed to generate %s parameter history: %w", comp, err)
+		}
+	}
+	return nil
+}
+
+// GenerateUpgradeHistory generates knowledge/tidb/upgrade_history.json, mapping TiDB's bootstrap versions to releases
+// The bootstrap versions are read at each release tag of the TiDB repository if given, otherwise taken from
+// the TiDB defaults already in the knowledge base
+func GenerateUpgradeHistory(opts Options) error {
+	return newGenerator(opts).generateUpgradeHistory()
+}
+
+// hasUpgradeLogicSource checks if the repository of a component with upgrade logic is given
+func (g *generator) hasUpgradeLogicSource() bool {
+	return (g.components["tidb"] && g.TiDBRepo != "") || (g.components["pd"] && g.PDRepo != "")
+}
+
+// generateUpgradeLogic generates the upgrade logic of TiDB and PD, and returns the errors of both
+func (g *generator) generateUpgradeLogic() error {
+	var errs []error
+	if g.components["tidb"] && g.TiDBRepo != "" {
+		outputPath := filepath.Join(g.KnowledgeDir, "tidb", "upgrade_logic.json")
+		if err := generateTiDBUpgradeLogic(g.TiDBRepo, outputPath, g.Strict); err != nil {
+			errs = append(errs, fmt.Errorf("failed to generate upgrade_logic.json: %w", err))
+		}
+	}
+	if g.components["pd"] && g.PDRepo != "" {
+		outputPath := filepath.Join(g.KnowledgeDir, "pd", "upgrade_logic.json")
+		if err := generatePDUpgradeLogic(g.PDRepo, outputPath); err != nil {
+			errs = append(errs, fmt.Errorf("failed to generate PD upgrade_logic.json: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// generateDefaults generates the defaults of the versions from the source of the options
+func (g *generator) generateDefaults() error {
+	versions, err := g.Versions()
+	if err != nil {
+		return err
+	}
+	if len(versions) > 1 {
+		fmt.Printf("Version range mode: generating knowledge base for %s and %s\n", versions[0], versions[1])
+	} else {
+		fmt.Printf("Single version mode: generating knowledge base for %s\n", versions[0])
+	}
+
+	// Binaries of a single version, no source code
+	if g.FromBinaries != "" {
+		if err := g.generateFromBinaries(g.Version); err != nil {
+			return fmt.Errorf("failed to generate knowledge base from binaries: %w", err)
+		}
+		return nil
+	}
+
+	// A running cluster has a single version
+	if g.Source == SourceRuntimeOnly {
+		if err := g.generateFromCluster(g.Version, g.Cluster); err != nil {
+			return fmt.Errorf("failed to generate knowledge base from cluster: %w", err)
+		}
+		return nil
+	}
+
+	// Source code only: no playground lifecycle, only AST-based extraction
+	if g.Source == SourceSourceOnly {
+		for _, version := range versions {
+			if err := g.generateFromSource(version); err != nil {
+				return fmt.Errorf("failed to generate knowledge base from source code: %w", err)
+			}
+		}
+		return nil
+	}
+
+	for i, version := range versions {
+		if i > 0 {
+			fmt.Printf("\n")
+			fmt.Printf("========================================\n")
+			fmt.Printf("Processing next version: %s\n", version)
+			fmt.Printf("========================================\n")
+			fmt.Printf("\n")
+		}
+		if err := g.generateFromPlayground(version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// generateFromPlayground generates the defaults of a version from a tiup playground started for it
+func (g *generator) generateFromPlayground(version string) error {
+	// Generate unique tag for this run (shared across all components)
+	tag := fmt.Sprintf("kb-gen-%s-%d", version, time.Now().Unix())
+
+	// Start playground cluster first (before any component collection)
+	// This ensures all components can access the cluster data
+	fmt.Printf("Starting tiup playground cluster for version %s (tag: %s)...\n", version, tag)
+	if err := common.StartPlayground(version, tag); err != nil {
+		return fmt.Errorf("failed to start playground cluster: %w", err)
+	}
+
+	// Wait for cluster to be ready
+	fmt.Printf("Waiting for cluster to be ready...\n")
+	if err := common.WaitForClusterReady(tag, defaultTiDBPort); err != nil {
+		return fmt.Errorf("cluster failed to become ready: %w", err)
+	}
+
+	// The PD address is determined from the playground before the components are generated concurrently
+	pdAddr := playgroundPDAddr(tag)
+
+	// Generate the components concurrently: once the playground is up, each collector hits different
+	// endpoints, parses a different repository and writes its own defaults.json
+	var jobs []componentJob
+	if g.components["tidb"] && g.TiDBRepo != "" {
+		jobs = append(jobs, componentJob{
+			component: "tidb",
+			generate:  func() error { return g.generateSingleVersionTiDB(version, tag) },
+			fatal:     func(error) bool { return true },
+		})
+	}
+	if g.components["pd"] && g.PDRepo != "" {
+		jobs = append(jobs, componentJob{
+			component: "pd",
+			generate:  func() error { return g.generateSingleVersionPD(version, pdAddr) },
+			fatal:     func(error) bool { return true },
+		})
+	}
+	// TiKV and TiFlash failures are warnings, except duplicate keys with conflicting values in strict mode
+	if g.components["tikv"] && g.TiKVRepo != "" {
+		jobs = append(jobs, componentJob{
+			component: "tikv",
+			generate:  func() error { return g.generateSingleVersionTiKV(version, tag) },
+			fatal:     isKeyConflictsError,
+		})
+	}
+	if g.components["tiflash"] && g.TiFlashRepo != "" {
+		jobs = append(jobs, componentJob{
+			component: "tiflash",
+			generate:  func() error { return g.generateSingleVersionTiFlash(version, tag) },
+			fatal:     isKeyConflictsError,
+		})
+	}
+
+	start := time.Now()
+	outcomes := runComponentJobs(jobs, g.FailFast, func() {
+		log.Printf("A component failed, stopping playground cluster (--fail-fast)...\n")
+		if err := common.StopPlayground(tag); err != nil {
+			log.Printf("Warning: failed to stop playground cluster: %v\n", err)
+		}
+	})
+	fmt.Print(formatComponentSummary(version, outcomes, time.Since(start)))
+
+	if hasFatalOutcome(outcomes) {
+		common.StopPlayground(tag)
+		return fmt.Errorf("failed to generate knowledge base for %s: %w", version, fatalOutcomeErrors(outcomes))
+	}
+
+	// Cleanup cluster after each version
+	// This ensures cleanup happens synchronously and resources are released immediately
+	// For serial generation, this ensures complete cleanup after each version to avoid conflicts
+	fmt.Printf("========================================\n")
+	fmt.Printf("Forcefully cleaning up playground cluster (tag: %s)...\n", tag)
+	fmt.Printf("========================================\n")
+	if err := common.StopPlayground(tag); err != nil {
+		log.Printf("Warning: failed to stop playground cluster: %v\n", err)
+	}
+	// Wait longer to ensure all processes are terminated and resources are released
+	// This is especially important for serial generation to avoid conflicts
+	time.Sleep(5 * time.Second)
+	fmt.Printf("✓ Cleanup completed, ready for next version\n")
+	fmt.Printf("========================================\n\n")
+	return nil
+}
+
+// VersionGroup extracts the version group (first two digits) from a full version string
+// Example: v6.5.0 -> v6.5, v7.5.0 -> v7.5
+func VersionGroup(version string) string {
+	// Remove 'v' prefix if present
+	version = strings.TrimPrefix(version, "v")
+
+	// Split by '.' and take first two parts
+	parts := strings.Split(version, ".")
+	if len(parts) >= 2 {
+		return "v" + parts[0] + "." + parts[1]
+	}
+	// Fallback: if version doesn't have expected format, return as is
+	return "v" + version
+}
+
+// isKeyConflictsError reports whether err is ErrKeyConflicts (duplicate keys with conflicting values, strict mode)
+func isKeyConflictsError(err error) bool {
+	return errors.Is(err, ErrKeyConflicts)
+}
+
+// playgroundPDAddr returns the address of the PD instance of a playground
+// It falls back to the default PD address if the instance is not found in the playground data directory
+func playgroundPDAddr(tag string) string {
+	pdAddr, err := common.FindPlaygroundInstanceAddr("pd", tag)
+	if err != nil {
+		pdAddr = fmt.Sprintf("%s:%d", "127.0.0.1", defaultPDPort)
+		log.Printf("Warning: %v, using default PD address: %s\n", err, pdAddr)
+		return pdAddr
+	}
+	fmt.Printf("Found PD address in playground: %s\n", pdAddr)
+	return pdAddr
+}
@@ -0,0 +1,113 @@
+package kbgenerator
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptionsValidate(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Version = "v8.5.0"
+	require.NoError(t, opts.Validate())
+	versions, err := opts.Versions()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v8.5.0"}, versions)
+
+	tests := []struct {
+		name   string
+		modify func(*Options)
+		err    string
+	}{
+		{"no version", func(o *Options) { o.Version = "" }, "must specify either --version"},
+		{"version and range", func(o *Options) { o.FromTag, o.ToTag = "v7.5.0", "v8.5.0" }, "cannot specify both"},
+		{"half range", func(o *Options) { o.Version, o.FromTag = "", "v7.5.0" }, "requires both --from-tag and --to-tag"},
+		{"source", func(o *Options) { o.Source = "cluster" }, "invalid --source"},
+		{"binaries in range mode", func(o *Options) { o.Version, o.FromTag, o.ToTag, o.FromBinaries = "", "v7.5.0", "v8.5.0", "bin" }, "--from-binaries requires --version"},
+		{"cluster in range mode", func(o *Options) { o.Version, o.FromTag, o.ToTag, o.Source = "", "v7.5.0", "v8.5.0", SourceRuntimeOnly }, "requires --version"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := opts
+			tt.modify(&o)
+			assert.ErrorContains(t, o.Validate(), tt.err)
+		})
+	}
+
+	opts.Version, opts.FromTag, opts.ToTag = "", "v7.5.0", "v8.5.0"
+	versions, err = opts.Versions()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v7.5.0", "v8.5.0"}, versions)
+}
+
+func TestVersionGroup(t *testing.T) {
+	assert.Equal(t, "v6.5", VersionGroup("v6.5.0"))
+	assert.Equal(t, "v8.5", VersionGroup("8.5.1"))
+	assert.Equal(t, "vnightly", VersionGroup("nightly"))
+}
+
+func TestParseComponents(t *testing.T) {
+	assert.Equal(t, []string{"tidb", "pd"}, ParseComponents(" tidb, ,pd,"))
+	assert.Empty(t, ParseComponents(""))
+}
+
+func TestGenerateUpgradeLogic_NoRepository(t *testing.T) {
+	opts := DefaultOptions()
+	opts.TiKVRepo = t.TempDir()
+	assert.ErrorContains(t, GenerateUpgradeLogic(opts), "--tidb-repo or --pd-repo is required")
+
+	// The repository of a component that is not generated is ignored
+	opts.TiDBRepo = t.TempDir()
+	opts.Components = []string{"tikv"}
+	assert.ErrorContains(t, GenerateUpgradeLogic(opts), "--tidb-repo or --pd-repo is required")
+}
+
+func TestGenerateDefaults_SourceOnlyRequiresTiDBRepo(t *testing.T) {
+	opts := DefaultOptions()
+	opts.KnowledgeDir = t.TempDir()
+	opts.Version = "v8.5.0"
+	opts.Source = SourceSourceOnly
+	assert.ErrorContains(t, GenerateDefaults(opts), "--tidb-repo is required")
+
+	// Only TiDB has knowledge that can be extracted from source code
+	opts.Components = []string{"pd", "tikv"}
+	assert.NoError(t, GenerateDefaults(opts))
+}
+
+func TestRunComponentJobs(t *testing.T) {
+	errTiKV := errors.New("tikv failed")
+	jobs := []componentJob{
+		{component: "tidb", generate: func() error { return nil }, fatal: func(error) bool { return true }},
+		{component: "tikv", generate: func() error { return errTiKV }, fatal: isKeyConflictsError},
+		{component: "tiflash", generate: func() error { return fmt.Errorf("%w: 1 in tiflash v8.5.0", ErrKeyConflicts) }, fatal: isKeyConflictsError},
+	}
+
+	var aborts int32
+	outcomes := runComponentJobs(jobs, false, func() { atomic.AddInt32(&aborts, 1) })
+	require.Len(t, outcomes, 3)
+	assert.Equal(t, "tidb", outcomes[0].Component)
+	assert.NoError(t, outcomes[0].Err)
+	assert.ErrorIs(t, outcomes[1].Err, errTiKV)
+	assert.False(t, outcomes[1].Fatal, "a TiKV failure is a warning")
+	assert.True(t, outcomes[2].Fatal, "conflicting keys are fatal")
+	assert.Zero(t, atomic.LoadInt32(&aborts))
+	assert.True(t, hasFatalOutcome(outcomes))
+	assert.ErrorIs(t, fatalOutcomeErrors(outcomes), ErrKeyConflicts)
+	assert.NotErrorIs(t, fatalOutcomeErrors(outcomes), errTiKV)
+
+	summary := formatComponentSummary("v8.5.0", outcomes, 0)
+	assert.Contains(t, summary, "Knowledge generation summary for v8.5.0")
+	assert.Contains(t, summary, "warning: tikv failed")
+	assert.Contains(t, summary, "FAILED: duplicate keys")
+
+	// With failFast every failure is fatal, and abort is called once
+	outcomes = runComponentJobs(jobs, true, func() { atomic.AddInt32(&aborts, 1) })
+	assert.True(t, outcomes[1].Fatal)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&aborts))
+
+	assert.False(t, hasFatalOutcome(runComponentJobs(jobs[:1], true, nil)))
+}
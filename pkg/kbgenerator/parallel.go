@@ -0,0 +1,99 @@
+package kbgenerator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// componentJob generates the knowledge of a component for a version, from the shared playground
+type componentJob struct {
+	// component is the component type (tidb, pd, tikv, tiflash)
+	component string
+	// generate collects the knowledge of the component and saves it to the component's own output path
+	generate func() error
+	// fatal tells if an error of generate must fail the generation (otherwise it is reported as a warning)
+	fatal func(error) bool
+}
+
+// componentOutcome is the outcome of a componentJob
+type componentOutcome struct {
+	Component string
+	Duration  time.Duration
+	Err       error
+	// Fatal is set when Err must fail the generation
+	Fatal bool
+}
+
+// runComponentJobs runs the jobs concurrently and returns their outcomes, in job order
+// Components hit different endpoints of the playground and parse different repositories, so their jobs are independent
+// A failure does not stop the other jobs, unless failFast is set: then every failure is fatal, and abort is called
+// once on the first failure (e.g., to stop the playground, which makes the running collections fail fast)
+func runComponentJobs(jobs []componentJob, failFast bool, abort func()) []componentOutcome {
+	outcomes := make([]componentOutcome, len(jobs))
+	var (
+		wg        sync.WaitGroup
+		abortOnce sync.Once
+	)
+	for i, job := range jobs {
+		wg.Add(1)
+		go func(i int, job componentJob) {
+			defer wg.Done()
+			start := time.Now()
+			err := job.generate()
+			outcome := componentOutcome{Component: job.component, Duration: time.Since(start), Err: err}
+			if err != nil {
+				outcome.Fatal = failFast || (job.fatal != nil && job.fatal(err))
+			}
+			outcomes[i] = outcome
+
+			if err != nil && failFast && abort != nil {
+				abortOnce.Do(abort)
+			}
+		}(i, job)
+	}
+	wg.Wait()
+	return outcomes
+}
+
+// formatComponentSummary formats the outcomes of the components of a version, with the wall-clock time of the
+// parallel phase and the sum of the component durations (the time a serial generation would have taken)
+func formatComponentSummary(version string, outcomes []componentOutcome, wallClock time.Duration) string {
+	summary := fmt.Sprintf("Knowledge generation summary for %s:\n", version)
+	var total time.Duration
+	for _, outcome := range outcomes {
+		total += outcome.Duration
+		status := "ok"
+		switch {
+		case outcome.Err != nil && outcome.Fatal:
+			status = fmt.Sprintf("FAILED: %v", outcome.Err)
+		case outcome.Err != nil:
+			status = fmt.Sprintf("warning: %v", outcome.Err)
+		}
+		summary += fmt.Sprintf("  %-8s %8s  %s\n", outcome.Component, outcome.Duration.Round(time.Millisecond), status)
+	}
+	summary += fmt.Sprintf("  Wall clock: %s (sum of components: %s)\n", wallClock.Round(time.Millisecond), total.Round(time.Millisecond))
+	return summary
+}
+
+// hasFatalOutcome reports whether a component failed with a fatal error
+func hasFatalOutcome(outcomes []componentOutcome) bool {
+	for _, outcome := range outcomes {
+		if outcome.Err != nil && outcome.Fatal {
+			return true
+		}
+	}
+	return false
+}
+
+// fatalOutcomeErrors joins the errors of the components that failed with a fatal error
+func fatalOutcomeErrors(outcomes []componentOutcome) error {
+	var errs []error
+	for _, outcome := range outcomes {
+		if outcome.Err != nil && outcome.Fatal {
+			errs = append(errs, fmt.Errorf("%s: %w", outcome.Component, outcome.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,99 @@
+// Package notifier pushes a summary of precheck results to external systems (chat webhooks, ...)
+// when precheck runs from automation
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/api"
+)
+
+// Notifier sends a notification of precheck results
+// Implementations must not modify the payload
+type Notifier interface {
+	Notify(ctx context.Context, payload *Payload) error
+}
+
+// Status is the overall status of a precheck run
+type Status string
+
+const (
+	// StatusCritical means critical or error findings were found
+	StatusCritical Status = "critical"
+	// StatusWarning means warning findings were found, but no critical or error finding
+	StatusWarning Status = "warning"
+	// StatusOK means only informational findings were found
+	StatusOK Status = "ok"
+)
+
+// Payload is the compact summary of a precheck run sent in notifications
+type Payload struct {
+	// Status is the overall status, derived from the most severe finding
+	Status Status `json:"status"`
+	// SourceVersion is the version the cluster was checked against
+	SourceVersion string `json:"source_version"`
+	// TargetVersion is the target version of the upgrade
+	TargetVersion string `json:"target_version"`
+	// Summary contains the counts per severity and component, and the most severe findings
+	Summary api.FindingsSummary `json:"summary"`
+	// Reports are the locations (paths or URLs) of the generated reports
+	Reports []string `json:"reports,omitempty"`
+}
+
+// NewPayload builds the notification payload of an analysis result
+// Summary.TopCritical holds at most topN findings (api.DefaultTopN if topN <= 0)
+func NewPayload(result *analyzer.AnalysisResult, reports []string, topN int) *Payload {
+	summary := api.Summarize(result, topN)
+	payload := &Payload{
+		Status:  StatusOK,
+		Summary: summary,
+		Reports: reports,
+	}
+	if result != nil {
+		payload.SourceVersion = result.SourceVersion
+		payload.TargetVersion = result.TargetVersion
+	}
+	if summary.BySeverity["critical"] > 0 || summary.BySeverity["error"] > 0 {
+		payload.Status = StatusCritical
+	} else if summary.BySeverity["warning"] > 0 {
+		payload.Status = StatusWarning
+	}
+	return payload
+}
+
+// Threshold controls when a notification is sent
+type Threshold string
+
+const (
+	// NotifyAlways sends a notification after every run
+	NotifyAlways Threshold = "always"
+	// NotifyOnWarning sends a notification if warning, error or critical findings were found
+	NotifyOnWarning Threshold = "on-warning"
+	// NotifyOnCritical sends a notification if error or critical findings were found
+	NotifyOnCritical Threshold = "on-critical"
+)
+
+// ParseThreshold parses a --notify-on value
+func ParseThreshold(s string) (Threshold, error) {
+	switch t := Threshold(strings.ToLower(strings.TrimSpace(s))); t {
+	case NotifyAlways, NotifyOnWarning, NotifyOnCritical:
+		return t, nil
+	default:
+		return "", fmt.Errorf("invalid notification threshold %q (expected %s, %s or %s)", s, NotifyAlways, NotifyOnWarning, NotifyOnCritical)
+	}
+}
+
+// ShouldNotify checks if a notification must be sent for the payload
+func (t Threshold) ShouldNotify(payload *Payload) bool {
+	switch t {
+	case NotifyOnWarning:
+		return payload.Status == StatusCritical || payload.Status == StatusWarning
+	case NotifyOnCritical:
+		return payload.Status == StatusCritical
+	default:
+		return true
+	}
+}
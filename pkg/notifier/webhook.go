@@ -0,0 +1,165 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Format is the body format of webhook notifications
+type Format string
+
+const (
+	// FormatJSON posts the Payload as is
+	FormatJSON Format = "json"
+	// FormatSlack posts a Slack-compatible message with blocks (incoming webhooks)
+	FormatSlack Format = "slack"
+)
+
+// ParseFormat parses a --notify-format value
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case FormatJSON, FormatSlack:
+		return f, nil
+	default:
+		return "", fmt.Errorf("invalid notification format %q (expected %s or %s)", s, FormatJSON, FormatSlack)
+	}
+}
+
+// WebhookNotifier posts notifications to an HTTP webhook
+type WebhookNotifier struct {
+	// URL is the webhook endpoint
+	URL string
+	// Format is the body format
+	Format Format
+	// HTTPClient is the client used for requests (a client with a 10s timeout is used if nil)
+	HTTPClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url in the given format
+func NewWebhookNotifier(url string, format Format) *WebhookNotifier {
+	return &WebhookNotifier{
+		URL:    url,
+		Format: format,
+		HTTPClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Notify posts the payload to the webhook
+// A response status other than 2xx is returned as an error
+func (n *WebhookNotifier) Notify(ctx context.Context, payload *Payload) error {
+	var message interface{} = payload
+	if n.Format == FormatSlack {
+		message = newSlackMessage(payload)
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	httpClient := n.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create notification request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		// Chat webhooks explain the rejection in the body (e.g., "invalid_blocks")
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+// slackMessage is a Slack incoming webhook message
+// Text is the fallback shown in notifications, Blocks are the rendered content
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+// slackBlock is a Block Kit block (header, section or context)
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+// slackText is a Block Kit text object
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// statusEmoji is the emoji shown in front of the status of a run
+var statusEmoji = map[Status]string{
+	StatusCritical: ":red_circle:",
+	StatusWarning:  ":warning:",
+	StatusOK:       ":white_check_mark:",
+}
+
+// newSlackMessage renders the payload as Slack blocks:
+// a header, the counts per severity, the critical findings and the report locations
+func newSlackMessage(payload *Payload) slackMessage {
+	title := fmt.Sprintf("Upgrade precheck %s -> %s: %s", payload.SourceVersion, payload.TargetVersion, payload.Status)
+	message := slackMessage{
+		Text: title,
+		Blocks: []slackBlock{
+			{Type: "header", Text: &slackText{Type: "plain_text", Text: title}},
+		},
+	}
+
+	counts := slackBlock{Type: "section"}
+	for _, severity := range []string{"critical", "error", "warning", "info"} {
+		counts.Fields = append(counts.Fields, slackText{
+			Type: "mrkdwn",
+			Text: fmt.Sprintf("*%s*\n%d", severity, payload.Summary.BySeverity[severity]),
+		})
+	}
+	message.Blocks = append(message.Blocks,
+		slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn",
+			Text: fmt.Sprintf("%s %d findings", statusEmoji[payload.Status], payload.Summary.Total)}},
+		counts,
+	)
+
+	if len(payload.Summary.TopCritical) > 0 {
+		var lines []string
+		for _, finding := range payload.Summary.TopCritical {
+			subject := finding.Component
+			if finding.ParameterName != "" {
+				subject += "/" + finding.ParameterName
+			}
+			lines = append(lines, fmt.Sprintf("• [%s] `%s` %s", finding.Severity, subject, finding.Message))
+		}
+		message.Blocks = append(message.Blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: "*Critical findings*\n" + strings.Join(lines, "\n")},
+		})
+	}
+
+	if len(payload.Reports) > 0 {
+		message.Blocks = append(message.Blocks, slackBlock{
+			Type:     "context",
+			Elements: []slackText{{Type: "mrkdwn", Text: "Full report: " + strings.Join(payload.Reports, ", ")}},
+		})
+	}
+	return message
+}
@@ -0,0 +1,150 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestResult returns an analysis result with one finding per given severity
+func newTestResult(severities ...string) *analyzer.AnalysisResult {
+	result := &analyzer.AnalysisResult{SourceVersion: "v7.5.0", TargetVersion: "v8.5.0"}
+	for _, severity := range severities {
+		result.CheckResults = append(result.CheckResults, rules.CheckResult{
+			RuleID:        "UPGRADE_DIFFERENCES",
+			Component:     "tikv",
+			ParameterName: "storage.engine",
+			Severity:      severity,
+			Message:       "Parameter storage.engine in tikv: " + severity,
+		})
+	}
+	return result
+}
+
+// recordingServer returns a webhook server recording the request bodies, answering with status
+func recordingServer(t *testing.T, status int) (*httptest.Server, *[][]byte) {
+	var bodies [][]byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		bodies = append(bodies, body)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte("invalid_payload"))
+	}))
+	t.Cleanup(server.Close)
+	return server, &bodies
+}
+
+func TestWebhookNotifier_JSONPayload(t *testing.T) {
+	server, bodies := recordingServer(t, http.StatusOK)
+
+	payload := NewPayload(newTestResult("critical", "warning", "info"), []string{"/tmp/report.html"}, 0)
+	require.NoError(t, NewWebhookNotifier(server.URL, FormatJSON).Notify(context.Background(), payload))
+	require.Len(t, *bodies, 1)
+
+	var got map[string]interface{}
+	require.NoError(t, json.Unmarshal((*bodies)[0], &got))
+	assert.Equal(t, "critical", got["status"])
+	assert.Equal(t, "v7.5.0", got["source_version"])
+	assert.Equal(t, "v8.5.0", got["target_version"])
+	assert.Equal(t, []interface{}{"/tmp/report.html"}, got["reports"])
+
+	summary := got["summary"].(map[string]interface{})
+	assert.Equal(t, float64(3), summary["total"])
+	assert.Equal(t, map[string]interface{}{"critical": float64(1), "warning": float64(1), "info": float64(1)}, summary["by_severity"])
+	topCritical := summary["top_critical"].([]interface{})
+	require.Len(t, topCritical, 1)
+	assert.Equal(t, "storage.engine", topCritical[0].(map[string]interface{})["parameter_name"])
+}
+
+func TestWebhookNotifier_SlackPayload(t *testing.T) {
+	server, bodies := recordingServer(t, http.StatusOK)
+
+	payload := NewPayload(newTestResult("error", "warning"), []string{"s3://bucket/report.html"}, 0)
+	require.NoError(t, NewWebhookNotifier(server.URL, FormatSlack).Notify(context.Background(), payload))
+	require.Len(t, *bodies, 1)
+
+	var got slackMessage
+	require.NoError(t, json.Unmarshal((*bodies)[0], &got))
+	assert.Equal(t, "Upgrade precheck v7.5.0 -> v8.5.0: critical", got.Text)
+
+	var types []string
+	for _, block := range got.Blocks {
+		types = append(types, block.Type)
+	}
+	assert.Equal(t, []string{"header", "section", "section", "section", "context"}, types)
+	assert.Len(t, got.Blocks[2].Fields, 4)
+	assert.Equal(t, "*error*\n1", got.Blocks[2].Fields[1].Text)
+	assert.Contains(t, got.Blocks[3].Text.Text, "`tikv/storage.engine` Parameter storage.engine in tikv: error")
+	assert.Equal(t, "Full report: s3://bucket/report.html", got.Blocks[4].Elements[0].Text)
+}
+
+func TestWebhookNotifier_ErrorStatus(t *testing.T) {
+	server, _ := recordingServer(t, http.StatusBadRequest)
+
+	err := NewWebhookNotifier(server.URL, FormatSlack).Notify(context.Background(), NewPayload(newTestResult("info"), nil, 0))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "status 400: invalid_payload")
+}
+
+func TestThreshold_ShouldNotify(t *testing.T) {
+	tests := []struct {
+		name       string
+		severities []string
+		want       map[Threshold]bool
+	}{
+		{
+			name:       "only info",
+			severities: []string{"info"},
+			want:       map[Threshold]bool{NotifyAlways: true, NotifyOnWarning: false, NotifyOnCritical: false},
+		},
+		{
+			name:       "warning",
+			severities: []string{"info", "warning"},
+			want:       map[Threshold]bool{NotifyAlways: true, NotifyOnWarning: true, NotifyOnCritical: false},
+		},
+		{
+			name:       "error counts as critical",
+			severities: []string{"error"},
+			want:       map[Threshold]bool{NotifyAlways: true, NotifyOnWarning: true, NotifyOnCritical: true},
+		},
+		{
+			name:       "critical",
+			severities: []string{"critical", "warning"},
+			want:       map[Threshold]bool{NotifyAlways: true, NotifyOnWarning: true, NotifyOnCritical: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := NewPayload(newTestResult(tt.severities...), nil, 0)
+			for threshold, want := range tt.want {
+				assert.Equal(t, want, threshold.ShouldNotify(payload), "threshold %s", threshold)
+			}
+		})
+	}
+}
+
+func TestParseThresholdAndFormat(t *testing.T) {
+	threshold, err := ParseThreshold("On-Critical")
+	require.NoError(t, err)
+	assert.Equal(t, NotifyOnCritical, threshold)
+	_, err = ParseThreshold("sometimes")
+	assert.Error(t, err)
+
+	format, err := ParseFormat("slack")
+	require.NoError(t, err)
+	assert.Equal(t, FormatSlack, format)
+	_, err = ParseFormat("teams")
+	assert.Error(t, err)
+}
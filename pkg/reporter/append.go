@@ -0,0 +1,78 @@
+package reporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AppendFilename is the report file name (without extension) used when appending reports
+// (see Options.Append), so that successive runs write to the same file
+const AppendFilename = "upgrade_precheck_report"
+
+// CanAppend returns true if reports of the format can be appended to an existing report
+// HTML documents cannot be concatenated
+func CanAppend(format Format) bool {
+	switch format {
+	case TextFormat, MarkdownFormat, JSONFormat:
+		return true
+	default:
+		return false
+	}
+}
+
+// AppendReport appends a report to the content of an existing report of the same format
+// JSON reports are collected in a JSON array (an existing single report becomes the first element),
+// text and markdown reports are separated by a horizontal rule and the time the report was appended
+// An empty existing report starts a new one (a one-element array for JSON)
+func AppendReport(existing, report []byte, format Format, appendedAt time.Time) ([]byte, error) {
+	switch format {
+	case JSONFormat:
+		return appendJSONReport(existing, report)
+	case TextFormat, MarkdownFormat:
+		if len(bytes.TrimSpace(existing)) == 0 {
+			return report, nil
+		}
+		timestamp := appendedAt.Format("2006-01-02 15:04:05")
+		var separator string
+		if format == MarkdownFormat {
+			separator = fmt.Sprintf("\n\n---\n\n_Report appended at %s_\n\n", timestamp)
+		} else {
+			separator = fmt.Sprintf("\n%s\nReport appended at %s\n%s\n\n", strings.Repeat("=", 80), timestamp, strings.Repeat("=", 80))
+		}
+		merged := append(bytes.TrimRight(existing, "\n"), separator...)
+		return append(merged, report...), nil
+	default:
+		return nil, fmt.Errorf("appending is not supported for %s reports", format)
+	}
+}
+
+// appendJSONReport adds a JSON report to a JSON array of reports
+func appendJSONReport(existing, report []byte) ([]byte, error) {
+	var reports []json.RawMessage
+	existing = bytes.TrimSpace(existing)
+	switch {
+	case len(existing) == 0:
+	case existing[0] == '[':
+		if err := json.Unmarshal(existing, &reports); err != nil {
+			return nil, fmt.Errorf("existing report is not a JSON array of reports: %w", err)
+		}
+	default:
+		if !json.Valid(existing) {
+			return nil, fmt.Errorf("existing report is not valid JSON")
+		}
+		reports = append(reports, existing)
+	}
+	if !json.Valid(report) {
+		return nil, fmt.Errorf("report is not valid JSON")
+	}
+	reports = append(reports, report)
+
+	merged, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(merged, '\n'), nil
+}
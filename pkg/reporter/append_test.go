@@ -0,0 +1,77 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendReport(t *testing.T) {
+	appendedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	merged, err := AppendReport(nil, []byte("first\n"), TextFormat, appendedAt)
+	require.NoError(t, err)
+	assert.Equal(t, "first\n", string(merged))
+	merged, err = AppendReport(merged, []byte("second\n"), TextFormat, appendedAt)
+	require.NoError(t, err)
+	assert.Equal(t, "first\n"+strings.Repeat("=", 80)+"\nReport appended at 2026-01-02 03:04:05\n"+strings.Repeat("=", 80)+"\n\nsecond\n", string(merged))
+
+	merged, err = AppendReport([]byte("# First\n"), []byte("# Second\n"), MarkdownFormat, appendedAt)
+	require.NoError(t, err)
+	assert.Equal(t, "# First\n\n---\n\n_Report appended at 2026-01-02 03:04:05_\n\n# Second\n", string(merged))
+
+	_, err = AppendReport([]byte("<html></html>"), []byte("<html></html>"), HTMLFormat, appendedAt)
+	assert.Error(t, err)
+}
+
+func TestAppendReport_JSON(t *testing.T) {
+	// A single report (written without appending) becomes the first element of the array
+	merged, err := AppendReport([]byte(`{"run": 1}`), []byte(`{"run": 2}`), JSONFormat, time.Now())
+	require.NoError(t, err)
+	merged, err = AppendReport(merged, []byte(`{"run": 3}`), JSONFormat, time.Now())
+	require.NoError(t, err)
+	var reports []map[string]int
+	require.NoError(t, json.Unmarshal(merged, &reports))
+	assert.Equal(t, []map[string]int{{"run": 1}, {"run": 2}, {"run": 3}}, reports)
+
+	merged, err = AppendReport(nil, []byte(`{"run": 1}`), JSONFormat, time.Now())
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(merged, &reports))
+	assert.Len(t, reports, 1)
+
+	_, err = AppendReport([]byte(`{"run": `), []byte(`{"run": 2}`), JSONFormat, time.Now())
+	assert.Error(t, err)
+	_, err = AppendReport([]byte(`[1, `), []byte(`{"run": 2}`), JSONFormat, time.Now())
+	assert.Error(t, err)
+}
+
+func TestGenerator_GenerateFromAnalysisResult_Append(t *testing.T) {
+	dir := t.TempDir()
+	gen := NewGenerator()
+	options := &Options{Format: JSONFormat, OutputURI: fileURI(dir), Append: true}
+
+	for i := 0; i < 2; i++ {
+		location, err := gen.GenerateFromAnalysisResult(context.Background(), newOutputTestResult(), options)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, AppendFilename+".json"), location)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, AppendFilename+".json"))
+	require.NoError(t, err)
+	var reports []map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &reports))
+	require.Len(t, reports, 2)
+	assert.Equal(t, "v8.5.0", reports[1]["target_version"])
+
+	// Appending needs to read the existing report back
+	var out bytes.Buffer
+	_, err = gen.GenerateFromAnalysisResult(context.Background(), newOutputTestResult(), &Options{Format: TextFormat, Writer: &stdoutWriter{out: &out}, Append: true})
+	assert.ErrorContains(t, err, "local output directory")
+}
@@ -0,0 +1,82 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReports_DeterministicAcrossRuns runs the analysis twice on the same cluster and knowledge base
+// and checks that the reports are byte-identical (rules produce their results in map iteration order)
+func TestReports_DeterministicAcrossRuns(t *testing.T) {
+	collector.ClearKBCache()
+	defer collector.ClearKBCache()
+
+	kbPath := filepath.Join(t.TempDir(), "knowledge")
+	components := map[string]types.ComponentType{"tidb": types.ComponentTiDB, "pd": types.ComponentPD, "tikv": types.ComponentTiKV}
+	snapshot := &collector.ClusterSnapshot{
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.1.0",
+		Components:    make(map[string]collector.ComponentState),
+	}
+	for name, componentType := range components {
+		sourceDefaults := make(map[string]interface{})
+		targetDefaults := make(map[string]interface{})
+		current := make(map[string]interface{})
+		for i := 0; i < 20; i++ {
+			param := fmt.Sprintf("section-%d.param-%d", i%3, i)
+			sourceDefaults[param] = map[string]interface{}{"value": float64(i), "type": "int"}
+			targetDefaults[param] = map[string]interface{}{"value": float64(i + i%2), "type": "int"}
+			current[param] = float64(i + i%3)
+		}
+		for _, kb := range []struct {
+			group, version string
+			defaults       map[string]interface{}
+		}{{"v7.5", "v7.5.0", sourceDefaults}, {"v8.1", "v8.1.0", targetDefaults}} {
+			writeKnowledgeBaseDefaults(t, kbPath, kb.group, kb.version, name, map[string]interface{}{
+				"component":       name,
+				"version":         kb.version,
+				"config_defaults": kb.defaults,
+			})
+		}
+		snapshot.Components[name] = collector.ComponentState{
+			Type:    componentType,
+			Version: "v7.5.0",
+			Config:  types.ConvertConfigToDefaults(current),
+		}
+	}
+	sourceKB, err := collector.LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+	targetKB, err := collector.LoadKnowledgeBase(kbPath, "v8.1.0")
+	require.NoError(t, err)
+
+	render := func() map[Format]string {
+		result, err := analyzer.NewAnalyzer(nil).Analyze(context.Background(), snapshot, "v7.5.0", "v8.1.0", sourceKB, targetKB)
+		require.NoError(t, err)
+		require.NotEmpty(t, result.CheckResults)
+		// Rule durations are the only expected difference between runs
+		for i := range result.RuleExecutions {
+			result.RuleExecutions[i].DurationMs = 0
+		}
+		reports := make(map[Format]string)
+		for _, format := range []Format{JSONFormat, MarkdownFormat} {
+			var out bytes.Buffer
+			require.NoError(t, NewGenerator().GenerateToWriter(result, &Options{Format: format}, &out))
+			reports[format] = normalizeReport(out.String())
+		}
+		return reports
+	}
+
+	first := render()
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, first, render())
+	}
+}
@@ -1,6 +1,8 @@
 package formats
 
 import (
+	"sort"
+
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
 	rules "github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
 )
@@ -13,6 +15,8 @@ const (
 	MarkdownFormat Format = "markdown"
 	HTMLFormat     Format = "html"
 	JSONFormat     Format = "json"
+	CSVFormat      Format = "csv"
+	JUnitFormat    Format = "junit"
 )
 
 // ReportType represents the type of parameter change
@@ -33,6 +37,8 @@ const (
 	ReportTypeInconsistency ReportType = "inconsistency"
 	// ReportTypeHighRisk - High-risk parameter check
 	ReportTypeHighRisk ReportType = "high_risk"
+	// ReportTypeGoldenDrift - Runtime value deviates from the golden configuration profile
+	ReportTypeGoldenDrift ReportType = "golden_drift"
 )
 
 // RiskLevel is re-exported from rules package for convenience
@@ -59,6 +65,10 @@ func GetReportType(check rules.CheckResult) ReportType {
 		return ReportTypeInconsistency
 	case "high_risk":
 		return ReportTypeHighRisk
+	case "golden_drift":
+		return ReportTypeGoldenDrift
+	case "new_in_target":
+		return ReportTypeNewParameter
 	case "upgrade_difference":
 		// For upgrade_difference, check if it's a default change or deprecated/new
 		if check.SourceDefault != nil && check.TargetDefault == nil {
@@ -80,6 +90,15 @@ func GetReportType(check rules.CheckResult) ReportType {
 	}
 }
 
+// SeverityLabel returns the severity of a CheckResult as shown in reports,
+// with the severity set by the rule if a severity profile changed it (e.g., "error (was warning)")
+func SeverityLabel(check rules.CheckResult) string {
+	if check.OriginalSeverity != "" && check.OriginalSeverity != check.Severity {
+		return check.Severity + " (was " + check.OriginalSeverity + ")"
+	}
+	return check.Severity
+}
+
 // Options represents report options
 type Options struct {
 	Format    Format
@@ -114,3 +133,14 @@ type ReportFooter interface {
 	// Render renders the footer content
 	Render(result *analyzer.AnalysisResult) (string, error)
 }
+
+// SortedComponentKeys returns the components of check results grouped by component, sorted,
+// so that sections render components deterministically
+func SortedComponentKeys(byComponent map[string][]rules.CheckResult) []string {
+	components := make([]string, 0, len(byComponent))
+	for component := range byComponent {
+		components = append(components, component)
+	}
+	sort.Strings(components)
+	return components
+}
@@ -0,0 +1,70 @@
+package csv
+
+import (
+	"encoding/csv"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// header is the first row of a CSV report, one column per field of a check result
+var header = []string{
+	"rule_id", "category", "component", "parameter_name", "param_type",
+	"severity", "original_severity", "risk_level", "message", "details", "suggestions",
+	"current_value", "source_default", "target_default", "forced_value",
+}
+
+// CSVFormatter handles CSV format rendering
+type CSVFormatter struct{}
+
+// NewCSVFormatter creates a new CSV formatter
+func NewCSVFormatter() *CSVFormatter {
+	return &CSVFormatter{}
+}
+
+// Generate generates a CSV format report: a header row and a row per check result
+// Values are given as shown in the other formats (see formats.FormatValue), suggestions are joined with "; "
+func (f *CSVFormatter) Generate(result *analyzer.AnalysisResult, options *formats.Options) (string, error) {
+	var sb strings.Builder
+	w := csv.NewWriter(&sb)
+	if err := w.Write(header); err != nil {
+		return "", err
+	}
+	for _, check := range result.CheckResults {
+		if err := w.Write(row(check)); err != nil {
+			return "", err
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// row returns the CSV row of a check result, in the order of header
+func row(check rules.CheckResult) []string {
+	var forcedValue string
+	if check.ForcedValue != nil || rules.IsForcedRemoval(check) {
+		forcedValue = formats.FormatForcedValue(check)
+	}
+	return []string{
+		check.RuleID, check.Category, check.Component, check.ParameterName, check.ParamType,
+		check.Severity, check.OriginalSeverity, string(check.RiskLevel), check.Message, check.Details,
+		strings.Join(check.Suggestions, "; "),
+		displayValue(check.ParameterName, check.CurrentValue),
+		displayValue(check.ParameterName, check.SourceDefault),
+		displayValue(check.ParameterName, check.TargetDefault),
+		forcedValue,
+	}
+}
+
+// displayValue formats a parameter value, or returns "" if it is not set
+func displayValue(param string, value interface{}) string {
+	if value == nil {
+		return ""
+	}
+	return formats.FormatValue(param, value)
+}
@@ -41,6 +41,41 @@ func (h *HTMLHeader) Render(result *analyzer.AnalysisResult) (string, error) {
     <p><strong>Source Version:</strong> {{.SourceVersion}}</p>
     <p><strong>Target Version:</strong> {{.TargetVersion}}</p>
     <p><strong>Generated At:</strong> {{.GeneratedAt}}</p>
+    {{if .MixedVersion}}
+    <div class="error">
+        <h2>⚠️ Mixed-Version Cluster Detected</h2>
+        <p>Not all instances run the cluster source version {{.MixedVersion.ClusterVersion}}.</p>
+        <table>
+            <tr><th>Component</th><th>Address</th><th>Version</th></tr>
+            {{range .MixedVersion.Nodes}}
+            <tr><td>{{.Component}}</td><td>{{.Address}}</td><td>{{if .Version}}{{.Version}}{{else}}unknown{{end}}</td></tr>
+            {{end}}
+        </table>
+        {{range $comp, $version := .MixedVersion.ComponentSourceVersions}}
+        <p>{{$comp}} user-modified parameters are compared against {{$version}} defaults</p>
+        {{end}}
+        {{range $comp, $versions := .MixedVersion.ComponentInstanceVersions}}
+        <p>{{$comp}} user-modified parameters are compared against the defaults of each instance version ({{range $i, $version := $versions}}{{if $i}}, {{end}}{{$version}}{{end}})</p>
+        {{end}}
+    </div>
+    {{end}}
+    {{if .ChangedSince}}
+    <div class="info">
+        <p><strong>Changed Since:</strong> {{.ChangedSince.Summary}}</p>
+        <p>Only findings about these parameters are reported ({{.ChangedSince.FindingsUnchanged}} findings about unchanged parameters omitted); forced changes are reported for all parameters.</p>
+    </div>
+    {{end}}
+    {{if and .Collection .Collection.Mode}}
+    <div class="info">
+        <p><strong>Collection Mode:</strong> {{.Collection.Mode}}</p>
+        {{if .Collection.Limitations}}
+        <ul>
+            {{range .Collection.Limitations}}<li>{{.}}</li>
+            {{end}}
+        </ul>
+        {{end}}
+    </div>
+    {{end}}
     
     <h2>Summary</h2>
     <table>
@@ -57,6 +92,15 @@ func (h *HTMLHeader) Render(result *analyzer.AnalysisResult) (string, error) {
         <tr><td>Parameters Skipped (source == target)</td><td>{{.ParametersSkipped}}</td></tr>
         <tr><td>Parameters Filtered (deployment-specific)</td><td>{{.ParametersFiltered}}</td></tr>
         {{end}}
+        {{if .ParametersMachineDerived}}
+        <tr><td>Parameters Skipped (machine-derived)</td><td>{{.ParametersMachineDerived}}</td></tr>
+        {{end}}
+        {{if .ParametersCollected}}
+        <tr><td>Parameters Collected</td><td>{{.ParametersCollected}}</td></tr>
+        {{end}}
+        {{if .FindingsIgnored}}
+        <tr><td>Findings Ignored (ignore patterns)</td><td>{{.FindingsIgnored}}</td></tr>
+        {{end}}
     </table>`
 
 	data := struct {
@@ -73,6 +117,12 @@ func (h *HTMLHeader) Render(result *analyzer.AnalysisResult) (string, error) {
 		ParametersWithDifferences int
 		ParametersSkipped         int
 		ParametersFiltered        int
+		ParametersMachineDerived  int
+		ParametersCollected       string
+		FindingsIgnored           int
+		MixedVersion              *analyzer.MixedVersionInfo
+		ChangedSince              *analyzer.ChangedSinceInfo
+		Collection                *analyzer.CollectionInfo
 	}{
 		SourceVersion:             result.SourceVersion,
 		TargetVersion:             result.TargetVersion,
@@ -87,6 +137,12 @@ func (h *HTMLHeader) Render(result *analyzer.AnalysisResult) (string, error) {
 		ParametersWithDifferences: result.Statistics.ParametersWithDifferences,
 		ParametersSkipped:         result.Statistics.ParametersSkipped,
 		ParametersFiltered:        result.Statistics.ParametersFiltered,
+		ParametersMachineDerived:  result.Statistics.ParametersMachineDerived,
+		ParametersCollected:       result.Statistics.ParametersCollectedSummary(),
+		FindingsIgnored:           result.Statistics.FindingsIgnored,
+		MixedVersion:              result.MixedVersion,
+		ChangedSince:              result.ChangedSince,
+		Collection:                result.Collection,
 	}
 
 	tmpl, err := template.New("header").Parse(headerTemplate)
@@ -20,7 +20,13 @@ type HTMLFormatter struct {
 func NewHTMLFormatter() *HTMLFormatter {
 	return &HTMLFormatter{
 		sections: []formats.ReportSection{
+			sections.NewCollectionLimitationsSection(),
 			sections.NewParameterCheckSection(),
+			sections.NewGoldenDriftSection(),
+			sections.NewNewParametersSection(),
+			sections.NewIgnoredFindingsSection(),
+			sections.NewTikvNodesSection(),
+			sections.NewRuleExecutionSection(),
 			// Future: Add plan check section here
 		},
 		header: NewHTMLHeader(),
@@ -49,10 +49,6 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 		if check.ParameterName == "tidb_config" {
 			continue
 		}
-		// Skip statistics CheckResults (they are handled separately)
-		if check.ParameterName == "__statistics__" {
-			continue
-		}
 
 		// Path parameters are already filtered in preprocessing stage
 		// No need to filter again here
@@ -201,7 +197,7 @@ function toggleSection(sectionId, buttonId) {
 			content.WriteString(fmt.Sprintf("<h3>%s Component</h3>\n", strings.ToUpper(compType)))
 
 			// Sort checks by parameter name
-			sort.Slice(compChecks, func(i, j int) bool {
+			sort.SliceStable(compChecks, func(i, j int) bool {
 				return compChecks[i].ParameterName < compChecks[j].ParameterName
 			})
 
@@ -249,20 +245,21 @@ function toggleSection(sectionId, buttonId) {
 				currentFormatted := formatValueWithHighlight(check.CurrentValue, check.SourceDefault, check.TargetDefault, "current")
 				sourceFormatted := formatValueWithHighlight(check.SourceDefault, check.SourceDefault, check.TargetDefault, "source")
 				targetFormatted := formatValueWithHighlight(check.TargetDefault, check.SourceDefault, check.TargetDefault, "target")
-				forcedFormatted := formatValue(check.ForcedValue)
+				forcedFormatted := formatForcedValue(check)
 
 				content.WriteString(fmt.Sprintf(
 					"<tr class=\"%s\"><td><code>%s</code><br/><small>%s</small></td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td>%s</td><td class=\"%s\">%s</td><td>%s</td><td>%s</td></tr>\n",
 					severityClass, check.ParameterName, reportTypeLabel, paramType,
 					currentFormatted, sourceFormatted, targetFormatted, forcedFormatted,
-					severityClass, check.Severity, check.Message, check.Details))
+					severityClass, formats.SeverityLabel(check), check.Message, check.Details))
 			}
 
 			content.WriteString("</table>\n")
 		}
 
 		// Display unknown components if any
-		for compType, compChecks := range byComponent {
+		for _, compType := range formats.SortedComponentKeys(byComponent) {
+			compChecks := byComponent[compType]
 			found := false
 			for _, knownComp := range componentOrder {
 				if compType == knownComp {
@@ -292,7 +289,7 @@ function toggleSection(sectionId, buttonId) {
 						"<tr class=\"%s\"><td><code>%s</code></td><td>%s</td><td>%v</td><td class=\"%s\">%s</td><td>%s</td></tr>\n",
 						severityClass, check.ParameterName, paramType,
 						formatValue(check.CurrentValue),
-						severityClass, check.Severity, check.Message))
+						severityClass, formats.SeverityLabel(check), check.Message))
 				}
 				content.WriteString("</table>\n")
 			}
@@ -353,7 +350,7 @@ function toggleDeprecated() {
 			content.WriteString(fmt.Sprintf("<h3>%s Component</h3>\n", strings.ToUpper(compType)))
 
 			// Sort checks by parameter name
-			sort.Slice(compChecks, func(i, j int) bool {
+			sort.SliceStable(compChecks, func(i, j int) bool {
 				return compChecks[i].ParameterName < compChecks[j].ParameterName
 			})
 
@@ -385,14 +382,15 @@ function toggleDeprecated() {
 					"<tr class=\"%s\"><td><code>%s</code><br/><small>🗑️ Deprecated</small></td><td>%s</td><td>%s</td><td>%s</td><td class=\"%s\">%s</td><td>%s</td><td>%s</td></tr>\n",
 					severityClass, check.ParameterName, paramType,
 					currentFormatted, sourceFormatted,
-					severityClass, check.Severity, check.Message, check.Details))
+					severityClass, formats.SeverityLabel(check), check.Message, check.Details))
 			}
 
 			content.WriteString("</table>\n")
 		}
 
 		// Display unknown components if any
-		for compType, compChecks := range deprecatedByComponent {
+		for _, compType := range formats.SortedComponentKeys(deprecatedByComponent) {
+			compChecks := deprecatedByComponent[compType]
 			found := false
 			for _, knownComp := range componentOrder {
 				if compType == knownComp {
@@ -424,7 +422,7 @@ function toggleDeprecated() {
 						"<tr class=\"%s\"><td><code>%s</code><br/><small>🗑️ Deprecated</small></td><td>%s</td><td>%s</td><td>%s</td><td class=\"%s\">%s</td><td>%s</td><td>%s</td></tr>\n",
 						severityClass, check.ParameterName, paramType,
 						currentFormatted, sourceFormatted,
-						severityClass, check.Severity, check.Message, check.Details))
+						severityClass, formats.SeverityLabel(check), check.Message, check.Details))
 				}
 				content.WriteString("</table>\n")
 			}
@@ -445,6 +443,15 @@ func formatValue(v interface{}) string {
 	return rules.FormatValue(v)
 }
 
+// formatForcedValue formats the forced value of a check result
+// Forced changes that delete the variable are shown as "variable removed"
+func formatForcedValue(check rules.CheckResult) string {
+	if check.ForcedValue == nil {
+		return "<em>N/A</em>"
+	}
+	return rules.FormatForcedValue(check)
+}
+
 // formatValueWithHighlight formats a value with highlighting for differences
 // role: "current", "source", or "target"
 func formatValueWithHighlight(value, sourceDefault, targetDefault interface{}, role string) string {
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/buildinfo"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
 )
 
@@ -17,11 +19,65 @@ func NewJSONFormatter() *JSONFormatter {
 
 // Generate generates a complete JSON format report
 // JSON format doesn't need header/footer/sections, just serialize the result
+// The build information of the tool is added to the metadata section, and the values of the findings
+// are given as shown in the other formats next to the raw values (display)
 func (f *JSONFormatter) Generate(result *analyzer.AnalysisResult, options *formats.Options) (string, error) {
-	data, err := json.MarshalIndent(result, "", "  ")
+	report := *result
+	if report.Metadata == nil {
+		report.Metadata = &analyzer.ReportMetadata{
+			ToolVersion: buildinfo.Version,
+			GitCommit:   buildinfo.Commit,
+			BuildTime:   buildinfo.BuildTime,
+		}
+	}
+
+	report.CheckResults = withDisplayValues(report.CheckResults)
+	report.IgnoredResults = withDisplayValues(report.IgnoredResults)
+	if report.TikvInconsistencies != nil {
+		inconsistencies := make(map[string][]analyzer.InconsistentNode, len(report.TikvInconsistencies))
+		for param, nodes := range report.TikvInconsistencies {
+			displayed := append([]analyzer.InconsistentNode(nil), nodes...)
+			for i := range displayed {
+				if !displayed[i].Missing {
+					displayed[i].Display = formats.FormatValue(param, displayed[i].Value)
+				}
+			}
+			inconsistencies[param] = displayed
+		}
+		report.TikvInconsistencies = inconsistencies
+	}
+
+	data, err := json.MarshalIndent(&report, "", "  ")
 	if err != nil {
 		return "", err
 	}
 	return string(data), nil
 }
 
+// withDisplayValues returns a copy of the check results with their display values set
+func withDisplayValues(checks []rules.CheckResult) []rules.CheckResult {
+	if checks == nil {
+		return nil
+	}
+	displayed := make([]rules.CheckResult, len(checks))
+	for i, check := range checks {
+		display := make(map[string]string)
+		for field, value := range map[string]interface{}{
+			"current_value":  check.CurrentValue,
+			"source_default": check.SourceDefault,
+			"target_default": check.TargetDefault,
+		} {
+			if value != nil {
+				display[field] = formats.FormatValue(check.ParameterName, value)
+			}
+		}
+		if check.ForcedValue != nil || rules.IsForcedRemoval(check) {
+			display["forced_value"] = formats.FormatForcedValue(check)
+		}
+		if len(display) > 0 {
+			check.Display = display
+		}
+		displayed[i] = check
+	}
+	return displayed
+}
@@ -0,0 +1,126 @@
+package junit
+
+import (
+	"encoding/xml"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// testSuites is the root element of a JUnit XML report
+type testSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Suites   []testSuite `xml:"testsuite"`
+}
+
+// testSuite groups the findings of a rule
+type testSuite struct {
+	Name       string     `xml:"name,attr"`
+	Tests      int        `xml:"tests,attr"`
+	Failures   int        `xml:"failures,attr"`
+	Properties []property `xml:"properties>property"`
+	Cases      []testCase `xml:"testcase"`
+}
+
+type property struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// testCase is a finding, failed if its severity is error or critical
+type testCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Failure   *failure `xml:"failure,omitempty"`
+	SystemOut *output  `xml:"system-out,omitempty"`
+}
+
+// failure is the failure of a test case, Type is the severity of the finding
+type failure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// output is written as CDATA, so that the multi-line details of the findings stay readable
+type output struct {
+	Text string `xml:",cdata"`
+}
+
+// JUnitFormatter handles JUnit XML format rendering
+type JUnitFormatter struct{}
+
+// NewJUnitFormatter creates a new JUnit formatter
+func NewJUnitFormatter() *JUnitFormatter {
+	return &JUnitFormatter{}
+}
+
+// Generate generates a JUnit XML format report, so that CI systems show the findings as test results
+// Each rule is a test suite and each of its findings a test case, which fails if the finding
+// is high risk (error or critical severity); the other findings are passed test cases with their details as output
+func (f *JUnitFormatter) Generate(result *analyzer.AnalysisResult, options *formats.Options) (string, error) {
+	report := testSuites{Name: "tidb-upgrade-precheck"}
+	suiteIndex := make(map[string]int)
+	for _, check := range result.CheckResults {
+		i, ok := suiteIndex[check.RuleID]
+		if !ok {
+			i = len(report.Suites)
+			suiteIndex[check.RuleID] = i
+			report.Suites = append(report.Suites, testSuite{
+				Name: check.RuleID,
+				Properties: []property{
+					{Name: "source_version", Value: result.SourceVersion},
+					{Name: "target_version", Value: result.TargetVersion},
+				},
+			})
+		}
+		suite := &report.Suites[i]
+
+		tc := testCase{Name: caseName(check), ClassName: check.RuleID}
+		if rules.GetRiskLevel(check.Severity) == rules.RiskLevelHigh {
+			tc.Failure = &failure{Message: check.Message, Type: check.Severity, Text: caseOutput(check)}
+			suite.Failures++
+			report.Failures++
+		} else {
+			tc.SystemOut = &output{Text: caseOutput(check)}
+		}
+		suite.Cases = append(suite.Cases, tc)
+		suite.Tests++
+		report.Tests++
+	}
+
+	data, err := xml.MarshalIndent(&report, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return xml.Header + string(data) + "\n", nil
+}
+
+// caseName returns the test case name of a finding, "<component>/<parameter>" or its message
+// for findings that are not about a parameter
+func caseName(check rules.CheckResult) string {
+	if check.ParameterName == "" {
+		return check.Message
+	}
+	if check.Component == "" {
+		return check.ParameterName
+	}
+	return check.Component + "/" + check.ParameterName
+}
+
+// caseOutput returns the description of a finding: its severity, message, details and suggestions
+func caseOutput(check rules.CheckResult) string {
+	lines := []string{"Severity: " + formats.SeverityLabel(check), check.Message}
+	if check.Details != "" {
+		lines = append(lines, check.Details)
+	}
+	for _, suggestion := range check.Suggestions {
+		lines = append(lines, "Suggestion: "+suggestion)
+	}
+	return strings.Join(lines, "\n")
+}
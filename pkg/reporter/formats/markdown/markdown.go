@@ -20,7 +20,13 @@ type MarkdownFormatter struct {
 func NewMarkdownFormatter() *MarkdownFormatter {
 	return &MarkdownFormatter{
 		sections: []formats.ReportSection{
+			sections.NewCollectionLimitationsSection(),
 			sections.NewParameterCheckSection(),
+			sections.NewGoldenDriftSection(),
+			sections.NewNewParametersSection(),
+			sections.NewIgnoredFindingsSection(),
+			sections.NewTikvNodesSection(),
+			sections.NewRuleExecutionSection(),
 			// Future: Add plan check section here
 		},
 		header: NewMarkdownHeader(),
@@ -47,10 +47,6 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 		if check.ParameterName == "tidb_config" {
 			continue
 		}
-		// Skip statistics CheckResults (they are handled separately)
-		if check.ParameterName == "__statistics__" {
-			continue
-		}
 
 		// Path parameters are already filtered in preprocessing stage
 		// No need to filter again here
@@ -146,7 +142,7 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 			content.WriteString(fmt.Sprintf("### %s Component\n\n", strings.ToUpper(compType)))
 
 			// Sort checks by parameter name
-			sort.Slice(compChecks, func(i, j int) bool {
+			sort.SliceStable(compChecks, func(i, j int) bool {
 				return compChecks[i].ParameterName < compChecks[j].ParameterName
 			})
 
@@ -185,20 +181,21 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 				currentFormatted := formatValueWithHighlight(check.CurrentValue, check.SourceDefault, check.TargetDefault, "current")
 				sourceFormatted := formatValueWithHighlight(check.SourceDefault, check.SourceDefault, check.TargetDefault, "source")
 				targetFormatted := formatValueWithHighlight(check.TargetDefault, check.SourceDefault, check.TargetDefault, "target")
-				forcedFormatted := formatValue(check.ForcedValue)
+				forcedFormatted := formatForcedValue(check)
 
 				content.WriteString(fmt.Sprintf(
 					"| `%s`<br/>%s | %s | %s | %s | %s | %s | %s | %s |\n",
 					check.ParameterName, reportTypeLabel, paramType,
 					currentFormatted, sourceFormatted, targetFormatted, forcedFormatted,
-					check.Severity, check.Message))
+					formats.SeverityLabel(check), check.Message))
 			}
 
 			content.WriteString("\n")
 		}
 
 		// Display unknown components if any
-		for compType, compChecks := range byComponent {
+		for _, compType := range formats.SortedComponentKeys(byComponent) {
+			compChecks := byComponent[compType]
 			found := false
 			for _, knownComp := range componentOrder {
 				if compType == knownComp {
@@ -219,7 +216,7 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 						"| `%s` | %s | %v | %s | %s |\n",
 						check.ParameterName, paramType,
 						formatValue(check.CurrentValue),
-						check.Severity, check.Message))
+						formats.SeverityLabel(check), check.Message))
 				}
 				content.WriteString("\n")
 			}
@@ -264,6 +261,15 @@ func formatValue(v interface{}) string {
 	return str
 }
 
+// formatForcedValue formats the forced value of a check result
+// Forced changes that delete the variable are shown as "variable removed"
+func formatForcedValue(check rules.CheckResult) string {
+	if check.ForcedValue == nil {
+		return "N/A"
+	}
+	return rules.FormatForcedValue(check)
+}
+
 // formatValueWithHighlight formats a value with highlighting for differences
 // role: "current", "source", or "target"
 func formatValueWithHighlight(value, sourceDefault, targetDefault interface{}, role string) string {
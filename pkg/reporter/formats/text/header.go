@@ -27,6 +27,40 @@ func (h *TextHeader) Render(result *analyzer.AnalysisResult) (string, error) {
 	content.WriteString(fmt.Sprintf("Target Version: %s\n", result.TargetVersion))
 	content.WriteString(fmt.Sprintf("Generated At: %s\n\n", time.Now().Format("2006-01-02 15:04:05")))
 
+	// Mixed-version warning (e.g., a previous upgrade was only partially completed)
+	if result.MixedVersion != nil {
+		content.WriteString("⚠️  WARNING: MIXED-VERSION CLUSTER DETECTED\n")
+		content.WriteString(fmt.Sprintf("Not all instances run the cluster source version %s:\n", result.MixedVersion.ClusterVersion))
+		for _, node := range result.MixedVersion.Nodes {
+			content.WriteString(fmt.Sprintf("  - %-8s %-24s %s\n", node.Component, node.Address, displayNodeVersion(node.Version)))
+		}
+		for _, comp := range result.MixedVersion.SortedComponentSourceVersions() {
+			version := result.MixedVersion.ComponentSourceVersions[comp]
+			content.WriteString(fmt.Sprintf("  Note: %s user-modified parameters are compared against %s defaults\n", comp, version))
+		}
+		for _, comp := range result.MixedVersion.SortedComponentInstanceVersions() {
+			versions := strings.Join(result.MixedVersion.ComponentInstanceVersions[comp], ", ")
+			content.WriteString(fmt.Sprintf("  Note: %s user-modified parameters are compared against the defaults of each instance version (%s)\n", comp, versions))
+		}
+		content.WriteString("\n")
+	}
+
+	// Restriction to the parameters changed since a previous snapshot (--changed-since)
+	if result.ChangedSince != nil {
+		content.WriteString(fmt.Sprintf("Changed Since: %s\n", result.ChangedSince.Summary()))
+		content.WriteString(fmt.Sprintf("  Only findings about these parameters are reported (%d findings about unchanged parameters omitted)\n", result.ChangedSince.FindingsUnchanged))
+		content.WriteString("  Forced changes are reported for all parameters\n\n")
+	}
+
+	// Collection mode of a partial collection (--sql-only)
+	if result.Collection != nil && result.Collection.Mode != "" {
+		content.WriteString(fmt.Sprintf("Collection Mode: %s\n", result.Collection.Mode))
+		for _, limitation := range result.Collection.Limitations {
+			content.WriteString(fmt.Sprintf("  - %s\n", limitation))
+		}
+		content.WriteString("\n")
+	}
+
 	// Summary
 	content.WriteString("Summary:\n")
 	content.WriteString(fmt.Sprintf("  Modified Parameters: %d\n", countModifiedParams(result.ModifiedParams)))
@@ -41,12 +75,28 @@ func (h *TextHeader) Render(result *analyzer.AnalysisResult) (string, error) {
 		content.WriteString(fmt.Sprintf("  Parameters Skipped (source == target): %d\n", result.Statistics.ParametersSkipped))
 		content.WriteString(fmt.Sprintf("  Parameters Filtered (deployment-specific): %d\n", result.Statistics.ParametersFiltered))
 	}
+	if result.Statistics.ParametersMachineDerived > 0 {
+		content.WriteString(fmt.Sprintf("  Parameters Skipped (machine-derived): %d\n", result.Statistics.ParametersMachineDerived))
+	}
+	if collected := result.Statistics.ParametersCollectedSummary(); collected != "" {
+		content.WriteString(fmt.Sprintf("  Parameters Collected: %s\n", collected))
+	}
+	if result.Statistics.FindingsIgnored > 0 {
+		content.WriteString(fmt.Sprintf("  Findings Ignored (ignore patterns): %d\n", result.Statistics.FindingsIgnored))
+	}
 	content.WriteString("\n")
 
 	return content.String(), nil
 }
 
 // Helper functions
+func displayNodeVersion(version string) string {
+	if version == "" {
+		return "unknown"
+	}
+	return version
+}
+
 func countModifiedParams(modifiedParams map[string]map[string]analyzer.ModifiedParamInfo) int {
 	count := 0
 	for _, params := range modifiedParams {
@@ -47,10 +47,6 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 		if check.ParameterName == "tidb_config" {
 			continue
 		}
-		// Skip statistics CheckResults (they are handled separately)
-		if check.ParameterName == "__statistics__" {
-			continue
-		}
 
 		// Path parameters are already filtered in preprocessing stage
 		// No need to filter again here
@@ -146,7 +142,7 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 			content.WriteString(fmt.Sprintf("   [%s Component]\n", strings.ToUpper(compType)))
 
 			// Sort checks by parameter name
-			sort.Slice(compChecks, func(i, j int) bool {
+			sort.SliceStable(compChecks, func(i, j int) bool {
 				return compChecks[i].ParameterName < compChecks[j].ParameterName
 			})
 
@@ -178,7 +174,7 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 				}
 
 				// Format as checklist item
-				content.WriteString(fmt.Sprintf("   - [%s] %s %s (%s)\n", check.Severity, reportTypeLabel, check.ParameterName, paramType))
+				content.WriteString(fmt.Sprintf("   - [%s] %s %s (%s)\n", formats.SeverityLabel(check), reportTypeLabel, check.ParameterName, paramType))
 
 				// If Details contains formatted content, use it; otherwise show individual values
 				if check.Details != "" && strings.Contains(check.Details, "Current Value:") {
@@ -201,7 +197,7 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 						content.WriteString(fmt.Sprintf("     Target Default: %s\n", formatValueForDisplay(check.TargetDefault)))
 					}
 					if check.ForcedValue != nil {
-						content.WriteString(fmt.Sprintf("     Forced To: %s\n", formatValueForDisplay(check.ForcedValue)))
+						content.WriteString(fmt.Sprintf("     Forced To: %s\n", formatForcedValueForDisplay(check)))
 					}
 					if check.Details != "" {
 						detailsLines := strings.Split(check.Details, "\n")
@@ -227,7 +223,8 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 		}
 
 		// Display unknown components if any
-		for compType, compChecks := range byComponent {
+		for _, compType := range formats.SortedComponentKeys(byComponent) {
+			compChecks := byComponent[compType]
 			found := false
 			for _, knownComp := range componentOrder {
 				if compType == knownComp {
@@ -242,7 +239,7 @@ func (s *ParameterCheckSection) Render(format formats.Format, result *analyzer.A
 					if paramType == "" {
 						paramType = "config"
 					}
-					content.WriteString(fmt.Sprintf("   - [%s] %s (%s): %s\n", check.Severity, check.ParameterName, paramType, check.Message))
+					content.WriteString(fmt.Sprintf("   - [%s] %s (%s): %s\n", formats.SeverityLabel(check), check.ParameterName, paramType, check.Message))
 				}
 			}
 		}
@@ -260,6 +257,15 @@ func formatValue(v interface{}) string {
 	return rules.FormatValue(v)
 }
 
+// formatForcedValueForDisplay formats the forced value of a check result
+// Forced changes that delete the variable are shown as "variable removed"
+func formatForcedValueForDisplay(check rules.CheckResult) string {
+	if rules.IsForcedRemoval(check) {
+		return rules.ForcedRemovalDisplay
+	}
+	return formatValueForDisplay(check.ForcedValue)
+}
+
 // formatValueForDisplay formats a value for clear display, handling complex types
 func formatValueForDisplay(v interface{}) string {
 	if v == nil {
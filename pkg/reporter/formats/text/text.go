@@ -20,7 +20,13 @@ type TextFormatter struct {
 func NewTextFormatter() *TextFormatter {
 	return &TextFormatter{
 		sections: []formats.ReportSection{
+			sections.NewCollectionLimitationsSection(),
 			sections.NewParameterCheckSection(),
+			sections.NewGoldenDriftSection(),
+			sections.NewNewParametersSection(),
+			sections.NewIgnoredFindingsSection(),
+			sections.NewTikvNodesSection(),
+			sections.NewRuleExecutionSection(),
 			// Future: Add plan check section here
 		},
 		header: NewTextHeader(),
@@ -0,0 +1,219 @@
+package formats
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	rules "github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+// unitScale is a unit of the raw numeric values of parameters
+type unitScale struct {
+	// size is true for sizes, false for durations
+	size bool
+	// scale is the bytes (sizes) or nanoseconds (durations) of one unit
+	scale float64
+}
+
+var unitScales = map[string]unitScale{
+	"B":   {size: true, scale: 1},
+	"MiB": {size: true, scale: 1 << 20},
+	"ns":  {scale: 1},
+	"ms":  {scale: 1e6},
+	"s":   {scale: 1e9},
+}
+
+// parameterUnits maps the parameters whose values are plain numbers to the unit of these numbers
+// System variables are listed without the "sysvar:" prefix. Sizes and durations written with their unit
+// (e.g., TiKV's "1GiB", PD's "30m") need no entry
+var parameterUnits = map[string]string{
+	// Sizes in bytes
+	"max_allowed_packet":                        "B",
+	"mem-quota-query":                           "B",
+	"performance.server-memory-quota":           "B",
+	"performance.txn-entry-size-limit":          "B",
+	"performance.txn-total-size-limit":          "B",
+	"status.grpc-initial-window-size":           "B",
+	"status.grpc-max-send-msg-size":             "B",
+	"tidb_ddl_disk_quota":                       "B",
+	"tidb_instance_plan_cache_max_size":         "B",
+	"tidb_mem_quota_analyze":                    "B",
+	"tidb_mem_quota_apply_cache":                "B",
+	"tidb_mem_quota_binding_cache":              "B",
+	"tidb_mem_quota_query":                      "B",
+	"tidb_opt_range_max_size":                   "B",
+	"tidb_schema_cache_size":                    "B",
+	"tidb_server_memory_limit_sess_min_size":    "B",
+	"tidb_stats_cache_mem_quota":                "B",
+	"tidb_tmp_table_max_size":                   "B",
+	"tidb_txn_entry_size_limit":                 "B",
+	"tikv-client.grpc-initial-conn-window-size": "B",
+	"tikv-client.grpc-initial-window-size":      "B",
+	"tikv-client.ttl-refreshed-txn-size":        "B",
+	"tmp-storage-quota":                         "B",
+
+	// Sizes in MiB
+	"instance.tidb_stmt_summary_file_max_size": "MiB",
+	"log.file.max-size":                        "MiB",
+	"schedule.max-merge-region-size":           "MiB",
+	"tidb_stmt_summary_file_max_size":          "MiB",
+	"tikv-client.copr-cache.capacity-mb":       "MiB",
+
+	// Durations in nanoseconds
+	"tikv-client.async-commit.allowed-clock-drift": "ns",
+	"tikv-client.async-commit.safe-window":         "ns",
+	"tikv-client.copr-req-timeout":                 "ns",
+	"tikv-client.max-batch-wait-time":              "ns",
+
+	// Durations in milliseconds
+	"instance.ddl_slow_threshold":             "ms",
+	"instance.tidb_slow_log_threshold":        "ms",
+	"max_execution_time":                      "ms",
+	"performance.max-txn-ttl":                 "ms",
+	"tidb_load_binding_timeout":               "ms",
+	"tidb_low_resolution_tso_update_interval": "ms",
+	"tidb_slow_log_threshold":                 "ms",
+	"tidb_stats_load_sync_wait":               "ms",
+
+	// Durations in seconds
+	"graceful-wait-before-shutdown":                "s",
+	"innodb_lock_wait_timeout":                     "s",
+	"instance.tidb_expensive_query_time_threshold": "s",
+	"instance.tidb_expensive_txn_time_threshold":   "s",
+	"interactive_timeout":                          "s",
+	"net_read_timeout":                             "s",
+	"net_write_timeout":                            "s",
+	"pd-client.pd-server-timeout":                  "s",
+	"status.grpc-keepalive-time":                   "s",
+	"status.grpc-keepalive-timeout":                "s",
+	"tidb_expensive_query_time_threshold":          "s",
+	"tidb_expensive_txn_time_threshold":            "s",
+	"tidb_gc_max_wait_time":                        "s",
+	"tidb_idle_transaction_timeout":                "s",
+	"tidb_max_auto_analyze_time":                   "s",
+	"tidb_stmt_summary_refresh_interval":           "s",
+	"tikv-client.grpc-keepalive-time":              "s",
+	"tikv-client.grpc-keepalive-timeout":           "s",
+	"tikv-client.region-cache-ttl":                 "s",
+	"wait_timeout":                                 "s",
+}
+
+// FormatValue formats the value of a parameter as shown in every report format, so that the same value
+// reads the same in the text, markdown, HTML, JSON, CSV and JUnit reports:
+//   - sizes in the largest binary unit with 1 decimal (e.g., "1.0 GiB", "384.0 MiB", "512 B")
+//   - durations in the largest unit they are a whole number of (e.g., "30m", "90s", "1500ms")
+//
+// Strings with a unit (e.g., "1GiB", "1800s") are sizes or durations; plain positive numbers are
+// if the parameter is listed in parameterUnits. Other values are formatted by rules.FormatValue
+func FormatValue(param string, value interface{}) string {
+	if s, ok := value.(string); ok {
+		if display, ok := formatUnitString(s); ok {
+			return display
+		}
+	}
+	if unit, ok := parameterUnits[strings.TrimPrefix(param, "sysvar:")]; ok {
+		if num, ok := positiveNumber(value); ok {
+			scale := unitScales[unit]
+			if scale.size {
+				return formatBytes(num * scale.scale)
+			}
+			return formatDuration(num * scale.scale)
+		}
+	}
+	return rules.FormatValue(value)
+}
+
+// FormatForcedValue formats the forced value of a check result like FormatValue
+// Forced changes that delete the variable are shown as "variable removed"
+func FormatForcedValue(check rules.CheckResult) string {
+	if rules.IsForcedRemoval(check) {
+		return rules.ForcedRemovalDisplay
+	}
+	return FormatValue(check.ParameterName, check.ForcedValue)
+}
+
+// formatUnitString formats a size (ending with B, e.g., "64MiB", "1GB") or a duration (e.g., "10m", "1h30m")
+// Returns false for other strings, plain numbers included
+func formatUnitString(s string) (string, bool) {
+	trimmed := strings.ToLower(strings.TrimSpace(s))
+	if _, err := strconv.ParseFloat(trimmed, 64); err != nil && trimmed != "" {
+		if strings.HasSuffix(trimmed, "b") {
+			if bytes, ok := types.ParseSize(trimmed); ok {
+				return formatBytes(bytes), true
+			}
+			return "", false
+		}
+		if ns, ok := types.ParseDuration(trimmed); ok {
+			return formatDuration(ns), true
+		}
+	}
+	return "", false
+}
+
+// positiveNumber converts a number or a numeric string to float64, if it is positive
+// Zero and negative values usually mean disabled or unlimited, they are shown as is
+func positiveNumber(value interface{}) (float64, bool) {
+	var num float64
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num = float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		num = v.Float()
+	case reflect.String:
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(v.String()), 64)
+		if err != nil {
+			return 0, false
+		}
+		num = parsed
+	default:
+		return 0, false
+	}
+	return num, num > 0
+}
+
+var sizeUnitNames = []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+
+// formatBytes formats a size in the largest binary unit it reaches, with 1 decimal (bytes without)
+func formatBytes(bytes float64) string {
+	i := 0
+	for bytes >= 1024 && i < len(sizeUnitNames)-1 {
+		bytes /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f B", bytes)
+	}
+	return fmt.Sprintf("%.1f %s", bytes, sizeUnitNames[i])
+}
+
+var durationUnitNames = []struct {
+	name string
+	ns   int64
+}{
+	{"h", 3600 * 1e9},
+	{"m", 60 * 1e9},
+	{"s", 1e9},
+	{"ms", 1e6},
+	{"us", 1e3},
+	{"ns", 1},
+}
+
+// formatDuration formats a duration in the largest unit it is a whole number of (e.g., "30m", "90s")
+func formatDuration(ns float64) string {
+	rounded := int64(math.Round(ns))
+	if rounded == 0 {
+		return "0s"
+	}
+	for _, unit := range durationUnitNames {
+		if rounded%unit.ns == 0 {
+			return fmt.Sprintf("%d%s", rounded/unit.ns, unit.name)
+		}
+	}
+	return fmt.Sprintf("%dns", rounded)
+}
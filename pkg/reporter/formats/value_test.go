@@ -0,0 +1,52 @@
+package formats
+
+import (
+	"testing"
+
+	rules "github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		param string
+		value interface{}
+		want  string
+	}{
+		{"nanoseconds", "tikv-client.copr-req-timeout", float64(1800000000000), "30m"},
+		{"duration string", "raftstore.raft-log-gc-tick-interval", "1800s", "30m"},
+		{"composite duration", "gc.run-interval", "1h30m", "90m"},
+		{"fractional duration", "raftstore.pd-heartbeat-tick-interval", "1.5s", "1500ms"},
+		{"zero duration", "raftstore.raft-log-gc-tick-interval", "0s", "0s"},
+		{"milliseconds", "performance.max-txn-ttl", float64(3600000), "1h"},
+		{"seconds as string", "sysvar:wait_timeout", "28800", "8h"},
+		{"bytes", "tidb_mem_quota_query", "1073741824", "1.0 GiB"},
+		{"bytes below a KiB", "tidb_mem_quota_query", 512, "512 B"},
+		{"MiB", "log.file.max-size", 300, "300.0 MiB"},
+		{"size string", "storage.block-cache.capacity", "1536MiB", "1.5 GiB"},
+		{"decimal size string", "coprocessor.region-split-size", "96MB", "96.0 MiB"},
+		{"unlimited is unchanged", "tidb_mem_quota_analyze", -1, "-1"},
+		{"disabled is unchanged", "max_execution_time", 0, "0"},
+		{"unclassified number", "token-limit", 1000, "1000"},
+		{"unclassified size-like name", "schedule.leader-schedule-limit", 4, "4"},
+		{"plain string", "storage.engine", "raft-kv", `"raft-kv"`},
+		{"percentage", "tidb_server_memory_limit", "80%", `"80%"`},
+		{"bool", "enable-telemetry", false, "false"},
+		{"nil", "tidb_mem_quota_query", nil, "<nil>"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, FormatValue(tt.param, tt.value))
+		})
+	}
+}
+
+func TestFormatForcedValue(t *testing.T) {
+	assert.Equal(t, "1.0 GiB", FormatForcedValue(rules.CheckResult{ParameterName: "tidb_mem_quota_query", ForcedValue: "1073741824"}))
+	assert.Equal(t, rules.ForcedRemovalDisplay, FormatForcedValue(rules.CheckResult{
+		ParameterName: "tidb_mem_quota_query",
+		ForcedValue:   "",
+		Metadata:      map[string]interface{}{"forced_removal": true},
+	}))
+}
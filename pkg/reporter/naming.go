@@ -0,0 +1,96 @@
+package reporter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+)
+
+// DefaultOutputName is the report file name template (without extension) used when Options.OutputName is not set
+const DefaultOutputName = "upgrade_precheck_report_{timestamp}"
+
+// LatestName is the name (without extension) of the link to the newest report of each format (see Options.LatestSymlink)
+const LatestName = "latest"
+
+// unknownCluster replaces {cluster} in report file names when the PD cluster ID was not collected
+const unknownCluster = "unknown"
+
+// outputNameVariables are the variables of report file name templates (see RenderOutputName)
+var outputNameVariables = []string{"timestamp", "source", "target", "format", "cluster"}
+
+// outputNameVariablePattern matches the variables of a report file name template
+var outputNameVariablePattern = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// ValidateOutputName checks a report file name template: only the variables of RenderOutputName are allowed,
+// and the rendered name must stay in the output directory
+func ValidateOutputName(template string) error {
+	if strings.TrimSpace(template) == "" {
+		return fmt.Errorf("empty report file name")
+	}
+	if strings.ContainsAny(template, `/\`) {
+		return fmt.Errorf("report file name %q must not contain a path separator", template)
+	}
+	for _, match := range outputNameVariablePattern.FindAllStringSubmatch(template, -1) {
+		if !isOutputNameVariable(match[1]) {
+			return fmt.Errorf("unknown variable {%s} in report file name %q (supported: {%s})",
+				match[1], template, strings.Join(outputNameVariables, "}, {"))
+		}
+	}
+	return nil
+}
+
+func isOutputNameVariable(name string) bool {
+	for _, variable := range outputNameVariables {
+		if variable == name {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderOutputName renders a report file name template (without extension) for a report of result in format
+// generated at now. Supported variables:
+//   - {timestamp}: generation time (20060102_150405)
+//   - {source}, {target}: source and target versions
+//   - {format}: report format (text, markdown, html, json, csv, junit)
+//   - {cluster}: PD cluster ID, "unknown" if it was not collected
+func RenderOutputName(template string, result *analyzer.AnalysisResult, format Format, now time.Time) (string, error) {
+	if err := ValidateOutputName(template); err != nil {
+		return "", err
+	}
+	cluster := result.ClusterID
+	if cluster == "" {
+		cluster = unknownCluster
+	}
+	values := map[string]string{
+		"timestamp": now.Format("20060102_150405"),
+		"source":    result.SourceVersion,
+		"target":    result.TargetVersion,
+		"format":    string(format),
+		"cluster":   cluster,
+	}
+	name := outputNameVariablePattern.ReplaceAllStringFunc(template, func(variable string) string {
+		// Values come from the cluster: keep the name in the output directory
+		return strings.NewReplacer("/", "_", `\`, "_").Replace(values[variable[1:len(variable)-1]])
+	})
+	return name, nil
+}
+
+// uniqueArtifactName returns name.ext, or name_<n>.ext with the smallest n not used in the destination of checker
+// if name.ext already exists there
+func uniqueArtifactName(checker ArtifactChecker, name, ext string) (string, error) {
+	candidate := fmt.Sprintf("%s.%s", name, ext)
+	for n := 1; ; n++ {
+		exists, err := checker.ArtifactExists(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s_%d.%s", name, n, ext)
+	}
+}
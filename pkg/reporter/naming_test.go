@@ -0,0 +1,109 @@
+package reporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderOutputName(t *testing.T) {
+	result := newOutputTestResult()
+	now := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+
+	name, err := RenderOutputName("precheck_{cluster}_{source}_to_{target}_{format}_{timestamp}", result, JSONFormat, now)
+	require.NoError(t, err)
+	assert.Equal(t, "precheck_unknown_v7.5.0_to_v8.5.0_json_20240506_070809", name)
+
+	result.ClusterID = "7283456789012345678"
+	name, err = RenderOutputName("{cluster}", result, TextFormat, now)
+	require.NoError(t, err)
+	assert.Equal(t, "7283456789012345678", name)
+
+	name, err = RenderOutputName(DefaultOutputName, result, TextFormat, now)
+	require.NoError(t, err)
+	assert.Equal(t, "upgrade_precheck_report_20240506_070809", name)
+
+	// Values from the cluster cannot escape the output directory
+	result.SourceVersion = "../v7.5.0"
+	name, err = RenderOutputName("{source}", result, TextFormat, now)
+	require.NoError(t, err)
+	assert.Equal(t, ".._v7.5.0", name)
+
+	for template, wantErr := range map[string]string{
+		"report_{date}":    "unknown variable {date}",
+		"reports/{target}": "path separator",
+		" ":                "empty report file name",
+	} {
+		_, err := RenderOutputName(template, result, TextFormat, now)
+		assert.ErrorContains(t, err, wantErr, template)
+	}
+}
+
+func TestGenerator_OutputNameCollision(t *testing.T) {
+	dir := t.TempDir()
+	gen := NewGenerator()
+	options := &Options{Format: JSONFormat, OutputURI: fileURI(dir), OutputName: "report_{target}"}
+
+	// An existing report is not overwritten, the new one gets the next free suffix
+	for _, want := range []string{"report_v8.5.0.json", "report_v8.5.0_1.json", "report_v8.5.0_2.json"} {
+		location, err := gen.GenerateFromAnalysisResult(context.Background(), newOutputTestResult(), options)
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, want), location)
+	}
+
+	options.Overwrite = true
+	location, err := gen.GenerateFromAnalysisResult(context.Background(), newOutputTestResult(), options)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "report_v8.5.0.json"), location)
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+}
+
+func TestGenerator_LatestSymlink(t *testing.T) {
+	dir := t.TempDir()
+	gen := NewGenerator()
+	options := &Options{OutputURI: fileURI(dir), OutputName: "report_{source}_{format}", LatestSymlink: true}
+
+	// Two consecutive runs: latest.<ext> follows the newest report of each format
+	for _, run := range []struct {
+		target string
+		want   []string
+	}{
+		{target: "v8.5.0", want: []string{"report_v7.5.0_text.txt", "report_v7.5.0_json.json"}},
+		{target: "v8.5.1", want: []string{"report_v7.5.0_text_1.txt", "report_v7.5.0_json_1.json"}},
+	} {
+		result := newOutputTestResult()
+		result.TargetVersion = run.target
+		locations, err := gen.GenerateFormats(context.Background(), result, []Format{TextFormat, JSONFormat}, options)
+		require.NoError(t, err)
+		assert.Equal(t, []string{filepath.Join(dir, run.want[0]), filepath.Join(dir, run.want[1])}, locations)
+
+		for i, latest := range []string{"latest.txt", "latest.json"} {
+			report, err := os.ReadFile(locations[i])
+			require.NoError(t, err)
+			content, err := os.ReadFile(filepath.Join(dir, latest))
+			require.NoError(t, err)
+			assert.Equal(t, report, content, latest)
+			assert.Contains(t, string(content), run.target, latest)
+			// Platforms without symlinks get a copy
+			if target, err := os.Readlink(filepath.Join(dir, latest)); err == nil {
+				assert.Equal(t, run.want[i], target)
+			}
+		}
+	}
+
+	// Linking needs a local output directory
+	_, err := gen.GenerateFromAnalysisResult(context.Background(), newOutputTestResult(), &Options{
+		Format:        TextFormat,
+		Filename:      "report",
+		Writer:        NewS3Writer(newFakeS3Client(), "bucket", "precheck"),
+		LatestSymlink: true,
+	})
+	assert.ErrorContains(t, err, "local output directory")
+}
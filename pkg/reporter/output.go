@@ -0,0 +1,244 @@
+// Package reporter provides report generation for analyzer results
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+// StdoutURI is the output URI that writes reports to standard output
+const StdoutURI = "-"
+
+// OutputWriter writes a generated report artifact to its destination
+// Write returns the location of the written artifact (file path, object URL, or "-" for stdout)
+type OutputWriter interface {
+	Write(ctx context.Context, name string, content []byte, contentType string) (string, error)
+}
+
+// ArtifactReader is implemented by output writers that can read back an artifact they wrote,
+// which appending reports requires (see Options.Append)
+// ReadArtifact returns nil content and no error if the artifact does not exist
+type ArtifactReader interface {
+	ReadArtifact(name string) ([]byte, error)
+}
+
+// ArtifactChecker is implemented by output writers that can tell if an artifact exists, so that a new report
+// gets a numeric suffix instead of overwriting an existing one (see Options.Overwrite)
+type ArtifactChecker interface {
+	ArtifactExists(name string) (bool, error)
+}
+
+// LatestLinker is implemented by output writers that can point a stable name at an artifact they wrote
+// (see Options.LatestSymlink). LinkLatest points latest at name and returns the location of latest
+type LatestLinker interface {
+	LinkLatest(name, latest string, content []byte) (string, error)
+}
+
+// S3PutObjectAPI is the subset of the S3 client used by the S3 writer
+// It allows tests to replace the S3 client with a fake
+type S3PutObjectAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// FallbackError is returned when a report could not be written to its destination
+// and was saved to a local fallback file instead, so that the report is not lost
+type FallbackError struct {
+	// Destination is the location the report should have been written to
+	Destination string
+	// FallbackPath is the local file the report was saved to
+	FallbackPath string
+	// Err is the error that occurred while writing to the destination
+	Err error
+}
+
+func (e *FallbackError) Error() string {
+	return fmt.Sprintf("failed to write report to %s (saved to %s instead): %v", e.Destination, e.FallbackPath, e.Err)
+}
+
+func (e *FallbackError) Unwrap() error {
+	return e.Err
+}
+
+// NewOutputWriter creates an OutputWriter for an output URI
+// Supported URIs:
+//   - "-": standard output
+//   - "file:///path/to/dir" or a plain directory path: local directory. File URIs must be absolute:
+//     in "file://reports/dir" the host "reports" would silently be dropped, so such URIs are refused
+//   - "s3://bucket/prefix": S3 bucket, credentials are loaded from the standard AWS chain (env, profile, IRSA)
+func NewOutputWriter(ctx context.Context, outputURI string) (OutputWriter, error) {
+	if outputURI == StdoutURI {
+		return &stdoutWriter{out: os.Stdout}, nil
+	}
+
+	if !strings.Contains(outputURI, "://") {
+		return &localWriter{dir: outputURI}, nil
+	}
+
+	u, err := url.Parse(outputURI)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output URI %s: %w", outputURI, err)
+	}
+
+	switch u.Scheme {
+	case "file":
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, fmt.Errorf("invalid output URI %s: file URIs must be absolute (file:///path), use a plain path for a relative directory", outputURI)
+		}
+		return &localWriter{dir: fileURIPath(u)}, nil
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid output URI %s: missing bucket", outputURI)
+		}
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+		}
+		return NewS3Writer(s3.NewFromConfig(cfg), u.Host, u.Path), nil
+	default:
+		return nil, fmt.Errorf("unsupported output URI scheme: %s", u.Scheme)
+	}
+}
+
+// fileURIPath returns the local path of a file URI
+// On Windows, the drive letter of "file:///C:/reports" is kept without the leading slash
+func fileURIPath(u *url.URL) string {
+	p := u.Path
+	if len(p) > 1 && p[0] == '/' && filepath.VolumeName(p[1:]) != "" {
+		p = p[1:]
+	}
+	return filepath.FromSlash(p)
+}
+
+// localWriter writes reports to a local directory
+type localWriter struct {
+	dir string
+}
+
+func (w *localWriter) Write(_ context.Context, name string, content []byte, _ string) (string, error) {
+	if err := os.MkdirAll(w.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	filePath := filepath.Join(w.dir, name)
+	if err := os.WriteFile(filePath, content, 0644); err != nil {
+		return "", fmt.Errorf("failed to write report to file: %w", err)
+	}
+	return filePath, nil
+}
+
+func (w *localWriter) ReadArtifact(name string) ([]byte, error) {
+	content, err := os.ReadFile(filepath.Join(w.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read existing report: %w", err)
+	}
+	return content, nil
+}
+
+func (w *localWriter) ArtifactExists(name string) (bool, error) {
+	_, err := os.Lstat(filepath.Join(w.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check existing report: %w", err)
+	}
+	return true, nil
+}
+
+// LinkLatest replaces latest with a relative symlink to name, or with a copy of content on platforms
+// without symlinks (e.g., Windows without the privilege to create them)
+// The link is created under a temporary name and renamed, so that readers never miss latest
+func (w *localWriter) LinkLatest(name, latest string, content []byte) (string, error) {
+	latestPath := filepath.Join(w.dir, latest)
+	tmpPath := latestPath + ".tmp"
+	if err := os.Remove(tmpPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to update %s: %w", latest, err)
+	}
+	if err := os.Symlink(name, tmpPath); err != nil {
+		if err := os.WriteFile(tmpPath, content, 0644); err != nil {
+			return "", fmt.Errorf("failed to update %s: %w", latest, err)
+		}
+	}
+	if err := os.Rename(tmpPath, latestPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to update %s: %w", latest, err)
+	}
+	return latestPath, nil
+}
+
+// stdoutWriter writes reports to standard output
+type stdoutWriter struct {
+	out io.Writer
+}
+
+func (w *stdoutWriter) Write(_ context.Context, _ string, content []byte, _ string) (string, error) {
+	if _, err := w.out.Write(content); err != nil {
+		return "", fmt.Errorf("failed to write report to stdout: %w", err)
+	}
+	return StdoutURI, nil
+}
+
+// S3Writer uploads reports to an S3 bucket under a key prefix
+type S3Writer struct {
+	client S3PutObjectAPI
+	bucket string
+	prefix string
+}
+
+// NewS3Writer creates a writer that uploads reports to s3://bucket/prefix
+func NewS3Writer(client S3PutObjectAPI, bucket, prefix string) *S3Writer {
+	return &S3Writer{
+		client: client,
+		bucket: bucket,
+		prefix: strings.Trim(prefix, "/"),
+	}
+}
+
+// Write uploads the report and returns its s3:// URL
+// If the upload fails, the report is saved to a local temp file and a *FallbackError is returned
+// together with the fallback path
+func (w *S3Writer) Write(ctx context.Context, name string, content []byte, contentType string) (string, error) {
+	key := path.Join(w.prefix, name)
+	objectURL := fmt.Sprintf("s3://%s/%s", w.bucket, key)
+
+	_, err := w.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(w.bucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(content),
+		ContentType: aws.String(contentType),
+	})
+	if err == nil {
+		return objectURL, nil
+	}
+
+	// Do not lose the report: save it locally and report both the error and the fallback path
+	fallbackDir, mkErr := os.MkdirTemp("", "tidb-upgrade-precheck-")
+	if mkErr != nil {
+		return "", fmt.Errorf("failed to upload report to %s: %w (local fallback also failed: %v)", objectURL, err, mkErr)
+	}
+	fallbackPath, writeErr := (&localWriter{dir: fallbackDir}).Write(ctx, name, content, contentType)
+	if writeErr != nil {
+		return "", fmt.Errorf("failed to upload report to %s: %w (local fallback also failed: %v)", objectURL, err, writeErr)
+	}
+
+	return fallbackPath, &FallbackError{
+		Destination:  objectURL,
+		FallbackPath: fallbackPath,
+		Err:          err,
+	}
+}
@@ -0,0 +1,238 @@
+package reporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeS3Client records uploaded objects instead of calling S3
+type fakeS3Client struct {
+	objects      map[string][]byte
+	contentTypes map[string]string
+	err          error
+}
+
+func newFakeS3Client() *fakeS3Client {
+	return &fakeS3Client{
+		objects:      make(map[string][]byte),
+		contentTypes: make(map[string]string),
+	}
+}
+
+func (c *fakeS3Client) PutObject(_ context.Context, params *s3.PutObjectInput, _ ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	body, err := io.ReadAll(params.Body)
+	if err != nil {
+		return nil, err
+	}
+	key := aws.ToString(params.Bucket) + "/" + aws.ToString(params.Key)
+	c.objects[key] = body
+	c.contentTypes[key] = aws.ToString(params.ContentType)
+	return &s3.PutObjectOutput{}, nil
+}
+
+func newOutputTestResult() *analyzer.AnalysisResult {
+	return &analyzer.AnalysisResult{
+		SourceVersion:       "v7.5.0",
+		TargetVersion:       "v8.5.0",
+		ModifiedParams:      make(map[string]map[string]analyzer.ModifiedParamInfo),
+		TikvInconsistencies: make(map[string][]analyzer.InconsistentNode),
+		UpgradeDifferences:  make(map[string]map[string]analyzer.UpgradeDifference),
+		ForcedChanges:       make(map[string]map[string]analyzer.ForcedChange),
+	}
+}
+
+// fileURI returns the file URI of a local directory ("file:///C:/dir" on Windows)
+func fileURI(dir string) string {
+	uriPath := filepath.ToSlash(dir)
+	if !strings.HasPrefix(uriPath, "/") {
+		uriPath = "/" + uriPath
+	}
+	return "file://" + uriPath
+}
+
+func TestNewOutputWriter(t *testing.T) {
+	ctx := context.Background()
+
+	writer, err := NewOutputWriter(ctx, "-")
+	require.NoError(t, err)
+	assert.IsType(t, &stdoutWriter{}, writer)
+
+	writer, err = NewOutputWriter(ctx, "file:///tmp/reports")
+	require.NoError(t, err)
+	if local, ok := writer.(*localWriter); assert.True(t, ok) {
+		assert.Equal(t, filepath.FromSlash("/tmp/reports"), local.dir)
+	}
+
+	writer, err = NewOutputWriter(ctx, "file://localhost/tmp/reports")
+	require.NoError(t, err)
+	if local, ok := writer.(*localWriter); assert.True(t, ok) {
+		assert.Equal(t, filepath.FromSlash("/tmp/reports"), local.dir)
+	}
+
+	// The first path segment of a relative file URI is parsed as the host, the report would go to /dir
+	_, err = NewOutputWriter(ctx, "file://relative/dir")
+	assert.ErrorContains(t, err, "file URIs must be absolute")
+
+	writer, err = NewOutputWriter(ctx, "reports")
+	require.NoError(t, err)
+	assert.IsType(t, &localWriter{}, writer)
+
+	_, err = NewOutputWriter(ctx, "gs://bucket/prefix")
+	assert.Error(t, err)
+
+	_, err = NewOutputWriter(ctx, "s3:///prefix")
+	assert.Error(t, err)
+}
+
+func TestGenerator_GenerateFormats_S3(t *testing.T) {
+	client := newFakeS3Client()
+	gen := NewGenerator()
+
+	locations, err := gen.GenerateFormats(context.Background(), newOutputTestResult(), []Format{TextFormat, JSONFormat}, &Options{
+		Filename: "report",
+		Writer:   NewS3Writer(client, "bucket", "/precheck/run-1/"),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		"s3://bucket/precheck/run-1/report.txt",
+		"s3://bucket/precheck/run-1/report.json",
+	}, locations)
+	assert.Contains(t, string(client.objects["bucket/precheck/run-1/report.txt"]), "v8.5.0")
+	assert.Equal(t, "text/plain; charset=utf-8", client.contentTypes["bucket/precheck/run-1/report.txt"])
+	assert.Equal(t, "application/json", client.contentTypes["bucket/precheck/run-1/report.json"])
+}
+
+func TestGenerator_GenerateFromAnalysisResult_S3FallbackOnError(t *testing.T) {
+	client := newFakeS3Client()
+	client.err = errors.New("access denied")
+	gen := NewGenerator()
+
+	location, err := gen.GenerateFromAnalysisResult(context.Background(), newOutputTestResult(), &Options{
+		Format:   MarkdownFormat,
+		Filename: "report",
+		Writer:   NewS3Writer(client, "bucket", "precheck"),
+	})
+	require.Error(t, err)
+
+	var fallbackErr *FallbackError
+	require.True(t, errors.As(err, &fallbackErr))
+	assert.Equal(t, "s3://bucket/precheck/report.md", fallbackErr.Destination)
+	assert.Equal(t, fallbackErr.FallbackPath, location)
+	assert.Contains(t, err.Error(), "access denied")
+	defer os.RemoveAll(filepath.Dir(location))
+
+	// The report is not lost
+	content, readErr := os.ReadFile(location)
+	require.NoError(t, readErr)
+	assert.Contains(t, string(content), "v8.5.0")
+}
+
+func TestGenerator_GenerateFromAnalysisResult_Stdout(t *testing.T) {
+	var out bytes.Buffer
+	gen := NewGenerator()
+
+	location, err := gen.GenerateFromAnalysisResult(context.Background(), newOutputTestResult(), &Options{
+		Format:   TextFormat,
+		Filename: "report",
+		Writer:   &stdoutWriter{out: &out},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, StdoutURI, location)
+	assert.Contains(t, out.String(), "v8.5.0")
+}
+
+func TestGenerator_GenerateFromAnalysisResult_FileURI(t *testing.T) {
+	dir := t.TempDir()
+	gen := NewGenerator()
+
+	location, err := gen.GenerateFromAnalysisResult(context.Background(), newOutputTestResult(), &Options{
+		Format:    HTMLFormat,
+		Filename:  "report",
+		OutputURI: fileURI(dir),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "report.html"), location)
+	_, statErr := os.Stat(location)
+	assert.NoError(t, statErr)
+}
+
+// writeKnowledgeBaseDefaults writes the defaults.json of a component in a knowledge base directory
+func writeKnowledgeBaseDefaults(t *testing.T, kbPath, versionGroup, version, component string, defaults map[string]interface{}) {
+	dir := filepath.Join(kbPath, versionGroup, version, component)
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	data, err := json.Marshal(defaults)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "defaults.json"), data, 0644))
+}
+
+// TestOfflineAnalysis_Portable runs the offline analysis path (knowledge base and snapshot in, reports out)
+// in directories with spaces, as under Windows and macOS user profiles; CI runs it on Linux, macOS and Windows
+func TestOfflineAnalysis_Portable(t *testing.T) {
+	collector.ClearKBCache()
+	defer collector.ClearKBCache()
+
+	kbPath := filepath.Join(t.TempDir(), "upgrade precheck", "knowledge")
+	for _, kb := range []struct{ group, version string }{{"v7.5", "v7.5.0"}, {"v8.1", "v8.1.0"}} {
+		writeKnowledgeBaseDefaults(t, kbPath, kb.group, kb.version, "tidb", map[string]interface{}{
+			"component": "tidb",
+			"version":   kb.version,
+			"config_defaults": map[string]interface{}{
+				"log.level": map[string]interface{}{"value": "info", "type": "string"},
+			},
+			"system_variables": map[string]interface{}{
+				"tidb_txn_mode": map[string]interface{}{"value": "pessimistic", "type": "string"},
+			},
+		})
+	}
+	sourceKB, err := collector.LoadKnowledgeBase(kbPath, "v7.5.0")
+	require.NoError(t, err)
+	targetKB, err := collector.LoadKnowledgeBase(kbPath, "v8.1.0")
+	require.NoError(t, err)
+
+	snapshot := &collector.ClusterSnapshot{
+		SourceVersion: "v7.5.0",
+		TargetVersion: "v8.1.0",
+		Components: map[string]collector.ComponentState{
+			"tidb": {
+				Type:      types.ComponentTiDB,
+				Version:   "v7.5.0",
+				Config:    types.ConvertConfigToDefaults(map[string]interface{}{"log.level": "info"}),
+				Variables: types.ConvertVariablesToSystemVariables(map[string]string{"tidb_txn_mode": "optimistic"}),
+			},
+		},
+	}
+	result, err := analyzer.NewAnalyzer(nil).Analyze(context.Background(), snapshot, "v7.5.0", "v8.1.0", sourceKB, targetKB)
+	require.NoError(t, err)
+
+	outputDir := filepath.Join(t.TempDir(), "precheck reports")
+	formats := []Format{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat}
+	locations, err := NewGenerator().GenerateFormats(context.Background(), result, formats, &Options{Filename: "report", OutputDir: outputDir})
+	require.NoError(t, err)
+	require.Len(t, locations, len(formats))
+
+	for i, ext := range []string{"txt", "md", "html", "json"} {
+		assert.Equal(t, filepath.Join(outputDir, "report."+ext), locations[i])
+		content, err := os.ReadFile(locations[i])
+		require.NoError(t, err)
+		assert.Contains(t, string(content), "tidb_txn_mode")
+	}
+}
@@ -2,15 +2,20 @@
 package reporter
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
 	"time"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+	csvfmt "github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats/csv"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats/html"
 	jsonfmt "github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats/json"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats/junit"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats/markdown"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats/text"
 )
@@ -23,6 +28,8 @@ const (
 	MarkdownFormat Format = "markdown"
 	HTMLFormat     Format = "html"
 	JSONFormat     Format = "json"
+	CSVFormat      Format = "csv"
+	JUnitFormat    Format = "junit"
 )
 
 // Options defines options for report generation
@@ -30,6 +37,27 @@ type Options struct {
 	Format    Format
 	OutputDir string
 	Filename  string
+	// OutputURI is the report destination: "file:///path", "s3://bucket/prefix", or "-" for stdout
+	// If empty, reports are written to OutputDir
+	OutputURI string
+	// Writer overrides the writer created from OutputURI/OutputDir (mainly for tests)
+	Writer OutputWriter
+	// TemplateDir is a directory of report templates overriding the built-in formatters (see --template-dir)
+	// A format uses <TemplateDir>/<format>.tmpl if it exists (e.g., html.tmpl), executed against TemplateData
+	TemplateDir string
+	// Append appends the report to the existing report of the same name instead of overwriting it
+	// (see AppendReport). The destination must be a local directory, and reports are named AppendFilename
+	// unless Filename is set, so that successive runs write to the same file
+	Append bool
+	// OutputName is the report file name template (without extension, see RenderOutputName), DefaultOutputName
+	// if empty. Filename takes precedence
+	OutputName string
+	// Overwrite overwrites an existing report of the same name; otherwise the new report gets a numeric suffix
+	// (e.g., report_1.json). Only local destinations are checked, appended reports are always written to the same file
+	Overwrite bool
+	// LatestSymlink points LatestName.<ext> (e.g., latest.json) at the report in the output directory, so that
+	// automation finds the newest report of each format at a stable path. The destination must be a local directory
+	LatestSymlink bool
 }
 
 // Generator generates reports in various formats
@@ -41,18 +69,108 @@ func NewGenerator() *Generator {
 }
 
 // GenerateFromAnalysisResult generates a report from analyzer.AnalysisResult
-// Uses modular formatters for different output formats
-func (g *Generator) GenerateFromAnalysisResult(result *analyzer.AnalysisResult, options *Options) (string, error) {
-	// Create output directory if it doesn't exist
-	if err := os.MkdirAll(options.OutputDir, 0755); err != nil {
-		return "", fmt.Errorf("failed to create output directory: %w", err)
-	}
-
+// The report is rendered with GenerateToWriter and written to the destination of options
+// (OutputURI or OutputDir, or options.Writer), returning its location. ctx bounds the upload of the report
+func (g *Generator) GenerateFromAnalysisResult(ctx context.Context, result *analyzer.AnalysisResult, options *Options) (string, error) {
 	// Generate filename if not provided
 	filename := options.Filename
+	if filename == "" && options.Append && options.OutputName == "" {
+		filename = AppendFilename
+	}
 	if filename == "" {
-		timestamp := time.Now().Format("20060102_150405")
-		filename = fmt.Sprintf("upgrade_precheck_report_%s", timestamp)
+		outputName := options.OutputName
+		if outputName == "" {
+			outputName = DefaultOutputName
+		}
+		var err error
+		if filename, err = RenderOutputName(outputName, result, options.Format, time.Now()); err != nil {
+			return "", err
+		}
+	}
+
+	var content bytes.Buffer
+	if err := g.GenerateToWriter(result, options, &content); err != nil {
+		return "", err
+	}
+
+	// Write to the destination (local directory, S3, or stdout)
+	writer, err := options.outputWriter(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	name := fmt.Sprintf("%s.%s", filename, getFileExtension(options.Format))
+	if checker, ok := writer.(ArtifactChecker); ok && !options.Append && !options.Overwrite {
+		var err error
+		if name, err = uniqueArtifactName(checker, filename, getFileExtension(options.Format)); err != nil {
+			return "", err
+		}
+	}
+	report := content.Bytes()
+	if options.Append {
+		reader, ok := writer.(ArtifactReader)
+		if !ok {
+			return "", fmt.Errorf("appending reports requires a local output directory")
+		}
+		existing, err := reader.ReadArtifact(name)
+		if err != nil {
+			return "", err
+		}
+		if report, err = AppendReport(existing, report, options.Format, time.Now()); err != nil {
+			return "", fmt.Errorf("failed to append to %s: %w", name, err)
+		}
+	}
+
+	// On *FallbackError, location is the local fallback path of the report
+	location, err := writer.Write(ctx, name, report, getContentType(options.Format))
+	if err != nil {
+		return location, err
+	}
+
+	if options.LatestSymlink {
+		linker, ok := writer.(LatestLinker)
+		if !ok {
+			return location, fmt.Errorf("linking the latest report requires a local output directory")
+		}
+		if _, err := linker.LinkLatest(name, fmt.Sprintf("%s.%s", LatestName, getFileExtension(options.Format)), report); err != nil {
+			return location, err
+		}
+	}
+
+	return location, nil
+}
+
+// outputWriter returns options.Writer, or the writer of the destination of options (OutputURI or OutputDir)
+func (o *Options) outputWriter(ctx context.Context) (OutputWriter, error) {
+	if o.Writer != nil {
+		return o.Writer, nil
+	}
+	outputURI := o.OutputURI
+	if outputURI == "" {
+		outputURI = o.OutputDir
+	}
+	return NewOutputWriter(ctx, outputURI)
+}
+
+// GenerateToWriter renders a report from analyzer.AnalysisResult in options.Format and writes it to w
+// Only the format of options is used, the destination options are ignored. This allows streaming a report
+// (e.g., as an HTTP response or to a pipe) without a temporary file
+// Uses modular formatters for different output formats, or the template of the format in options.TemplateDir
+func (g *Generator) GenerateToWriter(result *analyzer.AnalysisResult, options *Options, w io.Writer) error {
+	// Results loaded from a file may not be sorted like the ones of the analyzer
+	result = withSortedCheckResults(result)
+
+	if options.TemplateDir != "" {
+		tmpl, err := loadTemplate(options.TemplateDir, options.Format)
+		if err != nil {
+			return err
+		}
+		if tmpl != nil {
+			if err := tmpl.Execute(w, newTemplateData(result, options.Format)); err != nil {
+				return fmt.Errorf("failed to execute template %s: %w", templatePath(options.TemplateDir, options.Format), err)
+			}
+			return nil
+		}
 	}
 
 	var content string
@@ -89,21 +207,90 @@ func (g *Generator) GenerateFromAnalysisResult(result *analyzer.AnalysisResult,
 			OutputDir: options.OutputDir,
 			Filename:  options.Filename,
 		})
+	case "csv":
+		formatter := csvfmt.NewCSVFormatter()
+		content, err = formatter.Generate(result, &formats.Options{
+			Format:    formats.CSVFormat,
+			OutputDir: options.OutputDir,
+			Filename:  options.Filename,
+		})
+	case "junit":
+		formatter := junit.NewJUnitFormatter()
+		content, err = formatter.Generate(result, &formats.Options{
+			Format:    formats.JUnitFormat,
+			OutputDir: options.OutputDir,
+			Filename:  options.Filename,
+		})
 	default:
-		return "", fmt.Errorf("unsupported format: %s", formatStr)
+		return fmt.Errorf("unsupported format: %s", formatStr)
 	}
 
 	if err != nil {
-		return "", fmt.Errorf("failed to generate report content: %w", err)
+		return fmt.Errorf("failed to generate report content: %w", err)
+	}
+
+	if _, err := io.WriteString(w, content); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
 	}
+	return nil
+}
+
+// withSortedCheckResults returns a copy of result whose check results are sorted (see analyzer.SortCheckResults),
+// so that reports are identical across runs; result is not modified
+func withSortedCheckResults(result *analyzer.AnalysisResult) *analyzer.AnalysisResult {
+	sorted := *result
+	sorted.CheckResults = append([]rules.CheckResult(nil), result.CheckResults...)
+	analyzer.SortCheckResults(sorted.CheckResults)
+	return &sorted
+}
+
+// GenerateFormats generates a report for each format and returns the location of every artifact
+// Artifacts that could not be written to the destination are saved to a local fallback file
+// (see FallbackError); they are included in the returned locations, and their errors are returned joined.
+// Any other error stops generation and is returned immediately
+// The formats share one writer, so that the destination (e.g., the S3 client) is set up once
+func (g *Generator) GenerateFormats(ctx context.Context, result *analyzer.AnalysisResult, formatList []Format, options *Options) ([]string, error) {
+	var locations []string
+	var fallbackErrs []error
 
-	// Write to file
-	filePath := filepath.Join(options.OutputDir, fmt.Sprintf("%s.%s", filename, getFileExtension(options.Format)))
-	if err := os.WriteFile(filePath, []byte(content), 0644); err != nil {
-		return "", fmt.Errorf("failed to write report to file: %w", err)
+	writer, err := options.outputWriter(ctx)
+	if err != nil {
+		return nil, err
 	}
+	for _, format := range formatList {
+		formatOptions := *options
+		formatOptions.Format = format
+		formatOptions.Writer = writer
+		location, err := g.GenerateFromAnalysisResult(ctx, result, &formatOptions)
+		if err != nil {
+			var fallbackErr *FallbackError
+			if !errors.As(err, &fallbackErr) {
+				return locations, fmt.Errorf("%s report: %w", format, err)
+			}
+			fallbackErrs = append(fallbackErrs, fmt.Errorf("%s report: %w", format, err))
+		}
+		locations = append(locations, location)
+	}
+
+	return locations, errors.Join(fallbackErrs...)
+}
 
-	return filePath, nil
+// getContentType returns the content type for a given format
+func getContentType(format Format) string {
+	switch format {
+	case MarkdownFormat:
+		return "text/markdown; charset=utf-8"
+	case HTMLFormat:
+		return "text/html; charset=utf-8"
+	case JSONFormat:
+		return "application/json"
+	case CSVFormat:
+		return "text/csv; charset=utf-8"
+	case JUnitFormat:
+		return "application/xml"
+	default:
+		return "text/plain; charset=utf-8"
+	}
 }
 
 // getFileExtension returns the file extension for a given format
@@ -117,6 +304,10 @@ func getFileExtension(format Format) string {
 		return "html"
 	case JSONFormat:
 		return "json"
+	case CSVFormat:
+		return "csv"
+	case JUnitFormat:
+		return "xml"
 	default:
 		return "txt"
 	}
@@ -0,0 +1,79 @@
+package reporter
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/internal/golden"
+	"github.com/stretchr/testify/require"
+)
+
+// Golden file tests guard the report output against unintended format changes
+// Downstream tooling parses these reports, so any change to the output must be deliberate.
+// The reports are generated from a small hand-made AnalysisResult, so that rule changes don't churn them:
+// the analysis itself is covered by the tests of the analyzer and the self-test.
+// To regenerate the golden files after an intended change, run:
+//
+//	go test -tags update_golden ./pkg/reporter/
+//
+// and review the resulting diff under testdata/golden.
+
+const (
+	goldenFixturePath = "testdata/fixture.json"
+	goldenDir         = "testdata/golden"
+)
+
+// generatedAtPattern matches the report generation timestamp, which changes on every run
+var generatedAtPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}`)
+
+// loadGoldenFixture loads the canonical AnalysisResult used by all golden tests
+func loadGoldenFixture(t *testing.T) *analyzer.AnalysisResult {
+	t.Helper()
+
+	data, err := os.ReadFile(goldenFixturePath)
+	require.NoError(t, err)
+
+	var result analyzer.AnalysisResult
+	require.NoError(t, json.Unmarshal(data, &result))
+	return &result
+}
+
+// normalizeReport replaces run-dependent content so that reports can be compared byte by byte
+func normalizeReport(content string) string {
+	return generatedAtPattern.ReplaceAllString(content, "<GENERATED_AT>")
+}
+
+func TestGenerator_GenerateFromAnalysisResult_Golden(t *testing.T) {
+	formats := []Format{
+		TextFormat,
+		MarkdownFormat,
+		HTMLFormat,
+		JSONFormat,
+		CSVFormat,
+		JUnitFormat,
+	}
+
+	for _, format := range formats {
+		t.Run(string(format), func(t *testing.T) {
+			result := loadGoldenFixture(t)
+
+			gen := NewGenerator()
+			filePath, err := gen.GenerateFromAnalysisResult(context.Background(), result, &Options{
+				Format:    format,
+				OutputDir: t.TempDir(),
+				Filename:  "report",
+			})
+			require.NoError(t, err)
+
+			data, err := os.ReadFile(filePath)
+			require.NoError(t, err)
+			golden.Check(t, filepath.Join(goldenDir, string(format)+".expected"), []byte(normalizeReport(string(data))),
+				"go test -tags update_golden ./pkg/reporter/")
+		})
+	}
+}
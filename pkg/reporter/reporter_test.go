@@ -1,11 +1,17 @@
 package reporter
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
 	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -112,7 +118,7 @@ func TestGenerator_GenerateFromAnalysisResult(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			gen := NewGenerator()
-			content, err := gen.GenerateFromAnalysisResult(tt.result, tt.options)
+			content, err := gen.GenerateFromAnalysisResult(context.Background(), tt.result, tt.options)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -168,7 +174,7 @@ func TestGenerator_GenerateFromAnalysisResult_WithData(t *testing.T) {
 	}
 
 	gen := NewGenerator()
-	filePath, err := gen.GenerateFromAnalysisResult(result, options)
+	filePath, err := gen.GenerateFromAnalysisResult(context.Background(), result, options)
 
 	require.NoError(t, err)
 	assert.NotEmpty(t, filePath)
@@ -182,3 +188,288 @@ func TestGenerator_GenerateFromAnalysisResult_WithData(t *testing.T) {
 	assert.Contains(t, content, "v8.5.0")
 	assert.Contains(t, content, "max-connections")
 }
+
+func TestGenerator_GenerateFromAnalysisResult_ForcedRemoval(t *testing.T) {
+	result := newOutputTestResult()
+	result.CheckResults = []rules.CheckResult{
+		{
+			RuleID:        "UPGRADE_DIFFERENCES",
+			Category:      "upgrade_difference",
+			Component:     "tidb",
+			ParameterName: "tidb_enable_clustered_index",
+			ParamType:     "system_variable",
+			Severity:      "error",
+			Message:       "Parameter tidb_enable_clustered_index in tidb will be forcibly changed during upgrade",
+			CurrentValue:  "ON",
+			TargetDefault: "ON",
+			ForcedValue:   "",
+			Metadata:      map[string]interface{}{"forced_removal": true},
+		},
+	}
+
+	for _, format := range []Format{TextFormat, MarkdownFormat, HTMLFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var out bytes.Buffer
+			_, err := NewGenerator().GenerateFromAnalysisResult(context.Background(), result, &Options{
+				Format:   format,
+				Filename: "report",
+				Writer:   &stdoutWriter{out: &out},
+			})
+			require.NoError(t, err)
+			assert.Contains(t, out.String(), rules.ForcedRemovalDisplay)
+		})
+	}
+}
+
+func TestGenerator_JSONMetadata(t *testing.T) {
+	result := newOutputTestResult()
+	result.CheckResults = []rules.CheckResult{
+		{
+			RuleID:        "FORCED_CHANGES",
+			Category:      "upgrade_difference",
+			Component:     "tidb",
+			ParameterName: "tidb_enable_async_merge_global_stats",
+			ParamType:     "system_variable",
+			Severity:      "error",
+			Metadata:      map[string]interface{}{"bootstrap_version": int64(177), "function_name": "upgradeToVer177"},
+		},
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, NewGenerator().GenerateToWriter(result, &Options{Format: JSONFormat}, &out))
+	var decoded struct {
+		CheckResults []struct {
+			Metadata map[string]interface{} `json:"metadata"`
+		} `json:"check_results"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Len(t, decoded.CheckResults, 1)
+	assert.Equal(t, map[string]interface{}{"bootstrap_version": float64(177), "function_name": "upgradeToVer177"}, decoded.CheckResults[0].Metadata)
+}
+
+func TestGenerator_ConsistentValueDisplay(t *testing.T) {
+	result := newOutputTestResult()
+	result.CheckResults = []rules.CheckResult{
+		{
+			RuleID:        "FORCED_CHANGES",
+			Category:      "upgrade_difference",
+			Component:     "tidb",
+			ParameterName: "tikv-client.copr-req-timeout",
+			ParamType:     "config",
+			Severity:      "error",
+			Message:       "Parameter tikv-client.copr-req-timeout in tidb will be forcibly changed during upgrade",
+			CurrentValue:  float64(60000000000),
+			TargetDefault: "60s",
+			ForcedValue:   float64(1800000000000),
+		},
+	}
+	result.TikvInconsistencies["storage.block-cache.capacity"] = []analyzer.InconsistentNode{
+		{NodeName: "tikv-0", NodeAddress: "10.0.0.1:20160", Value: "1GiB", IsMajority: true},
+		{NodeName: "tikv-1", NodeAddress: "10.0.0.2:20160", Value: "1536MiB"},
+	}
+
+	// The same values read the same in every format
+	for _, format := range []Format{TextFormat, MarkdownFormat, HTMLFormat} {
+		var out bytes.Buffer
+		require.NoError(t, NewGenerator().GenerateToWriter(result, &Options{Format: format}, &out), format)
+		report := out.String()
+		assert.Contains(t, report, "Forced To: 30m\n", format)
+		assert.Contains(t, report, "1.0 GiB", format)
+		assert.Contains(t, report, "1.5 GiB", format)
+		assert.NotContains(t, report, "1800000000000", format)
+	}
+
+	// JSON keeps the raw values, with the same display strings next to them
+	var out bytes.Buffer
+	require.NoError(t, NewGenerator().GenerateToWriter(result, &Options{Format: JSONFormat}, &out))
+	var decoded struct {
+		CheckResults []struct {
+			ForcedValue interface{}       `json:"forced_value"`
+			Display     map[string]string `json:"display"`
+		} `json:"check_results"`
+		TikvInconsistencies map[string][]struct {
+			Value   interface{} `json:"value"`
+			Display string      `json:"display"`
+		} `json:"tikv_inconsistencies"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	require.Len(t, decoded.CheckResults, 1)
+	assert.Equal(t, float64(1800000000000), decoded.CheckResults[0].ForcedValue)
+	assert.Equal(t, map[string]string{"current_value": "1m", "target_default": "1m", "forced_value": "30m"}, decoded.CheckResults[0].Display)
+	nodes := decoded.TikvInconsistencies["storage.block-cache.capacity"]
+	require.Len(t, nodes, 2)
+	assert.Equal(t, "1GiB", nodes[0].Value)
+	assert.Equal(t, "1.0 GiB", nodes[0].Display)
+	assert.Equal(t, "1.5 GiB", nodes[1].Display)
+	// The analysis result itself is left as is
+	assert.Nil(t, result.CheckResults[0].Display)
+	assert.Empty(t, result.TikvInconsistencies["storage.block-cache.capacity"][0].Display)
+}
+
+// failingWriter is an io.Writer whose writes always fail
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestGenerator_GenerateToWriter(t *testing.T) {
+	gen := NewGenerator()
+
+	var out bytes.Buffer
+	require.NoError(t, gen.GenerateToWriter(newOutputTestResult(), &Options{Format: JSONFormat}, &out))
+	var decoded analyzer.AnalysisResult
+	require.NoError(t, json.Unmarshal(out.Bytes(), &decoded))
+	assert.Equal(t, "v8.5.0", decoded.TargetVersion)
+
+	// Destination options are ignored, nothing is written to OutputDir
+	dir := t.TempDir()
+	out.Reset()
+	require.NoError(t, gen.GenerateToWriter(newOutputTestResult(), &Options{Format: TextFormat, OutputDir: dir, Filename: "report"}, &out))
+	assert.Contains(t, out.String(), "v8.5.0")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+
+	err = gen.GenerateToWriter(newOutputTestResult(), &Options{Format: "pdf"}, &out)
+	assert.ErrorContains(t, err, "unsupported format")
+
+	err = gen.GenerateToWriter(newOutputTestResult(), &Options{Format: MarkdownFormat}, failingWriter{})
+	assert.ErrorContains(t, err, "broken pipe")
+}
+
+func TestGenerator_RuleExecutionAppendix(t *testing.T) {
+	gen := NewGenerator()
+	result := newOutputTestResult()
+	result.RuleExecutions = []rules.RuleExecution{
+		{
+			RuleID:             "UPGRADE_DIFFERENCES",
+			DurationMs:         12.5,
+			Statistics:         &rules.RuleStatistics{ParametersExamined: 420},
+			FindingsBySeverity: map[string]int{"warning": 2, "critical": 1},
+		},
+		{RuleID: "TIKV_CONSISTENCY", Skipped: true, SkipReason: "no tikv data collected"},
+	}
+
+	for _, format := range []Format{TextFormat, MarkdownFormat, HTMLFormat} {
+		var out bytes.Buffer
+		require.NoError(t, gen.GenerateToWriter(result, &Options{Format: format}, &out), format)
+		report := out.String()
+		assert.Contains(t, report, "Appendix: Rule Execution", format)
+		assert.Contains(t, report, "UPGRADE_DIFFERENCES", format)
+		assert.Contains(t, report, "12.5 ms", format)
+		assert.Contains(t, report, "420", format)
+		assert.Contains(t, report, "1 critical, 2 warning", format)
+		assert.Contains(t, report, "skipped: no tikv data collected", format)
+	}
+
+	// Reports without rule executions (e.g., loaded from an older JSON report) have no appendix
+	var out bytes.Buffer
+	require.NoError(t, gen.GenerateToWriter(newOutputTestResult(), &Options{Format: MarkdownFormat}, &out))
+	assert.NotContains(t, out.String(), "Appendix: Rule Execution")
+}
+
+func TestGenerator_NewParametersSection(t *testing.T) {
+	gen := NewGenerator()
+	result := newOutputTestResult()
+	result.CheckResults = []rules.CheckResult{
+		{
+			RuleID:        "UPGRADE_DIFFERENCES",
+			Category:      "new_in_target",
+			Component:     "tidb",
+			ParameterName: "tidb_enable_fast_create_table",
+			ParamType:     "system_variable",
+			Severity:      "info",
+			TargetDefault: true,
+			Metadata:      map[string]interface{}{"type": "bool"},
+		},
+		{
+			RuleID:        "UPGRADE_DIFFERENCES",
+			Category:      "new_in_target",
+			Component:     "tikv",
+			ParameterName: "raftstore.periodic-full-compact-start-max-cpu",
+			ParamType:     "config",
+			Severity:      "info",
+			TargetDefault: 0.1,
+			Metadata:      map[string]interface{}{"type": "float"},
+		},
+	}
+
+	for _, format := range []Format{MarkdownFormat, HTMLFormat} {
+		var out bytes.Buffer
+		require.NoError(t, gen.GenerateToWriter(result, &Options{Format: format}, &out), format)
+		report := out.String()
+		assert.Contains(t, report, "New parameters in v8.5.0", format)
+		assert.Contains(t, report, "tidb_enable_fast_create_table", format)
+		assert.Contains(t, report, "raftstore.periodic-full-compact-start-max-cpu", format)
+		assert.Contains(t, report, "system variable", format)
+	}
+
+	// The text report only gives the counts
+	var out bytes.Buffer
+	require.NoError(t, gen.GenerateToWriter(result, &Options{Format: TextFormat}, &out))
+	report := out.String()
+	assert.Contains(t, report, "New parameters in v8.5.0")
+	assert.Contains(t, report, "2 parameters are introduced by the target version")
+	assert.Contains(t, report, "- tikv: 1")
+	assert.NotContains(t, report, "tidb_enable_fast_create_table")
+}
+
+func TestGenerator_IgnoredFindingsSection(t *testing.T) {
+	gen := NewGenerator()
+	result := newOutputTestResult()
+	result.Statistics.FindingsIgnored = 1
+	result.IgnoredResults = []rules.CheckResult{
+		{
+			RuleID:        "USER_MODIFIED_PARAMS",
+			Category:      "user_modified",
+			Component:     "tikv",
+			ParameterName: "rocksdb.max-open-files",
+			Severity:      "warning",
+			Message:       "Parameter rocksdb.max-open-files has been modified",
+			Ignored:       true,
+			IgnoreReason:  `matches ignore pattern "rocksdb.*"`,
+		},
+	}
+
+	for _, format := range []Format{TextFormat, MarkdownFormat, HTMLFormat} {
+		var out bytes.Buffer
+		require.NoError(t, gen.GenerateToWriter(result, &Options{Format: format}, &out), format)
+		report := out.String()
+		assert.Contains(t, report, "Ignored Findings", format)
+		assert.Contains(t, report, "Parameter rocksdb.max-open-files has been modified", format)
+		assert.Contains(t, report, "rocksdb.*", format)
+		assert.Contains(t, report, "Findings Ignored (ignore patterns)", format)
+	}
+	var out bytes.Buffer
+	require.NoError(t, gen.GenerateToWriter(result, &Options{Format: TextFormat}, &out))
+	assert.Contains(t, out.String(), "[IGNORED] [warning] tikv")
+
+	// Without --show-ignored the ignored findings are only counted
+	result.IgnoredResults = nil
+	out.Reset()
+	require.NoError(t, gen.GenerateToWriter(result, &Options{Format: MarkdownFormat}, &out))
+	assert.NotContains(t, out.String(), "## Ignored Findings")
+}
+
+func TestGenerator_CollectionLimitationsSection(t *testing.T) {
+	gen := NewGenerator()
+	result := newOutputTestResult()
+	result.Collection = &analyzer.CollectionInfo{
+		MissingPrivileges: []types.MissingPrivilege{
+			{Query: "SHOW CONFIG", Privilege: "CONFIG", DataClass: types.DataClassConfig, Impact: "TiDB configuration"},
+		},
+	}
+
+	for _, format := range []Format{TextFormat, MarkdownFormat, HTMLFormat} {
+		var out bytes.Buffer
+		require.NoError(t, gen.GenerateToWriter(result, &Options{Format: format}, &out), format)
+		report := out.String()
+		assert.Contains(t, report, "Collection Limitations", format)
+		assert.Contains(t, report, "SHOW CONFIG", format)
+		assert.Contains(t, report, "CONFIG", format)
+		assert.Contains(t, report, "check-privileges", format)
+		// Without a collection mode (full collection) no mode is shown
+		assert.NotContains(t, strings.ToLower(report), "collection mode", format)
+	}
+}
@@ -0,0 +1,68 @@
+package sections
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// CollectionLimitationsSection renders the privileges the MySQL user lacked during the collection
+// It comes first so that the findings are read knowing which of them may be incomplete
+type CollectionLimitationsSection struct{}
+
+// NewCollectionLimitationsSection creates a new collection limitations section
+func NewCollectionLimitationsSection() *CollectionLimitationsSection {
+	return &CollectionLimitationsSection{}
+}
+
+// Name returns the section name
+func (s *CollectionLimitationsSection) Name() string {
+	return "Collection Limitations"
+}
+
+// HasContent checks if this section has any content to render
+func (s *CollectionLimitationsSection) HasContent(result *analyzer.AnalysisResult) bool {
+	return result.Collection != nil && len(result.Collection.MissingPrivileges) > 0
+}
+
+// Render renders the section content based on the format
+func (s *CollectionLimitationsSection) Render(format formats.Format, result *analyzer.AnalysisResult) (string, error) {
+	const intro = "The MySQL user lacks the privileges below; the data they guard was not collected and the findings listed may be incomplete. Run 'precheck check-privileges' for the GRANT statements."
+
+	var content strings.Builder
+	switch format {
+	case formats.HTMLFormat:
+		content.WriteString("<h2>Collection Limitations</h2>\n")
+		content.WriteString(fmt.Sprintf("<p>%s</p>\n<table>\n", html.EscapeString(intro)))
+		content.WriteString("<tr><th>Query</th><th>Missing Privilege</th><th>Possibly Incomplete</th></tr>\n")
+		for _, missing := range result.Collection.MissingPrivileges {
+			content.WriteString(fmt.Sprintf("<tr><td><code>%s</code></td><td><code>%s</code></td><td>%s</td></tr>\n",
+				html.EscapeString(missing.Query), html.EscapeString(missing.Privilege), html.EscapeString(missing.Impact)))
+		}
+		content.WriteString("</table>\n")
+	case formats.MarkdownFormat:
+		content.WriteString("\n## Collection Limitations\n\n")
+		content.WriteString(intro + "\n\n")
+		content.WriteString("| Query | Missing Privilege | Possibly Incomplete |\n")
+		content.WriteString("|---|---|---|\n")
+		for _, missing := range result.Collection.MissingPrivileges {
+			content.WriteString(fmt.Sprintf("| `%s` | `%s` | %s |\n", missing.Query, missing.Privilege,
+				strings.ReplaceAll(missing.Impact, "|", "\\|")))
+		}
+	case formats.TextFormat:
+		content.WriteString("\nCollection Limitations\n")
+		content.WriteString(fmt.Sprintf("   %s\n\n", intro))
+		for _, missing := range result.Collection.MissingPrivileges {
+			content.WriteString(fmt.Sprintf("   - %s: requires %s\n", missing.Query, missing.Privilege))
+			if missing.Impact != "" {
+				content.WriteString(fmt.Sprintf("     Possibly incomplete: %s\n", missing.Impact))
+			}
+		}
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+	return content.String(), nil
+}
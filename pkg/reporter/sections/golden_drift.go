@@ -0,0 +1,102 @@
+package sections
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// GoldenDriftSection renders drift from the golden configuration profile (--golden-config)
+// These findings are not upgrade differences and are kept out of the parameter check section
+type GoldenDriftSection struct{}
+
+// NewGoldenDriftSection creates a new golden config drift section
+func NewGoldenDriftSection() *GoldenDriftSection {
+	return &GoldenDriftSection{}
+}
+
+// Name returns the section name
+func (s *GoldenDriftSection) Name() string {
+	return "Golden Config Drift"
+}
+
+// HasContent checks if this section has any content to render
+func (s *GoldenDriftSection) HasContent(result *analyzer.AnalysisResult) bool {
+	for _, check := range result.CheckResults {
+		if check.Category == "golden_drift" {
+			return true
+		}
+	}
+	return false
+}
+
+// Render renders the section content
+// Drifted parameters are listed by component, stale profile entries are listed last
+func (s *GoldenDriftSection) Render(format formats.Format, result *analyzer.AnalysisResult) (string, error) {
+	switch format {
+	case formats.HTMLFormat, formats.MarkdownFormat, formats.TextFormat:
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+
+	var drifted, stale []rules.CheckResult
+	for _, check := range result.CheckResults {
+		if check.Category != "golden_drift" {
+			continue
+		}
+		if check.Metadata != nil && check.Metadata["stale"] == true {
+			stale = append(stale, check)
+		} else {
+			drifted = append(drifted, check)
+		}
+	}
+	sortByComponentParameter(drifted)
+	sortByComponentParameter(stale)
+
+	var content strings.Builder
+	content.WriteString("\nGolden Config Drift\n")
+	content.WriteString("   Parameters whose runtime value deviates from the golden configuration profile.\n\n")
+
+	if len(drifted) == 0 {
+		content.WriteString("   No drift detected.\n")
+	}
+	currentComponent := ""
+	for _, check := range drifted {
+		if check.Component != currentComponent {
+			currentComponent = check.Component
+			content.WriteString(fmt.Sprintf("   [%s Component]\n", strings.ToUpper(currentComponent)))
+		}
+		content.WriteString(fmt.Sprintf("   - [%s] %s (%s)\n", formats.SeverityLabel(check), check.ParameterName, check.ParamType))
+		for _, line := range strings.Split(check.Details, "\n") {
+			if line != "" {
+				content.WriteString(fmt.Sprintf("     %s\n", line))
+			}
+		}
+	}
+
+	if len(stale) > 0 {
+		content.WriteString("\n   Stale profile entries (unknown parameters):\n")
+		for _, check := range stale {
+			content.WriteString(fmt.Sprintf("   - %s: %s (%s)\n", check.Component, check.ParameterName, check.ParamType))
+		}
+	}
+
+	return content.String(), nil
+}
+
+// sortByComponentParameter sorts findings by component, parameter type and name
+func sortByComponentParameter(checks []rules.CheckResult) {
+	sort.SliceStable(checks, func(i, j int) bool {
+		if checks[i].Component != checks[j].Component {
+			return checks[i].Component < checks[j].Component
+		}
+		if checks[i].ParamType != checks[j].ParamType {
+			return checks[i].ParamType < checks[j].ParamType
+		}
+		return checks[i].ParameterName < checks[j].ParameterName
+	})
+}
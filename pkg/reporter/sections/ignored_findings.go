@@ -0,0 +1,68 @@
+package sections
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// IgnoredFindingsSection renders the findings suppressed by the ignore patterns (--config-file)
+// They are only kept in the result with --show-ignored, and are listed apart from the findings
+// so that they are not mistaken for risks to act on
+type IgnoredFindingsSection struct{}
+
+// NewIgnoredFindingsSection creates a new ignored findings section
+func NewIgnoredFindingsSection() *IgnoredFindingsSection {
+	return &IgnoredFindingsSection{}
+}
+
+// Name returns the section name
+func (s *IgnoredFindingsSection) Name() string {
+	return "Ignored Findings"
+}
+
+// HasContent checks if this section has any content to render
+func (s *IgnoredFindingsSection) HasContent(result *analyzer.AnalysisResult) bool {
+	return len(result.IgnoredResults) > 0
+}
+
+// Render renders the section content based on the format
+func (s *IgnoredFindingsSection) Render(format formats.Format, result *analyzer.AnalysisResult) (string, error) {
+	const intro = "Findings suppressed by the ignore patterns of the configuration file. They are not counted in the summary."
+
+	var content strings.Builder
+	switch format {
+	case formats.HTMLFormat:
+		content.WriteString("<h2>Ignored Findings</h2>\n")
+		content.WriteString(fmt.Sprintf("<p>%s</p>\n<table>\n", intro))
+		content.WriteString("<tr><th>Component</th><th>Parameter</th><th>Finding</th><th>Reason</th></tr>\n")
+		for _, check := range result.IgnoredResults {
+			content.WriteString(fmt.Sprintf("<tr style=\"color: #999\"><td>%s</td><td><code>%s</code></td><td><s>[%s]</s> %s</td><td>%s</td></tr>\n",
+				html.EscapeString(check.Component), html.EscapeString(check.ParameterName),
+				html.EscapeString(formats.SeverityLabel(check)), html.EscapeString(check.Message), html.EscapeString(check.IgnoreReason)))
+		}
+		content.WriteString("</table>\n")
+	case formats.MarkdownFormat:
+		content.WriteString("\n## Ignored Findings\n\n")
+		content.WriteString(intro + "\n\n")
+		content.WriteString("| Component | Parameter | Finding | Reason |\n")
+		content.WriteString("|---|---|---|---|\n")
+		for _, check := range result.IgnoredResults {
+			content.WriteString(fmt.Sprintf("| %s | `%s` | ~~%s~~ %s | %s |\n", check.Component, check.ParameterName,
+				formats.SeverityLabel(check), strings.ReplaceAll(check.Message, "|", "\\|"), check.IgnoreReason))
+		}
+	case formats.TextFormat:
+		content.WriteString("\nIgnored Findings\n")
+		content.WriteString(fmt.Sprintf("   %s\n\n", intro))
+		for _, check := range result.IgnoredResults {
+			content.WriteString(fmt.Sprintf("   - [IGNORED] [%s] %s: %s\n", formats.SeverityLabel(check), check.Component, check.Message))
+			content.WriteString(fmt.Sprintf("     %s\n", check.IgnoreReason))
+		}
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+	return content.String(), nil
+}
@@ -0,0 +1,113 @@
+package sections
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// NewParametersSection renders the parameters introduced by the target version (new_in_target category),
+// which are neither in the source version nor set in the cluster
+// Target versions add hundreds of parameters, so the text report only gives their count per component,
+// the markdown and HTML reports list them all (as does the JSON report)
+type NewParametersSection struct{}
+
+// NewNewParametersSection creates a new section of the parameters introduced by the target version
+func NewNewParametersSection() *NewParametersSection {
+	return &NewParametersSection{}
+}
+
+// Name returns the section name
+func (s *NewParametersSection) Name() string {
+	return "New Parameters"
+}
+
+// HasContent checks if this section has any content to render
+func (s *NewParametersSection) HasContent(result *analyzer.AnalysisResult) bool {
+	for _, check := range result.CheckResults {
+		if check.Category == "new_in_target" {
+			return true
+		}
+	}
+	return false
+}
+
+// Render renders the section content based on the format
+func (s *NewParametersSection) Render(format formats.Format, result *analyzer.AnalysisResult) (string, error) {
+	var params []rules.CheckResult
+	for _, check := range result.CheckResults {
+		if check.Category == "new_in_target" {
+			params = append(params, check)
+		}
+	}
+	sortByComponentParameter(params)
+	title := fmt.Sprintf("New parameters in %s", result.TargetVersion)
+	header := []string{"Component", "Parameter", "Kind", "Type", "Default"}
+
+	var content strings.Builder
+	switch format {
+	case formats.HTMLFormat:
+		content.WriteString(fmt.Sprintf("<h2>%s</h2>\n", html.EscapeString(title)))
+		content.WriteString(fmt.Sprintf("<p>%d parameters are introduced by the target version. They take their default value after the upgrade unless configured.</p>\n<table>\n<tr>", len(params)))
+		for _, column := range header {
+			content.WriteString(fmt.Sprintf("<th>%s</th>", column))
+		}
+		content.WriteString("</tr>\n")
+		for _, check := range params {
+			row := newParameterRow(check)
+			for i := range row {
+				row[i] = html.EscapeString(row[i])
+			}
+			row[1] = "<code>" + row[1] + "</code>"
+			content.WriteString("<tr><td>" + strings.Join(row, "</td><td>") + "</td></tr>\n")
+		}
+		content.WriteString("</table>\n")
+	case formats.MarkdownFormat:
+		content.WriteString(fmt.Sprintf("\n## %s\n\n", title))
+		content.WriteString(fmt.Sprintf("%d parameters are introduced by the target version. They take their default value after the upgrade unless configured.\n\n", len(params)))
+		content.WriteString("| " + strings.Join(header, " | ") + " |\n")
+		content.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+		for _, check := range params {
+			row := newParameterRow(check)
+			row[1] = "`" + row[1] + "`"
+			row[4] = strings.ReplaceAll(row[4], "|", "\\|")
+			content.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+	case formats.TextFormat:
+		content.WriteString(fmt.Sprintf("\n%s\n", title))
+		content.WriteString(fmt.Sprintf("   %d parameters are introduced by the target version (listed in the markdown, HTML and JSON reports)\n", len(params)))
+		counts := make(map[string]int)
+		for _, check := range params {
+			counts[check.Component]++
+		}
+		components := make([]string, 0, len(counts))
+		for component := range counts {
+			components = append(components, component)
+		}
+		sort.Strings(components)
+		for _, component := range components {
+			content.WriteString(fmt.Sprintf("   - %s: %d\n", component, counts[component]))
+		}
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+	return content.String(), nil
+}
+
+// newParameterRow returns the cells of a new parameter, in the order of the table header
+func newParameterRow(check rules.CheckResult) []string {
+	kind := "config"
+	if check.ParamType == "system_variable" {
+		kind = "system variable"
+	}
+	valueType, _ := check.Metadata["type"].(string)
+	if valueType == "" {
+		valueType = "-"
+	}
+	return []string{check.Component, check.ParameterName, kind, valueType, formats.FormatValue(check.ParameterName, check.TargetDefault)}
+}
@@ -67,8 +67,13 @@ func filterAndGroupResults(checkResults []rules.CheckResult) ([]rules.CheckResul
 		if check.ParameterName == "tidb_config" {
 			continue
 		}
-		// Skip statistics CheckResults (they are handled separately)
-		if check.ParameterName == "__statistics__" {
+
+		// Golden config drift is rendered in its own section (GoldenDriftSection)
+		if check.Category == "golden_drift" {
+			continue
+		}
+		// Parameters introduced by the target version are rendered in their own section (NewParametersSection)
+		if check.Category == "new_in_target" {
 			continue
 		}
 
@@ -171,6 +176,10 @@ func (s *textParameterCheckSection) renderText(resultsByRiskLevel map[formats.Ri
 			content.WriteString(fmt.Sprintf("   [%s Component]\n", strings.ToUpper(compType)))
 			for _, check := range compChecks {
 				content.WriteString(fmt.Sprintf("   - %s: %s\n", check.ParameterName, check.Message))
+				// Show the canonical forced value ("variable removed" for DELETE-style changes)
+				if formats.GetReportType(check) == formats.ReportTypeForcedChange {
+					content.WriteString(fmt.Sprintf("     Forced To: %s\n", formats.FormatForcedValue(check)))
+				}
 			}
 		}
 	}
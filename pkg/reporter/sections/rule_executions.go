@@ -0,0 +1,116 @@
+package sections
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// ruleExecutionSeverities are the severities shown in the findings column, most severe first
+var ruleExecutionSeverities = []string{"critical", "error", "warning", "info"}
+
+// RuleExecutionSection renders the execution of each rule as an appendix table
+// (duration, parameters examined, findings by severity, skipped rules)
+type RuleExecutionSection struct{}
+
+// NewRuleExecutionSection creates a new rule execution section
+func NewRuleExecutionSection() *RuleExecutionSection {
+	return &RuleExecutionSection{}
+}
+
+// Name returns the section name
+func (s *RuleExecutionSection) Name() string {
+	return "Appendix: Rule Execution"
+}
+
+// HasContent checks if this section has any content to render
+func (s *RuleExecutionSection) HasContent(result *analyzer.AnalysisResult) bool {
+	return len(result.RuleExecutions) > 0
+}
+
+// Render renders the section content based on the format
+func (s *RuleExecutionSection) Render(format formats.Format, result *analyzer.AnalysisResult) (string, error) {
+	rows := make([][]string, 0, len(result.RuleExecutions))
+	for _, execution := range result.RuleExecutions {
+		rows = append(rows, ruleExecutionRow(execution))
+	}
+	header := []string{"Rule", "Duration", "Parameters Examined", "Findings", "Status"}
+
+	var content strings.Builder
+	switch format {
+	case formats.HTMLFormat:
+		content.WriteString("<h2>Appendix: Rule Execution</h2>\n<table>\n<tr>")
+		for _, column := range header {
+			content.WriteString(fmt.Sprintf("<th>%s</th>", column))
+		}
+		content.WriteString("</tr>\n")
+		for _, row := range rows {
+			content.WriteString("<tr>")
+			for _, cell := range row {
+				content.WriteString(fmt.Sprintf("<td>%s</td>", html.EscapeString(cell)))
+			}
+			content.WriteString("</tr>\n")
+		}
+		content.WriteString("</table>\n")
+	case formats.MarkdownFormat:
+		content.WriteString("\n## Appendix: Rule Execution\n\n")
+		content.WriteString("| " + strings.Join(header, " | ") + " |\n")
+		content.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+		for _, row := range rows {
+			content.WriteString("| " + strings.Join(row, " | ") + " |\n")
+		}
+	case formats.TextFormat:
+		content.WriteString("\nAppendix: Rule Execution\n\n")
+		tw := tabwriter.NewWriter(&content, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "   "+strings.Join(header, "\t"))
+		for _, row := range rows {
+			fmt.Fprintln(tw, "   "+strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+	return content.String(), nil
+}
+
+// ruleExecutionRow returns the cells of a rule execution, in the order of the table header
+func ruleExecutionRow(execution rules.RuleExecution) []string {
+	duration, examined, findings, status := "-", "-", "-", "ok"
+	switch {
+	case execution.Skipped:
+		status = "skipped: " + execution.SkipReason
+	case execution.Error != "":
+		status = "failed: " + execution.Error
+	case execution.Partial:
+		status = "partial: " + execution.PartialReason
+	}
+	if !execution.Skipped {
+		duration = fmt.Sprintf("%.1f ms", execution.DurationMs)
+		findings = formatFindingsBySeverity(execution.FindingsBySeverity)
+	}
+	if execution.Statistics != nil {
+		examined = fmt.Sprintf("%d", execution.Statistics.ParametersExamined)
+	}
+	return []string{execution.RuleID, duration, examined, findings, status}
+}
+
+// formatFindingsBySeverity formats finding counts as "1 critical, 2 warning" ("0" if there are none)
+func formatFindingsBySeverity(counts map[string]int) string {
+	var parts []string
+	for _, severity := range ruleExecutionSeverities {
+		if counts[severity] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[severity], severity))
+		}
+	}
+	if len(parts) == 0 {
+		return "0"
+	}
+	return strings.Join(parts, ", ")
+}
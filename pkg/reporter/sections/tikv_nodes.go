@@ -0,0 +1,124 @@
+package sections
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// TikvNodesSection renders a table per inconsistent TiKV parameter, with the value of every node
+// The majority value is highlighted so that the deviating nodes stand out
+type TikvNodesSection struct{}
+
+// NewTikvNodesSection creates a new TiKV node consistency section
+func NewTikvNodesSection() *TikvNodesSection {
+	return &TikvNodesSection{}
+}
+
+// Name returns the section name
+func (s *TikvNodesSection) Name() string {
+	return "TiKV Node Consistency"
+}
+
+// HasContent checks if this section has any content to render
+func (s *TikvNodesSection) HasContent(result *analyzer.AnalysisResult) bool {
+	for _, nodes := range result.TikvInconsistencies {
+		if len(nodes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// Render renders the section content based on the format
+func (s *TikvNodesSection) Render(format formats.Format, result *analyzer.AnalysisResult) (string, error) {
+	params := make([]string, 0, len(result.TikvInconsistencies))
+	for param, nodes := range result.TikvInconsistencies {
+		if len(nodes) > 0 {
+			params = append(params, param)
+		}
+	}
+	sort.Strings(params)
+	header := []string{"Node", "Address", "Value", "Status"}
+
+	var content strings.Builder
+	switch format {
+	case formats.HTMLFormat:
+		content.WriteString("<h2>TiKV Node Consistency</h2>\n")
+		for _, param := range params {
+			content.WriteString(fmt.Sprintf("<h3><code>%s</code></h3>\n<table>\n<tr>", html.EscapeString(param)))
+			for _, column := range header {
+				content.WriteString(fmt.Sprintf("<th>%s</th>", column))
+			}
+			content.WriteString("</tr>\n")
+			for _, node := range result.TikvInconsistencies[param] {
+				row := tikvNodeRow(param, node)
+				for i := range row {
+					row[i] = html.EscapeString(row[i])
+				}
+				if node.IsMajority {
+					row[2] = "<strong>" + row[2] + "</strong>"
+				} else {
+					row[3] = `<span class="warning">` + row[3] + "</span>"
+				}
+				content.WriteString("<tr><td>" + strings.Join(row, "</td><td>") + "</td></tr>\n")
+			}
+			content.WriteString("</table>\n")
+		}
+	case formats.MarkdownFormat:
+		content.WriteString("\n## TiKV Node Consistency\n")
+		for _, param := range params {
+			content.WriteString(fmt.Sprintf("\n### `%s`\n\n", param))
+			content.WriteString("| " + strings.Join(header, " | ") + " |\n")
+			content.WriteString("|" + strings.Repeat("---|", len(header)) + "\n")
+			for _, node := range result.TikvInconsistencies[param] {
+				row := tikvNodeRow(param, node)
+				if node.IsMajority {
+					row[2] = "**" + row[2] + "**"
+				}
+				content.WriteString("| " + strings.Join(row, " | ") + " |\n")
+			}
+		}
+	case formats.TextFormat:
+		content.WriteString("\nTiKV Node Consistency\n")
+		for _, param := range params {
+			content.WriteString(fmt.Sprintf("\n  %s\n", param))
+			tw := tabwriter.NewWriter(&content, 0, 0, 2, ' ', 0)
+			fmt.Fprintln(tw, "   "+strings.Join(header, "\t"))
+			for _, node := range result.TikvInconsistencies[param] {
+				fmt.Fprintln(tw, "   "+strings.Join(tikvNodeRow(param, node), "\t"))
+			}
+			if err := tw.Flush(); err != nil {
+				return "", err
+			}
+		}
+	default:
+		return "", fmt.Errorf("unsupported format: %s", format)
+	}
+	return content.String(), nil
+}
+
+// tikvNodeRow returns the cells of a node, in the order of the table header
+func tikvNodeRow(param string, node analyzer.InconsistentNode) []string {
+	value, status := formats.FormatValue(param, node.Value), "deviates"
+	if node.Missing {
+		value = "(not set)"
+	}
+	switch {
+	case node.IsMajority:
+		status = "majority"
+	case node.Missing:
+		// Not necessarily a deviation: nodes collected through TiDB lack the node-local parameters
+		status = "not set"
+	}
+	name := node.NodeName
+	if name == "" {
+		name = "-"
+	}
+	return []string{name, node.NodeAddress, value, status}
+}
@@ -0,0 +1,157 @@
+package reporter
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter/formats"
+)
+
+// TemplateData is the data a report template (see --template-dir) is executed against
+// Every field of the analysis result is available directly (e.g., {{.TargetVersion}}, {{range .CheckResults}}),
+// along with the helper methods below. Inside a range, the helpers are called on the root: {{$.SeverityIcon .Severity}}
+type TemplateData struct {
+	*analyzer.AnalysisResult
+	// Format is the format of the report being generated (text, markdown, html, json, csv, junit)
+	Format Format
+	// GeneratedAt is the generation time of the report, formatted as "2006-01-02 15:04:05"
+	GeneratedAt string
+}
+
+// newTemplateData creates the template data of a report
+func newTemplateData(result *analyzer.AnalysisResult, format Format) *TemplateData {
+	return &TemplateData{
+		AnalysisResult: result,
+		Format:         format,
+		GeneratedAt:    time.Now().Format("2006-01-02 15:04:05"),
+	}
+}
+
+// SeverityIcon returns an icon for a severity ("" for unknown severities)
+func (d *TemplateData) SeverityIcon(severity string) string {
+	switch severity {
+	case "critical":
+		return "🔴"
+	case "error":
+		return "🟠"
+	case "warning":
+		return "⚠️"
+	case "info":
+		return "ℹ️"
+	default:
+		return ""
+	}
+}
+
+// SeverityLabel returns the severity of a check result as shown in the built-in reports,
+// e.g. "error (was warning)" if a severity profile changed it
+func (d *TemplateData) SeverityLabel(check rules.CheckResult) string {
+	return formats.SeverityLabel(check)
+}
+
+// FormatValue formats a value without unit conversion, see DisplayValue for parameter values
+func (d *TemplateData) FormatValue(value interface{}) string {
+	return rules.FormatValue(value)
+}
+
+// DisplayValue formats the value of a parameter as shown in the built-in reports,
+// sizes and durations in human units (see formats.FormatValue)
+func (d *TemplateData) DisplayValue(param string, value interface{}) string {
+	return formats.FormatValue(param, value)
+}
+
+// Severities returns the severities of check results, most severe first
+func (d *TemplateData) Severities() []string {
+	return []string{"critical", "error", "warning", "info"}
+}
+
+// ChecksBySeverity returns the check results of a severity
+func (d *TemplateData) ChecksBySeverity(severity string) []rules.CheckResult {
+	var checks []rules.CheckResult
+	for _, check := range d.CheckResults {
+		if check.Severity == severity {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// ChecksByComponent returns the check results of a component
+func (d *TemplateData) ChecksByComponent(component string) []rules.CheckResult {
+	var checks []rules.CheckResult
+	for _, check := range d.CheckResults {
+		if check.Component == component {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// Components returns the components with check results, in summary order (tidb, pd, tikv, tiflash, then others)
+func (d *TemplateData) Components() []string {
+	components := analyzer.SeverityBreakdown{}
+	for _, check := range d.CheckResults {
+		components[check.Component] = nil
+	}
+	return components.SortedComponents()
+}
+
+// reportTemplate is a parsed report template, html/template for HTML reports and text/template otherwise
+type reportTemplate interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// templatePath returns the path of the template of a format in a template directory
+func templatePath(templateDir string, format Format) string {
+	return filepath.Join(templateDir, string(format)+".tmpl")
+}
+
+// loadTemplate loads the template of a format from a template directory
+// It returns nil if the directory has no template for the format, so that the built-in formatter is used
+func loadTemplate(templateDir string, format Format) (reportTemplate, error) {
+	path := templatePath(templateDir, format)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", path, err)
+	}
+
+	var tmpl reportTemplate
+	if format == HTMLFormat {
+		tmpl, err = htmltemplate.New(filepath.Base(path)).Parse(string(data))
+	} else {
+		tmpl, err = texttemplate.New(filepath.Base(path)).Parse(string(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid template %s: %w", path, err)
+	}
+	return tmpl, nil
+}
+
+// ValidateTemplateDir checks that a template directory exists and that its templates parse,
+// so that an invalid template fails the run before the cluster is collected
+func ValidateTemplateDir(templateDir string) error {
+	info, err := os.Stat(templateDir)
+	if err != nil {
+		return fmt.Errorf("template directory: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("template directory %s is not a directory", templateDir)
+	}
+	for _, format := range []Format{TextFormat, MarkdownFormat, HTMLFormat, JSONFormat, CSVFormat, JUnitFormat} {
+		if _, err := loadTemplate(templateDir, format); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package reporter
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerator_GenerateToWriter_TemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "text.tmpl"),
+		[]byte(`{{.SourceVersion}} -> {{.TargetVersion}} ({{.Format}}){{range .CheckResults}}
+{{$.SeverityIcon .Severity}} {{$.SeverityLabel .}} {{.ParameterName}}{{end}}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "html.tmpl"),
+		[]byte(`{{range .CheckResults}}<p>{{.Message}}</p>{{end}}`), 0644))
+	result := loadGoldenFixture(t)
+	generator := NewGenerator()
+
+	var text bytes.Buffer
+	require.NoError(t, generator.GenerateToWriter(result, &Options{Format: TextFormat, TemplateDir: dir}, &text))
+	assert.Contains(t, text.String(), result.SourceVersion+" -> "+result.TargetVersion+" (text)")
+	assert.Contains(t, text.String(), "🟠 error (was warning) tidb_txn_mode")
+
+	// HTML templates escape the values
+	result.CheckResults[0].Message = "<script>"
+	var html bytes.Buffer
+	require.NoError(t, generator.GenerateToWriter(result, &Options{Format: HTMLFormat, TemplateDir: dir}, &html))
+	assert.Contains(t, html.String(), "<p>&lt;script&gt;</p>")
+
+	// Formats without a template use the built-in report
+	var builtin, markdown bytes.Buffer
+	require.NoError(t, generator.GenerateToWriter(result, &Options{Format: MarkdownFormat}, &builtin))
+	require.NoError(t, generator.GenerateToWriter(result, &Options{Format: MarkdownFormat, TemplateDir: dir}, &markdown))
+	assert.Equal(t, normalizeReport(builtin.String()), normalizeReport(markdown.String()))
+}
+
+func TestValidateTemplateDir(t *testing.T) {
+	require.NoError(t, ValidateTemplateDir(filepath.Join("..", "..", "examples", "templates")))
+
+	dir := t.TempDir()
+	require.NoError(t, ValidateTemplateDir(dir))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "markdown.tmpl"), []byte(`{{range .CheckResults}}`), 0644))
+	assert.ErrorContains(t, ValidateTemplateDir(dir), "invalid template")
+
+	assert.Error(t, ValidateTemplateDir(filepath.Join(dir, "missing")))
+	assert.Error(t, ValidateTemplateDir(filepath.Join(dir, "markdown.tmpl")))
+}
+
+func TestExampleTemplates(t *testing.T) {
+	dir := filepath.Join("..", "..", "examples", "templates")
+	for _, format := range []Format{TextFormat, MarkdownFormat, HTMLFormat} {
+		t.Run(string(format), func(t *testing.T) {
+			var out bytes.Buffer
+			require.NoError(t, NewGenerator().GenerateToWriter(loadGoldenFixture(t), &Options{Format: format, TemplateDir: dir}, &out))
+			assert.Contains(t, out.String(), "tidb_txn_mode")
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Package fixtures embeds the fixtures of the self-test: a cluster snapshot and a small knowledge base subset
+// of a v7.5.0 -> v8.5.0 upgrade (a few hundred parameters). The target defaults without a value are left out,
+// since UPGRADE_DIFFERENCES rejects them as a knowledge base loading issue
+// The same fixtures are the input of the golden tests (the expected findings of the self-test and the report
+// golden files of pkg/reporter), so that they don't drift
+package fixtures
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/types"
+)
+
+//go:embed *.json
+var files embed.FS
+
+const (
+	// SourceVersion is the source version of the fixtures
+	SourceVersion = "v7.5.0"
+	// TargetVersion is the target version of the fixtures
+	TargetVersion = "v8.5.0"
+
+	// ExpectedFile is the fixture of the expected findings of the self-test
+	ExpectedFile = "expected.json"
+
+	snapshotFile = "snapshot.json"
+	sourceKBFile = "source_kb.json"
+	targetKBFile = "target_kb.json"
+)
+
+// Analyze runs the analyzer with the default rules on the fixtures
+func Analyze(ctx context.Context) (*analyzer.AnalysisResult, error) {
+	var snapshot types.ClusterSnapshot
+	if err := Read(snapshotFile, &snapshot); err != nil {
+		return nil, err
+	}
+	var sourceKB, targetKB map[string]interface{}
+	if err := Read(sourceKBFile, &sourceKB); err != nil {
+		return nil, err
+	}
+	if err := Read(targetKBFile, &targetKB); err != nil {
+		return nil, err
+	}
+	return analyzer.NewAnalyzer(nil).Analyze(ctx, &snapshot, SourceVersion, TargetVersion, sourceKB, targetKB)
+}
+
+// Read decodes a fixture
+func Read(name string, v interface{}) error {
+	data, err := files.ReadFile(name)
+	if err != nil {
+		return fmt.Errorf("failed to read fixture %s: %w", name, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse fixture %s: %w", name, err)
+	}
+	return nil
+}
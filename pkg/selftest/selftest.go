@@ -0,0 +1,252 @@
+// Package selftest checks that an installation of the precheck (binary and knowledge directory) is healthy
+// It runs the whole pipeline offline, against the fixtures embedded in the binary (see package fixtures)
+package selftest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/analyzer/rules"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/collector"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/reporter"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/selftest/fixtures"
+)
+
+const (
+	// FixtureSourceVersion is the source version of the embedded fixtures
+	FixtureSourceVersion = fixtures.SourceVersion
+	// FixtureTargetVersion is the target version of the embedded fixtures
+	FixtureTargetVersion = fixtures.TargetVersion
+
+	reportFilePrefix = "self_test_report"
+)
+
+// reportFormats are the report formats generated by the self-test
+var reportFormats = []reporter.Format{reporter.TextFormat, reporter.MarkdownFormat, reporter.HTMLFormat, reporter.JSONFormat}
+
+// Options contains the options of the self-test
+type Options struct {
+	// KnowledgeBasePath is the installed knowledge directory to verify
+	KnowledgeBasePath string
+	// OutputDir is the directory reports are generated into (a temporary directory, removed afterwards, if empty)
+	OutputDir string
+}
+
+// Check is the outcome of a single self-test check
+type Check struct {
+	// Name identifies the check (e.g., "report html")
+	Name string `json:"name"`
+	// Passed is set if the check succeeded
+	Passed bool `json:"passed"`
+	// Duration is the time the check took
+	Duration time.Duration `json:"duration"`
+	// Message explains a failure, or summarizes what was checked
+	Message string `json:"message,omitempty"`
+}
+
+// Expected contains the expected outcome of the analysis of the fixtures
+type Expected struct {
+	// FindingsByRule is the number of findings of each rule
+	FindingsByRule map[string]int `json:"findings_by_rule"`
+}
+
+// Passed returns true if every check passed
+func Passed(checks []Check) bool {
+	for _, check := range checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Run runs every check of the self-test and returns their outcomes, in order
+// A failed check doesn't stop the self-test, except that reports are only generated if the analysis succeeded
+func Run(ctx context.Context, opts Options) []Check {
+	var checks []Check
+	run := func(name string, fn func() (string, error)) bool {
+		start := time.Now()
+		message, err := fn()
+		check := Check{Name: name, Passed: err == nil, Duration: time.Since(start), Message: message}
+		if err != nil {
+			check.Message = err.Error()
+		}
+		checks = append(checks, check)
+		return check.Passed
+	}
+
+	var result *analyzer.AnalysisResult
+	analyzed := run("analyze fixtures", func() (string, error) {
+		var err error
+		result, err = Analyze(ctx)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%d findings", len(result.CheckResults)), nil
+	})
+	if analyzed {
+		run("expected findings", func() (string, error) {
+			return "", verifyFindings(result)
+		})
+		outputDir := opts.OutputDir
+		if outputDir == "" {
+			tempDir, err := os.MkdirTemp("", "precheck-self-test-")
+			if err != nil {
+				run("report output directory", func() (string, error) { return "", err })
+				return append(checks, verifyKnowledgeCheck(opts.KnowledgeBasePath))
+			}
+			defer os.RemoveAll(tempDir)
+			outputDir = tempDir
+		}
+		for _, format := range reportFormats {
+			run("report "+string(format), func() (string, error) {
+				return generateReport(ctx, result, format, outputDir)
+			})
+		}
+	}
+	return append(checks, verifyKnowledgeCheck(opts.KnowledgeBasePath))
+}
+
+// Analyze runs the analyzer with the default rules on the embedded fixtures
+func Analyze(ctx context.Context) (*analyzer.AnalysisResult, error) {
+	return fixtures.Analyze(ctx)
+}
+
+// LoadExpected returns the expected outcome of the analysis of the fixtures
+func LoadExpected() (*Expected, error) {
+	var expected Expected
+	if err := fixtures.Read(fixtures.ExpectedFile, &expected); err != nil {
+		return nil, err
+	}
+	return &expected, nil
+}
+
+// FindingsByRule counts the findings of an analysis result by rule
+func FindingsByRule(result *analyzer.AnalysisResult) map[string]int {
+	counts := make(map[string]int)
+	for _, check := range result.CheckResults {
+		counts[check.RuleID]++
+	}
+	return counts
+}
+
+// verifyFindings compares the number of findings of each rule with the expected ones
+func verifyFindings(result *analyzer.AnalysisResult) error {
+	expected, err := LoadExpected()
+	if err != nil {
+		return err
+	}
+	actual := FindingsByRule(result)
+	ruleIDs := make(map[string]bool)
+	for ruleID := range expected.FindingsByRule {
+		ruleIDs[ruleID] = true
+	}
+	for ruleID := range actual {
+		ruleIDs[ruleID] = true
+	}
+	var mismatches []string
+	for ruleID := range ruleIDs {
+		if actual[ruleID] != expected.FindingsByRule[ruleID] {
+			mismatches = append(mismatches, fmt.Sprintf("%s: %d findings, expected %d", ruleID, actual[ruleID], expected.FindingsByRule[ruleID]))
+		}
+	}
+	if len(mismatches) > 0 {
+		sort.Strings(mismatches)
+		return fmt.Errorf("unexpected findings: %s", strings.Join(mismatches, "; "))
+	}
+	return nil
+}
+
+// generateReport generates the report of a format into outputDir and checks that it is not empty
+// JSON reports must also decode back into an analysis result with the same findings
+func generateReport(ctx context.Context, result *analyzer.AnalysisResult, format reporter.Format, outputDir string) (string, error) {
+	location, err := reporter.NewGenerator().GenerateFromAnalysisResult(ctx, result, &reporter.Options{
+		Format:    format,
+		OutputDir: outputDir,
+		Filename:  reportFilePrefix,
+	})
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(location)
+	if err != nil {
+		return "", fmt.Errorf("failed to read report %s: %w", location, err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return "", fmt.Errorf("report %s is empty", location)
+	}
+	if format == reporter.JSONFormat {
+		var decoded analyzer.AnalysisResult
+		if err := json.Unmarshal(data, &decoded); err != nil {
+			return "", fmt.Errorf("report %s is not a valid JSON report: %w", location, err)
+		}
+		if len(decoded.CheckResults) != len(result.CheckResults) {
+			return "", fmt.Errorf("report %s has %d findings, expected %d", location, len(decoded.CheckResults), len(result.CheckResults))
+		}
+	}
+	return fmt.Sprintf("%d bytes", len(data)), nil
+}
+
+// verifyKnowledgeCheck runs the verification of the installed knowledge directory as a check
+func verifyKnowledgeCheck(knowledgeBasePath string) Check {
+	start := time.Now()
+	message, err := VerifyKnowledgeBase(knowledgeBasePath)
+	check := Check{Name: "knowledge directory", Passed: err == nil, Duration: time.Since(start), Message: message}
+	if err != nil {
+		check.Message = err.Error()
+	}
+	return check
+}
+
+// VerifyKnowledgeBase checks that a knowledge directory can be used by the precheck:
+// it covers at least one version, every defaults.json parses, and so does every global file
+// (upgrade_matrix.json, deployment_specific.json, ...)
+// Returns a summary of what was verified
+func VerifyKnowledgeBase(knowledgeBasePath string) (string, error) {
+	if knowledgeBasePath == "" {
+		return "", fmt.Errorf("knowledge directory not found")
+	}
+	listing, err := collector.ListKnowledgeBase(knowledgeBasePath)
+	if err != nil {
+		return "", err
+	}
+	if len(listing.Versions) == 0 {
+		return "", fmt.Errorf("knowledge directory %s has no versions", knowledgeBasePath)
+	}
+	var invalid []string
+	for _, version := range listing.Versions {
+		for _, component := range version.Components {
+			if component.Error != "" {
+				invalid = append(invalid, fmt.Sprintf("%s/%s: %s", version.Version, component.Component, component.Error))
+			}
+		}
+	}
+
+	globalFiles, err := filepath.Glob(filepath.Join(knowledgeBasePath, "*.json"))
+	if err != nil {
+		return "", err
+	}
+	for _, path := range globalFiles {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			invalid = append(invalid, err.Error())
+		} else if !json.Valid(data) {
+			invalid = append(invalid, fmt.Sprintf("%s: invalid JSON", filepath.Base(path)))
+		}
+	}
+	if _, err := rules.LoadUpgradeMatrix(filepath.Join(knowledgeBasePath, rules.UpgradeMatrixFile)); err != nil {
+		invalid = append(invalid, err.Error())
+	}
+	if len(invalid) > 0 {
+		return "", fmt.Errorf("invalid knowledge in %s: %s", knowledgeBasePath, strings.Join(invalid, "; "))
+	}
+	return fmt.Sprintf("%s: %d versions, %d global files", knowledgeBasePath, len(listing.Versions), len(globalFiles)), nil
+}
@@ -0,0 +1,58 @@
+package selftest
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/internal/golden"
+	"github.com/pingcap/tidb-upgrade-precheck/pkg/selftest/fixtures"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestFixtures_ExpectedFindings guards the expected findings embedded with the fixtures, so that a change
+// of the analyzer doesn't make the self-test of every installation fail. After an intended change, run:
+//
+//	go test -tags update_golden ./pkg/selftest/
+//
+// and review the resulting diff of fixtures/expected.json
+func TestFixtures_ExpectedFindings(t *testing.T) {
+	result, err := Analyze(context.Background())
+	require.NoError(t, err)
+	actual := Expected{FindingsByRule: FindingsByRule(result)}
+
+	data, err := json.MarshalIndent(actual, "", "  ")
+	require.NoError(t, err)
+	golden.Check(t, filepath.Join("fixtures", fixtures.ExpectedFile), append(data, '\n'), "go test -tags update_golden ./pkg/selftest/")
+	// The fixtures exercise the main rules
+	for _, ruleID := range []string{"USER_MODIFIED_PARAMS", "UPGRADE_DIFFERENCES", "FORCED_CHANGES"} {
+		assert.Positive(t, actual.FindingsByRule[ruleID], ruleID)
+	}
+	for _, execution := range result.RuleExecutions {
+		assert.Empty(t, execution.Error, execution.RuleID)
+	}
+}
+
+func TestRun(t *testing.T) {
+	outputDir := t.TempDir()
+	checks := Run(context.Background(), Options{KnowledgeBasePath: "../../knowledge", OutputDir: outputDir})
+	for _, check := range checks {
+		assert.True(t, check.Passed, "%s: %s", check.Name, check.Message)
+	}
+	assert.True(t, Passed(checks))
+	names := make([]string, 0, len(checks))
+	for _, check := range checks {
+		names = append(names, check.Name)
+	}
+	assert.Equal(t, []string{"analyze fixtures", "expected findings", "report text", "report markdown", "report html", "report json", "knowledge directory"}, names)
+
+	// A broken knowledge directory fails the self-test
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(dir+"/upgrade_matrix.json", []byte("{"), 0644))
+	checks = Run(context.Background(), Options{KnowledgeBasePath: dir, OutputDir: outputDir})
+	assert.False(t, Passed(checks))
+	assert.False(t, checks[len(checks)-1].Passed)
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 )
 
@@ -32,20 +34,75 @@ type ParameterValue struct {
 	Description string      `json:"description,omitempty"`
 }
 
-// ConfigDefaults represents configuration parameter defaults for a component
-type ConfigDefaults map[string]ParameterValue
+// ParameterMap maps parameter names to their values
+// It is used for both configuration parameters and system variables, as knowledge base defaults and runtime values
+type ParameterMap map[string]ParameterValue
 
-// SystemVariables represents system variable defaults for a component (mainly for TiDB)
-type SystemVariables map[string]ParameterValue
+// Merge returns a new map with the parameters of m and other; parameters of other win on conflicts
+// Neither m nor other is modified
+func (m ParameterMap) Merge(other ParameterMap) ParameterMap {
+	result := make(ParameterMap, len(m)+len(other))
+	for name, value := range m {
+		result[name] = value
+	}
+	for name, value := range other {
+		result[name] = value
+	}
+	return result
+}
+
+// Filter returns a new map with the parameters for which predicate returns true
+func (m ParameterMap) Filter(predicate func(name string, value ParameterValue) bool) ParameterMap {
+	result := make(ParameterMap)
+	for name, value := range m {
+		if predicate(name, value) {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+// Keys returns the parameter names in sorted order
+func (m ParameterMap) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for name := range m {
+		keys = append(keys, name)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ProvenanceRuntimeOnly is the provenance of defaults collected from the runtime APIs of a cluster only
+// (SHOW CONFIG, SHOW GLOBAL VARIABLES, PD config API), without source code
+const ProvenanceRuntimeOnly = "runtime-only"
 
 // KBSnapshot represents a knowledge base snapshot for any component
 // This is a generic structure that can be used by TiDB, PD, TiKV, TiFlash, etc.
 type KBSnapshot struct {
-	Component        ComponentType   `json:"component"`
-	Version          string          `json:"version"`
-	ConfigDefaults   ConfigDefaults  `json:"config_defaults"`
-	SystemVariables  SystemVariables `json:"system_variables,omitempty"` // Only for TiDB and TiFlash
-	BootstrapVersion int64           `json:"bootstrap_version"`          // Always include, even if 0 (extraction failed)
+	Component        ComponentType `json:"component"`
+	Version          string        `json:"version"`
+	ConfigDefaults   ParameterMap  `json:"config_defaults"`
+	SystemVariables  ParameterMap  `json:"system_variables,omitempty"` // Only for TiDB and TiFlash
+	BootstrapVersion int64         `json:"bootstrap_version"`          // Always include, even if 0 (extraction failed)
+	GeneratedAt      string        `json:"generated_at,omitempty"`     // When the snapshot was generated (RFC3339), set on save
+	// Provenance tells where the defaults were collected from (e.g., ProvenanceRuntimeOnly), empty for the
+	// default generation (runtime defaults of a playground and source code). It is informational: the analyzer
+	// uses defaults the same way whatever their provenance
+	Provenance string `json:"provenance,omitempty"`
+	// KeyCollisions are the duplicate keys resolved during generation (validation report, not saved)
+	KeyCollisions []KeyCollision `json:"-"`
+}
+
+// KeyCollision is a parameter collected under two keys, a prefixed key and an orphan key equal to its suffix
+// (e.g., "raftstore.store-pool-size" and "store-pool-size")
+// The prefixed key is kept and the orphan key is dropped
+type KeyCollision struct {
+	PrefixedKey   string      `json:"prefixed_key"`
+	OrphanKey     string      `json:"orphan_key"`
+	PrefixedValue interface{} `json:"prefixed_value"`
+	OrphanValue   interface{} `json:"orphan_value"`
+	// Conflict is true if the two keys have different values
+	Conflict bool `json:"conflict"`
 }
 
 // UpgradeParamChange represents a forced parameter change during upgrade
@@ -67,17 +124,37 @@ type UpgradeParamChange struct {
 	DetailsNote   string      `json:"details_note,omitempty"`  // Additional note to append to details message
 	Suggestions   []string    `json:"suggestions,omitempty"`   // Custom suggestions for this parameter (overrides default)
 	ReportSeverity string     `json:"report_severity,omitempty"` // Override default report severity: "error", "warning", "info"
+	// FunctionDocstring is the doc comment of the upgrade function making the change (TiDB-specific), explaining its purpose
+	FunctionDocstring string `json:"function_docstring,omitempty"`
+	// Condition is the condition of the if statements wrapping the change in the upgrade function (TiDB-specific),
+	// e.g. "ver < version92"; nested conditions are joined with " && "
+	Condition string `json:"condition,omitempty"`
+}
+
+// UnresolvedVarName records a variable name constant in the upgrade logic source that could not be resolved
+// to a user-visible system variable name. The change is still recorded with the Fallback name,
+// which may not match anything in the runtime or the knowledge base
+type UnresolvedVarName struct {
+	Constant string `json:"constant"`  // Constant reference as written in source (e.g., vardef.TiDBEnableXxx)
+	Fallback string `json:"fallback"`  // Name derived from the constant name (snake_case)
+	FuncName string `json:"func_name"` // upgradeToVerXX function where the constant appeared
+	Line     int    `json:"line"`      // Line number in the upgrade logic file
 }
 
 // UpgradeLogicSnapshot represents upgrade logic for a component
 // Changes contains forced parameter changes for the component
+// UnresolvedNames lists variable name constants that could not be resolved during extraction
 type UpgradeLogicSnapshot struct {
-	Component ComponentType        `json:"component"`
-	Changes   []UpgradeParamChange `json:"changes"`
+	Component       ComponentType        `json:"component"`
+	Changes         []UpgradeParamChange `json:"changes"`
+	UnresolvedNames []UnresolvedVarName  `json:"unresolved_names,omitempty"`
 }
 
 // SaveKBSnapshot saves a KB snapshot to a file
 func SaveKBSnapshot(snapshot *KBSnapshot, outputPath string) error {
+	if snapshot.GeneratedAt == "" {
+		snapshot.GeneratedAt = time.Now().UTC().Format(time.RFC3339)
+	}
 	return saveJSON(snapshot, outputPath)
 }
 
@@ -104,10 +181,10 @@ func saveJSON(data interface{}, outputPath string) error {
 	return nil
 }
 
-// ConvertConfigToDefaults converts a map[string]interface{} to ConfigDefaults
+// ConvertConfigToDefaults converts a map[string]interface{} to a ParameterMap
 // This is used when collecting runtime configuration to maintain consistency with knowledge base format
-func ConvertConfigToDefaults(config map[string]interface{}) ConfigDefaults {
-	defaults := make(ConfigDefaults)
+func ConvertConfigToDefaults(config map[string]interface{}) ParameterMap {
+	defaults := make(ParameterMap)
 	for k, v := range config {
 		defaults[k] = ParameterValue{
 			Value: v,
@@ -117,10 +194,10 @@ func ConvertConfigToDefaults(config map[string]interface{}) ConfigDefaults {
 	return defaults
 }
 
-// ConvertVariablesToSystemVariables converts a map[string]string to SystemVariables
+// ConvertVariablesToSystemVariables converts a map[string]string to a ParameterMap
 // This is used when collecting runtime system variables to maintain consistency with knowledge base format
-func ConvertVariablesToSystemVariables(variables map[string]string) SystemVariables {
-	sysVars := make(SystemVariables)
+func ConvertVariablesToSystemVariables(variables map[string]string) ParameterMap {
+	sysVars := make(ParameterMap)
 	for k, v := range variables {
 		sysVars[k] = ParameterValue{
 			Value: v,
@@ -160,14 +237,70 @@ type ComponentState struct {
 	// Version is the version of the component
 	Version string `json:"version"`
 	// Config is the configuration of the component
-	// Uses ConfigDefaults to maintain consistency with knowledge base format
+	// Uses ParameterMap to maintain consistency with knowledge base format
 	// Runtime values are converted to ParameterValue format
-	Config ConfigDefaults `json:"config"`
+	Config ParameterMap `json:"config"`
+	// DefaultConfig is the default configuration reported by the running component, for PD only
+	// (/pd/api/v1/config/default). Config holds the effective configuration persisted in etcd, which
+	// includes the config file and the runtime changes made with pd-ctl. It is nil if the endpoint is
+	// unavailable (e.g., on some TiDB Operator deployments)
+	DefaultConfig ParameterMap `json:"default_config,omitempty"`
 	// Variables are system variables (for TiDB only)
-	// Uses SystemVariables to maintain consistency with knowledge base format
-	Variables SystemVariables `json:"variables,omitempty"`
+	// Uses ParameterMap to maintain consistency with knowledge base format
+	Variables ParameterMap `json:"variables,omitempty"`
 	// Status is the status information of the component
 	Status map[string]interface{} `json:"status"`
+	// StartupArgs are the effective command-line flags of the component (flag name without dashes -> value),
+	// nil if not exposed: read via /debug/pprof/cmdline for TiDB and PD, derived from the configuration for TiKV
+	// Values that look like credentials are redacted
+	StartupArgs map[string]string `json:"startup_args,omitempty"`
+	// DeclaredStartupArgs are the command-line flags the topology file declares for the instance (see StartupArgs)
+	DeclaredStartupArgs map[string]string `json:"declared_startup_args,omitempty"`
+}
+
+// SystemVariablePrefix is the prefix of system variable names in knowledge base defaults (e.g., "sysvar:tidb_txn_mode")
+const SystemVariablePrefix = "sysvar:"
+
+// ParameterCount returns the number of collected parameters: config parameters and system variables
+func (c *ComponentState) ParameterCount() int {
+	return len(c.Config) + len(c.Variables)
+}
+
+// ParameterNames returns the names of the config parameters, sorted
+// If includeVars is true, system variables are included with the "sysvar:" prefix used by the knowledge base
+func (c *ComponentState) ParameterNames(includeVars bool) []string {
+	size := len(c.Config)
+	if includeVars {
+		size += len(c.Variables)
+	}
+	names := make([]string, 0, size)
+	for name := range c.Config {
+		names = append(names, name)
+	}
+	if includeVars {
+		for name := range c.Variables {
+			names = append(names, SystemVariablePrefix+name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetParam returns the value of a parameter
+// Names with the "sysvar:" prefix are looked up in Variables only; other names are looked up in Config,
+// then in Variables
+func (c *ComponentState) GetParam(name string) (interface{}, bool) {
+	if varName, isVar := strings.CutPrefix(name, SystemVariablePrefix); isVar {
+		value, ok := c.Variables[varName]
+		return value.Value, ok
+	}
+	if value, ok := c.Config[name]; ok {
+		return value.Value, true
+	}
+	if value, ok := c.Variables[name]; ok {
+		return value.Value, true
+	}
+	return nil, false
 }
 
 // InstanceState represents the state of a component instance
@@ -194,6 +327,168 @@ type ClusterSnapshot struct {
 	TargetVersion string `json:"target_version,omitempty"`
 	// Components contains the state of each component
 	Components map[string]ComponentState `json:"components"`
+	// NodeVersions contains the version reported by every collected component instance
+	// This is used to detect mixed-version clusters (e.g., a partially completed previous upgrade)
+	NodeVersions []NodeVersion `json:"node_versions,omitempty"`
+	// ClusterInfo contains cluster-level topology metadata
+	ClusterInfo ClusterInfo `json:"cluster_info"`
+	// GlobalVariablesTable contains the rows of mysql.global_variables, in table order
+	// The upgrade bootstrap reads and rewrites this table, so it is checked against SHOW GLOBAL VARIABLES
+	// Nil if the table was not collected (not required by any rule, or no SELECT privilege)
+	GlobalVariablesTable []GlobalVariableRow `json:"global_variables_table,omitempty"`
+	// GCSafePoints contains the GC safepoint of the cluster and the service safepoints registered in PD
+	// Nil if it was not collected (not required by any rule, or neither TiDB nor PD could be read)
+	GCSafePoints *GCSafePointState `json:"gc_safe_points,omitempty"`
+	// Placement contains the resource groups, placement policies and PD store labels of the cluster
+	// Nil if it was not collected (not required by any rule, or TiDB could not be read)
+	Placement *PlacementState `json:"placement,omitempty"`
+	// Stores contains the TiKV stores registered in PD, with the version and state PD recorded for them
+	// Nil if it was not collected (not required by any rule, or PD could not be read)
+	Stores *StoreState `json:"stores,omitempty"`
+	// CollectedData lists the classes of data that were collected from the cluster (see DataClass)
+	// Nil for snapshots written before it was recorded, which are assumed to contain every class
+	CollectedData []DataClass `json:"collected_data"`
+	// CollectionMode is how the snapshot was collected, empty for the full collection from every component
+	CollectionMode string `json:"collection_mode,omitempty"`
+	// CollectionLimitations describe the data the collection mode could not collect
+	CollectionLimitations []string `json:"collection_limitations,omitempty"`
+	// MissingPrivileges are the queries the precheck user lacked the privileges for, whose data was not collected
+	MissingPrivileges []MissingPrivilege `json:"missing_privileges,omitempty"`
+}
+
+// MissingPrivilege is a query denied during collection for a missing privilege
+type MissingPrivilege struct {
+	// Query is the denied statement (e.g., SHOW CONFIG)
+	Query string `json:"query"`
+	// Privilege is the privilege the statement needs (e.g., CONFIG)
+	Privilege string `json:"privilege"`
+	// DataClass is the class of data the statement collects
+	DataClass DataClass `json:"data_class,omitempty"`
+	// Impact describes the findings that may be incomplete without the data
+	Impact string `json:"impact,omitempty"`
+}
+
+// AddMissingPrivilege records a denied query, once
+func (s *ClusterSnapshot) AddMissingPrivilege(missing MissingPrivilege) {
+	for _, existing := range s.MissingPrivileges {
+		if existing.Query == missing.Query {
+			return
+		}
+	}
+	s.MissingPrivileges = append(s.MissingPrivileges, missing)
+}
+
+// CollectionModeSQLOnly is the collection of a cluster through the MySQL protocol endpoint of TiDB only:
+// the PD, TiKV and TiFlash data is proxied by TiDB's information_schema
+const CollectionModeSQLOnly = "sql-only"
+
+// DataClass is a class of data collected from the cluster
+type DataClass string
+
+const (
+	// DataClassConfig is the configuration of the components
+	DataClassConfig DataClass = "config"
+	// DataClassSystemVariables is the global system variables of TiDB (SHOW GLOBAL VARIABLES)
+	DataClassSystemVariables DataClass = "system_variables"
+	// DataClassGlobalVariablesTable is the rows of mysql.global_variables
+	DataClassGlobalVariablesTable DataClass = "global_variables_table"
+	// DataClassGCSafePoints is the GC safepoint of the cluster and the service safepoints registered in PD
+	DataClassGCSafePoints DataClass = "gc_safe_points"
+	// DataClassPlacement is the resource groups, placement policies and PD store labels
+	DataClassPlacement DataClass = "placement"
+	// DataClassStores is the TiKV stores registered in PD, with their versions and states
+	DataClassStores DataClass = "stores"
+)
+
+// GlobalVariableRow is a row of the mysql.global_variables table
+type GlobalVariableRow struct {
+	// Name is the VARIABLE_NAME column
+	Name string `json:"name"`
+	// Value is the VARIABLE_VALUE column
+	Value string `json:"value"`
+}
+
+// componentKeys returns the keys of the components of the given type, sorted
+// A component matches if its Type is t, or if its Type is empty and its key starts with t (e.g., "tikv-10-0-0-1-20160")
+func (s *ClusterSnapshot) componentKeys(t ComponentType) []string {
+	if s == nil {
+		return nil
+	}
+	var keys []string
+	for key, comp := range s.Components {
+		if comp.Type == t || (comp.Type == "" && strings.HasPrefix(key, string(t))) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ComponentByType returns the first component of the given type
+// The component keyed by the type name (e.g., "tikv", which the collector sets to the first TiKV node) is preferred,
+// otherwise the first matching component in key order is returned
+// The returned state is a copy: changes to it are not reflected in the snapshot
+func (s *ClusterSnapshot) ComponentByType(t ComponentType) (*ComponentState, bool) {
+	if s == nil {
+		return nil, false
+	}
+	if comp, ok := s.Components[string(t)]; ok && (comp.Type == t || comp.Type == "") {
+		return &comp, true
+	}
+	keys := s.componentKeys(t)
+	if len(keys) == 0 {
+		return nil, false
+	}
+	comp := s.Components[keys[0]]
+	return &comp, true
+}
+
+// ComponentsByType returns all the instances of the given type (e.g., every TiKV node), sorted by key
+// The component keyed by the type name only aliases the first instance when per-instance keys
+// (e.g., "tikv-10-0-0-1-20160") are present, so it is skipped in that case to avoid counting a node twice
+// The returned states are copies: changes to them are not reflected in the snapshot
+func (s *ClusterSnapshot) ComponentsByType(t ComponentType) []*ComponentState {
+	keys := s.componentKeys(t)
+	components := make([]*ComponentState, 0, len(keys))
+	for _, key := range keys {
+		if key == string(t) && len(keys) > 1 {
+			continue
+		}
+		comp := s.Components[key]
+		components = append(components, &comp)
+	}
+	return components
+}
+
+// HasComponent checks if the snapshot contains a component of the given type
+func (s *ClusterSnapshot) HasComponent(t ComponentType) bool {
+	return len(s.componentKeys(t)) > 0
+}
+
+// HasCollected checks if a class of data was collected from the cluster
+// Snapshots that do not record CollectedData are assumed to contain every class
+func (s *ClusterSnapshot) HasCollected(class DataClass) bool {
+	if s.CollectedData == nil {
+		return true
+	}
+	for _, c := range s.CollectedData {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// ClusterInfo contains cluster-level topology metadata collected from the cluster
+type ClusterInfo struct {
+	// TiKVNodeCount is the number of TiKV nodes in the cluster topology
+	TiKVNodeCount int `json:"tikv_node_count"`
+	// PDLeaderAddr is the client address of the PD leader
+	PDLeaderAddr string `json:"pd_leader_addr,omitempty"`
+	// ClusterID is the cluster ID assigned by PD
+	ClusterID string `json:"cluster_id,omitempty"`
+	// StorageEngines lists the storage engines deployed in the cluster (e.g., "tikv", "tiflash")
+	StorageEngines []string `json:"storage_engines,omitempty"`
 }
 
 // ClusterEndpoints contains connection information for cluster components
@@ -206,6 +501,9 @@ type ClusterEndpoints struct {
 	TiDBUser string `json:"tidb_user,omitempty"`
 	// TiDBPassword is the MySQL password for TiDB connection (provided by TiUP/Operator)
 	TiDBPassword string `json:"tidb_password,omitempty"`
+	// TiDBStatusAddr is the HTTP status API endpoint of the same TiDB instance (host:status_port)
+	// Used to collect configuration without MySQL credentials. If empty, the default status port (10080) is tried
+	TiDBStatusAddr string `json:"tidb_status_addr,omitempty"`
 	// TiKVAddrs are HTTP API endpoints for TiKV instances
 	TiKVAddrs []string `json:"tikv_addrs,omitempty"`
 	// TiKVDataDirs maps TiKV address to its data_dir path (from topology file)
@@ -218,4 +516,10 @@ type ClusterEndpoints struct {
 	// SourceVersion is the version extracted from topology file (if available)
 	// This can be used as a fallback when cluster version detection fails
 	SourceVersion string `json:"source_version,omitempty"`
+	// SQLOnly is set when only the MySQL protocol endpoint of TiDB is reachable (e.g., TiDB Cloud):
+	// PD, TiKV and TiFlash are then collected through TiDB's information_schema (see CollectionModeSQLOnly)
+	SQLOnly bool `json:"sql_only,omitempty"`
+	// DeclaredStartupArgs maps the address an instance is collected from (TiDBAddr, a PD or TiKV address)
+	// to the command-line flags the topology file declares for it (see ComponentState.DeclaredStartupArgs)
+	DeclaredStartupArgs map[string]map[string]string `json:"declared_startup_args,omitempty"`
 }
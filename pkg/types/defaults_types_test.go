@@ -22,13 +22,13 @@ func TestSaveKBSnapshot(t *testing.T) {
 			snapshot: &KBSnapshot{
 				Component: ComponentTiDB,
 				Version:   "v7.5.0",
-				ConfigDefaults: ConfigDefaults{
+				ConfigDefaults: ParameterMap{
 					"max-connections": ParameterValue{
 						Value: 1000,
 						Type:  "int",
 					},
 				},
-				SystemVariables: SystemVariables{
+				SystemVariables: ParameterMap{
 					"tidb_mem_quota_query": ParameterValue{
 						Value: 1073741824,
 						Type:  "int",
@@ -42,7 +42,7 @@ func TestSaveKBSnapshot(t *testing.T) {
 			snapshot: &KBSnapshot{
 				Component: ComponentPD,
 				Version:   "v7.5.0",
-				ConfigDefaults: ConfigDefaults{
+				ConfigDefaults: ParameterMap{
 					"max-connections": ParameterValue{
 						Value: 1000,
 						Type:  "int",
@@ -132,16 +132,102 @@ func TestSaveUpgradeLogic(t *testing.T) {
 	}
 }
 
+func TestParameterMap_Merge(t *testing.T) {
+	config := ParameterMap{
+		"a": {Value: 1, Type: "int"},
+		"b": {Value: "x", Type: "string"},
+	}
+	override := ParameterMap{
+		"b": {Value: "y", Type: "string"},
+		"c": {Value: true, Type: "bool"},
+	}
+
+	merged := config.Merge(override)
+	assert.Equal(t, ParameterMap{
+		"a": {Value: 1, Type: "int"},
+		"b": {Value: "y", Type: "string"},
+		"c": {Value: true, Type: "bool"},
+	}, merged)
+	// Inputs are not modified
+	assert.Len(t, config, 2)
+	assert.Equal(t, "x", config["b"].Value)
+
+	assert.Equal(t, config, config.Merge(nil))
+	assert.Equal(t, override, ParameterMap(nil).Merge(override))
+}
+
+func TestParameterMap_Filter(t *testing.T) {
+	params := ParameterMap{
+		"log.level":       {Value: "info", Type: "string"},
+		"log.file.name":   {Value: "tidb.log", Type: "string"},
+		"max-connections": {Value: 0, Type: "int"},
+	}
+
+	logParams := params.Filter(func(name string, _ ParameterValue) bool {
+		return len(name) > 4 && name[:4] == "log."
+	})
+	assert.Equal(t, []string{"log.file.name", "log.level"}, logParams.Keys())
+
+	ints := params.Filter(func(_ string, value ParameterValue) bool {
+		return value.Type == "int"
+	})
+	assert.Equal(t, []string{"max-connections"}, ints.Keys())
+	assert.Len(t, params, 3)
+}
+
+func TestParameterMap_Keys(t *testing.T) {
+	params := ParameterMap{"b": {}, "a": {}, "c": {}}
+	assert.Equal(t, []string{"a", "b", "c"}, params.Keys())
+	assert.Empty(t, ParameterMap(nil).Keys())
+}
+
+func TestComponentState_Parameters(t *testing.T) {
+	state := &ComponentState{
+		Config: ParameterMap{
+			"log.level":       {Value: "info", Type: "string"},
+			"max-connections": {Value: 1000, Type: "int"},
+		},
+		Variables: ParameterMap{
+			"tidb_txn_mode":      {Value: "pessimistic", Type: "string"},
+			"max_allowed_packet": {Value: "67108864", Type: "string"},
+		},
+	}
+
+	assert.Equal(t, 4, state.ParameterCount())
+	assert.Equal(t, []string{"log.level", "max-connections"}, state.ParameterNames(false))
+	assert.Equal(t, []string{"log.level", "max-connections", "sysvar:max_allowed_packet", "sysvar:tidb_txn_mode"}, state.ParameterNames(true))
+
+	value, ok := state.GetParam("log.level")
+	assert.True(t, ok)
+	assert.Equal(t, "info", value)
+	value, ok = state.GetParam("sysvar:tidb_txn_mode")
+	assert.True(t, ok)
+	assert.Equal(t, "pessimistic", value)
+	// Unprefixed names fall back to the system variables
+	value, ok = state.GetParam("tidb_txn_mode")
+	assert.True(t, ok)
+	assert.Equal(t, "pessimistic", value)
+	// Prefixed names are never looked up in Config
+	_, ok = state.GetParam("sysvar:log.level")
+	assert.False(t, ok)
+	_, ok = state.GetParam("missing")
+	assert.False(t, ok)
+
+	empty := &ComponentState{}
+	assert.Zero(t, empty.ParameterCount())
+	assert.Empty(t, empty.ParameterNames(true))
+}
+
 func TestConvertConfigToDefaults(t *testing.T) {
 	tests := []struct {
 		name   string
 		config map[string]interface{}
-		want   ConfigDefaults
+		want   ParameterMap
 	}{
 		{
 			name:   "empty config",
 			config: make(map[string]interface{}),
-			want:   make(ConfigDefaults),
+			want:   make(ParameterMap),
 		},
 		{
 			name: "with values",
@@ -149,7 +235,7 @@ func TestConvertConfigToDefaults(t *testing.T) {
 				"max-connections": 1000,
 				"port":            4000,
 			},
-			want: ConfigDefaults{
+			want: ParameterMap{
 				"max-connections": ParameterValue{Value: 1000, Type: "int"},
 				"port":            ParameterValue{Value: 4000, Type: "int"},
 			},
@@ -171,19 +257,19 @@ func TestConvertVariablesToSystemVariables(t *testing.T) {
 	tests := []struct {
 		name      string
 		variables map[string]string
-		want      SystemVariables
+		want      ParameterMap
 	}{
 		{
 			name:      "empty variables",
 			variables: make(map[string]string),
-			want:      make(SystemVariables),
+			want:      make(ParameterMap),
 		},
 		{
 			name: "with values",
 			variables: map[string]string{
 				"tidb_mem_quota_query": "1073741824",
 			},
-			want: SystemVariables{
+			want: ParameterMap{
 				"tidb_mem_quota_query": ParameterValue{Value: "1073741824", Type: "string"},
 			},
 		},
@@ -211,10 +297,10 @@ func TestComponentState_JSON(t *testing.T) {
 			state: ComponentState{
 				Type:    ComponentTiDB,
 				Version: "v7.5.0",
-				Config: ConfigDefaults{
+				Config: ParameterMap{
 					"max-connections": ParameterValue{Value: 1000, Type: "int"},
 				},
-				Variables: SystemVariables{
+				Variables: ParameterMap{
 					"tidb_mem_quota_query": ParameterValue{Value: "1073741824", Type: "string"},
 				},
 				Status: map[string]interface{}{
@@ -228,7 +314,7 @@ func TestComponentState_JSON(t *testing.T) {
 			state: ComponentState{
 				Type:    ComponentPD,
 				Version: "v7.5.0",
-				Config: ConfigDefaults{
+				Config: ParameterMap{
 					"max-request-size": ParameterValue{Value: 100, Type: "int"},
 				},
 				Status: make(map[string]interface{}),
@@ -240,7 +326,7 @@ func TestComponentState_JSON(t *testing.T) {
 			state: ComponentState{
 				Type:    ComponentTiKV,
 				Version: "",
-				Config:  make(ConfigDefaults),
+				Config:  make(ParameterMap),
 				Status:  make(map[string]interface{}),
 			},
 			wantErr: false,
@@ -286,10 +372,10 @@ func TestClusterSnapshot_JSON(t *testing.T) {
 					"tidb": {
 						Type:    ComponentTiDB,
 						Version: "v7.5.0",
-						Config: ConfigDefaults{
+						Config: ParameterMap{
 							"max-connections": ParameterValue{Value: 1000, Type: "int"},
 						},
-						Variables: SystemVariables{
+						Variables: ParameterMap{
 							"tidb_mem_quota_query": ParameterValue{Value: "1073741824", Type: "string"},
 						},
 						Status: make(map[string]interface{}),
@@ -297,7 +383,7 @@ func TestClusterSnapshot_JSON(t *testing.T) {
 					"pd": {
 						Type:    ComponentPD,
 						Version: "v7.5.0",
-						Config: ConfigDefaults{
+						Config: ParameterMap{
 							"max-request-size": ParameterValue{Value: 100, Type: "int"},
 						},
 						Status: make(map[string]interface{}),
@@ -315,7 +401,7 @@ func TestClusterSnapshot_JSON(t *testing.T) {
 					"tidb": {
 						Type:    ComponentTiDB,
 						Version: "v7.5.0",
-						Config:  make(ConfigDefaults),
+						Config:  make(ParameterMap),
 						Status:  make(map[string]interface{}),
 					},
 				},
@@ -355,6 +441,49 @@ func TestClusterSnapshot_JSON(t *testing.T) {
 	}
 }
 
+func TestClusterSnapshot_ComponentByType(t *testing.T) {
+	snapshot := &ClusterSnapshot{
+		Components: map[string]ComponentState{
+			"tidb":                  {Type: ComponentTiDB, Version: "v7.5.0"},
+			"tikv":                  {Type: ComponentTiKV, Status: map[string]interface{}{"address": "10.0.0.1:20160"}},
+			"tikv-10-0-0-1-20160":   {Type: ComponentTiKV, Status: map[string]interface{}{"address": "10.0.0.1:20160"}},
+			"tikv-10-0-0-2-20160":   {Type: ComponentTiKV, Status: map[string]interface{}{"address": "10.0.0.2:20160"}},
+			"tiflash-10-0-0-3-3930": {Status: map[string]interface{}{"address": "10.0.0.3:3930"}},
+		},
+	}
+
+	tidb, ok := snapshot.ComponentByType(ComponentTiDB)
+	require.True(t, ok)
+	assert.Equal(t, "v7.5.0", tidb.Version)
+
+	// The component keyed by the type name is preferred
+	tikv, ok := snapshot.ComponentByType(ComponentTiKV)
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.1:20160", tikv.Status["address"])
+
+	// Components without a type are matched by key prefix
+	tiflash, ok := snapshot.ComponentByType(ComponentTiFlash)
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.3:3930", tiflash.Status["address"])
+
+	_, ok = snapshot.ComponentByType(ComponentPD)
+	assert.False(t, ok)
+	assert.False(t, snapshot.HasComponent(ComponentPD))
+	assert.True(t, snapshot.HasComponent(ComponentTiFlash))
+
+	// The "tikv" alias of the first node is not returned as a separate instance
+	tikvNodes := snapshot.ComponentsByType(ComponentTiKV)
+	require.Len(t, tikvNodes, 2)
+	assert.Equal(t, "10.0.0.1:20160", tikvNodes[0].Status["address"])
+	assert.Equal(t, "10.0.0.2:20160", tikvNodes[1].Status["address"])
+	assert.Len(t, snapshot.ComponentsByType(ComponentTiDB), 1)
+
+	var nilSnapshot *ClusterSnapshot
+	_, ok = nilSnapshot.ComponentByType(ComponentTiDB)
+	assert.False(t, ok)
+	assert.Empty(t, nilSnapshot.ComponentsByType(ComponentTiKV))
+}
+
 func TestClusterEndpoints_JSON(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -429,7 +558,7 @@ func TestInstanceState_JSON(t *testing.T) {
 				State: ComponentState{
 					Type:    ComponentTiDB,
 					Version: "v7.5.0",
-					Config: ConfigDefaults{
+					Config: ParameterMap{
 						"max-connections": ParameterValue{Value: 1000, Type: "int"},
 					},
 					Status: make(map[string]interface{}),
@@ -444,7 +573,7 @@ func TestInstanceState_JSON(t *testing.T) {
 				State: ComponentState{
 					Type:    ComponentPD,
 					Version: "v7.5.0",
-					Config:  make(ConfigDefaults),
+					Config:  make(ParameterMap),
 					Status:  make(map[string]interface{}),
 				},
 			},
@@ -489,7 +618,7 @@ func TestClusterState_JSON(t *testing.T) {
 						State: ComponentState{
 							Type:    ComponentTiDB,
 							Version: "v7.5.0",
-							Config:  make(ConfigDefaults),
+							Config:  make(ParameterMap),
 							Status:  make(map[string]interface{}),
 						},
 					},
@@ -498,7 +627,7 @@ func TestClusterState_JSON(t *testing.T) {
 						State: ComponentState{
 							Type:    ComponentPD,
 							Version: "v7.5.0",
-							Config:  make(ConfigDefaults),
+							Config:  make(ParameterMap),
 							Status:  make(map[string]interface{}),
 						},
 					},
@@ -0,0 +1,67 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// GCSafePointState is the garbage collection state of a cluster, checked before an upgrade window:
+// a GC safepoint that stopped advancing, or a backup, restore or import holding a PD service safepoint
+type GCSafePointState struct {
+	// GCSafePoint is the tikv_gc_safe_point row of mysql.tidb, as stored by the GC worker
+	// (e.g., "20240102-10:00:00.000 +0800"). Empty if mysql.tidb could not be read
+	GCSafePoint string `json:"gc_safe_point,omitempty"`
+	// GCLastRunTime is the tikv_gc_last_run_time row of mysql.tidb, as stored by the GC worker
+	GCLastRunTime string `json:"gc_last_run_time,omitempty"`
+	// PDGCSafePoint is the GC safepoint TSO reported by PD (0 if not available)
+	PDGCSafePoint uint64 `json:"pd_gc_safe_point,omitempty"`
+	// ServiceSafePointsAvailable is false if PD doesn't provide the service safepoint list API
+	// (older versions) or it could not be read. Only the TiDB side is checked then
+	ServiceSafePointsAvailable bool `json:"service_safe_points_available"`
+	// ServiceSafePoints are the service GC safepoints registered in PD (gc_worker, BR, Lightning, TiCDC, ...)
+	ServiceSafePoints []ServiceSafePoint `json:"service_safe_points,omitempty"`
+}
+
+// ServiceSafePoint is a service GC safepoint registered in PD
+// GC doesn't advance past the safepoint of any service until the safepoint expires
+type ServiceSafePoint struct {
+	// ServiceID identifies the service (e.g., "gc_worker", "br-<uuid>", "lightning-<uuid>", "ticdc-default-...")
+	ServiceID string `json:"service_id"`
+	// ExpiredAt is the Unix time (seconds) at which the safepoint expires (math.MaxInt64 if it never does)
+	ExpiredAt int64 `json:"expired_at"`
+	// SafePoint is the safepoint TSO
+	SafePoint uint64 `json:"safe_point"`
+}
+
+// Active checks if the safepoint is still in effect at now
+func (s ServiceSafePoint) Active(now time.Time) bool {
+	return s.ExpiredAt > now.Unix()
+}
+
+// tsoPhysicalShift is the number of logical bits of a TSO, the physical time (ms) is in the upper bits
+const tsoPhysicalShift = 18
+
+// TSOPhysicalTime returns the physical time of a TSO
+func TSOPhysicalTime(tso uint64) time.Time {
+	return time.UnixMilli(int64(tso >> tsoPhysicalShift))
+}
+
+// gcTimeFormats are the formats of the times stored in mysql.tidb by the GC worker
+var gcTimeFormats = []string{
+	"20060102-15:04:05.000 -0700",
+	"20060102-15:04:05 -0700",
+	"20060102-15:04:05.000 -0700 MST",
+	"20060102-15:04:05 -0700 MST",
+}
+
+// ParseGCTime parses a time stored in mysql.tidb by the GC worker (tikv_gc_safe_point, tikv_gc_last_run_time)
+func ParseGCTime(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	for _, format := range gcTimeFormats {
+		if t, err := time.Parse(format, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid GC time %q (expected format 20060102-15:04:05.000 -0700)", value)
+}
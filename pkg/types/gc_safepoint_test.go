@@ -0,0 +1,39 @@
+package types
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseGCTime(t *testing.T) {
+	expected := time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)
+
+	parsed, err := ParseGCTime("20240102-10:00:00.000 +0800")
+	require.NoError(t, err)
+	assert.True(t, expected.Equal(parsed))
+
+	// Older versions store the zone name as well
+	parsed, err = ParseGCTime("20240102-10:00:00 +0800 CST")
+	require.NoError(t, err)
+	assert.True(t, expected.Equal(parsed))
+
+	_, err = ParseGCTime("2024-01-02 10:00:00")
+	assert.Error(t, err)
+}
+
+func TestTSOPhysicalTime(t *testing.T) {
+	physical := time.Date(2024, 1, 2, 2, 0, 0, 0, time.UTC)
+	tso := uint64(physical.UnixMilli())<<tsoPhysicalShift | 42
+	assert.True(t, physical.Equal(TSOPhysicalTime(tso)))
+}
+
+func TestServiceSafePoint_Active(t *testing.T) {
+	now := time.Unix(1704160000, 0)
+	assert.True(t, ServiceSafePoint{ExpiredAt: math.MaxInt64}.Active(now))
+	assert.True(t, ServiceSafePoint{ExpiredAt: now.Unix() + 1}.Active(now))
+	assert.False(t, ServiceSafePoint{ExpiredAt: now.Unix()}.Active(now))
+}
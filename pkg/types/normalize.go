@@ -0,0 +1,97 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NormalizeParamValue coerces a raw runtime value to the type of a knowledge base parameter
+// paramType is the Type of the knowledge base ParameterValue ("bool", "int", "float", "string")
+// Runtime values come as strings from the PD and TiDB APIs ("true", "1024") while knowledge base
+// defaults are typed; after normalization they can be compared with the defaults:
+//   - "bool": "true"/"false", "on"/"off", "1"/"0" (case-insensitive) and the numbers 1/0 become a bool
+//   - "int", "float": numeric strings (including scientific notation) and integers become a float64,
+//     the type of JSON numbers in the knowledge base
+//   - "string": bools and numbers are formatted as strings ("true", "1024")
+//
+// Values that cannot be coerced, and values of other types (size, duration, array, map), are returned unchanged
+func NormalizeParamValue(raw interface{}, paramType string) interface{} {
+	if raw == nil {
+		return nil
+	}
+	switch paramType {
+	case "bool":
+		if b, ok := toBool(raw); ok {
+			return b
+		}
+	case "int", "float":
+		if f, ok := toFloat(raw); ok {
+			return f
+		}
+	case "string":
+		switch v := raw.(type) {
+		case bool:
+			return strconv.FormatBool(v)
+		case string:
+			return v
+		}
+		if f, ok := toFloat(raw); ok {
+			return strconv.FormatFloat(f, 'f', -1, 64)
+		}
+	}
+	return raw
+}
+
+// toBool converts a bool, a boolean keyword or the numbers 0/1 to a bool
+func toBool(raw interface{}) (bool, bool) {
+	switch v := raw.(type) {
+	case bool:
+		return v, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "true", "on", "1":
+			return true, true
+		case "false", "off", "0":
+			return false, true
+		}
+		return false, false
+	}
+	if f, ok := toFloat(raw); ok && (f == 0 || f == 1) {
+		return f == 1, true
+	}
+	return false, false
+}
+
+// toFloat converts a number or a numeric string to a float64
+func toFloat(raw interface{}) (float64, bool) {
+	switch v := raw.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	}
+	return 0, false
+}
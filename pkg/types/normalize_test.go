@@ -0,0 +1,52 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeParamValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       interface{}
+		paramType string
+		want      interface{}
+	}{
+		{"bool from true", "true", "bool", true},
+		{"bool from FALSE", "FALSE", "bool", false},
+		{"bool from ON", "ON", "bool", true},
+		{"bool from off with spaces", " off ", "bool", false},
+		{"bool from string 1", "1", "bool", true},
+		{"bool from string 0", "0", "bool", false},
+		{"bool from number 1", float64(1), "bool", true},
+		{"bool from int 0", 0, "bool", false},
+		{"bool unchanged", true, "bool", true},
+		{"bool invalid", "maybe", "bool", "maybe"},
+		{"bool number out of range", 2, "bool", 2},
+		{"int from string", "1024", "int", float64(1024)},
+		{"int from int", 1024, "int", float64(1024)},
+		{"int from int64", int64(-3), "int", float64(-3)},
+		{"int from uint32", uint32(7), "int", float64(7)},
+		{"float from string", "0.5", "float", 0.5},
+		{"float from scientific notation", "1.44e+06", "float", float64(1440000)},
+		{"float from float32", float32(0.25), "float", 0.25},
+		{"float invalid", "64MiB", "float", "64MiB"},
+		{"float from bool", true, "float", true},
+		{"string from bool", true, "string", "true"},
+		{"string from float", float64(1024), "string", "1024"},
+		{"string from fraction", 0.8, "string", "0.8"},
+		{"string from int", 3, "string", "3"},
+		{"string unchanged", "1024", "string", "1024"},
+		{"string from map", map[string]interface{}{"a": 1}, "string", map[string]interface{}{"a": 1}},
+		{"size unchanged", "1GiB", "size", "1GiB"},
+		{"duration unchanged", "10m", "duration", "10m"},
+		{"unknown type", "true", "", "true"},
+		{"nil", nil, "bool", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeParamValue(tt.raw, tt.paramType))
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package types
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Equal compares two parameter values guided by their Type (the Type of p, or of other if p has none)
+//   - "int", "float", "number": compared as float64, so float64(256) equals int64(256) and "256"
+//   - "bool": compared as bool, so "ON", "1" and true are equal
+//   - "size": compared in bytes, binary and decimal unit names are the same (TiKV sizes are binary: 1GB is 1GiB)
+//   - "duration": compared in nanoseconds, with the units ns, us, ms, s, m, h and d ("1h30m" equals "90m")
+//   - "string": compared ignoring case
+//
+// Values of other types, and values that cannot be converted to the type, are compared untyped:
+// numbers and numeric strings numerically, other values by their formatted value
+// An unset (nil) value equals an unset value or the empty string
+func (p ParameterValue) Equal(other ParameterValue) bool {
+	v1, v2 := p.Value, other.Value
+	if v1 == nil || v2 == nil {
+		return isUnsetOrEmpty(v1) && isUnsetOrEmpty(v2)
+	}
+
+	paramType := p.Type
+	if paramType == "" {
+		paramType = other.Type
+	}
+	switch paramType {
+	case "int", "float", "number":
+		if f1, ok1 := toFloat(v1); ok1 {
+			if f2, ok2 := toFloat(v2); ok2 {
+				return f1 == f2
+			}
+		}
+	case "bool":
+		if b1, ok1 := toBool(v1); ok1 {
+			if b2, ok2 := toBool(v2); ok2 {
+				return b1 == b2
+			}
+		}
+	case "size":
+		if s1, ok1 := ParseSize(v1); ok1 {
+			if s2, ok2 := ParseSize(v2); ok2 {
+				return s1 == s2
+			}
+		}
+	case "duration":
+		if d1, ok1 := ParseDuration(v1); ok1 {
+			if d2, ok2 := ParseDuration(v2); ok2 {
+				// Allow floating point differences of fractional units (e.g., 1.5s vs 1500ms)
+				return d1-d2 < 1 && d2-d1 < 1
+			}
+		}
+	case "string":
+		s1, ok1 := v1.(string)
+		s2, ok2 := v2.(string)
+		if ok1 && ok2 {
+			return strings.EqualFold(strings.TrimSpace(s1), strings.TrimSpace(s2))
+		}
+	}
+	return untypedEqual(v1, v2)
+}
+
+// Compare orders two parameter values guided by their Type, like Equal:
+// sizes in bytes, durations in nanoseconds, other values as numbers
+// Returns -1, 0 or 1, and false if either value can't be converted (e.g., a non-numeric string)
+func (p ParameterValue) Compare(other ParameterValue) (int, bool) {
+	paramType := p.Type
+	if paramType == "" {
+		paramType = other.Type
+	}
+	convert := toFloat
+	switch paramType {
+	case "size":
+		convert = ParseSize
+	case "duration":
+		convert = ParseDuration
+	}
+	f1, ok1 := convert(p.Value)
+	f2, ok2 := convert(other.Value)
+	if !ok1 || !ok2 {
+		return 0, false
+	}
+	switch {
+	case f1 < f2:
+		return -1, true
+	case f1 > f2:
+		return 1, true
+	}
+	return 0, true
+}
+
+// isUnsetOrEmpty checks if a value is unset (nil) or the empty string
+func isUnsetOrEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	s, ok := v.(string)
+	return ok && s == ""
+}
+
+// untypedEqual compares two values of unknown type: numerically if both are numbers or numeric strings,
+// by their formatted value otherwise
+func untypedEqual(v1, v2 interface{}) bool {
+	if f1, ok1 := toFloat(v1); ok1 {
+		if f2, ok2 := toFloat(v2); ok2 {
+			return f1 == f2
+		}
+	}
+	return reflect.DeepEqual(v1, v2) || fmt.Sprintf("%v", v1) == fmt.Sprintf("%v", v2)
+}
+
+// sizeUnits are the byte multipliers of the size units (lower case, without the trailing "b" or "ib")
+var sizeUnits = map[string]float64{
+	"":  1,
+	"k": 1 << 10,
+	"m": 1 << 20,
+	"g": 1 << 30,
+	"t": 1 << 40,
+	"p": 1 << 50,
+}
+
+var sizePattern = regexp.MustCompile(`^([0-9]*\.?[0-9]+)\s*([kmgtp]?)(i?b)?$`)
+
+// ParseSize converts a size (e.g., "64MB", "1GiB", "512", 1024) to bytes
+// Binary and decimal unit names are the same: TiKV reads 1GB as 1GiB
+func ParseSize(v interface{}) (float64, bool) {
+	if s, ok := v.(string); ok {
+		matches := sizePattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(s)))
+		if matches == nil {
+			return 0, false
+		}
+		num, err := strconv.ParseFloat(matches[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		return num * sizeUnits[matches[2]], true
+	}
+	return toFloat(v)
+}
+
+// durationUnits are the nanoseconds of the duration units
+var durationUnits = map[string]float64{
+	"ns": 1,
+	"us": 1e3,
+	"µs": 1e3,
+	"ms": 1e6,
+	"s":  1e9,
+	"m":  60 * 1e9,
+	"h":  3600 * 1e9,
+	"d":  86400 * 1e9,
+}
+
+var durationPartPattern = regexp.MustCompile(`([0-9]*\.?[0-9]+)(ns|us|µs|ms|s|m|h|d)`)
+
+// ParseDuration converts a duration (e.g., "10m", "1h30m", "1.5s", "0") to nanoseconds
+// Numbers other than 0 have no unit and are not durations
+func ParseDuration(v interface{}) (float64, bool) {
+	s, ok := v.(string)
+	if !ok {
+		if f, ok := toFloat(v); ok && f == 0 {
+			return 0, true
+		}
+		return 0, false
+	}
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "0" {
+		return 0, true
+	}
+	if s == "" || durationPartPattern.ReplaceAllString(s, "") != "" {
+		return 0, false
+	}
+	var total float64
+	for _, part := range durationPartPattern.FindAllStringSubmatch(s, -1) {
+		num, err := strconv.ParseFloat(part[1], 64)
+		if err != nil {
+			return 0, false
+		}
+		total += num * durationUnits[part[2]]
+	}
+	return total, true
+}
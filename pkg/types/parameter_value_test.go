@@ -0,0 +1,71 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParameterValue_Equal(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  ParameterValue
+		equal bool
+	}{
+		{"int float64 vs int64", ParameterValue{Value: float64(256), Type: "int"}, ParameterValue{Value: int64(256)}, true},
+		{"int vs numeric string", ParameterValue{Value: 256, Type: "int"}, ParameterValue{Value: "256"}, true},
+		{"float scientific notation", ParameterValue{Value: "1.44e+06", Type: "float"}, ParameterValue{Value: float64(1440000)}, true},
+		{"number differs", ParameterValue{Value: 256, Type: "number"}, ParameterValue{Value: 512}, false},
+		{"type of other", ParameterValue{Value: "1"}, ParameterValue{Value: "ON", Type: "bool"}, true},
+		{"bool keywords", ParameterValue{Value: "ON", Type: "bool"}, ParameterValue{Value: true}, true},
+		{"bool differs", ParameterValue{Value: "OFF", Type: "bool"}, ParameterValue{Value: "1"}, false},
+		{"size binary vs decimal", ParameterValue{Value: "1GiB", Type: "size"}, ParameterValue{Value: "1GB"}, true},
+		{"size units", ParameterValue{Value: "1GB", Type: "size"}, ParameterValue{Value: "1024MB"}, true},
+		{"size bytes", ParameterValue{Value: "64KB", Type: "size"}, ParameterValue{Value: float64(65536)}, true},
+		{"size differs", ParameterValue{Value: "64MB", Type: "size"}, ParameterValue{Value: "64KB"}, false},
+		{"duration units", ParameterValue{Value: "1m", Type: "duration"}, ParameterValue{Value: "60s"}, true},
+		{"duration composite", ParameterValue{Value: "1h30m", Type: "duration"}, ParameterValue{Value: "90m"}, true},
+		{"duration fraction", ParameterValue{Value: "1.5s", Type: "duration"}, ParameterValue{Value: "1500ms"}, true},
+		{"duration days", ParameterValue{Value: "1d", Type: "duration"}, ParameterValue{Value: "24h"}, true},
+		{"duration zero", ParameterValue{Value: "0s", Type: "duration"}, ParameterValue{Value: "0"}, true},
+		{"duration differs", ParameterValue{Value: "10m", Type: "duration"}, ParameterValue{Value: "10s"}, false},
+		{"string ignoring case", ParameterValue{Value: "Snappy", Type: "string"}, ParameterValue{Value: "snappy"}, true},
+		{"string differs", ParameterValue{Value: "lz4", Type: "string"}, ParameterValue{Value: "zstd"}, false},
+		{"unset vs empty", ParameterValue{Value: nil, Type: "string"}, ParameterValue{Value: ""}, true},
+		{"unset vs set", ParameterValue{Value: nil}, ParameterValue{Value: "x"}, false},
+		{"unconvertible falls back untyped", ParameterValue{Value: "auto", Type: "size"}, ParameterValue{Value: "auto"}, true},
+		{"untyped numbers", ParameterValue{Value: float64(3)}, ParameterValue{Value: int32(3)}, true},
+		{"untyped strings are case-sensitive", ParameterValue{Value: "Auto"}, ParameterValue{Value: "auto"}, false},
+		{"array", ParameterValue{Value: []interface{}{"a", "b"}, Type: "array"}, ParameterValue{Value: []interface{}{"a", "b"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.equal, tt.a.Equal(tt.b))
+			assert.Equal(t, tt.equal, tt.b.Equal(tt.a), "symmetric")
+		})
+	}
+}
+
+func TestParameterValue_Compare(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b ParameterValue
+		want int
+		ok   bool
+	}{
+		{"numbers", ParameterValue{Value: float64(256), Type: "int"}, ParameterValue{Value: int64(512)}, -1, true},
+		{"numeric string", ParameterValue{Value: "1024"}, ParameterValue{Value: 512}, 1, true},
+		{"size units", ParameterValue{Value: "2GiB", Type: "size"}, ParameterValue{Value: "1024MB"}, 1, true},
+		{"size bytes", ParameterValue{Value: float64(107374182400), Type: "size"}, ParameterValue{Value: "100GiB"}, 0, true},
+		{"duration units", ParameterValue{Value: "90s", Type: "duration"}, ParameterValue{Value: "2m"}, -1, true},
+		{"not a number", ParameterValue{Value: "auto", Type: "int"}, ParameterValue{Value: 1}, 0, false},
+		{"untyped size", ParameterValue{Value: "1GiB"}, ParameterValue{Value: 1}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.a.Compare(tt.b)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package types
+
+// PlacementState is the inventory of the resource control and placement objects of a cluster
+// Resource groups and placement policies interact with some upgrade paths (RU accounting changes,
+// placement rules referencing store labels), so they are reviewed before an upgrade
+type PlacementState struct {
+	// ResourceGroupsAvailable is false if information_schema.resource_groups could not be read
+	// (versions without resource control, or missing privileges), see Notes
+	ResourceGroupsAvailable bool `json:"resource_groups_available"`
+	// ResourceGroups are the rows of information_schema.resource_groups
+	ResourceGroups []ResourceGroup `json:"resource_groups,omitempty"`
+	// PlacementPoliciesAvailable is false if information_schema.placement_policies could not be read
+	// (versions without placement policies, or missing privileges), see Notes
+	PlacementPoliciesAvailable bool `json:"placement_policies_available"`
+	// PlacementPolicies are the rows of information_schema.placement_policies
+	PlacementPolicies []PlacementPolicy `json:"placement_policies,omitempty"`
+	// StoresAvailable is false if the stores could not be read from PD
+	StoresAvailable bool `json:"stores_available"`
+	// Stores are the stores registered in PD, with their labels
+	Stores []StoreLabels `json:"stores,omitempty"`
+	// Notes explain why some of the data is not available
+	Notes []string `json:"notes,omitempty"`
+	// MissingPrivileges are the queries denied for missing privileges (query IDs of the TiDB collector), see Notes
+	MissingPrivileges []string `json:"missing_privileges,omitempty"`
+}
+
+// ResourceGroup is a row of information_schema.resource_groups
+type ResourceGroup struct {
+	// Name is the NAME column
+	Name string `json:"name"`
+	// RUPerSec is the RU_PER_SEC column, a number or "UNLIMITED"
+	RUPerSec string `json:"ru_per_sec"`
+	// Priority is the PRIORITY column (LOW, MEDIUM, HIGH)
+	Priority string `json:"priority,omitempty"`
+	// Burstable is the BURSTABLE column (YES or NO)
+	Burstable string `json:"burstable,omitempty"`
+}
+
+// PlacementPolicy is a row of information_schema.placement_policies
+type PlacementPolicy struct {
+	// Name is the POLICY_NAME column
+	Name string `json:"name"`
+	// PrimaryRegion is the PRIMARY_REGION column, the value of the "region" label of the leaders
+	PrimaryRegion string `json:"primary_region,omitempty"`
+	// Regions is the REGIONS column, comma separated values of the "region" label
+	Regions string `json:"regions,omitempty"`
+	// Constraints is the CONSTRAINTS column (e.g., "[+disk=ssd]" or "{\"+zone=sh\": 1}")
+	Constraints string `json:"constraints,omitempty"`
+	// LeaderConstraints is the LEADER_CONSTRAINTS column
+	LeaderConstraints string `json:"leader_constraints,omitempty"`
+	// FollowerConstraints is the FOLLOWER_CONSTRAINTS column
+	FollowerConstraints string `json:"follower_constraints,omitempty"`
+	// LearnerConstraints is the LEARNER_CONSTRAINTS column
+	LearnerConstraints string `json:"learner_constraints,omitempty"`
+	// Schedule is the SCHEDULE column (EVEN, MAJORITY_IN_PRIMARY)
+	Schedule string `json:"schedule,omitempty"`
+	// Followers is the FOLLOWERS column
+	Followers int `json:"followers,omitempty"`
+	// Learners is the LEARNERS column
+	Learners int `json:"learners,omitempty"`
+}
+
+// StoreLabels is a store registered in PD, with its labels
+type StoreLabels struct {
+	// ID is the store ID
+	ID uint64 `json:"id"`
+	// Address is the address of the store
+	Address string `json:"address"`
+	// Labels maps label keys to values (e.g., "zone": "z1")
+	Labels map[string]string `json:"labels,omitempty"`
+}
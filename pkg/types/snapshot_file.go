@@ -0,0 +1,56 @@
+package types
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// snapshotFile is the file format read by LoadClusterSnapshot
+// It accepts both a saved ClusterSnapshot and a baseline capture of baseline-validator,
+// which names the source version "version" and stores the timestamp as a RFC3339 string
+type snapshotFile struct {
+	ClusterSnapshot
+	// Timestamp shadows ClusterSnapshot.Timestamp so that an empty or missing timestamp is accepted
+	Timestamp string `json:"timestamp"`
+	// Version is the source version of a baseline capture
+	Version string `json:"version"`
+}
+
+// SaveClusterSnapshot saves a cluster snapshot to a file
+// The file can be given to a later run with --changed-since (see LoadClusterSnapshot)
+func SaveClusterSnapshot(snapshot *ClusterSnapshot, outputPath string) error {
+	if snapshot.Timestamp.IsZero() {
+		snapshot.Timestamp = time.Now().UTC()
+	}
+	return saveJSON(snapshot, outputPath)
+}
+
+// LoadClusterSnapshot reads a cluster snapshot saved with SaveClusterSnapshot or a baseline capture
+func LoadClusterSnapshot(path string) (*ClusterSnapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var file snapshotFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("%s is not a cluster snapshot: %w", path, err)
+	}
+	if len(file.Components) == 0 {
+		return nil, fmt.Errorf("%s is not a cluster snapshot: no components", path)
+	}
+
+	snapshot := file.ClusterSnapshot
+	if file.Timestamp != "" {
+		timestamp, err := time.Parse(time.RFC3339Nano, file.Timestamp)
+		if err != nil {
+			return nil, fmt.Errorf("%s: invalid timestamp %q: %w", path, file.Timestamp, err)
+		}
+		snapshot.Timestamp = timestamp
+	}
+	if snapshot.SourceVersion == "" {
+		snapshot.SourceVersion = file.Version
+	}
+	return &snapshot, nil
+}
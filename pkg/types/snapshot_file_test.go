@@ -0,0 +1,66 @@
+package types
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveAndLoadClusterSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots", "snapshot.json")
+	snapshot := &ClusterSnapshot{
+		Timestamp:     time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC),
+		SourceVersion: "v7.5.0",
+		Components: map[string]ComponentState{
+			"tidb": {
+				Type:      ComponentTiDB,
+				Config:    ParameterMap{"max-connections": {Value: float64(1000)}},
+				Variables: ParameterMap{"tidb_mem_quota_query": {Value: "1073741824"}},
+			},
+		},
+	}
+	require.NoError(t, SaveClusterSnapshot(snapshot, path))
+
+	loaded, err := LoadClusterSnapshot(path)
+	require.NoError(t, err)
+	assert.True(t, snapshot.Timestamp.Equal(loaded.Timestamp))
+	assert.Equal(t, "v7.5.0", loaded.SourceVersion)
+	assert.Equal(t, float64(1000), loaded.Components["tidb"].Config["max-connections"].Value)
+	assert.Equal(t, "1073741824", loaded.Components["tidb"].Variables["tidb_mem_quota_query"].Value)
+}
+
+func TestLoadClusterSnapshot_BaselineCapture(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	// baseline-validator captures name the version "version" and store the timestamp as a string
+	loaded, err := LoadClusterSnapshot(write("baseline.json", `{
+		"version": "v7.5.0",
+		"timestamp": "2026-01-02T15:04:05Z",
+		"components": {"tidb": {"type": "tidb", "config": {"max-connections": {"value": 1000, "type": "int"}}}}
+	}`))
+	require.NoError(t, err)
+	assert.Equal(t, "v7.5.0", loaded.SourceVersion)
+	assert.Equal(t, time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC), loaded.Timestamp.UTC())
+	assert.Contains(t, loaded.Components["tidb"].Config, "max-connections")
+
+	// A missing timestamp is accepted
+	loaded, err = LoadClusterSnapshot(write("no_timestamp.json", `{"components": {"pd": {"type": "pd"}}}`))
+	require.NoError(t, err)
+	assert.True(t, loaded.Timestamp.IsZero())
+
+	_, err = LoadClusterSnapshot(write("report.json", `{"source_version": "v7.5.0", "check_results": []}`))
+	assert.ErrorContains(t, err, "no components")
+	_, err = LoadClusterSnapshot(write("bad_timestamp.json", `{"timestamp": "yesterday", "components": {"pd": {}}}`))
+	assert.ErrorContains(t, err, "invalid timestamp")
+	_, err = LoadClusterSnapshot(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
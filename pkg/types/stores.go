@@ -0,0 +1,37 @@
+package types
+
+// StoreState is PD's view of the TiKV stores, checked against the TiKV nodes before a rolling upgrade
+// The upgrade relies on the store metadata in PD: a store whose recorded version or state is stale
+// (e.g., a node patched manually, or a store being removed) confuses it
+type StoreState struct {
+	// Stores are the TiKV stores registered in PD, including the Offline and Tombstone ones (TiFlash stores excluded)
+	Stores []StoreInfo `json:"stores"`
+	// TopologyAddresses are the TiKV addresses the precheck was given (topology file or --tikv-addrs)
+	TopologyAddresses []string `json:"topology_addresses,omitempty"`
+}
+
+// Store states reported by PD (StoreInfo.StateName)
+const (
+	StoreStateUp        = "Up"
+	StoreStateOffline   = "Offline"
+	StoreStateTombstone = "Tombstone"
+)
+
+// StoreInfo is a store registered in PD, as reported by /pd/api/v1/stores
+type StoreInfo struct {
+	// ID is the store ID
+	ID uint64 `json:"id"`
+	// Address is the gRPC address of the store
+	Address string `json:"address"`
+	// StatusAddress is the status (HTTP API) address of the store, empty for old versions
+	StatusAddress string `json:"status_address,omitempty"`
+	// Version is the version PD recorded for the store
+	Version string `json:"version,omitempty"`
+	// StateName is the state of the store (Up, Disconnected, Down, Offline, Tombstone)
+	StateName string `json:"state_name,omitempty"`
+}
+
+// MatchesAddress checks if addr is the status or the gRPC address of the store
+func (s StoreInfo) MatchesAddress(addr string) bool {
+	return addr != "" && (addr == s.StatusAddress || addr == s.Address)
+}
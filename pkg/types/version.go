@@ -0,0 +1,67 @@
+package types
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// releaseVersionPattern matches a three-part release version with an optional "v" prefix
+var releaseVersionPattern = regexp.MustCompile(`v?(\d+)\.(\d+)\.(\d+)`)
+
+// userVersionPattern matches a version given by a user: a release version or a version group,
+// with an optional "v" prefix (e.g., "v8.5.1", "8.5.1", "v8.5", "8.5")
+var userVersionPattern = regexp.MustCompile(`^[vV]?(\d+)\.(\d+)(?:\.(\d+))?$`)
+
+// NodeVersion records the version reported by a single component instance
+// Versions are collected per instance so that partially upgraded (mixed-version) clusters can be detected
+type NodeVersion struct {
+	// Component is the type of the component (tidb, pd, tikv, tiflash)
+	Component ComponentType `json:"component"`
+	// Address is the address of the instance
+	Address string `json:"address"`
+	// Version is the normalized release version (e.g., "v7.5.0")
+	Version string `json:"version"`
+	// RawVersion is the version string exactly as reported by the instance
+	RawVersion string `json:"raw_version,omitempty"`
+}
+
+// NormalizeVersion extracts the release version in "vX.Y.Z" form from a version string reported by a component
+// Examples:
+//   - "5.7.25-TiDB-v7.5.0" (TiDB SELECT VERSION()) -> "v7.5.0"
+//   - "7.5.0" (TiKV/PD status API) -> "v7.5.0"
+//   - "v6.5.0-alpha" -> "v6.5.0"
+//
+// Returns an empty string if no release version can be found
+func NormalizeVersion(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	// TiDB reports a MySQL compatible version first (e.g., "5.7.25-TiDB-v7.5.0")
+	// The actual TiDB version follows the "-TiDB-" marker
+	if idx := strings.Index(raw, "-TiDB-"); idx >= 0 {
+		raw = raw[idx+len("-TiDB-"):]
+	}
+
+	match := releaseVersionPattern.FindStringSubmatch(raw)
+	if match == nil {
+		return ""
+	}
+	return "v" + match[1] + "." + match[2] + "." + match[3]
+}
+
+// ParseUserVersion validates a version given by a user (e.g., --target-version) and normalizes it
+// to "vX.Y.Z", or to "vX.Y" for a version group, whose patch version is to be chosen by the caller
+// Examples: "8.5.1" -> "v8.5.1", "v8.5" -> "v8.5" (group)
+func ParseUserVersion(raw string) (version string, isGroup bool, err error) {
+	match := userVersionPattern.FindStringSubmatch(strings.TrimSpace(raw))
+	if match == nil {
+		return "", false, fmt.Errorf("invalid version %q: expected a release version (e.g., v8.5.1 or 8.5.1) or a version group (e.g., v8.5 or 8.5)", raw)
+	}
+	if match[3] == "" {
+		return "v" + match[1] + "." + match[2], true, nil
+	}
+	return "v" + match[1] + "." + match[2] + "." + match[3], false, nil
+}
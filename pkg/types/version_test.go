@@ -0,0 +1,62 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{name: "tidb version()", raw: "5.7.25-TiDB-v7.5.0", want: "v7.5.0"},
+		{name: "tikv status", raw: "7.5.1", want: "v7.5.1"},
+		{name: "with v prefix", raw: "v8.5.0", want: "v8.5.0"},
+		{name: "with suffix", raw: "v6.5.0-alpha", want: "v6.5.0"},
+		{name: "with whitespace", raw: " 7.1.0\n", want: "v7.1.0"},
+		{name: "empty", raw: "", want: ""},
+		{name: "unparseable", raw: "unknown", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, NormalizeVersion(tt.raw))
+		})
+	}
+}
+
+func TestParseUserVersion(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    string
+		isGroup bool
+		wantErr bool
+	}{
+		{raw: "v8.5.1", want: "v8.5.1"},
+		{raw: "8.5.1", want: "v8.5.1"},
+		{raw: " V7.5.0 ", want: "v7.5.0"},
+		{raw: "v8.5", want: "v8.5", isGroup: true},
+		{raw: "8.5", want: "v8.5", isGroup: true},
+		{raw: "8", wantErr: true},
+		{raw: "v8.5.1.2", wantErr: true},
+		{raw: "v8.5.x", wantErr: true},
+		{raw: "latest", wantErr: true},
+		{raw: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			version, isGroup, err := ParseUserVersion(tt.raw)
+			if tt.wantErr {
+				assert.ErrorContains(t, err, "expected a release version")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+			assert.Equal(t, tt.isGroup, isGroup)
+		})
+	}
+}
@@ -1,5 +1,5 @@
 #!/bin/bash
-# Generate full knowledge base for all LTS versions (v6.5.0 and later) using tiup playground
+# Generate full knowledge base for all LTS versions (v5.4.0 and later) using tiup playground
 # This script collects runtime configuration from actual running clusters
 # and merges with code definitions.
 #
@@ -152,7 +152,7 @@ get_version_group() {
 }
 
 # Function to check if version is LTS and standard format (vX.Y.Z only)
-# LTS versions are: v6.5.x, v7.1.x, v7.5.x, v8.1.x, v8.5.x
+# LTS versions are: v5.4.x, v6.5.x, v7.1.x, v7.5.x, v8.1.x, v8.5.x
 is_lts_version() {
     local version="$1"
     
@@ -170,6 +170,7 @@ is_lts_version() {
     
     # Check if version is one of the LTS series
     case "$major.$minor" in
+        5.4) return 0 ;;  # v5.4.x (source version only, sessionctx/ layout)
         6.5) return 0 ;;  # v6.5.x
         7.1) return 0 ;;  # v7.1.x
         7.5) return 0 ;;  # v7.5.x
@@ -179,7 +180,7 @@ is_lts_version() {
     esac
 }
 
-# Function to get versions from git tags (LTS versions only, starting from v6.5.0)
+# Function to get versions from git tags (LTS versions only, starting from v5.4.0)
 get_versions_from_tags() {
     local repo_path="$1"
     local temp_file=$(mktemp)
@@ -194,7 +195,7 @@ get_versions_from_tags() {
     # Use strict pattern to exclude versions like v6.5.0-20230109 or v6.6.0-alpha
     (cd "$repo_path" && git tag -l | grep -E "^v[0-9]+\.[0-9]+\.[0-9]+$" | sort -V) > "$temp_file"
     
-    # Filter LTS versions (v6.5.0 and later) and format as version_group/version
+    # Filter LTS versions (v5.4.0 and later) and format as version_group/version
     while IFS= read -r version; do
         if is_lts_version "$version"; then
             version_group=$(get_version_group "$version")
@@ -213,7 +214,7 @@ if [ -z "$VERSIONS_FILE" ]; then
         TAG_REPO=${TIDB_REPO:-${PROJECT_ROOT}/../tidb}
     fi
     
-    echo "Auto-detecting LTS versions (v6.5.0 and later) from git tags in: $TAG_REPO"
+    echo "Auto-detecting LTS versions (v5.4.0 and later) from git tags in: $TAG_REPO"
     VERSIONS_TEMP=$(mktemp)
     if ! get_versions_from_tags "$TAG_REPO" > "$VERSIONS_TEMP"; then
         echo "Error: Failed to get versions from git tags"
@@ -222,7 +223,7 @@ if [ -z "$VERSIONS_FILE" ]; then
     fi
     
     VERSION_COUNT=$(wc -l < "$VERSIONS_TEMP" | tr -d ' ')
-    echo "Found $VERSION_COUNT LTS versions (v6.5.0 and later) from git tags"
+    echo "Found $VERSION_COUNT LTS versions (v5.4.0 and later) from git tags"
     VERSIONS_FILE="$VERSIONS_TEMP"
     USE_TEMP_FILE=true
 else
@@ -462,7 +463,7 @@ echo "Max concurrent: $MAX_CONCURRENT"
 echo "Components: $COMPONENTS"
 echo ""
 
-# Note: upgrade_logic.json is now automatically generated by kb_generator
+# Note: upgrade_logic.json is now automatically generated by "precheck kb generate all"
 # when TiDB component is included. No need to generate it separately here.
 
 # Function to count running processes
@@ -576,9 +577,9 @@ start_version() {
         VERSION_LOG="${LOGS_DIR}/knowledge_generation_${version}.log"
         echo "[$version] Starting generation at $(date)" >> "$VERSION_LOG"
         
-        echo "  Running in background: go run cmd/kb_generator/main.go ${CMD_ARGS[*]}" | tee -a "$VERSION_LOG"
+        echo "  Running in background: go run ./cmd/precheck kb generate all ${CMD_ARGS[*]}" | tee -a "$VERSION_LOG"
         # Use GOWORK=off to disable workspace mode and avoid replace directive issues
-        if (cd "$PROJECT_ROOT" && GOWORK=off go run cmd/kb_generator/main.go "${CMD_ARGS[@]}" >> "$VERSION_LOG" 2>&1); then
+        if (cd "$PROJECT_ROOT" && GOWORK=off go run ./cmd/precheck kb generate all "${CMD_ARGS[@]}" >> "$VERSION_LOG" 2>&1); then
             echo "[$version] ✓ Successfully generated at $(date)" >> "$VERSION_LOG"
             echo "$version:SUCCESS" >> "${TMP_DIR}/.version_results"
         else
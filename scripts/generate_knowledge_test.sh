@@ -142,7 +142,7 @@ create_test_script() {
     cp "$ORIGINAL_SCRIPT" "$test_script"
     
     # Replace the go run command with our mock
-    sed -i.bak 's|go run cmd/kb_generator/main.go|go run cmd/kb_generator/main.go.mock|g' "$test_script"
+    sed -i.bak 's|go run ./cmd/precheck kb generate all|go run cmd/kb_generator/main.go.mock|g' "$test_script"
     rm -f "$test_script.bak"
     
     # Disable set -e temporarily for testing (we'll handle errors manually)
@@ -42,10 +42,10 @@ func TestFullPrecheckFlow(t *testing.T) {
 			"tidb": {
 				Type:    types.ComponentTiDB,
 				Version: "v7.5.0",
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"max-connections": types.ParameterValue{Value: 2000, Type: "int"}, // Modified from default
 				},
-				Variables: types.SystemVariables{
+				Variables: types.ParameterMap{
 					"tidb_mem_quota_query": types.ParameterValue{Value: 1073741824, Type: "int"},
 				},
 				Status: make(map[string]interface{}),
@@ -53,7 +53,7 @@ func TestFullPrecheckFlow(t *testing.T) {
 			"pd": {
 				Type:    types.ComponentPD,
 				Version: "v7.5.0",
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"max-request-size": types.ParameterValue{Value: 100, Type: "int"},
 				},
 				Status: make(map[string]interface{}),
@@ -141,7 +141,7 @@ func TestFullPrecheckFlow(t *testing.T) {
 				Filename:  "test-report-" + string(format),
 			}
 
-			reportPath, err := reportGenerator.GenerateFromAnalysisResult(analysisResult, options)
+			reportPath, err := reportGenerator.GenerateFromAnalysisResult(context.Background(), analysisResult, options)
 			require.NoError(t, err)
 			assert.NotEmpty(t, reportPath)
 		})
@@ -154,11 +154,11 @@ func TestUserModifiedParamsRule(t *testing.T) {
 		Components: map[string]collector.ComponentState{
 			"tidb": {
 				Type: types.ComponentTiDB,
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"max-connections": types.ParameterValue{Value: 2000, Type: "int"},      // Modified
 					"log-level":       types.ParameterValue{Value: "info", Type: "string"}, // Default
 				},
-				Variables: types.SystemVariables{
+				Variables: types.ParameterMap{
 					"tidb_mem_quota_query": types.ParameterValue{Value: 2147483648, Type: "int"}, // Modified
 				},
 			},
@@ -222,10 +222,10 @@ func TestUpgradeDifferencesRule(t *testing.T) {
 		Components: map[string]collector.ComponentState{
 			"tidb": {
 				Type: types.ComponentTiDB,
-				Config: types.ConfigDefaults{
+				Config: types.ParameterMap{
 					"max-connections": types.ParameterValue{Value: 1000, Type: "int"},
 				},
-				Variables: types.SystemVariables{
+				Variables: types.ParameterMap{
 					"tidb_mem_quota_query": types.ParameterValue{Value: 1073741824, Type: "int"},
 				},
 			},
@@ -347,7 +347,7 @@ func TestReportGeneration(t *testing.T) {
 				Filename:  "test-report" + fmt.ext,
 			}
 
-			reportPath, err := reportGenerator.GenerateFromAnalysisResult(analysisResult, options)
+			reportPath, err := reportGenerator.GenerateFromAnalysisResult(context.Background(), analysisResult, options)
 			require.NoError(t, err)
 			assert.NotEmpty(t, reportPath)
 			assert.Contains(t, reportPath, fmt.ext)